@@ -0,0 +1,251 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package analyze computes the graph analytics helping analysts find the pivotal assets in the asset
+// graph, such as the degree centrality of the assets, the connected components of the graph, and the
+// clusters of names sharing infrastructure.
+package analyze
+
+import (
+	"sort"
+
+	"github.com/owasp-amass/amass/v4/export"
+	"github.com/owasp-amass/amass/v4/format"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// Ranked is an asset with its degree centrality in the graph.
+type Ranked struct {
+	Asset  *format.AssetRecord `json:"asset"`
+	Degree int                 `json:"degree"`
+	// Centrality is the degree divided by the number of other assets in the graph
+	Centrality float64 `json:"centrality"`
+}
+
+// Component is a set of assets connected by relations, regardless of their direction.
+type Component struct {
+	Size   int                   `json:"size"`
+	Assets []*format.AssetRecord `json:"assets"`
+}
+
+// Cluster is a set of names connected through the addresses they share.
+type Cluster struct {
+	Names     []string `json:"names"`
+	Addresses []string `json:"addresses"`
+}
+
+// Report contains the analytics computed over the graph.
+type Report struct {
+	Assets     int          `json:"assets"`
+	Relations  int          `json:"relations"`
+	Central    []*Ranked    `json:"central"`
+	Components []*Component `json:"components"`
+	Clusters   []*Cluster   `json:"clusters"`
+}
+
+// Analyze returns the analytics computed over the graph, keeping the top ranked entries in each list,
+// or all of them when top is zero.
+func Analyze(g *export.Graph, top int) *Report {
+	central := DegreeCentrality(g)
+	comps := Components(g)
+	clusters := Clusters(g)
+
+	return &Report{
+		Assets:     len(g.Assets),
+		Relations:  len(g.Relations),
+		Central:    central[:limit(len(central), top)],
+		Components: comps[:limit(len(comps), top)],
+		Clusters:   clusters[:limit(len(clusters), top)],
+	}
+}
+
+// adjacency returns the distinct neighbors of each asset, ignoring the direction of the relations.
+func adjacency(g *export.Graph) map[string]map[string]struct{} {
+	adj := make(map[string]map[string]struct{}, len(g.Assets))
+
+	for _, a := range g.Assets {
+		adj[export.NodeID(a)] = make(map[string]struct{})
+	}
+	for _, rel := range g.Relations {
+		from, to := export.NodeID(rel.From), export.NodeID(rel.To)
+		if from == to {
+			continue
+		}
+
+		adj[from][to] = struct{}{}
+		adj[to][from] = struct{}{}
+	}
+	return adj
+}
+
+// DegreeCentrality returns the assets ranked by the number of distinct assets they are related to.
+func DegreeCentrality(g *export.Graph) []*Ranked {
+	adj := adjacency(g)
+
+	var ranked []*Ranked
+	for _, a := range g.Assets {
+		deg := len(adj[export.NodeID(a)])
+
+		var c float64
+		if n := len(g.Assets); n > 1 {
+			c = float64(deg) / float64(n-1)
+		}
+		ranked = append(ranked, &Ranked{Asset: a, Degree: deg, Centrality: c})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Degree != ranked[j].Degree {
+			return ranked[i].Degree > ranked[j].Degree
+		}
+		return export.NodeID(ranked[i].Asset) < export.NodeID(ranked[j].Asset)
+	})
+	return ranked
+}
+
+// Components returns the connected components of the graph, with the largest components first.
+func Components(g *export.Graph) []*Component {
+	adj := adjacency(g)
+	byID := make(map[string]*format.AssetRecord, len(g.Assets))
+	for _, a := range g.Assets {
+		byID[export.NodeID(a)] = a
+	}
+
+	var comps []*Component
+	seen := make(map[string]struct{})
+	for _, a := range g.Assets {
+		id := export.NodeID(a)
+		if _, found := seen[id]; found {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		c := &Component{}
+		for queue := []string{id}; len(queue) > 0; queue = queue[1:] {
+			cur := queue[0]
+			c.Assets = append(c.Assets, byID[cur])
+
+			for n := range adj[cur] {
+				if _, found := seen[n]; !found {
+					seen[n] = struct{}{}
+					queue = append(queue, n)
+				}
+			}
+		}
+
+		sortRecords(c.Assets)
+		c.Size = len(c.Assets)
+		comps = append(comps, c)
+	}
+
+	sort.SliceStable(comps, func(i, j int) bool {
+		if comps[i].Size != comps[j].Size {
+			return comps[i].Size > comps[j].Size
+		}
+		return export.NodeID(comps[i].Assets[0]) < export.NodeID(comps[j].Assets[0])
+	})
+	return comps
+}
+
+// Clusters returns the groups of names resolving to common addresses, with the largest clusters first.
+// The names sharing an address are placed in the same cluster, transitively, so a cluster reveals the
+// infrastructure that the names depend on together.
+func Clusters(g *export.Graph) []*Cluster {
+	names := make(map[string][]string)
+	for _, rel := range g.Relations {
+		if rel.Relation != "a_record" && rel.Relation != "aaaa_record" {
+			continue
+		}
+		if rel.From.Type != string(oam.FQDN) || rel.To.Type != string(oam.IPAddress) {
+			continue
+		}
+		names[rel.To.Key] = appendUnique(names[rel.To.Key], rel.From.Key)
+	}
+
+	uf := newUnionFind()
+	for _, list := range names {
+		for _, n := range list[1:] {
+			uf.union(list[0], n)
+		}
+	}
+
+	byRoot := make(map[string]*Cluster)
+	for addr, list := range names {
+		// The addresses used by a single name do not connect anything
+		if len(list) < 2 {
+			continue
+		}
+
+		root := uf.find(list[0])
+		c, found := byRoot[root]
+		if !found {
+			c = &Cluster{}
+			byRoot[root] = c
+		}
+		c.Addresses = append(c.Addresses, addr)
+		for _, n := range list {
+			c.Names = appendUnique(c.Names, n)
+		}
+	}
+
+	var clusters []*Cluster
+	for _, c := range byRoot {
+		sort.Strings(c.Names)
+		sort.Strings(c.Addresses)
+		clusters = append(clusters, c)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Names) != len(clusters[j].Names) {
+			return len(clusters[i].Names) > len(clusters[j].Names)
+		}
+		return clusters[i].Names[0] < clusters[j].Names[0]
+	})
+	return clusters
+}
+
+func limit(n, top int) int {
+	if top > 0 && n > top {
+		return top
+	}
+	return n
+}
+
+func sortRecords(recs []*format.AssetRecord) {
+	sort.Slice(recs, func(i, j int) bool {
+		return export.NodeID(recs[i]) < export.NodeID(recs[j])
+	})
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+type unionFind map[string]string
+
+func newUnionFind() unionFind {
+	return make(unionFind)
+}
+
+func (uf unionFind) find(x string) string {
+	p, found := uf[x]
+	if !found || p == x {
+		uf[x] = x
+		return x
+	}
+
+	root := uf.find(p)
+	uf[x] = root
+	return root
+}
+
+func (uf unionFind) union(a, b string) {
+	if ra, rb := uf.find(a), uf.find(b); ra != rb {
+		uf[rb] = ra
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analyze
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/export"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func testGraph() *export.Graph {
+	fqdn := func(id, name string) *types.Asset {
+		return &types.Asset{ID: id, Asset: domain.FQDN{Name: name}}
+	}
+	ip := func(id, addr string) *types.Asset {
+		return &types.Asset{ID: id, Asset: network.IPAddress{Address: netip.MustParseAddr(addr), Type: "IPv4"}}
+	}
+	rel := func(t string) *types.Relation { return &types.Relation{Type: t} }
+
+	www, api, mail := fqdn("1", "www.owasp.org"), fqdn("2", "api.owasp.org"), fqdn("3", "mail.owasp.org")
+	addr1, addr2, addr3 := ip("4", "192.0.2.1"), ip("5", "192.0.2.2"), ip("6", "198.51.100.1")
+	cidr := &types.Asset{ID: "7", Asset: network.Netblock{Cidr: netip.MustParsePrefix("192.0.2.0/24"), Type: "IPv4"}}
+	other := fqdn("8", "unrelated.example")
+
+	g := export.NewGraph()
+	g.AddRelation(www, rel("a_record"), addr1)
+	g.AddRelation(api, rel("a_record"), addr1)
+	g.AddRelation(api, rel("a_record"), addr2)
+	g.AddRelation(mail, rel("a_record"), addr3)
+	g.AddRelation(cidr, rel("contains"), addr1)
+	g.AddRelation(cidr, rel("contains"), addr2)
+	g.AddAsset(other)
+	return g
+}
+
+func TestDegreeCentrality(t *testing.T) {
+	ranked := DegreeCentrality(testGraph())
+
+	if len(ranked) != 8 {
+		t.Fatalf("Expected eight ranked assets, got %d", len(ranked))
+	}
+	if top := ranked[0]; top.Asset.Key != "192.0.2.1" || top.Degree != 3 || top.Centrality != 3.0/7 {
+		t.Errorf("Unexpected top ranked asset: %s with degree %d", top.Asset.Key, top.Degree)
+	}
+	if last := ranked[len(ranked)-1]; last.Degree != 0 {
+		t.Errorf("Expected the isolated asset to be ranked last, got %s", last.Asset.Key)
+	}
+}
+
+func TestComponents(t *testing.T) {
+	comps := Components(testGraph())
+
+	if len(comps) != 3 {
+		t.Fatalf("Expected three components, got %d", len(comps))
+	}
+	if comps[0].Size != 5 || comps[1].Size != 2 || comps[2].Size != 1 {
+		t.Errorf("Unexpected component sizes: %d, %d and %d", comps[0].Size, comps[1].Size, comps[2].Size)
+	}
+}
+
+func TestClusters(t *testing.T) {
+	clusters := Clusters(testGraph())
+
+	if len(clusters) != 1 {
+		t.Fatalf("Expected one cluster, got %d", len(clusters))
+	}
+	if c := clusters[0]; len(c.Names) != 2 || len(c.Addresses) != 1 || c.Addresses[0] != "192.0.2.1" {
+		t.Errorf("Unexpected cluster: %+v", c)
+	}
+
+	r := Analyze(testGraph(), 1)
+	if r.Assets != 8 || r.Relations != 6 || len(r.Central) != 1 || len(r.Components) != 1 {
+		t.Errorf("Unexpected report: %+v", r)
+	}
+}
+
+func TestOrgChart(t *testing.T) {
+	rel := func(t string) *types.Relation { return &types.Relation{Type: t} }
+	asn := func(id string, n int) *types.Asset {
+		return &types.Asset{ID: id, Asset: network.AutonomousSystem{Number: n}}
+	}
+	org := func(id, name string) *types.Asset {
+		return &types.Asset{ID: id, Asset: network.RIROrganization{Name: name, RIRId: id}}
+	}
+	cidr := func(id, prefix string) *types.Asset {
+		return &types.Asset{ID: id, Asset: network.Netblock{Cidr: netip.MustParsePrefix(prefix), Type: "IPv4"}}
+	}
+
+	as1, as2, as3, as4 := asn("a1", 64496), asn("a2", 64497), asn("a3", 64498), asn("a4", 64499)
+	block1, block2 := cidr("b1", "192.0.2.0/24"), cidr("b2", "198.51.100.0/24")
+	addr := &types.Asset{ID: "ip", Asset: network.IPAddress{Address: netip.MustParseAddr("198.51.100.7"), Type: "IPv4"}}
+	www := &types.Asset{ID: "www", Asset: domain.FQDN{Name: "www.example-cloud.com"}}
+
+	g := testGraph()
+	g.AddRelation(as1, rel("managed_by"), org("o1", "Example, Inc."))
+	g.AddRelation(as2, rel("managed_by"), org("o2", "EXAMPLE INC"))
+	g.AddRelation(as3, rel("managed_by"), org("o3", "Example Cloud Services LLC"))
+	g.AddRelation(as4, rel("managed_by"), org("o4", "Domains By Proxy, LLC"))
+	g.AddRelation(as1, rel("announces"), block1)
+	g.AddRelation(as3, rel("announces"), block2)
+	g.AddRelation(block2, rel("contains"), addr)
+	g.AddRelation(www, rel("a_record"), addr)
+
+	roots := OrgChart(g)
+	if len(roots) != 1 {
+		t.Fatalf("Expected one root entity, got %d", len(roots))
+	}
+
+	parent := roots[0]
+	if parent.Name != "EXAMPLE INC" || len(parent.Registrants) != 2 || len(parent.ASNs) != 2 {
+		t.Errorf("Unexpected parent entity: %+v", parent)
+	}
+	if len(parent.Netblocks) != 1 || parent.Netblocks[0] != "192.0.2.0/24" {
+		t.Errorf("Unexpected netblocks of the parent: %v", parent.Netblocks)
+	}
+	if len(parent.Children) != 1 {
+		t.Fatalf("Expected one subsidiary, got %d", len(parent.Children))
+	}
+
+	child := parent.Children[0]
+	if child.Name != "Example Cloud Services LLC" || len(child.Domains) != 1 || child.Domains[0] != "example-cloud.com" {
+		t.Errorf("Unexpected subsidiary: %+v", child)
+	}
+}
+
+func TestNetblockUtilization(t *testing.T) {
+	rel := func(t string) *types.Relation { return &types.Relation{Type: t} }
+	dense := &types.Asset{ID: "20", Asset: network.Netblock{Cidr: netip.MustParsePrefix("203.0.113.0/30"), Type: "IPv4"}}
+	empty := &types.Asset{ID: "21", Asset: network.Netblock{Cidr: netip.MustParsePrefix("203.0.113.128/25"), Type: "IPv4"}}
+	addr := &types.Asset{ID: "22", Asset: network.IPAddress{Address: netip.MustParseAddr("203.0.113.1"), Type: "IPv4"}}
+	idle := &types.Asset{ID: "23", Asset: network.IPAddress{Address: netip.MustParseAddr("203.0.113.129"), Type: "IPv4"}}
+	vpn := &types.Asset{ID: "24", Asset: domain.FQDN{Name: "vpn.owasp.org"}}
+
+	g := testGraph()
+	g.AddRelation(dense, rel("contains"), addr)
+	g.AddRelation(vpn, rel("a_record"), addr)
+	g.AddRelation(empty, rel("contains"), idle)
+
+	usage := NetblockUtilization(g)
+	if len(usage) != 3 {
+		t.Fatalf("Expected three netblocks, got %d", len(usage))
+	}
+
+	for i, want := range []struct {
+		cidr  string
+		used  int
+		names int
+		class string
+	}{
+		{"203.0.113.0/30", 1, 1, UtilizationDense},
+		{"192.0.2.0/24", 2, 3, UtilizationSparse},
+		{"203.0.113.128/25", 0, 0, UtilizationEmpty},
+	} {
+		u := usage[i]
+		if u.Netblock != want.cidr || u.Used != want.used || u.Names != want.names || u.Class != want.class {
+			t.Errorf("Unexpected utilization at %d: %+v", i, u)
+		}
+	}
+	if usage[0].Size != 4 || usage[0].Utilization != 0.25 {
+		t.Errorf("Unexpected size of the dense netblock: %+v", usage[0])
+	}
+}
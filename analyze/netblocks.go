@@ -0,0 +1,101 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analyze
+
+import (
+	"math"
+	"net/netip"
+	"sort"
+
+	"github.com/owasp-amass/amass/v4/export"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// DenseUtilization is the fraction of the addresses in a netblock with observed names, at or above which
+// the netblock is considered dense and worth deeper active scanning.
+const DenseUtilization = 0.1
+
+// The classifications of the netblocks by their utilization.
+const (
+	UtilizationDense  = "dense"
+	UtilizationSparse = "sparse"
+	UtilizationEmpty  = "empty"
+)
+
+// NetblockUsage is the number of addresses in a netblock with names observed resolving to them.
+type NetblockUsage struct {
+	Netblock string `json:"netblock"`
+	// Size is the number of addresses in the netblock, which is approximate for the large IPv6 netblocks
+	Size        float64 `json:"size"`
+	Addresses   int     `json:"addresses"`
+	Used        int     `json:"used"`
+	Names       int     `json:"names"`
+	Utilization float64 `json:"utilization"`
+	Class       string  `json:"class"`
+}
+
+// NetblockUtilization returns the utilization of each netblock in the graph, with the densest netblocks
+// first. The addresses contained by a netblock are used when at least one name resolves to them.
+func NetblockUtilization(g *export.Graph) []*NetblockUsage {
+	names := make(map[string][]string)
+	contained := make(map[string][]string)
+	netblocks := make(map[string]struct{})
+
+	for _, a := range g.Assets {
+		if a.Type == string(oam.Netblock) {
+			netblocks[a.Key] = struct{}{}
+		}
+	}
+	for _, rel := range g.Relations {
+		switch {
+		case rel.Relation == "contains" && rel.From.Type == string(oam.Netblock):
+			contained[rel.From.Key] = appendUnique(contained[rel.From.Key], rel.To.Key)
+		case (rel.Relation == "a_record" || rel.Relation == "aaaa_record") && rel.From.Type == string(oam.FQDN):
+			names[rel.To.Key] = appendUnique(names[rel.To.Key], rel.From.Key)
+		}
+	}
+
+	var usage []*NetblockUsage
+	for cidr := range netblocks {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			continue
+		}
+
+		u := &NetblockUsage{
+			Netblock:  cidr,
+			Size:      math.Pow(2, float64(prefix.Addr().BitLen()-prefix.Bits())),
+			Addresses: len(contained[cidr]),
+		}
+		for _, addr := range contained[cidr] {
+			if n := len(names[addr]); n > 0 {
+				u.Used++
+				u.Names += n
+			}
+		}
+
+		u.Utilization = float64(u.Used) / u.Size
+		switch {
+		case u.Used == 0:
+			u.Class = UtilizationEmpty
+		case u.Utilization >= DenseUtilization:
+			u.Class = UtilizationDense
+		default:
+			u.Class = UtilizationSparse
+		}
+		usage = append(usage, u)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Utilization != usage[j].Utilization {
+			return usage[i].Utilization > usage[j].Utilization
+		}
+		if usage[i].Used != usage[j].Used {
+			return usage[i].Used > usage[j].Used
+		}
+		return usage[i].Netblock < usage[j].Netblock
+	})
+	return usage
+}
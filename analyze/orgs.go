@@ -0,0 +1,131 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analyze
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/assoc"
+	"github.com/owasp-amass/amass/v4/export"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/network"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Entity is a legal entity identified by the registrant organizations sharing its normalized name,
+// with the infrastructure registered to it and the domains hosted on that infrastructure.
+type Entity struct {
+	Name        string    `json:"name"`
+	Registrants []string  `json:"registrants"`
+	ASNs        []string  `json:"asns,omitempty"`
+	Netblocks   []string  `json:"netblocks,omitempty"`
+	Domains     []string  `json:"domains,omitempty"`
+	Children    []*Entity `json:"children,omitempty"`
+	key         string
+}
+
+// OrgChart returns the hierarchy of the legal entities registering the autonomous systems in the graph.
+// The registrants with names that only differ in case, punctuation and legal form are merged into one
+// entity, and an entity is placed under the entity with the longest name its name starts with, such as
+// the subsidiaries and brands carrying the name of the parent. The netblocks announced by the autonomous
+// systems of each entity are attributed to it, along with the registered domains of the names resolving
+// to the addresses in those netblocks. The privacy and proxy services are not included.
+func OrgChart(g *export.Graph) []*Entity {
+	byKey := make(map[string]*Entity)
+	orgASNs := make(map[string][]string)
+	announced := make(map[string][]string)
+	contained := make(map[string][]string)
+	names := make(map[string][]string)
+
+	for _, rel := range g.Relations {
+		switch {
+		case rel.Relation == "managed_by" && rel.To.Type == string(oam.RIROrg):
+			var rir network.RIROrganization
+			if err := json.Unmarshal(rel.To.Asset, &rir); err != nil {
+				continue
+			}
+
+			key := assoc.RegistrantKey(rir.Name)
+			if key == "" {
+				continue
+			}
+			e, found := byKey[key]
+			if !found {
+				e = &Entity{key: key}
+				byKey[key] = e
+			}
+			e.Registrants = appendUnique(e.Registrants, rir.Name)
+			orgASNs[key] = appendUnique(orgASNs[key], rel.From.Key)
+		case rel.Relation == "announces":
+			announced[rel.From.Key] = appendUnique(announced[rel.From.Key], rel.To.Key)
+		case rel.Relation == "contains":
+			contained[rel.From.Key] = appendUnique(contained[rel.From.Key], rel.To.Key)
+		case (rel.Relation == "a_record" || rel.Relation == "aaaa_record") && rel.From.Type == string(oam.FQDN):
+			names[rel.To.Key] = appendUnique(names[rel.To.Key], rel.From.Key)
+		}
+	}
+
+	for key, e := range byKey {
+		sort.Strings(e.Registrants)
+		// The shortest variation of the name is the most likely to be the name of the entity
+		e.Name = e.Registrants[0]
+		for _, r := range e.Registrants[1:] {
+			if len(r) < len(e.Name) {
+				e.Name = r
+			}
+		}
+
+		for _, asn := range orgASNs[key] {
+			e.ASNs = appendUnique(e.ASNs, asn)
+			for _, cidr := range announced[asn] {
+				e.Netblocks = appendUnique(e.Netblocks, cidr)
+				for _, addr := range contained[cidr] {
+					for _, name := range names[addr] {
+						if d, err := publicsuffix.EffectiveTLDPlusOne(name); err == nil {
+							e.Domains = appendUnique(e.Domains, d)
+						}
+					}
+				}
+			}
+		}
+		sort.Strings(e.ASNs)
+		sort.Strings(e.Netblocks)
+		sort.Strings(e.Domains)
+	}
+
+	var roots []*Entity
+	for key, e := range byKey {
+		if parent := parentEntity(byKey, key); parent != nil {
+			parent.Children = append(parent.Children, e)
+		} else {
+			roots = append(roots, e)
+		}
+	}
+
+	sortEntities(roots)
+	return roots
+}
+
+// parentEntity returns the entity with the longest name that the name of the entity starts with.
+func parentEntity(byKey map[string]*Entity, key string) *Entity {
+	words := strings.Fields(key)
+
+	for i := len(words) - 1; i > 0; i-- {
+		if parent, found := byKey[strings.Join(words[:i], " ")]; found {
+			return parent
+		}
+	}
+	return nil
+}
+
+func sortEntities(entities []*Entity) {
+	sort.Slice(entities, func(i, j int) bool { return entities[i].key < entities[j].key })
+
+	for _, e := range entities {
+		sortEntities(e.Children)
+	}
+}
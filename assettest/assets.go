@@ -0,0 +1,95 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package assettest
+
+import (
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// FQDN returns the asset of the name.
+func FQDN(name string) oam.Asset {
+	return domain.FQDN{Name: name}
+}
+
+// IP returns the asset of the address, which must be valid.
+func IP(addr string) oam.Asset {
+	ip := netip.MustParseAddr(addr)
+
+	t := "IPv4"
+	if ip.Is6() && !ip.Is4In6() {
+		t = "IPv6"
+	}
+	return network.IPAddress{Address: ip, Type: t}
+}
+
+// Netblock returns the asset of the CIDR, which must be valid.
+func Netblock(cidr string) oam.Asset {
+	prefix := netip.MustParsePrefix(cidr)
+
+	t := "IPv4"
+	if prefix.Addr().Is6() {
+		t = "IPv6"
+	}
+	return network.Netblock{Cidr: prefix, Type: t}
+}
+
+// ASN returns the asset of the autonomous system.
+func ASN(number int) oam.Asset {
+	return network.AutonomousSystem{Number: number}
+}
+
+// Org returns the asset of the organization registered with the RIR.
+func Org(name, rirID string) oam.Asset {
+	return network.RIROrganization{Name: name, RIRId: rirID}
+}
+
+// Factory builds the assets and relations held in memory, for the tests of the functions that
+// do not read the graph database. The assets are given sequential IDs, starting from one.
+type Factory struct {
+	next int
+	// Now is the creation and last seen time of the assets and relations, or the current time when zero
+	Now time.Time
+}
+
+// Asset returns the asset with the next ID.
+func (f *Factory) Asset(a oam.Asset) *types.Asset {
+	f.next++
+	now := f.now()
+
+	return &types.Asset{
+		ID:        strconv.Itoa(f.next),
+		CreatedAt: now,
+		LastSeen:  now,
+		Asset:     a,
+	}
+}
+
+// Relation returns the relation between the assets, with an ID built from the IDs of the assets.
+func (f *Factory) Relation(from *types.Asset, relation string, to *types.Asset) *types.Relation {
+	now := f.now()
+
+	return &types.Relation{
+		ID:        from.ID + relation + to.ID,
+		Type:      relation,
+		CreatedAt: now,
+		LastSeen:  now,
+		FromAsset: from,
+		ToAsset:   to,
+	}
+}
+
+func (f *Factory) now() time.Time {
+	if f.Now.IsZero() {
+		return time.Now()
+	}
+	return f.Now
+}
@@ -0,0 +1,133 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package assettest provides the graph databases and assets used by the tests, so the integration tests
+// of the packages and commands reading the graph database can be written without setting up a database.
+package assettest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// PostgresEnv is the environment variable providing the connection string of the PostgreSQL
+// database used by NewPostgresGraph, such as "host=localhost port=5432 user=amass dbname=test".
+const PostgresEnv = "AMASS_TEST_POSTGRES"
+
+// NewGraph returns an empty graph database stored in a temporary directory, which is removed once the test
+// completes. Each call returns another database, unlike the memory databases that share their cache.
+func NewGraph(t testing.TB) *netmap.Graph {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+	g := netmap.NewGraph("local", path, "")
+	if g == nil {
+		t.Fatalf("Failed to create the graph database at %s", path)
+	}
+	return g
+}
+
+// NewPostgresGraph returns the graph database in the PostgreSQL database provided by the PostgresEnv
+// environment variable, and skips the test when it is not set. The tables are removed once the test
+// completes, so the database should only be used by the tests.
+func NewPostgresGraph(t testing.TB) *netmap.Graph {
+	t.Helper()
+
+	dsn := os.Getenv(PostgresEnv)
+	if dsn == "" {
+		t.Skipf("The %s environment variable does not provide a PostgreSQL database", PostgresEnv)
+	}
+
+	g := netmap.NewGraph("postgres", dsn, "")
+	if g == nil {
+		t.Fatal("Failed to create the graph database in PostgreSQL")
+	}
+	t.Cleanup(g.Remove)
+	return g
+}
+
+// Store adds the assets to the graph database and returns the stored assets in the same order.
+func Store(t testing.TB, g *netmap.Graph, assets ...oam.Asset) []*types.Asset {
+	t.Helper()
+
+	var stored []*types.Asset
+	for _, a := range assets {
+		sa, err := g.DB.Create(nil, "", a)
+		if err != nil {
+			t.Fatalf("Failed to store the asset: %v", err)
+		}
+		stored = append(stored, sa)
+	}
+	return stored
+}
+
+// Link adds both assets to the graph database, unless they are already present, and relates them.
+// The relation must be valid in the asset taxonomy. The stored assets are returned.
+func Link(t testing.TB, g *netmap.Graph, from oam.Asset, relation string, to oam.Asset) (*types.Asset, *types.Asset) {
+	t.Helper()
+
+	src, err := g.DB.Create(nil, "", from)
+	if err != nil {
+		t.Fatalf("Failed to store the asset: %v", err)
+	}
+
+	dst, err := g.DB.Create(src, relation, to)
+	if err != nil {
+		t.Fatalf("Failed to relate the assets with %s: %v", relation, err)
+	}
+	return src, dst
+}
+
+// Populate adds the records to the graph database, such as the names and their addresses.
+func Populate(t testing.TB, g *netmap.Graph, records ...Record) {
+	t.Helper()
+
+	ctx := context.Background()
+	for _, rec := range records {
+		if err := rec(ctx, g); err != nil {
+			t.Fatalf("Failed to populate the graph database: %v", err)
+		}
+	}
+}
+
+// Record adds data to the graph database using the methods of the graph, as the enumerations do.
+type Record func(ctx context.Context, g *netmap.Graph) error
+
+// A returns the record relating the name to its IPv4 address.
+func A(name, addr string) Record {
+	return func(ctx context.Context, g *netmap.Graph) error { return g.UpsertA(ctx, name, addr) }
+}
+
+// AAAA returns the record relating the name to its IPv6 address.
+func AAAA(name, addr string) Record {
+	return func(ctx context.Context, g *netmap.Graph) error { return g.UpsertAAAA(ctx, name, addr) }
+}
+
+// CNAME returns the record relating the name to its alias target.
+func CNAME(name, target string) Record {
+	return func(ctx context.Context, g *netmap.Graph) error { return g.UpsertCNAME(ctx, name, target) }
+}
+
+// NS returns the record relating the name to its name server.
+func NS(name, target string) Record {
+	return func(ctx context.Context, g *netmap.Graph) error { return g.UpsertNS(ctx, name, target) }
+}
+
+// MX returns the record relating the name to its mail server.
+func MX(name, target string) Record {
+	return func(ctx context.Context, g *netmap.Graph) error { return g.UpsertMX(ctx, name, target) }
+}
+
+// Infrastructure returns the record announcing the netblock containing the address from the autonomous system.
+func Infrastructure(asn int, desc, addr, cidr string) Record {
+	return func(ctx context.Context, g *netmap.Graph) error {
+		return g.UpsertInfrastructure(ctx, asn, desc, addr, cidr)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package assettest
+
+import (
+	"testing"
+	"time"
+
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func TestNewGraph(t *testing.T) {
+	g := NewGraph(t)
+
+	Populate(t, g,
+		A("www.owasp.org", "192.0.2.1"),
+		CNAME("docs.owasp.org", "www.owasp.org"),
+		Infrastructure(64496, "OWASP", "192.0.2.1", "192.0.2.0/24"),
+	)
+	_, dst := Link(t, g, FQDN("owasp.org"), "ns_record", FQDN("ns1.owasp.org"))
+	if dst.Asset.AssetType() != oam.FQDN {
+		t.Errorf("Expected the name server to be stored as a FQDN, got %v", dst.Asset)
+	}
+
+	names, err := g.DB.FindByType(oam.FQDN, time.Time{})
+	if err != nil || len(names) != 4 {
+		t.Errorf("Expected four names in the graph database, got %d: %v", len(names), err)
+	}
+	// Each test is provided another database
+	if other, _ := NewGraph(t).DB.FindByType(oam.FQDN, time.Time{}); len(other) != 0 {
+		t.Errorf("Expected an empty graph database, got %d names", len(other))
+	}
+}
+
+func TestFactory(t *testing.T) {
+	now := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	f := &Factory{Now: now}
+
+	www := f.Asset(FQDN("www.owasp.org"))
+	addr := f.Asset(IP("2001:db8::1"))
+	if www.ID != "1" || addr.ID != "2" || !www.CreatedAt.Equal(now) {
+		t.Errorf("Unexpected assets: %+v and %+v", www, addr)
+	}
+	if ip := addr.Asset.(network.IPAddress); ip.Type != "IPv6" {
+		t.Errorf("Expected an IPv6 address, got %s", ip.Type)
+	}
+	if nb := Netblock("192.0.2.0/24").(network.Netblock); nb.Type != "IPv4" {
+		t.Errorf("Expected an IPv4 netblock, got %s", nb.Type)
+	}
+
+	rel := f.Relation(www, "aaaa_record", addr)
+	if rel.ID != "1aaaa_record2" || rel.FromAsset != www || rel.ToAsset != addr {
+		t.Errorf("Unexpected relation: %+v", rel)
+	}
+	if !oam.ValidRelationship(www.Asset.AssetType(), rel.Type, addr.Asset.AssetType()) {
+		t.Error("Expected the relation to be valid in the taxonomy")
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package assoc explains how the assets in the graph database are associated with the root domains,
+// by finding the strongest path of relations leading to each asset and scoring the confidence in it.
+package assoc
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/asset-db/types"
+)
+
+// HopDecay is the factor applied to the confidence for each relation in the path,
+// so distant assets are less likely to belong to the organization.
+const HopDecay = 0.9
+
+// Weights are the confidence in each type of relation connecting the associated assets. The records
+// commonly pointing to shared infrastructure, such as the name and mail servers, are weighted lower.
+var Weights = map[string]float64{
+	"a_record":     0.9,
+	"aaaa_record":  0.9,
+	"contains":     0.9,
+	"announces":    0.9,
+	"managed_by":   0.9,
+	"cname_record": 0.8,
+	"srv_record":   0.7,
+	"ptr_record":   0.6,
+	"ns_record":    0.4,
+	"mx_record":    0.4,
+	// The registered domains hosted at an address with the target, according to passive DNS,
+	// are further weighted by how exclusively the address was used by them
+	"cohosted": 0.6,
+	// The registrant organizations with similar names are likely the same organization
+	SimilarRegistrant: 0.7,
+}
+
+// DefaultWeight is the confidence in the types of relations missing from Weights.
+const DefaultWeight = 0.5
+
+// Link is a relation connecting an asset to one of its neighbors in the graph.
+type Link struct {
+	Relation *types.Relation
+	Asset    *types.Asset
+	// Incoming is true when the relation points from the neighbor to the asset
+	Incoming bool
+	// Weight is the confidence in the link, when it differs from the weight of the relation type
+	Weight float64
+}
+
+// Neighbors returns the links from the asset to the neighbors that should be traversed.
+type Neighbors func(a *types.Asset) []*Link
+
+// Step is a relation in the path, in the direction it is stored in the graph database.
+type Step struct {
+	From     *format.AssetRecord `json:"from"`
+	Relation string              `json:"relation"`
+	To       *format.AssetRecord `json:"to"`
+}
+
+// Association is an asset reachable from the root domains, with the path of relations providing
+// the evidence for the association and the confidence in it, between zero and one.
+type Association struct {
+	Asset      *format.AssetRecord `json:"asset"`
+	Seed       string              `json:"seed"`
+	Path       []*Step             `json:"path"`
+	Confidence float64             `json:"confidence"`
+}
+
+// Options control the traversal of the graph.
+type Options struct {
+	// MaxDepth is the maximum number of relations in a path, or zero for no limit
+	MaxDepth int
+	// MaxAssets is the maximum number of associations, or zero for no limit. The traversal stops
+	// once it is reached, and the most confident associations are returned
+	MaxAssets int
+	// MinConfidence is the confidence required for the associations to be returned
+	MinConfidence float64
+	// Shared identifies the addresses used by many unrelated tenants, such as shared hosting and
+	// CDNs, and the relations followed from them are weighted by the SharedPenalty
+	Shared func(a *types.Asset) bool
+	// Workers is the number of assets expanded concurrently, or zero for the DefaultWorkers.
+	// The Neighbors and Shared functions must be safe for concurrent use, unless it is one
+	Workers int
+}
+
+// Weight returns the confidence in the type of relation.
+func Weight(relation string) float64 {
+	if w, found := Weights[relation]; found {
+		return w
+	}
+	return DefaultWeight
+}
+
+type node struct {
+	asset      *types.Asset
+	seed       *types.Asset
+	prev       *node
+	link       *Link
+	depth      int
+	confidence float64
+}
+
+// Associations returns the assets reachable from the seeds, each with the path providing the most
+// confidence in the association, sorted with the most confident associations first.
+func Associations(seeds []*types.Asset, next Neighbors, opts Options) []*Association {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	// The neighbors of the assets are queried concurrently as they join the frontier
+	p := newPrefetcher(next, opts.Shared, workers)
+	defer p.stop()
+
+	best := make(map[string]*node)
+	pq := &queue{}
+	push := func(n *node) {
+		best[n.asset.ID] = n
+		heap.Push(pq, n)
+		if opts.MaxDepth <= 0 || n.depth < opts.MaxDepth {
+			p.start(n.asset)
+		}
+	}
+
+	for _, s := range seeds {
+		push(&node{asset: s, seed: s, confidence: 1})
+	}
+
+	var associated int
+	done := make(map[string]struct{})
+	for pq.Len() > 0 {
+		cur := heap.Pop(pq).(*node)
+		if _, found := done[cur.asset.ID]; found {
+			continue
+		}
+		done[cur.asset.ID] = struct{}{}
+		// The assets are reached in the order of confidence, so the traversal can stop at the limit
+		if cur.prev != nil {
+			associated++
+			if opts.MaxAssets > 0 && associated >= opts.MaxAssets {
+				break
+			}
+		}
+
+		if opts.MaxDepth > 0 && cur.depth >= opts.MaxDepth {
+			continue
+		}
+
+		links, shared := p.expand(cur.asset)
+
+		penalty := 1.0
+		if shared {
+			penalty = SharedPenalty
+		}
+		for _, l := range links {
+			if l == nil || l.Asset == nil || l.Relation == nil {
+				continue
+			}
+
+			w := l.Weight
+			if w == 0 {
+				w = Weight(l.Relation.Type)
+			}
+
+			c := cur.confidence * w * penalty * HopDecay
+			if c < opts.MinConfidence {
+				continue
+			}
+			if b, found := best[l.Asset.ID]; found && b.confidence >= c {
+				continue
+			}
+
+			push(&node{
+				asset:      l.Asset,
+				seed:       cur.seed,
+				prev:       cur,
+				link:       l,
+				depth:      cur.depth + 1,
+				confidence: c,
+			})
+		}
+	}
+
+	var results []*Association
+	for id, n := range best {
+		// The assets still in the frontier when the traversal stopped are not associations
+		if _, found := done[id]; !found || n.prev == nil {
+			continue
+		}
+		results = append(results, &Association{
+			Asset:      format.NewAssetRecord(n.asset),
+			Seed:       format.AssetKey(n.seed.Asset),
+			Path:       path(n),
+			Confidence: n.confidence,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Confidence != results[j].Confidence {
+			return results[i].Confidence > results[j].Confidence
+		}
+		if results[i].Asset.Type != results[j].Asset.Type {
+			return results[i].Asset.Type < results[j].Asset.Type
+		}
+		return results[i].Asset.Key < results[j].Asset.Key
+	})
+	return results
+}
+
+func path(n *node) []*Step {
+	var steps []*Step
+
+	for ; n.prev != nil; n = n.prev {
+		steps = append([]*Step{linkStep(n.prev.asset, n.link)}, steps...)
+	}
+	return steps
+}
+
+// queue orders the nodes by confidence, so each asset is first reached by its strongest path.
+type queue []*node
+
+func (q queue) Len() int { return len(q) }
+
+func (q queue) Less(i, j int) bool { return q[i].confidence > q[j].confidence }
+
+func (q queue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(*node)) }
+
+func (q *queue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
@@ -0,0 +1,228 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package assoc
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+type testGraph map[string][]*Link
+
+func (g testGraph) link(from *types.Asset, rel string, to *types.Asset) {
+	r := &types.Relation{ID: from.ID + rel + to.ID, Type: rel, FromAsset: from, ToAsset: to}
+
+	g[from.ID] = append(g[from.ID], &Link{Relation: r, Asset: to})
+	g[to.ID] = append(g[to.ID], &Link{Relation: r, Asset: from, Incoming: true})
+}
+
+func TestAssociations(t *testing.T) {
+	root := &types.Asset{ID: "1", Asset: domain.FQDN{Name: "owasp.org"}}
+	www := &types.Asset{ID: "2", Asset: domain.FQDN{Name: "www.owasp.org"}}
+	addr := &types.Asset{ID: "3", Asset: network.IPAddress{Address: netip.MustParseAddr("192.0.2.1"), Type: "IPv4"}}
+	cidr := &types.Asset{ID: "4", Asset: network.Netblock{Cidr: netip.MustParsePrefix("192.0.2.0/24"), Type: "IPv4"}}
+	asn := &types.Asset{ID: "5", Asset: network.AutonomousSystem{Number: 64496}}
+	ns := &types.Asset{ID: "6", Asset: domain.FQDN{Name: "ns1.dnsprovider.net"}}
+
+	g := testGraph{}
+	g.link(www, "a_record", addr)
+	g.link(cidr, "contains", addr)
+	g.link(asn, "announces", cidr)
+	g.link(root, "ns_record", ns)
+	// A weaker path to the address through the name server
+	g.link(ns, "a_record", addr)
+
+	next := func(a *types.Asset) []*Link { return g[a.ID] }
+
+	seeds := []*types.Asset{root, www}
+	results := Associations(seeds, next, Options{})
+	if len(results) != 4 {
+		t.Fatalf("Expected four associations, got %d", len(results))
+	}
+
+	var found *Association
+	for _, a := range results {
+		if a.Asset.Key == "64496" {
+			found = a
+		}
+	}
+	if found == nil {
+		t.Fatal("The autonomous system was not associated")
+	}
+	if len(found.Path) != 3 || found.Seed != "www.owasp.org" {
+		t.Fatalf("Unexpected path to the autonomous system: %d steps from %s", len(found.Path), found.Seed)
+	}
+	if s := found.Path[0]; s.From.Key != "www.owasp.org" || s.Relation != "a_record" || s.To.Key != "192.0.2.1" {
+		t.Errorf("Unexpected step: %s %s %s", s.From.Key, s.Relation, s.To.Key)
+	}
+	// The relations are provided in the direction they are stored
+	if s := found.Path[2]; s.From.Key != "64496" || s.Relation != "announces" || s.To.Key != "192.0.2.0/24" {
+		t.Errorf("Unexpected step: %s %s %s", s.From.Key, s.Relation, s.To.Key)
+	}
+	want := math.Pow(HopDecay, 3) * Weight("a_record") * Weight("contains") * Weight("announces")
+	if math.Abs(found.Confidence-want) > 1e-9 {
+		t.Errorf("Expected a confidence of %f, got %f", want, found.Confidence)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Confidence > results[i-1].Confidence {
+			t.Error("The associations are not sorted by confidence")
+		}
+	}
+
+	limited := Associations(seeds, next, Options{MaxDepth: 2, MinConfidence: 0.5})
+	for _, a := range limited {
+		if len(a.Path) > 2 || a.Confidence < 0.5 {
+			t.Errorf("The association of %s exceeds the limits", a.Asset.Key)
+		}
+		// The name server is associated through the address, since the NS record has a lower weight
+		if a.Asset.Key == "ns1.dnsprovider.net" && (len(a.Path) != 2 || a.Path[1].Relation != "a_record") {
+			t.Error("The name server was not associated through the stronger path")
+		}
+	}
+}
+
+func TestLinkWeight(t *testing.T) {
+	www := &types.Asset{ID: "1", Asset: domain.FQDN{Name: "www.owasp.org"}}
+	addr := &types.Asset{ID: "2", Asset: network.IPAddress{Address: netip.MustParseAddr("192.0.2.1"), Type: "IPv4"}}
+	other := &types.Asset{ID: "3", Asset: domain.FQDN{Name: "owasp-foundation.org"}}
+
+	g := testGraph{}
+	g.link(www, "a_record", addr)
+	r := &types.Relation{ID: "cohosted", Type: "cohosted", FromAsset: other, ToAsset: addr}
+	g[addr.ID] = append(g[addr.ID], &Link{Relation: r, Asset: other, Incoming: true, Weight: Weight("cohosted") * 0.5})
+
+	results := Associations([]*types.Asset{www}, func(a *types.Asset) []*Link { return g[a.ID] }, Options{})
+	if len(results) != 2 || results[1].Asset.Key != "owasp-foundation.org" {
+		t.Fatalf("Unexpected associations: %d", len(results))
+	}
+
+	want := math.Pow(HopDecay, 2) * Weight("a_record") * Weight("cohosted") * 0.5
+	if math.Abs(results[1].Confidence-want) > 1e-9 {
+		t.Errorf("Expected a confidence of %f, got %f", want, results[1].Confidence)
+	}
+}
+
+func TestSharedHosting(t *testing.T) {
+	www := &types.Asset{ID: "1", Asset: domain.FQDN{Name: "www.owasp.org"}}
+	addr := &types.Asset{ID: "2", Asset: network.IPAddress{Address: netip.MustParseAddr("192.0.2.1"), Type: "IPv4"}}
+	cidr := &types.Asset{ID: "3", Asset: network.Netblock{Cidr: netip.MustParsePrefix("192.0.2.0/24"), Type: "IPv4"}}
+
+	g := testGraph{}
+	g.link(www, "a_record", addr)
+	g.link(cidr, "contains", addr)
+
+	next := func(a *types.Asset) []*Link { return g[a.ID] }
+	shared := func(a *types.Asset) bool { return a.ID == addr.ID }
+
+	results := Associations([]*types.Asset{www}, next, Options{Shared: shared})
+	if len(results) != 2 {
+		t.Fatalf("Expected two associations, got %d", len(results))
+	}
+	// The shared address remains associated, but the pivot to the netblock is penalized
+	if results[0].Asset.Key != "192.0.2.1" || math.Abs(results[0].Confidence-Weight("a_record")*HopDecay) > 1e-9 {
+		t.Errorf("Unexpected association of the address: %s %f", results[0].Asset.Key, results[0].Confidence)
+	}
+	want := math.Pow(HopDecay, 2) * Weight("a_record") * Weight("contains") * SharedPenalty
+	if math.Abs(results[1].Confidence-want) > 1e-9 {
+		t.Errorf("Expected a confidence of %f for the netblock, got %f", want, results[1].Confidence)
+	}
+
+	if !SharedProvider("CLOUDFLARENET - Cloudflare, Inc.") || SharedProvider("OWASP Foundation") {
+		t.Error("The shared providers were not identified")
+	}
+}
+
+func TestConcurrentAssociations(t *testing.T) {
+	g := testGraph{}
+	var seeds []*types.Asset
+	// Names resolving to addresses in netblocks announced by a few autonomous systems
+	for i := 0; i < 200; i++ {
+		name := &types.Asset{ID: fmt.Sprintf("n%d", i), Asset: domain.FQDN{Name: fmt.Sprintf("host%d.owasp.org", i)}}
+		addr := &types.Asset{ID: fmt.Sprintf("a%d", i%50), Asset: network.IPAddress{
+			Address: netip.AddrFrom4([4]byte{192, 0, 2, byte(i % 50)}),
+			Type:    "IPv4",
+		}}
+		cidr := &types.Asset{ID: fmt.Sprintf("c%d", i%5), Asset: network.Netblock{
+			Cidr: netip.PrefixFrom(netip.AddrFrom4([4]byte{198, 51, byte(i % 5), 0}), 24),
+			Type: "IPv4",
+		}}
+
+		seeds = append(seeds, name)
+		g.link(name, "a_record", addr)
+		g.link(cidr, "contains", addr)
+	}
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	next := func(a *types.Asset) []*Link {
+		mu.Lock()
+		calls[a.ID]++
+		mu.Unlock()
+		return g[a.ID]
+	}
+
+	serial := Associations(seeds, func(a *types.Asset) []*Link { return g[a.ID] }, Options{Workers: 1})
+	concurrent := Associations(seeds, next, Options{Workers: 8})
+	if len(serial) != len(concurrent) {
+		t.Fatalf("Expected %d associations, got %d", len(serial), len(concurrent))
+	}
+	for i := range serial {
+		if serial[i].Asset.Key != concurrent[i].Asset.Key || serial[i].Confidence != concurrent[i].Confidence {
+			t.Errorf("Unexpected association %s %f", concurrent[i].Asset.Key, concurrent[i].Confidence)
+		}
+	}
+	for id, n := range calls {
+		if n > 1 {
+			t.Errorf("The neighbors of %s were queried %d times", id, n)
+		}
+	}
+}
+
+func TestMaxAssets(t *testing.T) {
+	root := &types.Asset{ID: "1", Asset: domain.FQDN{Name: "owasp.org"}}
+	g := testGraph{}
+	// A registrant shared by many domains would otherwise lead the traversal through the whole graph
+	prev := root
+	for i := 2; i < 100; i++ {
+		a := &types.Asset{ID: strconv.Itoa(i), Asset: domain.FQDN{Name: fmt.Sprintf("host%d.owasp.org", i)}}
+		g.link(prev, "cname_record", a)
+		prev = a
+	}
+
+	next := func(a *types.Asset) []*Link { return g[a.ID] }
+	results := Associations([]*types.Asset{root}, next, Options{MaxAssets: 10})
+	if len(results) != 10 {
+		t.Fatalf("Expected ten associations, got %d", len(results))
+	}
+	for i, a := range results {
+		if len(a.Path) != i+1 {
+			t.Errorf("Expected the most confident associations, got %s at %d relations", a.Asset.Key, len(a.Path))
+		}
+	}
+}
+
+func TestRegistrantKey(t *testing.T) {
+	for name, want := range map[string]string{
+		"OWASP Foundation, Inc.":        "owasp foundation",
+		"OWASP FOUNDATION INC":          "owasp foundation",
+		"Example Holdings Co. Ltd":      "example holdings",
+		"Domains By Proxy, LLC":         "",
+		"REDACTED FOR PRIVACY":          "",
+		"Inc":                           "inc",
+		"Acme-Widgets GmbH (Stuttgart)": "acme widgets gmbh stuttgart",
+	} {
+		if got := RegistrantKey(name); got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package assoc
+
+import (
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/asset-db/types"
+)
+
+// DefaultMaxPaths is the number of shortest paths returned when no limit is provided.
+const DefaultMaxPaths = 10
+
+// PathOptions control the search for the paths between two assets.
+type PathOptions struct {
+	// MaxDepth is the maximum number of relations in a path, or zero for no limit
+	MaxDepth int
+	// MaxPaths is the maximum number of paths returned, or zero for the DefaultMaxPaths
+	MaxPaths int
+}
+
+type parent struct {
+	asset *types.Asset
+	link  *Link
+}
+
+// ShortestPaths returns the paths with the fewest relations connecting the from asset to the to asset,
+// following the relations in both directions. Nil is returned when the assets are not connected.
+func ShortestPaths(from, to *types.Asset, next Neighbors, opts PathOptions) [][]*Step {
+	if from == nil || to == nil {
+		return nil
+	}
+	if from.ID == to.ID {
+		return [][]*Step{{}}
+	}
+
+	dist := map[string]int{from.ID: 0}
+	parents := make(map[string][]*parent)
+	level := []*types.Asset{from}
+
+	for depth := 1; len(level) > 0; depth++ {
+		if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+			break
+		}
+
+		var nextLevel []*types.Asset
+		for _, cur := range level {
+			// The target is not expanded, since longer paths through it are not shortest paths
+			if cur.ID == to.ID {
+				continue
+			}
+
+			for _, l := range next(cur) {
+				if l == nil || l.Asset == nil || l.Relation == nil {
+					continue
+				}
+
+				id := l.Asset.ID
+				if d, found := dist[id]; found {
+					// Another path of the same length reaching the asset
+					if d == depth {
+						parents[id] = append(parents[id], &parent{asset: cur, link: l})
+					}
+					continue
+				}
+
+				dist[id] = depth
+				parents[id] = []*parent{{asset: cur, link: l}}
+				nextLevel = append(nextLevel, l.Asset)
+			}
+		}
+		if _, found := dist[to.ID]; found {
+			break
+		}
+		level = nextLevel
+	}
+	if _, found := dist[to.ID]; !found {
+		return nil
+	}
+
+	limit := opts.MaxPaths
+	if limit <= 0 {
+		limit = DefaultMaxPaths
+	}
+
+	var paths [][]*Step
+	var walk func(id string, suffix []*Step)
+	walk = func(id string, suffix []*Step) {
+		if len(paths) >= limit {
+			return
+		}
+		if id == from.ID {
+			paths = append(paths, suffix)
+			return
+		}
+
+		for _, p := range parents[id] {
+			s := linkStep(p.asset, p.link)
+			walk(p.asset.ID, append([]*Step{s}, suffix...))
+		}
+	}
+	walk(to.ID, nil)
+	return paths
+}
+
+// linkStep returns the Step for the link followed from the asset, in the direction it is stored in the graph database.
+func linkStep(a *types.Asset, l *Link) *Step {
+	from, to := a, l.Asset
+	if l.Incoming {
+		from, to = to, from
+	}
+
+	return &Step{
+		From:     format.NewAssetRecord(from),
+		Relation: l.Relation.Type,
+		To:       format.NewAssetRecord(to),
+	}
+}
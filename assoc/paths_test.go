@@ -0,0 +1,66 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package assoc
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func TestShortestPaths(t *testing.T) {
+	www := &types.Asset{ID: "1", Asset: domain.FQDN{Name: "www.owasp.org"}}
+	api := &types.Asset{ID: "2", Asset: domain.FQDN{Name: "api.owasp.org"}}
+	addr1 := &types.Asset{ID: "3", Asset: network.IPAddress{Address: netip.MustParseAddr("192.0.2.1"), Type: "IPv4"}}
+	addr2 := &types.Asset{ID: "4", Asset: network.IPAddress{Address: netip.MustParseAddr("192.0.2.2"), Type: "IPv4"}}
+	cidr := &types.Asset{ID: "5", Asset: network.Netblock{Cidr: netip.MustParsePrefix("192.0.2.0/24"), Type: "IPv4"}}
+	asn := &types.Asset{ID: "6", Asset: network.AutonomousSystem{Number: 64496}}
+	org := &types.Asset{ID: "7", Asset: network.RIROrganization{Name: "OWASP Foundation"}}
+	other := &types.Asset{ID: "8", Asset: domain.FQDN{Name: "unrelated.example"}}
+
+	g := testGraph{}
+	g.link(www, "a_record", addr1)
+	g.link(api, "a_record", addr2)
+	g.link(cidr, "contains", addr1)
+	g.link(cidr, "contains", addr2)
+	g.link(asn, "announces", cidr)
+	g.link(asn, "managed_by", org)
+	// A longer path to the organization that should not be returned
+	g.link(www, "cname_record", api)
+	g.link(addr1, "ptr_record", www)
+
+	next := func(a *types.Asset) []*Link { return g[a.ID] }
+
+	paths := ShortestPaths(addr1, org, next, PathOptions{})
+	if len(paths) != 1 || len(paths[0]) != 3 {
+		t.Fatalf("Expected one path with three relations, got %d", len(paths))
+	}
+	if s := paths[0][0]; s.From.Key != "192.0.2.0/24" || s.Relation != "contains" || s.To.Key != "192.0.2.1" {
+		t.Errorf("The step was not provided in the direction of the relation: %+v", s)
+	}
+
+	paths = ShortestPaths(www, addr2, next, PathOptions{})
+	if len(paths) != 1 || len(paths[0]) != 2 {
+		t.Fatalf("Expected one path with two relations, got %v", paths)
+	}
+	// The address and the name are connected by both the A and PTR records
+	paths = ShortestPaths(addr1, api, next, PathOptions{})
+	if len(paths) != 2 || len(paths[0]) != 2 || paths[0][0].Relation == paths[1][0].Relation {
+		t.Fatalf("Expected two shortest paths, got %d", len(paths))
+	}
+	if paths = ShortestPaths(addr1, api, next, PathOptions{MaxPaths: 1}); len(paths) != 1 {
+		t.Errorf("Expected the paths to be limited, got %d", len(paths))
+	}
+
+	if paths := ShortestPaths(www, org, next, PathOptions{MaxDepth: 3}); paths != nil {
+		t.Errorf("Expected no paths within the maximum depth, got %d", len(paths))
+	}
+	if paths := ShortestPaths(www, other, next, PathOptions{}); paths != nil {
+		t.Errorf("Expected no paths to an unconnected asset, got %d", len(paths))
+	}
+}
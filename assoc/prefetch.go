@@ -0,0 +1,121 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package assoc
+
+import (
+	"sync"
+
+	"github.com/owasp-amass/asset-db/types"
+)
+
+// DefaultWorkers is the number of assets expanded concurrently when the Options do not provide it.
+const DefaultWorkers = 16
+
+// expansion is the result of the queries for the neighbors of an asset.
+type expansion struct {
+	done    chan struct{}
+	claimed bool
+	links   []*Link
+	shared  bool
+}
+
+// prefetcher queries the neighbors of the assets as soon as they are added to the frontier, using a
+// pool of workers, so the queries overlap while the traversal still expands the assets in order.
+type prefetcher struct {
+	sync.Mutex
+	next    Neighbors
+	shared  func(a *types.Asset) bool
+	jobs    chan *types.Asset
+	pending map[string]*expansion
+	wg      sync.WaitGroup
+}
+
+func newPrefetcher(next Neighbors, shared func(a *types.Asset) bool, workers int) *prefetcher {
+	p := &prefetcher{
+		next:    next,
+		shared:  shared,
+		jobs:    make(chan *types.Asset, workers),
+		pending: make(map[string]*expansion),
+	}
+
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+// start requests the expansion of the asset without waiting for it. The request is dropped when
+// the workers are busy, and the asset is then expanded when the traversal reaches it.
+func (p *prefetcher) start(a *types.Asset) {
+	p.Lock()
+	if _, found := p.pending[a.ID]; found {
+		p.Unlock()
+		return
+	}
+	p.pending[a.ID] = &expansion{done: make(chan struct{})}
+	p.Unlock()
+
+	select {
+	case p.jobs <- a:
+	default:
+	}
+}
+
+// expand returns the neighbors of the asset and whether it is shared infrastructure, waiting for
+// the workers when they are already querying the asset.
+func (p *prefetcher) expand(a *types.Asset) ([]*Link, bool) {
+	p.Lock()
+	e, found := p.pending[a.ID]
+	if !found {
+		e = &expansion{done: make(chan struct{})}
+		p.pending[a.ID] = e
+	}
+	claim := !e.claimed
+	e.claimed = true
+	p.Unlock()
+
+	if claim {
+		p.run(a, e)
+	}
+	<-e.done
+
+	p.Lock()
+	delete(p.pending, a.ID)
+	p.Unlock()
+	return e.links, e.shared
+}
+
+// stop waits for the workers to finish the expansions they started.
+func (p *prefetcher) stop() {
+	close(p.jobs)
+	p.wg.Wait()
+}
+
+func (p *prefetcher) worker() {
+	defer p.wg.Done()
+
+	for a := range p.jobs {
+		p.Lock()
+		e, found := p.pending[a.ID]
+		claim := found && !e.claimed
+		if claim {
+			e.claimed = true
+		}
+		p.Unlock()
+
+		if claim {
+			p.run(a, e)
+		}
+	}
+}
+
+func (p *prefetcher) run(a *types.Asset, e *expansion) {
+	e.links = p.next(a)
+	if p.shared != nil {
+		e.shared = p.shared(a)
+	}
+	close(e.done)
+}
@@ -0,0 +1,59 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package assoc
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SimilarRegistrant is the type of the relations connecting the registrant organizations with similar names.
+const SimilarRegistrant = "similar_registrant"
+
+// LegalSuffixes are the words identifying the legal form of the organizations, which are ignored
+// when comparing the names of the registrants.
+var LegalSuffixes = []string{
+	"ab", "ag", "bv", "co", "company", "corp", "corporation", "gmbh", "inc", "incorporated",
+	"limited", "llc", "llp", "lp", "ltd", "nv", "oy", "plc", "pty", "sa", "sarl", "sas", "spa",
+}
+
+// PrivacyRegistrants are the keywords found in the names of the privacy and proxy services
+// registering on behalf of many unrelated organizations.
+var PrivacyRegistrants = []string{
+	"privacy",
+	"proxy",
+	"redacted",
+	"withheld",
+	"whoisguard",
+	"not disclosed",
+}
+
+// RegistrantKey returns the normalized name of the registrant organization, without the case, punctuation
+// and legal form, so similar names share the key. An empty key is returned for the privacy services.
+func RegistrantKey(name string) string {
+	lower := strings.ToLower(name)
+	for _, k := range PrivacyRegistrants {
+		if strings.Contains(lower, k) {
+			return ""
+		}
+	}
+
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for len(words) > 1 && legalSuffix(words[len(words)-1]) {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+func legalSuffix(word string) bool {
+	for _, s := range LegalSuffixes {
+		if word == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package assoc
+
+import "strings"
+
+// SharedPenalty is the factor applied to the relations followed from an address identified as shared
+// hosting or a CDN. The address still belongs to the path, but pivoting from it to the netblock, the
+// autonomous system or the other tenants provides little evidence of common ownership.
+const SharedPenalty = 0.2
+
+// SharedTenants is the number of registered domains resolving to an address, beyond which
+// the address is considered shared by unrelated tenants.
+const SharedTenants = 10
+
+// SharedProviders are the keywords found in the names of the organizations operating the CDNs
+// and shared hosting platforms, where the addresses are used by many unrelated tenants.
+var SharedProviders = []string{
+	"akamai",
+	"automattic",
+	"bunny",
+	"cdn77",
+	"cdnetworks",
+	"cloudflare",
+	"cloudfront",
+	"edgecast",
+	"edgio",
+	"fastly",
+	"github",
+	"godaddy",
+	"imperva",
+	"incapsula",
+	"limelight",
+	"netlify",
+	"shopify",
+	"squarespace",
+	"stackpath",
+	"sucuri",
+	"vercel",
+	"wix",
+	"wpengine",
+}
+
+// SharedProvider returns true when the organization name or description belongs to a CDN or shared hosting platform.
+func SharedProvider(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, k := range SharedProviders {
+		if strings.Contains(name, k) {
+			return true
+		}
+	}
+	return false
+}
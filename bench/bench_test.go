@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package bench
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestTarget(t *testing.T) {
+	target, err := NewTarget("example.com", 10)
+	if err != nil {
+		t.Fatalf("Failed to start the target: %v", err)
+	}
+	defer target.Close()
+
+	c := new(dns.Client)
+	for _, tt := range []struct {
+		name  string
+		rcode int
+	}{
+		{name: "host3.example.com", rcode: dns.RcodeSuccess},
+		{name: "missing.example.com", rcode: dns.RcodeNameError},
+		{name: "owasp.org", rcode: dns.RcodeRefused},
+	} {
+		m := new(dns.Msg)
+		m.SetQuestion(dns.Fqdn(tt.name), dns.TypeA)
+
+		resp, _, err := c.Exchange(m, target.DNSAddr())
+		if err != nil {
+			t.Fatalf("%s: query failed: %v", tt.name, err)
+		}
+		if resp.Rcode != tt.rcode {
+			t.Errorf("%s: expected rcode %d, got %d", tt.name, tt.rcode, resp.Rcode)
+		}
+		if tt.rcode == dns.RcodeSuccess {
+			if len(resp.Answer) != 1 || resp.Answer[0].(*dns.A).A.String() != "10.0.0.3" {
+				t.Errorf("%s: unexpected answers %v", tt.name, resp.Answer)
+			}
+		}
+	}
+
+	resp, err := http.Get(target.APIURL() + NamesPath + "?domain=example.com")
+	if err != nil {
+		t.Fatalf("API request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if names := strings.Split(string(body), "\n"); len(names) != 10 {
+		t.Errorf("Expected 10 names from the API, got %d", len(names))
+	}
+}
+
+func BenchmarkTargetQueries(b *testing.B) {
+	target, err := NewTarget("example.com", 1000)
+	if err != nil {
+		b.Fatalf("Failed to start the target: %v", err)
+	}
+	defer target.Close()
+
+	c := new(dns.Client)
+	stats := Start()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := new(dns.Msg)
+		m.SetQuestion(dns.Fqdn(target.Names[i%len(target.Names)]), dns.TypeA)
+
+		if _, _, err := c.Exchange(m, target.DNSAddr()); err != nil {
+			b.Fatalf("Query failed: %v", err)
+		}
+		stats.AddEvents(1)
+	}
+	b.StopTimer()
+	stats.Report(b)
+}
@@ -0,0 +1,89 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package bench provides the synthetic targets and measurements used by the benchmarks,
+// so performance regressions in the engine can be detected by comparing runs with benchstat.
+package bench
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Reporter is implemented by *testing.B.
+type Reporter interface {
+	ReportMetric(n float64, unit string)
+}
+
+// Stats measures the events processed, database writes and memory used during a benchmark.
+type Stats struct {
+	start   time.Time
+	elapsed time.Duration
+	heap    uint64
+	alloc   uint64
+	events  int64
+	writes  int64
+}
+
+// Start returns Stats that begin measuring from this point.
+func Start() *Stats {
+	var m runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return &Stats{
+		start: time.Now(),
+		heap:  m.HeapAlloc,
+		alloc: m.TotalAlloc,
+	}
+}
+
+// StopTimer stops measuring the elapsed time, so the setup of each iteration can be excluded.
+func (s *Stats) StopTimer() {
+	if !s.start.IsZero() {
+		s.elapsed += time.Since(s.start)
+		s.start = time.Time{}
+	}
+}
+
+// StartTimer resumes measuring the elapsed time.
+func (s *Stats) StartTimer() {
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+}
+
+// AddEvents records the number of events processed.
+func (s *Stats) AddEvents(n int) {
+	atomic.AddInt64(&s.events, int64(n))
+}
+
+// AddWrites records the number of database writes performed.
+func (s *Stats) AddWrites(n int) {
+	atomic.AddInt64(&s.writes, int64(n))
+}
+
+// Report provides the events/sec, writes/sec, heap-MB and alloc-MB metrics to the reporter.
+func (s *Stats) Report(r Reporter) {
+	s.StopTimer()
+	elapsed := s.elapsed.Seconds()
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	if events := atomic.LoadInt64(&s.events); events > 0 && elapsed > 0 {
+		r.ReportMetric(float64(events)/elapsed, "events/sec")
+	}
+	if writes := atomic.LoadInt64(&s.writes); writes > 0 && elapsed > 0 {
+		r.ReportMetric(float64(writes)/elapsed, "writes/sec")
+	}
+
+	var heap uint64
+	if m.HeapAlloc > s.heap {
+		heap = m.HeapAlloc - s.heap
+	}
+	r.ReportMetric(float64(heap)/(1<<20), "heap-MB")
+	r.ReportMetric(float64(m.TotalAlloc-s.alloc)/(1<<20), "alloc-MB")
+}
@@ -0,0 +1,182 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package bench
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// NamesPath is the mock API endpoint that returns the subdomain names of the target, one per line.
+const NamesPath = "/names"
+
+// The mock API also answers RDAP queries, so the benchmarks never reach the public registries.
+var rdapPaths = []string{"/domain/", "/ip/", "/autnum/"}
+
+// Target is a synthetic organization served by a local authoritative DNS server and a mock
+// data source API. The names and addresses only depend on the domain and size, so each run
+// of the benchmarks is performed against exactly the same target.
+type Target struct {
+	Domain  string
+	Names   []string
+	records map[string]net.IP
+	server  *dns.Server
+	conn    net.PacketConn
+	api     *httptest.Server
+}
+
+// NewTarget starts the DNS server and mock API for a domain with the provided number of subdomains.
+func NewTarget(domain string, size int) (*Target, error) {
+	domain = strings.ToLower(strings.Trim(domain, "."))
+	t := &Target{
+		Domain:  domain,
+		records: make(map[string]net.IP, size+1),
+	}
+
+	t.records[domain] = net.IPv4(10, 0, 0, 1)
+	for i := 1; i <= size; i++ {
+		name := fmt.Sprintf("host%d.%s", i, domain)
+
+		t.Names = append(t.Names, name)
+		t.records[name] = net.IPv4(10, byte(i>>16), byte(i>>8), byte(i))
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the DNS server: %v", err)
+	}
+	t.conn = conn
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	t.server = &dns.Server{
+		PacketConn:        conn,
+		Handler:           dns.HandlerFunc(t.serveDNS),
+		NotifyStartedFunc: wg.Done,
+	}
+	go func() { _ = t.server.ActivateAndServe() }()
+	wg.Wait()
+
+	t.api = httptest.NewServer(http.HandlerFunc(t.serveAPI))
+	return t, nil
+}
+
+// DNSAddr returns the address of the authoritative DNS server.
+func (t *Target) DNSAddr() string {
+	return t.conn.LocalAddr().String()
+}
+
+// APIURL returns the base URL of the mock data source and RDAP API.
+func (t *Target) APIURL() string {
+	return t.api.URL
+}
+
+// Address returns the address the name resolves to, or nil when the name does not exist.
+func (t *Target) Address(name string) net.IP {
+	return t.records[strings.ToLower(strings.TrimSuffix(name, "."))]
+}
+
+// Zone returns the target as the text of a DNS zone file.
+func (t *Target) Zone() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("$ORIGIN %s.\n$TTL 300\n", t.Domain))
+	b.WriteString(fmt.Sprintf("@ IN SOA ns1.%s. hostmaster.%s. 1 7200 3600 1209600 300\n", t.Domain, t.Domain))
+	b.WriteString(fmt.Sprintf("@ IN NS ns1.%s.\n", t.Domain))
+	b.WriteString(fmt.Sprintf("@ IN A %s\n", t.records[t.Domain]))
+	for _, name := range t.Names {
+		b.WriteString(fmt.Sprintf("%s. IN A %s\n", name, t.records[name]))
+	}
+	return b.String()
+}
+
+// Close stops the DNS server and mock API.
+func (t *Target) Close() {
+	t.api.Close()
+	_ = t.server.Shutdown()
+}
+
+func (t *Target) serveDNS(w dns.ResponseWriter, req *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(req)
+	m.Authoritative = true
+
+	if len(req.Question) == 0 {
+		m.Rcode = dns.RcodeFormatError
+		_ = w.WriteMsg(m)
+		return
+	}
+
+	q := req.Question[0]
+	name := strings.ToLower(strings.TrimSuffix(q.Name, "."))
+	if name != t.Domain && !strings.HasSuffix(name, "."+t.Domain) {
+		m.Rcode = dns.RcodeRefused
+		_ = w.WriteMsg(m)
+		return
+	}
+
+	ip, found := t.records[name]
+	if !found {
+		m.Rcode = dns.RcodeNameError
+		m.Ns = append(m.Ns, t.soa())
+		_ = w.WriteMsg(m)
+		return
+	}
+
+	hdr := dns.RR_Header{Name: q.Name, Class: dns.ClassINET, Ttl: 300}
+	switch {
+	case q.Qtype == dns.TypeA:
+		hdr.Rrtype = dns.TypeA
+		m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: ip})
+	case q.Qtype == dns.TypeNS && name == t.Domain:
+		hdr.Rrtype = dns.TypeNS
+		m.Answer = append(m.Answer, &dns.NS{Hdr: hdr, Ns: "ns1." + t.Domain + "."})
+	case q.Qtype == dns.TypeSOA && name == t.Domain:
+		m.Answer = append(m.Answer, t.soa())
+	default:
+		m.Ns = append(m.Ns, t.soa())
+	}
+	_ = w.WriteMsg(m)
+}
+
+func (t *Target) soa() dns.RR {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: t.Domain + ".", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+		Ns:      "ns1." + t.Domain + ".",
+		Mbox:    "hostmaster." + t.Domain + ".",
+		Serial:  1,
+		Refresh: 7200,
+		Retry:   3600,
+		Expire:  1209600,
+		Minttl:  300,
+	}
+}
+
+func (t *Target) serveAPI(w http.ResponseWriter, r *http.Request) {
+	for _, p := range rdapPaths {
+		if strings.HasPrefix(r.URL.Path, p) {
+			w.Header().Set("Content-Type", "application/rdap+json")
+			_, _ = w.Write([]byte(`{"entities":[]}`))
+			return
+		}
+	}
+	if r.URL.Path != NamesPath {
+		http.NotFound(w, r)
+		return
+	}
+	if d := strings.ToLower(r.URL.Query().Get("domain")); d != t.Domain {
+		http.Error(w, "unknown domain", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(strings.Join(t.Names, "\n")))
+}
@@ -0,0 +1,219 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package bgp watches the routes announced for the netblocks in scope, and detects the changes
+// of the origin and upstream autonomous systems that can indicate a hijack or a route leak.
+package bgp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/session"
+)
+
+// StateFileName is the name of the file in the output directory that stores the routes last observed.
+const StateFileName = "bgp_routes.json"
+
+// stateKind is the versioned format of the state file, which is documented by the session package.
+var stateKind = &session.Kind{Name: "bgp_routes", Version: 1}
+
+// TypeRouteChange is the type of the findings recorded for the route changes.
+const TypeRouteChange = "bgp_route_change"
+
+// The kinds of route changes.
+const (
+	ChangeOrigin   = "origin"
+	ChangeUpstream = "upstream"
+)
+
+// Route describes how a prefix is reached, as observed by the collectors of a feed.
+type Route struct {
+	Prefix string `json:"prefix"`
+	// Origins are the autonomous systems announcing the prefix
+	Origins []int `json:"origins"`
+	// Upstreams are the autonomous systems observed providing transit to the origins
+	Upstreams []int     `json:"upstreams"`
+	Observed  time.Time `json:"observed"`
+}
+
+// Feed provides the routes currently announced for a prefix.
+type Feed interface {
+	Route(ctx context.Context, prefix string) (*Route, error)
+}
+
+// Change is a difference between the route previously observed for a prefix and the current route.
+type Change struct {
+	Prefix   string
+	Kind     string
+	Previous []int
+	Current  []int
+}
+
+// Compare returns the changes of the origins and the new upstreams between the routes. No changes are
+// returned when the prefix is not currently announced, since the collectors can miss announcements.
+func Compare(prev, cur *Route) []*Change {
+	if prev == nil || cur == nil || len(cur.Origins) == 0 {
+		return nil
+	}
+
+	var changes []*Change
+	if !equal(prev.Origins, cur.Origins) {
+		changes = append(changes, &Change{
+			Prefix:   cur.Prefix,
+			Kind:     ChangeOrigin,
+			Previous: prev.Origins,
+			Current:  cur.Origins,
+		})
+	}
+	// The upstreams observed by the collectors vary, so only those never observed before are reported
+	if len(prev.Upstreams) > 0 && len(difference(cur.Upstreams, prev.Upstreams)) > 0 {
+		changes = append(changes, &Change{
+			Prefix:   cur.Prefix,
+			Kind:     ChangeUpstream,
+			Previous: prev.Upstreams,
+			Current:  cur.Upstreams,
+		})
+	}
+	return changes
+}
+
+// Added returns the autonomous systems in the current list that were not in the previous list.
+func (c *Change) Added() []int {
+	return difference(c.Current, c.Previous)
+}
+
+// Finding returns the finding recorded for the change. New origins are assigned a higher severity,
+// since another autonomous system announcing the prefix is the signature of a hijack.
+func (c *Change) Finding() *findings.Finding {
+	f := &findings.Finding{
+		Asset:    c.Prefix,
+		Type:     TypeRouteChange,
+		Severity: findings.SeverityMedium,
+		Title:    fmt.Sprintf("The %s autonomous systems of %s changed", c.Kind, c.Prefix),
+		Details:  fmt.Sprintf("Previously %s, now %s", asList(c.Previous), asList(c.Current)),
+		Attributes: map[string]string{
+			"change":   c.Kind,
+			"previous": asList(c.Previous),
+			"current":  asList(c.Current),
+		},
+	}
+
+	switch {
+	case c.Kind == ChangeOrigin && len(c.Added()) > 0:
+		f.Severity = findings.SeverityHigh
+		f.Title = fmt.Sprintf("%s is announced by %s", c.Prefix, asList(c.Added()))
+	case c.Kind == ChangeUpstream:
+		f.Title = fmt.Sprintf("%s is reached through the new upstream %s", c.Prefix, asList(c.Added()))
+	}
+	return f
+}
+
+// State is the set of routes last observed for the prefixes, kept across the monitoring cycles.
+type State map[string]*Route
+
+// ReadState returns the routes stored in the file, or an empty State when the file does not exist.
+func ReadState(path string) (State, error) {
+	s := make(State)
+	if _, err := stateKind.Read(path, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write stores the routes in the file.
+func (s State) Write(path string) error {
+	return stateKind.Write(path, s)
+}
+
+// Check obtains the current route of each prefix from the feed, returns the changes from the routes
+// in the State, and updates the State. The prefixes observed for the first time become the baseline,
+// and the upstreams accumulate, so the upstreams that come and go are only reported the first time.
+func (s State) Check(ctx context.Context, feed Feed, prefixes []string) ([]*Change, error) {
+	var changes []*Change
+	var msgs []string
+
+	for _, prefix := range prefixes {
+		if err := ctx.Err(); err != nil {
+			return changes, err
+		}
+
+		cur, err := feed.Route(ctx, prefix)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", prefix, err))
+			continue
+		}
+		if len(cur.Origins) == 0 {
+			continue
+		}
+
+		prev := s[prefix]
+		changes = append(changes, Compare(prev, cur)...)
+		if prev != nil {
+			cur.Upstreams = uniqueSorted(append(cur.Upstreams, prev.Upstreams...))
+		}
+		s[prefix] = cur
+	}
+	if len(msgs) > 0 {
+		return changes, fmt.Errorf("failed to obtain the routes: %s", strings.Join(msgs, "; "))
+	}
+	return changes, nil
+}
+
+func uniqueSorted(list []int) []int {
+	sort.Ints(list)
+
+	var results []int
+	for i, v := range list {
+		if i == 0 || v != list[i-1] {
+			results = append(results, v)
+		}
+	}
+	return results
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// difference returns the values in a that are not in b.
+func difference(a, b []int) []int {
+	var results []int
+
+	for _, v := range a {
+		found := false
+		for _, n := range b {
+			if n == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			results = append(results, v)
+		}
+	}
+	return results
+}
+
+func asList(list []int) string {
+	var parts []string
+
+	for _, asn := range list {
+		parts = append(parts, "AS"+strconv.Itoa(asn))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,103 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package bgp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+type testFeed map[string]*Route
+
+func (f testFeed) Route(ctx context.Context, prefix string) (*Route, error) {
+	if r, found := f[prefix]; found {
+		c := *r
+		return &c, nil
+	}
+	return nil, fmt.Errorf("no route for %s", prefix)
+}
+
+func TestCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+
+	state, err := ReadState(path)
+	if err != nil || len(state) != 0 {
+		t.Fatalf("Expected an empty state: %v", err)
+	}
+
+	feed := testFeed{"192.0.2.0/24": {Prefix: "192.0.2.0/24", Origins: []int{64496}, Upstreams: []int{64500}}}
+	if changes, err := state.Check(context.Background(), feed, []string{"192.0.2.0/24"}); err != nil || len(changes) != 0 {
+		t.Fatalf("Expected the first observation to become the baseline: %v", err)
+	}
+	if err := state.Write(path); err != nil {
+		t.Fatalf("Failed to write the state: %v", err)
+	}
+
+	state, err = ReadState(path)
+	if err != nil || state["192.0.2.0/24"] == nil {
+		t.Fatalf("Failed to read the state: %v", err)
+	}
+
+	// A hijack announces the prefix from another autonomous system through a new upstream
+	feed["192.0.2.0/24"] = &Route{Prefix: "192.0.2.0/24", Origins: []int{64496, 64511}, Upstreams: []int{64501}}
+	changes, err := state.Check(context.Background(), feed, []string{"192.0.2.0/24", "198.51.100.0/24"})
+	if err == nil {
+		t.Error("Expected an error for the prefix missing from the feed")
+	}
+	if len(changes) != 2 || changes[0].Kind != ChangeOrigin || changes[1].Kind != ChangeUpstream {
+		t.Fatalf("Expected the origin and upstream changes, got %d changes", len(changes))
+	}
+
+	f := changes[0].Finding()
+	if f.Type != TypeRouteChange || f.Severity != findings.SeverityHigh || f.Title != "192.0.2.0/24 is announced by AS64511" {
+		t.Errorf("Unexpected origin finding: %+v", f)
+	}
+	if f := changes[1].Finding(); f.Severity != findings.SeverityMedium || f.Attributes["current"] != "AS64501" {
+		t.Errorf("Unexpected upstream finding: %+v", f)
+	}
+	if got := state["192.0.2.0/24"].Upstreams; !reflect.DeepEqual(got, []int{64500, 64501}) {
+		t.Errorf("Expected the upstreams to accumulate, got %v", got)
+	}
+
+	// The upstreams already observed are not reported again
+	feed["192.0.2.0/24"] = &Route{Prefix: "192.0.2.0/24", Origins: []int{64496, 64511}, Upstreams: []int{64500}}
+	if changes, _ := state.Check(context.Background(), feed, []string{"192.0.2.0/24"}); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %d", len(changes))
+	}
+}
+
+func TestRIPEstat(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("resource") != "192.0.2.0/24" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"bgp_state": [
+			{"target_prefix": "192.0.2.0/24", "path": [3333, 64500, 64496, 64496]},
+			{"target_prefix": "192.0.2.0/24", "path": [1103, 64501, 64496]},
+			{"target_prefix": "192.0.2.0/25", "path": [1103, 64502, 64511]}
+		]}}`)
+	}))
+	defer ts.Close()
+
+	feed := NewRIPEstat()
+	feed.BaseURL = ts.URL
+	feed.HTTP = ts.Client()
+
+	route, err := feed.Route(context.Background(), "192.0.2.0/24")
+	if err != nil {
+		t.Fatalf("Failed to obtain the route: %v", err)
+	}
+	if !reflect.DeepEqual(route.Origins, []int{64496}) || !reflect.DeepEqual(route.Upstreams, []int{64500, 64501}) {
+		t.Errorf("Unexpected route: origins %v, upstreams %v", route.Origins, route.Upstreams)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package bgp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+const (
+	requestTimeout = time.Minute
+	maxBodySize    = 32 * 1024 * 1024
+)
+
+// RIPEstat obtains the routes from the BGP state API of RIPEstat, which provides the paths observed
+// by the RIPE RIS route collectors.
+type RIPEstat struct {
+	// BaseURL is the address of the bgp-state data call
+	BaseURL string
+	HTTP    *http.Client
+}
+
+type ripestatResponse struct {
+	Data struct {
+		BGPState []struct {
+			TargetPrefix string        `json:"target_prefix"`
+			Path         []interface{} `json:"path"`
+		} `json:"bgp_state"`
+	} `json:"data"`
+}
+
+// NewRIPEstat returns the RIPEstat feed.
+func NewRIPEstat() *RIPEstat {
+	return &RIPEstat{
+		BaseURL: "https://stat.ripe.net/data/bgp-state/data.json",
+		HTTP: amasshttp.NewClient("ripestat", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Cache:   true,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// Route implements the Feed interface.
+func (r *RIPEstat) Route(ctx context.Context, prefix string) (*Route, error) {
+	u := r.BaseURL + "?resource=" + url.QueryEscape(prefix) + "&sourceapp=amass"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("RIPEstat returned status %d", resp.StatusCode)
+	}
+
+	var data ripestatResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode the RIPEstat response: %v", err)
+	}
+
+	route := &Route{Prefix: prefix, Observed: time.Now().UTC()}
+	for _, st := range data.Data.BGPState {
+		// More specific prefixes are announced separately, and are checked when in scope
+		if st.TargetPrefix != "" && st.TargetPrefix != prefix {
+			continue
+		}
+
+		path := asPath(st.Path)
+		if n := len(path); n > 0 {
+			route.Origins = append(route.Origins, path[n-1])
+			// The upstream is the first autonomous system in the path that is not the origin,
+			// since the origin can be prepended
+			for i := n - 2; i >= 0; i-- {
+				if path[i] != path[n-1] {
+					route.Upstreams = append(route.Upstreams, path[i])
+					break
+				}
+			}
+		}
+	}
+	route.Origins = uniqueSorted(route.Origins)
+	route.Upstreams = uniqueSorted(route.Upstreams)
+	return route, nil
+}
+
+// asPath returns the autonomous systems in the path, skipping the AS sets.
+func asPath(path []interface{}) []int {
+	var results []int
+
+	for _, v := range path {
+		if n, ok := v.(float64); ok {
+			results = append(results, int(n))
+		}
+	}
+	return results
+}
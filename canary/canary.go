@@ -0,0 +1,175 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package canary performs a clearly labeled set of reconnaissance behaviors against the infrastructure of
+// the user, so defenders can find out which of them were visible in their logs and SIEM. Each probe carries
+// a unique token in the names queried, the requests sent and the headers, and the tokens found in the
+// exported logs mark the probes as visible.
+package canary
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the file in the output directory that stores the canary runs.
+const FileName = "canary.json"
+
+// TokenPrefix starts the tokens labeling the probes, so they are easy to search for in the logs.
+const TokenPrefix = "amass-canary-"
+
+// The behaviors performed by the probes.
+const (
+	// BehaviorDNSBruteForce queries nonexistent names under the domain, like a brute forcing wordlist
+	BehaviorDNSBruteForce = "dns_bruteforce"
+	// BehaviorZoneTransfer requests a zone transfer of the domain from each of its nameservers
+	BehaviorZoneTransfer = "zone_transfer"
+	// BehaviorHTTP requests a labeled path from the web server
+	BehaviorHTTP = "http_probe"
+	// BehaviorTLSSNI performs a TLS handshake providing a labeled name as the SNI value
+	BehaviorTLSSNI = "tls_sni"
+	// BehaviorPortScan connects to each of the ports
+	BehaviorPortScan = "port_scan"
+)
+
+// Behaviors are all the behaviors, in the order they are performed.
+var Behaviors = []string{BehaviorDNSBruteForce, BehaviorZoneTransfer, BehaviorHTTP, BehaviorTLSSNI, BehaviorPortScan}
+
+// Labeled returns true when the probes of the behavior carry the token, so they can be found in the logs
+// automatically. The other probes are identified by their time and the address of the host running them.
+func Labeled(behavior string) bool {
+	return behavior != BehaviorZoneTransfer && behavior != BehaviorPortScan
+}
+
+// Probe is a behavior performed against a target.
+type Probe struct {
+	// Token is the unique label carried by the probe, which also identifies it
+	Token     string    `json:"token"`
+	Behavior  string    `json:"behavior"`
+	Target    string    `json:"target"`
+	Performed time.Time `json:"performed"`
+	// Detail describes what was sent, such as the names queried or the URL requested
+	Detail string `json:"detail"`
+	Error  string `json:"error,omitempty"`
+	// Visible is when the probe was found in the logs, or nil while it was not
+	Visible *time.Time `json:"visible,omitempty"`
+}
+
+// Run is a set of probes performed together.
+type Run struct {
+	Started    time.Time `json:"started"`
+	Engagement string    `json:"engagement,omitempty"`
+	Probes     []*Probe  `json:"probes"`
+}
+
+// Log is the set of canary runs.
+type Log struct {
+	Runs []*Run `json:"runs"`
+}
+
+// ReadLog returns the runs stored in the file, or an empty Log when the file does not exist.
+func ReadLog(path string) (*Log, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return new(Log), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	l := new(Log)
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("failed to parse the canary file %s: %v", path, err)
+	}
+	return l, nil
+}
+
+// Write stores the runs in the file.
+func (l *Log) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Probe returns the probe labeled with the token.
+func (l *Log) Probe(token string) (*Probe, error) {
+	token = strings.ToLower(token)
+	for _, run := range l.Runs {
+		for _, p := range run.Probes {
+			if p.Token == token {
+				return p, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s is not the token of a canary probe", token)
+}
+
+var tokenRegex = regexp.MustCompile(`(?i)` + regexp.QuoteMeta(TokenPrefix) + `[0-9a-f]{8}`)
+
+// Match marks the probes whose tokens appear in the line of a log as visible, and returns the probes
+// that were marked for the first time.
+func (l *Log) Match(line string, now time.Time) []*Probe {
+	var marked []*Probe
+	for _, token := range tokenRegex.FindAllString(line, -1) {
+		if p, err := l.Probe(token); err == nil && p.Visible == nil {
+			p.MarkVisible(now)
+			marked = append(marked, p)
+		}
+	}
+	return marked
+}
+
+// MarkVisible records that the probe was found in the logs.
+func (p *Probe) MarkVisible(now time.Time) {
+	t := now.UTC()
+	p.Visible = &t
+}
+
+// Coverage is the number of probes performed and visible for a behavior.
+type Coverage struct {
+	Behavior  string
+	Performed int
+	Visible   int
+}
+
+// Coverage returns the number of probes of the run performed and visible for each behavior, in the order
+// of the behaviors. The probes that failed are not counted, since they may not have reached the target.
+func (r *Run) Coverage() []*Coverage {
+	var results []*Coverage
+	for _, b := range Behaviors {
+		c := &Coverage{Behavior: b}
+		for _, p := range r.Probes {
+			if p.Behavior != b || p.Error != "" {
+				continue
+			}
+			c.Performed++
+			if p.Visible != nil {
+				c.Visible++
+			}
+		}
+		if c.Performed > 0 {
+			results = append(results, c)
+		}
+	}
+	return results
+}
+
+// newToken returns a unique token labeling a probe.
+func newToken() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return TokenPrefix + hex.EncodeToString(b)
+}
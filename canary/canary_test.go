@@ -0,0 +1,145 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package canary
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings(map[string]interface{}{
+		"targets":   []interface{}{"Example.com", "192.0.2.10"},
+		"behaviors": []interface{}{"dns_bruteforce", "http_probe"},
+		"names":     5,
+		"ports":     []interface{}{22, 443},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the settings: %v", err)
+	}
+	if s.Targets[0] != "example.com" || len(s.Behaviors) != 2 || s.Names != 5 || len(s.Ports) != 2 {
+		t.Errorf("Unexpected settings: %+v", s)
+	}
+
+	if s, err = ParseSettings(map[string]interface{}{"targets": []interface{}{"example.com"}}); err != nil || len(s.Behaviors) != len(Behaviors) || s.Names != DefaultNames {
+		t.Errorf("Expected the default behaviors: %+v %v", s, err)
+	}
+	for _, raw := range []interface{}{
+		"example.com",
+		map[string]interface{}{"targets": "example.com"},
+		map[string]interface{}{"targets": []interface{}{"example.com"}, "behaviors": []interface{}{"exploit"}},
+		map[string]interface{}{"targets": []interface{}{"example.com"}, "ports": []interface{}{70000}},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	// The labels of the requests received, keyed by the paths
+	received := make(map[string]string)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.URL.Path] = r.Header.Get("X-Amass-Canary")
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	var queried []string
+	prober := &Prober{
+		Lookup: func(ctx context.Context, name string) error {
+			queried = append(queried, name)
+			return nil
+		},
+		LookupNS: func(ctx context.Context, domain string) ([]string, error) {
+			return nil, errors.New("no nameservers")
+		},
+		// Every target is served by the test server
+		Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, _ := net.SplitHostPort(addr)
+			return (&net.Dialer{}).DialContext(ctx, network, net.JoinHostPort("127.0.0.1", port))
+		},
+		HTTPPort: p,
+		TLSPort:  p,
+		Timeout:  5 * time.Second,
+	}
+
+	run := prober.Run(context.Background(), &Settings{
+		Targets:   []string{"example.com", "127.0.0.1"},
+		Behaviors: []string{BehaviorDNSBruteForce, BehaviorZoneTransfer, BehaviorHTTP, BehaviorPortScan},
+		Names:     3,
+		Ports:     []int{p},
+	})
+	// The DNS behaviors are not performed against the address
+	if len(run.Probes) != 6 {
+		t.Fatalf("Expected six probes, got %d", len(run.Probes))
+	}
+
+	brute := run.Probes[0]
+	if len(queried) != 3 || !strings.HasPrefix(queried[0], brute.Token+"-1.") || brute.Error != "" {
+		t.Errorf("Unexpected brute forcing: %v %+v", queried, brute)
+	}
+	if run.Probes[1].Error == "" {
+		t.Error("Expected an error for the zone transfer without nameservers")
+	}
+
+	mu.Lock()
+	for _, probe := range run.Probes {
+		if probe.Behavior == BehaviorHTTP && (probe.Error != "" || received["/"+probe.Token] != probe.Token) {
+			t.Errorf("Unexpected HTTP probe: %+v %v", probe, received)
+		}
+	}
+	mu.Unlock()
+	if scan := run.Probes[5]; !strings.Contains(scan.Detail, "open: "+port) {
+		t.Errorf("Unexpected port scan: %+v", scan)
+	}
+
+	l := &Log{Runs: []*Run{run}}
+	marked := l.Match("query: "+strings.ToUpper(brute.Token)+"-2.example.com IN A NXDOMAIN", time.Now())
+	if len(marked) != 1 || marked[0] != brute || brute.Visible == nil {
+		t.Errorf("The brute forcing was not marked as visible: %v", marked)
+	}
+	if marked = l.Match(brute.Token+"-3.example.com", time.Now()); len(marked) != 0 {
+		t.Error("Expected the probe to be marked once")
+	}
+
+	for _, c := range run.Coverage() {
+		switch c.Behavior {
+		case BehaviorDNSBruteForce:
+			if c.Performed != 1 || c.Visible != 1 {
+				t.Errorf("Unexpected coverage: %+v", c)
+			}
+		case BehaviorZoneTransfer:
+			t.Error("The failed zone transfer was counted")
+		case BehaviorHTTP:
+			if c.Visible != 0 {
+				t.Errorf("Unexpected coverage: %+v", c)
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := l.Write(path); err != nil {
+		t.Fatalf("Failed to write the log: %v", err)
+	}
+	if l, err := ReadLog(path); err != nil || len(l.Runs) != 1 {
+		t.Fatalf("Failed to read the log: %v", err)
+	} else if probe, err := l.Probe(brute.Token); err != nil || probe.Visible == nil {
+		t.Errorf("Unexpected probe read from the log: %+v %v", probe, err)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package canary
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// Defaults of the canary settings.
+const (
+	DefaultNames   = 10
+	DefaultTimeout = 10 * time.Second
+)
+
+// DefaultPorts are the ports connected to by the port scan when no other ports are provided.
+var DefaultPorts = []int{22, 80, 443, 3389, 8080}
+
+// Settings select the targets and the behaviors performed against them.
+type Settings struct {
+	// Targets are the domain names and IP addresses of the infrastructure of the user
+	Targets   []string
+	Behaviors []string
+	// Names is the number of names queried by the DNS brute forcing of each domain
+	Names int
+	Ports []int
+}
+
+// ParseSettings returns the Settings provided by the canary option.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	s := &Settings{Behaviors: Behaviors, Names: DefaultNames, Ports: DefaultPorts}
+	if raw == nil {
+		return s, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the canary option must be a section providing the targets and behaviors")
+	}
+
+	targets, err := stringList(m["targets"])
+	if err != nil {
+		return nil, fmt.Errorf("the canary targets %v", err)
+	}
+	for _, t := range targets {
+		s.Targets = append(s.Targets, strings.ToLower(strings.TrimSpace(t)))
+	}
+
+	if v, found := m["behaviors"]; found {
+		behaviors, err := stringList(v)
+		if err != nil {
+			return nil, fmt.Errorf("the canary behaviors %v", err)
+		}
+		s.Behaviors = nil
+		for _, b := range behaviors {
+			b = strings.ToLower(strings.TrimSpace(b))
+			if !known(b) {
+				return nil, fmt.Errorf("%s is not a canary behavior, the behaviors are %s", b, strings.Join(Behaviors, ", "))
+			}
+			s.Behaviors = append(s.Behaviors, b)
+		}
+	}
+
+	if v, found := m["names"]; found {
+		n, ok := v.(int)
+		if !ok || n <= 0 {
+			return nil, errors.New("the canary names must be a number greater than zero")
+		}
+		s.Names = n
+	}
+
+	if v, found := m["ports"]; found {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, errors.New("the canary ports must be a list")
+		}
+		s.Ports = nil
+		for _, p := range list {
+			port, ok := p.(int)
+			if !ok || port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("%v is not a valid canary port", p)
+			}
+			s.Ports = append(s.Ports, port)
+		}
+	}
+	return s, nil
+}
+
+func stringList(v interface{}) ([]string, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("must be a list")
+	}
+
+	var results []string
+	for _, item := range list {
+		str, ok := item.(string)
+		if !ok || strings.TrimSpace(str) == "" {
+			return nil, fmt.Errorf("must be a list of names, but %v was provided", item)
+		}
+		results = append(results, str)
+	}
+	return results, nil
+}
+
+func known(behavior string) bool {
+	for _, b := range Behaviors {
+		if b == behavior {
+			return true
+		}
+	}
+	return false
+}
+
+// Prober performs the behaviors against the targets.
+type Prober struct {
+	// Lookup resolves the name, where a name that does not exist is not an error
+	Lookup func(ctx context.Context, name string) error
+	// LookupNS returns the nameservers of the domain
+	LookupNS func(ctx context.Context, domain string) ([]string, error)
+	Dial     func(ctx context.Context, network, addr string) (net.Conn, error)
+	// HTTPPort and TLSPort are the ports of the web server and the TLS handshake
+	HTTPPort int
+	TLSPort  int
+	Timeout  time.Duration
+}
+
+// NewProber returns a Prober using the system resolver and the configured network settings.
+func NewProber() *Prober {
+	return &Prober{
+		Lookup:   lookup,
+		LookupNS: lookupNS,
+		Dial:     amassnet.DialContext,
+		HTTPPort: 443,
+		TLSPort:  443,
+		Timeout:  DefaultTimeout,
+	}
+}
+
+func lookup(ctx context.Context, name string) error {
+	_, err := net.DefaultResolver.LookupHost(ctx, name)
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil
+	}
+	return err
+}
+
+func lookupNS(ctx context.Context, domain string) ([]string, error) {
+	records, err := net.DefaultResolver.LookupNS(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, ns := range records {
+		names = append(names, strings.TrimSuffix(ns.Host, "."))
+	}
+	return names, nil
+}
+
+// Run performs the behaviors of the settings against each target, and returns the probes performed. The
+// DNS behaviors are only performed against the domain names.
+func (p *Prober) Run(ctx context.Context, s *Settings) *Run {
+	run := &Run{Started: time.Now().UTC()}
+
+	for _, target := range s.Targets {
+		isAddr := net.ParseIP(target) != nil
+		for _, b := range s.Behaviors {
+			if isAddr && (b == BehaviorDNSBruteForce || b == BehaviorZoneTransfer) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return run
+			}
+
+			probe := &Probe{
+				Token:     newToken(),
+				Behavior:  b,
+				Target:    target,
+				Performed: time.Now().UTC(),
+			}
+
+			var err error
+			switch b {
+			case BehaviorDNSBruteForce:
+				err = p.bruteForce(ctx, probe, s.Names)
+			case BehaviorZoneTransfer:
+				err = p.zoneTransfer(ctx, probe)
+			case BehaviorHTTP:
+				err = p.httpProbe(ctx, probe)
+			case BehaviorTLSSNI:
+				err = p.tlsSNI(ctx, probe)
+			case BehaviorPortScan:
+				err = p.portScan(ctx, probe, s.Ports)
+			}
+			if err != nil {
+				probe.Error = err.Error()
+			}
+			run.Probes = append(run.Probes, probe)
+		}
+	}
+	return run
+}
+
+func (p *Prober) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (p *Prober) bruteForce(ctx context.Context, probe *Probe, names int) error {
+	probe.Detail = fmt.Sprintf("%d queries for %s-N.%s", names, probe.Token, probe.Target)
+
+	var failed int
+	var last error
+	for i := 1; i <= names; i++ {
+		cctx, cancel := p.context(ctx)
+		err := p.Lookup(cctx, fmt.Sprintf("%s-%d.%s", probe.Token, i, probe.Target))
+		cancel()
+		if err != nil {
+			failed++
+			last = err
+		}
+	}
+	if failed == names {
+		return fmt.Errorf("all the queries failed: %v", last)
+	}
+	return nil
+}
+
+func (p *Prober) zoneTransfer(ctx context.Context, probe *Probe) error {
+	cctx, cancel := p.context(ctx)
+	defer cancel()
+
+	servers, err := p.LookupNS(cctx, probe.Target)
+	if err != nil {
+		return fmt.Errorf("failed to obtain the nameservers: %v", err)
+	}
+	if len(servers) == 0 {
+		return errors.New("the domain has no nameservers")
+	}
+
+	var results []string
+	for _, ns := range servers {
+		results = append(results, ns+": "+p.transfer(ctx, probe.Target, ns))
+	}
+	probe.Detail = "AXFR requested from " + strings.Join(results, ", ")
+	return nil
+}
+
+// transfer requests the zone transfer from the nameserver, and returns the outcome.
+func (p *Prober) transfer(ctx context.Context, domain, ns string) string {
+	cctx, cancel := p.context(ctx)
+	defer cancel()
+
+	conn, err := p.Dial(cctx, "tcp", net.JoinHostPort(ns, "53"))
+	if err != nil {
+		return "not reached"
+	}
+	defer conn.Close()
+
+	if deadline, ok := cctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(domain))
+
+	t := &dns.Transfer{Conn: &dns.Conn{Conn: conn}}
+	envs, err := t.In(msg, "")
+	if err != nil {
+		return "refused"
+	}
+	for env := range envs {
+		if env.Error != nil {
+			return "refused"
+		}
+	}
+	return "allowed"
+}
+
+func (p *Prober) httpProbe(ctx context.Context, probe *Probe) error {
+	cctx, cancel := p.context(ctx)
+	defer cancel()
+
+	u := "https://" + net.JoinHostPort(probe.Target, strconv.Itoa(p.HTTPPort)) + "/" + probe.Token
+	probe.Detail = "GET " + u
+	req, err := http.NewRequestWithContext(cctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; "+probe.Token+")")
+	req.Header.Set("X-Amass-Canary", probe.Token)
+
+	client := amasshttp.NewClient("canary", &amasshttp.ClientOptions{
+		Timeout: p.Timeout,
+		Dial:    p.Dial,
+		// The probe only needs to reach the web server, which may use a self-signed certificate
+		Insecure:     true,
+		NoRedirects:  true,
+		NoKeepAlives: true,
+	})
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	probe.Detail += " (" + resp.Status + ")"
+	return nil
+}
+
+func (p *Prober) tlsSNI(ctx context.Context, probe *Probe) error {
+	cctx, cancel := p.context(ctx)
+	defer cancel()
+
+	sni := probe.Token + "." + probe.Target
+	if net.ParseIP(probe.Target) != nil {
+		sni = probe.Token + ".invalid"
+	}
+	probe.Detail = "TLS handshake with the SNI " + sni
+
+	conn, err := p.Dial(cctx, "tcp", net.JoinHostPort(probe.Target, strconv.Itoa(p.TLSPort)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c := tls.Client(conn, &tls.Config{ServerName: sni, InsecureSkipVerify: true})
+	// A handshake refused for the unknown name still reached the server
+	_ = c.HandshakeContext(cctx)
+	return nil
+}
+
+func (p *Prober) portScan(ctx context.Context, probe *Probe, ports []int) error {
+	var open, closed []string
+	for _, port := range ports {
+		cctx, cancel := p.context(ctx)
+		conn, err := p.Dial(cctx, "tcp", net.JoinHostPort(probe.Target, strconv.Itoa(port)))
+		cancel()
+		if err != nil {
+			closed = append(closed, strconv.Itoa(port))
+			continue
+		}
+		_ = conn.Close()
+		open = append(open, strconv.Itoa(port))
+	}
+
+	probe.Detail = fmt.Sprintf("connected to %d ports, open: %s, closed or filtered: %s",
+		len(ports), listOrNone(open), listOrNone(closed))
+	return nil
+}
+
+func listOrNone(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,189 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package classify tags hosts with their likely function, so the interesting
+// portions of the attack surface can be prioritized.
+package classify
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Tags assigned to the hosts.
+const (
+	TagAdmin   = "admin"
+	TagAPI     = "api"
+	TagApp     = "app"
+	TagDev     = "dev"
+	TagInfra   = "infra"
+	TagMail    = "mail"
+	TagStaging = "staging"
+	TagVPN     = "vpn"
+)
+
+var keywords = map[string][]string{
+	TagAdmin:   {"admin", "administrator", "cpanel", "console", "dashboard", "grafana", "jenkins", "kibana", "manage", "management", "phpmyadmin", "plesk", "portainer", "webmin"},
+	TagAPI:     {"api", "apis", "graphql", "grpc", "rest", "rpc", "soap", "ws"},
+	TagApp:     {"app", "apps", "portal", "shop", "sso", "store", "web", "www"},
+	TagDev:     {"dev", "develop", "development", "demo", "lab", "qa", "sandbox", "sbx", "test", "testing"},
+	TagInfra:   {"dc", "dns", "firewall", "fw", "gw", "kerberos", "lb", "ldap", "ns", "ntp", "proxy", "radius", "router", "snmp"},
+	TagMail:    {"autodiscover", "exchange", "imap", "mail", "mta", "mx", "owa", "pop", "pop3", "smtp", "webmail"},
+	TagStaging: {"preprod", "stage", "staging", "stg", "uat"},
+	TagVPN:     {"anyconnect", "citrix", "globalprotect", "netscaler", "openvpn", "pulse", "remote", "sslvpn", "vpn"},
+}
+
+var ports = map[int]string{
+	25:   TagMail,
+	53:   TagInfra,
+	88:   TagInfra,
+	110:  TagMail,
+	123:  TagInfra,
+	143:  TagMail,
+	161:  TagInfra,
+	389:  TagInfra,
+	465:  TagMail,
+	500:  TagVPN,
+	587:  TagMail,
+	636:  TagInfra,
+	993:  TagMail,
+	995:  TagMail,
+	1194: TagVPN,
+	1723: TagVPN,
+	4500: TagVPN,
+}
+
+var banners = map[string]string{
+	"esmtp":         TagMail,
+	"imap":          TagMail,
+	"pop3":          TagMail,
+	"smtp":          TagMail,
+	"anyconnect":    TagVPN,
+	"fortigate":     TagVPN,
+	"globalprotect": TagVPN,
+	"openvpn":       TagVPN,
+	"pulse secure":  TagVPN,
+	"jenkins":       TagAdmin,
+	"grafana":       TagAdmin,
+	"kibana":        TagAdmin,
+}
+
+var lookup = make(map[string]string)
+
+func init() {
+	for tag, words := range keywords {
+		for _, word := range words {
+			lookup[word] = tag
+		}
+	}
+}
+
+// Name returns the tags for the host based on the labels of the name.
+func Name(name string) []string {
+	return Host(name, nil)
+}
+
+// Host returns the tags for the host based on the labels of the name, the open ports,
+// and the banners returned by the services, when they are known.
+func Host(name string, openPorts []int, serviceBanners ...string) []string {
+	tags := make(map[string]struct{})
+
+	labels := strings.Split(strings.ToLower(strings.Trim(name, ".")), ".")
+	// The top-level domain does not describe the host
+	if len(labels) > 1 {
+		labels = labels[:len(labels)-1]
+	}
+	for _, label := range labels {
+		for _, token := range tokens(label) {
+			if tag, found := lookup[token]; found {
+				tags[tag] = struct{}{}
+			}
+		}
+	}
+
+	for _, port := range openPorts {
+		if tag, found := ports[port]; found {
+			tags[tag] = struct{}{}
+		}
+	}
+
+	for _, banner := range serviceBanners {
+		banner = strings.ToLower(banner)
+
+		for keyword, tag := range banners {
+			if strings.Contains(banner, keyword) {
+				tags[tag] = struct{}{}
+			}
+		}
+	}
+
+	var results []string
+	for tag := range tags {
+		results = append(results, tag)
+	}
+	sort.Strings(results)
+	return results
+}
+
+// Environment returns TagDev or TagStaging when the name appears to belong to a non-production
+// environment, along with the likely name of the production host. The labels of the provided
+// root domain name are not considered. An empty tag is returned for all other names.
+func Environment(name, domain string) (string, string) {
+	name = strings.ToLower(strings.Trim(name, "."))
+	domain = strings.ToLower(strings.Trim(domain, "."))
+	if domain == "" || name == domain || !strings.HasSuffix(name, "."+domain) {
+		return "", ""
+	}
+
+	var env string
+	var prod []string
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."+domain), ".") {
+		var parts []string
+
+		for _, part := range strings.Split(label, "-") {
+			word := strings.TrimRightFunc(part, unicode.IsDigit)
+
+			if tag := lookup[word]; tag == TagDev || tag == TagStaging {
+				// Staging is reported when both environments are indicated
+				if env != TagStaging {
+					env = tag
+				}
+				continue
+			}
+			parts = append(parts, part)
+		}
+		if len(parts) > 0 {
+			prod = append(prod, strings.Join(parts, "-"))
+		}
+	}
+	if env == "" {
+		return "", ""
+	}
+	return env, strings.Join(append(prod, domain), ".")
+}
+
+// Has returns true when the tag is in the provided tags.
+func Has(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tokens splits the label into the words separated by hyphens and digits,
+// such as "dev2-api" becoming "dev" and "api", and includes the whole label.
+func tokens(label string) []string {
+	results := []string{label}
+
+	words := strings.FieldsFunc(label, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) > 1 || (len(words) == 1 && words[0] != label) {
+		results = append(results, words...)
+	}
+	return results
+}
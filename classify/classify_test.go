@@ -0,0 +1,67 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package classify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"vpn.owasp.org", []string{TagVPN}},
+		{"mail01.owasp.org", []string{TagMail}},
+		{"dev2-api.owasp.org", []string{TagAPI, TagDev}},
+		{"api.staging.owasp.org", []string{TagAPI, TagStaging}},
+		{"ns1.owasp.org.", []string{TagInfra}},
+		{"WWW.OWASP.ORG", []string{TagApp}},
+		{"developers.owasp.org", nil},
+		{"owasp.mail", nil},
+	}
+
+	for _, test := range tests {
+		if got := Name(test.name); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestHost(t *testing.T) {
+	got := Host("host1.owasp.org", []int{443, 587}, "SSH-2.0-OpenSSH", "220 gw ESMTP Postfix")
+	if !reflect.DeepEqual(got, []string{TagMail}) {
+		t.Errorf("Expected the host to be tagged as mail, got %v", got)
+	}
+	if !Has(Host("host2.owasp.org", []int{1194}), TagVPN) {
+		t.Error("Expected the OpenVPN port to tag the host as vpn")
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		prod string
+	}{
+		{"dev-api.owasp.org", TagDev, "api.owasp.org"},
+		{"api.staging.owasp.org", TagStaging, "api.owasp.org"},
+		{"shop-uat2.eu.owasp.org", TagStaging, "shop.eu.owasp.org"},
+		{"stage.dev.owasp.org", TagStaging, "owasp.org"},
+		{"qa.owasp.org", TagDev, "owasp.org"},
+		{"www.owasp.org", "", ""},
+		{"developers.owasp.org", "", ""},
+		{"owasp.org", "", ""},
+		{"dev.example.com", "", ""},
+	}
+
+	for _, test := range tests {
+		env, prod := Environment(test.name, "owasp.org")
+		if env != test.env || prod != test.prod {
+			t.Errorf("%s: expected %q and %q, got %q and %q", test.name, test.env, test.prod, env, prod)
+		}
+	}
+}
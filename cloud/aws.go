@@ -0,0 +1,433 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	maxBodySize    = 16 * 1024 * 1024
+)
+
+// AWS collects the EC2 instances, Elastic IP addresses, internet-facing load balancers and Route 53 records
+// of the account, using the query and REST APIs signed with Signature Version 4. The IAM principal requires
+// the ec2:Describe*, elasticloadbalancing:DescribeLoadBalancers and route53:List* permissions, such as
+// provided by the ReadOnlyAccess policy.
+type AWS struct {
+	Settings *AWSSettings
+	HTTP     *http.Client
+	// Endpoint returns the base URL of the service in the region
+	Endpoint func(service, region string) string
+	now      func() time.Time
+}
+
+// NewAWS returns the AWS provider for the account of the access keys.
+func NewAWS(s *AWSSettings) *AWS {
+	return &AWS{
+		Settings: s,
+		HTTP: amasshttp.NewClient("aws", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+		Endpoint: awsEndpoint,
+		now:      time.Now,
+	}
+}
+
+func awsEndpoint(service, region string) string {
+	switch service {
+	case "sts":
+		return "https://sts.amazonaws.com"
+	case "route53":
+		return "https://route53.amazonaws.com"
+	}
+	return "https://" + service + "." + region + ".amazonaws.com"
+}
+
+// Name implements the Provider interface.
+func (a *AWS) Name() string {
+	return ProviderAWS
+}
+
+// Collect implements the Provider interface.
+func (a *AWS) Collect(ctx context.Context) ([]*Resource, error) {
+	var ident struct {
+		Account string `xml:"GetCallerIdentityResult>Account"`
+	}
+	if err := a.query(ctx, "sts", "us-east-1", url.Values{
+		"Action":  {"GetCallerIdentity"},
+		"Version": {"2011-06-15"},
+	}, &ident); err != nil {
+		return nil, fmt.Errorf("failed to identify the account: %v", err)
+	}
+
+	var msgs []string
+	var resources []*Resource
+	for _, region := range a.Settings.Regions {
+		for _, fn := range []func(context.Context, string, string) ([]*Resource, error){
+			a.instances, a.elasticIPs, a.loadBalancers,
+		} {
+			res, err := fn(ctx, ident.Account, region)
+			resources = append(resources, res...)
+			if err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s: %v", region, err))
+			}
+		}
+	}
+
+	res, err := a.records(ctx, ident.Account)
+	resources = append(resources, res...)
+	if err != nil {
+		msgs = append(msgs, fmt.Sprintf("route53: %v", err))
+	}
+
+	if len(msgs) > 0 {
+		return resources, errors.New(strings.Join(msgs, "; "))
+	}
+	return resources, nil
+}
+
+func (a *AWS) instances(ctx context.Context, account, region string) ([]*Resource, error) {
+	var resources []*Resource
+
+	params := url.Values{"Action": {"DescribeInstances"}, "Version": {"2016-11-15"}}
+	for {
+		var resp struct {
+			Reservations []struct {
+				Instances []struct {
+					ID      string   `xml:"instanceId"`
+					Address string   `xml:"ipAddress"`
+					DNSName string   `xml:"dnsName"`
+					IPv6    []string `xml:"networkInterfaceSet>item>ipv6AddressesSet>item>ipv6Address"`
+				} `xml:"instancesSet>item"`
+			} `xml:"reservationSet>item"`
+			NextToken string `xml:"nextToken"`
+		}
+		if err := a.query(ctx, "ec2", region, params, &resp); err != nil {
+			return resources, err
+		}
+
+		for _, rsv := range resp.Reservations {
+			for _, inst := range rsv.Instances {
+				r := &Resource{
+					Provider:  ProviderAWS,
+					Account:   account,
+					Region:    region,
+					Type:      "ec2_instance",
+					ID:        inst.ID,
+					Names:     nonEmpty(inst.DNSName),
+					Addresses: nonEmpty(append([]string{inst.Address}, inst.IPv6...)...),
+				}
+				if len(r.Names) > 0 || len(r.Addresses) > 0 {
+					resources = append(resources, r)
+				}
+			}
+		}
+		if resp.NextToken == "" {
+			return resources, nil
+		}
+		params.Set("NextToken", resp.NextToken)
+	}
+}
+
+func (a *AWS) elasticIPs(ctx context.Context, account, region string) ([]*Resource, error) {
+	var resp struct {
+		Addresses []struct {
+			Address      string `xml:"publicIp"`
+			AllocationID string `xml:"allocationId"`
+		} `xml:"addressesSet>item"`
+	}
+	if err := a.query(ctx, "ec2", region, url.Values{
+		"Action":  {"DescribeAddresses"},
+		"Version": {"2016-11-15"},
+	}, &resp); err != nil {
+		return nil, err
+	}
+
+	var resources []*Resource
+	for _, addr := range resp.Addresses {
+		resources = append(resources, &Resource{
+			Provider:  ProviderAWS,
+			Account:   account,
+			Region:    region,
+			Type:      "elastic_ip",
+			ID:        addr.AllocationID,
+			Addresses: nonEmpty(addr.Address),
+		})
+	}
+	return resources, nil
+}
+
+func (a *AWS) loadBalancers(ctx context.Context, account, region string) ([]*Resource, error) {
+	var resources []*Resource
+
+	params := url.Values{"Action": {"DescribeLoadBalancers"}, "Version": {"2015-12-01"}}
+	for {
+		var resp struct {
+			LoadBalancers []struct {
+				ARN     string `xml:"LoadBalancerArn"`
+				DNSName string `xml:"DNSName"`
+				Scheme  string `xml:"Scheme"`
+			} `xml:"DescribeLoadBalancersResult>LoadBalancers>member"`
+			NextMarker string `xml:"DescribeLoadBalancersResult>NextMarker"`
+		}
+		if err := a.query(ctx, "elasticloadbalancing", region, params, &resp); err != nil {
+			return resources, err
+		}
+
+		for _, lb := range resp.LoadBalancers {
+			if lb.Scheme != "internet-facing" {
+				continue
+			}
+			resources = append(resources, &Resource{
+				Provider: ProviderAWS,
+				Account:  account,
+				Region:   region,
+				Type:     "load_balancer",
+				ID:       lb.ARN,
+				Names:    nonEmpty(lb.DNSName),
+			})
+		}
+		if resp.NextMarker == "" {
+			return resources, nil
+		}
+		params.Set("Marker", resp.NextMarker)
+	}
+}
+
+// records returns the A, AAAA and CNAME records of the public hosted zones.
+func (a *AWS) records(ctx context.Context, account string) ([]*Resource, error) {
+	var zones []string
+
+	params := url.Values{}
+	for {
+		var resp struct {
+			Zones []struct {
+				ID      string `xml:"Id"`
+				Private bool   `xml:"Config>PrivateZone"`
+			} `xml:"HostedZones>HostedZone"`
+			IsTruncated bool   `xml:"IsTruncated"`
+			NextMarker  string `xml:"NextMarker"`
+		}
+		if err := a.rest(ctx, "route53", "/2013-04-01/hostedzone", params, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, z := range resp.Zones {
+			if !z.Private {
+				zones = append(zones, z.ID)
+			}
+		}
+		if !resp.IsTruncated {
+			break
+		}
+		params.Set("marker", resp.NextMarker)
+	}
+
+	var resources []*Resource
+	for _, zone := range zones {
+		params := url.Values{}
+		for {
+			var resp struct {
+				Records []struct {
+					Name   string   `xml:"Name"`
+					Type   string   `xml:"Type"`
+					Values []string `xml:"ResourceRecords>ResourceRecord>Value"`
+					Alias  string   `xml:"AliasTarget>DNSName"`
+				} `xml:"ResourceRecordSets>ResourceRecordSet"`
+				IsTruncated    bool   `xml:"IsTruncated"`
+				NextRecordName string `xml:"NextRecordName"`
+				NextRecordType string `xml:"NextRecordType"`
+			}
+			if err := a.rest(ctx, "route53", "/2013-04-01"+zone+"/rrset", params, &resp); err != nil {
+				return resources, err
+			}
+
+			for _, rec := range resp.Records {
+				name := strings.TrimSuffix(strings.ToLower(rec.Name), ".")
+				// The wildcard records are escaped by Route 53
+				if strings.HasPrefix(name, `\052`) {
+					continue
+				}
+
+				r := &Resource{
+					Provider: ProviderAWS,
+					Account:  account,
+					Type:     "dns_record",
+					ID:       strings.TrimPrefix(zone, "/hostedzone/") + "/" + name + "/" + rec.Type,
+					Names:    []string{name},
+				}
+				switch rec.Type {
+				case "A", "AAAA":
+					if rec.Alias == "" {
+						r.Addresses = nonEmpty(rec.Values...)
+					}
+				case "CNAME":
+				default:
+					continue
+				}
+				resources = append(resources, r)
+			}
+			if !resp.IsTruncated {
+				break
+			}
+			params.Set("name", resp.NextRecordName)
+			params.Set("type", resp.NextRecordType)
+		}
+	}
+	return resources, nil
+}
+
+// query sends the request of the query API, such as the EC2 API, and decodes the XML response into v.
+func (a *AWS) query(ctx context.Context, service, region string, params url.Values, v interface{}) error {
+	return a.send(ctx, service, region, a.Endpoint(service, region)+"/?"+params.Encode(), v)
+}
+
+// rest sends the request of the REST API, such as the Route 53 API, and decodes the XML response into v.
+func (a *AWS) rest(ctx context.Context, service, path string, params url.Values, v interface{}) error {
+	u := a.Endpoint(service, "us-east-1") + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return a.send(ctx, service, "us-east-1", u, v)
+}
+
+var awsMessage = regexp.MustCompile(`<Message>([^<]*)</Message>`)
+
+func (a *AWS) send(ctx context.Context, service, region, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	a.sign(req, service, region, a.now())
+
+	resp, err := a.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if m := awsMessage.FindSubmatch(body); m != nil {
+			return fmt.Errorf("%s returned status %d: %s", service, resp.StatusCode, m[1])
+		}
+		return fmt.Errorf("%s returned status %d", service, resp.StatusCode)
+	}
+	return xml.Unmarshal(body, v)
+}
+
+// sign adds the Signature Version 4 authorization of the request without a body.
+func (a *AWS) sign(req *http.Request, service, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.Settings.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.Settings.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key := range req.Header {
+		if lower := strings.ToLower(key); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(key))
+		}
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, name := range names {
+		canonHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payload := sha256.Sum256(nil)
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signed,
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + a.Settings.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.Settings.AccessKeyID, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+func canonicalQuery(values url.Values) string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		vals := append([]string{}, values[key]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func nonEmpty(values ...string) []string {
+	var results []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			results = append(results, v)
+		}
+	}
+	return results
+}
@@ -0,0 +1,292 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// Azure collects the public IP addresses and the records of the public DNS zones of the subscriptions, using
+// the Azure Resource Manager API with the client credentials of a service principal with the Reader role.
+type Azure struct {
+	Settings *AzureSettings
+	HTTP     *http.Client
+	// LoginURL and ManagementURL are the base URLs of the identity platform and the Resource Manager API
+	LoginURL      string
+	ManagementURL string
+}
+
+// NewAzure returns the Azure provider for the subscriptions of the service principal.
+func NewAzure(s *AzureSettings) *Azure {
+	return &Azure{
+		Settings: s,
+		HTTP: amasshttp.NewClient("azure", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+		LoginURL:      "https://login.microsoftonline.com",
+		ManagementURL: "https://management.azure.com",
+	}
+}
+
+// Name implements the Provider interface.
+func (az *Azure) Name() string {
+	return ProviderAzure
+}
+
+// Collect implements the Provider interface.
+func (az *Azure) Collect(ctx context.Context) ([]*Resource, error) {
+	token, err := az.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain the access token: %v", err)
+	}
+
+	subs := az.Settings.Subscriptions
+	if len(subs) == 0 {
+		err := az.list(ctx, token, "/subscriptions?api-version=2020-01-01", func(raw json.RawMessage) error {
+			var sub struct {
+				ID string `json:"subscriptionId"`
+			}
+			if err := json.Unmarshal(raw, &sub); err != nil {
+				return err
+			}
+			subs = append(subs, sub.ID)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the subscriptions: %v", err)
+		}
+	}
+
+	var msgs []string
+	var resources []*Resource
+	for _, sub := range subs {
+		res, err := az.publicIPs(ctx, token, sub)
+		resources = append(resources, res...)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", sub, err))
+		}
+
+		res, err = az.records(ctx, token, sub)
+		resources = append(resources, res...)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", sub, err))
+		}
+	}
+
+	if len(msgs) > 0 {
+		return resources, errors.New(strings.Join(msgs, "; "))
+	}
+	return resources, nil
+}
+
+func (az *Azure) token(ctx context.Context) (string, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {az.Settings.ClientID},
+		"client_secret": {az.Settings.ClientSecret},
+		"scope":         {az.ManagementURL + "/.default"},
+	}
+	return requestToken(ctx, az.HTTP, az.LoginURL+"/"+url.PathEscape(az.Settings.TenantID)+"/oauth2/v2.0/token", form)
+}
+
+func (az *Azure) publicIPs(ctx context.Context, token, sub string) ([]*Resource, error) {
+	var resources []*Resource
+
+	path := "/subscriptions/" + url.PathEscape(sub) + "/providers/Microsoft.Network/publicIPAddresses?api-version=2023-04-01"
+	err := az.list(ctx, token, path, func(raw json.RawMessage) error {
+		var ip struct {
+			ID       string `json:"id"`
+			Location string `json:"location"`
+			Props    struct {
+				Address string `json:"ipAddress"`
+				DNS     struct {
+					FQDN string `json:"fqdn"`
+				} `json:"dnsSettings"`
+			} `json:"properties"`
+		}
+		if err := json.Unmarshal(raw, &ip); err != nil {
+			return err
+		}
+
+		r := &Resource{
+			Provider:  ProviderAzure,
+			Account:   sub,
+			Region:    ip.Location,
+			Type:      "public_ip",
+			ID:        ip.ID,
+			Names:     nonEmpty(ip.Props.DNS.FQDN),
+			Addresses: nonEmpty(ip.Props.Address),
+		}
+		// The dynamic addresses are not allocated while the resources are stopped
+		if len(r.Names) > 0 || len(r.Addresses) > 0 {
+			resources = append(resources, r)
+		}
+		return nil
+	})
+	return resources, err
+}
+
+// records returns the A, AAAA and CNAME records of the public DNS zones.
+func (az *Azure) records(ctx context.Context, token, sub string) ([]*Resource, error) {
+	var zones []string
+
+	path := "/subscriptions/" + url.PathEscape(sub) + "/providers/Microsoft.Network/dnszones?api-version=2018-05-01"
+	if err := az.list(ctx, token, path, func(raw json.RawMessage) error {
+		var zone struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &zone); err != nil {
+			return err
+		}
+		zones = append(zones, zone.ID)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var resources []*Resource
+	for _, zone := range zones {
+		err := az.list(ctx, token, zone+"/recordsets?api-version=2018-05-01", func(raw json.RawMessage) error {
+			var set struct {
+				ID    string `json:"id"`
+				Type  string `json:"type"`
+				Props struct {
+					FQDN string `json:"fqdn"`
+					A    []struct {
+						Address string `json:"ipv4Address"`
+					} `json:"ARecords"`
+					AAAA []struct {
+						Address string `json:"ipv6Address"`
+					} `json:"AAAARecords"`
+					CNAME *struct {
+						Name string `json:"cname"`
+					} `json:"CNAMERecord"`
+				} `json:"properties"`
+			}
+			if err := json.Unmarshal(raw, &set); err != nil {
+				return err
+			}
+
+			name := strings.TrimSuffix(strings.ToLower(set.Props.FQDN), ".")
+			if strings.HasPrefix(name, "*.") {
+				return nil
+			}
+			r := &Resource{
+				Provider: ProviderAzure,
+				Account:  sub,
+				Type:     "dns_record",
+				ID:       set.ID,
+				Names:    []string{name},
+			}
+			for _, a := range set.Props.A {
+				r.Addresses = append(r.Addresses, nonEmpty(a.Address)...)
+			}
+			for _, a := range set.Props.AAAA {
+				r.Addresses = append(r.Addresses, nonEmpty(a.Address)...)
+			}
+			if len(r.Addresses) > 0 || set.Props.CNAME != nil {
+				resources = append(resources, r)
+			}
+			return nil
+		})
+		if err != nil {
+			return resources, err
+		}
+	}
+	return resources, nil
+}
+
+// list calls fn for each value of the collection at the path, following the next links.
+func (az *Azure) list(ctx context.Context, token, path string, fn func(json.RawMessage) error) error {
+	u := az.ManagementURL + path
+	for u != "" {
+		var page struct {
+			Values   []json.RawMessage `json:"value"`
+			NextLink string            `json:"nextLink"`
+		}
+		if err := getJSON(ctx, az.HTTP, u, token, &page); err != nil {
+			return err
+		}
+
+		for _, v := range page.Values {
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+		u = page.NextLink
+	}
+	return nil
+}
+
+// requestToken returns the access token granted by the OAuth 2.0 token endpoint for the form.
+func requestToken(ctx context.Context, client *http.Client, endpoint string, form url.Values) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&tok); err != nil {
+		return "", fmt.Errorf("the token endpoint returned status %d", resp.StatusCode)
+	}
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("the token endpoint returned status %d: %s %s", resp.StatusCode, tok.Error, tok.Description)
+	}
+	return tok.AccessToken, nil
+}
+
+// getJSON decodes the JSON response of the API authorized by the bearer token into v.
+func getJSON(ctx context.Context, client *http.Client, u, token string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Error.Message != "" {
+			return fmt.Errorf("the API returned status %d: %s", resp.StatusCode, e.Error.Message)
+		}
+		return fmt.Errorf("the API returned status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, v)
+}
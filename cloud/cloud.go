@@ -0,0 +1,318 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cloud reconciles the externally discovered assets against the inventory of the cloud accounts of
+// the user. The inventory is collected using read-only credentials supplied by the user, and the reconciliation
+// flags the in-scope assets hosted by the providers that are missing from the accounts, and the resources of the
+// accounts exposed to the internet that are not part of the known external attack surface.
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+// FileName is the name of the file in the output directory that stores the last inventory collected.
+const FileName = "cloud_inventory.json"
+
+// The providers of the cloud accounts.
+const (
+	ProviderAWS   = "aws"
+	ProviderAzure = "azure"
+	ProviderGCP   = "gcp"
+)
+
+// Types of the findings recorded by the reconciliation.
+const (
+	// TypeUnmanaged is the type of the findings recorded for the in-scope assets hosted by a provider of the
+	// connected accounts, that are missing from the inventory of the accounts
+	TypeUnmanaged = "unmanaged_cloud_asset"
+	// TypeUnknownExposure is the type of the findings recorded for the resources of the accounts exposed to the
+	// internet, that were not discovered by the enumerations
+	TypeUnknownExposure = "unknown_cloud_exposure"
+)
+
+// Resource is a resource of a cloud account providing public addresses or DNS names.
+type Resource struct {
+	Provider string `json:"provider"`
+	// Account is the AWS account, the Azure subscription or the GCP project
+	Account string `json:"account"`
+	Region  string `json:"region,omitempty"`
+	// Type is the type of the resource, such as ec2_instance or dns_record
+	Type      string   `json:"type"`
+	ID        string   `json:"id"`
+	Names     []string `json:"names,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// Inventory is the set of resources collected from the cloud accounts.
+type Inventory struct {
+	Collected time.Time `json:"collected"`
+	// Providers are the providers of the connected accounts
+	Providers []string    `json:"providers"`
+	Resources []*Resource `json:"resources"`
+}
+
+// ReadInventory returns the inventory stored in the file.
+func ReadInventory(path string) (*Inventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := new(Inventory)
+	if err := json.Unmarshal(data, inv); err != nil {
+		return nil, fmt.Errorf("failed to parse the cloud inventory %s: %v", path, err)
+	}
+	return inv, nil
+}
+
+// Write stores the inventory in the file.
+func (inv *Inventory) Write(path string) error {
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Provider collects the resources of the cloud accounts reachable with the credentials.
+type Provider interface {
+	// Name returns the name of the provider, such as aws.
+	Name() string
+	// Collect returns the resources providing public addresses or DNS names, along with the errors
+	// of the accounts or regions that could not be collected.
+	Collect(ctx context.Context) ([]*Resource, error)
+}
+
+// Collect returns the inventory of the providers. The errors of the providers are returned along with the
+// inventory. The providers that failed are not included in the Providers of the inventory, since their
+// resources may be incomplete, but the resources collected from them are kept.
+func Collect(ctx context.Context, providers []Provider) (*Inventory, error) {
+	inv := &Inventory{Collected: time.Now().UTC()}
+
+	var msgs []string
+	for _, p := range providers {
+		res, err := p.Collect(ctx)
+		inv.Resources = append(inv.Resources, res...)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", p.Name(), err))
+			continue
+		}
+		inv.Providers = append(inv.Providers, p.Name())
+	}
+
+	if len(msgs) > 0 {
+		return inv, errors.New(strings.Join(msgs, "; "))
+	}
+	return inv, nil
+}
+
+// External is an in-scope name discovered by the enumerations, with the aliases followed to its addresses.
+type External struct {
+	Name      string
+	Aliases   []string
+	Addresses []string
+}
+
+// Issue is an asset flagged by the reconciliation.
+type Issue struct {
+	Type string
+	// Asset is the name or address of the asset
+	Asset    string
+	Provider string
+	// Resource is the resource of the accounts exposing the asset, for the unknown exposures
+	Resource *Resource
+	// External is the discovered name of the unmanaged assets
+	External *External
+}
+
+// Finding returns the finding recorded for the issue.
+func (i *Issue) Finding() *findings.Finding {
+	attrs := map[string]string{"provider": i.Provider}
+
+	f := &findings.Finding{
+		Asset:      i.Asset,
+		Type:       i.Type,
+		Attributes: attrs,
+	}
+	switch i.Type {
+	case TypeUnmanaged:
+		f.Severity = findings.SeverityHigh
+		f.Title = "Hosted by " + i.Provider + " but missing from the connected accounts"
+		attrs["addresses"] = strings.Join(i.External.Addresses, ",")
+		if len(i.External.Aliases) > 0 {
+			attrs["aliases"] = strings.Join(i.External.Aliases, ",")
+		}
+	case TypeUnknownExposure:
+		f.Severity = findings.SeverityMedium
+		f.Title = "Exposed by the " + i.Provider + " " + i.Resource.Type + " " + i.Resource.ID + " but not discovered externally"
+		attrs["account"] = i.Resource.Account
+		attrs["resource_type"] = i.Resource.Type
+		attrs["resource"] = i.Resource.ID
+		if i.Resource.Region != "" {
+			attrs["region"] = i.Resource.Region
+		}
+	}
+	return f
+}
+
+// Reconciliation is the outcome of reconciling the discovered assets against the inventory.
+type Reconciliation struct {
+	// Matched are the discovered names found in the inventory
+	Matched []*External
+	Issues  []*Issue
+}
+
+// hostSuffixes identify the names of the resources hosted by the providers, which the names are aliases of.
+var hostSuffixes = map[string][]string{
+	ProviderAWS: {".amazonaws.com", ".cloudfront.net", ".awsglobalaccelerator.com", ".elasticbeanstalk.com"},
+	ProviderAzure: {".cloudapp.azure.com", ".cloudapp.net", ".azurewebsites.net", ".trafficmanager.net",
+		".azureedge.net", ".azurefd.net", ".core.windows.net", ".azurecontainer.io"},
+	ProviderGCP: {".googleusercontent.com", ".appspot.com", ".run.app", ".cloudfunctions.net", ".storage.googleapis.com"},
+}
+
+// HostedBy returns the provider hosting the name, based on the suffixes of the provider domains.
+func HostedBy(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for provider, suffixes := range hostSuffixes {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(name, suffix) {
+				return provider
+			}
+		}
+	}
+	return ""
+}
+
+// Reconcile compares the discovered names against the inventory. A discovered name is matched when the
+// inventory holds the name, one of its aliases or addresses. Otherwise, it is unmanaged when an alias or an
+// address is hosted by a provider of the inventory, according to the hosted map of the addresses to their
+// providers. The resources of the inventory providing none of the discovered names, aliases and addresses
+// are unknown exposures.
+func Reconcile(inv *Inventory, ext []*External, hosted map[string]string) *Reconciliation {
+	connected := make(map[string]struct{})
+	for _, p := range inv.Providers {
+		connected[p] = struct{}{}
+	}
+
+	inventory := make(map[string]struct{})
+	for _, r := range inv.Resources {
+		for _, v := range values(r) {
+			inventory[v] = struct{}{}
+		}
+	}
+
+	rec := new(Reconciliation)
+	discovered := make(map[string]struct{})
+	for _, e := range ext {
+		keys := append(append([]string{e.Name}, e.Aliases...), e.Addresses...)
+
+		var matched bool
+		for _, k := range keys {
+			k = normalize(k)
+			discovered[k] = struct{}{}
+			if _, found := inventory[k]; found {
+				matched = true
+			}
+		}
+		if matched {
+			rec.Matched = append(rec.Matched, e)
+			continue
+		}
+
+		if provider := hostedBy(e, hosted); provider != "" {
+			if _, found := connected[provider]; found {
+				rec.Issues = append(rec.Issues, &Issue{
+					Type:     TypeUnmanaged,
+					Asset:    e.Name,
+					Provider: provider,
+					External: e,
+				})
+			}
+		}
+	}
+
+	for _, r := range inv.Resources {
+		var found bool
+		for _, v := range values(r) {
+			if _, ok := discovered[v]; ok {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		for _, v := range values(r) {
+			// Private addresses are not exposed to the internet
+			if ip := net.ParseIP(v); ip != nil && (ip.IsPrivate() || ip.IsLoopback()) {
+				continue
+			}
+			rec.Issues = append(rec.Issues, &Issue{
+				Type:     TypeUnknownExposure,
+				Asset:    v,
+				Provider: r.Provider,
+				Resource: r,
+			})
+			break
+		}
+	}
+
+	sort.SliceStable(rec.Issues, func(i, j int) bool {
+		if rec.Issues[i].Type != rec.Issues[j].Type {
+			return rec.Issues[i].Type < rec.Issues[j].Type
+		}
+		return rec.Issues[i].Asset < rec.Issues[j].Asset
+	})
+	return rec
+}
+
+func hostedBy(e *External, hosted map[string]string) string {
+	for _, alias := range e.Aliases {
+		if provider := HostedBy(alias); provider != "" {
+			return provider
+		}
+	}
+	for _, addr := range e.Addresses {
+		if provider, found := hosted[normalize(addr)]; found {
+			return provider
+		}
+	}
+	return ""
+}
+
+// values returns the names and addresses of the resource.
+func values(r *Resource) []string {
+	var results []string
+	for _, v := range append(append([]string{}, r.Names...), r.Addresses...) {
+		if v = normalize(v); v != "" {
+			results = append(results, v)
+		}
+	}
+	return results
+}
+
+func normalize(v string) string {
+	v = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(v), "."))
+	if ip := net.ParseIP(v); ip != nil {
+		return ip.String()
+	}
+	return v
+}
@@ -0,0 +1,155 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings(map[string]interface{}{
+		"aws": map[string]interface{}{
+			"access_key_id":     "AKIDEXAMPLE",
+			"secret_access_key": "secret",
+			"regions":           []interface{}{"us-east-1"},
+		},
+		"azure": map[string]interface{}{
+			"tenant_id":     "tenant",
+			"client_id":     "client",
+			"client_secret": "secret",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the settings: %v", err)
+	}
+	if s.AWS == nil || len(s.AWS.Regions) != 1 || s.Azure == nil || s.GCP != nil {
+		t.Errorf("Unexpected settings: %+v", s)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	if s, err := ParseSettings(map[string]interface{}{"aws": nil}); err != nil || s.AWS.AccessKeyID != "AKIDEXAMPLE" ||
+		len(s.AWS.Regions) != len(DefaultAWSRegions) {
+		t.Errorf("Expected the credentials of the environment: %+v %v", s, err)
+	}
+
+	for _, raw := range []interface{}{
+		true,
+		map[string]interface{}{"oracle": map[string]interface{}{}},
+		map[string]interface{}{"azure": map[string]interface{}{"tenant_id": "tenant"}},
+		map[string]interface{}{"aws": map[string]interface{}{"regions": "us-east-1"}},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	inv := &Inventory{
+		Providers: []string{ProviderAWS},
+		Resources: []*Resource{
+			{Provider: ProviderAWS, Type: "elastic_ip", ID: "eipalloc-1", Addresses: []string{"203.0.113.10"}},
+			{Provider: ProviderAWS, Type: "load_balancer", ID: "lb-1", Names: []string{"lb-1.us-east-1.elb.amazonaws.com"}},
+			{Provider: ProviderAWS, Type: "ec2_instance", ID: "i-1", Addresses: []string{"203.0.113.20"}},
+			{Provider: ProviderAWS, Type: "ec2_instance", ID: "i-2", Addresses: []string{"10.0.0.5"}},
+		},
+	}
+	ext := []*External{
+		{Name: "www.example.com", Addresses: []string{"203.0.113.10"}},
+		{Name: "api.example.com", Aliases: []string{"LB-1.us-east-1.elb.amazonaws.com."}, Addresses: []string{"198.51.100.1"}},
+		{Name: "old.example.com", Aliases: []string{"old-bucket.s3.amazonaws.com"}, Addresses: []string{"198.51.100.2"}},
+		{Name: "vm.example.com", Addresses: []string{"198.51.100.3"}},
+		{Name: "app.example.com", Aliases: []string{"app.azurewebsites.net"}, Addresses: []string{"198.51.100.4"}},
+	}
+
+	rec := Reconcile(inv, ext, map[string]string{"198.51.100.3": ProviderAWS})
+	if len(rec.Matched) != 2 {
+		t.Errorf("Expected 2 matched names, got %d", len(rec.Matched))
+	}
+
+	var got []string
+	for _, issue := range rec.Issues {
+		got = append(got, issue.Type+" "+issue.Asset)
+	}
+	want := []string{
+		TypeUnknownExposure + " 203.0.113.20",
+		TypeUnmanaged + " old.example.com",
+		TypeUnmanaged + " vm.example.com",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected the issues %v, got %v", want, got)
+	}
+
+	if f := rec.Issues[0].Finding(); f.Attributes["resource"] != "i-1" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+}
+
+func TestAWSCollect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch {
+		case r.URL.Query().Get("Action") == "GetCallerIdentity":
+			fmt.Fprint(w, `<GetCallerIdentityResponse><GetCallerIdentityResult><Account>123456789012</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`)
+		case r.URL.Query().Get("Action") == "DescribeInstances":
+			fmt.Fprint(w, `<DescribeInstancesResponse><reservationSet><item><instancesSet><item><instanceId>i-1</instanceId><ipAddress>203.0.113.20</ipAddress></item><item><instanceId>i-2</instanceId></item></instancesSet></item></reservationSet></DescribeInstancesResponse>`)
+		case r.URL.Query().Get("Action") == "DescribeAddresses":
+			fmt.Fprint(w, `<DescribeAddressesResponse><addressesSet><item><publicIp>203.0.113.10</publicIp><allocationId>eipalloc-1</allocationId></item></addressesSet></DescribeAddressesResponse>`)
+		case r.URL.Query().Get("Action") == "DescribeLoadBalancers":
+			fmt.Fprint(w, `<DescribeLoadBalancersResponse><DescribeLoadBalancersResult><LoadBalancers><member><LoadBalancerArn>arn:lb-1</LoadBalancerArn><DNSName>lb-1.elb.amazonaws.com</DNSName><Scheme>internet-facing</Scheme></member><member><LoadBalancerArn>arn:lb-2</LoadBalancerArn><Scheme>internal</Scheme></member></LoadBalancers></DescribeLoadBalancersResult></DescribeLoadBalancersResponse>`)
+		case r.URL.Path == "/2013-04-01/hostedzone":
+			fmt.Fprint(w, `<ListHostedZonesResponse><HostedZones><HostedZone><Id>/hostedzone/Z1</Id><Config><PrivateZone>false</PrivateZone></Config></HostedZone><HostedZone><Id>/hostedzone/Z2</Id><Config><PrivateZone>true</PrivateZone></Config></HostedZone></HostedZones><IsTruncated>false</IsTruncated></ListHostedZonesResponse>`)
+		case r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset":
+			fmt.Fprint(w, `<ListResourceRecordSetsResponse><ResourceRecordSets><ResourceRecordSet><Name>www.example.com.</Name><Type>A</Type><ResourceRecords><ResourceRecord><Value>203.0.113.10</Value></ResourceRecord></ResourceRecords></ResourceRecordSet><ResourceRecordSet><Name>example.com.</Name><Type>MX</Type></ResourceRecordSet><ResourceRecordSet><Name>\052.example.com.</Name><Type>A</Type></ResourceRecordSet></ResourceRecordSets><IsTruncated>false</IsTruncated></ListResourceRecordSetsResponse>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	a := NewAWS(&AWSSettings{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", Regions: []string{"us-east-1"}})
+	a.Endpoint = func(string, string) string { return ts.URL }
+
+	res, err := a.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to collect the resources: %v", err)
+	}
+
+	var got []string
+	for _, r := range res {
+		if r.Account != "123456789012" {
+			t.Errorf("Unexpected account of %s: %s", r.ID, r.Account)
+		}
+		got = append(got, r.Type+" "+r.ID)
+	}
+	want := []string{"ec2_instance i-1", "elastic_ip eipalloc-1", "load_balancer arn:lb-1", "dns_record Z1/www.example.com/A"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected the resources %v, got %v", want, got)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// The get-vanilla-query request of the Signature Version 4 test suite
+	a := NewAWS(&AWSSettings{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"})
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/?Param2=value2&Param1=value1", nil)
+	a.sign(req, "service", "us-east-1", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// gcpScope is the scope of the access tokens requested for the service account.
+const gcpScope = "https://www.googleapis.com/auth/cloud-platform.read-only"
+
+// GCP collects the external addresses, the external addresses of the instances and the records of the
+// public Cloud DNS zones of the projects, using the key of a service account with the Viewer role.
+type GCP struct {
+	Settings *GCPSettings
+	HTTP     *http.Client
+	// ComputeURL and DNSURL are the base URLs of the Compute Engine and Cloud DNS APIs
+	ComputeURL string
+	DNSURL     string
+	email      string
+	key        *rsa.PrivateKey
+	tokenURI   string
+	now        func() time.Time
+}
+
+// NewGCP returns the GCP provider for the projects of the service account key.
+func NewGCP(s *GCPSettings) (*GCP, error) {
+	data, err := os.ReadFile(s.Credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the gcp credentials: %v", err)
+	}
+
+	var cred struct {
+		Type       string `json:"type"`
+		Email      string `json:"client_email"`
+		PrivateKey string `json:"private_key"`
+		TokenURI   string `json:"token_uri"`
+		ProjectID  string `json:"project_id"`
+	}
+	if err := json.Unmarshal(data, &cred); err != nil {
+		return nil, fmt.Errorf("failed to parse the gcp credentials %s: %v", s.Credentials, err)
+	}
+	if cred.Type != "service_account" || cred.Email == "" {
+		return nil, fmt.Errorf("the gcp credentials %s are not the key of a service account", s.Credentials)
+	}
+
+	key, err := parseRSAKey(cred.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key of %s: %v", cred.Email, err)
+	}
+
+	settings := *s
+	if len(settings.Projects) == 0 && cred.ProjectID != "" {
+		settings.Projects = []string{cred.ProjectID}
+	}
+	if cred.TokenURI == "" {
+		cred.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	return &GCP{
+		Settings: &settings,
+		HTTP: amasshttp.NewClient("gcp", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+		ComputeURL: "https://compute.googleapis.com/compute/v1",
+		DNSURL:     "https://dns.googleapis.com/dns/v1",
+		email:      cred.Email,
+		key:        key,
+		tokenURI:   cred.TokenURI,
+		now:        time.Now,
+	}, nil
+}
+
+func parseRSAKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("the key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Name implements the Provider interface.
+func (g *GCP) Name() string {
+	return ProviderGCP
+}
+
+// Collect implements the Provider interface.
+func (g *GCP) Collect(ctx context.Context) ([]*Resource, error) {
+	if len(g.Settings.Projects) == 0 {
+		return nil, errors.New("no projects were provided and the service account key does not name one")
+	}
+
+	token, err := g.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain the access token: %v", err)
+	}
+
+	var msgs []string
+	var resources []*Resource
+	for _, project := range g.Settings.Projects {
+		for _, collect := range []func(context.Context, string, string) ([]*Resource, error){
+			g.addresses, g.instances, g.records,
+		} {
+			res, err := collect(ctx, token, project)
+			resources = append(resources, res...)
+			if err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s: %v", project, err))
+			}
+		}
+	}
+
+	if len(msgs) > 0 {
+		return resources, errors.New(strings.Join(msgs, "; "))
+	}
+	return resources, nil
+}
+
+// token exchanges an assertion signed by the service account key for an access token.
+func (g *GCP) token(ctx context.Context) (string, error) {
+	now := g.now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   g.email,
+		"scope": gcpScope,
+		"aud":   g.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, g.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return requestToken(ctx, g.HTTP, g.tokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)},
+	})
+}
+
+// addresses returns the external static addresses reserved in the project.
+func (g *GCP) addresses(ctx context.Context, token, project string) ([]*Resource, error) {
+	var resources []*Resource
+
+	u := g.ComputeURL + "/projects/" + url.PathEscape(project) + "/aggregated/addresses"
+	err := g.list(ctx, token, u, "items", func(page json.RawMessage) error {
+		var agg map[string]struct {
+			Addresses []struct {
+				Name    string `json:"name"`
+				Address string `json:"address"`
+				Type    string `json:"addressType"`
+			} `json:"addresses"`
+		}
+		if err := json.Unmarshal(page, &agg); err != nil {
+			return err
+		}
+
+		for scope, list := range agg {
+			for _, a := range list.Addresses {
+				if a.Type == "INTERNAL" || a.Address == "" {
+					continue
+				}
+				resources = append(resources, &Resource{
+					Provider:  ProviderGCP,
+					Account:   project,
+					Region:    region(scope),
+					Type:      "external_address",
+					ID:        a.Name,
+					Addresses: []string{a.Address},
+				})
+			}
+		}
+		return nil
+	})
+	return resources, err
+}
+
+// instances returns the instances with external addresses in their access configurations.
+func (g *GCP) instances(ctx context.Context, token, project string) ([]*Resource, error) {
+	var resources []*Resource
+
+	u := g.ComputeURL + "/projects/" + url.PathEscape(project) + "/aggregated/instances"
+	err := g.list(ctx, token, u, "items", func(page json.RawMessage) error {
+		var agg map[string]struct {
+			Instances []struct {
+				Name     string `json:"name"`
+				Networks []struct {
+					Access []struct {
+						NatIP string `json:"natIP"`
+					} `json:"accessConfigs"`
+				} `json:"networkInterfaces"`
+			} `json:"instances"`
+		}
+		if err := json.Unmarshal(page, &agg); err != nil {
+			return err
+		}
+
+		for scope, list := range agg {
+			for _, inst := range list.Instances {
+				r := &Resource{
+					Provider: ProviderGCP,
+					Account:  project,
+					Region:   region(scope),
+					Type:     "compute_instance",
+					ID:       inst.Name,
+				}
+				for _, nic := range inst.Networks {
+					for _, ac := range nic.Access {
+						r.Addresses = append(r.Addresses, nonEmpty(ac.NatIP)...)
+					}
+				}
+				if len(r.Addresses) > 0 {
+					resources = append(resources, r)
+				}
+			}
+		}
+		return nil
+	})
+	return resources, err
+}
+
+// records returns the A, AAAA and CNAME records of the public Cloud DNS zones.
+func (g *GCP) records(ctx context.Context, token, project string) ([]*Resource, error) {
+	var zones []string
+
+	base := g.DNSURL + "/projects/" + url.PathEscape(project) + "/managedZones"
+	if err := g.list(ctx, token, base, "managedZones", func(page json.RawMessage) error {
+		var list []struct {
+			Name       string `json:"name"`
+			Visibility string `json:"visibility"`
+		}
+		if err := json.Unmarshal(page, &list); err != nil {
+			return err
+		}
+		for _, z := range list {
+			if z.Visibility != "private" {
+				zones = append(zones, z.Name)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var resources []*Resource
+	for _, zone := range zones {
+		err := g.list(ctx, token, base+"/"+url.PathEscape(zone)+"/rrsets", "rrsets", func(page json.RawMessage) error {
+			var list []struct {
+				Name    string   `json:"name"`
+				Type    string   `json:"type"`
+				Rrdatas []string `json:"rrdatas"`
+			}
+			if err := json.Unmarshal(page, &list); err != nil {
+				return err
+			}
+
+			for _, set := range list {
+				name := strings.TrimSuffix(strings.ToLower(set.Name), ".")
+				if strings.HasPrefix(name, "*.") {
+					continue
+				}
+
+				r := &Resource{
+					Provider: ProviderGCP,
+					Account:  project,
+					Type:     "dns_record",
+					ID:       zone + "/" + name + "/" + set.Type,
+					Names:    []string{name},
+				}
+				switch set.Type {
+				case "A", "AAAA":
+					r.Addresses = set.Rrdatas
+				case "CNAME":
+				default:
+					continue
+				}
+				resources = append(resources, r)
+			}
+			return nil
+		})
+		if err != nil {
+			return resources, err
+		}
+	}
+	return resources, nil
+}
+
+// list calls fn with the field of each page of the collection, following the page tokens.
+func (g *GCP) list(ctx context.Context, token, u, field string, fn func(json.RawMessage) error) error {
+	var next string
+	for {
+		pu := u
+		if next != "" {
+			pu += "?pageToken=" + url.QueryEscape(next)
+		}
+
+		var page map[string]json.RawMessage
+		if err := getJSON(ctx, g.HTTP, pu, token, &page); err != nil {
+			return err
+		}
+		if items, found := page[field]; found {
+			if err := fn(items); err != nil {
+				return err
+			}
+		}
+
+		next = ""
+		if raw, found := page["nextPageToken"]; found {
+			if err := json.Unmarshal(raw, &next); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+	}
+}
+
+// region returns the region or zone of the aggregated list scope, such as regions/us-central1.
+func region(scope string) string {
+	if _, name, found := strings.Cut(scope, "/"); found {
+		return name
+	}
+	return scope
+}
@@ -0,0 +1,85 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// ASNs are the autonomous systems announcing the addresses of the providers.
+var ASNs = map[int]string{
+	7224:   ProviderAWS,
+	8987:   ProviderAWS,
+	14618:  ProviderAWS,
+	16509:  ProviderAWS,
+	38895:  ProviderAWS,
+	8068:   ProviderAzure,
+	8069:   ProviderAzure,
+	8075:   ProviderAzure,
+	12076:  ProviderAzure,
+	15169:  ProviderGCP,
+	19527:  ProviderGCP,
+	139070: ProviderGCP,
+	396982: ProviderGCP,
+}
+
+// Database is the subset of the graph database read to find the addresses hosted by the providers.
+type Database interface {
+	FindByType(atype oam.AssetType, since time.Time) ([]*types.Asset, error)
+	FindById(id string, since time.Time) (*types.Asset, error)
+	OutgoingRelations(asset *types.Asset, since time.Time, relationTypes ...string) ([]*types.Relation, error)
+}
+
+// Hosted returns the addresses in the graph database contained by the netblocks announced by the providers,
+// mapped to the providers.
+func Hosted(db Database) (map[string]string, error) {
+	systems, err := db.FindByType(oam.ASN, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the autonomous systems: %v", err)
+	}
+
+	hosted := make(map[string]string)
+	for _, as := range systems {
+		a, ok := as.Asset.(network.AutonomousSystem)
+		if !ok {
+			continue
+		}
+		provider, found := ASNs[a.Number]
+		if !found {
+			continue
+		}
+
+		rels, err := db.OutgoingRelations(as, time.Time{}, "announces")
+		if err != nil {
+			continue
+		}
+		for _, rel := range rels {
+			nb, err := db.FindById(rel.ToAsset.ID, time.Time{})
+			if err != nil {
+				continue
+			}
+
+			contains, err := db.OutgoingRelations(nb, time.Time{}, "contains")
+			if err != nil {
+				continue
+			}
+			for _, c := range contains {
+				addr, err := db.FindById(c.ToAsset.ID, time.Time{})
+				if err != nil {
+					continue
+				}
+				if ip, ok := addr.Asset.(network.IPAddress); ok {
+					hosted[ip.Address.String()] = provider
+				}
+			}
+		}
+	}
+	return hosted, nil
+}
@@ -0,0 +1,153 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Settings are provided by the cloud_inventory option. The credentials that are not provided are read
+// from the environment variables used by the command-line tools of the providers.
+type Settings struct {
+	AWS   *AWSSettings
+	Azure *AzureSettings
+	GCP   *GCPSettings
+}
+
+// AWSSettings provide the access keys of an IAM principal with read-only permissions.
+type AWSSettings struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Regions are the regions of the EC2 and load balancer resources
+	Regions []string
+}
+
+// AzureSettings provide the client credentials of a service principal with the Reader role.
+type AzureSettings struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	// Subscriptions are the subscriptions collected, instead of all those readable by the service principal
+	Subscriptions []string
+}
+
+// GCPSettings provide the key of a service account with the Viewer role.
+type GCPSettings struct {
+	// Credentials is the path to the JSON key file of the service account
+	Credentials string
+	// Projects are the projects collected, instead of the project of the service account
+	Projects []string
+}
+
+// DefaultAWSRegions are the regions collected when none are provided.
+var DefaultAWSRegions = []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2", "eu-west-1", "eu-central-1", "ap-southeast-1", "ap-northeast-1"}
+
+// ParseSettings returns the Settings provided by the cloud_inventory option, which is a section with a
+// section for each provider of the connected accounts.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the cloud_inventory option must be a section providing the aws, azure or gcp accounts")
+	}
+
+	s := new(Settings)
+	for key, val := range m {
+		section, ok := val.(map[string]interface{})
+		if !ok && val != nil {
+			return nil, fmt.Errorf("the cloud_inventory %s option must be a section", key)
+		}
+
+		var err error
+		switch key {
+		case ProviderAWS:
+			s.AWS = &AWSSettings{
+				AccessKeyID:     str(section, "access_key_id", "AWS_ACCESS_KEY_ID"),
+				SecretAccessKey: str(section, "secret_access_key", "AWS_SECRET_ACCESS_KEY"),
+				SessionToken:    str(section, "session_token", "AWS_SESSION_TOKEN"),
+			}
+			if s.AWS.Regions, err = list(section, "regions"); err == nil && len(s.AWS.Regions) == 0 {
+				s.AWS.Regions = DefaultAWSRegions
+			}
+			if s.AWS.AccessKeyID == "" || s.AWS.SecretAccessKey == "" {
+				return nil, errors.New("the aws account requires the access_key_id and secret_access_key")
+			}
+		case ProviderAzure:
+			s.Azure = &AzureSettings{
+				TenantID:     str(section, "tenant_id", "AZURE_TENANT_ID"),
+				ClientID:     str(section, "client_id", "AZURE_CLIENT_ID"),
+				ClientSecret: str(section, "client_secret", "AZURE_CLIENT_SECRET"),
+			}
+			s.Azure.Subscriptions, err = list(section, "subscriptions")
+			if s.Azure.TenantID == "" || s.Azure.ClientID == "" || s.Azure.ClientSecret == "" {
+				return nil, errors.New("the azure account requires the tenant_id, client_id and client_secret")
+			}
+		case ProviderGCP:
+			s.GCP = &GCPSettings{Credentials: str(section, "credentials", "GOOGLE_APPLICATION_CREDENTIALS")}
+			s.GCP.Projects, err = list(section, "projects")
+			if s.GCP.Credentials == "" {
+				return nil, errors.New("the gcp account requires the credentials of a service account")
+			}
+		default:
+			return nil, fmt.Errorf("%s is not a supported cloud provider, the providers are aws, azure and gcp", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the cloud_inventory %s %v", key, err)
+		}
+	}
+	return s, nil
+}
+
+func str(section map[string]interface{}, key, env string) string {
+	if v, ok := section[key].(string); ok && v != "" {
+		return v
+	}
+	return os.Getenv(env)
+}
+
+func list(section map[string]interface{}, key string) ([]string, error) {
+	v, found := section[key]
+	if !found {
+		return nil, nil
+	}
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list", key)
+	}
+
+	var results []string
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			return nil, fmt.Errorf("%s must be a list of names, but %v was provided", key, item)
+		}
+		results = append(results, strings.TrimSpace(s))
+	}
+	return results, nil
+}
+
+// Providers returns the providers of the accounts in the settings.
+func (s *Settings) Providers() ([]Provider, error) {
+	var providers []Provider
+
+	if s.AWS != nil {
+		providers = append(providers, NewAWS(s.AWS))
+	}
+	if s.Azure != nil {
+		providers = append(providers, NewAzure(s.Azure))
+	}
+	if s.GCP != nil {
+		p, err := NewGCP(s.GCP)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
@@ -0,0 +1,146 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/analyze"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/systems"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+const (
+	analyzeUsageMsg = "analyze [options] -d DOMAIN"
+)
+
+type analyzeArgs struct {
+	Domains   *stringset.Set
+	Top       int
+	Since     string
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+		JSONOutput string
+	}
+}
+
+func defineAnalyzeFlags(analyzeFlags *flag.FlagSet, args *analyzeArgs) {
+	analyzeFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	analyzeFlags.IntVar(&args.Top, "top", 10, "Number of entries shown in each ranked list (0 shows all of them)")
+	analyzeFlags.StringVar(&args.Since, "since", "", "Only analyze the assets seen since the date (YYYY-MM-DD or RFC3339) or duration (e.g. 72h)")
+	analyzeFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	analyzeFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	analyzeFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
+	analyzeFlags.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON output file providing the analytics")
+}
+
+func runAnalyzeCommand(clArgs []string) {
+	args := analyzeArgs{
+		Domains: stringset.New(),
+	}
+	var help1, help2 bool
+	analyzeCommand := flag.NewFlagSet("analyze", flag.ContinueOnError)
+
+	analyzeBuf := new(bytes.Buffer)
+	analyzeCommand.SetOutput(analyzeBuf)
+
+	analyzeCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	analyzeCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineAnalyzeFlags(analyzeCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(analyzeUsageMsg, analyzeCommand, analyzeBuf)
+		return
+	}
+	if err := analyzeCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(analyzeUsageMsg, analyzeCommand, analyzeBuf)
+		return
+	}
+	if args.Top < 0 {
+		fatalf(errUsage, "The number of entries cannot be negative")
+	}
+
+	since, err := parseSince(args.Since, time.Now())
+	if err != nil {
+		fatal(errUsage, err)
+	}
+
+	cfg, err := datasetConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, args.Domains, args.Filepaths.Domains)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	eg, err := exportGraph(ctx, cfg, sys.GraphDatabases()[0], since)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	report := analyze.Analyze(eg, args.Top)
+	printAnalysis(report)
+
+	if args.Filepaths.JSONOutput != "" {
+		f, err := os.Create(args.Filepaths.JSONOutput)
+		if err != nil {
+			fatalf(errIO, "Failed to create the JSON output file: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		_ = format.NewRecordWriter(f).Write(report)
+	}
+}
+
+// printAnalysis shows the ranked lists of the report, identifying each component by its first names.
+func printAnalysis(report *analyze.Report) {
+	fmt.Fprintf(color.Output, "%s assets and %s relations were analyzed\n\n", green(report.Assets), green(report.Relations))
+
+	fmt.Fprintln(color.Output, blue("Degree Centrality"))
+	for _, rk := range report.Central {
+		fmt.Fprintf(color.Output, "%s %s %s\n", fgY.Sprintf("%5d", rk.Degree),
+			white(fmt.Sprintf("%.3f", rk.Centrality)), recordAssetName(rk.Asset))
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", blue("Connected Components"))
+	for i, c := range report.Components {
+		var names []string
+		for _, a := range c.Assets {
+			if a.Type == string(oam.FQDN) && len(names) < 3 {
+				names = append(names, a.Key)
+			}
+		}
+		fmt.Fprintf(color.Output, "%s %s %s\n", fgY.Sprintf("%5d", i+1),
+			white(fmt.Sprintf("%d assets", c.Size)), green(strings.Join(names, ", ")))
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", blue("Shared Infrastructure Clusters"))
+	for i, c := range report.Clusters {
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", fgY.Sprintf("%5d", i+1),
+			white(fmt.Sprintf("%d names", len(c.Names))), white("-->"), yellow(strings.Join(c.Addresses, ", ")))
+		for _, n := range c.Names {
+			fmt.Fprintf(color.Output, "\t%s\n", green(n))
+		}
+	}
+}
@@ -0,0 +1,495 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/assoc"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/pdns"
+	"github.com/owasp-amass/amass/v4/relations"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	assocUsageMsg = "assoc [options] -d DOMAIN"
+)
+
+type assocArgs struct {
+	Bidirectional bool
+	Domains       *stringset.Set
+	MaxAssets     int
+	MaxDepth      int
+	MinConfidence float64
+	ShowPath      bool
+	Since         string
+	Workers       int
+	Filepaths     struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+		JSONOutput string
+	}
+}
+
+func defineAssocFlags(assocFlags *flag.FlagSet, args *assocArgs) {
+	assocFlags.BoolVar(&args.Bidirectional, "bidirectional", false, "Also follow the incoming relations from names and the registrants with similar names")
+	assocFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	assocFlags.IntVar(&args.MaxAssets, "max-assets", 0, "Stop the traversal after associating this many assets, keeping the most confident (0 for no limit)")
+	assocFlags.IntVar(&args.MaxDepth, "max-depth", 6, "Maximum number of relations between a subdomain name and an associated asset")
+	assocFlags.Float64Var(&args.MinConfidence, "min-confidence", 0, "Only show the associations with at least this confidence (0.0 - 1.0)")
+	assocFlags.BoolVar(&args.ShowPath, "show-path", false, "Show the relations providing the evidence for each association")
+	assocFlags.StringVar(&args.Since, "since", "", "Only traverse the assets seen since the date (YYYY-MM-DD or RFC3339) or duration (e.g. 72h)")
+	assocFlags.IntVar(&args.Workers, "workers", assoc.DefaultWorkers, "Number of assets expanded concurrently during the traversal")
+	assocFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	assocFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	assocFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
+	assocFlags.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON output file providing the associations and their paths")
+}
+
+func runAssocCommand(clArgs []string) {
+	args := assocArgs{
+		Domains: stringset.New(),
+	}
+	var help1, help2 bool
+	assocCommand := flag.NewFlagSet("assoc", flag.ContinueOnError)
+
+	assocBuf := new(bytes.Buffer)
+	assocCommand.SetOutput(assocBuf)
+
+	assocCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	assocCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineAssocFlags(assocCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(assocUsageMsg, assocCommand, assocBuf)
+		return
+	}
+	if err := assocCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(assocUsageMsg, assocCommand, assocBuf)
+		return
+	}
+	if args.MaxAssets < 0 || args.MaxDepth < 0 {
+		fatalf(errUsage, "The maximum number of assets and depth must not be negative")
+	}
+	if args.MinConfidence < 0 || args.MinConfidence > 1 {
+		fatalf(errUsage, "The minimum confidence must be between 0.0 and 1.0")
+	}
+
+	since, err := parseSince(args.Since, time.Now())
+	if err != nil {
+		fatal(errUsage, err)
+	}
+	if !since.IsZero() {
+		since = since.UTC()
+	}
+
+	cfg, err := datasetConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, args.Domains, args.Filepaths.Domains)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	g := sys.GraphDatabases()[0]
+	seeds, err := scopeNames(cfg, g, since)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	fs, _ := findings.Read(findingsPath(cfg))
+	// The candidates found through passive DNS extend the associations beyond the graph database
+	next := coHostedNeighbors(graphNeighbors(cfg, g, since, args.Bidirectional), fs)
+	if args.Bidirectional {
+		next = registrantNeighbors(next, g, since)
+	}
+
+	results := assoc.Associations(seeds, next, assoc.Options{
+		MaxDepth:      args.MaxDepth,
+		MaxAssets:     args.MaxAssets,
+		MinConfidence: args.MinConfidence,
+		Shared:        sharedAddresses(g, since, fs),
+		Workers:       args.Workers,
+	})
+	for _, a := range results {
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", fgY.Sprintf("%.2f", a.Confidence),
+			green(a.Seed), white("-->"), recordAssetName(a.Asset))
+		if !args.ShowPath {
+			continue
+		}
+		for _, s := range a.Path {
+			fmt.Fprintf(color.Output, "\t%s %s %s %s %s\n", recordAssetName(s.From), white("-->"),
+				magenta(s.Relation), white("-->"), recordAssetName(s.To))
+		}
+	}
+	if args.MaxAssets > 0 && len(results) >= args.MaxAssets {
+		fmt.Fprintf(color.Error, "The traversal stopped after associating %s assets\n", yellow(args.MaxAssets))
+	}
+
+	if args.Filepaths.JSONOutput != "" {
+		f, err := os.Create(args.Filepaths.JSONOutput)
+		if err != nil {
+			fatalf(errIO, "Failed to create the JSON output file: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		w := format.NewRecordWriter(f)
+		for _, a := range results {
+			_ = w.Write(a)
+		}
+	}
+}
+
+// scopeNames returns the subdomain names of the root domains in the graph database seen since the provided time.
+func scopeNames(cfg *config.Config, g *netmap.Graph, since time.Time) ([]*types.Asset, error) {
+	var fqdns []oam.Asset
+	for _, d := range cfg.Domains() {
+		fqdns = append(fqdns, domain.FQDN{Name: d})
+	}
+
+	assets, err := g.DB.FindByScope(fqdns, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the graph database: %v", err)
+	}
+
+	var names []*types.Asset
+	for _, a := range assets {
+		// The query also matches names that only share the suffix of a root domain
+		if fqdn, ok := a.Asset.(domain.FQDN); ok && cfg.IsDomainInScope(fqdn.Name) {
+			names = append(names, a)
+		}
+	}
+	return names, nil
+}
+
+// graphNeighbors returns the function providing the neighbors of the assets in the graph database.
+// The infrastructure related to the names, such as the addresses, netblocks and autonomous systems,
+// is followed in both directions, but the names outside of the scope are not expanded, and names are
+// not reached through incoming relations, so the assets of other organizations are not included,
+// unless the traversal is bidirectional. The function is safe for concurrent use.
+func graphNeighbors(cfg *config.Config, g *netmap.Graph, since time.Time, bidirectional bool) assoc.Neighbors {
+	lookup := newAssetLookup(g, since, relationAliases(cfg))
+
+	return func(a *types.Asset) []*assoc.Link {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok && !cfg.IsDomainInScope(fqdn.Name) {
+			return nil
+		}
+
+		var links []*assoc.Link
+		for _, l := range lookup.links(a) {
+			if bidirectional || !l.Incoming || l.Asset.Asset.AssetType() != oam.FQDN {
+				links = append(links, l)
+			}
+		}
+		return links
+	}
+}
+
+// assetLookupWorkers is the number of assets each batch queries from the graph database concurrently.
+const assetLookupWorkers = 8
+
+// assetLookup keeps the assets found by their IDs, so the assets shared by many relations,
+// such as the netblocks and autonomous systems, are only queried once during a traversal.
+type assetLookup struct {
+	sync.Mutex
+	g       *netmap.Graph
+	since   time.Time
+	aliases relations.Mapping
+	assets  map[string]*types.Asset
+}
+
+func newAssetLookup(g *netmap.Graph, since time.Time, aliases relations.Mapping) *assetLookup {
+	return &assetLookup{
+		g:       g,
+		since:   since,
+		aliases: aliases,
+		assets:  make(map[string]*types.Asset),
+	}
+}
+
+// relationAliases returns the mapping of the relation types used by previous releases to the current types.
+func relationAliases(cfg *config.Config) relations.Mapping {
+	m, err := relations.ParseMapping(cfg.Options["relation_aliases"])
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	return m
+}
+
+// links returns the links of the relations in both directions, with the assets on the other
+// side of the relations found in a single batch.
+func (l *assetLookup) links(a *types.Asset) []*assoc.Link {
+	out, _ := l.g.DB.OutgoingRelations(a, l.since)
+	in, _ := l.g.DB.IncomingRelations(a, l.since)
+
+	ids := make([]string, 0, len(out)+len(in))
+	for _, rel := range out {
+		ids = append(ids, rel.ToAsset.ID)
+	}
+	for _, rel := range in {
+		ids = append(ids, rel.FromAsset.ID)
+	}
+	found := l.find(ids)
+
+	var links []*assoc.Link
+	// The relations stored by previous releases are provided using the current relation types
+	for _, rels := range [][]*types.Relation{out, in} {
+		for _, rel := range rels {
+			rel.Type = l.aliases.Canonical(rel.Type)
+		}
+	}
+	for _, rel := range out {
+		if to, ok := found[rel.ToAsset.ID]; ok {
+			links = append(links, &assoc.Link{Relation: rel, Asset: to})
+		}
+	}
+	for _, rel := range in {
+		if from, ok := found[rel.FromAsset.ID]; ok {
+			links = append(links, &assoc.Link{Relation: rel, Asset: from, Incoming: true})
+		}
+	}
+	return links
+}
+
+// find returns the assets with the IDs, querying the assets that have not been found before concurrently.
+func (l *assetLookup) find(ids []string) map[string]*types.Asset {
+	found := make(map[string]*types.Asset, len(ids))
+
+	var missing []string
+	l.Lock()
+	for _, id := range ids {
+		if a, ok := l.assets[id]; ok {
+			found[id] = a
+		} else if _, dup := found[id]; !dup {
+			found[id] = nil
+			missing = append(missing, id)
+		}
+	}
+	l.Unlock()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, assetLookupWorkers)
+	for _, id := range missing {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(id string) {
+			defer func() { <-sem; wg.Done() }()
+
+			a, err := l.g.DB.FindById(id, l.since)
+			mu.Lock()
+			if err == nil && a != nil {
+				found[id] = a
+			} else {
+				delete(found, id)
+			}
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	l.Lock()
+	for _, id := range missing {
+		if a, ok := found[id]; ok {
+			l.assets[id] = a
+		}
+	}
+	l.Unlock()
+	return found
+}
+
+// coHostedNeighbors extends the neighbors of the addresses with the registered domains that were hosted
+// at them according to the findings, weighted by how exclusively the addresses were used by the domains.
+func coHostedNeighbors(next assoc.Neighbors, fs []*findings.Finding) assoc.Neighbors {
+	byAddr := make(map[string][]*findings.Finding)
+	for _, f := range fs {
+		if f.Type == pdns.TypeCoHosted {
+			byAddr[f.Attributes["address"]] = append(byAddr[f.Attributes["address"]], f)
+		}
+	}
+
+	return func(a *types.Asset) []*assoc.Link {
+		links := next(a)
+
+		ip, ok := a.Asset.(network.IPAddress)
+		if !ok {
+			return links
+		}
+
+		seen := make(map[string]struct{})
+		for _, f := range byAddr[ip.Address.String()] {
+			if _, found := seen[f.Asset]; found {
+				continue
+			}
+			seen[f.Asset] = struct{}{}
+
+			excl, err := strconv.ParseFloat(f.Attributes["exclusivity"], 64)
+			if err != nil || excl <= 0 {
+				continue
+			}
+
+			candidate := &types.Asset{
+				ID:        "cohosted:" + f.Asset,
+				CreatedAt: f.Time,
+				LastSeen:  f.Time,
+				Asset:     domain.FQDN{Name: f.Asset},
+			}
+			links = append(links, &assoc.Link{
+				Relation: &types.Relation{
+					ID:        "cohosted:" + f.Asset + ":" + a.ID,
+					Type:      "cohosted",
+					FromAsset: candidate,
+					ToAsset:   a,
+				},
+				Asset:    candidate,
+				Incoming: true,
+				Weight:   assoc.Weight("cohosted") * excl,
+			})
+		}
+		return links
+	}
+}
+
+// registrantNeighbors extends the neighbors of the registrant organizations with the other registrants
+// in the graph database sharing the normalized name, so the organization is associated through the
+// netblocks registered under the variations of its name.
+func registrantNeighbors(next assoc.Neighbors, g *netmap.Graph, since time.Time) assoc.Neighbors {
+	byKey := make(map[string][]*types.Asset)
+	if orgs, err := g.DB.FindByType(oam.RIROrg, since); err == nil {
+		for _, org := range orgs {
+			if rir, ok := org.Asset.(network.RIROrganization); ok {
+				if key := assoc.RegistrantKey(rir.Name); key != "" {
+					byKey[key] = append(byKey[key], org)
+				}
+			}
+		}
+	}
+
+	return func(a *types.Asset) []*assoc.Link {
+		links := next(a)
+
+		rir, ok := a.Asset.(network.RIROrganization)
+		if !ok {
+			return links
+		}
+
+		for _, other := range byKey[assoc.RegistrantKey(rir.Name)] {
+			if other.ID == a.ID {
+				continue
+			}
+			links = append(links, &assoc.Link{
+				Relation: &types.Relation{
+					ID:        assoc.SimilarRegistrant + ":" + a.ID + ":" + other.ID,
+					Type:      assoc.SimilarRegistrant,
+					FromAsset: a,
+					ToAsset:   other,
+				},
+				Asset: other,
+			})
+		}
+		return links
+	}
+}
+
+// sharedAddresses returns the function identifying the addresses used by many unrelated tenants, based on
+// the shared hosting findings, the organization announcing the netblock of the address, and the number
+// of registered domains in the graph database resolving to the address.
+func sharedAddresses(g *netmap.Graph, since time.Time, fs []*findings.Finding) func(*types.Asset) bool {
+	recorded := make(map[string]struct{})
+	for _, f := range fs {
+		if f.Type == pdns.TypeSharedHosting {
+			recorded[f.Asset] = struct{}{}
+		}
+	}
+
+	return func(a *types.Asset) bool {
+		ip, ok := a.Asset.(network.IPAddress)
+		if !ok {
+			return false
+		}
+		if _, found := recorded[ip.Address.String()]; found {
+			return true
+		}
+		return tenantDomains(g, a, since) > assoc.SharedTenants || sharedProvider(g, a, since)
+	}
+}
+
+// tenantDomains returns the number of registered domains with names resolving to the address.
+func tenantDomains(g *netmap.Graph, addr *types.Asset, since time.Time) int {
+	rels, err := g.DB.IncomingRelations(addr, since, "a_record", "aaaa_record")
+	if err != nil {
+		return 0
+	}
+
+	domains := stringset.New()
+	defer domains.Close()
+
+	for _, rel := range rels {
+		if from, err := g.DB.FindById(rel.FromAsset.ID, since); err == nil {
+			if fqdn, ok := from.Asset.(domain.FQDN); ok {
+				if d, err := publicsuffix.EffectiveTLDPlusOne(fqdn.Name); err == nil {
+					domains.Insert(d)
+				}
+			}
+		}
+	}
+	return domains.Len()
+}
+
+// sharedProvider returns true when the netblock of the address is announced by a CDN or shared hosting platform.
+func sharedProvider(g *netmap.Graph, addr *types.Asset, since time.Time) bool {
+	netblocks, err := g.DB.IncomingRelations(addr, since, "contains")
+	if err != nil {
+		return false
+	}
+
+	for _, nb := range netblocks {
+		announced, err := g.DB.IncomingRelations(nb.FromAsset, since, "announces")
+		if err != nil {
+			continue
+		}
+
+		for _, ann := range announced {
+			orgs, err := g.DB.OutgoingRelations(ann.FromAsset, since, "managed_by")
+			if err != nil {
+				continue
+			}
+
+			for _, rel := range orgs {
+				if org, err := g.DB.FindById(rel.ToAsset.ID, since); err == nil {
+					if rir, ok := org.Asset.(network.RIROrganization); ok && assoc.SharedProvider(rir.Name) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/canary"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	canaryUsageMsg = "canary [options] run | check FILE ... | visible TOKEN ... | report"
+)
+
+type canaryArgs struct {
+	Timeout    int
+	Engagement engagement
+	Filepaths  struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineCanaryFlags(canaryFlags *flag.FlagSet, args *canaryArgs) {
+	canaryFlags.IntVar(&args.Timeout, "timeout", int(canary.DefaultTimeout/time.Second), "Number of seconds allowed for each query and connection")
+	canaryFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	canaryFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+	defineEngagementFlags(canaryFlags, &args.Engagement)
+}
+
+func runCanaryCommand(clArgs []string) {
+	var args canaryArgs
+	var help1, help2 bool
+	canaryCommand := flag.NewFlagSet("canary", flag.ContinueOnError)
+
+	canaryBuf := new(bytes.Buffer)
+	canaryCommand.SetOutput(canaryBuf)
+
+	canaryCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	canaryCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineCanaryFlags(canaryCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(canaryUsageMsg, canaryCommand, canaryBuf)
+		return
+	}
+	if err := canaryCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 || canaryCommand.NArg() < 1 {
+		commandUsage(canaryUsageMsg, canaryCommand, canaryBuf)
+		return
+	}
+	if args.Timeout <= 0 {
+		fatalf(errUsage, "The timeout must be greater than zero")
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	if err := cfg.UpdateConfig(&args.Engagement); err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
+
+	path := canaryPath(cfg)
+	l, err := canary.ReadLog(path)
+	if err != nil {
+		fatal(errIO, err)
+	}
+
+	action, params := canaryCommand.Arg(0), canaryCommand.Args()[1:]
+	switch action {
+	case "run":
+		runCanary(cfg, l, time.Duration(args.Timeout)*time.Second)
+	case "check":
+		if len(params) == 0 {
+			fatalf(errUsage, "The check action requires the files exported from the logs, or - for the standard input")
+		}
+		checkCanaryLogs(l, params)
+	case "visible":
+		if len(params) == 0 {
+			fatalf(errUsage, "The visible action requires the tokens of the probes")
+		}
+		for _, token := range params {
+			p, err := l.Probe(token)
+			if err != nil {
+				fatal(errUsage, err)
+			}
+			p.MarkVisible(time.Now())
+		}
+	case "report":
+		if len(l.Runs) == 0 {
+			fatalf(errNoResults, "No canary runs were performed")
+		}
+		for _, run := range l.Runs {
+			printCanaryRun(run)
+		}
+		return
+	default:
+		fatalf(errUsage, "%s is not a supported action", action)
+	}
+
+	if err := l.Write(path); err != nil {
+		fatalf(errIO, "Failed to write the canary runs: %v", err)
+	}
+}
+
+// runCanary performs the behaviors of the canary option against the targets, which must be in scope,
+// since the probes are only meant for the infrastructure of the user.
+func runCanary(cfg *config.Config, l *canary.Log, timeout time.Duration) {
+	settings, err := canary.ParseSettings(cfg.Options["canary"])
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	if len(settings.Targets) == 0 {
+		fatalf(errConfig, "No targets were provided by the canary option")
+	}
+	for _, target := range settings.Targets {
+		inScope := cfg.IsDomainInScope(target)
+		if net.ParseIP(target) != nil {
+			inScope = cfg.IsAddressInScope(target)
+		}
+		if !inScope {
+			fatalf(errConfig, "The canary target %s is not in scope", target)
+		}
+	}
+
+	// The probes are active techniques, so they require the engagement metadata
+	cfg.Active = true
+	if err := checkEngagement(cfg); err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	prober := canary.NewProber()
+	prober.Timeout = timeout
+	run := prober.Run(ctx, settings)
+	run.Engagement = engagementFromConfig(cfg).String()
+	l.Runs = append(l.Runs, run)
+
+	for _, p := range run.Probes {
+		printCanaryProbe(p)
+	}
+	fmt.Fprintf(color.Error, "%s probes were performed. Export the logs covering %s and provide them to the check action\n",
+		green(len(run.Probes)), green(run.Started.Local().Format("2006-01-02 15:04")))
+}
+
+// checkCanaryLogs marks the probes whose tokens appear in the files as visible.
+func checkCanaryLogs(l *canary.Log, paths []string) {
+	var marked int
+	for _, path := range paths {
+		var in io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				fatalf(errIO, "Failed to open the log file: %v", err)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			for _, p := range l.Match(scanner.Text(), time.Now()) {
+				marked++
+				fmt.Fprintf(color.Output, "%s %s %s\n", green("visible"), blue(p.Behavior), p.Target)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fatalf(errIO, "Failed to read the log file %s: %v", path, err)
+		}
+	}
+	fmt.Fprintf(color.Error, "%s probes were found in the logs\n", green(marked))
+}
+
+func canaryPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), canary.FileName)
+}
+
+// printCanaryRun shows the probes of the run and the behaviors that were visible in the logs.
+func printCanaryRun(run *canary.Run) {
+	fmt.Fprintf(color.Output, "%s %s\n", blue("Canary run started on"), yellow(run.Started.Local().Format("2006-01-02 15:04")))
+	if run.Engagement != "" {
+		fmt.Fprintf(color.Output, "\t%s\n", run.Engagement)
+	}
+	for _, p := range run.Probes {
+		printCanaryProbe(p)
+	}
+
+	for _, c := range run.Coverage() {
+		ratio := fmt.Sprintf("%d/%d", c.Visible, c.Performed)
+		if c.Visible < c.Performed {
+			ratio = r.Sprint(ratio)
+		} else {
+			ratio = green(ratio)
+		}
+		fmt.Fprintf(color.Output, "\t%-15s %s visible\n", c.Behavior, ratio)
+	}
+	fmt.Fprintln(color.Output)
+}
+
+func printCanaryProbe(p *canary.Probe) {
+	state := yellow("missed")
+	switch {
+	case p.Error != "":
+		state = r.Sprint("failed")
+	case p.Visible != nil:
+		state = green("visible")
+	}
+
+	fmt.Fprintf(color.Output, "%s %s %s %s\n", state, white(p.Token), blue(p.Behavior), p.Target)
+	detail := p.Detail
+	if p.Error != "" {
+		detail = p.Error
+	}
+	fmt.Fprintf(color.Output, "\t%s\n", detail)
+}
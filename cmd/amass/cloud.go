@@ -0,0 +1,175 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/cloud"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	cloudUsageMsg = "cloud [options] -d DOMAIN"
+)
+
+type cloudArgs struct {
+	Domains   *stringset.Set
+	Cached    bool
+	Refresh   bool
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+	}
+}
+
+func defineCloudFlags(cloudFlags *flag.FlagSet, args *cloudArgs) {
+	cloudFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	cloudFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	cloudFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+	cloudFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
+	cloudFlags.BoolVar(&args.Cached, "cached", false, "Reconcile against the inventory collected by the previous run, rather than the provider APIs")
+	cloudFlags.BoolVar(&args.Refresh, "refresh", false, "Rebuild the views of the discovered names before the reconciliation")
+}
+
+func runCloudCommand(clArgs []string) {
+	args := cloudArgs{
+		Domains: stringset.New(),
+	}
+	var help1, help2 bool
+	cloudCommand := flag.NewFlagSet("cloud", flag.ContinueOnError)
+
+	cloudBuf := new(bytes.Buffer)
+	cloudCommand.SetOutput(cloudBuf)
+
+	cloudCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	cloudCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineCloudFlags(cloudCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(cloudUsageMsg, cloudCommand, cloudBuf)
+		return
+	}
+	if err := cloudCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(cloudUsageMsg, cloudCommand, cloudBuf)
+		return
+	}
+
+	cfg, err := datasetConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, args.Domains, args.Filepaths.Domains)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	inv := cloudInventory(cfg, args.Cached)
+	if len(inv.Providers) == 0 {
+		fatalf(errFailed, "The inventory of none of the cloud accounts could be collected")
+	}
+
+	var ext []*cloud.External
+	for _, res := range loadViews(cfg, args.Refresh).Resolutions {
+		if cfg.IsDomainInScope(res.Name) {
+			ext = append(ext, &cloud.External{
+				Name:      res.Name,
+				Aliases:   res.Aliases,
+				Addresses: res.Addresses,
+			})
+		}
+	}
+	if len(ext) == 0 {
+		fatalf(errNoResults, "No discovered names were found for the root domains")
+	}
+
+	rec := cloud.Reconcile(inv, ext, hostedAddresses(cfg))
+
+	var fs []*findings.Finding
+	for _, issue := range rec.Issues {
+		f := issue.Finding()
+		fs = append(fs, f)
+		fmt.Fprintf(color.Output, "%s %s %s\n", fgR.Sprintf("[%s]", f.Severity), green(f.Asset), f.Title)
+	}
+	recordFindings(cfg, fs)
+
+	fmt.Fprintf(color.Error, "%s discovered names were found in the inventory of %s resources: %s unmanaged assets and %s unknown exposures\n",
+		green(len(rec.Matched)), green(len(inv.Resources)), yellow(countIssues(rec, cloud.TypeUnmanaged)), yellow(countIssues(rec, cloud.TypeUnknownExposure)))
+}
+
+// cloudInventory returns the inventory collected from the accounts of the cloud_inventory option and saves it
+// in the output directory, or the inventory saved by the previous run when cached is true.
+func cloudInventory(cfg *config.Config, cached bool) *cloud.Inventory {
+	path := filepath.Join(config.OutputDirectory(cfg.Dir), cloud.FileName)
+	if cached {
+		inv, err := cloud.ReadInventory(path)
+		if errors.Is(err, os.ErrNotExist) {
+			fatalf(errUsage, "No cloud inventory was collected yet, run the command without the cached flag")
+		} else if err != nil {
+			fatal(errIO, err)
+		}
+		return inv
+	}
+
+	raw, found := cfg.Options["cloud_inventory"]
+	if !found {
+		fatalf(errConfig, "The cloud accounts must be provided by the cloud_inventory option")
+	}
+	settings, err := cloud.ParseSettings(raw)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	providers, err := settings.Providers()
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	inv, err := cloud.Collect(ctx, providers)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+	}
+	if err := inv.Write(path); err != nil {
+		r.Fprintf(color.Error, "Failed to save the cloud inventory: %v\n", err)
+	}
+	return inv
+}
+
+// hostedAddresses returns the addresses in the graph database announced by the cloud providers.
+func hostedAddresses(cfg *config.Config) map[string]string {
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	hosted, err := cloud.Hosted(sys.GraphDatabases()[0].DB)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+	return hosted
+}
+
+func countIssues(rec *cloud.Reconciliation, itype string) int {
+	var count int
+	for _, issue := range rec.Issues {
+		if issue.Type == itype {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,231 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/query"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	collectionUsageMsg = "collection [options] list | show NAME | create NAME [ASSET ...] | add NAME [ASSET ...] | remove NAME ASSET ... | delete NAME"
+)
+
+type collectionArgs struct {
+	Description string
+	Domains     *stringset.Set
+	Expression  string
+	Filepaths   struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineCollectionFlags(collectionFlags *flag.FlagSet, args *collectionArgs) {
+	collectionFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	collectionFlags.StringVar(&args.Description, "description", "", "Description of the collection when it is created")
+	collectionFlags.StringVar(&args.Expression, "e", "", "Query selecting the assets added to the collection, e.g. 'fqdn resolving under example.com'")
+	collectionFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	collectionFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+}
+
+func runCollectionCommand(clArgs []string) {
+	args := collectionArgs{
+		Domains: stringset.New(),
+	}
+	var help1, help2 bool
+	collectionCommand := flag.NewFlagSet("collection", flag.ContinueOnError)
+
+	collectionBuf := new(bytes.Buffer)
+	collectionCommand.SetOutput(collectionBuf)
+
+	collectionCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	collectionCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineCollectionFlags(collectionCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(collectionUsageMsg, collectionCommand, collectionBuf)
+		return
+	}
+	if err := collectionCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 || collectionCommand.NArg() < 1 {
+		commandUsage(collectionUsageMsg, collectionCommand, collectionBuf)
+		return
+	}
+
+	action := collectionCommand.Arg(0)
+	var name string
+	if action != "list" {
+		if collectionCommand.NArg() < 2 {
+			fatalf(errUsage, "The %s action requires the name of the collection", action)
+		}
+		name = collectionCommand.Arg(1)
+	}
+
+	var members []collections.Member
+	for i := 2; i < collectionCommand.NArg(); i++ {
+		arg := collectionCommand.Arg(i)
+		m, err := collections.ParseMember(arg)
+		if err != nil {
+			fatal(errUsage, err)
+		}
+		members = append(members, m)
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	cfg.AddDomains(args.Domains.Slice()...)
+
+	path := collectionsPath(cfg)
+	store, err := collections.ReadStore(path)
+	if err != nil {
+		fatal(errIO, err)
+	}
+
+	switch action {
+	case "list":
+		printCollections(store)
+		return
+	case "show":
+		c, err := store.Get(name)
+		if err != nil {
+			fatal(errUsage, err)
+		}
+		printCollection(c)
+		return
+	case "create":
+		if _, err := store.Create(name, args.Description, args.Expression); err != nil {
+			fatal(errUsage, err)
+		}
+	case "add", "remove":
+		if _, err := store.Get(name); err != nil {
+			fatal(errUsage, err)
+		}
+	case "delete":
+		if err := store.Delete(name); err != nil {
+			fatal(errUsage, err)
+		}
+	default:
+		fatalf(errUsage, "%s is not a supported action", action)
+	}
+
+	if args.Expression != "" {
+		if action != "create" && action != "add" {
+			fatalf(errUsage, "Only the create and add actions accept the -e flag")
+		}
+		for _, rec := range queryAssets(cfg, args.Expression) {
+			members = append(members, collections.MemberOf(rec))
+		}
+	}
+
+	var count int
+	switch action {
+	case "create", "add":
+		count = store[name].Add(members...)
+	case "remove":
+		if len(members) == 0 {
+			fatalf(errUsage, "The remove action requires the assets removed from the collection")
+		}
+		count = store[name].Remove(members...)
+	}
+
+	if err := store.Write(path); err != nil {
+		fatalf(errIO, "Failed to write the collections: %v", err)
+	}
+	switch action {
+	case "create", "add":
+		fmt.Fprintf(color.Error, "%s assets were added to the %s collection\n", green(count), green(name))
+	case "remove":
+		fmt.Fprintf(color.Error, "%s assets were removed from the %s collection\n", green(count), green(name))
+	case "delete":
+		fmt.Fprintf(color.Error, "The %s collection was deleted\n", green(name))
+	}
+}
+
+// collectionsPath returns the path of the file storing the collections in the output directory.
+func collectionsPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), collections.FileName)
+}
+
+// queryAssets returns the assets of the graph database selected by the query expression.
+func queryAssets(cfg *config.Config, expr string) []*format.AssetRecord {
+	q, err := query.Parse(expr)
+	if err != nil {
+		fatal(errUsage, err)
+	}
+	q.Aliases = relationAliases(cfg)
+
+	if q.Under != "" {
+		cfg.AddDomain(q.Under)
+	}
+	if len(cfg.Domains()) == 0 {
+		fatalf(errConfig, "The query requires root domain names, provided by the -d flag, the configuration or the under clause")
+	}
+	if len(q.Where) > 0 {
+		if q.Findings, err = findings.Read(findingsPath(cfg)); err != nil {
+			fatalf(errIO, "Failed to read the findings: %v", err)
+		}
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	eg, err := federatedGraph(ctx, cfg, sys.GraphDatabases()[0], time.Time{}, false)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+	return q.Run(eg)
+}
+
+// printCollections shows the collections with the number of their members and their descriptions.
+func printCollections(store collections.Store) {
+	if len(store) == 0 {
+		fmt.Fprintln(color.Error, "No collections have been created using the create action")
+		return
+	}
+
+	for _, name := range store.Names() {
+		c := store[name]
+
+		fmt.Fprintf(color.Output, "%s %s\n", green(name), yellow(fmt.Sprintf("(%d assets)", len(c.Members))))
+		if c.Description != "" {
+			fmt.Fprintf(color.Output, "\t%s\n", c.Description)
+		}
+		if c.Query != "" {
+			fmt.Fprintf(color.Output, "\t%s\n", blue(c.Query))
+		}
+	}
+}
+
+// printCollection shows the members of the collection.
+func printCollection(c *collections.Collection) {
+	for _, m := range c.Members {
+		fmt.Fprintf(color.Output, "%s %s\n", blue(fmt.Sprintf("%-10s", m.Type)), green(m.Key))
+	}
+	fmt.Fprintf(color.Error, "The %s collection has %s assets, last updated %s\n",
+		green(c.Name), green(len(c.Members)), c.Updated.Format("2006-01-02 15:04"))
+}
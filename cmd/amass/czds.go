@@ -0,0 +1,196 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/dataset"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	czdsUsageMsg = "czds [options] -d DOMAIN"
+)
+
+type czdsArgs struct {
+	Domains   *stringset.Set
+	TLDs      *stringset.Set
+	Interval  int
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+	}
+}
+
+func defineCZDSFlags(czdsFlags *flag.FlagSet, args *czdsArgs) {
+	czdsFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	czdsFlags.Var(args.TLDs, "tld", "Approved zones separated by commas to be downloaded (default: all)")
+	czdsFlags.IntVar(&args.Interval, "interval", 0, "Number of hours between zone file downloads (default: download once)")
+	czdsFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	czdsFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	czdsFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
+}
+
+func runCZDSCommand(clArgs []string) {
+	args := czdsArgs{
+		Domains: stringset.New(),
+		TLDs:    stringset.New(),
+	}
+	var help1, help2 bool
+	czdsCommand := flag.NewFlagSet("czds", flag.ContinueOnError)
+
+	czdsBuf := new(bytes.Buffer)
+	czdsCommand.SetOutput(czdsBuf)
+
+	czdsCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	czdsCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineCZDSFlags(czdsCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(czdsUsageMsg, czdsCommand, czdsBuf)
+		return
+	}
+	if err := czdsCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(czdsUsageMsg, czdsCommand, czdsBuf)
+		return
+	}
+
+	cfg, err := datasetConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, args.Domains, args.Filepaths.Domains)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	var creds *config.Credentials
+	if cfg.DataSrcConfigs != nil {
+		creds = cfg.DataSrcConfigs.GetCredentials("CZDS")
+	}
+	if creds == nil || creds.Username == "" || creds.Password == "" {
+		fatalf(errConfig, "The CZDS username and password must be provided in the data sources configuration")
+	}
+	createOutputDirectory(cfg)
+
+	dir := filepath.Join(config.OutputDirectory(cfg.Dir), dataset.CZDSDirectory)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fatalf(errIO, "Failed to create the zone file directory: %v", err)
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	for {
+		if err := czdsDownloadZones(ctx, cfg, sys.GraphDatabases()[0], creds, args.TLDs, dir); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+		}
+		if args.Interval <= 0 {
+			return
+		}
+
+		t := time.NewTimer(time.Duration(args.Interval) * time.Hour)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// czdsDownloadZones downloads the approved zone files, imports the records within the
+// scope and reports the names that were not present in the previous zone file.
+func czdsDownloadZones(ctx context.Context, cfg *config.Config, g *netmap.Graph, creds *config.Credentials, tlds *stringset.Set, dir string) error {
+	c := dataset.NewCZDSClient()
+	if err := c.Authenticate(ctx, creds.Username, creds.Password); err != nil {
+		return err
+	}
+
+	links, err := c.ZoneLinks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain the approved zone files: %v", err)
+	}
+
+	for _, link := range links {
+		tld := dataset.ZoneName(link)
+		if tlds.Len() > 0 && !tlds.Has(tld) {
+			continue
+		}
+
+		path := filepath.Join(dir, tld+".zone.gz")
+		if err := c.Download(ctx, link, path); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			continue
+		}
+
+		names := stringset.New()
+		err := dataset.ParseFile(path, dataset.FormatZone, func(rec *dataset.Record) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			inserted, err := importRecord(ctx, cfg, g, rec)
+			if inserted {
+				names.Insert(rec.Name)
+			}
+			return err
+		})
+		if err != nil {
+			names.Close()
+			return err
+		}
+
+		for _, name := range czdsNewNames(filepath.Join(dir, tld+".names"), names) {
+			fmt.Fprintf(color.Output, "%s %s\n", green("New:"), name)
+		}
+		fmt.Fprintf(color.Error, "%s: %s names in scope\n", tld, green(names.Len()))
+		names.Close()
+	}
+	return nil
+}
+
+// czdsNewNames returns the names missing from the list saved for the previous zone file, and saves the current list.
+func czdsNewNames(path string, names *stringset.Set) []string {
+	var found []string
+
+	if prev, err := format.ListFromFile(path); err == nil {
+		old := stringset.New(prev...)
+		defer old.Close()
+
+		for _, name := range names.Slice() {
+			if !old.Has(name) {
+				found = append(found, name)
+			}
+		}
+	}
+
+	list := names.Slice()
+	sort.Strings(list)
+	_ = os.WriteFile(path, []byte(strings.Join(list, "\n")+"\n"), 0640)
+
+	sort.Strings(found)
+	return found
+}
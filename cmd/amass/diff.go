@@ -0,0 +1,114 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/export"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	diffUsageMsg = "diff [options] TAG TAG"
+)
+
+type diffArgs struct {
+	Format    string
+	List      bool
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Output     string
+	}
+}
+
+func defineDiffFlags(diffFlags *flag.FlagSet, args *diffArgs) {
+	diffFlags.StringVar(&args.Format, "format", export.FormatTable, "Diff format: "+strings.Join(export.DiffFormats, ", "))
+	diffFlags.BoolVar(&args.List, "list", false, "Print the tags of the stored snapshots")
+	diffFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	diffFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the stored snapshots")
+	diffFlags.StringVar(&args.Filepaths.Output, "o", "", "Path to the file where the diff is written (default: standard output)")
+}
+
+func runDiffCommand(clArgs []string) {
+	var args diffArgs
+	var help1, help2 bool
+	diffCommand := flag.NewFlagSet("diff", flag.ContinueOnError)
+
+	diffBuf := new(bytes.Buffer)
+	diffCommand.SetOutput(diffBuf)
+
+	diffCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	diffCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineDiffFlags(diffCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(diffUsageMsg, diffCommand, diffBuf)
+		return
+	}
+	if err := diffCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(diffUsageMsg, diffCommand, diffBuf)
+		return
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	dir := config.OutputDirectory(cfg.Dir)
+
+	if args.List {
+		tags, err := export.SnapshotTags(dir)
+		if err != nil {
+			fatalf(errIO, "Failed to read the stored snapshots: %v", err)
+		}
+		if len(tags) == 0 {
+			fatalf(errNoResults, "No snapshots have been stored using the export -tag flag")
+		}
+		for _, tag := range tags {
+			fmt.Fprintln(color.Output, tag)
+		}
+		return
+	}
+
+	if diffCommand.NArg() != 2 {
+		commandUsage(diffUsageMsg, diffCommand, diffBuf)
+		exitWithCode(errUsage, "The tags of the two snapshots must be provided")
+	}
+	fromTag, toTag := diffCommand.Arg(0), diffCommand.Arg(1)
+
+	from, err := export.LoadSnapshot(dir, fromTag)
+	if err != nil {
+		fatal(errIO, err)
+	}
+	to, err := export.LoadSnapshot(dir, toTag)
+	if err != nil {
+		fatal(errIO, err)
+	}
+	if (len(from.Assets) == 0 && from.Relations > 0) || (len(to.Assets) == 0 && to.Relations > 0) {
+		fatalf(errUsage, "The snapshots generated with the -stats-only flag cannot be compared")
+	}
+
+	d := export.DiffSnapshots(from, to, fromTag, toTag)
+
+	var buf bytes.Buffer
+	if err := export.WriteDiff(&buf, args.Format, d); err != nil {
+		fatal(errUsage, err)
+	}
+	if args.Filepaths.Output == "" {
+		_, _ = color.Output.Write(buf.Bytes())
+	} else if err := os.WriteFile(args.Filepaths.Output, buf.Bytes(), 0644); err != nil {
+		fatalf(errIO, "Failed to write the output file: %v", err)
+	}
+}
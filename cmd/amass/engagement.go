@@ -0,0 +1,146 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/export"
+	"github.com/owasp-amass/config/config"
+)
+
+// engagement describes the authorization under which the active techniques are performed.
+type engagement struct {
+	Client        string
+	Authorization string
+	Tester        string
+}
+
+func defineEngagementFlags(fs *flag.FlagSet, e *engagement) {
+	fs.StringVar(&e.Authorization, "authz", "", "Reference to the authorization for active techniques (e.g. contract or ticket ID)")
+	fs.StringVar(&e.Client, "client", "", "Name of the client that authorized the engagement")
+	fs.StringVar(&e.Tester, "tester", "", "Name of the tester performing the engagement")
+}
+
+// OverrideConfig implements the config.Updater interface. The engagement metadata
+// is kept in the options section, so it's available for the reports.
+func (e *engagement) OverrideConfig(conf *config.Config) error {
+	if conf.Options == nil {
+		conf.Options = make(map[string]interface{})
+	}
+
+	m, ok := conf.Options["engagement"].(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+	}
+	if e.Client != "" {
+		m["client"] = e.Client
+	}
+	if e.Authorization != "" {
+		m["authorization"] = e.Authorization
+	}
+	if e.Tester != "" {
+		m["tester"] = e.Tester
+	}
+
+	if len(m) > 0 {
+		conf.Options["engagement"] = m
+	}
+	return nil
+}
+
+// engagementFromConfig returns the engagement metadata found in the configuration options.
+func engagementFromConfig(conf *config.Config) *engagement {
+	e := new(engagement)
+
+	if m, ok := conf.Options["engagement"].(map[string]interface{}); ok {
+		e.Client, _ = m["client"].(string)
+		e.Authorization, _ = m["authorization"].(string)
+		e.Tester, _ = m["tester"].(string)
+	}
+	return e
+}
+
+func (e *engagement) check() error {
+	var missing []string
+
+	if strings.TrimSpace(e.Client) == "" {
+		missing = append(missing, "client")
+	}
+	if strings.TrimSpace(e.Authorization) == "" {
+		missing = append(missing, "authorization")
+	}
+	if strings.TrimSpace(e.Tester) == "" {
+		missing = append(missing, "tester")
+	}
+
+	if len(missing) > 0 {
+		return errors.New("active techniques require the engagement metadata, missing: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (e *engagement) String() string {
+	return fmt.Sprintf("Client: %s, Authorization: %s, Tester: %s", e.Client, e.Authorization, e.Tester)
+}
+
+// checkEngagement ensures that active techniques are only performed under a documented
+// engagement, and displays the consent banner before any active technique is executed.
+func checkEngagement(cfg *config.Config) error {
+	if !cfg.Active {
+		return nil
+	}
+
+	e := engagementFromConfig(cfg)
+	if err := e.check(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(color.Error, "%s\n%s\n", yellow("Active techniques will directly interact with the systems in scope"), yellow(e.String()))
+	return nil
+}
+
+// engagementPath returns the path of the file storing the engagement metadata in the output directory.
+func engagementPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), export.EngagementFileName)
+}
+
+// saveEngagement stores the engagement metadata of the session in the output directory,
+// so the reports and exports of the collected assets document the authorization.
+func saveEngagement(cfg *config.Config) error {
+	e := engagementFromConfig(cfg).metadata()
+	if e.Empty() {
+		return nil
+	}
+	return e.Write(engagementPath(cfg))
+}
+
+// sessionEngagement returns the engagement metadata of the configuration, or else the metadata stored
+// by the last session performing active techniques. Nil is returned when neither provides the metadata.
+func sessionEngagement(cfg *config.Config) *export.Engagement {
+	if e := engagementFromConfig(cfg).metadata(); !e.Empty() {
+		return e
+	}
+
+	stored, err := export.ReadEngagement(engagementPath(cfg))
+	if err != nil {
+		fmt.Fprintf(color.Error, "Failed to read the engagement metadata: %v\n", err)
+		return nil
+	}
+	if stored.Empty() {
+		return nil
+	}
+	return stored
+}
+
+// metadata returns the engagement metadata written by the reports and exports.
+func (e *engagement) metadata() *export.Engagement {
+	return &export.Engagement{Client: e.Client, Authorization: e.Authorization, Tester: e.Tester}
+}
@@ -17,6 +17,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -28,6 +29,9 @@ import (
 	"github.com/owasp-amass/amass/v4/datasrcs"
 	"github.com/owasp-amass/amass/v4/enum"
 	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/inherit"
+	"github.com/owasp-amass/amass/v4/logging"
+	"github.com/owasp-amass/amass/v4/metrics"
 	"github.com/owasp-amass/amass/v4/resources"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
@@ -45,22 +49,36 @@ type enumArgs struct {
 	BruteWordListMask *stringset.Set
 	Blacklist         *stringset.Set
 	Domains           *stringset.Set
+	Engagement        engagement
 	Excluded          *stringset.Set
+	Format            string
+	HealthAddr        string
+	MetricsAddr       string
 	Included          *stringset.Set
+	Inherit           *inherit.Settings
+	InheritOrg        string
 	Interface         string
 	MaxDNSQueries     int
 	ResolverQPS       int
 	TrustedQPS        int
 	MaxDepth          int
+	MemoryLimit       int
 	MinForRecursive   int
+	Monitor           int
 	Names             *stringset.Set
 	Ports             format.ParseInts
 	Resolvers         *stringset.Set
+	Sample            int
+	TaskAddr          string
+	Seed              int64
+	Shard             shardArg
 	Trusted           *stringset.Set
 	Timeout           int
+	Webhooks          *stringset.Set
 	Options           struct {
 		Active       bool
 		Alterations  bool
+		Backfill     bool
 		BruteForcing bool
 		DemoMode     bool
 		ListSources  bool
@@ -84,6 +102,7 @@ type enumArgs struct {
 		JSONOutput       string
 		LogFile          string
 		Names            format.ParseStrings
+		Progress         string
 		Resolvers        format.ParseStrings
 		Trusted          format.ParseStrings
 		ScriptsDirectory string
@@ -98,24 +117,36 @@ func defineEnumArgumentFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.Var(&args.CIDRs, "cidr", "CIDRs separated by commas (can be used multiple times)")
 	enumFlags.Var(args.Blacklist, "bl", "Blacklist of subdomain names that will not be investigated")
 	enumFlags.Var(args.BruteWordListMask, "wm", "\"hashcat-style\" wordlist masks for DNS brute forcing")
-	enumFlags.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	enumFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
 	enumFlags.Var(args.Excluded, "exclude", "Data source names separated by commas to be excluded")
+	enumFlags.StringVar(&args.Format, "format", "", "Go template formatting each output line, e.g. '{{.Name}},{{.IP}}'")
+	enumFlags.StringVar(&args.HealthAddr, "health", "", "Address (e.g. :8080) to serve the /healthz and /readyz endpoints on")
+	enumFlags.StringVar(&args.MetricsAddr, "metrics", "", "Address (e.g. :9090) to serve the Prometheus /metrics endpoint on")
 	enumFlags.Var(args.Included, "include", "Data source names separated by commas to be included")
+	enumFlags.StringVar(&args.InheritOrg, "inherit-org", "", "Inherit the domains and netblocks of the organization from the graph database")
 	enumFlags.StringVar(&args.Interface, "iface", "", "Provide the network interface to send traffic through")
 	enumFlags.IntVar(&args.MaxDNSQueries, "max-dns-queries", 0, "Deprecated flag to be replaced by dns-qps in version 4.0")
 	enumFlags.IntVar(&args.MaxDNSQueries, "dns-qps", 0, "Maximum number of DNS queries per second across all resolvers")
 	enumFlags.IntVar(&args.ResolverQPS, "rqps", 0, "Maximum number of DNS queries per second for each untrusted resolver")
 	enumFlags.IntVar(&args.TrustedQPS, "trqps", 0, "Maximum number of DNS queries per second for each trusted resolver")
 	enumFlags.IntVar(&args.MaxDepth, "max-depth", 0, "Maximum number of subdomain labels for brute forcing")
+	enumFlags.IntVar(&args.MemoryLimit, "memory", 0, "Memory budget in megabytes, enforced by shedding load")
 	enumFlags.IntVar(&args.MinForRecursive, "min-for-recursive", 1, "Subdomain labels seen before recursive brute forcing (Default: 1)")
+	enumFlags.IntVar(&args.Monitor, "monitor", 0, "Repeat the collection every N minutes and report the new assets")
 	enumFlags.Var(&args.Ports, "p", "Ports separated by commas (default: 80, 443)")
 	enumFlags.Var(args.Resolvers, "r", "IP addresses of untrusted DNS resolvers (can be used multiple times)")
+	enumFlags.IntVar(&args.Sample, "sample", 0, "Show up to N names returned by each data source and exit before the collection")
+	enumFlags.StringVar(&args.TaskAddr, "api", "", "Address (e.g. 127.0.0.1:8090) to serve the API receiving follow-up tasks on")
+	enumFlags.Int64Var(&args.Seed, "seed", 0, "Seed for the randomized behavior, so runs with the same inputs are comparable")
+	enumFlags.Var(&args.Shard, "shard", "Enumerate only the static portion (INDEX/COUNT) of the root domains assigned to this instance, without coordination between the instances")
 	enumFlags.Var(args.Resolvers, "tr", "IP addresses of trusted DNS resolvers (can be used multiple times)")
 	enumFlags.IntVar(&args.Timeout, "timeout", 0, "Number of minutes to let enumeration run before quitting")
+	enumFlags.Var(args.Webhooks, "webhook", "URLs that the new assets found by -monitor are posted to as JSON")
 }
 
 func defineEnumOptionFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.BoolVar(&args.Options.Active, "active", false, "Attempt zone transfers and certificate name grabs")
+	enumFlags.BoolVar(&args.Options.Backfill, "backfill", false, "Run only the included data sources across the assets already in the database")
 	enumFlags.BoolVar(&args.Options.BruteForcing, "brute", false, "Execute brute forcing after searches")
 	enumFlags.BoolVar(&args.Options.DemoMode, "demo", false, "Censor output to make it suitable for demonstrations")
 	enumFlags.BoolVar(&args.Options.ListSources, "list", false, "Print the names of all available data sources")
@@ -134,11 +165,13 @@ func defineEnumFilepathFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.Var(&args.Filepaths.BruteWordlist, "w", "Path to a different wordlist file for brute forcing")
 	enumFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
 	enumFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
-	enumFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
+	enumFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
 	enumFlags.StringVar(&args.Filepaths.ExcludedSrcs, "ef", "", "Path to a file providing data sources to exclude")
 	enumFlags.StringVar(&args.Filepaths.IncludedSrcs, "if", "", "Path to a file providing data sources to include")
+	enumFlags.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON output file, one record per line (- for stdout)")
 	enumFlags.StringVar(&args.Filepaths.LogFile, "log", "", "Path to the log file where errors will be written")
 	enumFlags.Var(&args.Filepaths.Names, "nf", "Path to a file providing already known subdomain names (from other tools/sources)")
+	enumFlags.StringVar(&args.Filepaths.Progress, "progress", "", "Path to the JSON progress stream of the brute forcing, one status per line (- for stdout)")
 	enumFlags.Var(&args.Filepaths.Resolvers, "rf", "Path to a file providing untrusted DNS resolvers")
 	enumFlags.Var(&args.Filepaths.Trusted, "trf", "Path to a file providing trusted DNS resolvers")
 	enumFlags.StringVar(&args.Filepaths.ScriptsDirectory, "scripts", "", "Path to a directory containing ADS scripts")
@@ -162,26 +195,65 @@ func runEnumCommand(clArgs []string) {
 		logfile = args.Filepaths.LogFile
 	}
 	// Start handling the log messages
-	go writeLogsAndMessages(rLog, logfile, args.Options.Verbose)
+	go writeLogsAndMessages(rLog, logfile, args.Options.Verbose, logOutputs(cfg), logRedactor(cfg))
+	// Record the engagement metadata in the audit log, and for the reports and exports
+	if cfg.Active {
+		cfg.Log.Printf("Engagement: %s", engagementFromConfig(cfg))
+		if err := saveEngagement(cfg); err != nil {
+			cfg.Log.Printf("Failed to store the engagement metadata: %v", err)
+		}
+	}
+	// Seed the randomized behavior and record the seed, so the run can be reproduced
+	cfg.Log.Printf("Seed: %d", systems.SetRandomSeed(cfg))
 	// Create the System that will provide architecture to this enumeration
 	sys, err := systems.NewLocalSystem(cfg)
 	if err != nil {
-		r.Fprintf(color.Error, "%v\n", err)
-		os.Exit(1)
+		fatal(systemError(err), err)
 	}
 	defer func() { _ = sys.Shutdown() }()
+	// Bootstrap the scope from the assets previously confirmed for the organization
+	if args.Inherit != nil && !inheritScope(cfg, sys, args) {
+		return
+	}
 
 	if err := sys.SetDataSources(datasrcs.GetAllSources(sys)); err != nil {
-		r.Fprintf(color.Error, "%v\n", err)
-		os.Exit(1)
+		fatal(errFailed, err)
+	}
+	// Show what the data sources return for the scope, without performing the collection
+	if args.Sample > 0 {
+		printSourceSamples(cfg, sys, args.Sample)
+		return
+	}
+	// Expose the health of the system to container orchestrators
+	if args.HealthAddr != "" {
+		health, err := systems.NewHealthServer(sys, args.HealthAddr)
+		if err != nil {
+			fatal(errNetwork, err)
+		}
+		defer func() { _ = health.Close() }()
+	}
+	// Expose the runtime metrics of the engine to Prometheus
+	if addr := metricsAddr(cfg, args); addr != "" {
+		ms, err := metrics.NewServer(metrics.Default, addr)
+		if err != nil {
+			fatal(errNetwork, err)
+		}
+		defer func() { _ = ms.Close() }()
+	}
+	// Repeat the collection on a schedule and send notifications for the new assets
+	if settings := monitorSettings(cfg, args); settings.Interval > 0 && !args.Options.Backfill {
+		runMonitor(cfg, sys, args, settings)
+		return
 	}
 
 	// Setup the new enumeration
 	e := enum.NewEnumeration(cfg, sys, sys.GraphDatabases()[0])
 	if e == nil {
-		r.Fprintf(color.Error, "%s\n", "Failed to setup the enumeration")
-		os.Exit(1)
+		fatalf(errFailed, "Failed to setup the enumeration")
 	}
+	e.Backfill = args.Options.Backfill
+	// External systems can push follow-up tasks into the enumeration
+	defer serveTaskAPI(e, taskAPISettings(cfg, args))()
 
 	var wg sync.WaitGroup
 	var outChans []chan string
@@ -211,8 +283,13 @@ func runEnumCommand(clArgs []string) {
 	}
 	defer cancel()
 
+	// The discoveries are also written as JSON records that can be consumed by other tools
+	records, closeRecords := openJSONOutput(args)
+	defer closeRecords()
+
+	tmpls := outputTemplates(cfg, args.Format)
 	wg.Add(1)
-	go processOutput(ctx, sys.GraphDatabases()[0], e, outChans, done, &wg)
+	go processOutput(ctx, sys.GraphDatabases()[0], e, outChans, records, tmpls, done, &wg)
 	// Monitor for cancellation by the user
 	go func(d chan struct{}, c context.Context, f context.CancelFunc) {
 		quit := make(chan os.Signal, 1)
@@ -222,19 +299,80 @@ func runEnumCommand(clArgs []string) {
 		select {
 		case <-quit:
 			f()
+		case <-stopRequests:
+			f()
 		case <-d:
 		case <-c.Done():
 		}
 	}(done, ctx, cancel)
 	// Start the enumeration process
+	stopProgress := startProgress(args.Filepaths.Progress, args.Options.Silent)
 	if err := e.Start(ctx); err != nil {
-		r.Println(err)
-		os.Exit(1)
+		fatal(errFailed, err)
 	}
+	stopProgress()
 	// Let all the output goroutines know that the enumeration has finished
 	close(done)
 	wg.Wait()
 	fmt.Fprintf(color.Error, "\n%s\n", green("The enumeration has finished"))
+	printScopeHitRates(e)
+	printTruncatedSources(e)
+}
+
+// printScopeHitRates shows how many of the names returned by each data source were out of scope,
+// so sources returning names unrelated to the targets can be identified.
+func printScopeHitRates(e *enum.Enumeration) {
+	rates := e.ScopeHitRates()
+	if len(rates) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Error, "\n%s\n", blue("Data source scope hit rates"))
+	for _, rate := range rates {
+		ratio := fmt.Sprintf("%.1f%%", rate.Ratio()*100)
+		if rate.Ratio() < scopeRatioWarning {
+			ratio = r.Sprint(ratio)
+		} else {
+			ratio = green(ratio)
+		}
+
+		fmt.Fprintf(color.Error, "%-20s %s within the scope, %s of %s names discarded\n",
+			rate.Source, ratio, yellow(rate.Discarded()), yellow(rate.Observed))
+	}
+}
+
+// printTruncatedSources shows the data sources that reached their cap, since the names they provided
+// for those root domains were limited rather than exhausted.
+func printTruncatedSources(e *enum.Enumeration) {
+	truncated := e.SessionStats().Truncated()
+	if len(truncated) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Error, "\n%s\n", blue("Data sources truncated by their caps"))
+	for _, src := range truncated {
+		var domains []string
+		for domain := range src.Truncated {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+
+		for _, domain := range domains {
+			fmt.Fprintf(color.Error, "%-20s %s names dropped for %s after the cap of %s\n",
+				src.Source, r.Sprint(src.Truncated[domain]), domain, yellow(src.Cap))
+		}
+	}
+}
+
+// metricsAddr returns the listener address for the metrics from the command-line flag or the metrics option.
+func metricsAddr(cfg *config.Config, args *enumArgs) string {
+	if args.MetricsAddr != "" {
+		return args.MetricsAddr
+	}
+	if addr, ok := cfg.Options["metrics"].(string); ok {
+		return addr
+	}
+	return ""
 }
 
 func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
@@ -250,6 +388,7 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 		Names:             stringset.New(),
 		Resolvers:         stringset.New(),
 		Trusted:           stringset.New(),
+		Webhooks:          stringset.New(),
 	}
 	var help1, help2 bool
 	enumCommand := flag.NewFlagSet("enum", flag.ContinueOnError)
@@ -262,14 +401,14 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 	defineEnumArgumentFlags(enumCommand, &args)
 	defineEnumOptionFlags(enumCommand, &args)
 	defineEnumFilepathFlags(enumCommand, &args)
+	defineEngagementFlags(enumCommand, &args.Engagement)
 
 	if len(clArgs) < 1 {
 		commandUsage(enumUsageMsg, enumCommand, enumBuf)
 		return nil, &args
 	}
 	if err := enumCommand.Parse(clArgs); err != nil {
-		r.Fprintf(color.Error, "%v\n", err)
-		os.Exit(1)
+		fatal(errUsage, err)
 	}
 	if help1 || help2 {
 		commandUsage(enumUsageMsg, enumCommand, enumBuf)
@@ -280,11 +419,10 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 		iface, err := net.InterfaceByName(args.Interface)
 		if err != nil || iface == nil {
 			fmt.Fprint(color.Output, format.InterfaceInfo())
-			os.Exit(1)
+			exitWithCode(errUsage, "The network interface "+args.Interface+" was not found")
 		}
 		if err := assignNetInterface(iface); err != nil {
-			r.Fprintf(color.Error, "%v\n", err)
-			os.Exit(1)
+			fatal(errUsage, err)
 		}
 	}
 	if args.Options.NoColor {
@@ -304,11 +442,10 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 		(args.Included.Len() > 0 || args.Filepaths.IncludedSrcs != "") {
 		r.Fprintln(color.Error, "Cannot provide both include and exclude arguments")
 		commandUsage(enumUsageMsg, enumCommand, enumBuf)
-		os.Exit(1)
+		exitWithCode(errUsage, "Cannot provide both include and exclude arguments")
 	}
 	if err := processEnumInputFiles(&args); err != nil {
-		fmt.Fprintf(color.Error, "%v\n", err)
-		os.Exit(1)
+		fatal(errIO, err)
 	}
 
 	cfg := config.NewConfig()
@@ -319,14 +456,21 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 			args.Resolvers = stringset.New(cfg.Resolvers...)
 		}
 	} else if args.Filepaths.ConfigFile != "" {
-		r.Fprintf(color.Error, "Failed to load the configuration file: %v\n", err)
-		os.Exit(1)
+		fatalf(errConfig, "Failed to load the configuration file: %v", err)
+	}
+	// Override configuration file settings with the environment variables
+	if err := cfg.UpdateConfig(environSettings(os.Environ())); err != nil {
+		fatalf(errConfig, "Environment configuration error: %v", err)
 	}
 	// Override configuration file settings with command-line arguments
 	if err := cfg.UpdateConfig(args); err != nil {
-		r.Fprintf(color.Error, "Configuration error: %v\n", err)
-		os.Exit(1)
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
+	if err := cfg.UpdateConfig(&args.Engagement); err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
 	}
+	// The root domain names are validated once all the settings are merged
+	cleanRootDomains(cfg, args.Domains.Slice())
 	// Check if the user has requested the data source names
 	if args.Options.ListSources {
 		for _, line := range GetAllSourceInfo(cfg) {
@@ -336,12 +480,40 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 	}
 	// Some input validation
 	if !cfg.Active && len(args.Ports) > 0 {
-		r.Fprintln(color.Error, "Ports can only be scanned in the active mode")
-		os.Exit(1)
+		fatalf(errUsage, "Ports can only be scanned in the active mode")
+	}
+	// The scope can be inherited from the assets of an organization already in the graph database
+	settings, err := inheritSettings(cfg, &args)
+	if err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
+	args.Inherit = settings
+	if len(cfg.Domains()) == 0 && args.Inherit == nil {
+		fatalf(errConfig, "Configuration error: No root domain names were provided")
 	}
-	if len(cfg.Domains()) == 0 {
-		r.Fprintln(color.Error, "Configuration error: No root domain names were provided")
-		os.Exit(1)
+	if args.Options.Backfill {
+		if !cfg.SourceFilter.Include || len(cfg.SourceFilter.Sources) == 0 {
+			fatalf(errConfig, "Configuration error: The backfill requires the data sources to be included")
+		}
+		if args.Monitor > 0 {
+			fatalf(errConfig, "Configuration error: The backfill cannot be performed by the monitor")
+		}
+		// Only the included data sources are run across the existing assets
+		cfg.BruteForcing = false
+		cfg.Alterations = false
+	}
+	if err := applyTimingProfile(cfg); err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
+	if err := checkEngagement(cfg); err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
+	if args.Shard.Count > 1 && !sharedGraphDB(cfg) {
+		fatalf(errConfig, "Configuration error: Sharded enumerations require a shared PostgreSQL graph database")
+	}
+	// The inherited domains are assigned to the shards once they are read from the graph database
+	if args.Inherit == nil && !shardScope(cfg, &args) {
+		return nil, &args
 	}
 	return cfg, &args
 }
@@ -378,8 +550,7 @@ func saveTextOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg
 
 	outptr, err := os.OpenFile(txtfile, os.O_WRONLY|os.O_CREATE, 0644)
 	if err != nil {
-		r.Fprintf(color.Error, "Failed to open the text output file: %v\n", err)
-		os.Exit(1)
+		fatalf(errIO, "Failed to open the text output file: %v", err)
 	}
 	defer func() {
 		_ = outptr.Sync()
@@ -395,7 +566,30 @@ func saveTextOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg
 	}
 }
 
-func processOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, outputs []chan string, done chan struct{}, wg *sync.WaitGroup) {
+func openJSONOutput(args *enumArgs) (*format.RecordWriter, func()) {
+	jsonfile := args.Filepaths.JSONOutput
+	if jsonfile == "" && args.Filepaths.AllFilePrefix != "" {
+		jsonfile = args.Filepaths.AllFilePrefix + ".json"
+	}
+
+	switch jsonfile {
+	case "":
+		return nil, func() {}
+	case "-":
+		return format.NewRecordWriter(os.Stdout), func() {}
+	}
+
+	outptr, err := os.OpenFile(jsonfile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		fatalf(errIO, "Failed to open the JSON output file: %v", err)
+	}
+	return format.NewRecordWriter(outptr), func() {
+		_ = outptr.Sync()
+		_ = outptr.Close()
+	}
+}
+
+func processOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, outputs []chan string, records *format.RecordWriter, tmpls *format.Templates, done chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 	defer func() {
 		// Signal all the other output goroutines to terminate
@@ -409,9 +603,14 @@ func processOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, ou
 	defer known.Close()
 	// The function that obtains output from the enum and puts it on the channel
 	extract := func(since time.Time) {
-		for _, o := range NewOutput(ctx, g, e, known, since) {
+		for _, rec := range NewRecords(ctx, g, e, known, since) {
+			if records != nil {
+				_ = records.Write(rec)
+			}
+
+			line := templateLine(tmpls, format.NewRelationFields(rec), recordLine(rec))
 			for _, ch := range outputs {
-				ch <- o
+				ch <- line
 			}
 		}
 	}
@@ -436,7 +635,39 @@ func processOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, ou
 	}
 }
 
-func writeLogsAndMessages(logs *io.PipeReader, logfile string, verbose bool) {
+// logOutputs returns the outputs selected by the logging option, which receive the log messages in addition to the log file.
+func logOutputs(cfg *config.Config) *logging.Outputs {
+	settings, err := logging.ParseSettings(cfg.Options["logging"])
+	if err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
+
+	outputs, err := logging.New(settings)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	return outputs
+}
+
+// logRedactor returns the Redactor masking the secrets in the log messages, including the credentials of the data sources.
+func logRedactor(cfg *config.Config) *logging.Redactor {
+	var secrets []string
+
+	if cfg.DataSrcConfigs != nil {
+		for _, src := range cfg.DataSrcConfigs.Datasources {
+			for _, creds := range src.Creds {
+				if creds != nil {
+					secrets = append(secrets, creds.Password, creds.Apikey, creds.Secret)
+				}
+			}
+		}
+	}
+	return logging.NewRedactor(secrets...)
+}
+
+func writeLogsAndMessages(logs *io.PipeReader, logfile string, verbose bool, outputs *logging.Outputs, redactor *logging.Redactor) {
+	defer outputs.Close()
+
 	wildcard := regexp.MustCompile("DNS wildcard")
 	queries := regexp.MustCompile("Querying")
 
@@ -464,6 +695,8 @@ func writeLogsAndMessages(logs *io.PipeReader, logfile string, verbose bool) {
 			fmt.Fprintf(color.Error, "Error reading the Amass logs: %v\n", err)
 			break
 		}
+		// Keep the API keys and tokens out of the log file and the other outputs
+		line = redactor.Redact(line)
 
 		if filePtr != nil {
 			fmt.Fprintln(filePtr, line)
@@ -471,6 +704,9 @@ func writeLogsAndMessages(logs *io.PipeReader, logfile string, verbose bool) {
 		// Remove the timestamp
 		parts := strings.Split(line, " ")
 		line = strings.Join(parts[1:], " ")
+		// Send the messages to the event log while running as a service
+		logEvent(line)
+		outputs.Log(line)
 		// Check for Amass DNS wildcard messages
 		if verbose && wildcard.FindString(line) != "" {
 			fgR.Fprintln(color.Error, line)
@@ -487,7 +723,7 @@ func processEnumInputFiles(args *enumArgs) error {
 	if args.Options.BruteForcing {
 		if len(args.Filepaths.BruteWordlist) > 0 {
 			for _, f := range args.Filepaths.BruteWordlist {
-				list, err := config.GetListFromFile(f)
+				list, err := format.ListFromFile(f)
 				if err != nil {
 					return fmt.Errorf("failed to parse the brute force wordlist file: %v", err)
 				}
@@ -495,7 +731,7 @@ func processEnumInputFiles(args *enumArgs) error {
 			}
 		} else {
 			if f, err := resources.GetResourceFile("namelist.txt"); err == nil {
-				if list, err := getWordList(f); err == nil {
+				if list, err := format.ReadList(f); err == nil {
 					args.BruteWordList.InsertMany(list...)
 				}
 			}
@@ -504,7 +740,7 @@ func processEnumInputFiles(args *enumArgs) error {
 	if !args.Options.NoAlts {
 		if len(args.Filepaths.AltWordlist) > 0 {
 			for _, f := range args.Filepaths.AltWordlist {
-				list, err := config.GetListFromFile(f)
+				list, err := format.ListFromFile(f)
 				if err != nil {
 					return fmt.Errorf("failed to parse the alterations wordlist file: %v", err)
 				}
@@ -512,28 +748,28 @@ func processEnumInputFiles(args *enumArgs) error {
 			}
 		} else {
 			if f, err := resources.GetResourceFile("alterations.txt"); err == nil {
-				if list, err := getWordList(f); err == nil {
+				if list, err := format.ReadList(f); err == nil {
 					args.AltWordList.InsertMany(list...)
 				}
 			}
 		}
 	}
 	if args.Filepaths.Blacklist != "" {
-		list, err := config.GetListFromFile(args.Filepaths.Blacklist)
+		list, err := format.ListFromFile(args.Filepaths.Blacklist)
 		if err != nil {
 			return fmt.Errorf("failed to parse the blacklist file: %v", err)
 		}
-		args.Blacklist.InsertMany(list...)
+		args.Blacklist.InsertMany(format.NormalizeNames(list)...)
 	}
 	if args.Filepaths.ExcludedSrcs != "" {
-		list, err := config.GetListFromFile(args.Filepaths.ExcludedSrcs)
+		list, err := format.ListFromFile(args.Filepaths.ExcludedSrcs)
 		if err != nil {
 			return fmt.Errorf("failed to parse the exclude file: %v", err)
 		}
 		args.Excluded.InsertMany(list...)
 	}
 	if args.Filepaths.IncludedSrcs != "" {
-		list, err := config.GetListFromFile(args.Filepaths.IncludedSrcs)
+		list, err := format.ListFromFile(args.Filepaths.IncludedSrcs)
 		if err != nil {
 			return fmt.Errorf("failed to parse the include file: %v", err)
 		}
@@ -541,25 +777,25 @@ func processEnumInputFiles(args *enumArgs) error {
 	}
 	if len(args.Filepaths.Names) > 0 {
 		for _, f := range args.Filepaths.Names {
-			list, err := config.GetListFromFile(f)
+			list, err := format.ListFromFile(f)
 			if err != nil {
 				return fmt.Errorf("failed to parse the subdomain names file: %v", err)
 			}
-			args.Names.InsertMany(list...)
+			args.Names.InsertMany(format.NormalizeNames(list)...)
 		}
 	}
 	if len(args.Filepaths.Domains) > 0 {
 		for _, f := range args.Filepaths.Domains {
-			list, err := config.GetListFromFile(f)
+			list, err := format.ListFromFile(f)
 			if err != nil {
 				return fmt.Errorf("failed to parse the domain names file: %v", err)
 			}
-			args.Domains.InsertMany(list...)
+			args.Domains.InsertMany(format.NormalizeNames(list)...)
 		}
 	}
 	if len(args.Filepaths.Resolvers) > 0 {
 		for _, f := range args.Filepaths.Resolvers {
-			list, err := config.GetListFromFile(f)
+			list, err := format.ListFromFile(f)
 			if err != nil {
 				return fmt.Errorf("failed to parse the esolver file: %v", err)
 			}
@@ -571,6 +807,12 @@ func processEnumInputFiles(args *enumArgs) error {
 
 // Setup the amass enumeration settings
 func (e enumArgs) OverrideConfig(conf *config.Config) error {
+	if e.Seed != 0 {
+		conf.Options["seed"] = e.Seed
+	}
+	if e.MemoryLimit > 0 {
+		conf.Options["memory_limit"] = e.MemoryLimit
+	}
 	if len(e.Addresses) > 0 {
 		conf.Scope.Addresses = e.Addresses
 	}
@@ -642,17 +884,3 @@ func (e enumArgs) OverrideConfig(conf *config.Config) error {
 	conf.AddDomains(e.Domains.Slice()...)
 	return nil
 }
-
-func getWordList(reader io.Reader) ([]string, error) {
-	var words []string
-
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		// Get the next word in the list
-		w := strings.TrimSpace(scanner.Text())
-		if err := scanner.Err(); err == nil && w != "" {
-			words = append(words, w)
-		}
-	}
-	return stringset.Deduplicate(words), nil
-}
@@ -0,0 +1,368 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/caffix/stringset"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/config/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Environment variables with this prefix are mapped onto the configuration file schema.
+	envPrefix = "AMASS_"
+	// Separates the levels of the configuration file schema within a variable name,
+	// e.g. AMASS_SCOPE__DOMAINS or AMASS_OPTIONS__BRUTEFORCE__ENABLED.
+	envSeparator = "__"
+)
+
+// envSettings holds the configuration provided through environment variables,
+// keyed by the schema path following the prefix.
+type envSettings map[string]string
+
+// environSettings extracts the configuration settings from the provided environment.
+// Variables such as AMASS_CONFIG, which do not contain a separator, are not included.
+func environSettings(environ []string) envSettings {
+	settings := make(envSettings)
+
+	for _, kv := range environ {
+		key, val, found := strings.Cut(kv, "=")
+		if !found || !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+
+		key = strings.TrimPrefix(key, envPrefix)
+		if strings.Contains(key, envSeparator) && strings.TrimSpace(val) != "" {
+			settings[key] = strings.TrimSpace(val)
+		}
+	}
+	return settings
+}
+
+// OverrideConfig implements the config.Updater interface.
+func (e envSettings) OverrideConfig(conf *config.Config) error {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// The data sources file must be loaded before credentials are added
+	var creds []string
+	for _, key := range keys {
+		path := strings.Split(key, envSeparator)
+
+		var err error
+		switch strings.ToLower(path[0]) {
+		case "scope":
+			err = envScope(conf, path[1:], e[key])
+		case "options":
+			err = envOption(conf, path[1:], e[key])
+		case "datasources":
+			creds = append(creds, key)
+		default:
+			err = fmt.Errorf("%s%s is not a known configuration section", envPrefix, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range creds {
+		if err := envCredentials(conf, strings.Split(key, envSeparator)[1:], e[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func envScope(conf *config.Config, path []string, val string) error {
+	if len(path) != 1 {
+		return fmt.Errorf("%sSCOPE%s%s is not a valid scope setting", envPrefix, envSeparator, strings.Join(path, envSeparator))
+	}
+
+	switch strings.ToLower(path[0]) {
+	case "domains":
+		conf.AddDomains(envList(val)...)
+	case "ips":
+		var ips format.ParseIPs
+		if err := ips.Set(val); err != nil {
+			return fmt.Errorf("failed to parse the scope IP addresses: %v", err)
+		}
+		conf.Scope.Addresses = ips
+	case "asns":
+		var asns format.ParseInts
+		if err := asns.Set(val); err != nil {
+			return fmt.Errorf("failed to parse the scope ASNs: %v", err)
+		}
+		conf.Scope.ASNs = asns
+	case "cidrs":
+		var cidrs format.ParseCIDRs
+		if err := cidrs.Set(val); err != nil {
+			return fmt.Errorf("failed to parse the scope CIDRs: %v", err)
+		}
+		conf.Scope.CIDRs = cidrs
+	case "ports":
+		var ports format.ParseInts
+		if err := ports.Set(val); err != nil {
+			return fmt.Errorf("failed to parse the scope ports: %v", err)
+		}
+		conf.Scope.Ports = ports
+	case "blacklist":
+		for _, name := range envList(val) {
+			conf.BlacklistSubdomain(name)
+		}
+	default:
+		return fmt.Errorf("%s is not a known scope setting", strings.ToLower(path[0]))
+	}
+	return nil
+}
+
+func envOption(conf *config.Config, path []string, val string) error {
+	if len(path) == 0 || path[0] == "" {
+		return fmt.Errorf("%sOPTIONS requires the name of an option", envPrefix)
+	}
+	for i := range path {
+		path[i] = strings.ToLower(path[i])
+	}
+
+	if conf.Options == nil {
+		conf.Options = make(map[string]interface{})
+	}
+	// Store the value in the options map so settings are available to all the packages
+	opts := conf.Options
+	for _, p := range path[:len(path)-1] {
+		m, ok := opts[p].(map[string]interface{})
+		if !ok {
+			m = make(map[string]interface{})
+			opts[p] = m
+		}
+		opts = m
+	}
+	opts[path[len(path)-1]] = envValue(val)
+
+	switch strings.Join(path, ".") {
+	case "resolvers":
+		return envResolvers(conf, val)
+	case "database":
+		return envDatabase(conf, val)
+	case "datasources":
+		return envDataSources(conf, val)
+	case "bruteforce.enabled":
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("bruteforce enabled is not a bool")
+		}
+		conf.BruteForcing = enabled
+	case "bruteforce.wordlists":
+		words, err := envWordlists(val)
+		if err != nil {
+			return err
+		}
+		conf.Wordlist = words
+	case "alterations.enabled":
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("alterations enabled is not a bool")
+		}
+		conf.Alterations = enabled
+	case "alterations.wordlists":
+		words, err := envWordlists(val)
+		if err != nil {
+			return err
+		}
+		conf.AltWordlist = words
+	}
+	return nil
+}
+
+func envResolvers(conf *config.Config, val string) error {
+	var resolvers []string
+
+	for _, r := range envList(val) {
+		if ip := net.ParseIP(r); ip != nil {
+			resolvers = append(resolvers, ip.String())
+			continue
+		}
+
+		list, err := format.ListFromFile(r)
+		if err != nil {
+			return fmt.Errorf("failed to load resolvers from file: %v", err)
+		}
+		resolvers = append(resolvers, list...)
+	}
+
+	if len(resolvers) == 0 {
+		return fmt.Errorf("no valid resolvers were found")
+	}
+	conf.SetResolvers(stringset.Deduplicate(resolvers)...)
+	return nil
+}
+
+func envDatabase(conf *config.Config, dbURI string) error {
+	u, err := url.Parse(dbURI)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" {
+		return fmt.Errorf("missing scheme in database URI")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return fmt.Errorf("missing username in database URI")
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("missing hostname in database URI")
+	}
+
+	db := &config.Database{
+		Primary:  true,
+		URL:      dbURI,
+		System:   u.Scheme,
+		Username: u.User.Username(),
+		Host:     u.Hostname(),
+		Port:     u.Port(),
+		DBName:   strings.TrimPrefix(u.Path, "/"),
+		Options:  u.Query().Encode(),
+	}
+	if password, isSet := u.User.Password(); isSet {
+		db.Password = password
+	}
+	// The environment takes precedence over the primary database in the configuration file
+	for _, d := range conf.GraphDBs {
+		d.Primary = false
+	}
+	conf.GraphDBs = append(conf.GraphDBs, db)
+	return nil
+}
+
+// envDataSources loads the data sources file provided by the environment into the schema of the configuration
+// package, like the file referenced by the datasources option of the configuration file.
+func envDataSources(conf *config.Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading datasources file: %v", err)
+	}
+
+	dsc := new(config.DataSourceConfig)
+	if err := yaml.Unmarshal(data, dsc); err != nil {
+		return fmt.Errorf("error unmarshalling datasources YAML: %v", err)
+	}
+
+	dataSourceDefaults(dsc)
+	conf.DataSrcConfigs = dsc
+	return nil
+}
+
+// dataSourceDefaults names the credentials after their data source and raises the TTL of the data
+// sources to the minimum_ttl global option, as the configuration package does for the data sources file.
+func dataSourceDefaults(dsc *config.DataSourceConfig) {
+	for _, src := range dsc.Datasources {
+		for _, creds := range src.Creds {
+			if creds != nil {
+				creds.Name = src.Name
+			}
+		}
+		if ttl := dsc.GlobalOptions["minimum_ttl"]; ttl > src.TTL {
+			src.TTL = ttl
+		}
+	}
+}
+
+// envCredentials handles variables in the form AMASS_DATASOURCES__<Name>__<Field>,
+// where the data source name is case-sensitive and the field is username, password,
+// apikey or secret.
+func envCredentials(conf *config.Config, path []string, val string) error {
+	if len(path) != 2 {
+		return fmt.Errorf("%sDATASOURCES%s%s is not a valid credential setting",
+			envPrefix, envSeparator, strings.Join(path, envSeparator))
+	}
+
+	if conf.DataSrcConfigs == nil {
+		conf.DataSrcConfigs = new(config.DataSourceConfig)
+	}
+
+	name := path[0]
+	ds := conf.GetDataSourceConfig(name)
+	if ds == nil {
+		ds = &config.DataSource{Name: name}
+		conf.DataSrcConfigs.Datasources = append(conf.DataSrcConfigs.Datasources, ds)
+	}
+
+	creds, ok := ds.Creds["environ"]
+	if !ok {
+		creds = new(config.Credentials)
+		if err := ds.AddCredentials("environ", creds); err != nil {
+			return err
+		}
+	}
+
+	switch strings.ToLower(path[1]) {
+	case "username":
+		creds.Username = val
+	case "password":
+		creds.Password = val
+	case "apikey":
+		creds.Apikey = val
+	case "secret":
+		creds.Secret = val
+	default:
+		return fmt.Errorf("%s is not a known credential field", strings.ToLower(path[1]))
+	}
+
+	dataSourceDefaults(conf.DataSrcConfigs)
+	return nil
+}
+
+func envWordlists(val string) ([]string, error) {
+	var words []string
+
+	for _, path := range envList(val) {
+		list, err := format.ListFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the wordlist file: %s: %v", path, err)
+		}
+		words = append(words, list...)
+	}
+	return stringset.Deduplicate(words), nil
+}
+
+// envValue converts the variable value into the type the YAML decoder would have produced.
+func envValue(val string) interface{} {
+	if strings.Contains(val, ",") {
+		var list []interface{}
+
+		for _, v := range envList(val) {
+			list = append(list, envValue(v))
+		}
+		return list
+	}
+	if i, err := strconv.Atoi(val); err == nil {
+		return i
+	}
+	if b, err := strconv.ParseBool(val); err == nil {
+		return b
+	}
+	return val
+}
+
+func envList(val string) []string {
+	var list []string
+
+	for _, v := range strings.Split(val, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,114 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+// errorFormatEnv selects the format of the errors reported by the subcommands, which is
+// the red text by default, or a JSON object on the last line of standard error when set to json.
+const errorFormatEnv = "AMASS_ERROR_FORMAT"
+
+// The codes of the errors, so wrapper tooling can tell the failures apart.
+const (
+	errUsage     = "usage"
+	errConfig    = "bad_config"
+	errDatabase  = "db_unreachable"
+	errResolvers = "no_resolvers"
+	errNetwork   = "network_error"
+	errNoResults = "no_results"
+	errIO        = "io_error"
+	errFailed    = "failed"
+)
+
+// errorHints suggest what the user can check for each code of the errors.
+var errorHints = map[string]string{
+	errUsage:     "Run the subcommand with the -h flag for the usage message",
+	errConfig:    "Check the configuration file, the AMASS_ environment variables and the flags",
+	errDatabase:  "Check the database option and that the database server can be reached",
+	errResolvers: "Check the resolvers option and the network connectivity of the DNS resolvers",
+	errNetwork:   "Check the addresses, the proxy and the network connectivity",
+	errNoResults: "Check the root domain names and the assets already collected",
+	errIO:        "Check the paths and the permissions of the files and directories",
+}
+
+// cliError is the machine-readable error reported by the subcommands.
+type cliError struct {
+	Command string `json:"command,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// jsonErrors returns true when the errors are reported as JSON objects.
+func jsonErrors() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv(errorFormatEnv)), "json")
+}
+
+// fatal reports the error using the code and exits.
+func fatal(code string, err error) {
+	fatalf(code, "%v", err)
+}
+
+// fatalf reports the formatted error message using the code and exits.
+func fatalf(code, format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+
+	if !jsonErrors() {
+		r.Fprintln(color.Error, msg)
+		os.Exit(1)
+	}
+	exitWithCode(code, msg)
+}
+
+// exitWithCode exits after the failure was explained to the user, such as by the usage message,
+// and only reports the error when it is provided as a JSON object.
+func exitWithCode(code, msg string) {
+	if jsonErrors() {
+		e := &cliError{
+			Code:    code,
+			Message: msg,
+			Hint:    errorHints[code],
+		}
+		if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+			e.Command = os.Args[1]
+		}
+
+		if data, err := json.Marshal(e); err == nil {
+			fmt.Fprintln(os.Stderr, string(data))
+		}
+	}
+	os.Exit(1)
+}
+
+// systemError returns the code of the error returned by systems.NewLocalSystem.
+func systemError(err error) string {
+	var serr *systems.Error
+	if !errors.As(err, &serr) {
+		return errFailed
+	}
+
+	switch serr.Kind {
+	case systems.KindConfig:
+		return errConfig
+	case systems.KindDatabase:
+		return errDatabase
+	case systems.KindResolvers:
+		return errResolvers
+	case systems.KindNetwork:
+		return errNetwork
+	case systems.KindIO:
+		return errIO
+	}
+	return errFailed
+}
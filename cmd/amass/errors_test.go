@@ -0,0 +1,38 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+func TestErrorCodes(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	for _, tt := range []struct {
+		err      error
+		expected string
+	}{
+		{&systems.Error{Kind: systems.KindConfig, Err: cause}, errConfig},
+		{fmt.Errorf("setup: %w", &systems.Error{Kind: systems.KindDatabase, Err: cause}), errDatabase},
+		{&systems.Error{Kind: systems.KindResolvers, Err: cause}, errResolvers},
+		{cause, errFailed},
+	} {
+		if code := systemError(tt.err); code != tt.expected {
+			t.Errorf("Expected %s for %v, got %s", tt.expected, tt.err, code)
+		}
+	}
+
+	if code := lookupError(fmt.Errorf("www.owasp.org %w", errAssetNotFound)); code != errNoResults {
+		t.Errorf("Expected %s for the missing asset, got %s", errNoResults, code)
+	}
+	if code := lookupError(cause); code != errDatabase {
+		t.Errorf("Expected %s for the failed lookup, got %s", errDatabase, code)
+	}
+}
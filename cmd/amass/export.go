@@ -0,0 +1,339 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/export"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	exportUsageMsg = "export [options] -d DOMAIN -format graphml|gexf|dot|cypher|html|json"
+)
+
+type exportArgs struct {
+	Collection  string
+	Domains     *stringset.Set
+	Federate    bool
+	Format      string
+	Since       string
+	Redact      patternList
+	RedactAddrs bool
+	StatsOnly   bool
+	Tag         string
+	Filters     filterArgs
+	Filepaths   struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+		Output     string
+		Provenance string
+		SigningKey string
+	}
+}
+
+func defineExportFlags(exportFlags *flag.FlagSet, args *exportArgs) {
+	exportFlags.StringVar(&args.Collection, "collection", "", "Only export the assets of the collection and the relations between them")
+	exportFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	exportFlags.StringVar(&args.Format, "format", "", "Export format: "+strings.Join(export.Formats, ", "))
+	exportFlags.BoolVar(&args.Federate, "federate", false, "Also export the assets of the databases in the federation option, labeled with their origins")
+	exportFlags.Var(&args.Redact, "redact", "Regular expression matching the assets redacted from the html and json snapshots (can be used multiple times)")
+	exportFlags.BoolVar(&args.RedactAddrs, "redact-addrs", false, "Redact the IP addresses and netblocks from the html and json snapshots")
+	exportFlags.BoolVar(&args.StatsOnly, "stats-only", false, "Only provide the asset statistics in the html and json snapshots")
+	exportFlags.StringVar(&args.Tag, "tag", "", "Store the html or json snapshot under the tag, so it can be compared by the diff subcommand")
+	defineFilterFlags(exportFlags, &args.Filters)
+	exportFlags.StringVar(&args.Since, "since", "", "Only export the assets seen since the date (YYYY-MM-DD or RFC3339) or duration (e.g. 72h)")
+	exportFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	exportFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	exportFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
+	exportFlags.StringVar(&args.Filepaths.Output, "o", "", "Path to the file where the graph is written (default: standard output)")
+	exportFlags.StringVar(&args.Filepaths.Provenance, "provenance", "", "Path to the file where the in-toto provenance of the export is written")
+	exportFlags.StringVar(&args.Filepaths.SigningKey, "sign-key", "", "Path to the PEM-encoded ed25519 private key used to sign the provenance")
+}
+
+func runExportCommand(clArgs []string) {
+	args := exportArgs{
+		Domains: stringset.New(),
+	}
+	var help1, help2 bool
+	exportCommand := flag.NewFlagSet("export", flag.ContinueOnError)
+
+	exportBuf := new(bytes.Buffer)
+	exportCommand.SetOutput(exportBuf)
+
+	exportCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	exportCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineExportFlags(exportCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(exportUsageMsg, exportCommand, exportBuf)
+		return
+	}
+	if err := exportCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(exportUsageMsg, exportCommand, exportBuf)
+		return
+	}
+	if args.Format == "" {
+		commandUsage(exportUsageMsg, exportCommand, exportBuf)
+		exitWithCode(errUsage, "The export format must be provided")
+	}
+
+	since, err := parseSince(args.Since, time.Now())
+	if err != nil {
+		fatal(errUsage, err)
+	}
+
+	redaction, err := export.NewRedaction(args.Redact, args.RedactAddrs)
+	if err != nil {
+		fatal(errUsage, err)
+	}
+
+	var key ed25519.PrivateKey
+	if args.Filepaths.SigningKey != "" {
+		if key, err = export.LoadSigningKey(args.Filepaths.SigningKey); err != nil {
+			fatal(errConfig, err)
+		}
+		// The provenance is written next to the output file when a path was not provided
+		if args.Filepaths.Provenance == "" && args.Filepaths.Output != "" {
+			args.Filepaths.Provenance = args.Filepaths.Output + ".intoto.json"
+		}
+	}
+	if args.Tag != "" {
+		if !export.IsSnapshot(args.Format) {
+			fatalf(errUsage, "Only the html and json snapshots can be stored under a tag")
+		}
+		if args.StatsOnly {
+			fatalf(errUsage, "The snapshots stored under a tag must provide the assets and relations")
+		}
+		if err := export.ValidTag(args.Tag); err != nil {
+			fatal(errUsage, err)
+		}
+	}
+	if args.Filepaths.Provenance != "" && args.Filepaths.Output == "" {
+		fatalf(errUsage, "The provenance requires the export to be written to a file using the -o flag")
+	}
+
+	cfg, err := datasetConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, args.Domains, args.Filepaths.Domains)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	started := time.Now()
+	eg, err := federatedGraph(ctx, cfg, sys.GraphDatabases()[0], since, args.Federate)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	filters := nameFilter(cfg, &args.Filters)
+	filterGraph(eg, filters, cfg.Domains())
+	if args.Collection != "" {
+		store, err := collections.ReadStore(collectionsPath(cfg))
+		if err != nil {
+			fatal(errIO, err)
+		}
+		c, err := store.Get(args.Collection)
+		if err != nil {
+			fatal(errUsage, err)
+		}
+		eg.Keep(c.IDs())
+	}
+
+	// The export documents the authorization under which the assets were collected
+	eg.Engagement = sessionEngagement(cfg)
+
+	// The export is buffered, so the provenance can provide its digest
+	var buf bytes.Buffer
+	if export.IsSnapshot(args.Format) {
+		// The snapshots are sanitized for sharing outside of the team performing the assessment
+		eg.Redact(redaction)
+
+		snapshot := export.NewSnapshot(eg, cfg.Domains(), args.StatsOnly)
+		if args.Tag != "" {
+			if err := export.SaveSnapshot(config.OutputDirectory(cfg.Dir), args.Tag, snapshot); err != nil {
+				fatalf(errIO, "Failed to store the snapshot %s: %v", args.Tag, err)
+			}
+			fmt.Fprintf(color.Error, "The snapshot was stored under the tag %s\n", green(args.Tag))
+		}
+		err = export.WriteSnapshot(&buf, args.Format, snapshot)
+	} else {
+		err = export.Write(&buf, args.Format, eg)
+	}
+	if err != nil {
+		fatal(errFailed, err)
+	}
+
+	if args.Filepaths.Output == "" {
+		_, _ = color.Output.Write(buf.Bytes())
+	} else if err := os.WriteFile(args.Filepaths.Output, buf.Bytes(), 0644); err != nil {
+		fatalf(errIO, "Failed to write the output file: %v", err)
+	}
+
+	if args.Filepaths.Provenance != "" {
+		params := &export.ExportParameters{
+			Format:   strings.ToLower(args.Format),
+			Domains:  cfg.Domains(),
+			Redacted: export.IsSnapshot(args.Format) && (len(args.Redact) > 0 || args.RedactAddrs),
+			Filters:  filters.Names(),
+		}
+		if !since.IsZero() {
+			params.Since = &since
+		}
+
+		if err := writeProvenance(args.Filepaths.Provenance, args.Filepaths.Output, buf.Bytes(),
+			export.NewProvenance(eg, format.Version, params, configDigest(cfg), exportSources(sys), started), key); err != nil {
+			fatal(errIO, err)
+		}
+	}
+	fmt.Fprintf(color.Error, "%s assets and %s relations were exported\n", green(len(eg.Assets)), green(len(eg.Relations)))
+}
+
+// writeProvenance writes the DSSE envelope of the provenance for the exported data,
+// which is signed when the key is provided.
+func writeProvenance(path, output string, data []byte, p *export.Provenance, key ed25519.PrivateKey) error {
+	env, err := export.Sign(export.NewStatement(filepath.Base(output), data, p), key)
+	if err != nil {
+		return fmt.Errorf("failed to sign the provenance: %v", err)
+	}
+
+	out, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write the provenance: %v", err)
+	}
+	return nil
+}
+
+// configDigest returns the digest of the scope and options of the configuration used for the export.
+func configDigest(cfg *config.Config) string {
+	digest, err := export.ConfigDigest(map[string]interface{}{
+		"scope":   cfg.Scope,
+		"options": cfg.Options,
+	})
+	if err != nil {
+		return ""
+	}
+	return digest
+}
+
+// exportSources returns the names of the data sources selected by the configuration.
+func exportSources(sys systems.System) []string {
+	var names []string
+
+	for _, src := range datasrcs.SelectedDataSources(sys.Config(), datasrcs.GetAllSources(sys)) {
+		names = append(names, src.String())
+	}
+	return names
+}
+
+// patternList implements the flag.Value interface for the regular expressions, which can contain commas.
+type patternList []string
+
+func (p *patternList) String() string {
+	if p == nil {
+		return ""
+	}
+	return strings.Join(*p, " ")
+}
+
+// Set implements the flag.Value interface.
+func (p *patternList) Set(s string) error {
+	if s == "" {
+		return errors.New("the pattern cannot be empty")
+	}
+
+	*p = append(*p, s)
+	return nil
+}
+
+// exportGraph walks the graph database from the subdomain names of the root domains, collecting
+// the assets seen since the provided time and the relations between them.
+func exportGraph(ctx context.Context, cfg *config.Config, g *netmap.Graph, since time.Time) (*export.Graph, error) {
+	qtime := time.Time{}
+	if !since.IsZero() {
+		qtime = since.UTC()
+	}
+
+	queue, err := scopeNames(cfg, g, qtime)
+	if err != nil {
+		return nil, err
+	}
+
+	eg := export.NewGraph()
+	next := graphNeighbors(cfg, g, qtime, false)
+	seen := make(map[string]struct{})
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		a := queue[0]
+		queue = queue[1:]
+		if _, found := seen[a.ID]; found {
+			continue
+		}
+		seen[a.ID] = struct{}{}
+
+		eg.AddAsset(a)
+		for _, l := range next(a) {
+			if l.Incoming {
+				eg.AddRelation(l.Asset, l.Relation, a)
+			} else {
+				eg.AddRelation(a, l.Relation, l.Asset)
+			}
+			queue = append(queue, l.Asset)
+		}
+	}
+	return eg, nil
+}
+
+// parseSince returns the time provided as a date, an RFC3339 timestamp, or a duration before now.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%s is not a valid date, timestamp or duration for the since flag", s)
+}
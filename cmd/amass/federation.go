@@ -0,0 +1,53 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/export"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+// federatedGraph collects the graph from the session database and, when federate is true, from each of the
+// databases in the federation option. The graphs are merged, and each asset is labeled with the names of the
+// databases providing it. The databases that cannot be searched are reported and left out of the results.
+func federatedGraph(ctx context.Context, cfg *config.Config, g *netmap.Graph, since time.Time, federate bool) (*export.Graph, error) {
+	eg, err := exportGraph(ctx, cfg, g, since)
+	if err != nil || !federate {
+		return eg, err
+	}
+
+	members, err := systems.ParseFederation(cfg.Options["federation"], cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("the -federate flag requires the databases of the federation option")
+	}
+
+	merged := export.NewGraph()
+	merged.Merge(eg, systems.FederationLocal)
+	for _, m := range members {
+		mg, err := m.Database.Open()
+		if err == nil {
+			var other *export.Graph
+			if other, err = exportGraph(ctx, cfg, mg, since); err == nil {
+				merged.Merge(other, m.Name)
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		r.Fprintf(color.Error, "Failed to search the federated database %s: %v\n", m.Name, err)
+	}
+	return merged, nil
+}
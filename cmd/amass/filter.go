@@ -0,0 +1,56 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/export"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/sightings"
+	"github.com/owasp-amass/config/config"
+)
+
+// filterArgs are the flags removing the noisy names from the listings and exports.
+type filterArgs struct {
+	Resolved      bool
+	NoWildcards   bool
+	LowConfidence format.ParseStrings
+}
+
+// defineFilterFlags adds the flags removing the noisy names from the listed or exported assets.
+func defineFilterFlags(fs *flag.FlagSet, args *filterArgs) {
+	fs.BoolVar(&args.Resolved, "resolved", false, "Only include the names with address records, directly or through CNAME records")
+	fs.BoolVar(&args.NoWildcards, "no-wildcards", false, "Exclude the names that appear to be answered by DNS wildcards")
+	fs.Var(&args.LowConfidence, "low-confidence", "Data source names separated by commas, whose names are excluded unless another data source provided them")
+}
+
+// nameFilter returns the NameFilter for the flags, using the sightings recorded by the enumerations.
+func nameFilter(cfg *config.Config, args *filterArgs) *export.NameFilter {
+	f := &export.NameFilter{
+		ResolvedOnly:  args.Resolved,
+		NoWildcards:   args.NoWildcards,
+		LowConfidence: args.LowConfidence,
+	}
+
+	if len(f.LowConfidence) > 0 {
+		srcs, err := sightings.Read(filepath.Join(config.OutputDirectory(cfg.Dir), sightings.FileName))
+		if err != nil {
+			fgY.Fprintf(color.Error, "The data sources of the names were not recorded, so the low confidence names cannot be excluded: %v\n", err)
+		}
+		f.Sources = srcs
+	}
+	return f
+}
+
+// filterGraph removes the names rejected by the filter flags from the graph.
+func filterGraph(eg *export.Graph, f *export.NameFilter, domains []string) {
+	if n := eg.Filter(f, domains); n > 0 {
+		fmt.Fprintf(color.Error, "%s names were excluded by the filters\n", yellow(n))
+	}
+}
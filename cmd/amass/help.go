@@ -23,6 +23,18 @@ func runHelpCommand(clArgs []string) {
 		runEnumCommand(help)
 	case "intel":
 		runIntelCommand(help)
+	case "import":
+		runImportCommand(help)
+	case "czds":
+		runCZDSCommand(help)
+	case "probe":
+		runProbeCommand(help)
+	case "report":
+		runReportCommand(help)
+	case "export":
+		runExportCommand(help)
+	case "assoc":
+		runAssocCommand(help)
 	default:
 		commandUsage(mainUsageMsg, helpCommand, helpBuf)
 		return
@@ -0,0 +1,210 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/dataset"
+	"github.com/owasp-amass/amass/v4/format"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	importUsageMsg = "import [options] -d DOMAIN -format FORMAT FILE..."
+)
+
+type importArgs struct {
+	Domains   *stringset.Set
+	Format    string
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+	}
+}
+
+func defineImportFlags(importFlags *flag.FlagSet, args *importArgs) {
+	importFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	importFlags.StringVar(&args.Format, "format", "", "Format of the datasets: "+strings.Join(dataset.Formats(), ", "))
+	importFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	importFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	importFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
+}
+
+func runImportCommand(clArgs []string) {
+	args := importArgs{Domains: stringset.New()}
+	var help1, help2 bool
+	importCommand := flag.NewFlagSet("import", flag.ContinueOnError)
+
+	importBuf := new(bytes.Buffer)
+	importCommand.SetOutput(importBuf)
+
+	importCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	importCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineImportFlags(importCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(importUsageMsg, importCommand, importBuf)
+		return
+	}
+	if err := importCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(importUsageMsg, importCommand, importBuf)
+		return
+	}
+	if args.Format == "" || importCommand.NArg() == 0 {
+		commandUsage(importUsageMsg, importCommand, importBuf)
+		exitWithCode(errUsage, "The dataset format and files must be provided")
+	}
+	cfg, err := datasetConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, args.Domains, args.Filepaths.Domains)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	createOutputDirectory(cfg)
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	g := sys.GraphDatabases()[0]
+	for _, path := range importCommand.Args() {
+		var count int
+
+		err := dataset.ParseFile(path, args.Format, func(rec *dataset.Record) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			inserted, err := importRecord(ctx, cfg, g, rec)
+			if inserted {
+				count++
+			}
+			return err
+		})
+		if err != nil {
+			fatal(errFailed, err)
+		}
+		fmt.Fprintf(color.Error, "%s: %s records were imported\n", path, green(count))
+	}
+}
+
+// interruptContext returns a context that is cancelled when the user interrupts the program.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(quit)
+
+		select {
+		case <-quit:
+			cancel()
+		case <-stopRequests:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// datasetConfig returns the configuration used when matching datasets against the scope.
+func datasetConfig(dir, cfgfile string, domains *stringset.Set, files []string) (*config.Config, error) {
+	for _, f := range files {
+		list, err := format.ListFromFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the domain names file: %v", err)
+		}
+		domains.InsertMany(format.NormalizeNames(list)...)
+	}
+
+	cfg := config.NewConfig()
+	// Check if a configuration file was provided, and if so, load the settings
+	if err := config.AcquireConfig(dir, cfgfile, cfg); err != nil && cfgfile != "" {
+		return nil, fmt.Errorf("failed to load the configuration file: %v", err)
+	}
+	// Override configuration file settings with the environment variables
+	if err := cfg.UpdateConfig(environSettings(os.Environ())); err != nil {
+		return nil, fmt.Errorf("environment configuration error: %v", err)
+	}
+	if dir != "" {
+		cfg.Dir = dir
+	}
+	cleanRootDomains(cfg, domains.Slice())
+	if len(cfg.Domains()) == 0 {
+		return nil, errors.New("configuration error: no root domain names were provided")
+	}
+	if err := amasshttp.Configure(cfg.Options["proxy"], cfg.Options["tls"], config.OutputDirectory(cfg.Dir), nil); err != nil {
+		return nil, fmt.Errorf("configuration error: %v", err)
+	}
+	return cfg, nil
+}
+
+// importRecord inserts the dataset record into the graph when it is within the configured scope.
+func importRecord(ctx context.Context, cfg *config.Config, g *netmap.Graph, rec *dataset.Record) (bool, error) {
+	if rec.Type == "PTR" {
+		if !importInScope(cfg, rec.Data) {
+			return false, nil
+		}
+		// Reverse DNS datasets provide the address instead of the in-addr.arpa name
+		name := rec.Name
+		if net.ParseIP(name) != nil {
+			arpa, err := dns.ReverseAddr(name)
+			if err != nil {
+				return false, nil
+			}
+			name = strings.TrimSuffix(arpa, ".")
+		}
+		return true, g.UpsertPTR(ctx, name, rec.Data)
+	}
+
+	if !importInScope(cfg, rec.Name) {
+		return false, nil
+	}
+
+	var err error
+	switch rec.Type {
+	case "A":
+		err = g.UpsertA(ctx, rec.Name, rec.Data)
+	case "AAAA":
+		err = g.UpsertAAAA(ctx, rec.Name, rec.Data)
+	case "CNAME":
+		err = g.UpsertCNAME(ctx, rec.Name, rec.Data)
+	case "NS":
+		err = g.UpsertNS(ctx, rec.Name, rec.Data)
+	case "MX":
+		err = g.UpsertMX(ctx, rec.Name, rec.Data)
+	default:
+		_, err = g.UpsertFQDN(ctx, rec.Name)
+	}
+	return true, err
+}
+
+func importInScope(cfg *config.Config, name string) bool {
+	return cfg.WhichDomain(name) != "" && !cfg.Blacklisted(name)
+}
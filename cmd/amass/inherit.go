@@ -0,0 +1,98 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/inherit"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+// inheritSettings returns the settings of the inherit_scope option, which are replaced by the organization
+// provided using the -inherit-org flag. Nil is returned when the scope is not inherited.
+func inheritSettings(cfg *config.Config, args *enumArgs) (*inherit.Settings, error) {
+	raw := cfg.Options["inherit_scope"]
+	if args.InheritOrg != "" {
+		raw = args.InheritOrg
+	}
+	return inherit.ParseSettings(raw)
+}
+
+// inheritScope adds the domains and netblocks of the organization found in the graph database, and in the
+// history database when provided, to the scope of the session. The scope is restricted to the shard once
+// the inherited domains are added. False is returned when no root domains are assigned to the session.
+func inheritScope(cfg *config.Config, sys systems.System, args *enumArgs) bool {
+	dbs := sys.GraphDatabases()[:1]
+	if h := sys.HistoryDatabase(); h != nil {
+		dbs = append(dbs, h)
+	}
+
+	for _, g := range dbs {
+		scope, err := inherit.Collect(g.DB, args.Inherit)
+		if err != nil {
+			fatal(errDatabase, err)
+		}
+
+		applyInheritedScope(cfg, scope)
+		cfg.Log.Printf("Inherited scope: %s", scope.String())
+	}
+
+	if len(cfg.Domains()) == 0 {
+		fatalf(errConfig, "Configuration error: No root domain names were provided or inherited from %s", args.Inherit.Organization)
+	}
+	return shardScope(cfg, args)
+}
+
+// applyInheritedScope adds the inherited domains, netblocks and autonomous systems to the scope of the configuration.
+func applyInheritedScope(cfg *config.Config, scope *inherit.Scope) {
+	cfg.AddDomains(scope.Domains...)
+
+	known := make(map[string]struct{})
+	for _, cidr := range cfg.Scope.CIDRs {
+		known[cidr.String()] = struct{}{}
+	}
+	for _, cidr := range scope.Netblocks {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if _, found := known[ipnet.String()]; found {
+			continue
+		}
+		known[ipnet.String()] = struct{}{}
+		cfg.Scope.CIDRs = append(cfg.Scope.CIDRs, ipnet)
+		cfg.Scope.CIDRStrings = append(cfg.Scope.CIDRStrings, ipnet.String())
+	}
+
+	asns := make(map[int]struct{})
+	for _, asn := range cfg.Scope.ASNs {
+		asns[asn] = struct{}{}
+	}
+	for _, asn := range scope.ASNs {
+		if _, found := asns[asn]; !found {
+			asns[asn] = struct{}{}
+			cfg.Scope.ASNs = append(cfg.Scope.ASNs, asn)
+		}
+	}
+}
+
+// shardScope restricts the scope to the root domains assigned to this shard.
+// False is returned when no root domains were assigned to the shard.
+func shardScope(cfg *config.Config, args *enumArgs) bool {
+	if args.Shard.Count <= 1 {
+		return true
+	}
+
+	cfg.Scope.Domains = args.Shard.Domains(cfg.Domains())
+	if len(cfg.Domains()) == 0 {
+		fmt.Fprintf(color.Error, "No root domain names were assigned to shard %s\n", args.Shard.String())
+		return false
+	}
+	return true
+}
@@ -28,7 +28,7 @@ import (
 )
 
 const (
-	intelUsageMsg = "intel [options] [-whois -d DOMAIN] [-addr ADDR -asn ASN -cidr CIDR]"
+	intelUsageMsg = "intel [options] [-whois|-tlds -d DOMAIN] [-addr ADDR -asn ASN -cidr CIDR]"
 )
 
 type intelArgs struct {
@@ -37,11 +37,14 @@ type intelArgs struct {
 	CIDRs            format.ParseCIDRs
 	OrganizationName string
 	Domains          *stringset.Set
+	Engagement       engagement
 	Excluded         *stringset.Set
+	Format           string
 	Included         *stringset.Set
 	MaxDNSQueries    int
 	Ports            format.ParseInts
 	Resolvers        *stringset.Set
+	Seed             int64
 	Timeout          int
 	Options          struct {
 		Active       bool
@@ -51,6 +54,7 @@ type intelArgs struct {
 		IPv6         bool
 		ListSources  bool
 		ReverseWhois bool
+		TLDExpansion bool
 		Verbose      bool
 	}
 	Filepaths struct {
@@ -60,6 +64,7 @@ type intelArgs struct {
 		ExcludedSrcs string
 		IncludedSrcs string
 		LogFile      string
+		Progress     string
 		Resolvers    format.ParseStrings
 		TermOut      string
 	}
@@ -69,13 +74,15 @@ func defineIntelArgumentFlags(intelFlags *flag.FlagSet, args *intelArgs) {
 	intelFlags.Var(&args.Addresses, "addr", "IPs and ranges (192.168.1.1-254) separated by commas")
 	intelFlags.Var(&args.ASNs, "asn", "ASNs separated by commas (can be used multiple times)")
 	intelFlags.Var(&args.CIDRs, "cidr", "CIDRs separated by commas (can be used multiple times)")
+	intelFlags.StringVar(&args.Format, "format", "", "Go template formatting each output line, e.g. '{{.Name}},{{.IP}}'")
 	intelFlags.StringVar(&args.OrganizationName, "org", "", "Search string provided against AS description information")
-	intelFlags.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	intelFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
 	intelFlags.Var(args.Excluded, "exclude", "Data source names separated by commas to be excluded")
 	intelFlags.Var(args.Included, "include", "Data source names separated by commas to be included")
 	intelFlags.IntVar(&args.MaxDNSQueries, "max-dns-queries", 0, "Maximum number of concurrent DNS queries")
 	intelFlags.Var(&args.Ports, "p", "Ports separated by commas (default: 80, 443)")
 	intelFlags.Var(args.Resolvers, "r", "IP addresses of preferred DNS resolvers (can be used multiple times)")
+	intelFlags.Int64Var(&args.Seed, "seed", 0, "Seed for the randomized behavior, so runs with the same inputs are comparable")
 	intelFlags.IntVar(&args.Timeout, "timeout", 0, "Number of minutes to let enumeration run before quitting")
 }
 
@@ -86,6 +93,7 @@ func defineIntelOptionFlags(intelFlags *flag.FlagSet, args *intelArgs) {
 	intelFlags.BoolVar(&args.Options.IPv4, "ipv4", false, "Show the IPv4 addresses for discovered names")
 	intelFlags.BoolVar(&args.Options.IPv6, "ipv6", false, "Show the IPv6 addresses for discovered names")
 	intelFlags.BoolVar(&args.Options.ListSources, "list", false, "Print additional information")
+	intelFlags.BoolVar(&args.Options.TLDExpansion, "tlds", false, "Discover registrations of the provided domains across all TLDs")
 	intelFlags.BoolVar(&args.Options.ReverseWhois, "whois", false, "All provided domains are run through reverse whois")
 	intelFlags.BoolVar(&args.Options.Verbose, "v", false, "Output status / debug / troubleshooting info")
 }
@@ -93,10 +101,11 @@ func defineIntelOptionFlags(intelFlags *flag.FlagSet, args *intelArgs) {
 func defineIntelFilepathFlags(intelFlags *flag.FlagSet, args *intelArgs) {
 	intelFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
 	intelFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
-	intelFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
+	intelFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
 	intelFlags.StringVar(&args.Filepaths.ExcludedSrcs, "ef", "", "Path to a file providing data sources to exclude")
 	intelFlags.StringVar(&args.Filepaths.IncludedSrcs, "if", "", "Path to a file providing data sources to include")
 	intelFlags.StringVar(&args.Filepaths.LogFile, "log", "", "Path to the log file where errors will be written")
+	intelFlags.StringVar(&args.Filepaths.Progress, "progress", "", "Path to the JSON progress stream of the netblock sweeps, one status per line (- for stdout)")
 	intelFlags.Var(&args.Filepaths.Resolvers, "rf", "Path to a file providing preferred DNS resolvers")
 	intelFlags.StringVar(&args.Filepaths.TermOut, "o", "", "Path to the text file containing terminal stdout/stderr")
 }
@@ -119,14 +128,14 @@ func runIntelCommand(clArgs []string) {
 	defineIntelArgumentFlags(intelCommand, &args)
 	defineIntelOptionFlags(intelCommand, &args)
 	defineIntelFilepathFlags(intelCommand, &args)
+	defineEngagementFlags(intelCommand, &args.Engagement)
 
 	if len(clArgs) < 1 {
 		commandUsage(intelUsageMsg, intelCommand, intelBuf)
 		return
 	}
 	if err := intelCommand.Parse(clArgs); err != nil {
-		r.Fprintf(color.Error, "%v\n", err)
-		os.Exit(1)
+		fatal(errUsage, err)
 	}
 	if help1 || help2 {
 		commandUsage(intelUsageMsg, intelCommand, intelBuf)
@@ -135,11 +144,10 @@ func runIntelCommand(clArgs []string) {
 	if (args.Excluded.Len() > 0 || args.Filepaths.ExcludedSrcs != "") &&
 		(args.Included.Len() > 0 || args.Filepaths.IncludedSrcs != "") {
 		commandUsage(intelUsageMsg, intelCommand, intelBuf)
-		os.Exit(1)
+		exitWithCode(errUsage, "Cannot provide both include and exclude arguments")
 	}
 	if err := processIntelInputFiles(&args); err != nil {
-		fmt.Fprintf(color.Error, "%v\n", err)
-		os.Exit(1)
+		fatal(errIO, err)
 	}
 
 	cfg := config.NewConfig()
@@ -150,25 +158,31 @@ func runIntelCommand(clArgs []string) {
 			args.Resolvers = stringset.New(cfg.Resolvers...)
 		}
 	} else if args.Filepaths.ConfigFile != "" {
-		r.Fprintf(color.Error, "Failed to load the configuration file: %v\n", err)
-		os.Exit(1)
+		fatalf(errConfig, "Failed to load the configuration file: %v", err)
+	}
+	// Override configuration file settings with the environment variables
+	if err := cfg.UpdateConfig(environSettings(os.Environ())); err != nil {
+		fatalf(errConfig, "Environment configuration error: %v", err)
 	}
 
 	// Override configuration file settings with command-line arguments
 	if err := cfg.UpdateConfig(args); err != nil {
-		r.Fprintf(color.Error, "Configuration error: %v\n", err)
-		os.Exit(1)
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
+	if err := cfg.UpdateConfig(&args.Engagement); err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
 	}
+	// The root domain names are validated once all the settings are merged
+	cleanRootDomains(cfg, args.Domains.Slice())
 
 	// Some input validation
-	if !args.Options.ReverseWhois && args.OrganizationName == "" && !args.Options.ListSources &&
+	if !args.Options.ReverseWhois && !args.Options.TLDExpansion && args.OrganizationName == "" && !args.Options.ListSources &&
 		len(args.Addresses) == 0 && len(args.CIDRs) == 0 && len(args.ASNs) == 0 {
 		commandUsage(intelUsageMsg, intelCommand, intelBuf)
-		os.Exit(1)
+		exitWithCode(errUsage, "The targets of the intelligence collection must be provided")
 	}
 	if !cfg.Active && len(args.Ports) > 0 {
-		r.Fprintln(color.Error, "Ports can only be scanned in the active mode")
-		os.Exit(1)
+		fatalf(errUsage, "Ports can only be scanned in the active mode")
 	}
 
 	// Check if the user requested data source information
@@ -178,6 +192,12 @@ func runIntelCommand(clArgs []string) {
 		}
 		return
 	}
+	if err := applyTimingProfile(cfg); err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
+	if err := checkEngagement(cfg); err != nil {
+		fatalf(errConfig, "Configuration error: %v", err)
+	}
 
 	rLog, wLog := io.Pipe()
 	cfg.Log = log.New(wLog, "", log.Lmicroseconds)
@@ -187,7 +207,16 @@ func runIntelCommand(clArgs []string) {
 	}
 
 	createOutputDirectory(cfg)
-	go writeLogsAndMessages(rLog, logfile, args.Options.Verbose)
+	go writeLogsAndMessages(rLog, logfile, args.Options.Verbose, logOutputs(cfg), logRedactor(cfg))
+	// Record the engagement metadata in the audit log, and for the reports and exports
+	if cfg.Active {
+		cfg.Log.Printf("Engagement: %s", engagementFromConfig(cfg))
+		if err := saveEngagement(cfg); err != nil {
+			cfg.Log.Printf("Failed to store the engagement metadata: %v", err)
+		}
+	}
+	// Seed the randomized behavior and record the seed, so the run can be reproduced
+	cfg.Log.Printf("Seed: %d", systems.SetRandomSeed(cfg))
 
 	sys, err := systems.NewLocalSystem(cfg)
 	if err != nil {
@@ -216,20 +245,22 @@ func runIntelCommand(clArgs []string) {
 
 	ic := intel.NewCollection(cfg, sys)
 	if ic == nil {
-		r.Fprintf(color.Error, "%s\n", "No DNS resolvers passed the sanity check")
-		os.Exit(1)
+		fatalf(errResolvers, "No DNS resolvers passed the sanity check")
 	}
 
-	if args.Options.ReverseWhois {
+	if args.Options.ReverseWhois || args.Options.TLDExpansion {
 		if len(ic.Config.Domains()) == 0 {
-			r.Fprintln(color.Error, "No root domain names were provided")
-			os.Exit(1)
+			fatalf(errConfig, "No root domain names were provided")
 		}
 
 		args.Options.IPs = false
 		args.Options.IPv4 = false
 		args.Options.IPv6 = false
-		go func() { _ = ic.ReverseWhois() }()
+		if args.Options.TLDExpansion {
+			go func() { _ = ic.ExpandTLDs(context.Background()) }()
+		} else {
+			go func() { _ = ic.ReverseWhois() }()
+		}
 	} else {
 		var ctx context.Context
 		var cancel context.CancelFunc
@@ -254,8 +285,11 @@ func runIntelCommand(clArgs []string) {
 		go func() { _ = ic.HostedDomains(ctx) }()
 	}
 
-	if !processIntelOutput(ic, &args) {
-		os.Exit(1)
+	stopProgress := startProgress(args.Filepaths.Progress, false)
+	found := processIntelOutput(ic, &args)
+	stopProgress()
+	if !found {
+		exitWithCode(errNoResults, "No assets were discovered")
 	}
 }
 
@@ -288,8 +322,7 @@ func processIntelOutput(ic *intel.Collection, args *intelArgs) bool {
 	if txtfile != "" {
 		outptr, err = os.OpenFile(txtfile, os.O_WRONLY|os.O_CREATE, 0644)
 		if err != nil {
-			r.Fprintf(color.Error, "Failed to open the text output file: %v\n", err)
-			os.Exit(1)
+			fatalf(errIO, "Failed to open the text output file: %v", err)
 		}
 		defer func() {
 			_ = outptr.Sync()
@@ -300,8 +333,18 @@ func processIntelOutput(ic *intel.Collection, args *intelArgs) bool {
 	}
 
 	var found bool
+	tmpls := outputTemplates(ic.Config, args.Format)
 	// Collect all the names returned by the intelligence collection
 	for out := range ic.Output {
+		if line, ok, err := tmpls.Line(format.NewOutputFields(out)); err == nil && ok {
+			fmt.Fprintln(color.Output, line)
+			if outptr != nil {
+				fmt.Fprintln(outptr, line)
+			}
+			found = true
+			continue
+		}
+
 		_, ips := format.OutputLineParts(out, args.Options.IPs || args.Options.IPv4 || args.Options.IPv6, args.Options.DemoMode)
 
 		if ips != "" {
@@ -321,14 +364,14 @@ func processIntelOutput(ic *intel.Collection, args *intelArgs) bool {
 // Obtain parameters from provided input files
 func processIntelInputFiles(args *intelArgs) error {
 	if args.Filepaths.ExcludedSrcs != "" {
-		list, err := config.GetListFromFile(args.Filepaths.ExcludedSrcs)
+		list, err := format.ListFromFile(args.Filepaths.ExcludedSrcs)
 		if err != nil {
 			return fmt.Errorf("failed to parse the exclude file: %v", err)
 		}
 		args.Excluded.InsertMany(list...)
 	}
 	if args.Filepaths.IncludedSrcs != "" {
-		list, err := config.GetListFromFile(args.Filepaths.IncludedSrcs)
+		list, err := format.ListFromFile(args.Filepaths.IncludedSrcs)
 		if err != nil {
 			return fmt.Errorf("failed to parse the include file: %v", err)
 		}
@@ -336,17 +379,17 @@ func processIntelInputFiles(args *intelArgs) error {
 	}
 	if len(args.Filepaths.Domains) > 0 {
 		for _, f := range args.Filepaths.Domains {
-			list, err := config.GetListFromFile(f)
+			list, err := format.ListFromFile(f)
 			if err != nil {
 				return fmt.Errorf("failed to parse the domain names file: %v", err)
 			}
 
-			args.Domains.InsertMany(list...)
+			args.Domains.InsertMany(format.NormalizeNames(list)...)
 		}
 	}
 	if len(args.Filepaths.Resolvers) > 0 {
 		for _, f := range args.Filepaths.Resolvers {
-			list, err := config.GetListFromFile(f)
+			list, err := format.ListFromFile(f)
 			if err != nil {
 				return fmt.Errorf("failed to parse the resolver file: %v", err)
 			}
@@ -359,6 +402,9 @@ func processIntelInputFiles(args *intelArgs) error {
 
 // Setup the amass intelligence collection settings
 func (i intelArgs) OverrideConfig(conf *config.Config) error {
+	if i.Seed != 0 {
+		conf.Options["seed"] = i.Seed
+	}
 	if i.Options.Active {
 		conf.Active = true
 	}
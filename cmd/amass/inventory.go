@@ -0,0 +1,157 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/inventory"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	inventoryUsageMsg = "inventory [options] import FILE ... | list | show | remove FILE ..."
+)
+
+type inventoryArgs struct {
+	Format    string
+	Origin    string
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineInventoryFlags(inventoryFlags *flag.FlagSet, args *inventoryArgs) {
+	inventoryFlags.StringVar(&args.Format, "format", "", "Format of the imported files, detected from the contents by default: "+strings.Join(inventory.Formats(), ", "))
+	inventoryFlags.StringVar(&args.Origin, "origin", "", "Domain name completing the relative names of the zone files without the $ORIGIN directive")
+	inventoryFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	inventoryFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+}
+
+func runInventoryCommand(clArgs []string) {
+	var args inventoryArgs
+	var help1, help2 bool
+	inventoryCommand := flag.NewFlagSet("inventory", flag.ContinueOnError)
+
+	inventoryBuf := new(bytes.Buffer)
+	inventoryCommand.SetOutput(inventoryBuf)
+
+	inventoryCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	inventoryCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineInventoryFlags(inventoryCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(inventoryUsageMsg, inventoryCommand, inventoryBuf)
+		return
+	}
+	if err := inventoryCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 || inventoryCommand.NArg() < 1 {
+		commandUsage(inventoryUsageMsg, inventoryCommand, inventoryBuf)
+		return
+	}
+
+	action := inventoryCommand.Arg(0)
+	files := inventoryCommand.Args()[1:]
+	switch action {
+	case "import", "remove":
+		if len(files) == 0 {
+			fatalf(errUsage, "The %s action requires the inventory files", action)
+		}
+	case "list", "show":
+	default:
+		fatalf(errUsage, "%s is not a supported action", action)
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	path := inventoryPath(cfg)
+	inv, err := inventory.Read(path)
+	if err != nil {
+		fatal(errIO, err)
+	}
+
+	switch action {
+	case "list":
+		printInventorySources(inv)
+		return
+	case "show":
+		printInventory(inv)
+		return
+	case "import":
+		createOutputDirectory(cfg)
+		for _, file := range files {
+			entries, err := inventory.ParseFile(file, args.Format, args.Origin)
+			if err != nil {
+				fatal(errFailed, err)
+			}
+
+			count := inv.Import(inventorySource(file), entries)
+			fmt.Fprintf(color.Error, "%s: %s names and addresses were imported\n", file, green(count))
+		}
+	case "remove":
+		for _, file := range files {
+			count := inv.Remove(inventorySource(file))
+			fmt.Fprintf(color.Error, "%s: %s names and addresses were removed\n", file, green(count))
+		}
+	}
+
+	if err := inv.Write(path); err != nil {
+		fatalf(errIO, "Failed to write the intended inventory: %v", err)
+	}
+}
+
+// inventoryPath returns the path of the file storing the intended inventory in the output directory.
+func inventoryPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), inventory.FileName)
+}
+
+// inventorySource returns the absolute path of the file, so the file is identified the same way
+// when it is imported again from another directory.
+func inventorySource(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return file
+}
+
+func printInventorySources(inv *inventory.Inventory) {
+	sources := inv.Sources()
+	if len(sources) == 0 {
+		fmt.Fprintln(color.Error, "No inventory files were imported")
+		return
+	}
+
+	var files []string
+	for file := range sources {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		fmt.Fprintf(color.Output, "%s %s\n", green(file), white(fmt.Sprintf("(%d names and addresses)", sources[file])))
+	}
+	fmt.Fprintf(color.Error, "The intended inventory was last updated %s\n", inv.Updated.Local().Format("2006-01-02 15:04:05"))
+}
+
+func printInventory(inv *inventory.Inventory) {
+	for _, e := range inv.Entries {
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", blue(fmt.Sprintf("%-9s", e.Type)), green(e.Key), yellow(e.Origin), white(filepath.Base(e.Source)))
+	}
+	if len(inv.Entries) == 0 {
+		fmt.Fprintln(color.Error, "No inventory files were imported")
+	}
+}
@@ -8,23 +8,34 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"strconv"
 	"time"
 
 	"github.com/caffix/netmap"
 	"github.com/caffix/stringset"
 	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/format"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
 	oam "github.com/owasp-amass/open-asset-model"
 	"github.com/owasp-amass/open-asset-model/domain"
-	"github.com/owasp-amass/open-asset-model/network"
 	"golang.org/x/net/publicsuffix"
 )
 
 func NewOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter *stringset.Set, since time.Time) []string {
 	var output []string
 
+	for _, rec := range NewRecords(ctx, g, e, filter, since) {
+		output = append(output, recordLine(rec))
+	}
+	return output
+}
+
+// NewRecords returns the relations discovered by the enumeration since the provided time that are not in the filter.
+// The filter is updated by NewRecords.
+func NewRecords(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter *stringset.Set, since time.Time) []*format.RelationRecord {
+	var records []*format.RelationRecord
+
 	// Make sure a filter has been created
 	if filter == nil {
 		filter = stringset.New()
@@ -38,11 +49,8 @@ func NewOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter
 		}
 	}
 
-	arrow := white("-->")
 	start := e.Config.CollectionStartTime.UTC()
 	for _, from := range assets {
-		fromstr := extractAssetName(from)
-
 		if rels, err := g.DB.OutgoingRelations(from, start); err == nil {
 			for _, rel := range rels {
 				lineid := from.ID + rel.ID + rel.ToAsset.ID
@@ -50,45 +58,54 @@ func NewOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter
 					continue
 				}
 				if to, err := g.DB.FindById(rel.ToAsset.ID, start); err == nil {
-					tostr := extractAssetName(to)
-
-					output = append(output, fmt.Sprintf("%s %s %s %s %s", fromstr, arrow, magenta(rel.Type), arrow, tostr))
+					records = append(records, format.NewRelationRecord(from, rel, to))
 					filter.Insert(lineid)
 				}
 			}
 		}
 	}
 
-	return output
+	return records
+}
+
+// recordLine returns the colorized line of terminal output for the relation.
+func recordLine(rec *format.RelationRecord) string {
+	arrow := white("-->")
+	return fmt.Sprintf("%s %s %s %s %s", recordAssetName(rec.From), arrow, magenta(rec.Relation), arrow, recordAssetName(rec.To))
 }
 
-func extractAssetName(a *types.Asset) string {
-	var result string
+func recordAssetName(rec *format.AssetRecord) string {
+	label := rec.Type
+	if label == string(oam.RIROrg) {
+		label = "RIROrganization"
+	}
+	return green(rec.Key) + blue(" ("+label+")")
+}
 
-	switch a.Asset.AssetType() {
-	case oam.FQDN:
-		if fqdn, ok := a.Asset.(domain.FQDN); ok {
-			result = green(fqdn.Name) + blue(" (FQDN)")
-		}
-	case oam.IPAddress:
-		if ip, ok := a.Asset.(network.IPAddress); ok {
-			result = green(ip.Address.String()) + blue(" (IPAddress)")
-		}
-	case oam.ASN:
-		if asn, ok := a.Asset.(network.AutonomousSystem); ok {
-			result = green(strconv.Itoa(asn.Number)) + blue(" (ASN)")
-		}
-	case oam.RIROrg:
-		if rir, ok := a.Asset.(network.RIROrganization); ok {
-			result = green(rir.RIRId+rir.Name) + blue(" (RIROrganization)")
-		}
-	case oam.Netblock:
-		if nb, ok := a.Asset.(network.Netblock); ok {
-			result = green(nb.Cidr.String()) + blue(" (Netblock)")
+// outputTemplates returns the templates formatting the output lines, using the -format flag as the
+// default and the output_templates option for the asset types. The process exits for invalid templates.
+func outputTemplates(cfg *config.Config, def string) *format.Templates {
+	byType, err := format.ParseTemplateSettings(cfg.Options["output_templates"])
+	if err == nil {
+		var tmpls *format.Templates
+
+		tmpls, err = format.NewTemplates(def, byType)
+		if err == nil {
+			return tmpls
 		}
 	}
 
-	return result
+	fatal(errConfig, err)
+	return nil
+}
+
+// templateLine returns the line formatted by the templates, or the default line when no template applies.
+func templateLine(tmpls *format.Templates, f *format.Fields, def string) string {
+	line, ok, err := tmpls.Line(f)
+	if err != nil || !ok {
+		return def
+	}
+	return line
 }
 
 // ExtractOutput is a convenience method for obtaining new discoveries made by the enumeration process.
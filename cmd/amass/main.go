@@ -31,6 +31,7 @@ import (
 	"path"
 
 	"github.com/caffix/service"
+	"github.com/caffix/stringset"
 	"github.com/fatih/color"
 	"github.com/owasp-amass/amass/v4/datasrcs"
 	"github.com/owasp-amass/amass/v4/format"
@@ -40,7 +41,7 @@ import (
 )
 
 const (
-	mainUsageMsg         = "intel|enum [options]"
+	mainUsageMsg         = "intel|enum|import|czds|probe|report|export|diff|assoc|path|analyze|orgs|query|update|service [options]"
 	exampleConfigFileURL = "https://github.com/owasp-amass/amass/blob/master/examples/config.yaml"
 	userGuideURL         = "https://github.com/owasp-amass/amass/blob/master/doc/user_guide.md"
 	tutorialURL          = "https://github.com/owasp-amass/amass/blob/master/doc/tutorial.md"
@@ -69,6 +70,27 @@ func commandUsage(msg string, cmdFlagSet *flag.FlagSet, errBuf *bytes.Buffer) {
 		g.Fprintf(color.Error, "\nSubcommands: \n\n")
 		g.Fprintf(color.Error, "\t%-11s - Discover targets for enumerations\n", "amass intel")
 		g.Fprintf(color.Error, "\t%-11s - Perform enumerations and network mapping\n", "amass enum")
+		g.Fprintf(color.Error, "\t%-11s - Import offline datasets into the graph database\n", "amass import")
+		g.Fprintf(color.Error, "\t%-11s - Match ICANN CZDS zone files against the scope\n", "amass czds")
+		g.Fprintf(color.Error, "\t%-11s - Serve requests from another vantage point\n", "amass probe")
+		g.Fprintf(color.Error, "\t%-11s - Summarize the findings of previous enumerations\n", "amass report")
+		g.Fprintf(color.Error, "\t%-11s - Export the asset graph for visualization and analysis\n", "amass export")
+		g.Fprintf(color.Error, "\t%-11s - Compare the assets and relations of two tagged snapshots\n", "amass diff")
+		g.Fprintf(color.Error, "\t%-11s - Explain how the assets are associated with the root domains\n", "amass assoc")
+		g.Fprintf(color.Error, "\t%-11s - Find the shortest relation paths between two assets\n", "amass path")
+		g.Fprintf(color.Error, "\t%-11s - Rank the pivotal assets and clusters of shared infrastructure\n", "amass analyze")
+		g.Fprintf(color.Error, "\t%-11s - Show the hierarchy of the legal entities and their infrastructure\n", "amass orgs")
+		g.Fprintf(color.Error, "\t%-11s - Run the saved and ad hoc queries against the graph database\n", "amass query")
+		g.Fprintf(color.Error, "\t%-11s - Manage the named collections of assets\n", "amass collection")
+		g.Fprintf(color.Error, "\t%-11s - Re-check the assets of the collections and alert on the changes\n", "amass verify")
+		g.Fprintf(color.Error, "\t%-11s - Assert the relations known to the analysts\n", "amass relate")
+		g.Fprintf(color.Error, "\t%-11s - Restore or purge the assets removed by the cleanup operations\n", "amass trash")
+		g.Fprintf(color.Error, "\t%-11s - Perform labeled probes and report which were visible in the logs\n", "amass canary")
+		g.Fprintf(color.Error, "\t%-11s - Import the intended inventory from Terraform state and DNS zone files\n", "amass inventory")
+		g.Fprintf(color.Error, "\t%-11s - Reconcile the discovered assets against the inventory of the cloud accounts\n", "amass cloud")
+		g.Fprintf(color.Error, "\t%-11s - Rename the relations stored using the types of previous releases\n", "amass migrate")
+		g.Fprintf(color.Error, "\t%-11s - Install the latest verified release from the selected channel\n", "amass update")
+		g.Fprintf(color.Error, "\t%-11s - Run the engine as a Windows service\n", "amass service")
 	}
 
 	g.Fprintln(color.Error)
@@ -93,8 +115,7 @@ func main() {
 		return
 	}
 	if err := mainFlagSet.Parse(os.Args[1:]); err != nil {
-		r.Fprintf(color.Error, "%v\n", err)
-		os.Exit(1)
+		fatal(errUsage, err)
 	}
 	if help1 || help2 {
 		commandUsage(mainUsageMsg, mainFlagSet, defaultBuf)
@@ -110,11 +131,53 @@ func main() {
 		runEnumCommand(os.Args[2:])
 	case "intel":
 		runIntelCommand(os.Args[2:])
+	case "import":
+		runImportCommand(os.Args[2:])
+	case "czds":
+		runCZDSCommand(os.Args[2:])
+	case "probe":
+		runProbeCommand(os.Args[2:])
+	case "report":
+		runReportCommand(os.Args[2:])
+	case "export":
+		runExportCommand(os.Args[2:])
+	case "diff":
+		runDiffCommand(os.Args[2:])
+	case "assoc":
+		runAssocCommand(os.Args[2:])
+	case "path":
+		runPathCommand(os.Args[2:])
+	case "analyze":
+		runAnalyzeCommand(os.Args[2:])
+	case "orgs":
+		runOrgsCommand(os.Args[2:])
+	case "query":
+		runQueryCommand(os.Args[2:])
+	case "collection":
+		runCollectionCommand(os.Args[2:])
+	case "verify":
+		runVerifyCommand(os.Args[2:])
+	case "relate":
+		runRelateCommand(os.Args[2:])
+	case "trash":
+		runTrashCommand(os.Args[2:])
+	case "canary":
+		runCanaryCommand(os.Args[2:])
+	case "inventory":
+		runInventoryCommand(os.Args[2:])
+	case "cloud":
+		runCloudCommand(os.Args[2:])
+	case "migrate":
+		runMigrateCommand(os.Args[2:])
+	case "update":
+		runUpdateCommand(os.Args[2:])
+	case "service":
+		runServiceCommand(os.Args[2:])
 	case "help":
 		runHelpCommand(os.Args[2:])
 	default:
 		commandUsage(mainUsageMsg, mainFlagSet, defaultBuf)
-		os.Exit(1)
+		exitWithCode(errUsage, os.Args[1]+" is not a subcommand")
 	}
 }
 
@@ -170,13 +233,11 @@ func createOutputDirectory(cfg *config.Config) {
 	// Prepare output file paths
 	dir := config.OutputDirectory(cfg.Dir)
 	if dir == "" {
-		r.Fprintln(color.Error, "Failed to obtain the output directory")
-		os.Exit(1)
+		fatalf(errIO, "Failed to obtain the output directory")
 	}
 	// If the directory does not yet exist, create it
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		r.Fprintf(color.Error, "Failed to create the directory: %v\n", err)
-		os.Exit(1)
+		fatalf(errIO, "Failed to create the directory: %v", err)
 	}
 }
 
@@ -206,3 +267,49 @@ func assignNetInterface(iface *net.Interface) error {
 	amassnet.LocalAddr = best
 	return nil
 }
+
+// pipedDomains implements the flag.Value interface for the -d flag, reading the
+// domain names piped to the standard input when the value is format.StdinPath.
+type pipedDomains struct {
+	domains *stringset.Set
+}
+
+func (p *pipedDomains) String() string {
+	if p == nil || p.domains == nil {
+		return ""
+	}
+	return p.domains.String()
+}
+
+// Set implements the flag.Value interface.
+func (p *pipedDomains) Set(s string) error {
+	if s != format.StdinPath {
+		return p.domains.Set(s)
+	}
+
+	list, err := format.StdinList()
+	if err != nil {
+		return err
+	}
+	p.domains.InsertMany(format.NormalizeNames(list)...)
+	return nil
+}
+
+// cleanRootDomains validates and normalizes the root domain names of the configuration, along with the
+// entries provided on the command line, and writes a summary of the rejected entries.
+func cleanRootDomains(cfg *config.Config, entries []string) {
+	// Most of the command-line entries were already added to the configuration by UpdateConfig
+	all := stringset.Deduplicate(append(append([]string{}, cfg.Domains()...), entries...))
+
+	domains, errs := format.CleanDomains(all)
+	cfg.Scope.Domains = nil
+	cfg.AddDomains(domains...)
+
+	if len(errs) == 0 {
+		return
+	}
+	fgY.Fprintf(color.Error, "Ignored %d invalid root domain names:\n", len(errs))
+	for _, err := range errs {
+		fgY.Fprintf(color.Error, "  %v\n", err)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/quality"
+	"github.com/owasp-amass/amass/v4/relations"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/amass/v4/trash"
+)
+
+const (
+	migrateUsageMsg = "migrate [options]"
+)
+
+type migrateArgs struct {
+	DryRun    bool
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineMigrateFlags(migrateFlags *flag.FlagSet, args *migrateArgs) {
+	migrateFlags.BoolVar(&args.DryRun, "dry-run", false, "List the relations that would be migrated without changing the graph database")
+	migrateFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	migrateFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+}
+
+func runMigrateCommand(clArgs []string) {
+	var args migrateArgs
+	var help1, help2 bool
+	migrateCommand := flag.NewFlagSet("migrate", flag.ContinueOnError)
+
+	migrateBuf := new(bytes.Buffer)
+	migrateCommand.SetOutput(migrateBuf)
+
+	migrateCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	migrateCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineMigrateFlags(migrateCommand, &args)
+
+	if err := migrateCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(migrateUsageMsg, migrateCommand, migrateBuf)
+		return
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	aliases := relationAliases(cfg)
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	db := sys.GraphDatabases()[0].DB
+	g, err := quality.Load(ctx, db)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	changes := aliases.Plan(g.Assets, g.Relations)
+	if !args.DryRun {
+		// The relations replaced are kept in the trash, so a migration can be reverted
+		path := trashPath(cfg)
+		t, err := trash.Read(path)
+		if err != nil {
+			fatal(errIO, err)
+		}
+
+		bin := trash.NewBin(db, t, "migrate", trash.CurrentActor(), "relation type replaced by the canonical type")
+		bin.Known(g.Relations)
+		if _, err := relations.Migrate(ctx, bin, changes); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+		}
+		if err := t.Write(path); err != nil {
+			r.Fprintf(color.Error, "Failed to write the trash: %v\n", err)
+		}
+	}
+
+	var migrated, skipped int
+	for _, c := range changes {
+		var status string
+		switch {
+		case c.Migrated:
+			migrated++
+			status = green(" (migrated)")
+		case c.Skipped:
+			skipped++
+			status = yellow(" (not valid in the asset taxonomy)")
+		}
+
+		fmt.Fprintf(color.Output, "%s %s %s %s%s\n", green(format.AssetKey(c.From.Asset)),
+			blue(c.Relation.Type+" -> "+c.Canonical), green(format.AssetKey(c.To.Asset)), white(c.Relation.ID), status)
+	}
+	fmt.Fprintf(color.Output, "\n%s relations were checked: %s use previous relation types, %s were migrated and %s were left for review\n",
+		green(len(g.Relations)), yellow(len(changes)), green(migrated), yellow(skipped))
+}
@@ -0,0 +1,439 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/bgp"
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/ctlog"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/expiry"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/leaks"
+	"github.com/owasp-amass/amass/v4/monitor"
+	"github.com/owasp-amass/amass/v4/progress"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// monitorSettings returns the settings from the monitor option, overridden by the command-line flags.
+func monitorSettings(cfg *config.Config, args *enumArgs) *monitor.Settings {
+	settings, err := monitor.ParseSettings(cfg.Options["monitor"])
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	if args.Monitor > 0 {
+		settings.Interval = time.Duration(args.Monitor) * time.Minute
+	}
+	for _, u := range args.Webhooks.Slice() {
+		settings.Webhooks = append(settings.Webhooks, monitor.NewWebhook(u, ""))
+	}
+	return settings
+}
+
+// runMonitor repeats the collection at the configured interval until interrupted, and
+// sends notifications for the assets that were not already in the graph database.
+func runMonitor(cfg *config.Config, sys systems.System, args *enumArgs, settings *monitor.Settings) {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	records, closeRecords := openJSONOutput(args)
+	defer closeRecords()
+
+	tmpls := outputTemplates(cfg, args.Format)
+	defer startProgress(args.Filepaths.Progress, args.Options.Silent)()
+
+	g := sys.GraphDatabases()[0]
+	// The session is resumed after the engine is restarted or upgraded
+	path := filepath.Join(config.OutputDirectory(cfg.Dir), monitor.SessionFileName)
+	prev, err := monitor.ReadSession(path)
+	if err != nil {
+		fatal(errIO, err)
+	}
+	ms := monitor.Resume(prev, cfg.Domains(), time.Now())
+	if ms.Cycle > 0 && !args.Options.Silent {
+		fmt.Fprintf(color.Error, "%s %s %s %s\n", blue("Resuming the monitoring session started on"),
+			yellow(ms.Started.Local().Format("2006-01-02 15:04")), blue("after cycle"), yellow(ms.Cycle))
+	}
+	if wait := ms.Wait(settings.Interval, time.Now()); wait > 0 {
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+
+	for first := true; ; first = false {
+		start := time.Now()
+		cfg.CollectionStartTime = start
+		// The scope follows the assets confirmed for the organization by the previous cycles
+		if !first && args.Inherit != nil && !inheritScope(cfg, sys, args) {
+			return
+		}
+		cycle := ms.Begin(cfg.Domains(), start)
+		if err := ms.Write(path); err != nil {
+			cfg.Log.Printf("Failed to write the monitoring session: %v", err)
+		}
+		if !args.Options.Silent {
+			fmt.Fprintf(color.Error, "%s %s\n", blue("Starting monitoring cycle"), yellow(cycle))
+		}
+
+		// The estimates of each cycle are based on the work of the cycle
+		progress.Default.Tracker(progress.BruteForce).Reset()
+		e := enum.NewEnumeration(cfg, sys, g)
+		if e == nil {
+			fatalf(errFailed, "Failed to setup the enumeration")
+		}
+
+		var cctx context.Context
+		var ccancel context.CancelFunc
+		if args.Timeout == 0 {
+			cctx, ccancel = context.WithCancel(ctx)
+		} else {
+			cctx, ccancel = context.WithTimeout(ctx, time.Duration(args.Timeout)*time.Minute)
+		}
+		err = e.Start(cctx)
+		ccancel()
+		if err != nil {
+			fatal(errFailed, err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		// The names logged since the previous cycle are stored before the new assets are collected
+		if len(settings.CTLogs) > 0 {
+			readCTLogs(ctx, cfg, g, settings.CTLogs)
+		}
+
+		var assets []*types.Asset
+		for _, atype := range monitor.AssetTypes {
+			if a, err := g.DB.FindByType(atype, start.UTC()); err == nil {
+				assets = append(assets, a...)
+			}
+		}
+		// The assets confirmed by the previous sessions are not new to the engagement
+		if h := sys.HistoryDatabase(); h != nil {
+			assets = unknownAssets(h, assets, start)
+		}
+
+		alert := &monitor.Alert{
+			Domains:  cfg.Domains(),
+			Started:  start,
+			Finished: time.Now(),
+			Assets:   monitor.NewAssets(assets, start),
+		}
+		if len(settings.Leaks) > 0 {
+			alert.Findings = append(alert.Findings, leakFindings(ctx, cfg, settings.Leaks)...)
+		}
+		if settings.BGP {
+			alert.Findings = append(alert.Findings, routeFindings(ctx, cfg, g, start)...)
+		}
+		if settings.Expiration > 0 {
+			alert.Findings = append(alert.Findings, expirationFindings(ctx, cfg, settings.Expiration)...)
+		}
+		findings.SortBySeverity(alert.Findings)
+		for _, rec := range alert.Assets {
+			if records != nil {
+				_ = records.Write(rec)
+			}
+			if !args.Options.Silent {
+				fmt.Fprintln(color.Output, templateLine(tmpls, format.NewFields(rec), recordAssetName(rec)))
+			}
+		}
+		if !args.Options.Silent {
+			for _, f := range alert.Findings {
+				fmt.Fprintf(color.Output, "%s %s %s\n", fgR.Sprintf("[%s]", f.Severity), f.Title, blue(f.Attributes["url"]))
+			}
+			fmt.Fprintf(color.Error, "%s %s\n", green("New assets discovered:"), yellow(len(alert.Assets)))
+		}
+
+		// The collections named by the routes can change between the cycles
+		routeCollections(cfg, settings)
+		if err := settings.Notify(ctx, alert); err != nil {
+			cfg.Log.Printf("Failed to send the monitoring notifications: %v", err)
+			if !args.Options.Silent {
+				r.Fprintf(color.Error, "%v\n", err)
+			}
+		}
+		if err := sendDigests(ctx, cfg, g, settings); err != nil {
+			cfg.Log.Printf("Failed to send the monitoring digests: %v", err)
+			if !args.Options.Silent {
+				r.Fprintf(color.Error, "%v\n", err)
+			}
+		}
+
+		ms.Finish(time.Now())
+		if err := ms.Write(path); err != nil {
+			cfg.Log.Printf("Failed to write the monitoring session: %v", err)
+		}
+
+		t := time.NewTimer(settings.Interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// unknownAssets returns the assets first seen since the provided time that are not in the history database.
+func unknownAssets(h *netmap.Graph, assets []*types.Asset, since time.Time) []*types.Asset {
+	var results []*types.Asset
+
+	for _, a := range assets {
+		if a == nil || a.Asset == nil || a.CreatedAt.Before(since) {
+			continue
+		}
+		if known, err := h.DB.FindByContent(a.Asset, time.Time{}); err == nil && len(known) > 0 {
+			continue
+		}
+		results = append(results, a)
+	}
+	return results
+}
+
+// sendDigests posts the digests of the periods that have elapsed since the previous digests, summarizing the
+// assets that appeared or were no longer observed, and the findings recorded during the period.
+func sendDigests(ctx context.Context, cfg *config.Config, g *netmap.Graph, settings *monitor.Settings) error {
+	periods := settings.Digests()
+	if len(periods) == 0 {
+		return nil
+	}
+
+	path := filepath.Join(config.OutputDirectory(cfg.Dir), monitor.DigestStateFileName)
+	state, err := monitor.ReadDigestState(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var due bool
+	var errs []string
+	for _, period := range periods {
+		if !state.Due(period, now) {
+			continue
+		}
+		due = true
+
+		// The assets observed since the start of the period, or during this cycle for the first period
+		since := cfg.CollectionStartTime
+		if ps, found := state[period]; found {
+			since = ps.Since
+		}
+
+		var assets []*types.Asset
+		for _, atype := range monitor.AssetTypes {
+			if a, err := g.DB.FindByType(atype, since.UTC()); err == nil {
+				assets = append(assets, a...)
+			}
+		}
+
+		fs, _ := findings.Read(findingsPath(cfg))
+		d := state.Next(period, cfg.Domains(), assets, fs, now)
+		if d == nil {
+			continue
+		}
+		// The riskiest assets are listed first when the risk scores were computed
+		scores := savedScores(cfg)
+		scores.OrderRecords(d.Added)
+		scores.OrderRecords(d.Removed)
+		if err := settings.NotifyDigest(ctx, d); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if due {
+		if err := state.Write(path); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to write the digest state file: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// leakFindings searches the leak sources for mentions of the root domains, and records the
+// mentions that were not found during previous cycles or sessions as findings.
+func leakFindings(ctx context.Context, cfg *config.Config, srcs []leaks.Source) []*findings.Finding {
+	mentions, err := leaks.Search(ctx, srcs, cfg.Domains())
+	if err != nil {
+		cfg.Log.Printf("Failed to search the leak sources: %v", err)
+	}
+
+	recorded, _ := findings.Read(findingsPath(cfg))
+	fs := leaks.NewFindings(recorded, mentions)
+	recordFindings(cfg, fs)
+	return fs
+}
+
+// expirationFindings checks the registrations of the root domains, and records the domains expiring
+// within the number of days as findings, once for each expiration date.
+func expirationFindings(ctx context.Context, cfg *config.Config, within int) []*findings.Finding {
+	domains, err := expiry.NewChecker().Check(ctx, cfg.Domains())
+	if err != nil {
+		cfg.Log.Printf("Failed to check the domain registrations: %v", err)
+	}
+
+	recorded, _ := findings.Read(findingsPath(cfg))
+	fs := expiry.NewFindings(recorded, domains, within, time.Now())
+	recordFindings(cfg, fs)
+	return fs
+}
+
+// readCTLogs reads the entries added to the Certificate Transparency logs since the previous cycle, and
+// adds the in-scope names of the logged certificates to the graph database.
+func readCTLogs(ctx context.Context, cfg *config.Config, g *netmap.Graph, logs []*ctlog.Log) {
+	path := filepath.Join(config.OutputDirectory(cfg.Dir), ctlog.StateFileName)
+	state, err := ctlog.ReadState(path)
+	if err != nil {
+		cfg.Log.Printf("%v", err)
+		return
+	}
+
+	inScope := func(name string) bool {
+		return cfg.IsDomainInScope(name) && !cfg.Blacklisted(name)
+	}
+	for _, l := range logs {
+		entries, err := state.Read(ctx, l, ctlog.DefaultMaxEntries, inScope)
+		if err != nil {
+			cfg.Log.Printf("Failed to read the %s CT log: %v", l.Name, err)
+		}
+		if cp := state[l.URL]; cp != nil && cp.Lag() > 0 {
+			cfg.Log.Printf("The %s CT log has %d entries left to read", l.Name, cp.Lag())
+		}
+
+		for _, e := range entries {
+			for _, name := range e.Names {
+				if _, err := g.UpsertFQDN(ctx, name); err != nil {
+					cfg.Log.Printf("Failed to add %s from the %s CT log: %v", name, l.Name, err)
+				}
+			}
+		}
+	}
+
+	if err := state.Write(path); err != nil {
+		cfg.Log.Printf("Failed to write the CT log state file: %v", err)
+	}
+}
+
+// routeFindings checks the routes of the netblocks observed during the cycle for origin and upstream
+// changes, which are recorded as findings, and adds the new origins announcing them to the graph.
+func routeFindings(ctx context.Context, cfg *config.Config, g *netmap.Graph, start time.Time) []*findings.Finding {
+	netblocks, err := g.DB.FindByType(oam.Netblock, start.UTC())
+	if err != nil || len(netblocks) == 0 {
+		return nil
+	}
+
+	var prefixes []string
+	for _, a := range netblocks {
+		if nb, ok := a.Asset.(network.Netblock); ok {
+			prefixes = append(prefixes, nb.Cidr.String())
+		}
+	}
+
+	path := filepath.Join(config.OutputDirectory(cfg.Dir), bgp.StateFileName)
+	state, err := bgp.ReadState(path)
+	if err != nil {
+		cfg.Log.Printf("%v", err)
+		return nil
+	}
+
+	changes, err := state.Check(ctx, bgp.NewRIPEstat(), prefixes)
+	if err != nil {
+		cfg.Log.Printf("Failed to check the BGP routes: %v", err)
+	}
+	if err := state.Write(path); err != nil {
+		cfg.Log.Printf("Failed to write the BGP state file: %v", err)
+	}
+
+	var fs []*findings.Finding
+	for _, c := range changes {
+		fs = append(fs, c.Finding())
+		if c.Kind != bgp.ChangeOrigin {
+			continue
+		}
+		// The new origins are recorded as announcing the netblock
+		for _, asn := range c.Added() {
+			if err := upsertAnnouncement(ctx, g, asn, c.Prefix); err != nil {
+				cfg.Log.Printf("Failed to add the announcement of %s by AS%d: %v", c.Prefix, asn, err)
+			}
+		}
+	}
+	recordFindings(cfg, fs)
+	return fs
+}
+
+func upsertAnnouncement(ctx context.Context, g *netmap.Graph, asn int, prefix string) error {
+	netblock, err := g.UpsertNetblock(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	as, err := g.DB.Create(nil, "", &network.AutonomousSystem{Number: asn})
+	if err != nil {
+		return err
+	}
+
+	_, err = g.DB.Create(as, "announces", netblock.Asset)
+	return err
+}
+
+// routeCollections provides the members of the collections named by the routes of the notifications.
+func routeCollections(cfg *config.Config, settings *monitor.Settings) {
+	store, err := collections.ReadStore(collectionsPath(cfg))
+	if err == nil {
+		err = settings.SetCollections(store)
+	}
+	if err != nil {
+		cfg.Log.Printf("Failed to read the collections of the routes: %v", err)
+	}
+}
+
+func findingsPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), findings.FileName)
+}
+
+// recordFindings appends the findings to the findings file in the output directory.
+func recordFindings(cfg *config.Config, fs []*findings.Finding) {
+	if len(fs) == 0 {
+		return
+	}
+
+	l, err := findings.NewLog(findingsPath(cfg))
+	if err != nil {
+		cfg.Log.Printf("Failed to open the findings file: %v", err)
+		return
+	}
+	defer func() { _ = l.Close() }()
+	// The severity option was already validated by the enumeration
+	policy, _ := findings.ParsePolicy(cfg.Options["severity"], func(name string) bool {
+		return cfg.WhichDomain(name) == name
+	})
+	l.SetPolicy(policy)
+
+	for _, f := range fs {
+		if err := l.Add(f); err != nil {
+			cfg.Log.Printf("Failed to record the %s finding for %s: %v", f.Type, f.Asset, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/analyze"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+const (
+	orgsUsageMsg = "orgs [options] -d DOMAIN"
+)
+
+type orgsArgs struct {
+	Domains   *stringset.Set
+	Since     string
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+		JSONOutput string
+	}
+}
+
+func defineOrgsFlags(orgsFlags *flag.FlagSet, args *orgsArgs) {
+	orgsFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	orgsFlags.StringVar(&args.Since, "since", "", "Only include the assets seen since the date (YYYY-MM-DD or RFC3339) or duration (e.g. 72h)")
+	orgsFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	orgsFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	orgsFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
+	orgsFlags.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON output file providing the hierarchy of the entities")
+}
+
+func runOrgsCommand(clArgs []string) {
+	args := orgsArgs{
+		Domains: stringset.New(),
+	}
+	var help1, help2 bool
+	orgsCommand := flag.NewFlagSet("orgs", flag.ContinueOnError)
+
+	orgsBuf := new(bytes.Buffer)
+	orgsCommand.SetOutput(orgsBuf)
+
+	orgsCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	orgsCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineOrgsFlags(orgsCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(orgsUsageMsg, orgsCommand, orgsBuf)
+		return
+	}
+	if err := orgsCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(orgsUsageMsg, orgsCommand, orgsBuf)
+		return
+	}
+
+	since, err := parseSince(args.Since, time.Now())
+	if err != nil {
+		fatal(errUsage, err)
+	}
+
+	cfg, err := datasetConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, args.Domains, args.Filepaths.Domains)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	eg, err := exportGraph(ctx, cfg, sys.GraphDatabases()[0], since)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	roots := analyze.OrgChart(eg)
+	if len(roots) == 0 {
+		fmt.Fprintln(color.Error, "No registrant organizations were found. The enum subcommand collects them when the addresses are resolved")
+		return
+	}
+	for _, e := range roots {
+		printEntity(e, "", "")
+	}
+
+	if args.Filepaths.JSONOutput != "" {
+		f, err := os.Create(args.Filepaths.JSONOutput)
+		if err != nil {
+			fatalf(errIO, "Failed to create the JSON output file: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		w := format.NewRecordWriter(f)
+		for _, e := range roots {
+			_ = w.Write(e)
+		}
+	}
+}
+
+// printEntity shows the entity with its infrastructure and the tree of its subsidiaries.
+func printEntity(e *analyze.Entity, prefix, branch string) {
+	fmt.Fprintf(color.Output, "%s%s%s\n", prefix, white(branch), blue(e.Name))
+
+	// The lines below the entity continue the branches of the tree
+	indent := prefix
+	switch branch {
+	case "├── ":
+		indent += "│   "
+	case "└── ":
+		indent += "    "
+	}
+
+	details := []struct {
+		label string
+		list  []string
+	}{
+		{"Registrants", e.Registrants},
+		{"ASNs", e.ASNs},
+		{"Netblocks", e.Netblocks},
+		{"Domains", e.Domains},
+	}
+	bar := "    "
+	if len(e.Children) > 0 {
+		bar = "│   "
+	}
+	for _, d := range details {
+		if len(d.list) > 0 {
+			fmt.Fprintf(color.Output, "%s%s%s %s\n", indent, white(bar), yellow(d.label+":"), green(strings.Join(d.list, ", ")))
+		}
+	}
+
+	for i, c := range e.Children {
+		b := "├── "
+		if i == len(e.Children)-1 {
+			b = "└── "
+		}
+		printEntity(c, indent, b)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/assoc"
+	"github.com/owasp-amass/amass/v4/format"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/relations"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+const (
+	pathUsageMsg = "path [options] -from ASSET -to ASSET"
+)
+
+type pathArgs struct {
+	From      string
+	To        string
+	MaxDepth  int
+	MaxPaths  int
+	Since     string
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		JSONOutput string
+	}
+}
+
+func definePathFlags(pathFlags *flag.FlagSet, args *pathArgs) {
+	pathFlags.StringVar(&args.From, "from", "", "Asset the paths start from (name, address, CIDR, AS number or org:NAME)")
+	pathFlags.StringVar(&args.To, "to", "", "Asset the paths lead to (name, address, CIDR, AS number or org:NAME)")
+	pathFlags.IntVar(&args.MaxDepth, "max-depth", 10, "Maximum number of relations in a path")
+	pathFlags.IntVar(&args.MaxPaths, "max-paths", assoc.DefaultMaxPaths, "Maximum number of shortest paths to show")
+	pathFlags.StringVar(&args.Since, "since", "", "Only traverse the assets seen since the date (YYYY-MM-DD or RFC3339) or duration (e.g. 72h)")
+	pathFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	pathFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	pathFlags.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON output file providing the paths")
+}
+
+func runPathCommand(clArgs []string) {
+	var args pathArgs
+	var help1, help2 bool
+	pathCommand := flag.NewFlagSet("path", flag.ContinueOnError)
+
+	pathBuf := new(bytes.Buffer)
+	pathCommand.SetOutput(pathBuf)
+
+	pathCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	pathCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	definePathFlags(pathCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(pathUsageMsg, pathCommand, pathBuf)
+		return
+	}
+	if err := pathCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(pathUsageMsg, pathCommand, pathBuf)
+		return
+	}
+	if args.From == "" || args.To == "" {
+		fatalf(errUsage, "Both the -from and -to assets must be provided")
+	}
+
+	from, err := parsePathAsset(args.From)
+	if err != nil {
+		fatal(errUsage, err)
+	}
+	to, err := parsePathAsset(args.To)
+	if err != nil {
+		fatal(errUsage, err)
+	}
+
+	since, err := parseSince(args.Since, time.Now())
+	if err != nil {
+		fatal(errUsage, err)
+	}
+	if !since.IsZero() {
+		since = since.UTC()
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	g := sys.GraphDatabases()[0]
+	start, err := findPathAsset(g, from, since)
+	if err != nil {
+		fatal(lookupError(err), err)
+	}
+	end, err := findPathAsset(g, to, since)
+	if err != nil {
+		fatal(lookupError(err), err)
+	}
+
+	paths := assoc.ShortestPaths(start, end, allNeighbors(g, since, relationAliases(cfg)), assoc.PathOptions{
+		MaxDepth: args.MaxDepth,
+		MaxPaths: args.MaxPaths,
+	})
+	if len(paths) == 0 {
+		fatalf(errNoResults, "No path was found between %s and %s", args.From, args.To)
+	}
+
+	for i, p := range paths {
+		fmt.Fprintf(color.Output, "%s %s\n", fgY.Sprintf("Path %d:", i+1), white(fmt.Sprintf("%d relations", len(p))))
+		for _, s := range p {
+			fmt.Fprintf(color.Output, "\t%s %s %s %s %s\n", recordAssetName(s.From), white("-->"),
+				magenta(s.Relation), white("-->"), recordAssetName(s.To))
+		}
+	}
+
+	if args.Filepaths.JSONOutput != "" {
+		f, err := os.Create(args.Filepaths.JSONOutput)
+		if err != nil {
+			fatalf(errIO, "Failed to create the JSON output file: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		w := format.NewRecordWriter(f)
+		for _, p := range paths {
+			_ = w.Write(p)
+		}
+	}
+}
+
+// parsePathAsset returns the asset identified on the command line. The organizations are prefixed with
+// org:, and the other assets are recognized as addresses, netblocks, autonomous systems or names.
+func parsePathAsset(s string) (oam.Asset, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "org:") {
+		name := strings.TrimSpace(strings.TrimPrefix(s, "org:"))
+		if name == "" {
+			return nil, errors.New("the organization name must be provided after org:")
+		}
+		return network.RIROrganization{Name: name}, nil
+	}
+	if addr, err := netip.ParseAddr(s); err == nil {
+		t := "IPv4"
+		if addr.Is6() {
+			t = "IPv6"
+		}
+		return network.IPAddress{Address: addr, Type: t}, nil
+	}
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		t := "IPv4"
+		if prefix.Addr().Is6() {
+			t = "IPv6"
+		}
+		return network.Netblock{Cidr: prefix.Masked(), Type: t}, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(s), "AS")); err == nil && n > 0 {
+		return network.AutonomousSystem{Number: n}, nil
+	}
+	if s == "" || strings.ContainsAny(s, " /:") {
+		return nil, fmt.Errorf("%s is not a valid asset", s)
+	}
+	return domain.FQDN{Name: strings.ToLower(strings.Trim(s, "."))}, nil
+}
+
+// graphConfig returns the configuration for the graph database, which does not require the root domain names.
+func graphConfig(dir, cfgfile string) (*config.Config, error) {
+	cfg := config.NewConfig()
+	// Check if a configuration file was provided, and if so, load the settings
+	if err := config.AcquireConfig(dir, cfgfile, cfg); err != nil && cfgfile != "" {
+		return nil, fmt.Errorf("failed to load the configuration file: %v", err)
+	}
+	// Override configuration file settings with the environment variables
+	if err := cfg.UpdateConfig(environSettings(os.Environ())); err != nil {
+		return nil, fmt.Errorf("environment configuration error: %v", err)
+	}
+	if dir != "" {
+		cfg.Dir = dir
+	}
+	// The HTTP clients of the subcommands apply the proxies, TLS settings and response cache of the configuration
+	if err := amasshttp.Configure(cfg.Options["proxy"], cfg.Options["tls"], config.OutputDirectory(cfg.Dir), nil); err != nil {
+		return nil, fmt.Errorf("configuration error: %v", err)
+	}
+	return cfg, nil
+}
+
+// errAssetNotFound is wrapped by the errors for the assets missing from the graph database.
+var errAssetNotFound = errors.New("was not found in the graph database")
+
+// findPathAsset returns the asset in the graph database seen since the provided time.
+func findPathAsset(g *netmap.Graph, a oam.Asset, since time.Time) (*types.Asset, error) {
+	assets, err := g.DB.FindByContent(a, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the graph database: %v", err)
+	}
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("%s %w", format.AssetKey(a), errAssetNotFound)
+	}
+	return assets[0], nil
+}
+
+// lookupError returns the code of the error returned by findPathAsset.
+func lookupError(err error) string {
+	if errors.Is(err, errAssetNotFound) {
+		return errNoResults
+	}
+	return errDatabase
+}
+
+// allNeighbors returns the function providing the neighbors of the assets in the graph database through
+// the relations in both directions, without the restrictions applied to the associations, so any asset
+// in the graph database can be reached.
+func allNeighbors(g *netmap.Graph, since time.Time, aliases relations.Mapping) assoc.Neighbors {
+	return newAssetLookup(g, since, aliases).links
+}
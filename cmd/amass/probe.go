@@ -0,0 +1,93 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/probe"
+)
+
+const (
+	probeUsageMsg = "probe [options] -region REGION -token TOKEN"
+)
+
+type probeArgs struct {
+	CertFile     string
+	ClientCAFile string
+	KeyFile      string
+	Listen       string
+	Region       string
+	Resolver     string
+	Tokens       format.ParseStrings
+}
+
+func defineProbeFlags(probeFlags *flag.FlagSet, args *probeArgs) {
+	probeFlags.StringVar(&args.CertFile, "cert", "", "Path to the certificate file used to serve the probe API over TLS")
+	probeFlags.StringVar(&args.ClientCAFile, "client-ca", "", "Path to the CA file used to verify the engine certificates (mutual TLS)")
+	probeFlags.StringVar(&args.KeyFile, "key", "", "Path to the private key file of the probe certificate")
+	probeFlags.StringVar(&args.Listen, "listen", ":8443", "Address the probe API will be served on")
+	probeFlags.StringVar(&args.Region, "region", "", "Name of the region or vantage point reported by the probe")
+	probeFlags.StringVar(&args.Resolver, "r", "8.8.8.8", "IP address of the DNS resolver used by the probe")
+	probeFlags.Var(&args.Tokens, "token", "Bearer tokens accepted from the engines separated by commas (can be used multiple times)")
+}
+
+func runProbeCommand(clArgs []string) {
+	var args probeArgs
+	var help1, help2 bool
+	probeCommand := flag.NewFlagSet("probe", flag.ContinueOnError)
+
+	probeBuf := new(bytes.Buffer)
+	probeCommand.SetOutput(probeBuf)
+
+	probeCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	probeCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineProbeFlags(probeCommand, &args)
+
+	if err := probeCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(probeUsageMsg, probeCommand, probeBuf)
+		return
+	}
+	if args.Region == "" {
+		fatalf(errUsage, "The probe requires a region to be provided")
+	}
+	if len(args.Tokens) == 0 && args.ClientCAFile == "" && !probe.LoopbackAddr(args.Listen) {
+		fatalf(errUsage, "The probe requires a token or a client CA to listen on %s, which is not a loopback address", args.Listen)
+	}
+
+	var tlsConfig *tls.Config
+	if args.CertFile != "" || args.KeyFile != "" || args.ClientCAFile != "" {
+		var err error
+
+		tlsConfig, err = probe.ServerTLSConfig(args.CertFile, args.KeyFile, args.ClientCAFile)
+		if err != nil {
+			fatal(errConfig, err)
+		}
+	} else if len(args.Tokens) > 0 {
+		fgY.Fprintln(color.Error, "The probe API is not served over TLS, so the tokens are sent in cleartext")
+	}
+
+	s, err := probe.NewServer(args.Listen, args.Region, args.Resolver, args.Tokens, tlsConfig)
+	if err != nil {
+		fatal(errNetwork, err)
+	}
+	defer func() { _ = s.Close() }()
+	g.Fprintf(color.Error, "The %s probe is listening on %s\n", args.Region, s.Addr())
+
+	// Run until the user interrupts the probe
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+}
@@ -0,0 +1,86 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/progress"
+)
+
+// progressInterval is how often the progress of the bounded operations is reported.
+const progressInterval = 30 * time.Second
+
+// startProgress reports the progress of the brute forcing and sweeps on the terminal, unless silent, and
+// writes it to the progress stream at the path, until the returned function is called.
+func startProgress(path string, silent bool) func() {
+	w, closeStream := openProgressStream(path)
+	if w == nil && silent {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		t := time.NewTicker(progressInterval)
+		defer t.Stop()
+		// The operations are only reported once when completed
+		completed := make(map[string]bool)
+		for {
+			select {
+			case <-done:
+				reportProgress(w, silent, completed)
+				return
+			case <-t.C:
+				reportProgress(w, silent, completed)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		closeStream()
+	}
+}
+
+func reportProgress(w *progress.Writer, silent bool, completed map[string]bool) {
+	for _, s := range progress.Default.Statuses(time.Now()) {
+		if completed[s.Operation] && s.Completed() {
+			continue
+		}
+		completed[s.Operation] = s.Completed()
+
+		if !silent {
+			fmt.Fprintf(color.Error, "%s %s\n", blue("Progress:"), yellow(s.String()))
+		}
+		if w != nil {
+			_ = w.Write(s)
+		}
+	}
+}
+
+func openProgressStream(path string) (*progress.Writer, func()) {
+	switch path {
+	case "":
+		return nil, func() {}
+	case "-":
+		return progress.NewWriter(os.Stdout), func() {}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		fatalf(errIO, "Failed to open the progress stream: %v", err)
+	}
+	return progress.NewWriter(f), func() {
+		_ = f.Sync()
+		_ = f.Close()
+	}
+}
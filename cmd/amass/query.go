@@ -0,0 +1,268 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/query"
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+const (
+	queryUsageMsg = "query [options] NAME [-PARAM value ...] | -e QUERY"
+)
+
+type queryArgs struct {
+	Domains    *stringset.Set
+	Expression string
+	Federate   bool
+	Format     string
+	List       bool
+	Page       query.Page
+	Since      string
+	Filters    filterArgs
+	Filepaths  struct {
+		ConfigFile string
+		Directory  string
+		JSONOutput string
+	}
+}
+
+func defineQueryFlags(queryFlags *flag.FlagSet, args *queryArgs) {
+	queryFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	queryFlags.StringVar(&args.Expression, "e", "", "Query to run instead of a saved query, e.g. 'fqdn resolving under example.com'")
+	queryFlags.BoolVar(&args.Federate, "federate", false, "Also search the databases of the federation option, labeling the assets with their origins")
+	queryFlags.StringVar(&args.Format, "format", "", "Go template formatting each selected asset, e.g. '{{.Name}},{{.IP}}'")
+	queryFlags.BoolVar(&args.List, "list", false, "Print the saved queries and their parameters")
+	definePageFlags(queryFlags, &args.Page)
+	defineFilterFlags(queryFlags, &args.Filters)
+	queryFlags.StringVar(&args.Since, "since", "", "Only query the assets seen since the date (YYYY-MM-DD or RFC3339) or duration (e.g. 72h)")
+	queryFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	queryFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	queryFlags.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON output file providing the selected assets")
+}
+
+func runQueryCommand(clArgs []string) {
+	args := queryArgs{
+		Domains: stringset.New(),
+	}
+	var help1, help2 bool
+	queryCommand := flag.NewFlagSet("query", flag.ContinueOnError)
+
+	queryBuf := new(bytes.Buffer)
+	queryCommand.SetOutput(queryBuf)
+
+	queryCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	queryCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineQueryFlags(queryCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(queryUsageMsg, queryCommand, queryBuf)
+		return
+	}
+	// The saved query is named before the flags, so its parameters can be provided as flags
+	var name string
+	if !strings.HasPrefix(clArgs[0], "-") {
+		name, clArgs = clArgs[0], clArgs[1:]
+	}
+
+	// The saved queries are loaded before the flags are parsed, since they define the parameter flags
+	cfg, err := graphConfig(argValue(clArgs, "dir"), argValue(clArgs, "config"))
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	saved, err := query.ParseSaved(cfg.Options["queries"])
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	params := make(map[string]*string)
+	if name != "" {
+		s, found := saved[name]
+		if !found {
+			fatalf(errUsage, "%s is not a saved query", name)
+		}
+
+		for _, p := range s.Params() {
+			if queryCommand.Lookup(p) != nil {
+				fatalf(errUsage, "The %s parameter of the %s query conflicts with the %s flag", p, name, p)
+			}
+			params[p] = queryCommand.String(p, "", "Value of the "+p+" parameter of the query")
+		}
+		args.Expression = s.Expression
+	}
+
+	if err := queryCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(queryUsageMsg, queryCommand, queryBuf)
+		return
+	}
+	if args.List {
+		printSavedQueries(saved)
+		return
+	}
+	if args.Expression == "" {
+		commandUsage(queryUsageMsg, queryCommand, queryBuf)
+		exitWithCode(errUsage, "A saved query or the -e flag must be provided")
+	}
+	if err := args.Page.Validate(); err != nil {
+		fatal(errUsage, err)
+	}
+
+	values := make(map[string]string)
+	for p, v := range params {
+		values[p] = *v
+	}
+	expr, err := query.Expand(args.Expression, values)
+	if err != nil {
+		fatal(errUsage, err)
+	}
+
+	q, err := query.Parse(expr)
+	if err != nil {
+		fatal(errUsage, err)
+	}
+
+	since, err := parseSince(args.Since, time.Now())
+	if err != nil {
+		fatal(errUsage, err)
+	}
+	// The assets are collected from the names under the root domains
+	cfg.AddDomains(args.Domains.Slice()...)
+	if q.Under != "" {
+		cfg.AddDomain(q.Under)
+	}
+	if len(cfg.Domains()) == 0 {
+		fatalf(errConfig, "The query requires root domain names, provided by the -d flag, the configuration or the under clause")
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	eg, err := federatedGraph(ctx, cfg, sys.GraphDatabases()[0], since, args.Federate)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	filterGraph(eg, nameFilter(cfg, &args.Filters), cfg.Domains())
+
+	q.Aliases = relationAliases(cfg)
+	// The where clauses are satisfied by the findings recorded by the enumerations
+	if len(q.Where) > 0 {
+		if q.Findings, err = findings.Read(findingsPath(cfg)); err != nil {
+			fatalf(errIO, "Failed to read the findings: %v", err)
+		}
+	}
+
+	tmpls := outputTemplates(cfg, args.Format)
+	all := q.Run(eg)
+	// Only the page of the results is listed, since the graph can hold millions of assets
+	indices, next := args.Page.Select(len(all), func(i int) string { return all[i].Key })
+
+	results := make([]*format.AssetRecord, 0, len(indices))
+	for _, i := range indices {
+		results = append(results, all[i])
+	}
+	for _, a := range results {
+		line := green(a.Key)
+		if len(a.Origins) > 0 {
+			line += " " + blue("["+strings.Join(a.Origins, ",")+"]")
+		}
+		fmt.Fprintln(color.Output, templateLine(tmpls, format.NewFields(a), line))
+	}
+
+	if args.Filepaths.JSONOutput != "" {
+		f, err := os.Create(args.Filepaths.JSONOutput)
+		if err != nil {
+			fatalf(errIO, "Failed to create the JSON output file: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		w := format.NewRecordWriter(f)
+		for _, a := range results {
+			_ = w.Write(a)
+		}
+	}
+	fmt.Fprintf(color.Error, "%s of %s assets selected by the query were listed\n", green(len(results)), green(len(all)))
+	printNextCursor(next)
+}
+
+// definePageFlags adds the flags selecting part of the listed results.
+func definePageFlags(fs *flag.FlagSet, p *query.Page) {
+	fs.IntVar(&p.Limit, "limit", 0, "Maximum number of results listed")
+	fs.IntVar(&p.Offset, "offset", 0, "Number of results skipped before the listing starts")
+	fs.StringVar(&p.Cursor, "cursor", "", "Continue the listing after the previous page, using the cursor printed with it")
+	fs.IntVar(&p.Sample, "sample", 0, "Number of results selected at random")
+}
+
+// printNextCursor shows how to continue the listing when more results follow the page.
+func printNextCursor(next string) {
+	if next != "" {
+		fmt.Fprintf(color.Error, "More results follow, continue the listing using %s\n", yellow("-cursor "+next))
+	}
+}
+
+// printSavedQueries shows the saved queries with their parameters and descriptions.
+func printSavedQueries(saved map[string]*query.Saved) {
+	if len(saved) == 0 {
+		fmt.Fprintln(color.Error, "No queries have been saved in the queries section of the configuration")
+		return
+	}
+
+	names := make([]string, 0, len(saved))
+	for name := range saved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := saved[name]
+
+		var flags []string
+		for _, p := range s.Params() {
+			flags = append(flags, "-"+p+" VALUE")
+		}
+		fmt.Fprintf(color.Output, "%s %s\n", green(name), yellow(strings.Join(flags, " ")))
+		if s.Description != "" {
+			fmt.Fprintf(color.Output, "\t%s\n", s.Description)
+		}
+		fmt.Fprintf(color.Output, "\t%s\n", blue(s.Expression))
+	}
+}
+
+// argValue returns the value of the named flag in the command-line arguments before they are parsed.
+func argValue(args []string, name string) string {
+	for i, arg := range args {
+		arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, name+"=") {
+			return strings.TrimPrefix(arg, name+"=")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os/user"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/relations"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	relateUsageMsg = "relate [options] -from ASSET -relation TYPE -to ASSET -reason TEXT"
+)
+
+type relateArgs struct {
+	From      string
+	Relation  string
+	To        string
+	Analyst   string
+	Reason    string
+	Create    bool
+	List      bool
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineRelateFlags(relateFlags *flag.FlagSet, args *relateArgs) {
+	relateFlags.StringVar(&args.From, "from", "", "Asset the relation starts from, as TYPE:KEY or the key alone")
+	relateFlags.StringVar(&args.Relation, "relation", "", "Type of the relation, e.g. managed_by")
+	relateFlags.StringVar(&args.To, "to", "", "Asset the relation leads to, as TYPE:KEY or the key alone")
+	relateFlags.StringVar(&args.Analyst, "analyst", "", "Identity of the analyst asserting the relation (default: the user name)")
+	relateFlags.StringVar(&args.Reason, "reason", "", "Why the relation is asserted, such as the document supporting it")
+	relateFlags.BoolVar(&args.Create, "create", false, "Add the assets missing from the graph database, rather than refusing the relation")
+	relateFlags.BoolVar(&args.List, "list", false, "List the relations asserted previously")
+	relateFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	relateFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+}
+
+func runRelateCommand(clArgs []string) {
+	var args relateArgs
+	var help1, help2 bool
+	relateCommand := flag.NewFlagSet("relate", flag.ContinueOnError)
+
+	relateBuf := new(bytes.Buffer)
+	relateCommand.SetOutput(relateBuf)
+
+	relateCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	relateCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineRelateFlags(relateCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(relateUsageMsg, relateCommand, relateBuf)
+		return
+	}
+	if err := relateCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(relateUsageMsg, relateCommand, relateBuf)
+		return
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	path := assertionsPath(cfg)
+
+	if args.List {
+		as, err := relations.ReadAssertions(path)
+		if err != nil {
+			fatalf(errIO, "Failed to read the asserted relations: %v", err)
+		}
+		if len(as) == 0 {
+			fatalf(errNoResults, "No relations have been asserted")
+		}
+		for _, a := range as {
+			printAssertion(a)
+		}
+		return
+	}
+
+	if args.From == "" || args.Relation == "" || args.To == "" {
+		fatalf(errUsage, "The -from, -relation and -to flags must be provided")
+	}
+	// The reason is the audit trail of the relation, since the graph database cannot explain it
+	if args.Reason == "" {
+		fatalf(errUsage, "The -reason flag must explain why the relation is asserted")
+	}
+	if args.Analyst == "" {
+		if u, err := user.Current(); err == nil {
+			args.Analyst = u.Username
+		}
+	}
+
+	from, err := collections.ParseMember(args.From)
+	if err != nil {
+		fatal(errUsage, err)
+	}
+	to, err := collections.ParseMember(args.To)
+	if err != nil {
+		fatal(errUsage, err)
+	}
+
+	a := &relations.Assertion{
+		From:     from,
+		Relation: args.Relation,
+		To:       to,
+		Analyst:  args.Analyst,
+		Reason:   args.Reason,
+	}
+	aliases := relationAliases(cfg)
+	if err := aliases.Validate(a); err != nil {
+		fatal(errUsage, err)
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	if err := aliases.Assert(sys.GraphDatabases()[0].DB, a, args.Create); err != nil {
+		fatal(errDatabase, err)
+	}
+	if err := relations.RecordAssertion(path, a); err != nil {
+		fatalf(errIO, "The relation was stored, but the assertion could not be recorded: %v", err)
+	}
+	printAssertion(a)
+}
+
+// assertionsPath returns the path of the file recording the asserted relations in the output directory.
+func assertionsPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), relations.AssertionsFileName)
+}
+
+// printAssertion shows the asserted relation with the analyst, the time and the reason.
+func printAssertion(a *relations.Assertion) {
+	fmt.Fprintf(color.Output, "%s %s %s %s\n", green(a.From.ID()), magenta(a.Relation), green(a.To.ID()),
+		yellow(fmt.Sprintf("(%s by %s on %s)", a.Source, a.Analyst, a.Time.Format("2006-01-02 15:04"))))
+	if a.Reason != "" {
+		fmt.Fprintf(color.Output, "\t%s\n", a.Reason)
+	}
+}
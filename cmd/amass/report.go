@@ -0,0 +1,777 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/analyze"
+	"github.com/owasp-amass/amass/v4/dbindex"
+	"github.com/owasp-amass/amass/v4/expiry"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/inventory"
+	"github.com/owasp-amass/amass/v4/pdns"
+	"github.com/owasp-amass/amass/v4/quality"
+	"github.com/owasp-amass/amass/v4/query"
+	"github.com/owasp-amass/amass/v4/risk"
+	"github.com/owasp-amass/amass/v4/sightings"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/amass/v4/trash"
+	"github.com/owasp-amass/amass/v4/views"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	reportUsageMsg = "report [options] -d DOMAIN REPORT"
+)
+
+type reportArgs struct {
+	Domains   *stringset.Set
+	Page      query.Page
+	Within    int
+	Workers   int
+	Repair    bool
+	Vacuum    bool
+	Refresh   bool
+	Where     conditionList
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+	}
+}
+
+func defineReportFlags(reportFlags *flag.FlagSet, args *reportArgs) {
+	reportFlags.Var(&pipedDomains{args.Domains}, "d", "Domain names separated by commas, or - to read them from the standard input (can be used multiple times)")
+	reportFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	reportFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+	reportFlags.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names, or - for the standard input")
+	reportFlags.IntVar(&args.Within, "within", expiry.DefaultWithin, "Number of days before the expiration when the domains are highlighted")
+	reportFlags.IntVar(&args.Workers, "workers", expiry.DefaultWorkers, "Number of root domains checked concurrently by the expirations report")
+	reportFlags.BoolVar(&args.Repair, "repair", false, "Repair the issues found by the quality report in the graph database")
+	reportFlags.BoolVar(&args.Refresh, "refresh", false, "Rebuild the views and risk scores read by the gaps, resolutions, services and risk reports")
+	reportFlags.BoolVar(&args.Vacuum, "vacuum", false, "Remove the unreferenced relations and sightings before the storage report")
+	reportFlags.Var(&args.Where, "where", "Only list the findings matching KEY=VALUE or KEY~VALUE, e.g. registrar~namecheap (can be used multiple times)")
+	definePageFlags(reportFlags, &args.Page)
+}
+
+func runReportCommand(clArgs []string) {
+	args := reportArgs{
+		Domains: stringset.New(),
+	}
+	var help1, help2 bool
+	reportCommand := flag.NewFlagSet("report", flag.ContinueOnError)
+
+	reportBuf := new(bytes.Buffer)
+	reportCommand.SetOutput(reportBuf)
+
+	reportCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	reportCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineReportFlags(reportCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(reportUsageMsg, reportCommand, reportBuf)
+		return
+	}
+	if err := reportCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 || reportCommand.NArg() != 1 {
+		commandUsage(reportUsageMsg, reportCommand, reportBuf)
+		fmt.Fprintf(color.Error, "%s\n", blue("Reports:"))
+		fmt.Fprintf(color.Error, "\t%-11s - Timeline of the past A, AAAA, NS and MX records of each name from the DNS history sources\n", "dns-history")
+		fmt.Fprintf(color.Error, "\t%-11s - Root domains by expiration date, with the summaries for each TLD and registrar\n", "expirations")
+		fmt.Fprintf(color.Error, "\t%-11s - All the findings, with the highest severity first\n", "findings")
+		fmt.Fprintf(color.Error, "\t%-11s - Discovered names missing from the intended inventory, and inventory entries never observed\n", "gaps")
+		fmt.Fprintf(color.Error, "\t%-11s - Missing indexes, sequential scans and slow queries of the PostgreSQL graph database\n", "indexes")
+		fmt.Fprintf(color.Error, "\t%-11s - Addresses with observed names in each netblock, with the densest netblocks first\n", "netblocks")
+		fmt.Fprintf(color.Error, "\t%-11s - Orphaned assets, invalid relations and duplicates in the graph database\n", "quality")
+		fmt.Fprintf(color.Error, "\t%-11s - Latest addresses of each name, following the CNAME records\n", "resolutions")
+		fmt.Fprintf(color.Error, "\t%-11s - Risk score of each asset and the exposure signals contributing to it\n", "risk")
+		fmt.Fprintf(color.Error, "\t%-11s - Ports of the addresses confirmed to serve the names\n", "services")
+		fmt.Fprintf(color.Error, "\t%-11s - Assets, relations and approximate size of each asset type in the graph database\n", "storage")
+		fmt.Fprintf(color.Error, "\t%-11s - Wildcard certificates, the hosts serving them and the age of their keys\n", "wildcards")
+		return
+	}
+
+	if err := args.Page.Validate(); err != nil {
+		fatal(errUsage, err)
+	}
+	if args.Within < 0 {
+		fatalf(errUsage, "The within flag cannot be negative")
+	}
+
+	cfg, err := datasetConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, args.Domains, args.Filepaths.Domains)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	// The reports document the authorization under which the assets were collected
+	if e := sessionEngagement(cfg); e != nil {
+		fmt.Fprintf(color.Output, "%s\n\n", yellow("Engagement: "+e.String()))
+	}
+
+	switch reportCommand.Arg(0) {
+	case "dns-history":
+		printDNSHistory(cfg)
+	case "expirations":
+		printExpirations(cfg, args.Within, args.Workers)
+	case "findings":
+		printFindings(cfg, args.Where, &args.Page)
+	case "gaps":
+		printGaps(cfg, args.Refresh, args.Where, &args.Page)
+	case "indexes":
+		printIndexAdvice(cfg, args.Page.Limit)
+	case "netblocks":
+		printNetblockUtilization(cfg)
+	case "quality":
+		printQuality(cfg, args.Repair)
+	case "resolutions":
+		printResolutions(cfg, args.Refresh)
+	case "risk":
+		printRisk(cfg, args.Refresh, &args.Page)
+	case "services":
+		printServices(cfg, args.Refresh)
+	case "storage":
+		printStorage(cfg, args.Vacuum)
+	case "wildcards":
+		printWildcardCertificates(cfg)
+	default:
+		fatalf(errUsage, "%s is not a supported report", reportCommand.Arg(0))
+	}
+}
+
+// printFindings lists the findings recorded by previous enumerations matching the conditions, with the highest severity first.
+func printFindings(cfg *config.Config, where []*findings.Condition, page *query.Page) {
+	all, err := findings.Read(findingsPath(cfg))
+	if err != nil {
+		fatalf(errIO, "Failed to read the findings: %v", err)
+	}
+
+	var fs []*findings.Finding
+	for _, f := range all {
+		if findings.MatchAll(f, where) {
+			fs = append(fs, f)
+		}
+	}
+
+	findings.SortBySeverity(fs)
+	indices, next := page.Select(len(fs), func(i int) string {
+		return strings.Join([]string{fs[i].Type, fs[i].Asset, fs[i].Time.Format(time.RFC3339Nano)}, "|")
+	})
+	for _, i := range indices {
+		f := fs[i]
+
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", fgR.Sprintf("[%s]", f.Severity),
+			green(f.Asset), f.Title, blue(f.Time.Format("2006-01-02")))
+	}
+	printNextCursor(next)
+}
+
+// printGaps lists the discovered names missing from the intended inventory and the entries of the inventory
+// never observed externally, matching the conditions, with the highest severity first.
+func printGaps(cfg *config.Config, refresh bool, where []*findings.Condition, page *query.Page) {
+	inv, err := inventory.Read(inventoryPath(cfg))
+	if err != nil {
+		fatal(errIO, err)
+	}
+	if len(inv.Entries) == 0 {
+		fatalf(errNoResults, "No intended inventory was imported, use the inventory subcommand first")
+	}
+
+	policy, err := findings.ParsePolicy(cfg.Options["severity"], func(name string) bool {
+		return cfg.WhichDomain(name) == name
+	})
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	v := loadViews(cfg, refresh)
+	var discovered []*inventory.Discovered
+	for _, res := range v.Resolutions {
+		if cfg.IsDomainInScope(res.Name) {
+			discovered = append(discovered, &inventory.Discovered{
+				Name:      res.Name,
+				Aliases:   res.Aliases,
+				Addresses: res.Addresses,
+			})
+		}
+	}
+
+	var fs []*findings.Finding
+	for _, f := range inventory.Gaps(inv, discovered, cfg.IsDomainInScope, policy) {
+		if findings.MatchAll(f, where) {
+			fs = append(fs, f)
+		}
+	}
+
+	indices, next := page.Select(len(fs), func(i int) string {
+		return fs[i].Type + "|" + fs[i].Asset
+	})
+	for _, i := range indices {
+		f := fs[i]
+
+		detail := f.Attributes["addresses"]
+		if f.Type == inventory.TypeStale {
+			detail = f.Attributes["origins"]
+		}
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", fgR.Sprintf("[%s]", f.Severity), green(f.Asset), f.Title, yellow(detail))
+	}
+	printNextCursor(next)
+	if len(fs) == 0 {
+		fmt.Fprintln(color.Error, "No gaps were found between the intended inventory and the discovered names")
+	}
+	printViewsAge(v)
+}
+
+// conditionList implements the flag.Value interface for the conditions selecting the findings, which can contain commas.
+type conditionList []*findings.Condition
+
+func (c *conditionList) String() string {
+	if c == nil {
+		return ""
+	}
+
+	var exprs []string
+	for _, cond := range *c {
+		op := "="
+		if cond.Contains {
+			op = "~"
+		}
+		exprs = append(exprs, cond.Key+op+cond.Value)
+	}
+	return strings.Join(exprs, " ")
+}
+
+// Set implements the flag.Value interface.
+func (c *conditionList) Set(s string) error {
+	cond, err := findings.ParseCondition(s)
+	if err != nil {
+		return err
+	}
+
+	*c = append(*c, cond)
+	return nil
+}
+
+// printExpirations lists the root domains by their expiration date and summarizes the registrations for each TLD
+// and registrar, highlighting the domains expiring within the number of days so they can be renewed in time.
+func printExpirations(cfg *config.Config, within, workers int) {
+	domains := cfg.Domains()
+	if len(domains) == 0 {
+		fatalf(errConfig, "No root domain names were provided")
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	checker := expiry.NewChecker()
+	checker.Workers = workers
+
+	regs, err := checker.Check(ctx, domains)
+	if err != nil {
+		fmt.Fprintf(color.Error, "%v\n", err)
+	}
+
+	now := time.Now()
+	for _, d := range regs {
+		expires, left := "unknown", ""
+		if !d.Expires.IsZero() {
+			expires = d.Expires.Format("2006-01-02")
+			left = fmt.Sprintf("%d days", d.DaysLeft(now))
+		}
+
+		status := green
+		if d.ExpiresWithin(within, now) {
+			status = fgR.SprintFunc()
+		}
+		fmt.Fprintf(color.Output, "%s %s %s %s %s\n", status(fmt.Sprintf("%-10s", expires)),
+			yellow(fmt.Sprintf("%-9s", left)), green(d.Domain), blue(d.Registrar), white(d.Source))
+	}
+
+	summaries := []struct {
+		label string
+		list  []*expiry.Summary
+	}{
+		{"TLDs", expiry.ByTLD(regs, within, now)},
+		{"Registrars", expiry.ByRegistrar(regs, within, now)},
+	}
+	for _, s := range summaries {
+		fmt.Fprintf(color.Output, "\n%s\n", blue(s.label+":"))
+		for _, sum := range s.list {
+			next := "unknown"
+			if !sum.Next.IsZero() {
+				next = sum.Next.Format("2006-01-02")
+			}
+			fmt.Fprintf(color.Output, "\t%s %s domains, %s expiring within %d days, next expiration %s\n",
+				green(sum.Key), yellow(sum.Domains), yellow(sum.Expiring), within, next)
+		}
+	}
+}
+
+// printWildcardCertificates lists the in-scope wildcard certificates observed by active enumerations.
+func printWildcardCertificates(cfg *config.Config) {
+	fs, err := findings.Read(filepath.Join(config.OutputDirectory(cfg.Dir), findings.FileName))
+	if err != nil {
+		fatalf(errIO, "Failed to read the findings: %v", err)
+	}
+
+	certs := findings.WildcardCertificates(fs, func(name string) bool {
+		return cfg.IsDomainInScope(strings.TrimPrefix(name, "*."))
+	})
+	if len(certs) == 0 {
+		fmt.Fprintln(color.Error, "No wildcard certificates have been observed. The enum subcommand records them when using -active")
+		return
+	}
+
+	now := time.Now()
+	for _, c := range certs {
+		days := int(c.KeyAge(now).Hours() / 24)
+
+		fmt.Fprintf(color.Output, "%s %s key used by %s certificates on %s hosts, %s days old, expires %s\n",
+			green(strings.Join(c.Names, ", ")), blue(shortKey(c.Key)), yellow(len(c.Fingerprints)),
+			yellow(len(c.Hosts)), yellow(days), c.NotAfter.Format("2006-01-02"))
+		for _, host := range c.Hosts {
+			fmt.Fprintf(color.Output, "\t%s\n", host)
+		}
+	}
+}
+
+// printDNSHistory shows the timeline of the past records of each in-scope name, as observed by the
+// DNS history sources selected by the dns_history option during the enumerations.
+func printDNSHistory(cfg *config.Config) {
+	fs, err := findings.Read(findingsPath(cfg))
+	if err != nil {
+		fatalf(errIO, "Failed to read the findings: %v", err)
+	}
+
+	timeline := pdns.Timeline(fs, cfg.IsDomainInScope)
+	if len(timeline) == 0 {
+		fmt.Fprintln(color.Error, "No DNS history has been recorded. The enum subcommand records it when using the dns_history option")
+		return
+	}
+
+	var name string
+	for _, r := range timeline {
+		if r.Name != name {
+			name = r.Name
+			fmt.Fprintln(color.Output, green(name))
+		}
+
+		fmt.Fprintf(color.Output, "\t%s %s %s to %s %s\n", yellow(fmt.Sprintf("%-4s", r.Type)), r.Value,
+			blue(historyDate(r.FirstSeen)), blue(historyDate(r.LastSeen)), strings.Join(r.Sources, ","))
+	}
+}
+
+func historyDate(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format("2006-01-02")
+}
+
+// printNetblockUtilization lists the in-scope netblocks by the fraction of their addresses with observed names,
+// so the dense netblocks can be selected for deeper active scanning and the empty netblocks deprioritized.
+func printNetblockUtilization(cfg *config.Config) {
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	eg, err := exportGraph(ctx, cfg, sys.GraphDatabases()[0], time.Time{})
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	usage := analyze.NetblockUtilization(eg)
+	if len(usage) == 0 {
+		fmt.Fprintln(color.Error, "No netblocks were found for the names in the graph database")
+		return
+	}
+
+	for _, u := range usage {
+		class := white
+		switch u.Class {
+		case analyze.UtilizationDense:
+			class = green
+		case analyze.UtilizationEmpty:
+			class = yellow
+		}
+
+		size := fmt.Sprintf("2^%.0f", math.Log2(u.Size))
+		if u.Size <= 1<<32 {
+			size = fmt.Sprintf("%.0f", u.Size)
+		}
+		fmt.Fprintf(color.Output, "%s %s %s addresses used of %s (%s), %s names\n", class(fmt.Sprintf("%-7s", u.Class)),
+			blue(u.Netblock), yellow(u.Used), size, fmt.Sprintf("%.2f%%", u.Utilization*100), yellow(u.Names))
+	}
+}
+
+// printQuality lists the issues found in the graph database, and repairs them when requested.
+func printQuality(cfg *config.Config, repair bool) {
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	db := sys.GraphDatabases()[0].DB
+	g, err := quality.Load(ctx, db)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	report := quality.Check(g)
+	if repair {
+		// The removals are kept in the trash, so a repair made by mistake can be restored
+		path := trashPath(cfg)
+		t, err := trash.Read(path)
+		if err != nil {
+			fatal(errIO, err)
+		}
+
+		bin := trash.NewBin(db, t, "report -repair", trash.CurrentActor(), "")
+		bin.Known(g.Relations)
+		if _, err := quality.Repair(ctx, bin, g, report); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+		}
+		if err := t.Write(path); err != nil {
+			r.Fprintf(color.Error, "Failed to write the trash: %v\n", err)
+		}
+	}
+
+	for _, i := range report.Issues {
+		subject := i.Asset
+		if subject == "" {
+			subject = i.AssetID
+		}
+		if i.Relation != "" {
+			subject += " " + i.Relation
+		}
+
+		var status string
+		if i.Repaired {
+			status = green(" (repaired)")
+		} else if i.Fix != "" && i.Type == quality.IssueInvalidRelation {
+			status = yellow(" (did you mean " + i.Fix + "?)")
+		}
+		fmt.Fprintf(color.Output, "%s %s %s%s\n", blue(fmt.Sprintf("%-18s", i.Type)), green(subject), white(i.Detail), status)
+	}
+
+	counts := report.Counts()
+	fmt.Fprintf(color.Output, "\n%s assets and %s relations were checked: %s orphaned assets, %s invalid relations, "+
+		"%s duplicate relations, %s duplicate assets\n", green(report.Assets), green(report.Relations),
+		yellow(counts[quality.IssueOrphanedAsset]), yellow(counts[quality.IssueInvalidRelation]),
+		yellow(counts[quality.IssueDuplicateRelation]), yellow(counts[quality.IssueDuplicateAsset]))
+}
+
+// printIndexAdvice lists the missing indexes, the tables read by sequential scans and the slowest queries
+// of the PostgreSQL graph database, creating the missing indexes when the system is set up.
+func printIndexAdvice(cfg *config.Config, limit int) {
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	dsn := sys.PostgresDSN()
+	if dsn == "" {
+		fatalf(errConfig, "The indexes report requires a PostgreSQL graph database")
+	}
+
+	db, err := dbindex.Open(dsn)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+	defer db.Close()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if limit == 0 {
+		limit = 10
+	}
+	report, err := dbindex.Advise(ctx, db, limit)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	for _, h := range report.Hotspots {
+		fmt.Fprintf(color.Output, "%s %s %s\n", blue(fmt.Sprintf("%-16s", h.Kind)), green(h.Subject), white(h.Detail))
+	}
+	if len(report.Hotspots) == 0 {
+		fmt.Fprintln(color.Output, "No hotspots were found in the graph database")
+	}
+	if !report.Statements {
+		fmt.Fprintln(color.Error, "The slow queries are reported once the pg_stat_statements extension is enabled")
+	}
+}
+
+// loadViews returns the views saved by the last enumeration, or rebuilds and saves them
+// from the graph database and the findings when requested or not saved yet.
+func loadViews(cfg *config.Config, refresh bool) *views.Views {
+	path := filepath.Join(config.OutputDirectory(cfg.Dir), views.FileName)
+	if !refresh {
+		if v, err := views.Load(path); err == nil {
+			return v
+		}
+	}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	fs, _ := findings.Read(findingsPath(cfg))
+	v, err := views.Build(ctx, sys.GraphDatabases()[0].DB, fs, func(name string) bool {
+		return cfg.IsDomainInScope(name)
+	})
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+	if err := views.Save(path, v); err != nil {
+		r.Fprintf(color.Error, "Failed to save the views: %v\n", err)
+	}
+	return v
+}
+
+// loadScores returns the risk scores saved by the last enumeration, or rebuilds and saves them from the views
+// and the findings when requested or not saved yet, using the weights of the risk option when provided.
+func loadScores(cfg *config.Config, refresh bool) *risk.Scores {
+	path := filepath.Join(config.OutputDirectory(cfg.Dir), risk.FileName)
+	if !refresh {
+		if s, err := risk.Load(path); err == nil {
+			return s
+		}
+	}
+
+	scorer, err := risk.ParseScorer(cfg.Options["risk"])
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	if scorer == nil {
+		scorer = risk.NewScorer()
+	}
+
+	fs, _ := findings.Read(findingsPath(cfg))
+	s := scorer.Build(loadViews(cfg, refresh), fs, time.Now())
+	if err := risk.Save(path, s); err != nil {
+		r.Fprintf(color.Error, "Failed to save the risk scores: %v\n", err)
+	}
+	return s
+}
+
+// savedScores returns the risk scores saved by the last enumeration, or nil when they were not computed.
+func savedScores(cfg *config.Config) *risk.Scores {
+	s, _ := risk.Load(filepath.Join(config.OutputDirectory(cfg.Dir), risk.FileName))
+	return s
+}
+
+// printRisk lists the in-scope assets presenting exposure signals, with the highest risk scores first.
+func printRisk(cfg *config.Config, refresh bool, page *query.Page) {
+	s := loadScores(cfg, refresh)
+
+	var scores []*risk.Score
+	for _, score := range s.Assets {
+		if cfg.IsDomainInScope(strings.TrimPrefix(score.Asset, "*.")) {
+			scores = append(scores, score)
+		}
+	}
+	indices, next := page.Select(len(scores), func(i int) string { return scores[i].Asset })
+	for _, i := range indices {
+		score := scores[i]
+
+		var factors []string
+		for _, f := range score.Factors {
+			factors = append(factors, fmt.Sprintf("%s +%d (%s)", f.Signal, f.Points, f.Reason))
+		}
+		fmt.Fprintf(color.Output, "%s %s %s\n", fgR.Sprintf("%3d", score.Score), green(score.Asset), white(strings.Join(factors, ", ")))
+	}
+	if len(scores) == 0 {
+		fmt.Fprintln(color.Error, "No assets presenting exposure signals were found")
+	}
+	printNextCursor(next)
+	fmt.Fprintf(color.Error, "\nThe risk scores were computed %s, and are recomputed using the -refresh flag\n",
+		s.Generated.Format("2006-01-02 15:04:05"))
+}
+
+// printResolutions lists the latest addresses of the in-scope names, along with the names of the CNAME
+// records followed, with the highest risk scores first when the scores were computed.
+func printResolutions(cfg *config.Config, refresh bool) {
+	v := loadViews(cfg, refresh)
+	if scores := savedScores(cfg); scores != nil {
+		sort.SliceStable(v.Resolutions, func(i, j int) bool {
+			return scores.Of(v.Resolutions[i].Name) > scores.Of(v.Resolutions[j].Name)
+		})
+	}
+
+	var count int
+	for _, res := range v.Resolutions {
+		if !cfg.IsDomainInScope(res.Name) {
+			continue
+		}
+
+		var aliases string
+		if len(res.Aliases) > 0 {
+			aliases = " via " + strings.Join(res.Aliases, ", ")
+		}
+		fmt.Fprintf(color.Output, "%s %s%s %s\n", green(res.Name), yellow(strings.Join(res.Addresses, ",")),
+			white(aliases), blue(res.LastSeen.Format("2006-01-02")))
+		count++
+	}
+	if count == 0 {
+		fmt.Fprintln(color.Error, "No resolutions were found for the names in the views")
+	}
+	printViewsAge(v)
+}
+
+// printServices lists the ports of the addresses confirmed to serve the in-scope names, with
+// the services of the names with the highest risk scores first when the scores were computed.
+func printServices(cfg *config.Config, refresh bool) {
+	v := loadViews(cfg, refresh)
+	if scores := savedScores(cfg); scores != nil {
+		highest := func(s *views.Service) int {
+			var max int
+			for _, name := range s.Names {
+				if n := scores.Of(name); n > max {
+					max = n
+				}
+			}
+			return max
+		}
+		sort.SliceStable(v.Services, func(i, j int) bool {
+			return highest(v.Services[i]) > highest(v.Services[j])
+		})
+	}
+
+	var count int
+	for _, s := range v.Services {
+		var names []string
+		for _, name := range s.Names {
+			if cfg.IsDomainInScope(strings.TrimPrefix(name, "*.")) {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(color.Output, "%s %s (%s) %s\n", green(net.JoinHostPort(s.Address, strconv.Itoa(s.Port))),
+			yellow(strings.Join(names, ", ")), white(strings.Join(s.Evidence, ", ")), blue(s.LastSeen.Format("2006-01-02")))
+		count++
+	}
+	if count == 0 {
+		fmt.Fprintln(color.Error, "No services were found for the names in the views. The enum subcommand confirms them using the sni_bruteforce and vhost_bruteforce options")
+	}
+	printViewsAge(v)
+}
+
+func printViewsAge(v *views.Views) {
+	fmt.Fprintf(color.Error, "\nThe views were generated %s, and are rebuilt using the -refresh flag\n",
+		v.Generated.Format("2006-01-02 15:04:05"))
+}
+
+// printStorage lists the storage used by each asset type in the graph database, after removing the relations
+// and sightings no longer referenced by the assets when requested, so long-lived databases remain compact.
+func printStorage(cfg *config.Config, vacuum bool) {
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	db := sys.GraphDatabases()[0].DB
+	g, err := quality.Load(ctx, db)
+	if err != nil {
+		fatal(errDatabase, err)
+	}
+
+	if vacuum {
+		// The relations removed are kept in the trash, like the removals made by the other cleanup operations
+		path := trashPath(cfg)
+		t, err := trash.Read(path)
+		if err != nil {
+			fatal(errIO, err)
+		}
+
+		bin := trash.NewBin(db, t, "report storage -vacuum", trash.CurrentActor(), "unreferenced relation")
+		bin.Known(g.Relations)
+		rels, err := quality.Vacuum(ctx, bin, g)
+		if err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+		}
+		if err := t.Write(path); err != nil {
+			r.Fprintf(color.Error, "Failed to write the trash: %v\n", err)
+		}
+
+		names := g.Names()
+		srcs, err := sightings.Compact(filepath.Join(config.OutputDirectory(cfg.Dir), sightings.FileName), func(name string) bool {
+			_, found := names[name]
+			return found
+		})
+		if err != nil && !os.IsNotExist(err) {
+			r.Fprintf(color.Error, "Failed to compact the sightings: %v\n", err)
+		}
+		fmt.Fprintf(color.Output, "%s unreferenced relations and %s sightings were removed\n\n", yellow(rels), yellow(srcs))
+	}
+
+	var total int64
+	for _, s := range quality.Stats(g) {
+		total += s.Bytes
+		fmt.Fprintf(color.Output, "%s %s assets, %s relations, %s\n", blue(fmt.Sprintf("%-16s", s.Type)),
+			green(s.Assets), green(s.Relations), yellow(byteSize(s.Bytes)))
+	}
+	fmt.Fprintf(color.Output, "\n%s assets and %s relations use approximately %s\n",
+		green(len(g.Assets)), green(len(g.Relations)), yellow(byteSize(total)))
+}
+
+// byteSize returns the number of bytes using the largest unit that keeps the value above one.
+func byteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func shortKey(key string) string {
+	if len(key) > 16 {
+		return key[:16]
+	}
+	return key
+}
@@ -0,0 +1,54 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+// The time each data source is given to provide the sample of names.
+const sampleTimeout = time.Minute
+
+// A warning is shown for data sources returning fewer names within the scope than this fraction.
+const scopeRatioWarning = 0.5
+
+// printSourceSamples shows a sample of the names returned by each data source, and how many
+// were within the scope, so problems with the scope can be identified before the collection.
+func printSourceSamples(cfg *config.Config, sys systems.System, num int) {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	fmt.Fprintf(color.Error, "Sampling up to %s names from each data source\n", green(num))
+
+	var empty []string
+	for _, s := range enum.SampleDataSources(ctx, cfg, sys, num, sampleTimeout) {
+		if s.Observed == 0 {
+			empty = append(empty, s.Source)
+			continue
+		}
+
+		ratio := fmt.Sprintf("%.1f%%", s.Ratio()*100)
+		if s.Ratio() < scopeRatioWarning {
+			ratio = r.Sprint(ratio)
+		} else {
+			ratio = green(ratio)
+		}
+
+		fmt.Fprintf(color.Output, "\n%s: %s names observed, %s within the scope\n", blue(s.Source), yellow(s.Observed), ratio)
+		for _, name := range s.Names {
+			fmt.Fprintf(color.Output, "    %s\n", name)
+		}
+	}
+
+	if len(empty) > 0 {
+		fmt.Fprintf(color.Output, "\n%s: %v\n", yellow("No names were returned by"), empty)
+	}
+}
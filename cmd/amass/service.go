@@ -0,0 +1,117 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/logging"
+)
+
+const (
+	serviceUsageMsg    = "service -install|-uninstall [options] -- [enum options]"
+	defaultServiceName = "amass"
+	serviceEventID     = 1
+	serviceDisplayName = "OWASP Amass"
+	serviceDescription = "Performs the OWASP Amass enumerations and network mapping"
+)
+
+var errServiceUnsupported = errors.New("running the engine as a service is only supported on Windows")
+
+// eventSink receives the log messages while the engine runs as a service, such as the Windows Event Log.
+type eventSink interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+}
+
+var (
+	// eventLog is set while the engine runs as a service
+	eventLog eventSink
+	// stopRequests is closed when the service manager asks the engine to stop, which is handled like an interrupt
+	stopRequests = make(chan struct{})
+	stopOnce     sync.Once
+)
+
+// requestStop lets the running command drain and return, as if the user had interrupted it.
+func requestStop() {
+	stopOnce.Do(func() { close(stopRequests) })
+}
+
+// logEvent sends the log message to the event log while the engine runs as a service.
+func logEvent(line string) {
+	if eventLog == nil || line == "" {
+		return
+	}
+
+	if logging.Level(line) == logging.LevelWarn {
+		_ = eventLog.Warning(serviceEventID, line)
+		return
+	}
+	_ = eventLog.Info(serviceEventID, line)
+}
+
+type serviceArgs struct {
+	Name      string
+	Install   bool
+	Uninstall bool
+	Run       bool
+}
+
+func runServiceCommand(clArgs []string) {
+	var args serviceArgs
+	var help1, help2 bool
+	serviceCommand := flag.NewFlagSet("service", flag.ContinueOnError)
+
+	serviceBuf := new(bytes.Buffer)
+	serviceCommand.SetOutput(serviceBuf)
+
+	serviceCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	serviceCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	serviceCommand.StringVar(&args.Name, "name", defaultServiceName, "Name of the service and the event log source")
+	serviceCommand.BoolVar(&args.Install, "install", false, "Install the service performing the enumeration with the options following --")
+	serviceCommand.BoolVar(&args.Uninstall, "uninstall", false, "Remove the service and the event log source")
+	// The service manager starts the installed service using this flag
+	serviceCommand.BoolVar(&args.Run, "run", false, "Run as the service, which is how the service manager starts it")
+
+	if len(clArgs) < 1 {
+		commandUsage(serviceUsageMsg, serviceCommand, serviceBuf)
+		return
+	}
+	if err := serviceCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(serviceUsageMsg, serviceCommand, serviceBuf)
+		return
+	}
+
+	var err error
+	switch {
+	case args.Install:
+		if serviceCommand.NArg() == 0 {
+			fatalf(errUsage, "The enumeration options must be provided after --, such as -- -d example.com -monitor 60")
+		}
+		if err = installService(args.Name, serviceCommand.Args()); err == nil {
+			fmt.Fprintf(color.Error, "The %s service was installed\n", green(args.Name))
+		}
+	case args.Uninstall:
+		if err = removeService(args.Name); err == nil {
+			fmt.Fprintf(color.Error, "The %s service was removed\n", green(args.Name))
+		}
+	case args.Run:
+		err = runService(args.Name, serviceCommand.Args())
+	default:
+		commandUsage(serviceUsageMsg, serviceCommand, serviceBuf)
+		return
+	}
+	if err != nil {
+		fatal(errFailed, err)
+	}
+}
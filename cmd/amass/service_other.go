@@ -0,0 +1,19 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package main
+
+func installService(name string, args []string) error {
+	return errServiceUnsupported
+}
+
+func removeService(name string) error {
+	return errServiceUnsupported
+}
+
+func runService(name string, args []string) error {
+	return errServiceUnsupported
+}
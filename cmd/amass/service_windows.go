@@ -0,0 +1,161 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// stopWaitHint is the time the service manager is told to wait before it checks on the draining again.
+const stopWaitHint = 30 * time.Second
+
+// installService registers the service that runs the enumeration with the arguments, and the event log source using the same name.
+func installService(name string, args []string) error {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.Abs(exe)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to locate the Amass binary: %v", err)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %v", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	if s, err := m.OpenService(name); err == nil {
+		_ = s.Close()
+		return fmt.Errorf("the %s service already exists", name)
+	}
+
+	s, err := m.CreateService(name, exe, mgr.Config{
+		DisplayName: serviceDisplayName,
+		Description: serviceDescription,
+		StartType:   mgr.StartAutomatic,
+	}, append([]string{"service", "-run", "-name", name, "--"}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to create the %s service: %v", name, err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if err := eventlog.InstallAsEventCreate(name, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		_ = s.Delete()
+		return fmt.Errorf("failed to install the %s event log source: %v", name, err)
+	}
+	return nil
+}
+
+// removeService deletes the service and the event log source.
+func removeService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %v", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("the %s service is not installed", name)
+	}
+	defer func() { _ = s.Close() }()
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to remove the %s service: %v", name, err)
+	}
+	if err := eventlog.Remove(name); err != nil {
+		return fmt.Errorf("failed to remove the %s event log source: %v", name, err)
+	}
+	return nil
+}
+
+// runService performs the enumeration with the arguments under the control of the service manager,
+// sending the log messages to the event log.
+func runService(name string, args []string) error {
+	if ok, err := svc.IsWindowsService(); err != nil || !ok {
+		return fmt.Errorf("the %s service can only be started by the service manager", name)
+	}
+
+	elog, err := eventlog.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open the %s event log: %v", name, err)
+	}
+	defer func() { _ = elog.Close() }()
+	eventLog = elog
+
+	if err := svc.Run(name, &serviceHandler{args: args}); err != nil {
+		_ = elog.Error(serviceEventID, fmt.Sprintf("The %s service failed: %v", name, err))
+		return err
+	}
+	return nil
+}
+
+type serviceHandler struct {
+	args []string
+}
+
+// Execute implements the svc.Handler interface. The stop and shutdown requests are handled like an interrupt,
+// so the enumeration drains and the graph database is closed before the service reports that it has stopped.
+func (h *serviceHandler) Execute(_ []string, reqs <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	const accepts = svc.AcceptStop | svc.AcceptShutdown
+	changes <- svc.Status{State: svc.StartPending}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runEnumCommand(h.args)
+	}()
+
+	changes <- svc.Status{State: svc.Running, Accepts: accepts}
+	logEvent("The service has started")
+	for {
+		select {
+		case <-done:
+			logEvent("The enumeration has finished")
+			changes <- svc.Status{State: svc.StopPending}
+			return false, 0
+		case c := <-reqs:
+			switch c.Cmd {
+			case svc.Interrogate:
+				changes <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				logEvent("The service is draining the enumeration before stopping")
+				h.drain(done, changes)
+				return false, 0
+			}
+		}
+	}
+}
+
+// drain stops the enumeration and keeps the service manager informed until it returns.
+func (h *serviceHandler) drain(done chan struct{}, changes chan<- svc.Status) {
+	checkpoint := uint32(1)
+	changes <- svc.Status{State: svc.StopPending, CheckPoint: checkpoint, WaitHint: uint32(stopWaitHint.Milliseconds())}
+	requestStop()
+
+	t := time.NewTicker(stopWaitHint / 2)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-done:
+			logEvent("The service has stopped")
+			return
+		case <-t.C:
+			checkpoint++
+			changes <- svc.Status{State: svc.StopPending, CheckPoint: checkpoint, WaitHint: uint32(stopWaitHint.Milliseconds())}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// shardArg identifies the portion of the apex domains in scope assigned to this engine instance.
+// The partition is static and uncoordinated: every instance is provided the same scope and a different
+// index, and selects its domains without communicating with the other instances. The domains of an
+// instance that fails or is not started are not enumerated, and the work is not rebalanced. The results
+// are only merged by the shared PostgreSQL graph database that the instances write to.
+type shardArg struct {
+	Index int // One-based index of this shard
+	Count int // Total number of shards
+}
+
+func (s *shardArg) String() string {
+	if s == nil || s.Count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Set implements the flag.Value interface.
+func (s *shardArg) Set(val string) error {
+	i, n, found := strings.Cut(strings.TrimSpace(val), "/")
+	if !found {
+		return fmt.Errorf("the shard must be provided in the form INDEX/COUNT")
+	}
+
+	index, err := strconv.Atoi(i)
+	if err != nil {
+		return fmt.Errorf("the shard index is not a number: %v", err)
+	}
+
+	count, err := strconv.Atoi(n)
+	if err != nil {
+		return fmt.Errorf("the shard count is not a number: %v", err)
+	}
+
+	if count < 1 || index < 1 || index > count {
+		return fmt.Errorf("the shard index must be between 1 and the shard count")
+	}
+
+	s.Index = index
+	s.Count = count
+	return nil
+}
+
+// Domains returns the apex domains assigned to this shard. The assignment only depends
+// on the domain name, so it remains stable when the order of the scope changes.
+func (s *shardArg) Domains(domains []string) []string {
+	if s.Count <= 1 {
+		return domains
+	}
+
+	var selected []string
+	for _, d := range domains {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(strings.ToLower(strings.TrimSpace(d))))
+
+		if int(h.Sum32()%uint32(s.Count)) == s.Index-1 {
+			selected = append(selected, d)
+		}
+	}
+	return selected
+}
+
+// sharedGraphDB returns true when the primary graph database is a PostgreSQL server, which the
+// shards write their findings to. The local databases of the instances are never merged.
+func sharedGraphDB(cfg *config.Config) bool {
+	for _, db := range cfg.GraphDBs {
+		if db.Primary {
+			return strings.EqualFold(db.System, "postgres")
+		}
+	}
+	return false
+}
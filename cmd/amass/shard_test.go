@@ -0,0 +1,81 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestShardArgSet(t *testing.T) {
+	tests := []struct {
+		val   string
+		valid bool
+	}{
+		{val: "1/1", valid: true},
+		{val: "3/8", valid: true},
+		{val: "0/8", valid: false},
+		{val: "9/8", valid: false},
+		{val: "8", valid: false},
+		{val: "a/8", valid: false},
+	}
+
+	for _, tt := range tests {
+		var s shardArg
+
+		if err := s.Set(tt.val); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid to be %t, got error %v", tt.val, tt.valid, err)
+		}
+	}
+}
+
+func TestShardArgDomains(t *testing.T) {
+	var domains []string
+	for i := 0; i < 100; i++ {
+		domains = append(domains, fmt.Sprintf("domain%d.com", i))
+	}
+
+	count := 4
+	seen := make(map[string]int)
+	for i := 1; i <= count; i++ {
+		s := &shardArg{Index: i, Count: count}
+
+		for _, d := range s.Domains(domains) {
+			seen[d]++
+		}
+	}
+
+	if len(seen) != len(domains) {
+		t.Errorf("Expected all %d domains to be assigned, got %d", len(domains), len(seen))
+	}
+	for d, n := range seen {
+		if n != 1 {
+			t.Errorf("%s was assigned to %d shards", d, n)
+		}
+	}
+}
+
+func TestSharedGraphDB(t *testing.T) {
+	tests := []struct {
+		dbs  []*config.Database
+		want bool
+	}{
+		{dbs: nil, want: false},
+		{dbs: []*config.Database{{System: "local", Primary: true}}, want: false},
+		{dbs: []*config.Database{{System: "postgres", Primary: true}}, want: true},
+		{dbs: []*config.Database{{System: "postgres"}, {System: "local", Primary: true}}, want: false},
+	}
+
+	for i, tt := range tests {
+		cfg := config.NewConfig()
+		cfg.GraphDBs = tt.dbs
+
+		if got := sharedGraphDB(cfg); got != tt.want {
+			t.Errorf("%d: expected %t, got %t", i, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/config/config"
+)
+
+// taskAPISettings returns the settings from the task_api option, overridden by the command-line flags.
+func taskAPISettings(cfg *config.Config, args *enumArgs) *enum.TaskAPISettings {
+	settings, err := enum.ParseTaskAPISettings(cfg.Options["task_api"])
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	if args.TaskAddr != "" {
+		settings.Listen = args.TaskAddr
+	}
+	return settings
+}
+
+// serveTaskAPI starts receiving the tasks of the external systems for the enumeration,
+// and returns the function stopping the server.
+func serveTaskAPI(e *enum.Enumeration, settings *enum.TaskAPISettings) func() {
+	if settings.Listen == "" {
+		return func() {}
+	}
+
+	tlsConfig, err := settings.TLSConfig()
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	if tlsConfig == nil && len(settings.Tokens) > 0 {
+		fgY.Fprintln(color.Error, "The task API is not served over TLS, so the tokens are sent in cleartext")
+	}
+
+	srv, err := enum.NewTaskServer(e, settings.Listen, settings.Tokens, tlsConfig)
+	if err != nil {
+		fatal(errNetwork, err)
+	}
+
+	e.WaitForTasks = settings.Wait
+	fmt.Fprintf(color.Error, "Accepting tasks on %s\n", green(srv.Addr()))
+	return func() { _ = srv.Close() }
+}
@@ -0,0 +1,50 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/config/config"
+)
+
+// Settings enforced by the stealth timing profile.
+const (
+	stealthQPS           = 2
+	stealthMaxDNSQueries = 10
+	stealthSweepPace     = 1
+)
+
+// applyTimingProfile lowers the query rates and prevents active techniques
+// when the stealth timing profile has been selected.
+func applyTimingProfile(cfg *config.Config) error {
+	if enum.TimingProfile(cfg) != enum.TimingStealth {
+		return nil
+	}
+	if cfg.Active {
+		return errors.New("active techniques cannot be performed with the stealth timing profile")
+	}
+
+	if cfg.ResolversQPS == 0 || cfg.ResolversQPS > stealthQPS {
+		cfg.ResolversQPS = stealthQPS
+	}
+	if cfg.TrustedQPS == 0 || cfg.TrustedQPS > stealthQPS {
+		cfg.TrustedQPS = stealthQPS
+	}
+	if cfg.MaxDNSQueries == 0 || cfg.MaxDNSQueries > stealthMaxDNSQueries {
+		cfg.MaxDNSQueries = stealthMaxDNSQueries
+	}
+	if _, found := cfg.Options["sweep_pace"]; !found {
+		cfg.Options["sweep_pace"] = stealthSweepPace
+	}
+	if _, found := cfg.Options["randomize"]; !found {
+		cfg.Options["randomize"] = true
+	}
+
+	fgY.Fprintln(color.Error, "The stealth timing profile is being used")
+	return nil
+}
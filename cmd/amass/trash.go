@@ -0,0 +1,171 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/amass/v4/trash"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	trashUsageMsg = "trash [options] list | restore ID ... | purge [ID ...]"
+)
+
+type trashArgs struct {
+	OlderThan int
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineTrashFlags(trashFlags *flag.FlagSet, args *trashArgs) {
+	trashFlags.IntVar(&args.OlderThan, "older-than", -1, "Purge the entries removed more than N days ago")
+	trashFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	trashFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+}
+
+func runTrashCommand(clArgs []string) {
+	var args trashArgs
+	var help1, help2 bool
+	trashCommand := flag.NewFlagSet("trash", flag.ContinueOnError)
+
+	trashBuf := new(bytes.Buffer)
+	trashCommand.SetOutput(trashBuf)
+
+	trashCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	trashCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineTrashFlags(trashCommand, &args)
+
+	if len(clArgs) < 1 {
+		commandUsage(trashUsageMsg, trashCommand, trashBuf)
+		return
+	}
+	if err := trashCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 || trashCommand.NArg() < 1 {
+		commandUsage(trashUsageMsg, trashCommand, trashBuf)
+		return
+	}
+
+	action := trashCommand.Arg(0)
+	var ids []int
+	for _, arg := range trashCommand.Args()[1:] {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			fatalf(errUsage, "%s is not the identifier of a trash entry", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	path := trashPath(cfg)
+	t, err := trash.Read(path)
+	if err != nil {
+		fatal(errIO, err)
+	}
+
+	switch action {
+	case "list":
+		if len(t.Entries) == 0 {
+			fatalf(errNoResults, "The trash is empty")
+		}
+		for _, e := range t.Entries {
+			printTrashEntry(e)
+		}
+		return
+	case "restore":
+		if len(ids) == 0 {
+			fatalf(errUsage, "The restore action requires the identifiers of the entries")
+		}
+		restoreTrash(cfg, t, ids)
+	case "purge":
+		// The purge cannot be undone, so the entries must be selected explicitly
+		if len(ids) == 0 && args.OlderThan < 0 {
+			fatalf(errUsage, "The purge action requires the identifiers of the entries or the -older-than flag")
+		}
+		if len(ids) > 0 && args.OlderThan >= 0 {
+			fatalf(errUsage, "The purge action accepts the identifiers of the entries or the -older-than flag, not both")
+		}
+
+		before := time.Now().AddDate(0, 0, -args.OlderThan)
+		for _, id := range ids {
+			if _, err := t.Get(id); err != nil {
+				fatal(errUsage, err)
+			}
+		}
+
+		purged := t.Purge(before, ids...)
+		if err := t.Write(path); err != nil {
+			fatalf(errIO, "Failed to write the trash: %v", err)
+		}
+		fmt.Fprintf(color.Error, "%s entries were permanently purged from the trash\n", green(len(purged)))
+	default:
+		fatalf(errUsage, "%s is not a supported action", action)
+	}
+}
+
+// restoreTrash stores the assets and relations of the entries in the graph database again.
+func restoreTrash(cfg *config.Config, t *trash.Trash, ids []int) {
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		fatal(systemError(err), err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	db := sys.GraphDatabases()[0].DB
+	path := trashPath(cfg)
+	for _, id := range ids {
+		e, err := t.Get(id)
+		if err == nil {
+			err = trash.Restore(db, e)
+		}
+		if err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			continue
+		}
+
+		fmt.Fprintf(color.Error, "The entry %s was restored: %s\n", green(e.ID), green(e.Subject()))
+		// The entry is marked as restored at once, so it is not restored twice after a later failure
+		if err := t.Write(path); err != nil {
+			fatalf(errIO, "Failed to write the trash: %v", err)
+		}
+	}
+}
+
+func trashPath(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), trash.FileName)
+}
+
+// printTrashEntry shows the entry with who removed it, when and why.
+func printTrashEntry(e *trash.Entry) {
+	var status string
+	if e.Restored != nil {
+		status = green(" (restored " + e.Restored.Format("2006-01-02 15:04") + ")")
+	}
+
+	fmt.Fprintf(color.Output, "%s %s %s%s\n", white(fmt.Sprintf("%-5d", e.ID)), green(e.Subject()),
+		yellow(fmt.Sprintf("(%s by %s on %s)", e.Operation, e.Actor, e.Deleted.Format("2006-01-02 15:04"))), status)
+	if e.Reason != "" {
+		fmt.Fprintf(color.Output, "\t%s\n", e.Reason)
+	}
+	if e.Asset != nil && len(e.Relations) > 0 {
+		fmt.Fprintf(color.Output, "\t%s\n", blue(fmt.Sprintf("%d relations", len(e.Relations))))
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/format"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/update"
+)
+
+const (
+	updateUsageMsg = "update [options]"
+)
+
+type updateArgs struct {
+	Channel    string
+	Key        string
+	Check      bool
+	AllowMajor bool
+	Filepaths  struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineUpdateFlags(updateFlags *flag.FlagSet, args *updateArgs) {
+	updateFlags.StringVar(&args.Channel, "channel", "", "Release channel that the update is installed from: stable or beta (default: stable)")
+	updateFlags.StringVar(&args.Key, "key", "", "The ed25519 public key, or path to the file providing it, that signs the release checksums")
+	updateFlags.BoolVar(&args.Check, "check", false, "Only show whether a newer release is available")
+	updateFlags.BoolVar(&args.AllowMajor, "allow-major", false, "Permit updating to a release with another major version")
+	updateFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	updateFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the configuration file")
+}
+
+func runUpdateCommand(clArgs []string) {
+	var args updateArgs
+	var help1, help2 bool
+	updateCommand := flag.NewFlagSet("update", flag.ContinueOnError)
+
+	updateBuf := new(bytes.Buffer)
+	updateCommand.SetOutput(updateBuf)
+
+	updateCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	updateCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineUpdateFlags(updateCommand, &args)
+
+	if err := updateCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(updateUsageMsg, updateCommand, updateBuf)
+		return
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	settings, err := update.ParseSettings(cfg.Options["update"])
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	// The command-line flags take precedence over the configuration
+	if args.Channel != "" {
+		settings.Channel = args.Channel
+	}
+	if args.Key != "" {
+		settings.PublicKey = args.Key
+	}
+
+	// The releases are downloaded using the proxy and TLS settings of the configuration
+	client := amasshttp.NewClient("update", &amasshttp.ClientOptions{Timeout: 5 * time.Minute})
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	rel, err := update.Latest(ctx, client, settings.URL, settings.Channel)
+	if err != nil {
+		fatal(errNetwork, err)
+	}
+	if err := update.CheckCompatible(format.Version, rel.Version, args.AllowMajor); err != nil {
+		fmt.Fprintf(color.Error, "No update was installed: %v\n", err)
+		return
+	}
+
+	fmt.Fprintf(color.Error, "Release %s is available in the %s channel (installed: %s)\n",
+		green(rel.Version), settings.Channel, yellow(format.Version))
+	if args.Check {
+		return
+	}
+	if settings.PublicKey == "" {
+		fatalf(errConfig, "The release signing key must be provided using the -key flag or the update section of the configuration")
+	}
+
+	pub, err := update.ParsePublicKey(settings.PublicKey)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	bin, err := update.Fetch(ctx, client, rel, runtime.GOOS, runtime.GOARCH, pub)
+	if err != nil {
+		fatal(errNetwork, err)
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fatalf(errIO, "Failed to locate the Amass binary: %v", err)
+	}
+	if err := update.Install(exe, bin); err != nil {
+		fatal(errFailed, err)
+	}
+	fmt.Fprintf(color.Error, "Amass was updated to %s\n", green(rel.Version))
+}
@@ -0,0 +1,185 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/monitor"
+	"github.com/owasp-amass/amass/v4/verify"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	verifyUsageMsg = "verify [options] [COLLECTION ...]"
+)
+
+type verifyArgs struct {
+	Once      bool
+	Port      int
+	Timeout   int
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func defineVerifyFlags(verifyFlags *flag.FlagSet, args *verifyArgs) {
+	verifyFlags.BoolVar(&args.Once, "once", false, "Check the collections that are due once and exit, rather than waiting for the next checks")
+	verifyFlags.IntVar(&args.Port, "port", verify.DefaultPort, "Port checked for the certificates and the web servers")
+	verifyFlags.IntVar(&args.Timeout, "timeout", int(verify.DefaultTimeout/time.Second), "Number of seconds allowed for the checks of each asset")
+	verifyFlags.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	verifyFlags.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+}
+
+func runVerifyCommand(clArgs []string) {
+	var args verifyArgs
+	var help1, help2 bool
+	verifyCommand := flag.NewFlagSet("verify", flag.ContinueOnError)
+
+	verifyBuf := new(bytes.Buffer)
+	verifyCommand.SetOutput(verifyBuf)
+
+	verifyCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	verifyCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	defineVerifyFlags(verifyCommand, &args)
+
+	if err := verifyCommand.Parse(clArgs); err != nil {
+		fatal(errUsage, err)
+	}
+	if help1 || help2 {
+		commandUsage(verifyUsageMsg, verifyCommand, verifyBuf)
+		return
+	}
+	if args.Port <= 0 || args.Port > 65535 {
+		fatalf(errUsage, "%d is not a valid port", args.Port)
+	}
+	if args.Timeout <= 0 {
+		fatalf(errUsage, "The timeout must be greater than zero")
+	}
+
+	cfg, err := graphConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile)
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	jobs, err := verify.ParseJobs(cfg.Options["verify"])
+	if err != nil {
+		fatal(errConfig, err)
+	}
+	// The collections named on the command line are checked once, regardless of the schedule
+	if verifyCommand.NArg() > 0 {
+		jobs = nil
+		for _, name := range verifyCommand.Args() {
+			jobs = append(jobs, &verify.Job{Collection: name})
+		}
+		args.Once = true
+	}
+	if len(jobs) == 0 {
+		fatalf(errUsage, "No collections were named or scheduled using the verify option")
+	}
+
+	settings, err := monitor.ParseSettings(cfg.Options["monitor"])
+	if err != nil {
+		fatal(errConfig, err)
+	}
+
+	checker := verify.NewChecker()
+	checker.Port = args.Port
+	checker.Timeout = time.Duration(args.Timeout) * time.Second
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	for {
+		runVerifyJobs(ctx, cfg, checker, settings, jobs)
+		if args.Once {
+			return
+		}
+
+		// The schedule is checked every minute, so the collections and intervals can differ by a minute at most
+		t := time.NewTimer(time.Minute)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// runVerifyJobs checks the members of the collections that are due, records the changes since the
+// previous checks as findings, and sends them to the notification channels.
+func runVerifyJobs(ctx context.Context, cfg *config.Config, checker *verify.Checker, settings *monitor.Settings, jobs []*verify.Job) {
+	path := filepath.Join(config.OutputDirectory(cfg.Dir), verify.StateFileName)
+	state, err := verify.ReadState(path)
+	if err != nil {
+		fatal(errIO, err)
+	}
+	store, err := collections.ReadStore(collectionsPath(cfg))
+	if err != nil {
+		fatal(errIO, err)
+	}
+
+	start := time.Now()
+	var changes []*findings.Finding
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		if job.Interval > 0 && !state.Due(job.Collection, job.Interval, start) {
+			continue
+		}
+
+		c, err := store.Get(job.Collection)
+		if err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			continue
+		}
+
+		results := checker.CheckAll(ctx, c.Members)
+		if ctx.Err() != nil {
+			return
+		}
+		fs := state.Update(c.Name, results, time.Now())
+		changes = append(changes, fs...)
+
+		fmt.Fprintf(color.Error, "%s assets of the %s collection were checked: %s changes\n",
+			green(len(results)), green(c.Name), yellow(len(fs)))
+	}
+
+	if err := state.Write(path); err != nil {
+		fatalf(errIO, "Failed to write the verification state: %v", err)
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	findings.SortBySeverity(changes)
+	recordFindings(cfg, changes)
+	for _, f := range changes {
+		fmt.Fprintf(color.Output, "%s %s %s\n", fgR.Sprintf("[%s]", f.Severity), green(f.Asset), f.Details)
+	}
+
+	routeCollections(cfg, settings)
+	alert := &monitor.Alert{
+		Domains:  cfg.Domains(),
+		Started:  start,
+		Finished: time.Now(),
+		Findings: changes,
+	}
+	if err := settings.Notify(ctx, alert); err != nil {
+		cfg.Log.Printf("Failed to send the verification notifications: %v", err)
+		r.Fprintf(color.Error, "%v\n", err)
+	}
+}
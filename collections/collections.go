@@ -0,0 +1,303 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package collections maintains the named selections of assets created by the users, such as the results
+// of a query or the assets chosen by hand, so the same part of the attack surface can be exported, routed
+// to the notification channels and re-verified without selecting the assets again.
+package collections
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/format"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// FileName is the name of the file in the output directory that stores the collections.
+const FileName = "collections.json"
+
+var nameRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// The asset types of the members, named as in the query expressions.
+var memberTypes = map[string]oam.AssetType{
+	"fqdn":     oam.FQDN,
+	"ip":       oam.IPAddress,
+	"netblock": oam.Netblock,
+	"asn":      oam.ASN,
+	"org":      oam.RIROrg,
+}
+
+// Member is an asset of a collection, identified by its type and key as in the exported graph.
+type Member struct {
+	Type string `json:"type"`
+	Key  string `json:"key"`
+}
+
+// ID returns the identifier of the asset in the exported graph.
+func (m Member) ID() string {
+	return m.Type + ":" + m.Key
+}
+
+// Asset returns the asset identified by the member, or nil when the type or the key is not valid.
+func (m Member) Asset() oam.Asset {
+	switch oam.AssetType(m.Type) {
+	case oam.FQDN:
+		return domain.FQDN{Name: m.Key}
+	case oam.IPAddress:
+		if addr, err := netip.ParseAddr(m.Key); err == nil {
+			return network.IPAddress{Address: addr, Type: ipType(addr)}
+		}
+	case oam.Netblock:
+		if prefix, err := netip.ParsePrefix(m.Key); err == nil {
+			return network.Netblock{Cidr: prefix, Type: ipType(prefix.Addr())}
+		}
+	case oam.ASN:
+		if n, err := strconv.Atoi(m.Key); err == nil {
+			return network.AutonomousSystem{Number: n}
+		}
+	case oam.RIROrg:
+		return network.RIROrganization{Name: m.Key}
+	}
+	return nil
+}
+
+func ipType(addr netip.Addr) string {
+	if addr.Is4() {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// MemberOf returns the Member identifying the asset record.
+func MemberOf(rec *format.AssetRecord) Member {
+	return Member{Type: rec.Type, Key: rec.Key}
+}
+
+// ParseMember returns the Member for the asset provided as TYPE:KEY, such as ip:192.0.2.1, or as the key alone,
+// in which case the type is the address, netblock or autonomous system number it parses as, or else a name.
+func ParseMember(s string) (Member, error) {
+	s = strings.TrimSpace(s)
+
+	if i := strings.Index(s, ":"); i > 0 {
+		if t, found := memberTypes[strings.ToLower(s[:i])]; found {
+			return newMember(t, s[i+1:])
+		}
+	}
+
+	if _, err := netip.ParseAddr(s); err == nil {
+		return newMember(oam.IPAddress, s)
+	}
+	if _, err := netip.ParsePrefix(s); err == nil {
+		return newMember(oam.Netblock, s)
+	}
+	if n := strings.TrimPrefix(strings.ToUpper(s), "AS"); n != "" {
+		if _, err := strconv.Atoi(n); err == nil {
+			return newMember(oam.ASN, n)
+		}
+	}
+	return newMember(oam.FQDN, s)
+}
+
+func newMember(t oam.AssetType, key string) (Member, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return Member{}, fmt.Errorf("the %s asset must provide a key", t)
+	}
+
+	switch t {
+	case oam.FQDN:
+		key = strings.ToLower(strings.TrimSuffix(key, "."))
+	case oam.IPAddress:
+		addr, err := netip.ParseAddr(key)
+		if err != nil {
+			return Member{}, fmt.Errorf("%s is not a valid IP address", key)
+		}
+		key = addr.String()
+	case oam.Netblock:
+		prefix, err := netip.ParsePrefix(key)
+		if err != nil {
+			return Member{}, fmt.Errorf("%s is not a valid netblock", key)
+		}
+		key = prefix.Masked().String()
+	case oam.ASN:
+		n, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(key), "AS"))
+		if err != nil || n <= 0 {
+			return Member{}, fmt.Errorf("%s is not a valid autonomous system number", key)
+		}
+		key = strconv.Itoa(n)
+	}
+	return Member{Type: string(t), Key: key}, nil
+}
+
+// Collection is a named selection of assets.
+type Collection struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Query is the expression that selected the members, when the collection was created from query results
+	Query   string    `json:"query,omitempty"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+	Members []Member  `json:"members"`
+}
+
+// Add adds the assets that are not members yet, and returns the number of members added.
+func (c *Collection) Add(members ...Member) int {
+	ids := c.IDs()
+
+	var count int
+	for _, m := range members {
+		if _, found := ids[m.ID()]; found {
+			continue
+		}
+
+		ids[m.ID()] = struct{}{}
+		c.Members = append(c.Members, m)
+		count++
+	}
+
+	if count > 0 {
+		sort.Slice(c.Members, func(i, j int) bool {
+			if c.Members[i].Type != c.Members[j].Type {
+				return c.Members[i].Type < c.Members[j].Type
+			}
+			return c.Members[i].Key < c.Members[j].Key
+		})
+		c.Updated = time.Now().UTC()
+	}
+	return count
+}
+
+// Remove removes the assets from the members, and returns the number of members removed.
+func (c *Collection) Remove(members ...Member) int {
+	remove := make(map[string]struct{}, len(members))
+	for _, m := range members {
+		remove[m.ID()] = struct{}{}
+	}
+
+	kept := c.Members[:0]
+	for _, m := range c.Members {
+		if _, found := remove[m.ID()]; !found {
+			kept = append(kept, m)
+		}
+	}
+
+	count := len(c.Members) - len(kept)
+	c.Members = kept
+	if count > 0 {
+		c.Updated = time.Now().UTC()
+	}
+	return count
+}
+
+// IDs returns the identifiers of the members in the exported graph.
+func (c *Collection) IDs() map[string]struct{} {
+	ids := make(map[string]struct{}, len(c.Members))
+	for _, m := range c.Members {
+		ids[m.ID()] = struct{}{}
+	}
+	return ids
+}
+
+// Keys returns the keys of the members, which identify the assets of the findings.
+func (c *Collection) Keys() map[string]struct{} {
+	keys := make(map[string]struct{}, len(c.Members))
+	for _, m := range c.Members {
+		keys[m.Key] = struct{}{}
+	}
+	return keys
+}
+
+// Store is the set of collections, keyed by their names.
+type Store map[string]*Collection
+
+// ReadStore returns the collections stored in the file, or an empty Store when the file does not exist.
+func ReadStore(path string) (Store, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return make(Store), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	s := make(Store)
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse the collections file %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// Write stores the collections in the file.
+func (s Store) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Names returns the names of the collections, sorted.
+func (s Store) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the named collection.
+func (s Store) Get(name string) (*Collection, error) {
+	c, found := s[name]
+	if !found {
+		return nil, fmt.Errorf("%s is not a collection", name)
+	}
+	return c, nil
+}
+
+// Create adds the empty collection to the Store.
+func (s Store) Create(name, description, query string) (*Collection, error) {
+	if !nameRE.MatchString(name) {
+		return nil, fmt.Errorf("%s is not a valid collection name, which must start with a letter or digit "+
+			"followed by letters, digits, dots, dashes and underscores", name)
+	}
+	if _, found := s[name]; found {
+		return nil, fmt.Errorf("the %s collection already exists", name)
+	}
+
+	now := time.Now().UTC()
+	c := &Collection{
+		Name:        name,
+		Description: description,
+		Query:       query,
+		Created:     now,
+		Updated:     now,
+	}
+	s[name] = c
+	return c, nil
+}
+
+// Delete removes the named collection from the Store.
+func (s Store) Delete(name string) error {
+	if _, found := s[name]; !found {
+		return fmt.Errorf("%s is not a collection", name)
+	}
+
+	delete(s, name)
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package collections
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMember(t *testing.T) {
+	for s, want := range map[string]string{
+		"WWW.Example.com.":    "FQDN:www.example.com",
+		"192.0.2.1":           "IPAddress:192.0.2.1",
+		"192.0.2.7/24":        "Netblock:192.0.2.0/24",
+		"AS64496":             "ASN:64496",
+		"asn:as64496":         "ASN:64496",
+		"fqdn:mail.owasp.org": "FQDN:mail.owasp.org",
+		"ip:2001:db8::1":      "IPAddress:2001:db8::1",
+	} {
+		m, err := ParseMember(s)
+		if err != nil {
+			t.Errorf("Failed to parse %s: %v", s, err)
+			continue
+		}
+		if m.ID() != want {
+			t.Errorf("%s: expected %s, got %s", s, want, m.ID())
+		}
+	}
+
+	for _, s := range []string{"ip:owasp.org", "netblock:192.0.2.1", "fqdn:", "asn:-1"} {
+		if _, err := ParseMember(s); err == nil {
+			t.Errorf("Expected an error for %s", s)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	s, err := ReadStore(path)
+	if err != nil || len(s) != 0 {
+		t.Fatalf("Expected an empty store: %v", err)
+	}
+
+	c, err := s.Create("crown-jewels", "Payment hosts", "")
+	if err != nil {
+		t.Fatalf("Failed to create the collection: %v", err)
+	}
+	if _, err := s.Create("crown-jewels", "", ""); err == nil {
+		t.Error("Expected an error for the existing collection")
+	}
+	if _, err := s.Create("-bad name", "", ""); err == nil {
+		t.Error("Expected an error for the invalid name")
+	}
+
+	www, _ := ParseMember("www.owasp.org")
+	addr, _ := ParseMember("192.0.2.1")
+	if n := c.Add(www, addr, www); n != 2 || len(c.Members) != 2 {
+		t.Errorf("Expected two members to be added, got %d", n)
+	}
+	if _, found := c.IDs()["FQDN:www.owasp.org"]; !found {
+		t.Error("The name was not a member of the collection")
+	}
+	if _, found := c.Keys()["192.0.2.1"]; !found {
+		t.Error("The address was not a key of the collection")
+	}
+	if err := s.Write(path); err != nil {
+		t.Fatalf("Failed to write the collections: %v", err)
+	}
+
+	s, err = ReadStore(path)
+	if err != nil {
+		t.Fatalf("Failed to read the collections: %v", err)
+	}
+	c, err = s.Get("crown-jewels")
+	if err != nil || c.Description != "Payment hosts" || len(c.Members) != 2 {
+		t.Fatalf("Unexpected collection: %+v, %v", c, err)
+	}
+	if n := c.Remove(addr); n != 1 || len(c.Members) != 1 || c.Members[0] != www {
+		t.Errorf("Unexpected members after the removal: %+v", c.Members)
+	}
+
+	if err := s.Delete("crown-jewels"); err != nil || len(s.Names()) != 0 {
+		t.Errorf("Failed to delete the collection: %v", err)
+	}
+	if _, err := s.Get("crown-jewels"); err == nil {
+		t.Error("Expected an error for the deleted collection")
+	}
+}
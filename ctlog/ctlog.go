@@ -0,0 +1,333 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ctlog reads the entries added to the Certificate Transparency logs directly from the
+// logs, such as the Let's Encrypt Oak and Google Argon logs, without depending on third-party
+// aggregators. The position reached in each log is kept between the runs, so only the
+// certificates logged since the previous run are read.
+package ctlog
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/session"
+)
+
+// StateFileName is the name of the file in the output directory that stores the position reached in each log.
+const StateFileName = "ct_logs.json"
+
+// stateKind is the versioned format of the state file, which is documented by the session package.
+var stateKind = &session.Kind{Name: "ct_logs", Version: 1}
+
+// DefaultMaxEntries is the number of entries read from each log per run when no other limit is provided.
+const DefaultMaxEntries = 100000
+
+const (
+	requestTimeout = 60 * time.Second
+	maxBodySize    = 64 * 1024 * 1024
+	batchSize      = 256
+)
+
+// The types of the entries in the Merkle tree leaves defined by RFC 6962.
+const (
+	x509Entry    = 0
+	precertEntry = 1
+)
+
+// KnownLogs are the URLs of the logs that can be selected by name. Other logs are selected by their URL.
+var KnownLogs = map[string]string{
+	"oak2025h2":   "https://oak.ct.letsencrypt.org/2025h2/",
+	"oak2026h1":   "https://oak.ct.letsencrypt.org/2026h1/",
+	"oak2026h2":   "https://oak.ct.letsencrypt.org/2026h2/",
+	"argon2026h1": "https://ct.googleapis.com/logs/us1/argon2026h1/",
+	"argon2026h2": "https://ct.googleapis.com/logs/us1/argon2026h2/",
+	"xenon2026h1": "https://ct.googleapis.com/logs/eu1/xenon2026h1/",
+	"xenon2026h2": "https://ct.googleapis.com/logs/eu1/xenon2026h2/",
+}
+
+// Log is a Certificate Transparency log providing the RFC 6962 API.
+type Log struct {
+	Name string
+	// URL is the prefix of the API, with the paths of the methods appended to it
+	URL  string
+	HTTP *http.Client
+}
+
+// Entry is a certificate or precertificate read from a log.
+type Entry struct {
+	Index     int64
+	Timestamp time.Time
+	Precert   bool
+	Serial    string
+	Issuer    string
+	// Names are the subdomain names of the certificate, without the wildcard labels
+	Names []string
+}
+
+// NewLog returns the log with the provided name and API URL.
+func NewLog(name, u string) *Log {
+	if !strings.HasSuffix(u, "/") {
+		u += "/"
+	}
+
+	return &Log{
+		Name: name,
+		URL:  u,
+		HTTP: amasshttp.NewClient("ctlog", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Cache:   true,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// LogsByName returns the logs with the provided names, or with the provided URLs.
+func LogsByName(names []string) ([]*Log, error) {
+	var logs []*Log
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if u, found := KnownLogs[strings.ToLower(name)]; found {
+			logs = append(logs, NewLog(strings.ToLower(name), u))
+			continue
+		}
+
+		u, err := url.Parse(name)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("%s is not a known CT log name or the URL of a log", name)
+		}
+		logs = append(logs, NewLog(u.Host+strings.TrimSuffix(u.Path, "/"), name))
+	}
+	return logs, nil
+}
+
+// TreeSize returns the number of entries in the log, according to its latest signed tree head.
+func (l *Log) TreeSize(ctx context.Context) (int64, error) {
+	var sth struct {
+		TreeSize int64 `json:"tree_size"`
+	}
+
+	if err := l.get(ctx, "ct/v1/get-sth", &sth); err != nil {
+		return 0, err
+	}
+	return sth.TreeSize, nil
+}
+
+// Entries returns the entries of the log from start to end inclusive. The logs can return fewer entries
+// than requested, and the entries that cannot be parsed are returned without names, so the number of
+// entries returned is always the number read from the log.
+func (l *Log) Entries(ctx context.Context, start, end int64) ([]*Entry, error) {
+	var resp struct {
+		Entries []struct {
+			LeafInput string `json:"leaf_input"`
+			ExtraData string `json:"extra_data"`
+		} `json:"entries"`
+	}
+
+	if err := l.get(ctx, fmt.Sprintf("ct/v1/get-entries?start=%d&end=%d", start, end), &resp); err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(resp.Entries))
+	for i, raw := range resp.Entries {
+		e := &Entry{Index: start + int64(i)}
+
+		leaf, err := base64.StdEncoding.DecodeString(raw.LeafInput)
+		if err == nil {
+			extra, _ := base64.StdEncoding.DecodeString(raw.ExtraData)
+			_ = e.parse(leaf, extra)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (l *Log) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.URL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the %s log returned status %d", l.Name, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode the response of the %s log: %v", l.Name, err)
+	}
+	return nil
+}
+
+// parse extracts the certificate from the MerkleTreeLeaf. The names of the precertificates are
+// obtained from the precertificate provided by the extra data, since the leaf only holds the TBS.
+func (e *Entry) parse(leaf, extra []byte) error {
+	if len(leaf) < 12 || leaf[0] != 0 || leaf[1] != 0 {
+		return errors.New("unsupported leaf version or type")
+	}
+	e.Timestamp = time.UnixMilli(int64(binary.BigEndian.Uint64(leaf[2:10]))).UTC()
+
+	var der []byte
+	switch binary.BigEndian.Uint16(leaf[10:12]) {
+	case x509Entry:
+		der = readCert(leaf[12:])
+	case precertEntry:
+		e.Precert = true
+		der = readCert(extra)
+	default:
+		return errors.New("unsupported entry type")
+	}
+	if der == nil {
+		return errors.New("the certificate is truncated")
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+
+	e.Serial = cert.SerialNumber.Text(16)
+	e.Issuer = cert.Issuer.CommonName
+	e.Names = certNames(cert)
+	return nil
+}
+
+// readCert returns the certificate prefixed by its 24-bit length.
+func readCert(b []byte) []byte {
+	if len(b) < 3 {
+		return nil
+	}
+
+	n := int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	if len(b) < 3+n {
+		return nil
+	}
+	return b[3 : 3+n]
+}
+
+func certNames(cert *x509.Certificate) []string {
+	seen := make(map[string]struct{})
+
+	var names []string
+	for _, n := range append([]string{cert.Subject.CommonName}, cert.DNSNames...) {
+		n = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(n)), "*."), ".")
+		if n == "" || !strings.Contains(n, ".") || strings.ContainsAny(n, " */:@") {
+			continue
+		}
+		if _, found := seen[n]; !found {
+			seen[n] = struct{}{}
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Checkpoint is the position reached in a log.
+type Checkpoint struct {
+	// Next is the index of the next entry to read
+	Next int64 `json:"next"`
+	// TreeSize is the size of the tree when the log was last read
+	TreeSize int64     `json:"tree_size"`
+	Updated  time.Time `json:"updated"`
+}
+
+// State is the position reached in each log, keyed by the URL of the log and kept across the runs.
+type State map[string]*Checkpoint
+
+// ReadState returns the checkpoints stored in the file, or an empty State when the file does not exist.
+func ReadState(path string) (State, error) {
+	s := make(State)
+	if _, err := stateKind.Read(path, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write stores the checkpoints in the file.
+func (s State) Write(path string) error {
+	return stateKind.Write(path, s)
+}
+
+// Read returns the entries added to the log since its checkpoint that provide names accepted by the
+// inScope function, with only those names. At most limit entries are read, and the remaining entries
+// are read by the following runs. The first run only records the size of the tree as the checkpoint,
+// since the logs hold billions of entries. The checkpoint is advanced as the entries are read, so
+// the entries read before an error are not read again.
+func (s State) Read(ctx context.Context, l *Log, limit int64, inScope func(name string) bool) ([]*Entry, error) {
+	size, err := l.TreeSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cp, found := s[l.URL]
+	if !found {
+		s[l.URL] = &Checkpoint{Next: size, TreeSize: size, Updated: time.Now().UTC()}
+		return nil, nil
+	}
+	cp.TreeSize = size
+	cp.Updated = time.Now().UTC()
+
+	end := size
+	if limit > 0 && end > cp.Next+limit {
+		end = cp.Next + limit
+	}
+
+	var results []*Entry
+	for cp.Next < end {
+		last := cp.Next + batchSize - 1
+		if last >= end {
+			last = end - 1
+		}
+
+		entries, err := l.Entries(ctx, cp.Next, last)
+		if err != nil {
+			return results, err
+		}
+		if len(entries) == 0 {
+			break
+		}
+		cp.Next += int64(len(entries))
+
+		for _, e := range entries {
+			var names []string
+			for _, n := range e.Names {
+				if inScope(n) {
+					names = append(names, n)
+				}
+			}
+			if len(names) > 0 {
+				e.Names = names
+				results = append(results, e)
+			}
+		}
+	}
+	return results, nil
+}
+
+// Lag returns the number of entries in the log that were not read yet.
+func (cp *Checkpoint) Lag() int64 {
+	if cp.TreeSize > cp.Next {
+		return cp.TreeSize - cp.Next
+	}
+	return 0
+}
@@ -0,0 +1,169 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package ctlog
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCert(t *testing.T, serial int64, names ...string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: names[0]},
+		Issuer:       pkix.Name{CommonName: "Test CA"},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create the certificate: %v", err)
+	}
+	return der
+}
+
+func withLength(der []byte) []byte {
+	n := len(der)
+	return append([]byte{byte(n >> 16), byte(n >> 8), byte(n)}, der...)
+}
+
+// testLeaf returns the leaf_input and extra_data of a log entry for the certificate.
+func testLeaf(der []byte, precert bool) (string, string) {
+	leaf := make([]byte, 12)
+	binary.BigEndian.PutUint64(leaf[2:10], uint64(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC).UnixMilli()))
+
+	var extra []byte
+	if precert {
+		binary.BigEndian.PutUint16(leaf[10:12], precertEntry)
+		// The leaf holds the issuer key hash and the TBS, which are not used
+		leaf = append(leaf, make([]byte, 32)...)
+		leaf = append(leaf, withLength([]byte{0x30, 0x00})...)
+		extra = withLength(der)
+	} else {
+		leaf = append(leaf, withLength(der)...)
+	}
+	return base64.StdEncoding.EncodeToString(leaf), base64.StdEncoding.EncodeToString(extra)
+}
+
+type testEntry struct {
+	LeafInput string `json:"leaf_input"`
+	ExtraData string `json:"extra_data"`
+}
+
+func TestRead(t *testing.T) {
+	var entries []testEntry
+	add := func(der []byte, precert bool) {
+		leaf, extra := testLeaf(der, precert)
+		entries = append(entries, testEntry{LeafInput: leaf, ExtraData: extra})
+	}
+	add(testCert(t, 1, "www.example.com"), false)
+	add(testCert(t, 2, "www.owasp.org"), false)
+
+	size := len(entries)
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/log/ct/v1/get-sth":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"tree_size": size})
+		case "/log/ct/v1/get-entries":
+			requests++
+			start, _ := strconv.Atoi(req.URL.Query().Get("start"))
+			end, _ := strconv.Atoi(req.URL.Query().Get("end"))
+			// The log returns at most two entries per request
+			if end > start+1 {
+				end = start + 1
+			}
+			if end >= size {
+				end = size - 1
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries[start : end+1]})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), StateFileName)
+	state, err := ReadState(path)
+	if err != nil || len(state) != 0 {
+		t.Fatalf("Expected an empty state: %v", err)
+	}
+
+	logs, err := LogsByName([]string{srv.URL + "/log"})
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Failed to select the log by URL: %v", err)
+	}
+	l := logs[0]
+	inScope := func(name string) bool {
+		return name == "owasp.org" || strings.HasSuffix(name, ".owasp.org")
+	}
+
+	// The first run only records the size of the tree
+	if found, err := state.Read(context.Background(), l, 0, inScope); err != nil || len(found) != 0 {
+		t.Fatalf("Expected the first run to only record the checkpoint: %v, %v", found, err)
+	}
+	if cp := state[l.URL]; cp == nil || cp.Next != 2 || requests != 0 {
+		t.Fatalf("Unexpected checkpoint after the first run: %+v", cp)
+	}
+	if err := state.Write(path); err != nil {
+		t.Fatalf("Failed to write the state: %v", err)
+	}
+
+	add(testCert(t, 3, "*.dev.owasp.org", "owasp.org", "example.net"), true)
+	add(testCert(t, 4, "mail.example.com"), false)
+	add(testCert(t, 5, "api.owasp.org"), false)
+	size = len(entries)
+
+	state, err = ReadState(path)
+	if err != nil {
+		t.Fatalf("Failed to read the state: %v", err)
+	}
+
+	// Only the entries since the checkpoint are read, up to the limit
+	found, err := state.Read(context.Background(), l, 2, inScope)
+	if err != nil {
+		t.Fatalf("Failed to read the log: %v", err)
+	}
+	if len(found) != 1 || !found[0].Precert || found[0].Index != 2 || found[0].Serial != "3" ||
+		strings.Join(found[0].Names, ",") != "dev.owasp.org,owasp.org" {
+		t.Fatalf("Unexpected entries: %+v", found)
+	}
+	if found[0].Timestamp.Year() != 2026 {
+		t.Errorf("Unexpected timestamp: %s", found[0].Timestamp)
+	}
+	if cp := state[l.URL]; cp.Next != 4 || cp.Lag() != 1 {
+		t.Errorf("Expected one entry left to read, got %+v", cp)
+	}
+
+	found, err = state.Read(context.Background(), l, 0, inScope)
+	if err != nil || len(found) != 1 || found[0].Names[0] != "api.owasp.org" || state[l.URL].Lag() != 0 {
+		t.Errorf("Expected the remaining entry to be read: %+v, %v", found, err)
+	}
+
+	if _, err := LogsByName([]string{"Oak2026h1", "nessie"}); err == nil {
+		t.Error("Expected an error for an unknown log name")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// The ICANN Centralized Zone Data Service API endpoints.
+const (
+	CZDSAuthURL  = "https://account-api.icann.org/api/authenticate"
+	CZDSLinksURL = "https://czds-api.icann.org/czds/downloads/links"
+)
+
+// CZDSDirectory is the directory within the output directory where the zone files are saved.
+const CZDSDirectory = "czds"
+
+// CZDSZoneFile returns the path of the zone file saved for the TLD within the output directory.
+func CZDSZoneFile(dir, tld string) string {
+	return filepath.Join(dir, CZDSDirectory, strings.ToLower(tld)+".zone.gz")
+}
+
+// CZDSClient downloads the zone files the account has been approved to access.
+type CZDSClient struct {
+	AuthURL  string
+	LinksURL string
+	HTTP     *http.Client
+	token    string
+}
+
+// NewCZDSClient returns a CZDSClient for the ICANN CZDS API.
+func NewCZDSClient() *CZDSClient {
+	return &CZDSClient{
+		AuthURL:  CZDSAuthURL,
+		LinksURL: CZDSLinksURL,
+		// Zone files for the largest TLDs can take a long time to download
+		HTTP: amasshttp.NewClient("czds", &amasshttp.ClientOptions{
+			Timeout: 2 * time.Hour,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// Authenticate obtains the access token using the credentials of the CZDS account.
+func (c *CZDSClient) Authenticate(ctx context.Context, username, password string) error {
+	body, err := json.Marshal(map[string]string{
+		"username": username,
+		"password": password,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.AuthURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the CZDS authentication failed with status %d", resp.StatusCode)
+	}
+
+	var auth struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return err
+	}
+	if auth.AccessToken == "" {
+		return errors.New("the CZDS authentication did not return an access token")
+	}
+
+	c.token = auth.AccessToken
+	return nil
+}
+
+// ZoneLinks returns the download links for the zone files the account has been approved to access.
+func (c *CZDSClient) ZoneLinks(ctx context.Context) ([]string, error) {
+	resp, err := c.get(ctx, c.LinksURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var links []string
+	if err := json.NewDecoder(resp.Body).Decode(&links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// Download saves the zone file at the link to the provided path.
+func (c *CZDSClient) Download(ctx context.Context, link, dst string) error {
+	resp, err := c.get(ctx, link)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Write to a temporary file, so an interrupted download does not replace the previous zone file
+	tmp := dst + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to download %s: %v", link, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+func (c *CZDSClient) get(ctx context.Context, u string) (*http.Response, error) {
+	if c.token == "" {
+		return nil, errors.New("the CZDS client has not been authenticated")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("the CZDS request for %s failed with status %d", u, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// ZoneName returns the TLD of the zone file provided by the CZDS download link.
+func ZoneName(link string) string {
+	return strings.ToLower(strings.TrimSuffix(path.Base(link), ".zone"))
+}
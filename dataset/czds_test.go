@@ -0,0 +1,76 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCZDSDownload(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	mux.HandleFunc("/api/authenticate", func(w http.ResponseWriter, r *http.Request) {
+		var creds map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil || creds["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"accessToken": "token"})
+	})
+	mux.HandleFunc("/czds/downloads/links", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]string{srv.URL + "/czds/downloads/example.zone"})
+	})
+	mux.HandleFunc("/czds/downloads/example.zone", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("owasp.example.\t86400\tin\tns\tns1.owasp.example.\n"))
+	})
+
+	c := NewCZDSClient()
+	c.AuthURL = srv.URL + "/api/authenticate"
+	c.LinksURL = srv.URL + "/czds/downloads/links"
+
+	ctx := context.Background()
+	if err := c.Authenticate(ctx, "user", "wrong"); err == nil {
+		t.Errorf("Expected the authentication to fail with the wrong password")
+	}
+	if err := c.Authenticate(ctx, "user", "secret"); err != nil {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+
+	links, err := c.ZoneLinks(ctx)
+	if err != nil || len(links) != 1 {
+		t.Fatalf("Failed to obtain the zone links: %v", err)
+	}
+	if tld := ZoneName(links[0]); tld != "example" {
+		t.Errorf("Expected the zone name example, got %s", tld)
+	}
+
+	dst := filepath.Join(t.TempDir(), "example.zone")
+	if err := c.Download(ctx, links[0], dst); err != nil {
+		t.Fatalf("Failed to download the zone file: %v", err)
+	}
+
+	var count int
+	if err := ParseFile(dst, FormatZone, func(rec *Record) error {
+		count++
+		return nil
+	}); err != nil || count != 1 {
+		t.Errorf("Failed to parse the downloaded zone file: %v", err)
+	}
+	if _, err := os.Stat(dst + ".part"); !os.IsNotExist(err) {
+		t.Errorf("The temporary download file was not removed")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dataset streams DNS records from bulk datasets that were downloaded
+// ahead of time, such as zone files and forward / reverse DNS dumps, and from
+// the output of other tools.
+package dataset
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	amassformat "github.com/owasp-amass/amass/v4/format"
+)
+
+// Supported dataset formats.
+const (
+	FormatZone = "zone"
+	FormatFDNS = "fdns"
+	FormatRDNS = "rdns"
+	// Formats of the output written by other tools
+	FormatHosts   = "hosts"
+	FormatMassDNS = "massdns"
+	FormatNmap    = "nmap"
+	FormatAmass3  = "amass3"
+)
+
+// Record is a DNS resource record obtained from a dataset.
+type Record struct {
+	Name string
+	Type string
+	Data string
+}
+
+// RecordFunc is called for each record read from the dataset. Returning
+// an error stops the parsing of the dataset.
+type RecordFunc func(*Record) error
+
+// Formats returns the names of the supported dataset formats.
+func Formats() []string {
+	return []string{FormatZone, FormatFDNS, FormatRDNS, FormatHosts, FormatMassDNS, FormatNmap, FormatAmass3}
+}
+
+// Parse streams the records from the dataset in the provided format.
+func Parse(r io.Reader, format string, fn RecordFunc) error {
+	// The datasets written by Windows tools can be UTF-16 encoded or start with a byte order mark
+	r = amassformat.NewTextReader(r)
+
+	switch format {
+	case FormatZone:
+		return parseZone(r, fn)
+	case FormatFDNS, FormatRDNS:
+		return parseSonar(r, fn)
+	case FormatHosts:
+		return parseHosts(r, fn)
+	case FormatMassDNS:
+		return parseMassDNS(r, fn)
+	case FormatNmap:
+		return parseNmap(r, fn)
+	case FormatAmass3:
+		return parseAmass3(r, fn)
+	}
+	return fmt.Errorf("the dataset format %s is not supported", format)
+}
+
+// ParseFile streams the records from the dataset file, which may be gzip compressed.
+func ParseFile(path, format string, fn RecordFunc) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open the dataset %s: %v", path, err)
+	}
+	defer f.Close()
+
+	r, err := decompress(f)
+	if err != nil {
+		return fmt.Errorf("failed to read the dataset %s: %v", path, err)
+	}
+	return Parse(r, format, fn)
+}
+
+// decompress returns a reader for the gzip compressed data, or the original data otherwise.
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+func cleanName(name string) string {
+	return amassformat.NormalizeName(name)
+}
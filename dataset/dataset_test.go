@@ -0,0 +1,99 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/bench"
+)
+
+func TestParseZone(t *testing.T) {
+	zone := `$ORIGIN example.
+$TTL 86400
+owasp	IN	NS	ns1.owasp.example.
+www.owasp	IN	A	192.0.2.1
+mail.owasp	IN	MX	10 mx.owasp.example.
+`
+	var records []*Record
+	if err := Parse(strings.NewReader(zone), FormatZone, func(rec *Record) error {
+		records = append(records, rec)
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to parse the zone file: %v", err)
+	}
+
+	expected := []Record{
+		{Name: "owasp.example", Type: "NS", Data: "ns1.owasp.example"},
+		{Name: "www.owasp.example", Type: "A", Data: "192.0.2.1"},
+		{Name: "mail.owasp.example", Type: "MX", Data: "mx.owasp.example"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(records))
+	}
+	for i, rec := range records {
+		if *rec != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], *rec)
+		}
+	}
+}
+
+func TestParseSonarGzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(`{"timestamp":"1690000000","name":"WWW.owasp.org","type":"a","value":"192.0.2.1"}
+not json
+{"timestamp":"1690000000","name":"192.0.2.2","type":"ptr","value":"host.owasp.org"}
+`))
+	_ = zw.Close()
+
+	r, err := decompress(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decompress the dataset: %v", err)
+	}
+
+	var records []*Record
+	if err := Parse(r, FormatFDNS, func(rec *Record) error {
+		records = append(records, rec)
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to parse the dataset: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if r := records[0]; r.Name != "www.owasp.org" || r.Type != "A" || r.Data != "192.0.2.1" {
+		t.Errorf("Unexpected forward DNS record: %v", *r)
+	}
+	if r := records[1]; r.Name != "192.0.2.2" || r.Type != "PTR" || r.Data != "host.owasp.org" {
+		t.Errorf("Unexpected reverse DNS record: %v", *r)
+	}
+}
+
+func BenchmarkParseZone(b *testing.B) {
+	target, err := bench.NewTarget("example.com", 10000)
+	if err != nil {
+		b.Fatalf("Failed to start the synthetic target: %v", err)
+	}
+	target.Close()
+	zone := target.Zone()
+
+	stats := bench.Start()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Parse(strings.NewReader(zone), FormatZone, func(rec *Record) error {
+			stats.AddEvents(1)
+			return nil
+		}); err != nil {
+			b.Fatalf("Failed to parse the zone file: %v", err)
+		}
+	}
+	b.StopTimer()
+	stats.Report(b)
+}
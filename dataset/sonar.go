@@ -0,0 +1,47 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// sonarRecord is a line from the forward and reverse DNS datasets published by Project Sonar.
+type sonarRecord struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// parseSonar streams the records from the JSON lines of a FDNS or RDNS dump.
+func parseSonar(r io.Reader, fn RecordFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var rec sonarRecord
+		// Skip malformed lines instead of abandoning a very large dataset
+		if err := json.Unmarshal([]byte(line), &rec); err != nil || rec.Name == "" || rec.Value == "" {
+			continue
+		}
+
+		if err := fn(&Record{
+			Name: cleanName(rec.Name),
+			Type: strings.ToUpper(rec.Type),
+			Data: cleanName(rec.Value),
+		}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,167 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// parseHosts streams the names from a plain list with one subdomain name per line,
+// such as the output of subfinder or assetfinder.
+func parseHosts(r io.Reader, fn RecordFunc) error {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name := cleanName(strings.Fields(line)[0])
+		if _, ok := dns.IsDomainName(name); !ok || net.ParseIP(name) != nil {
+			continue
+		}
+		if err := fn(&Record{Name: name}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// parseMassDNS streams the records from the simple (-o S) or full (-o F) text output of massdns.
+func parseMassDNS(r io.Reader, fn RecordFunc) error {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// The full output includes the headers of the responses
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		rr, err := dns.NewRR(line)
+		if err != nil || rr == nil {
+			continue
+		}
+		if err := fn(rrRecord(rr)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// amass3Record is a line from the JSON output of Amass version 3.
+type amass3Record struct {
+	Name      string `json:"name"`
+	Addresses []struct {
+		IP string `json:"ip"`
+	} `json:"addresses"`
+}
+
+// parseAmass3 streams the names and addresses from the JSON lines written by Amass version 3.
+func parseAmass3(r io.Reader, fn RecordFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var rec amass3Record
+
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.Name == "" {
+			continue
+		}
+
+		name := cleanName(rec.Name)
+		if len(rec.Addresses) == 0 {
+			if err := fn(&Record{Name: name}); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, a := range rec.Addresses {
+			if err := fn(addrRecord(name, a.IP)); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// nmapHost is a host element from the XML output of nmap.
+type nmapHost struct {
+	Addresses []struct {
+		Addr     string `xml:"addr,attr"`
+		AddrType string `xml:"addrtype,attr"`
+	} `xml:"address"`
+	Hostnames []struct {
+		Name string `xml:"name,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"hostnames>hostname"`
+}
+
+// parseNmap streams the names and addresses of the hosts from the XML output (-oX) of nmap.
+// Hostnames provided by the user become address records, and the others PTR records.
+func parseNmap(r io.Reader, fn RecordFunc) error {
+	d := xml.NewDecoder(r)
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "host" {
+			continue
+		}
+
+		var host nmapHost
+		if err := d.DecodeElement(&host, &se); err != nil {
+			return err
+		}
+
+		for _, a := range host.Addresses {
+			if a.AddrType != "ipv4" && a.AddrType != "ipv6" {
+				continue
+			}
+
+			for _, h := range host.Hostnames {
+				name := cleanName(h.Name)
+				if name == "" {
+					continue
+				}
+
+				rec := addrRecord(name, a.Addr)
+				if h.Type == "PTR" {
+					rec = &Record{Name: a.Addr, Type: "PTR", Data: name}
+				}
+				if err := fn(rec); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func addrRecord(name, addr string) *Record {
+	ip := net.ParseIP(strings.TrimSpace(addr))
+	if ip == nil {
+		return &Record{Name: name}
+	}
+
+	rtype := "A"
+	if ip.To4() == nil {
+		rtype = "AAAA"
+	}
+	return &Record{Name: name, Type: rtype, Data: ip.String()}
+}
@@ -0,0 +1,100 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseToolOutput(t *testing.T) {
+	tests := []struct {
+		format   string
+		data     string
+		expected []Record
+	}{
+		{
+			format: FormatHosts,
+			data:   "# subfinder\nWWW.owasp.org\n\n192.0.2.1\napi.owasp.org [crtsh]\n",
+			expected: []Record{
+				{Name: "www.owasp.org"},
+				{Name: "api.owasp.org"},
+			},
+		},
+		{
+			format: FormatHosts,
+			data:   "\ufeffWWW.owasp.org\r\nbücher.example\r\n",
+			expected: []Record{
+				{Name: "www.owasp.org"},
+				{Name: "xn--bcher-kva.example"},
+			},
+		},
+		{
+			format: FormatMassDNS,
+			data: `;; Server: 8.8.8.8:53
+www.owasp.org. A 192.0.2.1
+owasp.org. 300 IN MX 10 mail.owasp.org.
+not a record
+`,
+			expected: []Record{
+				{Name: "www.owasp.org", Type: "A", Data: "192.0.2.1"},
+				{Name: "owasp.org", Type: "MX", Data: "mail.owasp.org"},
+			},
+		},
+		{
+			format: FormatAmass3,
+			data: `{"name":"www.owasp.org","domain":"owasp.org","addresses":[{"ip":"192.0.2.1","cidr":"192.0.2.0/24","asn":64496},{"ip":"2001:db8::1"}],"tag":"cert","sources":["crtsh"]}
+{"name":"dev.owasp.org","domain":"owasp.org","addresses":[]}
+`,
+			expected: []Record{
+				{Name: "www.owasp.org", Type: "A", Data: "192.0.2.1"},
+				{Name: "www.owasp.org", Type: "AAAA", Data: "2001:db8::1"},
+				{Name: "dev.owasp.org"},
+			},
+		},
+		{
+			format: FormatNmap,
+			data: `<?xml version="1.0"?>
+<nmaprun scanner="nmap">
+<host><status state="up"/>
+<address addr="192.0.2.1" addrtype="ipv4"/>
+<address addr="00:00:5E:00:53:01" addrtype="mac"/>
+<hostnames>
+<hostname name="www.owasp.org" type="user"/>
+<hostname name="host1.owasp.org" type="PTR"/>
+</hostnames>
+<ports><port protocol="tcp" portid="443"><state state="open"/></port></ports>
+</host>
+</nmaprun>
+`,
+			expected: []Record{
+				{Name: "www.owasp.org", Type: "A", Data: "192.0.2.1"},
+				{Name: "192.0.2.1", Type: "PTR", Data: "host1.owasp.org"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var records []*Record
+
+		if err := Parse(strings.NewReader(test.data), test.format, func(rec *Record) error {
+			records = append(records, rec)
+			return nil
+		}); err != nil {
+			t.Errorf("%s: failed to parse the output: %v", test.format, err)
+			continue
+		}
+
+		if len(records) != len(test.expected) {
+			t.Errorf("%s: expected %d records, got %d", test.format, len(test.expected), len(records))
+			continue
+		}
+		for i, rec := range records {
+			if *rec != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.format, test.expected[i], *rec)
+			}
+		}
+	}
+}
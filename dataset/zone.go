@@ -0,0 +1,56 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"io"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// parseZone streams the records from a master file, such as the zone files provided by CZDS.
+func parseZone(r io.Reader, fn RecordFunc) error {
+	zp := dns.NewZoneParser(r, "", "")
+	zp.SetIncludeAllowed(false)
+
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		if err := fn(rrRecord(rr)); err != nil {
+			return err
+		}
+	}
+	return zp.Err()
+}
+
+// rrRecord returns the Record for the DNS resource record.
+func rrRecord(rr dns.RR) *Record {
+	hdr := rr.Header()
+
+	var data string
+	switch v := rr.(type) {
+	case *dns.A:
+		data = v.A.String()
+	case *dns.AAAA:
+		data = v.AAAA.String()
+	case *dns.CNAME:
+		data = v.Target
+	case *dns.NS:
+		data = v.Ns
+	case *dns.MX:
+		data = v.Mx
+	case *dns.PTR:
+		data = v.Ptr
+	case *dns.SRV:
+		data = v.Target
+	default:
+		data = strings.TrimSpace(strings.TrimPrefix(rr.String(), hdr.String()))
+	}
+
+	return &Record{
+		Name: cleanName(hdr.Name),
+		Type: dns.TypeToString[hdr.Rrtype],
+		Data: cleanName(data),
+	}
+}
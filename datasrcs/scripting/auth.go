@@ -0,0 +1,94 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// authConfig is provided by the 'auth' global of the scripts, and attaches the credentials of the API key
+// in use to the requests as a header, so the keys are not embedded in the URLs stored by caches and proxies.
+type authConfig struct {
+	// Header is the name of the header carrying the credential, such as x-apikey
+	Header string
+	// Prefix is written before the credential, such as the Bearer authentication scheme
+	Prefix string
+	// Domain limits the requests receiving the header to the API of the data source
+	Domain string
+	// Credential is the field of the credentials sent: apikey (default) or secret
+	Credential string
+}
+
+// scriptAuth returns the authConfig provided by the 'auth' global, or nil when the script does not provide it.
+func (s *Script) scriptAuth() (*authConfig, error) {
+	lv := s.luaState.GetGlobal("auth")
+	if lv.Type() == lua.LTNil {
+		return nil, nil
+	}
+
+	tbl, ok := lv.(*lua.LTable)
+	if !ok {
+		return nil, errors.New("the script global 'auth' is not a table")
+	}
+
+	a := new(authConfig)
+	a.Header, _ = getStringField(s.luaState, tbl, "header")
+	a.Prefix, _ = getStringField(s.luaState, tbl, "prefix")
+	a.Domain, _ = getStringField(s.luaState, tbl, "domain")
+	a.Credential, _ = getStringField(s.luaState, tbl, "credential")
+
+	a.Domain = strings.ToLower(strings.TrimSuffix(a.Domain, "."))
+	if a.Header == "" || a.Domain == "" {
+		return nil, errors.New("the script global 'auth' must provide the header and domain")
+	}
+
+	a.Credential = strings.ToLower(a.Credential)
+	if a.Credential == "" {
+		a.Credential = "apikey"
+	}
+	if a.Credential != "apikey" && a.Credential != "secret" {
+		return nil, errors.New("the 'auth' credential must be apikey or secret")
+	}
+	return a, nil
+}
+
+// attach returns the header with the credential added when the request is sent to the domain of the API.
+// The header provided by the script is kept when it already carries the credential.
+func (a *authConfig) attach(rawURL string, hdr http.Header, creds *http.Credentials) http.Header {
+	if a == nil || creds == nil {
+		return hdr
+	}
+
+	value := creds.Apikey
+	if a.Credential == "secret" {
+		value = creds.Secret
+	}
+	if value == "" {
+		return hdr
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.EqualFold(u.Scheme, "https") {
+		return hdr
+	}
+	if host := strings.ToLower(u.Hostname()); host != a.Domain && !strings.HasSuffix(host, "."+a.Domain) {
+		return hdr
+	}
+
+	attached := make(http.Header, len(hdr)+1)
+	for k, v := range hdr {
+		if strings.EqualFold(k, a.Header) {
+			return hdr
+		}
+		attached[k] = v
+	}
+	attached[a.Header] = a.Prefix + value
+	return attached
+}
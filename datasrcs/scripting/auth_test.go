@@ -0,0 +1,94 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestScriptAuth(t *testing.T) {
+	tests := []struct {
+		script string
+		auth   *authConfig
+		err    bool
+	}{
+		{script: `name = "None"`},
+		{
+			script: `auth = {['header']="Authorization", ['prefix']="Bearer ", ['domain']="IPinfo.io."}`,
+			auth:   &authConfig{Header: "Authorization", Prefix: "Bearer ", Domain: "ipinfo.io", Credential: "apikey"},
+		},
+		{
+			script: `auth = {['header']="X-Secret", ['domain']="example.com", ['credential']="Secret"}`,
+			auth:   &authConfig{Header: "X-Secret", Domain: "example.com", Credential: "secret"},
+		},
+		{script: `auth = "x-apikey"`, err: true},
+		{script: `auth = {['header']="x-apikey"}`, err: true},
+		{script: `auth = {['header']="x-apikey", ['domain']="example.com", ['credential']="password"}`, err: true},
+	}
+
+	for _, test := range tests {
+		s := &Script{luaState: lua.NewState()}
+		if err := s.luaState.DoString(test.script); err != nil {
+			t.Fatalf("Failed to load %s: %v", test.script, err)
+		}
+
+		a, err := s.scriptAuth()
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected an error for %s", test.script)
+			}
+		} else if err != nil {
+			t.Errorf("Unexpected error for %s: %v", test.script, err)
+		} else if (a == nil) != (test.auth == nil) || (a != nil && *a != *test.auth) {
+			t.Errorf("%s: expected %+v, got %+v", test.script, test.auth, a)
+		}
+		s.luaState.Close()
+	}
+}
+
+func TestAuthAttach(t *testing.T) {
+	a := &authConfig{Header: "x-apikey", Domain: "virustotal.com", Credential: "apikey"}
+	creds := &http.Credentials{Apikey: "key", Secret: "secret"}
+
+	hdr := http.Header{"Accept": "application/json"}
+	got := a.attach("https://www.virustotal.com/api/v3/domains/example.com/subdomains", hdr, creds)
+	if got["x-apikey"] != "key" || got["Accept"] != "application/json" {
+		t.Errorf("The credential was not attached: %v", got)
+	}
+	if _, found := hdr["x-apikey"]; found {
+		t.Error("The header provided by the script was modified")
+	}
+
+	for _, u := range []string{
+		"http://www.virustotal.com/api/v3/domains/example.com",
+		"https://virustotal.com.example.net/api",
+		"https://example.com/?u=https://www.virustotal.com",
+		"::not a url",
+	} {
+		if got := a.attach(u, nil, creds); got["x-apikey"] != "" {
+			t.Errorf("The credential was attached to the request for %s", u)
+		}
+	}
+
+	if got := a.attach("https://virustotal.com/", http.Header{"X-APIKEY": "other"}, creds); got["X-APIKEY"] != "other" || len(got) != 1 {
+		t.Errorf("The header provided by the script was replaced: %v", got)
+	}
+	if got := a.attach("https://virustotal.com/", nil, &http.Credentials{}); len(got) != 0 {
+		t.Errorf("The header was attached without a credential: %v", got)
+	}
+
+	b := &authConfig{Header: "Authorization", Prefix: "Bearer ", Domain: "ipinfo.io", Credential: "secret"}
+	if got := b.attach("https://ipinfo.io/AS15169/json", nil, creds); got["Authorization"] != "Bearer secret" {
+		t.Errorf("Unexpected header: %v", got)
+	}
+
+	var none *authConfig
+	if got := none.attach("https://virustotal.com/", hdr, creds); len(got) != 1 {
+		t.Errorf("The nil authConfig changed the header: %v", got)
+	}
+}
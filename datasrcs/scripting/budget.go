@@ -0,0 +1,266 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The event types of the data source callbacks that the budgets can limit.
+const (
+	EventVertical   = "vertical"
+	EventHorizontal = "horizontal"
+	EventResolved   = "resolved"
+	EventSubdomain  = "subdomain"
+	EventAddress    = "address"
+	EventASN        = "asn"
+	// allEvents is the key of the limit shared by all the event types
+	allEvents = "all"
+)
+
+var budgetEvents = []string{EventVertical, EventHorizontal, EventResolved, EventSubdomain, EventAddress, EventASN}
+
+// Budget limits the share of the enumeration a data source can consume, so an expensive data
+// source, such as the crawler, cannot monopolize the capacity used by the other data sources.
+type Budget struct {
+	// EventsPerMinute maps the event types to the maximum number processed per minute.
+	// The all key provides the maximum shared by all the event types.
+	EventsPerMinute map[string]int
+	// MaxConcurrent is the maximum number of the external calls made concurrently
+	MaxConcurrent int
+}
+
+// BudgetSettings are the budgets provided by the source_budgets option of the configuration.
+type BudgetSettings struct {
+	Default *Budget
+	Sources map[string]*Budget
+}
+
+// ParseBudgetSettings returns the settings from the source_budgets option of the configuration, which maps the
+// data source names to the budgets of those data sources, with the default key providing the budget of the others.
+func ParseBudgetSettings(raw interface{}) (*BudgetSettings, error) {
+	settings := &BudgetSettings{Sources: make(map[string]*Budget)}
+	if raw == nil {
+		return settings, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the source_budgets option must map data source names to budgets")
+	}
+
+	for name, v := range m {
+		b, err := parseBudget(v)
+		if err != nil {
+			return nil, fmt.Errorf("the %s budget: %v", name, err)
+		}
+
+		if strings.EqualFold(name, "default") {
+			settings.Default = b
+			continue
+		}
+		settings.Sources[strings.ToLower(name)] = b
+	}
+	return settings, nil
+}
+
+func parseBudget(raw interface{}) (*Budget, error) {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the budget must be a map")
+	}
+
+	b := &Budget{EventsPerMinute: make(map[string]int)}
+	for key, v := range m {
+		switch key {
+		case "events_per_minute":
+			if n, ok := v.(int); ok {
+				if n <= 0 {
+					return nil, errors.New("events_per_minute must be greater than zero")
+				}
+				b.EventsPerMinute[allEvents] = n
+				continue
+			}
+
+			events, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("events_per_minute must be a number or a map of event types to numbers")
+			}
+			for event, v := range events {
+				event = strings.ToLower(event)
+				if event != allEvents && !validEvent(event) {
+					return nil, fmt.Errorf("%s is not an event type", event)
+				}
+
+				n, ok := v.(int)
+				if !ok || n <= 0 {
+					return nil, fmt.Errorf("the %s events per minute must be a number greater than zero", event)
+				}
+				b.EventsPerMinute[event] = n
+			}
+		case "max_concurrent":
+			n, ok := v.(int)
+			if !ok || n <= 0 {
+				return nil, errors.New("max_concurrent must be a number greater than zero")
+			}
+			b.MaxConcurrent = n
+		default:
+			return nil, fmt.Errorf("%s is not a budget setting", key)
+		}
+	}
+	return b, nil
+}
+
+func validEvent(event string) bool {
+	for _, e := range budgetEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ForSource returns the budget of the named data source, where the settings missing from the
+// budget of the data source are provided by the default budget, or nil when neither is configured.
+func (s *BudgetSettings) ForSource(name string) *Budget {
+	src := s.Sources[strings.ToLower(name)]
+	if src == nil {
+		return s.Default
+	} else if s.Default == nil {
+		return src
+	}
+
+	b := &Budget{
+		EventsPerMinute: make(map[string]int),
+		MaxConcurrent:   src.MaxConcurrent,
+	}
+	if b.MaxConcurrent == 0 {
+		b.MaxConcurrent = s.Default.MaxConcurrent
+	}
+	for event, n := range s.Default.EventsPerMinute {
+		b.EventsPerMinute[event] = n
+	}
+	for event, n := range src.EventsPerMinute {
+		b.EventsPerMinute[event] = n
+	}
+	return b
+}
+
+// budgetLimiter enforces the budget of a data source. The events are counted within a sliding window
+// of one minute, and the external calls hold a slot of the semaphore until they complete.
+type budgetLimiter struct {
+	sync.Mutex
+	budget *Budget
+	window time.Duration
+	events map[string][]time.Time
+	slots  chan struct{}
+}
+
+func newBudgetLimiter(b *Budget) *budgetLimiter {
+	l := &budgetLimiter{
+		budget: b,
+		window: time.Minute,
+		events: make(map[string][]time.Time),
+	}
+	if b != nil && b.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, b.MaxConcurrent)
+	}
+	return l
+}
+
+// event blocks until the budget permits another event of the type to be processed, and returns
+// false when the context expires first. The event is counted once it is permitted.
+func (l *budgetLimiter) event(ctx context.Context, event string) bool {
+	if l.budget == nil || len(l.budget.EventsPerMinute) == 0 {
+		return true
+	}
+
+	for {
+		l.Lock()
+		wait := l.reserve(event, time.Now())
+		l.Unlock()
+		if wait <= 0 {
+			return true
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return false
+		case <-t.C:
+		}
+	}
+}
+
+// reserve counts the event when both the limit of the type and the shared limit permit it,
+// and otherwise returns how long to wait before the oldest event leaves the window.
+func (l *budgetLimiter) reserve(event string, now time.Time) time.Duration {
+	var wait time.Duration
+
+	keys := []string{event, allEvents}
+	for _, key := range keys {
+		limit, found := l.budget.EventsPerMinute[key]
+		if !found {
+			continue
+		}
+
+		times := l.events[key]
+		for len(times) > 0 && now.Sub(times[0]) >= l.window {
+			times = times[1:]
+		}
+		l.events[key] = times
+
+		if len(times) >= limit {
+			if w := l.window - now.Sub(times[0]); w > wait {
+				wait = w
+			}
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	for _, key := range keys {
+		if _, found := l.budget.EventsPerMinute[key]; found {
+			l.events[key] = append(l.events[key], now)
+		}
+	}
+	return 0
+}
+
+// acquire blocks until the number of concurrent external calls is within the budget, and returns
+// false when the context expires first. The release method must be called once the call completes.
+func (l *budgetLimiter) acquire(ctx context.Context) bool {
+	if l.slots == nil {
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case l.slots <- struct{}{}:
+	}
+	return true
+}
+
+func (l *budgetLimiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// concurrency returns the maximum number of concurrent external calls, or the default when the budget does not limit them.
+func (l *budgetLimiter) concurrency(def int) int {
+	if l.slots != nil && cap(l.slots) < def {
+		return cap(l.slots)
+	}
+	return def
+}
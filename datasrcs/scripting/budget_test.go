@@ -0,0 +1,111 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseBudgetSettings(t *testing.T) {
+	settings, err := ParseBudgetSettings(map[string]interface{}{
+		"default": map[string]interface{}{
+			"events_per_minute": 120,
+			"max_concurrent":    10,
+		},
+		"Active Crawl": map[string]interface{}{
+			"events_per_minute": map[string]interface{}{"Vertical": 5, "resolved": 30},
+			"max_concurrent":    2,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the budgets: %v", err)
+	}
+
+	if b := settings.ForSource("Crtsh"); b == nil || b.MaxConcurrent != 10 || b.EventsPerMinute[allEvents] != 120 {
+		t.Errorf("Expected the default budget, got %+v", b)
+	}
+	b := settings.ForSource("active crawl")
+	if b == nil || b.MaxConcurrent != 2 {
+		t.Fatalf("Expected the budget of the crawler, got %+v", b)
+	}
+	if b.EventsPerMinute[EventVertical] != 5 || b.EventsPerMinute[EventResolved] != 30 || b.EventsPerMinute[allEvents] != 120 {
+		t.Errorf("Expected the event limits merged with the default, got %v", b.EventsPerMinute)
+	}
+
+	if s, err := ParseBudgetSettings(nil); err != nil || s.ForSource("Crtsh") != nil {
+		t.Errorf("Expected no budget without the option, got %v: %v", s, err)
+	}
+	for _, raw := range []interface{}{
+		"fast",
+		map[string]interface{}{"Crtsh": 10},
+		map[string]interface{}{"Crtsh": map[string]interface{}{"max_concurrent": 0}},
+		map[string]interface{}{"Crtsh": map[string]interface{}{"events_per_minute": map[string]interface{}{"dns": 10}}},
+		map[string]interface{}{"Crtsh": map[string]interface{}{"requests": 10}},
+	} {
+		if _, err := ParseBudgetSettings(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+func TestBudgetLimiterEvents(t *testing.T) {
+	l := newBudgetLimiter(&Budget{EventsPerMinute: map[string]int{EventVertical: 2, allEvents: 3}})
+	now := time.Now()
+
+	if l.reserve(EventVertical, now) != 0 || l.reserve(EventVertical, now) != 0 {
+		t.Fatal("Expected the first two vertical events to be permitted")
+	}
+	if l.reserve(EventVertical, now) <= 0 {
+		t.Error("Expected the third vertical event to wait")
+	}
+	if l.reserve(EventAddress, now) != 0 {
+		t.Error("Expected the address event to be permitted")
+	}
+	if l.reserve(EventAddress, now) <= 0 {
+		t.Error("Expected the shared limit to be reached")
+	}
+	if l.reserve(EventVertical, now.Add(time.Minute)) != 0 {
+		t.Error("Expected the events to leave the window after a minute")
+	}
+
+	l = newBudgetLimiter(&Budget{EventsPerMinute: map[string]int{allEvents: 1}})
+	if !l.event(context.Background(), EventAddress) {
+		t.Fatal("Expected the first event to be permitted")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if l.event(ctx, EventAddress) {
+		t.Error("Expected the event to wait until the context expired")
+	}
+	if !newBudgetLimiter(nil).event(ctx, EventVertical) {
+		t.Error("Expected the events to be permitted without a budget")
+	}
+}
+
+func TestBudgetLimiterConcurrency(t *testing.T) {
+	l := newBudgetLimiter(&Budget{MaxConcurrent: 1})
+	if c := l.concurrency(5); c != 1 {
+		t.Errorf("Expected the concurrency to be limited to 1, got %d", c)
+	}
+	if c := newBudgetLimiter(nil).concurrency(5); c != 5 {
+		t.Errorf("Expected the default concurrency, got %d", c)
+	}
+
+	if !l.acquire(context.Background()) {
+		t.Fatal("Expected the first call to acquire the slot")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if l.acquire(ctx) {
+		t.Error("Expected the second call to wait until the context expired")
+	}
+
+	l.release()
+	if !l.acquire(context.Background()) {
+		t.Error("Expected the slot to be acquired after the release")
+	}
+}
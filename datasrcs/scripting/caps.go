@@ -0,0 +1,122 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CapSettings are the caps provided by the source_caps option of the configuration.
+type CapSettings struct {
+	Default int
+	Sources map[string]int
+}
+
+// ParseCapSettings returns the settings from the source_caps option of the configuration, which maps the
+// data source names to the maximum number of names each provides for a root domain during the session,
+// with the default key providing the cap of the others.
+func ParseCapSettings(raw interface{}) (*CapSettings, error) {
+	settings := &CapSettings{Sources: make(map[string]int)}
+	if raw == nil {
+		return settings, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the source_caps option must map data source names to numbers of names")
+	}
+
+	for name, v := range m {
+		n, ok := v.(int)
+		if !ok || n <= 0 {
+			return nil, fmt.Errorf("the %s cap must be a number greater than zero", name)
+		}
+
+		if strings.EqualFold(name, "default") {
+			settings.Default = n
+			continue
+		}
+		settings.Sources[strings.ToLower(name)] = n
+	}
+	return settings, nil
+}
+
+// ForSource returns the cap of the named data source, or zero when its names are not capped.
+func (s *CapSettings) ForSource(name string) int {
+	if n, found := s.Sources[strings.ToLower(name)]; found {
+		return n
+	}
+	return s.Default
+}
+
+// nameCap counts the unique names a data source provides for each root domain. Once the cap of a
+// root domain is reached, the new names are dropped and counted, so the truncation can be reported.
+type nameCap struct {
+	sync.Mutex
+	limit     int
+	names     map[string]map[string]struct{}
+	truncated map[string]int
+}
+
+func newNameCap(limit int) *nameCap {
+	return &nameCap{
+		limit:     limit,
+		names:     make(map[string]map[string]struct{}),
+		truncated: make(map[string]int),
+	}
+}
+
+// allow returns true when the name is within the cap of the root domain.
+func (c *nameCap) allow(domain, name string) bool {
+	if c == nil || c.limit <= 0 {
+		return true
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	set, found := c.names[domain]
+	if !found {
+		set = make(map[string]struct{})
+		c.names[domain] = set
+	}
+	if _, found := set[name]; found {
+		return true
+	}
+	if len(set) >= c.limit {
+		c.truncated[domain]++
+		return false
+	}
+
+	set[name] = struct{}{}
+	return true
+}
+
+// NameCap returns the maximum number of names the script provides for each root domain, or zero when not capped.
+func (s *Script) NameCap() int {
+	if s.caps == nil {
+		return 0
+	}
+	return s.caps.limit
+}
+
+// Truncated returns the number of names dropped for each root domain once the cap was reached.
+func (s *Script) Truncated() map[string]int {
+	if s.caps == nil {
+		return nil
+	}
+
+	s.caps.Lock()
+	defer s.caps.Unlock()
+
+	results := make(map[string]int, len(s.caps.truncated))
+	for domain, n := range s.caps.truncated {
+		results[domain] = n
+	}
+	return results
+}
@@ -0,0 +1,66 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import "testing"
+
+func TestParseCapSettings(t *testing.T) {
+	settings, err := ParseCapSettings(map[string]interface{}{
+		"default": 1000,
+		"Crtsh":   50000,
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the caps: %v", err)
+	}
+	if n := settings.ForSource("crtsh"); n != 50000 {
+		t.Errorf("Expected the cap of the data source, got %d", n)
+	}
+	if n := settings.ForSource("HackerTarget"); n != 1000 {
+		t.Errorf("Expected the default cap, got %d", n)
+	}
+
+	if s, err := ParseCapSettings(nil); err != nil || s.ForSource("Crtsh") != 0 {
+		t.Errorf("Expected no cap without the option, got %v: %v", s, err)
+	}
+	for _, raw := range []interface{}{
+		1000,
+		map[string]interface{}{"Crtsh": "many"},
+		map[string]interface{}{"Crtsh": 0},
+	} {
+		if _, err := ParseCapSettings(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+func TestNameCap(t *testing.T) {
+	c := newNameCap(2)
+
+	for _, name := range []string{"www.owasp.org", "mail.owasp.org", "www.owasp.org"} {
+		if !c.allow("owasp.org", name) {
+			t.Errorf("Expected %s to be within the cap", name)
+		}
+	}
+	if c.allow("owasp.org", "dev.owasp.org") || c.allow("owasp.org", "api.owasp.org") {
+		t.Error("Expected the names beyond the cap to be dropped")
+	}
+	// Each root domain has its own cap
+	if !c.allow("example.com", "www.example.com") {
+		t.Error("Expected the other root domain to be within the cap")
+	}
+
+	s := &Script{caps: c}
+	if s.NameCap() != 2 {
+		t.Errorf("Expected the cap of two names, got %d", s.NameCap())
+	}
+	if tr := s.Truncated(); len(tr) != 1 || tr["owasp.org"] != 2 {
+		t.Errorf("Expected two names truncated for owasp.org, got %v", tr)
+	}
+
+	var unlimited *nameCap
+	if !unlimited.allow("owasp.org", "www.owasp.org") {
+		t.Error("Expected the names to be allowed without a cap")
+	}
+}
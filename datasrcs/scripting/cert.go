@@ -0,0 +1,125 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// certFilter collapses the certificate transparency entries describing the same certificate, since the
+// precertificate and the final certificate share the issuer and serial number, and the renewals carrying
+// an identical set of names, so the names of each certificate are only released once.
+type certFilter struct {
+	sync.Mutex
+	serials   map[string]struct{}
+	sans      map[string]struct{}
+	observed  int
+	collapsed int
+}
+
+func newCertFilter() *certFilter {
+	return &certFilter{
+		serials: make(map[string]struct{}),
+		sans:    make(map[string]struct{}),
+	}
+}
+
+// unique returns true when neither the serial number from the issuer nor the set of names were seen before.
+func (f *certFilter) unique(issuer, serial string, names []string) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	f.observed++
+	var dup bool
+	if serial != "" {
+		key := strings.ToLower(issuer + "|" + strings.TrimLeft(strings.ReplaceAll(serial, ":", ""), "0"))
+		if _, found := f.serials[key]; found {
+			dup = true
+		}
+		f.serials[key] = struct{}{}
+	}
+
+	key := sanKey(names)
+	if _, found := f.sans[key]; found {
+		dup = true
+	}
+	f.sans[key] = struct{}{}
+
+	if dup {
+		f.collapsed++
+	}
+	return !dup
+}
+
+// sanKey returns the names of the certificate normalized and sorted, so renewals share the key.
+func sanKey(names []string) string {
+	set := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		if n = strings.ToLower(strings.TrimSpace(n)); n != "" {
+			set[n] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(set))
+	for n := range set {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// CertificateCounts returns the number of certificate transparency entries provided by the script, and
+// how many of them were collapsed as precertificates of the same certificate or renewals of the same names.
+func (s *Script) CertificateCounts() (observed, collapsed int) {
+	s.certs.Lock()
+	defer s.certs.Unlock()
+
+	return s.certs.observed, s.certs.collapsed
+}
+
+// Wrapper so that scripts can send the names of a certificate found in the certificate transparency logs.
+// The table provides the issuer, the serial number, and the names as an array or a newline separated string.
+func (s *Script) newCert(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		L.Push(lua.LBool(false))
+		return 1
+	}
+
+	tbl := L.CheckTable(2)
+	issuer, _ := getStringField(L, tbl, "issuer")
+	serial, _ := getStringField(L, tbl, "serial")
+
+	var names []string
+	switch v := L.GetField(tbl, "names").(type) {
+	case lua.LString:
+		names = strings.Split(string(v), "\n")
+	case *lua.LTable:
+		v.ForEach(func(_, n lua.LValue) {
+			if str, ok := n.(lua.LString); ok {
+				names = append(names, string(str))
+			}
+		})
+	}
+	if len(names) == 0 || !s.certs.unique(issuer, serial, names) {
+		L.Push(lua.LBool(false))
+		return 1
+	}
+
+	for _, n := range names {
+		if name := s.subre.FindString(n); name != "" {
+			if name = http.CleanName(name); name != "" && !s.names.duplicate(name) {
+				s.newNameWithContext(ctx, name)
+			}
+		}
+	}
+	L.Push(lua.LBool(true))
+	return 1
+}
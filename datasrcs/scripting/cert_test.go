@@ -0,0 +1,70 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestCertFilter(t *testing.T) {
+	f := newCertFilter()
+
+	if !f.unique("R3", "04:ab", []string{"www.owasp.org", "owasp.org"}) {
+		t.Error("Expected the first certificate to be unique")
+	}
+	// The precertificate shares the issuer and serial number with the final certificate
+	if f.unique("R3", "04AB", []string{"owasp.org", "www.owasp.org", "vpn.owasp.org"}) {
+		t.Error("Expected the precertificate to be collapsed")
+	}
+	// The renewal carries the same names with another serial number
+	if f.unique("R3", "05cd", []string{"OWASP.org", "www.owasp.org "}) {
+		t.Error("Expected the renewal to be collapsed")
+	}
+	if !f.unique("R3", "06ef", []string{"api.owasp.org"}) {
+		t.Error("Expected the certificate with other names to be unique")
+	}
+	if f.observed != 4 || f.collapsed != 2 {
+		t.Errorf("Expected 4 certificates observed and 2 collapsed, got %d and %d", f.observed, f.collapsed)
+	}
+}
+
+func TestNewCert(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="certs"
+		type="testing"
+
+		function vertical(ctx, domain)
+			new_cert(ctx, {['issuer']="R3", ['serial']="01", ['names']={"www.owasp.org", "owasp.org"}})
+			new_cert(ctx, {['issuer']="R3", ['serial']="02", ['names']="owasp.org\nwww.owasp.org"})
+			new_cert(ctx, {['issuer']="R3", ['serial']="03", ['names']={"api.owasp.org"}})
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	script.Input() <- &requests.DNSRequest{Domain: domain}
+
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-timer.C:
+			t.Fatalf("Only %d names were released before the timeout", i)
+		case <-script.Output():
+		}
+	}
+
+	if observed, collapsed := script.(*Script).CertificateCounts(); observed != 3 || collapsed != 1 {
+		t.Errorf("Expected 3 certificates observed and 1 collapsed, got %d and %d", observed, collapsed)
+	}
+}
@@ -5,8 +5,16 @@
 package scripting
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/caffix/service"
 	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/progress"
+	"github.com/owasp-amass/amass/v4/ratelimit"
+	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
 	lua "github.com/yuin/gopher-lua"
 )
@@ -105,25 +113,47 @@ func (s *Script) config(L *lua.LState) int {
 }
 
 func (s *Script) dataSourceConfig(L *lua.LState) int {
-	dsc := s.sys.Config().DataSrcConfigs
-	if dsc == nil {
-		L.Push(lua.LNil)
-		return 1
+	var cfg *config.DataSource
+	if s.sys.Config().DataSrcConfigs != nil {
+		cfg = s.sys.Config().GetDataSourceConfig(s.String())
 	}
 
-	cfg := s.sys.Config().GetDataSourceConfig(s.String())
-	if cfg == nil {
+	opts := sourceOptions(s.sys.Config(), s.String())
+	if cfg == nil && len(opts) == 0 {
 		L.Push(lua.LNil)
 		return 1
 	}
 
 	tb := L.NewTable()
-	tb.RawSetString("name", lua.LString(cfg.Name))
-	if cfg.TTL != 0 {
-		tb.RawSetString("ttl", lua.LNumber(cfg.TTL))
+	tb.RawSetString("name", lua.LString(s.String()))
+	if cfg != nil {
+		tb.RawSetString("name", lua.LString(cfg.Name))
+		if cfg.TTL != 0 {
+			tb.RawSetString("ttl", lua.LNumber(cfg.TTL))
+		}
+	}
+
+	// The settings of the source_options option select the behavior of the script, such as the query mode
+	if len(opts) > 0 {
+		o := L.NewTable()
+
+		for k, v := range opts {
+			switch val := v.(type) {
+			case string:
+				o.RawSetString(k, lua.LString(val))
+			case bool:
+				o.RawSetString(k, lua.LBool(val))
+			case int:
+				o.RawSetString(k, lua.LNumber(val))
+			case float64:
+				o.RawSetString(k, lua.LNumber(val))
+			}
+		}
+		tb.RawSetString("options", o)
 	}
 
-	if creds := dsc.GetCredentials(cfg.Name); creds != nil {
+	// The credentials of the API key in use are provided, so keys with exhausted quotas are rotated
+	if creds := s.quota.Credentials(); creds != nil {
 		c := L.NewTable()
 
 		c.RawSetString("name", lua.LString(creds.Name))
@@ -146,6 +176,24 @@ func (s *Script) dataSourceConfig(L *lua.LState) int {
 	return 1
 }
 
+// sourceOptions returns the settings of the data source provided by the source_options option,
+// which maps the names of the data sources to their settings.
+func sourceOptions(cfg *config.Config, name string) map[string]interface{} {
+	all, ok := cfg.Options["source_options"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for k, v := range all {
+		if strings.EqualFold(k, name) {
+			if opts, ok := v.(map[string]interface{}); ok {
+				return opts
+			}
+		}
+	}
+	return nil
+}
+
 // Wrapper so that scripts can check if a subdomain name is in scope.
 func (s *Script) inScope(L *lua.LState) int {
 	result := lua.LFalse
@@ -164,9 +212,14 @@ func (s *Script) bruteWordlist(L *lua.LState) int {
 	tb := L.NewTable()
 
 	if _, err := extractContext(L.CheckUserData(1)); err == nil {
-		for _, word := range s.sys.Config().Wordlist {
+		cfg := s.sys.Config()
+		for _, word := range systems.Shuffle(cfg, wordlistSeedSalt, cfg.Wordlist) {
 			tb.Append(lua.LString(word))
 		}
+		// Each word provides a name to the brute forcing, which is counted as done once sent
+		if s.SourceType == bruteSourceType {
+			progress.Default.Tracker(progress.BruteForce).Add(len(cfg.Wordlist))
+		}
 	}
 
 	L.Push(tb)
@@ -178,7 +231,8 @@ func (s *Script) altWordlist(L *lua.LState) int {
 	tb := L.NewTable()
 
 	if _, err := extractContext(L.CheckUserData(1)); err == nil {
-		for _, word := range s.sys.Config().AltWordlist {
+		cfg := s.sys.Config()
+		for _, word := range systems.Shuffle(cfg, wordlistSeedSalt, cfg.AltWordlist) {
 			tb.Append(lua.LString(word))
 		}
 	}
@@ -199,6 +253,25 @@ func numRateLimitChecks(srv service.Service, num int) {
 	}
 }
 
+// waitRateLimit blocks until the request made with the API key can be sent. The requests made with the
+// keys shared by several engine instances are coordinated across the instances when configured, and
+// the rate limit of the data source is applied locally when the coordinator cannot be reached.
+func (s *Script) waitRateLimit(ctx context.Context, key *http.APIKey) error {
+	if ratelimit.Shared != nil && key != nil && s.seconds > 0 {
+		c := key.Creds
+		id := ratelimit.KeyID(s.String(), c.Username, c.Password, c.Apikey, c.Secret)
+
+		err := ratelimit.Wait(ctx, ratelimit.Shared, id, time.Duration(s.seconds)*time.Second)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		s.sys.Config().Log.Printf("%s: %v", s.String(), err)
+	}
+
+	numRateLimitChecks(s, s.seconds)
+	return nil
+}
+
 // Wrapper so scripts can block until past the data source rate limit.
 func (s *Script) checkRateLimit(L *lua.LState) int {
 	numRateLimitChecks(s, s.seconds)
@@ -0,0 +1,229 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/systems"
+	"gopkg.in/yaml.v3"
+)
+
+// DeclarationExtensions are the file extensions of the data source declarations in the scripts directory.
+var DeclarationExtensions = []string{".yaml", ".yml", ".json"}
+
+// Declaration describes an API data source that can be queried for subdomain names without writing a script.
+type Declaration struct {
+	// Name of the data source, which is used to find the credentials and TTL in the configuration
+	Name string `yaml:"name" json:"name"`
+	// URL template where {domain} is replaced with the root domain name
+	URL    string            `yaml:"url" json:"url"`
+	Method string            `yaml:"method,omitempty" json:"method,omitempty"`
+	Header map[string]string `yaml:"header,omitempty" json:"header,omitempty"`
+	// Body template where {domain} is replaced with the root domain name, used with the POST method
+	Body string           `yaml:"body,omitempty" json:"body,omitempty"`
+	Auth *DeclarationAuth `yaml:"auth,omitempty" json:"auth,omitempty"`
+	// Path is the dot-separated keys of the JSON array containing the records. When
+	// not provided, all the subdomain names found in the response body are used
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Field is the key of the record containing the subdomain name. When not provided, the records are names
+	Field string `yaml:"field,omitempty" json:"field,omitempty"`
+	// RateLimit is the number of seconds between requests to the API
+	RateLimit int `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+}
+
+// DeclarationAuth describes where the API key from the data source credentials is placed in the request.
+type DeclarationAuth struct {
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+	// Prefix is added before the API key in the header, such as "Bearer "
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	Query  string `yaml:"query,omitempty" json:"query,omitempty"`
+}
+
+// ParseDeclaration parses and validates the YAML or JSON data source declaration.
+func ParseDeclaration(data []byte) (*Declaration, error) {
+	var d Declaration
+	// JSON is valid YAML, so both formats are accepted
+	if err := yaml.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+
+	if d.Name == "" {
+		return nil, errors.New("the data source declaration does not provide a name")
+	}
+	if d.URL == "" {
+		return nil, fmt.Errorf("the %s data source declaration does not provide a URL", d.Name)
+	}
+	if d.Auth != nil && d.Auth.Header == "" && d.Auth.Query == "" {
+		return nil, fmt.Errorf("the %s data source declaration does not provide the auth header or query parameter", d.Name)
+	}
+	return &d, nil
+}
+
+// Script returns the ADS script that implements the declared data source.
+func (d *Declaration) Script() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	// The declaration is embedded in a Lua long string that its content cannot terminate
+	level := ""
+	for strings.Contains(string(data), "]"+level+"]") {
+		level += "="
+	}
+	return fmt.Sprintf(declarativeScript, "["+level+"["+string(data)+"]"+level+"]"), nil
+}
+
+// NewDeclarativeScript returns the data source described by the YAML or JSON declaration.
+func NewDeclarativeScript(data []byte, sys systems.System) (*Script, error) {
+	d, err := ParseDeclaration(data)
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := d.Script()
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewScript(script, sys)
+	if s == nil {
+		return nil, fmt.Errorf("failed to load the %s data source declaration", d.Name)
+	}
+	return s, nil
+}
+
+// AcquireDeclarations returns the data source declarations found in the directory.
+func AcquireDeclarations(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	decls := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() || !isDeclaration(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if data, err := os.ReadFile(path); err == nil {
+			decls[path] = data
+		}
+	}
+	return decls, nil
+}
+
+func isDeclaration(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+
+	for _, e := range DeclarationExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+const declarativeScript = `
+local json = require("json")
+local url = require("url")
+
+local typeof = type
+local def = json.decode(%s)
+
+name = def.name
+type = "api"
+
+function start()
+    if (def.rate_limit ~= nil and def.rate_limit > 0) then
+        set_rate_limit(def.rate_limit)
+    end
+end
+
+function check()
+    return (def.auth == nil or api_key() ~= "")
+end
+
+function api_key()
+    local cfg = datasrc_config()
+    if (cfg ~= nil and cfg.credentials ~= nil and cfg.credentials.key ~= nil) then
+        return cfg.credentials.key
+    end
+    return ""
+end
+
+function vertical(ctx, domain)
+    local u = string.gsub(def.url, "{domain}", domain)
+    local params = {
+        ['url']=u,
+        ['method']=def.method,
+        ['header']={},
+    }
+    if (def.body ~= nil and def.body ~= "") then
+        params.body = string.gsub(def.body, "{domain}", domain)
+    end
+    if (def.header ~= nil) then
+        for k, v in pairs(def.header) do
+            params.header[k] = v
+        end
+    end
+
+    if (def.auth ~= nil) then
+        local key = api_key()
+        if (key == "") then
+            return
+        end
+
+        if (def.auth.header ~= nil and def.auth.header ~= "") then
+            params.header[def.auth.header] = (def.auth.prefix or "") .. key
+        end
+        if (def.auth.query ~= nil and def.auth.query ~= "") then
+            local sep = "&"
+            if (string.find(params.url, "?", 1, true) == nil) then
+                sep = "?"
+            end
+            params.url = params.url .. sep .. url.build_query_string({[def.auth.query]=key})
+        end
+    end
+
+    if (def.path == nil or def.path == "") then
+        local resp, err = request(ctx, params)
+        if (err ~= nil and err ~= "") then
+            log(ctx, "vertical request to service failed: " .. err)
+            return
+        elseif (resp.status_code < 200 or resp.status_code >= 400) then
+            log(ctx, "vertical request to service returned with status: " .. resp.status)
+            return
+        end
+
+        send_names(ctx, resp.body)
+        return
+    end
+
+    params.path = def.path
+    local _, err = json_stream(ctx, params, function(record)
+        local n = record
+        if (def.field ~= nil and def.field ~= "") then
+            if (typeof(record) ~= "table") then
+                return
+            end
+            n = record[def.field]
+        end
+
+        if (typeof(n) == "string" and n ~= "") then
+            new_name(ctx, n)
+        end
+    end)
+    if (err ~= nil and err ~= "") then
+        log(ctx, "vertical request to service failed: " .. err)
+    end
+end
+`
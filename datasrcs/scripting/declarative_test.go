@@ -0,0 +1,98 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestParseDeclaration(t *testing.T) {
+	tests := []struct {
+		data  string
+		valid bool
+	}{
+		{"name: PDNS\nurl: https://pdns.example.com/{domain}", true},
+		{`{"name": "PDNS", "url": "https://pdns.example.com/{domain}", "auth": {"query": "key"}}`, true},
+		{"url: https://pdns.example.com/{domain}", false},
+		{"name: PDNS", false},
+		{"name: PDNS\nurl: https://pdns.example.com/{domain}\nauth:\n  prefix: Bearer", false},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseDeclaration([]byte(test.data)); (err == nil) != test.valid {
+			t.Errorf("Declaration %q: expected valid to be %t, got error: %v", test.data, test.valid, err)
+		}
+	}
+}
+
+func TestDeclarativeScript(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("domain") != "owasp.org" || r.Header.Get("X-Custom") != "]]" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"result": {"items": [{"host": "www.owasp.org"}, {"host": "mail.owasp.org"}]}}`)
+	}))
+	defer ts.Close()
+
+	d, err := ParseDeclaration([]byte(fmt.Sprintf(`
+name: PDNS
+url: %s/api?domain={domain}
+header:
+  X-Custom: "]]"
+path: result.items
+field: host
+`, ts.URL)))
+	if err != nil {
+		t.Fatalf("Failed to parse the declaration: %v", err)
+	}
+
+	script, err := d.Script()
+	if err != nil {
+		t.Fatalf("Failed to generate the script: %v", err)
+	}
+
+	src, sys := setupMockScriptEnv(script)
+	if src == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	if src.String() != "PDNS" {
+		t.Errorf("Expected the data source to be named PDNS, got %s", src.String())
+	}
+
+	expected := stringset.New("www.owasp.org", "mail.owasp.org")
+	defer expected.Close()
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	src.Input() <- &requests.DNSRequest{Domain: domain}
+
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+
+	for expected.Len() > 0 {
+		select {
+		case <-timer.C:
+			t.Fatalf("The names %v were not provided", expected.Slice())
+		case req := <-src.Output():
+			if d, ok := req.(*requests.DNSRequest); !ok || !expected.Has(d.Name) {
+				t.Errorf("Unexpected output: %v", req)
+			} else {
+				expected.Remove(d.Name)
+			}
+		}
+	}
+}
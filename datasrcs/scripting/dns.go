@@ -17,6 +17,7 @@ import (
 	amassnet "github.com/owasp-amass/amass/v4/net"
 	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/sharedcache"
 	"github.com/owasp-amass/resolve"
 	bf "github.com/tylertreat/BoomFilters"
 	lua "github.com/yuin/gopher-lua"
@@ -92,6 +93,13 @@ func (s *Script) resolve(L *lua.LState) int {
 }
 
 func (s *Script) fwdQuery(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	// The answers verified by the trusted resolvers are shared with the other engine instances
+	if sharedcache.Default != nil {
+		if resp := sharedcache.Default.LookupDNS(name, qtype); resp != nil {
+			return resp, nil
+		}
+	}
+
 	msg := resolve.QueryMsg(name, qtype)
 	resp, err := s.dnsQuery(ctx, msg, s.sys.Resolvers(), 5)
 	if err != nil {
@@ -105,6 +113,9 @@ func (s *Script) fwdQuery(ctx context.Context, name string, qtype uint16) (*dns.
 	if resp == nil && err == nil {
 		err = errors.New("query failed")
 	}
+	if err == nil && sharedcache.Default != nil {
+		sharedcache.Default.StoreDNS(resp)
+	}
 	return resp, err
 }
 
@@ -206,11 +217,19 @@ func (s *Script) reverseSweep(L *lua.LState) int {
 		default:
 		}
 
+		// The budget of the data source limits the queries it has in flight
+		if !s.budget.acquire(ctx) {
+			L.Push(lua.LString("the context expired"))
+			return 1
+		}
+
 		sweepLock.Lock()
 		if a := ip.String(); !sweepFilter.TestAndAdd([]byte(a)) {
 			count++
 			<-sweepMaxCh
 			go s.getPTR(ctx, a, sweepMaxCh)
+		} else {
+			s.budget.release()
 		}
 		sweepLock.Unlock()
 	}
@@ -220,7 +239,10 @@ func (s *Script) reverseSweep(L *lua.LState) int {
 }
 
 func (s *Script) getPTR(ctx context.Context, addr string, ch chan struct{}) {
-	defer func() { ch <- struct{}{} }()
+	defer func() {
+		ch <- struct{}{}
+		s.budget.release()
+	}()
 
 	if reserved, _ := amassnet.IsReservedAddress(addr); reserved {
 		return
@@ -0,0 +1,131 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/sharedcache"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	defaultNameFilterSize = 1000000
+	nameFilterFileName    = "name_filter.txt"
+)
+
+var (
+	nameFiltersLock sync.Mutex
+	nameFilters     = make(map[*config.Config]*nameFilter)
+)
+
+// nameFilter removes the names already sent by the scripts during the session, so the same
+// names are not repeatedly released by the data sources. Once the filter holds the maximum
+// number of names, new names are no longer filtered, instead of being silently dropped.
+type nameFilter struct {
+	sync.Mutex
+	size  int
+	path  string
+	names map[string]struct{}
+	file  *os.File
+	w     *bufio.Writer
+}
+
+// NameFilterSize returns the maximum number of names held by the filter shared by the scripts.
+func NameFilterSize(cfg *config.Config) int {
+	if size, ok := cfg.Options["name_filter_size"].(int); ok && size > 0 {
+		return size
+	}
+	return defaultNameFilterSize
+}
+
+// sharedNameFilter returns the filter used by all the scripts with the same configuration.
+func sharedNameFilter(cfg *config.Config) *nameFilter {
+	nameFiltersLock.Lock()
+	defer nameFiltersLock.Unlock()
+
+	if f, found := nameFilters[cfg]; found {
+		return f
+	}
+
+	f := &nameFilter{size: NameFilterSize(cfg)}
+	// The filter can be kept in the output directory, so later sessions continue where it left off
+	if persist, ok := cfg.Options["name_filter_file"].(bool); ok && persist {
+		f.path = filepath.Join(config.OutputDirectory(cfg.Dir), nameFilterFileName)
+	}
+	nameFilters[cfg] = f
+	return f
+}
+
+// init allocates the filter when the first name is checked, since many scripts never send names.
+func (f *nameFilter) init() {
+	if f.names != nil {
+		return
+	}
+
+	f.names = make(map[string]struct{})
+	if f.path == "" {
+		return
+	}
+
+	if file, err := os.Open(f.path); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() && len(f.names) < f.size {
+			if name := scanner.Text(); name != "" {
+				f.names[name] = struct{}{}
+			}
+		}
+		_ = file.Close()
+	}
+
+	if file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644); err == nil {
+		f.file = file
+		f.w = bufio.NewWriter(file)
+	}
+}
+
+// duplicate returns true when the name has already been seen during the session, or by the
+// other engine instances of the session when the shared cache is configured.
+func (f *nameFilter) duplicate(name string) bool {
+	if sharedcache.Default != nil {
+		if added, err := sharedcache.Default.AddName(name); err == nil {
+			return !added
+		}
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	f.init()
+	if _, found := f.names[name]; found {
+		return true
+	}
+	if len(f.names) >= f.size {
+		return false
+	}
+
+	f.names[name] = struct{}{}
+	if f.w != nil {
+		_, _ = f.w.WriteString(name + "\n")
+	}
+	return false
+}
+
+// save writes the names added to a disk-backed filter to the output directory.
+func (f *nameFilter) save() error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.w == nil {
+		return nil
+	}
+	if err := f.w.Flush(); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
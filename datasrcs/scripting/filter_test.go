@@ -0,0 +1,47 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestNameFilter(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.Options["name_filter_size"] = 10000
+	cfg.Options["name_filter_file"] = true
+
+	f := sharedNameFilter(cfg)
+	if sharedNameFilter(cfg) != f {
+		t.Fatal("Expected the scripts with the same configuration to share the filter")
+	}
+	// The names beyond the size of the previous filter must not be truncated
+	for i := 0; i < 10000; i++ {
+		if f.duplicate(fmt.Sprintf("host%d.owasp.org", i)) {
+			t.Fatalf("The name host%d.owasp.org was incorrectly identified as a duplicate", i)
+		}
+	}
+	if !f.duplicate("host1.owasp.org") {
+		t.Error("Failed to identify the duplicate name")
+	}
+	// Names are no longer filtered once the filter is full
+	if f.duplicate("full.owasp.org") || f.duplicate("full.owasp.org") {
+		t.Error("The full filter dropped a name")
+	}
+	if err := f.save(); err != nil {
+		t.Fatalf("Failed to save the name filter: %v", err)
+	}
+	// A new session using the same output directory continues with the saved filter
+	cfg2 := config.NewConfig()
+	cfg2.Dir = cfg.Dir
+	cfg2.Options = cfg.Options
+	if !sharedNameFilter(cfg2).duplicate("host1.owasp.org") {
+		t.Error("The saved name filter was not loaded by the new session")
+	}
+}
@@ -6,13 +6,23 @@ package scripting
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/url"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/owasp-amass/amass/v4/metrics"
 	"github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/sharedcache"
+	"github.com/owasp-amass/config/config"
 	lua "github.com/yuin/gopher-lua"
+	luajson "layeh.com/gopher-json"
 )
 
 // Wrapper that allows scripts to make HTTP client requests.
@@ -55,12 +65,15 @@ func (s *Script) request(L *lua.LState) int {
 		}
 	}
 
+	// Conditional requests only transfer the content when it has changed since the last request
+	c, _ := L.GetField(opt, "conditional").(lua.LBool)
+
 	id, _ := getStringField(L, opt, "id")
 	pass, _ := getStringField(L, opt, "pass")
 	resp, err := s.req(ctx, url, body, hdr, &http.BasicAuth{
 		Username: id,
 		Password: pass,
-	})
+	}, bool(c))
 
 	if err != nil || resp == nil {
 		L.Push(lua.LNil)
@@ -93,6 +106,8 @@ func responseToTable(L *lua.LState, resp *http.Response) *lua.LTable {
 
 	r.RawSetString("body", lua.LString(resp.Body))
 	r.RawSetString("length", lua.LNumber(resp.Length))
+	r.RawSetString("not_modified", lua.LBool(resp.NotModified))
+	r.RawSetString("cached", lua.LBool(resp.Cached))
 
 	if resp.TLS != nil {
 		tls := L.NewTable()
@@ -130,6 +145,202 @@ func responseToTable(L *lua.LState, resp *http.Response) *lua.LTable {
 	return r
 }
 
+// Wrapper that allows scripts to process the elements of large JSON arrays as they are received.
+func (s *Script) jsonStream(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No user data parameter or context expired"))
+		return 2
+	}
+
+	opt := L.CheckTable(2)
+	if opt == nil {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No table parameter was provided"))
+		return 2
+	}
+
+	fn := L.CheckFunction(3)
+	if fn == nil {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No callback function was provided"))
+		return 2
+	}
+
+	url, found := getStringField(L, opt, "url")
+	if !found {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No URL found in the parameters"))
+		return 2
+	}
+
+	var hdr http.Header
+	if lv := L.GetField(opt, "header"); lv != nil {
+		if tbl, ok := lv.(*lua.LTable); ok {
+			hdr = make(http.Header)
+			tbl.ForEach(func(k, v lua.LValue) {
+				hdr[k.String()] = v.String()
+			})
+		}
+	}
+
+	method := "GET"
+	var body string
+	if m, ok := getStringField(L, opt, "method"); ok && strings.ToLower(m) == "post" {
+		method = "POST"
+		if d, ok := getStringField(L, opt, "body"); ok {
+			body = d
+		}
+	}
+
+	id, _ := getStringField(L, opt, "id")
+	pass, _ := getStringField(L, opt, "pass")
+	path, _ := getStringField(L, opt, "path")
+
+	key, err := s.quota.Acquire(ctx)
+	if err == nil {
+		err = s.waitRateLimit(ctx, key)
+	}
+	if err != nil {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	if key != nil {
+		hdr = s.auth.attach(url, hdr, key.Creds)
+	}
+
+	timeout, retries := streamSettings(L, opt)
+
+	var count int
+	// Requests failing before any element was processed, such as those timing out, are sent again
+	for attempt := 0; ; attempt++ {
+		err = s.streamJSON(ctx, L, fn, timeout, &http.Request{
+			URL:    url,
+			Method: method,
+			Header: hdr,
+			Body:   body,
+			Auth: &http.BasicAuth{
+				Username: id,
+				Password: pass,
+			},
+		}, key, path, &count)
+		if err == nil || count > 0 || attempt >= retries || !retryWait(ctx, attempt) {
+			break
+		}
+	}
+	metrics.DataSourceRequests.Inc(s.String())
+
+	L.Push(lua.LNumber(count))
+	if err != nil {
+		metrics.DataSourceErrors.Inc(s.String())
+		L.Push(lua.LString(err.Error()))
+	} else {
+		L.Push(lua.LNil)
+	}
+	return 2
+}
+
+// streamJSON requests the URL and provides each element of the JSON array at the path to the callback.
+func (s *Script) streamJSON(ctx context.Context, L *lua.LState, fn *lua.LFunction, timeout time.Duration, req *http.Request, key *http.APIKey, path string, count *int) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return http.StreamWebPage(ctx, req, func(resp *http.Response, r io.Reader) error {
+		s.updateQuota(key, resp)
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("the request returned with status: %s", resp.Status)
+		}
+
+		return http.StreamJSON(r, path, func(element json.RawMessage) error {
+			value, err := luajson.Decode(L, element)
+			if err != nil {
+				return err
+			}
+			return callStream(L, fn, value, count)
+		})
+	})
+}
+
+// callStream provides the value to the callback of the script. The callback can return false to stop the stream.
+func callStream(L *lua.LState, fn *lua.LFunction, value lua.LValue, count *int) error {
+	if err := L.CallByParam(lua.P{
+		Fn:      fn,
+		NRet:    1,
+		Protect: true,
+	}, value); err != nil {
+		return err
+	}
+
+	*count++
+	ret := L.Get(-1)
+	L.Pop(1)
+	if ret == lua.LFalse {
+		return http.ErrStopStream
+	}
+	return nil
+}
+
+// streamSettings returns the timeout of each attempt and the number of retries provided to the
+// streaming functions, which default to 20 seconds and no retries.
+func streamSettings(L *lua.LState, opt *lua.LTable) (time.Duration, int) {
+	timeout := 20 * time.Second
+	if secs, ok := getNumberField(L, opt, "timeout"); ok && secs > 0 {
+		timeout = time.Duration(secs * float64(time.Second))
+	}
+
+	var retries int
+	if n, ok := getNumberField(L, opt, "retries"); ok && n > 0 {
+		retries = int(n)
+	}
+	return timeout, retries
+}
+
+// retryWait waits before the next attempt, doubling the wait after each attempt up to the maximum
+// backoff. False is returned when the context expires first.
+func retryWait(ctx context.Context, attempt int) bool {
+	wait := http.MinBackoff << uint(attempt)
+	if wait <= 0 || wait > http.MaxBackoff {
+		wait = http.MaxBackoff
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+var (
+	respCacheOnce sync.Once
+	respCache     *http.ResponseCache
+)
+
+// responseCache returns the cache shared by the requests of all the scripts.
+func (s *Script) responseCache() *http.ResponseCache {
+	respCacheOnce.Do(func() {
+		// The responses are shared with the other engine instances when the shared cache is configured
+		if sharedcache.Default != nil {
+			respCache = http.NewSharedResponseCache(sharedcache.Default)
+			return
+		}
+
+		dir := filepath.Join(config.OutputDirectory(s.sys.Config().Dir), "http_cache")
+
+		if c, err := http.NewResponseCache(dir); err == nil {
+			respCache = c
+		} else {
+			s.sys.Config().Log.Printf("Failed to create the HTTP response cache: %v", err)
+		}
+	})
+	return respCache
+}
+
 // Wrapper so that scripts can scrape the contents of a GET request for subdomain names in scope.
 func (s *Script) scrape(L *lua.LState) int {
 	ctx, err := extractContext(L.CheckUserData(1))
@@ -174,7 +385,7 @@ func (s *Script) scrape(L *lua.LState) int {
 	if resp, err := s.req(ctx, url, body, hdr, &http.BasicAuth{
 		Username: id,
 		Password: pass,
-	}); err == nil {
+	}, false); err == nil {
 		if resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 400 {
 			if num := s.internalSendNames(ctx, resp.Body); num > 0 {
 				sucess = lua.LTrue
@@ -188,33 +399,90 @@ func (s *Script) scrape(L *lua.LState) int {
 	return 1
 }
 
-func (s *Script) req(ctx context.Context, url, data string, hdr http.Header, auth *http.BasicAuth) (*http.Response, error) {
+// cacheTTL returns how long the responses of the data source are reused, based on the TTL in its configuration.
+func (s *Script) cacheTTL() time.Duration {
+	cfg := s.sys.Config()
+
+	if enabled, ok := cfg.Options["http_cache"].(bool); ok && !enabled {
+		return 0
+	}
+	if dsc := cfg.GetDataSourceConfig(s.String()); dsc != nil && dsc.TTL > 0 {
+		return time.Duration(dsc.TTL) * time.Minute
+	}
+	return 0
+}
+
+func (s *Script) req(ctx context.Context, url, data string, hdr http.Header, auth *http.BasicAuth, conditional bool) (*http.Response, error) {
 	method := "GET"
 	if data != "" {
 		method = "POST"
 	}
 
-	numRateLimitChecks(s, s.seconds)
-	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
-	defer cancel()
-
-	resp, err := http.RequestWebPage(ctx, &http.Request{
+	r := &http.Request{
 		URL:    url,
 		Method: method,
 		Header: hdr,
 		Body:   data,
 		Auth:   auth,
-	})
+		TTL:    s.cacheTTL(),
+	}
+	if conditional || r.TTL > 0 {
+		r.Cache = s.responseCache()
+	}
+	// Responses stored within the TTL of the data source do not consume the rate limit or API quota
+	if r.Cache != nil {
+		if resp := r.Cache.Fresh(r); resp != nil {
+			metrics.DataSourceCacheHits.Inc(s.String())
+			return resp, nil
+		}
+	}
+
+	if !s.budget.acquire(ctx) {
+		return nil, errors.New("the context expired")
+	}
+	defer s.budget.release()
+
+	key, err := s.quota.Acquire(ctx)
 	if err != nil {
+		return nil, err
+	}
+	if err := s.waitRateLimit(ctx, key); err != nil {
+		return nil, err
+	}
+	if key != nil {
+		r.Header = s.auth.attach(url, r.Header, key.Creds)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	resp, err := http.RequestWebPage(ctx, r)
+	metrics.DataSourceRequests.Inc(s.String())
+	if resp != nil && resp.NotModified {
+		metrics.DataSourceCacheHits.Inc(s.String())
+	}
+	if err != nil {
+		metrics.DataSourceErrors.Inc(s.String())
 		cfg := s.sys.Config()
 
 		if cfg.Verbose {
 			cfg.Log.Printf("%s: %s: %v", s.String(), url, err)
 		}
 	}
+	s.updateQuota(key, resp)
 	return resp, err
 }
 
+// updateQuota provides the response to the quota manager, so the API keys are rotated and the requests backoff as needed.
+func (s *Script) updateQuota(key *http.APIKey, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	if msg := s.quota.Update(key, resp.StatusCode, resp.Header); msg != "" {
+		s.sys.Config().Log.Printf("%s: %s", s.String(), msg)
+	}
+}
+
 // Wrapper so that scripts can crawl for subdomain names in scope.
 func (s *Script) crawl(L *lua.LState) int {
 	cfg := s.sys.Config()
@@ -232,7 +500,7 @@ func (s *Script) crawl(L *lua.LState) int {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
-	err = http.Crawl(ctx, u, cfg.Domains(), max, func(req *http.Request, resp *http.Response) {
+	err = http.CrawlConcurrent(ctx, u, cfg.Domains(), max, s.budget.concurrency(http.DefaultCrawlRequests), func(req *http.Request, resp *http.Response) {
 		if u, err := url.Parse(req.URL); err == nil {
 			s.newNameWithContext(ctx, http.CleanName(u.Hostname()))
 		}
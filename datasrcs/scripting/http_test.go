@@ -0,0 +1,127 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestJSONStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"total": 4, "data": [{"name": "www.owasp.org"}, {"name": "mail.owasp.org"}, {"name": "stop"}, {"name": "dev.owasp.org"}]}`)
+	}))
+	defer ts.Close()
+
+	expected := stringset.New("www.owasp.org", "mail.owasp.org")
+	defer expected.Close()
+
+	script, sys := setupMockScriptEnv(fmt.Sprintf(`
+		name="json_stream"
+		type="testing"
+
+		function vertical(ctx, domain)
+			local num, err = json_stream(ctx, {
+				['url']="%s",
+				['path']="data",
+			}, function(record)
+				if (record.name == "stop") then
+					return false
+				end
+				new_name(ctx, record.name)
+			end)
+			if (err ~= nil and err ~= "") then
+				log(ctx, "vertical request to service failed: " .. err)
+			end
+		end
+	`, ts.URL))
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.DataSources()[0].Input() <- &requests.DNSRequest{Domain: domain}
+
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+
+	for expected.Len() > 0 {
+		select {
+		case <-timer.C:
+			t.Fatalf("The names %v were not streamed", expected.Slice())
+		case req := <-sys.DataSources()[0].Output():
+			if d, ok := req.(*requests.DNSRequest); !ok || !expected.Has(d.Name) {
+				t.Errorf("Unexpected output: %v", req)
+			} else {
+				expected.Remove(d.Name)
+			}
+		}
+	}
+}
+
+func TestJSONStreamRetries(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first request fails like the timeouts of the busy services
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"name": "www.owasp.org"}]`)
+	}))
+	defer ts.Close()
+
+	script, sys := setupMockScriptEnv(fmt.Sprintf(`
+		name="json_stream"
+		type="testing"
+
+		function vertical(ctx, domain)
+			local num, err = json_stream(ctx, {
+				['url']="%s",
+				['timeout']=5,
+				['retries']=1,
+			}, function(record)
+				new_name(ctx, record.name)
+			end)
+			if (err ~= nil and err ~= "") then
+				log(ctx, "vertical request to service failed: " .. err)
+			end
+		end
+	`, ts.URL))
+	if script == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	sys.DataSources()[0].Input() <- &requests.DNSRequest{Domain: domain}
+
+	timer := time.NewTimer(10 * time.Second)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		t.Fatal("The name was not streamed after retrying the request")
+	case req := <-sys.DataSources()[0].Output():
+		if d, ok := req.(*requests.DNSRequest); !ok || d.Name != "www.owasp.org" {
+			t.Errorf("Unexpected output: %v", req)
+		}
+	}
+	if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Errorf("Expected two requests, got %d", n)
+	}
+}
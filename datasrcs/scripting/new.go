@@ -8,21 +8,36 @@ import (
 	"context"
 	"net"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/miekg/dns"
 	amassnet "github.com/owasp-amass/amass/v4/net"
 	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/progress"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/resolve"
-	bf "github.com/tylertreat/BoomFilters"
 	lua "github.com/yuin/gopher-lua"
 	"golang.org/x/net/publicsuffix"
 )
 
+// NamesObserved returns the number of names the script has found, including those out of scope.
+func (s *Script) NamesObserved() int {
+	return int(atomic.LoadInt64(&s.observed))
+}
+
 func (s *Script) newNameWithContext(ctx context.Context, name string) {
+	atomic.AddInt64(&s.observed, 1)
+	if s.SourceType == bruteSourceType {
+		progress.Default.Tracker(progress.BruteForce).Done(1)
+	}
 	if domain := s.sys.Config().WhichDomain(name); domain != "" {
+		// The names beyond the cap of the data source are counted as truncated instead of silently dropped
+		if !s.caps.allow(domain, strings.ToLower(name)) {
+			return
+		}
+
 		select {
 		case <-ctx.Done():
 		case <-s.Done():
@@ -61,12 +76,9 @@ func (s *Script) sendNames(L *lua.LState) int {
 }
 
 func (s *Script) internalSendNames(ctx context.Context, content string) int {
-	filter := bf.NewDefaultStableBloomFilter(1000, 0.01)
-	defer filter.Reset()
-
 	var count int
 	for _, name := range s.subre.FindAllString(string(content), -1) {
-		if n := http.CleanName(name); n != "" && !filter.TestAndAdd([]byte(n)) {
+		if n := http.CleanName(name); n != "" && !s.names.duplicate(n) {
 			s.newNameWithContext(ctx, n)
 			count++
 		}
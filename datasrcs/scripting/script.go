@@ -6,14 +6,20 @@ package scripting
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
 	"sync"
+	"time"
 
 	"github.com/caffix/service"
 	luaurl "github.com/cjoudrey/gluaurl"
+	"github.com/owasp-amass/amass/v4/metrics"
+	amassnet "github.com/owasp-amass/amass/v4/net"
 	"github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/net/http"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
@@ -21,6 +27,15 @@ import (
 	luajson "layeh.com/gopher-json"
 )
 
+// bruteSourceType is the type of the script brute forcing the names using the wordlist.
+const bruteSourceType = "brute"
+
+// Provides the wordlist ordering with a sequence of random numbers different from other components.
+const wordlistSeedSalt = 4
+
+// Provides the math.random function of the scripts with a sequence of random numbers different from other components.
+const scriptSeedSalt = 5
+
 // Script callback functions
 type callbacks struct {
 	Start      lua.LValue
@@ -36,6 +51,8 @@ type callbacks struct {
 
 // Script is the Service that handles access to the Script data source.
 type Script struct {
+	// Accessed atomically, so it must remain 64-bit aligned
+	observed int64
 	service.BaseService
 	start      chan struct{}
 	startRet   chan error
@@ -46,7 +63,15 @@ type Script struct {
 	cbs        *callbacks
 	cbsLock    sync.Mutex
 	subre      *regexp.Regexp
+	names      *nameFilter
+	certs      *certFilter
+	quota      *http.QuotaManager
+	budget     *budgetLimiter
+	caps       *nameCap
 	seconds    int
+	proxy      *url.URL
+	tls        *tls.Config
+	auth       *authConfig
 	ctx        context.Context
 	cancel     context.CancelFunc
 }
@@ -64,6 +89,8 @@ func NewScript(script string, sys systems.System) *Script {
 		stop:     make(chan struct{}, 1),
 		sys:      sys,
 		subre:    re,
+		names:    sharedNameFilter(sys.Config()),
+		certs:    newCertFilter(),
 	}
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 	L := s.newLuaState(sys.Config())
@@ -85,13 +112,66 @@ func NewScript(script string, sys systems.System) *Script {
 		sys.Config().Log.Printf("Script: Failed to obtain the %s script type: %v", script, err)
 		return nil
 	}
+	// Pull the header attaching the API key to the requests from the script
+	s.auth, err = s.scriptAuth()
+	if err != nil {
+		sys.Config().Log.Printf("Script: Failed to obtain the %s script auth settings: %v", name, err)
+		return nil
+	}
 
+	s.quota = http.NewQuotaManager(quotaKeys(name, sys.Config().DataSrcConfigs)...)
+	// The proxy option was validated when the system was setup
+	if proxy, err := amassnet.ParseProxySettings(sys.Config().Options["proxy"]); err == nil {
+		s.proxy = proxy.ForSource(name)
+	}
+	// The TLS option was validated when the system was setup
+	if opts, err := amassnet.ParseTLSOptions(sys.Config().Options["tls"]); err == nil {
+		s.tls, _ = opts.ForSource(name).Config()
+	}
+	var budget *Budget
+	if budgets, err := ParseBudgetSettings(sys.Config().Options["source_budgets"]); err == nil {
+		budget = budgets.ForSource(name)
+	}
+	s.budget = newBudgetLimiter(budget)
+	var limit int
+	if caps, err := ParseCapSettings(sys.Config().Options["source_caps"]); err == nil {
+		limit = caps.ForSource(name)
+	}
+	s.caps = newNameCap(limit)
 	s.BaseService = *service.NewBaseService(s, name)
 	s.assignCallbacks()
 	go s.requests()
 	return s
 }
 
+// quotaKeys returns the credentials of the named data source, named by their labels when the names
+// are not provided, so the QuotaManager rotates through the API keys of the configuration.
+func quotaKeys(name string, dsc *config.DataSourceConfig) []*http.Credentials {
+	if dsc == nil {
+		return nil
+	}
+
+	var keys []*http.Credentials
+	for _, src := range dsc.Datasources {
+		if src.Name != name {
+			continue
+		}
+
+		for label, creds := range src.Creds {
+			if creds == nil {
+				continue
+			}
+
+			c := http.Credentials(*creds)
+			if c.Name == "" {
+				c.Name = label
+			}
+			keys = append(keys, &c)
+		}
+	}
+	return keys
+}
+
 // Setup the Lua state with desired constraints and access to necessary functionality.
 func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 	L := lua.NewState(lua.Options{
@@ -116,12 +196,15 @@ func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 	L.SetGlobal("mtime", L.NewFunction(s.modDateTime))
 	L.SetGlobal("new_name", L.NewFunction(s.newName))
 	L.SetGlobal("send_names", L.NewFunction(s.sendNames))
+	L.SetGlobal("new_cert", L.NewFunction(s.newCert))
 	L.SetGlobal("send_dns_records", L.NewFunction(s.sendDNSRecords))
 	L.SetGlobal("new_addr", L.NewFunction(s.newAddr))
 	L.SetGlobal("new_asn", L.NewFunction(s.newASN))
 	L.SetGlobal("associated", L.NewFunction(s.associated))
 	L.SetGlobal("in_scope", L.NewFunction(s.inScope))
 	L.SetGlobal("request", L.NewFunction(s.request))
+	L.SetGlobal("json_stream", L.NewFunction(s.jsonStream))
+	L.SetGlobal("sql_stream", L.NewFunction(s.sqlStream))
 	L.SetGlobal("scrape", L.NewFunction(s.scrape))
 	L.SetGlobal("crawl", L.NewFunction(s.crawl))
 	L.SetGlobal("resolve", L.NewFunction(s.resolve))
@@ -132,6 +215,12 @@ func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 	L.SetGlobal("set_rate_limit", L.NewFunction(s.setRateLimit))
 	L.SetGlobal("check_rate_limit", L.NewFunction(s.checkRateLimit))
 	L.SetGlobal("subdomain_regex", lua.LString(dns.AnySubdomainRegexString()))
+	// The random numbers of the scripts are derived from the seed of the run, instead of the global source
+	if mathlib, ok := L.GetGlobal("math").(*lua.LTable); ok {
+		rnd := &luaRand{rnd: systems.NewRand(cfg, scriptSeedSalt)}
+		L.SetField(mathlib, "random", L.NewFunction(rnd.random))
+		L.SetField(mathlib, "randomseed", L.NewFunction(rnd.randomseed))
+	}
 	return L
 }
 
@@ -319,6 +408,13 @@ func (s *Script) stopScript() {
 
 	s.luaState.Close()
 	s.luaState = nil
+
+	if err := s.names.save(); err != nil {
+		s.sys.Config().Log.Printf("%s: failed to save the name filter: %v", s.String(), err)
+	}
+	for _, line := range s.quota.Usage() {
+		s.sys.Config().Log.Printf("%s: %s", s.String(), line)
+	}
 }
 
 func (s *Script) dispatch(in interface{}) {
@@ -329,6 +425,9 @@ func (s *Script) dispatch(in interface{}) {
 		if s.cbs.Vertical.Type() != lua.LTNil && req != nil && req.Domain != "" {
 			callback := s.cbs.Vertical
 			s.cbsLock.Unlock()
+			if !s.budget.event(s.ctx, EventVertical) {
+				return
+			}
 			s.CheckRateLimit()
 			s.dnsRequest(s.ctx, callback, req)
 		}
@@ -336,6 +435,9 @@ func (s *Script) dispatch(in interface{}) {
 		if s.cbs.Resolved.Type() != lua.LTNil && req != nil && req.Name != "" && len(req.Records) > 0 {
 			callback := s.cbs.Resolved
 			s.cbsLock.Unlock()
+			if !s.budget.event(s.ctx, EventResolved) {
+				return
+			}
 			s.CheckRateLimit()
 			s.resolvedRequest(s.ctx, callback, req)
 		}
@@ -343,6 +445,9 @@ func (s *Script) dispatch(in interface{}) {
 		if s.cbs.Subdomain.Type() != lua.LTNil && req != nil && req.Name != "" {
 			callback := s.cbs.Subdomain
 			s.cbsLock.Unlock()
+			if !s.budget.event(s.ctx, EventSubdomain) {
+				return
+			}
 			s.CheckRateLimit()
 			s.subdomainRequest(s.ctx, callback, req)
 		}
@@ -350,6 +455,9 @@ func (s *Script) dispatch(in interface{}) {
 		if s.cbs.Address.Type() != lua.LTNil && req != nil && req.Address != "" {
 			callback := s.cbs.Address
 			s.cbsLock.Unlock()
+			if !s.budget.event(s.ctx, EventAddress) {
+				return
+			}
 			s.CheckRateLimit()
 			s.addrRequest(s.ctx, callback, req)
 		}
@@ -359,6 +467,9 @@ func (s *Script) dispatch(in interface{}) {
 			s.cbsLock.Unlock()
 			// check that the cache entry has not already been made by a previous request
 			if s.sys.Cache().AddrSearch(req.Address) == nil {
+				if !s.budget.event(s.ctx, EventASN) {
+					return
+				}
 				s.CheckRateLimit()
 				s.asnRequest(s.ctx, callback, req)
 			}
@@ -367,6 +478,9 @@ func (s *Script) dispatch(in interface{}) {
 		if s.cbs.Horizontal.Type() != lua.LTNil {
 			callback := s.cbs.Horizontal
 			s.cbsLock.Unlock()
+			if !s.budget.event(s.ctx, EventHorizontal) {
+				return
+			}
 			s.CheckRateLimit()
 			s.whoisRequest(s.ctx, callback, req)
 		}
@@ -375,16 +489,27 @@ func (s *Script) dispatch(in interface{}) {
 	}
 }
 
-func (s *Script) dnsRequest(ctx context.Context, callback lua.LValue, req *requests.DNSRequest) {
-	L := s.luaState
+// callback executes the script callback and records the metrics about the execution.
+func (s *Script) callback(name string, p lua.P, args ...lua.LValue) error {
+	start := time.Now()
+	err := s.luaState.CallByParam(p, args...)
 
+	metrics.Callbacks.Inc(s.String(), name)
+	metrics.CallbackSeconds.Add(time.Since(start).Seconds(), s.String(), name)
+	if err != nil {
+		metrics.CallbackErrors.Inc(s.String(), name)
+	}
+	return err
+}
+
+func (s *Script) dnsRequest(ctx context.Context, callback lua.LValue, req *requests.DNSRequest) {
 	if contextExpired(ctx) {
 		return
 	}
 
 	s.sys.Config().Log.Printf("Querying %s for %s subdomains", s.String(), req.Domain)
 
-	err := L.CallByParam(lua.P{
+	err := s.callback("vertical", lua.P{
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
@@ -411,7 +536,7 @@ func (s *Script) resolvedRequest(ctx context.Context, callback lua.LValue, req *
 		records.Append(tb)
 	}
 
-	err := L.CallByParam(lua.P{
+	err := s.callback("resolved", lua.P{
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
@@ -422,13 +547,11 @@ func (s *Script) resolvedRequest(ctx context.Context, callback lua.LValue, req *
 }
 
 func (s *Script) subdomainRequest(ctx context.Context, callback lua.LValue, req *requests.SubdomainRequest) {
-	L := s.luaState
-
 	if contextExpired(ctx) {
 		return
 	}
 
-	err := L.CallByParam(lua.P{
+	err := s.callback("subdomain", lua.P{
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
@@ -439,13 +562,11 @@ func (s *Script) subdomainRequest(ctx context.Context, callback lua.LValue, req
 }
 
 func (s *Script) addrRequest(ctx context.Context, callback lua.LValue, req *requests.AddrRequest) {
-	L := s.luaState
-
 	if contextExpired(ctx) {
 		return
 	}
 
-	err := L.CallByParam(lua.P{
+	err := s.callback("address", lua.P{
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
@@ -456,13 +577,11 @@ func (s *Script) addrRequest(ctx context.Context, callback lua.LValue, req *requ
 }
 
 func (s *Script) asnRequest(ctx context.Context, callback lua.LValue, req *requests.ASNRequest) {
-	L := s.luaState
-
 	if contextExpired(ctx) {
 		return
 	}
 
-	err := L.CallByParam(lua.P{
+	err := s.callback("asn", lua.P{
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
@@ -473,13 +592,11 @@ func (s *Script) asnRequest(ctx context.Context, callback lua.LValue, req *reque
 }
 
 func (s *Script) whoisRequest(ctx context.Context, callback lua.LValue, req *requests.WhoisRequest) {
-	L := s.luaState
-
 	if contextExpired(ctx) {
 		return
 	}
 
-	err := L.CallByParam(lua.P{
+	err := s.callback("horizontal", lua.P{
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
@@ -0,0 +1,171 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	// The pgx driver is registered as "pgx" with the database/sql package
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/owasp-amass/amass/v4/metrics"
+	"github.com/owasp-amass/amass/v4/net/http"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Wrapper that allows scripts to query the public PostgreSQL databases of the data sources, such as
+// the database of crt.sh, and process the rows as they are received. The rows are provided to the
+// callback as tables keyed by the column names.
+func (s *Script) sqlStream(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No user data parameter or context expired"))
+		return 2
+	}
+
+	opt := L.CheckTable(2)
+	if opt == nil {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No table parameter was provided"))
+		return 2
+	}
+
+	fn := L.CheckFunction(3)
+	if fn == nil {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No callback function was provided"))
+		return 2
+	}
+
+	dsn, found := getStringField(L, opt, "url")
+	if !found {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No URL found in the parameters"))
+		return 2
+	}
+	if u, err := url.Parse(dsn); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("Only postgres URLs are supported"))
+		return 2
+	}
+
+	query, found := getStringField(L, opt, "query")
+	if !found {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString("No query found in the parameters"))
+		return 2
+	}
+
+	var args []interface{}
+	if lv := L.GetField(opt, "args"); lv != nil {
+		if tbl, ok := lv.(*lua.LTable); ok {
+			tbl.ForEach(func(_, v lua.LValue) {
+				args = append(args, v.String())
+			})
+		}
+	}
+
+	if err := s.waitRateLimit(ctx, nil); err != nil {
+		L.Push(lua.LNumber(0))
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	timeout, retries := streamSettings(L, opt)
+
+	var count int
+	// Queries failing before any row was processed, such as those canceled by the server, are sent again
+	for attempt := 0; ; attempt++ {
+		err = streamRows(ctx, L, fn, timeout, dsn, query, args, &count)
+		if err == nil || count > 0 || attempt >= retries || !retryWait(ctx, attempt) {
+			break
+		}
+	}
+	metrics.DataSourceRequests.Inc(s.String())
+
+	L.Push(lua.LNumber(count))
+	if err != nil {
+		metrics.DataSourceErrors.Inc(s.String())
+		L.Push(lua.LString(err.Error()))
+	} else {
+		L.Push(lua.LNil)
+	}
+	return 2
+}
+
+// streamRows runs the query and provides each row to the callback of the script.
+func streamRows(ctx context.Context, L *lua.LState, fn *lua.LFunction, timeout time.Duration, dsn, query string, args []interface{}, count *int) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("the query failed: %v", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+
+		row := L.NewTable()
+		for i, col := range cols {
+			row.RawSetString(col, sqlValue(values[i]))
+		}
+		if err := callStream(L, fn, row, count); err != nil {
+			if err == http.ErrStopStream {
+				return nil
+			}
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// sqlValue converts the value of a column to the Lua value provided to the scripts.
+func sqlValue(v interface{}) lua.LValue {
+	switch val := v.(type) {
+	case nil:
+		return lua.LNil
+	case []byte:
+		return lua.LString(string(val))
+	case string:
+		return lua.LString(val)
+	case bool:
+		return lua.LBool(val)
+	case int64:
+		return lua.LNumber(val)
+	case int32:
+		return lua.LNumber(val)
+	case float64:
+		return lua.LNumber(val)
+	case time.Time:
+		return lua.LString(val.UTC().Format(time.RFC3339))
+	default:
+		return lua.LString(fmt.Sprint(val))
+	}
+}
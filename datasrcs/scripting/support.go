@@ -7,9 +7,11 @@ package scripting
 import (
 	"context"
 	"errors"
+	"math/rand"
 	"os"
 	"regexp"
 
+	amassnet "github.com/owasp-amass/amass/v4/net"
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -22,7 +24,8 @@ func (s *Script) contextToUserData(ctx context.Context) *lua.LUserData {
 	L := s.luaState
 	ud := L.NewUserData()
 
-	ud.Value = &contextWrapper{Ctx: ctx}
+	// The HTTP requests made by the script are routed through the proxy and use the TLS settings of the data source
+	ud.Value = &contextWrapper{Ctx: amassnet.WithTLS(amassnet.WithProxy(ctx, s.proxy), s.tls)}
 	L.SetMetatable(ud, L.GetTypeMetatable("context"))
 	return ud
 }
@@ -150,3 +153,36 @@ func getNumberField(L *lua.LState, t lua.LValue, key string) (float64, bool) {
 	}
 	return 0, false
 }
+
+// luaRand implements the math.random and math.randomseed functions of the scripts using
+// the provided source, rather than the global source of the math/rand package.
+type luaRand struct {
+	rnd *rand.Rand
+}
+
+func (r *luaRand) random(L *lua.LState) int {
+	switch L.GetTop() {
+	case 0:
+		L.Push(lua.LNumber(r.rnd.Float64()))
+	case 1:
+		n := L.CheckInt(1)
+		if n < 1 {
+			L.ArgError(1, "interval is empty")
+			return 0
+		}
+		L.Push(lua.LNumber(r.rnd.Intn(n) + 1))
+	default:
+		min, max := L.CheckInt(1), L.CheckInt(2)
+		if max < min {
+			L.ArgError(2, "interval is empty")
+			return 0
+		}
+		L.Push(lua.LNumber(r.rnd.Intn(max-min+1) + min))
+	}
+	return 1
+}
+
+func (r *luaRand) randomseed(L *lua.LState) int {
+	r.rnd.Seed(L.CheckInt64(1))
+	return 0
+}
@@ -0,0 +1,49 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"math/rand"
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestLuaRandom(t *testing.T) {
+	run := func(seed int64, code string) (string, error) {
+		L := lua.NewState()
+		defer L.Close()
+
+		rnd := &luaRand{rnd: rand.New(rand.NewSource(seed))}
+		L.SetField(L.GetGlobal("math"), "random", L.NewFunction(rnd.random))
+		L.SetField(L.GetGlobal("math"), "randomseed", L.NewFunction(rnd.randomseed))
+		if err := L.DoString(code); err != nil {
+			return "", err
+		}
+		return L.GetGlobal("result").String(), nil
+	}
+
+	code := `result = math.random(1, 100) .. " " .. math.random(10) .. " " .. math.random()`
+	a, err := run(42, code)
+	if err != nil {
+		t.Fatalf("The script failed: %v", err)
+	}
+	if b, _ := run(42, code); a != b {
+		t.Errorf("The same seed provided different numbers: %s and %s", a, b)
+	}
+	if b, _ := run(43, code); a == b {
+		t.Errorf("Different seeds provided the same numbers: %s", a)
+	}
+	if b, _ := run(43, "math.randomseed(42) "+code); a != b {
+		t.Errorf("The randomseed function did not reseed the source: %s and %s", a, b)
+	}
+
+	if r, err := run(42, `result = math.random(5, 5)`); err != nil || r != "5" {
+		t.Errorf("Expected 5 from the single value interval, got %s: %v", r, err)
+	}
+	if _, err := run(42, `result = math.random(0)`); err == nil {
+		t.Error("Expected an error for the empty interval")
+	}
+}
@@ -17,6 +17,10 @@ import (
 // GetAllSources returns a slice of all data source services initialized.
 func GetAllSources(sys systems.System) []service.Service {
 	var srvs []service.Service
+	// The data sources are not limited when the budgets cannot be parsed
+	if _, err := scripting.ParseBudgetSettings(sys.Config().Options["source_budgets"]); err != nil {
+		sys.Config().Log.Printf("Failed to parse the source_budgets option: %v", err)
+	}
 
 	if scripts, err := sys.Config().AcquireScripts(); err == nil {
 		for _, script := range scripts {
@@ -25,6 +29,19 @@ func GetAllSources(sys systems.System) []service.Service {
 			}
 		}
 	}
+	// Data sources can also be declared in YAML or JSON files within the scripts directory
+	if dir := sys.Config().ScriptsDirectory; dir != "" {
+		if decls, err := scripting.AcquireDeclarations(dir); err == nil {
+			for path, data := range decls {
+				s, err := scripting.NewDeclarativeScript(data, sys)
+				if err != nil {
+					sys.Config().Log.Printf("%s: %v", path, err)
+					continue
+				}
+				srvs = append(srvs, s)
+			}
+		}
+	}
 
 	sort.Slice(srvs, func(i, j int) bool {
 		return srvs[i].String() < srvs[j].String()
@@ -0,0 +1,179 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dbindex
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The kinds of the hotspots reported by the advisor.
+const (
+	HotspotMissingIndex = "missing_index"
+	HotspotSeqScans     = "sequential_scans"
+	HotspotSlowQuery    = "slow_query"
+)
+
+// SlowQueryMillis is the mean execution time in milliseconds, at or above which a query is reported as slow.
+const SlowQueryMillis = 100
+
+// MinSeqScanRows is the number of live rows, at or above which the sequential scans of a table are reported.
+const MinSeqScanRows = 10000
+
+// TableStats are the scan statistics of a table, as provided by pg_stat_user_tables.
+type TableStats struct {
+	Table       string
+	LiveRows    int64
+	SeqScans    int64
+	SeqRowsRead int64
+	IndexScans  int64
+}
+
+// QueryStats are the execution statistics of a query, as provided by pg_stat_statements.
+type QueryStats struct {
+	Query       string
+	Calls       int64
+	TotalMillis float64
+	MeanMillis  float64
+}
+
+// Hotspot is a query, table or index slowing down the graph database.
+type Hotspot struct {
+	Kind    string  `json:"kind"`
+	Subject string  `json:"subject"`
+	Detail  string  `json:"detail"`
+	Millis  float64 `json:"millis,omitempty"`
+}
+
+// Report contains the hotspots found by the advisor, with the missing indexes first and the slowest queries last.
+type Report struct {
+	Hotspots []*Hotspot `json:"hotspots"`
+	// Statements is false when the pg_stat_statements extension is not available to report the slow queries
+	Statements bool `json:"statements"`
+}
+
+// Advise reads the statistics of the database and returns the hotspots, with up to limit slow queries.
+func Advise(ctx context.Context, db *sql.DB, limit int) (*Report, error) {
+	missing, err := Missing(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := tableStats(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	// The extension is optional, so the other hotspots are still reported without it
+	queries, err := queryStats(ctx, db)
+	report := analyze(missing, tables, queries, limit)
+	report.Statements = err == nil
+	return report, nil
+}
+
+func tableStats(ctx context.Context, db *sql.DB) ([]*TableStats, error) {
+	rows, err := db.QueryContext(ctx, "SELECT relname, n_live_tup, seq_scan, seq_tup_read, COALESCE(idx_scan, 0) "+
+		"FROM pg_stat_user_tables WHERE relname IN ('assets', 'relations')")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the table statistics: %v", err)
+	}
+	defer rows.Close()
+
+	var stats []*TableStats
+	for rows.Next() {
+		s := new(TableStats)
+
+		if err := rows.Scan(&s.Table, &s.LiveRows, &s.SeqScans, &s.SeqRowsRead, &s.IndexScans); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+func queryStats(ctx context.Context, db *sql.DB) ([]*QueryStats, error) {
+	rows, err := db.QueryContext(ctx, "SELECT query, calls, total_exec_time, mean_exec_time FROM pg_stat_statements "+
+		"WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*QueryStats
+	for rows.Next() {
+		s := new(QueryStats)
+
+		if err := rows.Scan(&s.Query, &s.Calls, &s.TotalMillis, &s.MeanMillis); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// analyze returns the hotspots found in the statistics: the missing indexes, the large tables read mostly by
+// sequential scans, and the queries on the graph tables with a mean time of at least SlowQueryMillis, with
+// the queries taking the most total time first.
+func analyze(missing []*Index, tables []*TableStats, queries []*QueryStats, limit int) *Report {
+	report := new(Report)
+
+	for _, idx := range missing {
+		report.Hotspots = append(report.Hotspots, &Hotspot{
+			Kind:    HotspotMissingIndex,
+			Subject: idx.Name,
+			Detail:  fmt.Sprintf("%s needs the index on %s %s", idx.Reason, idx.Table, idx.Definition),
+		})
+	}
+
+	for _, t := range tables {
+		if t.LiveRows < MinSeqScanRows || t.SeqScans <= t.IndexScans {
+			continue
+		}
+
+		report.Hotspots = append(report.Hotspots, &Hotspot{
+			Kind:    HotspotSeqScans,
+			Subject: t.Table,
+			Detail: fmt.Sprintf("%d sequential scans read %d rows of the %d rows, versus %d index scans",
+				t.SeqScans, t.SeqRowsRead, t.LiveRows, t.IndexScans),
+		})
+	}
+
+	var slow []*QueryStats
+	for _, q := range queries {
+		if q.MeanMillis >= SlowQueryMillis && graphQuery(q.Query) {
+			slow = append(slow, q)
+		}
+	}
+	sort.SliceStable(slow, func(i, j int) bool {
+		return slow[i].TotalMillis > slow[j].TotalMillis
+	})
+	if limit > 0 && len(slow) > limit {
+		slow = slow[:limit]
+	}
+
+	for _, q := range slow {
+		report.Hotspots = append(report.Hotspots, &Hotspot{
+			Kind:    HotspotSlowQuery,
+			Subject: strings.Join(strings.Fields(q.Query), " "),
+			Detail:  fmt.Sprintf("%d calls averaging %.1f ms", q.Calls, q.MeanMillis),
+			Millis:  q.TotalMillis,
+		})
+	}
+	return report
+}
+
+// graphQuery returns true when the query reads or writes the tables of the graph database.
+func graphQuery(query string) bool {
+	q := strings.ToLower(query)
+	for _, table := range []string{"assets", "relations"} {
+		if strings.Contains(q, `"`+table+`"`) || strings.Contains(q, " "+table) {
+			return true
+		}
+	}
+	return false
+}
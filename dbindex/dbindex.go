@@ -0,0 +1,163 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dbindex creates the PostgreSQL indexes needed by the queries that the engine and the subcommands
+// send to the graph database, and reports the queries and tables that remain slow, since the migrations of
+// the asset database only index the names, the asset types and the timestamps.
+package dbindex
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	// The pgx driver is registered as "pgx" with the database/sql package
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Index is an index created on the tables of the graph database.
+type Index struct {
+	Name  string
+	Table string
+	// Definition is the list of columns and expressions following the table name
+	Definition string
+	// Reason is the query pattern served by the index
+	Reason string
+	// Invalid is true when an earlier creation of the index was interrupted
+	Invalid bool
+}
+
+// Indexes are the indexes needed by the queries of the engine and the subcommands. The content
+// expressions match the queries built for FindByContent, so the planner can use the indexes.
+var Indexes = []*Index{
+	{
+		Name:       "idx_amass_assets_content_name",
+		Table:      "assets",
+		Definition: "(json_extract_path_text(content::json, 'name'))",
+		Reason:     "FindByContent of the names and organizations",
+	},
+	{
+		Name:       "idx_amass_assets_content_address",
+		Table:      "assets",
+		Definition: "(json_extract_path_text(content::json, 'address'))",
+		Reason:     "FindByContent of the IP addresses",
+	},
+	{
+		Name:       "idx_amass_assets_content_cidr",
+		Table:      "assets",
+		Definition: "(json_extract_path_text(content::json, 'cidr'))",
+		Reason:     "FindByContent of the netblocks",
+	},
+	{
+		Name:       "idx_amass_assets_content_number",
+		Table:      "assets",
+		Definition: "(json_extract_path_text(content::json, 'number'))",
+		Reason:     "FindByContent of the autonomous systems",
+	},
+	{
+		Name:       "idx_amass_assets_type_last_seen",
+		Table:      "assets",
+		Definition: "(type, last_seen)",
+		Reason:     "FindByType with the since time filter",
+	},
+	{
+		Name:       "idx_amass_relations_from_type",
+		Table:      "relations",
+		Definition: "(from_asset_id, type)",
+		Reason:     "OutgoingRelations walks and the duplicate check of each Create",
+	},
+	{
+		Name:       "idx_amass_relations_to_type",
+		Table:      "relations",
+		Definition: "(to_asset_id, type)",
+		Reason:     "IncomingRelations walks and the cascading deletes of the assets",
+	},
+	{
+		Name:       "idx_amass_relations_last_seen",
+		Table:      "relations",
+		Definition: "(last_seen)",
+		Reason:     "time filters on the relations",
+	},
+}
+
+// Open returns the connection pool for the PostgreSQL database at the DSN, which
+// is either a URL or the keyword/value connection string used for the graph database.
+func Open(dsn string) (*sql.DB, error) {
+	return sql.Open("pgx", dsn)
+}
+
+// Missing returns the indexes that are not in the database, including the indexes left invalid by an interrupted creation.
+func Missing(ctx context.Context, db *sql.DB) ([]*Index, error) {
+	rows, err := db.QueryContext(ctx, "SELECT c.relname, i.indisvalid FROM pg_index i "+
+		"JOIN pg_class c ON c.oid = i.indexrelid JOIN pg_namespace n ON n.oid = c.relnamespace "+
+		"WHERE n.nspname = current_schema()")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the indexes: %v", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var valid bool
+
+		if err := rows.Scan(&name, &valid); err != nil {
+			return nil, err
+		}
+		existing[name] = valid
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []*Index
+	for _, idx := range Indexes {
+		if valid, found := existing[idx.Name]; !found || !valid {
+			m := *idx
+			m.Invalid = found
+			missing = append(missing, &m)
+		}
+	}
+	return missing, nil
+}
+
+// Ensure creates the indexes missing from the database and returns the indexes created. The indexes are
+// created concurrently, so the engine instances already writing to the database are not blocked, and the
+// indexes left invalid by an interrupted creation are removed and created again.
+func Ensure(ctx context.Context, db *sql.DB) ([]*Index, error) {
+	missing, err := Missing(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []*Index
+	for _, idx := range missing {
+		if idx.Invalid {
+			if _, err := db.ExecContext(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+idx.Name); err != nil {
+				return created, fmt.Errorf("failed to remove the invalid %s index: %v", idx.Name, err)
+			}
+		}
+		if _, err := db.ExecContext(ctx, idx.Statement()); err != nil {
+			return created, fmt.Errorf("failed to create the %s index: %v", idx.Name, err)
+		}
+		created = append(created, idx)
+	}
+	return created, nil
+}
+
+// Setup opens the database at the DSN and creates the missing indexes.
+func Setup(ctx context.Context, dsn string) ([]*Index, error) {
+	db, err := Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return Ensure(ctx, db)
+}
+
+// Statement returns the SQL statement creating the index.
+func (idx *Index) Statement() string {
+	return fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s %s", idx.Name, idx.Table, idx.Definition)
+}
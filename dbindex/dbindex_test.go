@@ -0,0 +1,69 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dbindex
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/assettest"
+)
+
+func TestAnalyze(t *testing.T) {
+	tables := []*TableStats{
+		{Table: "assets", LiveRows: 50000, SeqScans: 900, SeqRowsRead: 45000000, IndexScans: 20},
+		{Table: "relations", LiveRows: 80000, SeqScans: 3, IndexScans: 5000},
+	}
+	queries := []*QueryStats{
+		{Query: "SELECT * FROM \"assets\"  WHERE json_extract_path_text(\"content\"::json,'name') = $1", Calls: 40, TotalMillis: 8000, MeanMillis: 200},
+		{Query: "SELECT * FROM \"relations\" WHERE from_asset_id = $1", Calls: 10, TotalMillis: 20000, MeanMillis: 2000},
+		{Query: "SELECT * FROM \"relations\" WHERE to_asset_id = $1", Calls: 1000, TotalMillis: 1000, MeanMillis: 1},
+		{Query: "SELECT * FROM accounts", Calls: 1, TotalMillis: 5000, MeanMillis: 5000},
+	}
+
+	report := analyze(Indexes[:1], tables, queries, 10)
+	if len(report.Hotspots) != 4 {
+		t.Fatalf("Expected four hotspots, got %d", len(report.Hotspots))
+	}
+
+	expected := []struct{ kind, subject string }{
+		{HotspotMissingIndex, Indexes[0].Name},
+		{HotspotSeqScans, "assets"},
+		{HotspotSlowQuery, "SELECT * FROM \"relations\" WHERE from_asset_id = $1"},
+		{HotspotSlowQuery, "SELECT * FROM \"assets\" WHERE json_extract_path_text(\"content\"::json,'name') = $1"},
+	}
+	for i, e := range expected {
+		if h := report.Hotspots[i]; h.Kind != e.kind || h.Subject != e.subject {
+			t.Errorf("Expected hotspot %d to be %s %s, got %s %s", i, e.kind, e.subject, h.Kind, h.Subject)
+		}
+	}
+
+	if report = analyze(nil, nil, queries, 1); len(report.Hotspots) != 1 {
+		t.Errorf("Expected the limit to keep one slow query, got %d hotspots", len(report.Hotspots))
+	}
+}
+
+func TestEnsurePostgres(t *testing.T) {
+	_ = assettest.NewPostgresGraph(t)
+
+	db, err := Open(os.Getenv(assettest.PostgresEnv))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := Ensure(context.Background(), db); err != nil {
+		t.Fatalf("Failed to create the indexes: %v", err)
+	}
+
+	missing, err := Missing(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Failed to read the indexes: %v", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("Expected all the indexes to be created, got %d missing", len(missing))
+	}
+}
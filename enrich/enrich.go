@@ -0,0 +1,139 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package enrich queries the free endpoints of the internet scanning services for the context of the
+// in-scope addresses, such as the open ports observed by Shodan InternetDB and the classification of
+// the address by GreyNoise, without requiring API keys.
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+// Types of the findings recorded from the context of the addresses.
+const (
+	// TypeOpenPorts is the type of the findings recorded for the ports observed open by the scanning services
+	TypeOpenPorts = "open_ports"
+	// TypeScannerClassification is the type of the findings recorded for the addresses observed scanning the internet
+	TypeScannerClassification = "scanner_classification"
+)
+
+const (
+	requestTimeout = 20 * time.Second
+	maxBodySize    = 1024 * 1024
+)
+
+// ErrQuotaExceeded is returned by the sources once the free quota of the endpoint has been used.
+var ErrQuotaExceeded = errors.New("the quota of the free endpoint was exceeded")
+
+// Source is an internet scanning service providing the context of an address.
+type Source interface {
+	// Name returns the name of the source used in the configuration.
+	Name() string
+	// Enrich returns the findings about the address, or none when the service has not observed it.
+	Enrich(ctx context.Context, addr string) ([]*findings.Finding, error)
+}
+
+// Sources are the constructors of the sources that can be selected by name.
+var Sources = map[string]func() Source{
+	"greynoise":  func() Source { return NewGreyNoise() },
+	"internetdb": func() Source { return NewInternetDB() },
+}
+
+// SourcesByName returns the sources with the provided names.
+func SourcesByName(names []string) ([]Source, error) {
+	var srcs []Source
+
+	for _, name := range names {
+		fn, found := Sources[strings.ToLower(strings.TrimSpace(name))]
+		if !found {
+			return nil, fmt.Errorf("%s is not a supported address enrichment source", name)
+		}
+		srcs = append(srcs, fn())
+	}
+	return srcs, nil
+}
+
+// Enricher queries the sources for the context of the addresses. Once a source reports that its
+// free quota was exceeded, it is not queried again by the Enricher.
+type Enricher struct {
+	sync.Mutex
+	srcs      []Source
+	exhausted map[string]struct{}
+}
+
+// NewEnricher returns the Enricher querying the sources.
+func NewEnricher(srcs []Source) *Enricher {
+	return &Enricher{
+		srcs:      srcs,
+		exhausted: make(map[string]struct{}),
+	}
+}
+
+// Enrich returns the findings about the address from all the sources with quota remaining.
+func (e *Enricher) Enrich(ctx context.Context, addr string) ([]*findings.Finding, error) {
+	var results []*findings.Finding
+	var msgs []string
+
+	for _, src := range e.srcs {
+		e.Lock()
+		_, exhausted := e.exhausted[src.Name()]
+		e.Unlock()
+		if exhausted {
+			continue
+		}
+
+		fs, err := src.Enrich(ctx, addr)
+		if errors.Is(err, ErrQuotaExceeded) {
+			e.Lock()
+			e.exhausted[src.Name()] = struct{}{}
+			e.Unlock()
+		}
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %s: %v", src.Name(), addr, err))
+			continue
+		}
+		results = append(results, fs...)
+	}
+
+	if len(msgs) > 0 {
+		return results, errors.New(strings.Join(msgs, "; "))
+	}
+	return results, nil
+}
+
+// get returns the body of the response, or nil when the service has not observed the address.
+func get(ctx context.Context, client *http.Client, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	case http.StatusTooManyRequests:
+		return nil, ErrQuotaExceeded
+	default:
+		return nil, fmt.Errorf("the lookup returned status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+}
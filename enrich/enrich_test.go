@@ -0,0 +1,84 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestEnrich(t *testing.T) {
+	var greynoise int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/internetdb/192.0.2.1":
+			fmt.Fprint(w, `{"ip":"192.0.2.1","ports":[443,22,80],"hostnames":["www.owasp.org"],
+				"cpes":[],"tags":["cloud"],"vulns":["CVE-2023-0001"]}`)
+		case "/greynoise/192.0.2.1":
+			greynoise++
+			fmt.Fprint(w, `{"ip":"192.0.2.1","noise":true,"riot":false,"classification":"malicious",
+				"name":"unknown","link":"https://viz.greynoise.io/ip/192.0.2.1","last_seen":"2023-06-01"}`)
+		case "/greynoise/192.0.2.2":
+			greynoise++
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	idb := NewInternetDB()
+	idb.BaseURL = srv.URL + "/internetdb/"
+	gn := NewGreyNoise()
+	gn.BaseURL = srv.URL + "/greynoise/"
+	e := NewEnricher([]Source{idb, gn})
+
+	fs, err := e.Enrich(context.Background(), "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Failed to enrich the address: %v", err)
+	}
+	if len(fs) != 2 {
+		t.Fatalf("Expected two findings, got %d", len(fs))
+	}
+
+	ports := fs[0]
+	if ports.Type != TypeOpenPorts || ports.Attributes["ports"] != "22,80,443" || ports.Attributes["vulns"] != "CVE-2023-0001" {
+		t.Errorf("Unexpected open ports finding: %v", ports)
+	}
+	if _, found := ports.Attributes["cpes"]; found {
+		t.Error("Expected the empty lists to be left out of the attributes")
+	}
+
+	class := fs[1]
+	if class.Type != TypeScannerClassification || class.Severity != findings.SeverityMedium ||
+		class.Attributes["classification"] != "malicious" {
+		t.Errorf("Unexpected scanner classification finding: %v", class)
+	}
+	if _, found := class.Attributes["actor"]; found {
+		t.Error("Expected the unknown actor to be left out of the attributes")
+	}
+
+	// The addresses not observed by the services provide no findings
+	if fs, err := e.Enrich(context.Background(), "192.0.2.3"); err != nil || len(fs) != 0 {
+		t.Errorf("Expected no findings for an unobserved address, got %v, %v", fs, err)
+	}
+
+	if _, err := e.Enrich(context.Background(), "192.0.2.2"); err == nil {
+		t.Error("Expected an error once the quota was exceeded")
+	}
+	before := greynoise
+	if _, err := e.Enrich(context.Background(), "192.0.2.1"); err != nil || greynoise != before {
+		t.Errorf("Expected the source to not be queried after exceeding the quota: %v", err)
+	}
+
+	if _, err := SourcesByName([]string{"InternetDB", "censys"}); err == nil {
+		t.Error("Expected an error for an unsupported source")
+	}
+}
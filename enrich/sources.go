@@ -0,0 +1,186 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// InternetDB queries the free Shodan InternetDB service for the open ports of an address.
+type InternetDB struct {
+	// BaseURL is the address of the API, with the address appended to it
+	BaseURL string
+	HTTP    *http.Client
+}
+
+type internetDBResponse struct {
+	IP        string   `json:"ip"`
+	Ports     []int    `json:"ports"`
+	Hostnames []string `json:"hostnames"`
+	CPEs      []string `json:"cpes"`
+	Tags      []string `json:"tags"`
+	Vulns     []string `json:"vulns"`
+}
+
+// NewInternetDB returns the Shodan InternetDB source.
+func NewInternetDB() *InternetDB {
+	return &InternetDB{
+		BaseURL: "https://internetdb.shodan.io/",
+		HTTP: amasshttp.NewClient("internetdb", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Cache:   true,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// Name implements the Source interface.
+func (i *InternetDB) Name() string {
+	return "internetdb"
+}
+
+// Enrich implements the Source interface.
+func (i *InternetDB) Enrich(ctx context.Context, addr string) ([]*findings.Finding, error) {
+	body, err := get(ctx, i.HTTP, i.BaseURL+url.PathEscape(addr))
+	if err != nil || body == nil {
+		return nil, err
+	}
+
+	var resp internetDBResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode the InternetDB response: %v", err)
+	}
+	if len(resp.Ports) == 0 {
+		return nil, nil
+	}
+
+	sort.Ints(resp.Ports)
+	ports := make([]string, 0, len(resp.Ports))
+	for _, p := range resp.Ports {
+		ports = append(ports, strconv.Itoa(p))
+	}
+
+	attrs := map[string]string{
+		"ports":  strings.Join(ports, ","),
+		"source": i.Name(),
+	}
+	for key, list := range map[string][]string{
+		"hostnames": resp.Hostnames,
+		"cpes":      resp.CPEs,
+		"tags":      resp.Tags,
+		"vulns":     resp.Vulns,
+	} {
+		if len(list) > 0 {
+			sort.Strings(list)
+			attrs[key] = strings.Join(list, ",")
+		}
+	}
+
+	return []*findings.Finding{{
+		Asset:      addr,
+		Type:       TypeOpenPorts,
+		Severity:   findings.SeverityInfo,
+		Title:      fmt.Sprintf("Ports %s observed open by Shodan InternetDB", strings.Join(ports, ", ")),
+		Attributes: attrs,
+	}}, nil
+}
+
+// GreyNoise queries the free community API of GreyNoise for the classification of an address.
+type GreyNoise struct {
+	// BaseURL is the address of the community API, with the address appended to it
+	BaseURL string
+	HTTP    *http.Client
+}
+
+type greyNoiseResponse struct {
+	Noise          bool   `json:"noise"`
+	RIOT           bool   `json:"riot"`
+	Classification string `json:"classification"`
+	Name           string `json:"name"`
+	Link           string `json:"link"`
+	LastSeen       string `json:"last_seen"`
+}
+
+// NewGreyNoise returns the GreyNoise community source.
+func NewGreyNoise() *GreyNoise {
+	return &GreyNoise{
+		BaseURL: "https://api.greynoise.io/v3/community/",
+		HTTP: amasshttp.NewClient("greynoise", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Cache:   true,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// Name implements the Source interface.
+func (g *GreyNoise) Name() string {
+	return "greynoise"
+}
+
+// Enrich implements the Source interface. The in-scope addresses observed scanning the internet
+// are recorded, since they can indicate compromised hosts, and the malicious scanners have the
+// medium severity.
+func (g *GreyNoise) Enrich(ctx context.Context, addr string) ([]*findings.Finding, error) {
+	body, err := get(ctx, g.HTTP, g.BaseURL+url.PathEscape(addr))
+	if err != nil || body == nil {
+		return nil, err
+	}
+
+	var resp greyNoiseResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode the GreyNoise response: %v", err)
+	}
+	if !resp.Noise && !resp.RIOT {
+		return nil, nil
+	}
+
+	class := resp.Classification
+	if class == "" {
+		class = "unknown"
+	}
+	severity := findings.SeverityInfo
+	if class == "malicious" {
+		severity = findings.SeverityMedium
+	}
+
+	attrs := map[string]string{
+		"classification": class,
+		"noise":          strconv.FormatBool(resp.Noise),
+		"riot":           strconv.FormatBool(resp.RIOT),
+		"source":         g.Name(),
+	}
+	if resp.Name != "" && resp.Name != "unknown" {
+		attrs["actor"] = resp.Name
+	}
+	if resp.LastSeen != "" {
+		attrs["last_seen"] = resp.LastSeen
+	}
+	if resp.Link != "" {
+		attrs["link"] = resp.Link
+	}
+
+	title := fmt.Sprintf("Address observed scanning the internet, classified as %s by GreyNoise", class)
+	if !resp.Noise {
+		title = "Address of a common business service according to GreyNoise"
+	}
+	return []*findings.Finding{{
+		Asset:      addr,
+		Type:       TypeScannerClassification,
+		Severity:   severity,
+		Title:      title,
+		Attributes: attrs,
+	}}, nil
+}
@@ -0,0 +1,118 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/rdap"
+	"github.com/owasp-amass/amass/v4/net/registry"
+	"github.com/owasp-amass/amass/v4/net/whois"
+)
+
+const maxAbuseLookups = 5
+
+// abuseLookups obtains the abuse contacts for the in-scope netblocks and root domain names.
+type abuseLookups struct {
+	sync.WaitGroup
+	sync.Mutex
+	client  *rdap.Client
+	whois   *whois.Client
+	checked map[string]struct{}
+	sem     chan struct{}
+}
+
+func newAbuseLookups() *abuseLookups {
+	return &abuseLookups{
+		client:  rdap.NewClient(),
+		whois:   whois.NewClient(),
+		checked: make(map[string]struct{}),
+		sem:     make(chan struct{}, maxAbuseLookups),
+	}
+}
+
+// lookupAbuseContacts obtains the abuse contacts for the netblock or domain name once per enumeration.
+func (e *Enumeration) lookupAbuseContacts(object string) {
+	a := e.abuse
+	if a == nil || object == "" {
+		return
+	}
+
+	a.Lock()
+	_, found := a.checked[object]
+	a.checked[object] = struct{}{}
+	a.Unlock()
+	if found {
+		return
+	}
+
+	a.Add(1)
+	go func() {
+		defer a.Done()
+
+		a.sem <- struct{}{}
+		defer func() { <-a.sem }()
+
+		_, _, cerr := net.ParseCIDR(object)
+		// The root domain names are looked up before the netblocks at each registry
+		ctx := registry.WithPriority(e.ctx, registry.PriorityNormal)
+		if cerr != nil {
+			ctx = registry.WithPriority(e.ctx, registry.PriorityHigh)
+		}
+
+		var err error
+		var contacts []*rdap.Contact
+		if cerr == nil {
+			contacts, err = a.client.AbuseContacts(ctx, object)
+		} else {
+			contacts, err = e.domainRegistration(ctx, object)
+		}
+		if err != nil {
+			if e.Config.Verbose {
+				e.Config.Log.Printf("Abuse contacts for %s: %v", object, err)
+			}
+			return
+		}
+
+		for _, c := range contacts {
+			e.addFinding(&findings.Finding{
+				Asset: object,
+				Type:  "abuse_contact",
+				Title: "Abuse contact",
+				Attributes: map[string]string{
+					"handle": c.Handle,
+					"name":   c.Name,
+					"email":  c.Email,
+					"phone":  c.Phone,
+				},
+			})
+		}
+	}()
+}
+
+// domainRegistration records the registration of the root domain name, with all the fields provided by
+// the registry as the attributes of the finding, and returns the abuse contacts of the registration.
+func (e *Enumeration) domainRegistration(ctx context.Context, domain string) ([]*rdap.Contact, error) {
+	a := e.abuse
+
+	source := "rdap"
+	reg, err := a.client.DomainRegistration(ctx, domain)
+	// Fallback to WHOIS for the TLDs that do not provide RDAP
+	if err != nil {
+		resp, werr := a.whois.Query(ctx, domain)
+		if werr != nil {
+			return nil, err
+		}
+
+		source = "whois"
+		reg = resp.Registration()
+	}
+
+	e.addFinding(reg.Finding(domain, source))
+	return reg.Abuse, nil
+}
@@ -0,0 +1,96 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/requests"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// submitBackfill releases the in-scope names and addresses already in the graph databases directly
+// to the selected data sources, so the sources are run across the existing assets without the names
+// being resolved and stored again. The names returned by the sources enter the enumeration as usual.
+func (e *Enumeration) submitBackfill() {
+	var total int
+
+	for _, g := range e.Sys.GraphDatabases() {
+		total += e.backfillFromDatabase(g)
+	}
+	e.Config.Log.Printf("Backfill: %d existing assets were sent to the data sources", total)
+}
+
+func (e *Enumeration) backfillFromDatabase(db *netmap.Graph) int {
+	var count int
+	var names []string
+	seen := make(map[string]struct{})
+
+	for _, d := range e.Config.Domains() {
+		assets, err := db.DB.FindByScope([]oam.Asset{domain.FQDN{Name: d}}, time.Time{})
+		if err != nil {
+			continue
+		}
+
+		for _, a := range assets {
+			fqdn, ok := a.Asset.(domain.FQDN)
+			if !ok {
+				continue
+			}
+			if _, found := seen[fqdn.Name]; found {
+				continue
+			}
+			seen[fqdn.Name] = struct{}{}
+
+			select {
+			case <-e.done:
+				return count
+			default:
+			}
+
+			// The root domain names were already provided to the data sources
+			domain := e.Config.WhichDomain(fqdn.Name)
+			if domain == "" || domain == fqdn.Name || e.Config.Blacklisted(fqdn.Name) {
+				continue
+			}
+
+			names = append(names, fqdn.Name)
+			e.sendRequests(&requests.SubdomainRequest{
+				Name:   fqdn.Name,
+				Domain: domain,
+				Times:  1,
+			})
+			count++
+		}
+	}
+	if len(names) == 0 {
+		return count
+	}
+
+	pairs, err := db.NamesToAddrs(context.Background(), time.Time{}, names...)
+	if err != nil {
+		return count
+	}
+
+	addrs := make(map[string]struct{})
+	for _, p := range pairs {
+		addr := p.Addr.Address.String()
+		if _, found := addrs[addr]; found {
+			continue
+		}
+		addrs[addr] = struct{}{}
+
+		e.sendRequests(&requests.AddrRequest{
+			Address: addr,
+			InScope: true,
+			Domain:  e.Config.WhichDomain(p.FQDN.Name),
+		})
+		count++
+	}
+	return count
+}
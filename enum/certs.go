@@ -0,0 +1,139 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+)
+
+const (
+	maxCertChecks     = 10
+	certCheckTimeout  = 10 * time.Second
+	wildcardCertLabel = "*."
+)
+
+// certChecks obtains the certificates served for the resolved names during active enumerations.
+type certChecks struct {
+	sync.WaitGroup
+	sync.Mutex
+	checked map[string]struct{}
+	sem     chan struct{}
+}
+
+func newCertChecks() *certChecks {
+	return &certChecks{
+		checked: make(map[string]struct{}),
+		sem:     make(chan struct{}, maxCertChecks),
+	}
+}
+
+// checkCertificates records a finding for each in-scope wildcard certificate served for the
+// name at the address, once per name. The name is provided using SNI, since hosts sharing
+// an address commonly serve different certificates.
+func (e *Enumeration) checkCertificates(name, addr string) {
+	c := e.certs
+	if c == nil || !e.Config.Active || name == "" || addr == "" {
+		return
+	}
+
+	c.Lock()
+	_, found := c.checked[name]
+	c.checked[name] = struct{}{}
+	c.Unlock()
+	if found {
+		return
+	}
+
+	c.Add(1)
+	go func() {
+		defer c.Done()
+
+		c.sem <- struct{}{}
+		defer func() { <-c.sem }()
+
+		for _, port := range e.Config.Scope.Ports {
+			if port == 80 {
+				continue
+			}
+
+			cert := e.serverCertificate(name, addr, port)
+			if cert == nil {
+				continue
+			}
+			if wildcard := e.inScopeWildcard(cert); wildcard != "" {
+				e.addFinding(wildcardCertFinding(wildcard, net.JoinHostPort(name, strconv.Itoa(port)), addr, cert))
+			}
+		}
+	}()
+}
+
+func (e *Enumeration) serverCertificate(name, addr string, port int) *x509.Certificate {
+	ctx, cancel := context.WithTimeout(e.ctx, certCheckTimeout)
+	defer cancel()
+
+	conn, err := amassnet.DialContext(ctx, "tcp", net.JoinHostPort(addr, strconv.Itoa(port)))
+	if err != nil {
+		return nil
+	}
+
+	c := tls.Client(conn, &tls.Config{
+		ServerName:         name,
+		InsecureSkipVerify: true,
+	})
+	defer c.Close()
+
+	if err := c.HandshakeContext(ctx); err != nil {
+		return nil
+	}
+	if certs := c.ConnectionState().PeerCertificates; len(certs) > 0 {
+		return certs[0]
+	}
+	return nil
+}
+
+// inScopeWildcard returns the first wildcard name in the certificate that is within the scope.
+func (e *Enumeration) inScopeWildcard(cert *x509.Certificate) string {
+	for _, n := range append([]string{cert.Subject.CommonName}, cert.DNSNames...) {
+		n = strings.ToLower(strings.TrimSpace(n))
+
+		if strings.HasPrefix(n, wildcardCertLabel) && e.Config.IsDomainInScope(strings.TrimPrefix(n, wildcardCertLabel)) {
+			return n
+		}
+	}
+	return ""
+}
+
+func wildcardCertFinding(wildcard, host, addr string, cert *x509.Certificate) *findings.Finding {
+	fp := sha256.Sum256(cert.Raw)
+	key := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return &findings.Finding{
+		Asset: wildcard,
+		Type:  findings.TypeWildcardCertificate,
+		Title: "Wildcard certificate",
+		Attributes: map[string]string{
+			"host":        host,
+			"address":     addr,
+			"issuer":      cert.Issuer.CommonName,
+			"names":       strings.Join(cert.DNSNames, ","),
+			"fingerprint": hex.EncodeToString(fp[:]),
+			"key":         hex.EncodeToString(key[:]),
+			"not_before":  cert.NotBefore.UTC().Format(time.RFC3339),
+			"not_after":   cert.NotAfter.UTC().Format(time.RFC3339),
+		},
+	}
+}
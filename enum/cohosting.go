@@ -0,0 +1,123 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/pdns"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	maxReverseLookups  = 100
+	maxReverseRequests = 2
+)
+
+// reverseLookups queries the passive DNS sources for the names historically hosted at the in-scope addresses.
+type reverseLookups struct {
+	sync.WaitGroup
+	sync.Mutex
+	srcs    []pdns.Source
+	checked map[string]struct{}
+	sem     chan struct{}
+}
+
+// newReverseLookups returns the lookups for the sources selected by the reverse_pdns option, or
+// nil when the option is not provided.
+func newReverseLookups(cfg *config.Config) (*reverseLookups, error) {
+	raw, found := cfg.Options["reverse_pdns"]
+	if !found {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.New("the reverse_pdns option must be a list of passive DNS sources")
+	}
+
+	var names []string
+	for _, v := range list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("the reverse_pdns source %v must be a string", v)
+		}
+		names = append(names, s)
+	}
+
+	srcs, err := pdns.SourcesByName(names)
+	if err != nil {
+		return nil, err
+	}
+	for _, src := range srcs {
+		// The API key configured for the HackerTarget data source raises the daily quota
+		if ht, ok := src.(*pdns.HackerTarget); ok {
+			if creds := cfg.DataSrcConfigs.GetCredentials("HackerTarget"); creds != nil {
+				ht.Key = creds.Apikey
+			}
+		}
+	}
+
+	return &reverseLookups{
+		srcs:    srcs,
+		checked: make(map[string]struct{}),
+		sem:     make(chan struct{}, maxReverseRequests),
+	}, nil
+}
+
+// lookupCoHosted records the registered domains that shared the in-scope address with the target as
+// candidate associations, weighted by how exclusively the address was used by them.
+func (e *Enumeration) lookupCoHosted(addr string) {
+	rl := e.reverse
+	if rl == nil || addr == "" {
+		return
+	}
+
+	rl.Lock()
+	_, found := rl.checked[addr]
+	full := len(rl.checked) >= maxReverseLookups
+	if !found && !full {
+		rl.checked[addr] = struct{}{}
+	}
+	rl.Unlock()
+	if found || full {
+		return
+	}
+
+	rl.Add(1)
+	go func() {
+		defer rl.Done()
+
+		rl.sem <- struct{}{}
+		defer func() { <-rl.sem }()
+
+		hosted, err := pdns.Hosted(e.ctx, rl.srcs, addr)
+		if err != nil && e.Config.Verbose {
+			e.Config.Log.Printf("Passive DNS reverse lookup: %v", err)
+		}
+
+		// Addresses used by many registered domains are recorded, so the associations do not pivot on them
+		if n := pdns.RegisteredDomains(hosted); n > pdns.MaxCoHosted {
+			e.addFinding(&findings.Finding{
+				Asset: addr,
+				Type:  pdns.TypeSharedHosting,
+				Title: "Address used by many registered domains",
+				Attributes: map[string]string{
+					"domains": strconv.Itoa(n),
+				},
+			})
+			return
+		}
+
+		for _, c := range pdns.Candidates(addr, hosted, e.Config.IsDomainInScope) {
+			e.Config.Log.Printf("%s was hosted at %s (exclusivity %.3f)", c.Domain, addr, c.Exclusivity)
+			e.addFinding(c.Finding())
+		}
+	}()
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/caffix/queue"
 	"github.com/miekg/dns"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/sharedcache"
 	"github.com/owasp-amass/resolve"
 )
 
@@ -170,7 +171,7 @@ func (dt *dnsTask) Process(ctx context.Context, data pipeline.Data, tp pipeline.
 
 	if v, ok := data.(*requests.DNSRequest); ok {
 		qtype := FwdQueryTypes[0]
-		msg := resolve.QueryMsg(v.Name, qtype)
+		msg := dt.enum.queryMsg(v.Name, qtype)
 		k := key(msg.Id, msg.Question[0].Name)
 
 		if dt.addReqWithIncrement(k, &req{
@@ -180,6 +181,7 @@ func (dt *dnsTask) Process(ctx context.Context, data pipeline.Data, tp pipeline.
 			Attempts:   1,
 			HasRecords: len(v.Records) > 0,
 		}) {
+			dt.enum.stealth.delayQuery(ctx)
 			dt.pool.Query(ctx, msg, dt.resps)
 		} else {
 			dt.enum.Config.Log.Printf("Failed to enter %s into the request registry on the %s DNS task", msg.Question[0].Name, dt.trust)
@@ -318,7 +320,7 @@ func (dt *dnsTask) processResp(resp *dns.Msg) {
 		if resp.Rcode == dns.RcodeSuccess {
 			dt.processFwdRequest(ctx, resp, name, qtype, v, entry)
 		} else {
-			go dt.retry(resolve.QueryMsg(v.Name, qtype), resp.Id, entry)
+			go dt.retry(dt.enum.queryMsg(v.Name, qtype), resp.Id, entry)
 		}
 	default:
 		dt.delReqWithDecrement(k)
@@ -347,7 +349,7 @@ func (dt *dnsTask) nextType(ctx context.Context, name string, id, qtype uint16,
 		entry.Attempts = 1
 		entry.Servfails = 0
 		entry.Qtype = FwdQueryTypes[idx+1]
-		msg := resolve.QueryMsg(name, entry.Qtype)
+		msg := dt.enum.queryMsg(name, entry.Qtype)
 		dt.delReq(k)
 		dt.addReq(key(msg.Id, msg.Question[0].Name), entry)
 		dt.pool.Query(ctx, msg, dt.resps)
@@ -383,6 +385,20 @@ func (dt *dnsTask) processFwdRequest(ctx context.Context, resp *dns.Msg, name st
 	}
 
 	req.Records = append(req.Records, convertAnswers(rr)...)
+	// query from the additional vantage points for geo-dependent answers
+	if qtype != dns.TypeCNAME && dt.enum.hasVantagePoints() {
+		go func() {
+			req.Records = append(req.Records, dt.enum.vantageAnswers(ctx, name, qtype, req.Records)...)
+			dt.finishFwdRequest(ctx, resp, name, qtype, req, entry)
+		}()
+		return
+	}
+	dt.finishFwdRequest(ctx, resp, name, qtype, req, entry)
+}
+
+func (dt *dnsTask) finishFwdRequest(ctx context.Context, resp *dns.Msg, name string, qtype uint16, req *requests.DNSRequest, entry *req) {
+	k := key(resp.Id, resp.Question[0].Name)
+
 	entry.HasRecords = len(req.Records) > 0
 	// are there additional record types to query for?
 	if idx, found := fwdQueryTypesLookup[qtype]; found && qtype != dns.TypeCNAME && idx+1 < len(FwdQueryTypes) {
@@ -400,6 +416,7 @@ func (dt *dnsTask) subdomainQueries(ctx context.Context, req *requests.DNSReques
 	go dt.queryMX(ctx, req.Name, ch, tp)
 	go dt.querySOA(ctx, req.Name, ch, tp)
 	go dt.querySPF(ctx, req.Name, ch, tp)
+	go dt.enum.checkDNSSEC(ctx, req.Name, req.Domain)
 
 	for i := 0; i < 4; i++ {
 		if rr := <-ch; rr != nil {
@@ -482,6 +499,13 @@ func (dt *dnsTask) querySPF(ctx context.Context, name string, ch chan []requests
 }
 
 func (e *Enumeration) fwdQuery(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	// The answers verified by the trusted resolvers are shared with the other engine instances
+	if sharedcache.Default != nil {
+		if resp := sharedcache.Default.LookupDNS(name, qtype); resp != nil {
+			return resp, nil
+		}
+	}
+
 	resp, err := e.dnsQuery(ctx, name, qtype, e.Sys.Resolvers(), maxDNSQueryAttempts)
 	if err != nil {
 		return resp, err
@@ -494,11 +518,14 @@ func (e *Enumeration) fwdQuery(ctx context.Context, name string, qtype uint16) (
 	if resp == nil && err == nil {
 		err = errors.New("query failed")
 	}
+	if err == nil && sharedcache.Default != nil {
+		sharedcache.Default.StoreDNS(resp)
+	}
 	return resp, err
 }
 
 func (e *Enumeration) dnsQuery(ctx context.Context, name string, qtype uint16, r *resolve.Resolvers, attempts int) (*dns.Msg, error) {
-	msg := resolve.QueryMsg(name, qtype)
+	msg := e.queryMsg(name, qtype)
 
 	for num := 0; num < attempts; num++ {
 		select {
@@ -0,0 +1,108 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/pdns"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	maxHistoryLookups  = 100
+	maxHistoryRequests = 2
+)
+
+// historyLookups queries the DNS history sources for the past resolutions of the in-scope names.
+type historyLookups struct {
+	sync.WaitGroup
+	sync.Mutex
+	srcs    []pdns.HistorySource
+	checked map[string]struct{}
+	sem     chan struct{}
+}
+
+// newHistoryLookups returns the lookups for the sources selected by the dns_history option, or
+// nil when the option is not provided. The API keys are the credentials of the data sources.
+func newHistoryLookups(cfg *config.Config) (*historyLookups, error) {
+	raw, found := cfg.Options["dns_history"]
+	if !found {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.New("the dns_history option must be a list of DNS history sources")
+	}
+
+	var names []string
+	for _, v := range list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("the dns_history source %v must be a string", v)
+		}
+		names = append(names, s)
+	}
+
+	srcs, err := pdns.HistorySourcesByName(names, func(name string) string {
+		if dsc := cfg.GetDataSourceConfig(name); dsc != nil {
+			for _, creds := range dsc.Creds {
+				if creds != nil && creds.Apikey != "" {
+					return creds.Apikey
+				}
+			}
+		}
+		return ""
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &historyLookups{
+		srcs:    srcs,
+		checked: make(map[string]struct{}),
+		sem:     make(chan struct{}, maxHistoryRequests),
+	}, nil
+}
+
+// lookupHistory records the past resolutions of the in-scope name, with the dates each record was observed,
+// so the timeline of the name can be provided after the records have changed.
+func (e *Enumeration) lookupHistory(name string) {
+	hl := e.history
+	if hl == nil || name == "" || !e.Config.IsDomainInScope(name) {
+		return
+	}
+
+	hl.Lock()
+	_, found := hl.checked[name]
+	full := len(hl.checked) >= maxHistoryLookups
+	if !found && !full {
+		hl.checked[name] = struct{}{}
+	}
+	hl.Unlock()
+	if found || full {
+		return
+	}
+
+	hl.Add(1)
+	go func() {
+		defer hl.Done()
+
+		hl.sem <- struct{}{}
+		defer func() { <-hl.sem }()
+
+		res, err := pdns.History(e.ctx, hl.srcs, name)
+		if err != nil && e.Config.Verbose {
+			e.Config.Log.Printf("DNS history lookup: %v", err)
+		}
+
+		for _, r := range res {
+			e.addFinding(r.Finding())
+		}
+	}()
+}
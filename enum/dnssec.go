@@ -0,0 +1,94 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+const maxDNSSECQueryAttempts int = 5
+
+// checkDNSSEC records whether the zone is DNSSEC-signed and whether the trusted resolvers
+// were able to validate the responses. Validation failures are recorded with a higher severity.
+func (e *Enumeration) checkDNSSEC(ctx context.Context, name, domain string) {
+	resp := e.dnssecQuery(ctx, name, false)
+	if resp == nil {
+		return
+	}
+
+	if resp.Rcode == dns.RcodeServerFailure {
+		// Check if the failure was caused by the validation of the signatures
+		if cd := e.dnssecQuery(ctx, name, true); cd != nil && cd.Rcode == dns.RcodeSuccess && hasDNSKEY(cd) {
+			e.addFinding(&findings.Finding{
+				Asset:    name,
+				Type:     "dnssec",
+				Severity: findings.SeverityMedium,
+				Title:    "DNSSEC validation failed",
+				Details:  "The zone is signed, but the resolvers were unable to validate the responses",
+				Attributes: map[string]string{
+					"signed":    "true",
+					"validated": "false",
+				},
+			})
+		}
+		return
+	} else if resp.Rcode != dns.RcodeSuccess {
+		return
+	}
+
+	signed := hasDNSKEY(resp)
+	// Names below the root domain without keys are not considered zones
+	if !signed && name != domain {
+		return
+	}
+
+	title := "Zone is not DNSSEC-signed"
+	if signed {
+		title = "Zone is DNSSEC-signed"
+	}
+	e.addFinding(&findings.Finding{
+		Asset: name,
+		Type:  "dnssec",
+		Title: title,
+		Attributes: map[string]string{
+			"signed":    strconv.FormatBool(signed),
+			"validated": strconv.FormatBool(signed && resp.AuthenticatedData),
+		},
+	})
+}
+
+func (e *Enumeration) dnssecQuery(ctx context.Context, name string, cd bool) *dns.Msg {
+	for i := 0; i < maxDNSSECQueryAttempts; i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		msg := new(dns.Msg)
+		msg.SetQuestion(dns.Fqdn(name), dns.TypeDNSKEY)
+		msg.SetEdns0(dns.DefaultMsgSize, true)
+		msg.AuthenticatedData = true
+		msg.CheckingDisabled = cd
+
+		if resp, err := e.Sys.TrustedResolvers().QueryBlocking(ctx, msg); err == nil && resp != nil {
+			return resp
+		}
+	}
+	return nil
+}
+
+func hasDNSKEY(resp *dns.Msg) bool {
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == dns.TypeDNSKEY {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/mockinet"
+	"github.com/owasp-amass/amass/v4/net/rdap"
+	"github.com/owasp-amass/amass/v4/net/registry"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/resolve"
+)
+
+// newMockInternetSystem returns a system that only uses the mock Internet for DNS resolution and the named data source.
+func newMockInternetSystem(t testing.TB, cfg *config.Config, in *mockinet.Internet, source string) *systems.SimpleSystem {
+	sys := &systems.SimpleSystem{
+		Cfg:      cfg,
+		Pool:     resolve.NewResolvers(),
+		Trusted:  resolve.NewResolvers(),
+		Graph:    netmap.NewGraph("local", filepath.Join(cfg.Dir, "amass.sqlite"), ""),
+		ASNCache: requests.NewASNCache(),
+	}
+
+	for _, pool := range []*resolve.Resolvers{sys.Pool, sys.Trusted} {
+		pool.SetLogger(cfg.Log)
+		_ = pool.AddResolvers(100, in.DNSAddr())
+		pool.SetDetectionResolver(100, in.DNSAddr())
+	}
+
+	src := scripting.NewScript(in.Script(source), sys)
+	if src == nil {
+		t.Fatal("Failed to load the mock data source")
+	}
+	if err := sys.AddAndStart(src); err != nil {
+		t.Fatalf("Failed to start the mock data source: %v", err)
+	}
+	return sys
+}
+
+func TestEnumerationEndToEnd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("The end-to-end tests run the whole engine")
+	}
+
+	in := mockinet.New()
+	if err := in.AddZone("owasp.org",
+		"@ IN A 192.0.2.1",
+		"www IN A 192.0.2.2",
+		"docs IN CNAME www",
+		"@ IN MX 10 mail.owasp.org.",
+		"mail IN A 192.0.2.3",
+	); err != nil {
+		t.Fatalf("Failed to add the zone: %v", err)
+	}
+	if err := in.AddNetwork("192.0.2.0/24", "NET-192-0-2-0-1", "TEST-NET-1", "abuse@example.net"); err != nil {
+		t.Fatalf("Failed to add the network: %v", err)
+	}
+	in.AddSource("MockAPI", "www.owasp.org", "docs.owasp.org", "stale.owasp.org", "www.example.com")
+	if err := in.Start(); err != nil {
+		t.Fatalf("Failed to start the mock Internet: %v", err)
+	}
+	defer in.Close()
+
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.AddDomain("owasp.org")
+
+	sys := newMockInternetSystem(t, cfg, in, "MockAPI")
+	defer func() { _ = sys.Shutdown() }()
+
+	e := NewEnumeration(cfg, sys, sys.Graph)
+	e.abuse = newAbuseLookups()
+	e.abuse.client = &rdap.Client{
+		BaseURL: in.URL(),
+		HTTP:    e.abuse.client.HTTP,
+		Queue:   registry.NewQueue(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := e.Start(ctx); err != nil {
+		t.Fatalf("The enumeration failed: %v", err)
+	}
+
+	if in.Requests("MockAPI") == 0 {
+		t.Error("The mock data source was not queried")
+	}
+
+	assets, err := sys.Graph.DB.FindByScope([]oam.Asset{domain.FQDN{Name: "owasp.org"}}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to read the names from the graph database: %v", err)
+	}
+
+	found := make(map[string]bool)
+	var names []string
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok {
+			found[fqdn.Name] = true
+			names = append(names, fqdn.Name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range []string{"owasp.org", "www.owasp.org", "docs.owasp.org", "mail.owasp.org"} {
+		if !found[name] {
+			t.Errorf("%s was not stored, the graph database provided %v", name, names)
+		}
+	}
+	for _, name := range []string{"stale.owasp.org", "www.example.com"} {
+		if found[name] {
+			t.Errorf("%s should not have been stored", name)
+		}
+	}
+	if !sys.Graph.IsCNAMENode(ctx, "docs.owasp.org", time.Time{}) {
+		t.Error("The CNAME record of docs.owasp.org was not stored")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+// clientSubnets returns the EDNS Client Subnet settings from the client_subnet option.
+func clientSubnets(cfg *config.Config) (*amassdns.ClientSubnets, error) {
+	var values []string
+
+	switch v := cfg.Options["client_subnet"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		values = append(values, v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("client_subnet must be a string or a list of CIDRs")
+	}
+	return amassdns.ParseClientSubnets(values...)
+}
+
+// queryMsg returns a DNS query message using the EDNS Client Subnet settings of the enumeration.
+func (e *Enumeration) queryMsg(name string, qtype uint16) *dns.Msg {
+	if e.ecs == nil {
+		return resolve.QueryMsg(name, qtype)
+	}
+	return e.ecs.QueryMsg(name, qtype, 0)
+}
+
+// clientSubnetAnswers queries the name from each of the additional client subnets, and
+// returns the answers that were not already obtained, such as the members of GSLB pools.
+func (e *Enumeration) clientSubnetAnswers(ctx context.Context, name string, qtype uint16, known []requests.DNSAnswer) []requests.DNSAnswer {
+	seen := make(map[string]struct{})
+	for _, a := range known {
+		seen[strings.ToLower(a.Data)] = struct{}{}
+	}
+
+	var answers []requests.DNSAnswer
+	for i := 1; i < len(e.ecs.Subnets); i++ {
+		select {
+		case <-ctx.Done():
+			return answers
+		default:
+		}
+
+		resp, err := e.Sys.TrustedResolvers().QueryBlocking(ctx, e.ecs.QueryMsg(name, qtype, i))
+		if err != nil || resp == nil || resp.Rcode != dns.RcodeSuccess {
+			continue
+		}
+
+		for _, a := range convertAnswers(resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype)) {
+			if _, found := seen[strings.ToLower(a.Data)]; !found {
+				seen[strings.ToLower(a.Data)] = struct{}{}
+				answers = append(answers, a)
+			}
+		}
+	}
+	return answers
+}
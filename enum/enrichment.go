@@ -0,0 +1,98 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/enrich"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	maxEnrichLookups  = 250
+	maxEnrichRequests = 2
+)
+
+// enrichLookups queries the free endpoints of the internet scanning services for the context of the in-scope addresses.
+type enrichLookups struct {
+	sync.WaitGroup
+	sync.Mutex
+	enricher *enrich.Enricher
+	checked  map[string]struct{}
+	sem      chan struct{}
+}
+
+// newEnrichLookups returns the lookups for the sources selected by the address_enrichment option, or
+// nil when the option is not provided.
+func newEnrichLookups(cfg *config.Config) (*enrichLookups, error) {
+	raw, found := cfg.Options["address_enrichment"]
+	if !found {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.New("the address_enrichment option must be a list of sources")
+	}
+
+	var names []string
+	for _, v := range list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("the address_enrichment source %v must be a string", v)
+		}
+		names = append(names, s)
+	}
+
+	srcs, err := enrich.SourcesByName(names)
+	if err != nil {
+		return nil, err
+	}
+
+	return &enrichLookups{
+		enricher: enrich.NewEnricher(srcs),
+		checked:  make(map[string]struct{}),
+		sem:      make(chan struct{}, maxEnrichRequests),
+	}, nil
+}
+
+// enrichAddress records the open ports and the scanner classification of the in-scope address.
+func (e *Enumeration) enrichAddress(addr string) {
+	el := e.enrich
+	if el == nil || addr == "" {
+		return
+	}
+
+	el.Lock()
+	_, found := el.checked[addr]
+	full := len(el.checked) >= maxEnrichLookups
+	if !found && !full {
+		el.checked[addr] = struct{}{}
+	}
+	el.Unlock()
+	if found || full {
+		return
+	}
+
+	el.Add(1)
+	go func() {
+		defer el.Done()
+
+		el.sem <- struct{}{}
+		defer func() { <-el.sem }()
+
+		fs, err := el.enricher.Enrich(e.ctx, addr)
+		if err != nil && e.Config.Verbose {
+			e.Config.Log.Printf("Address enrichment: %v", err)
+		}
+
+		for _, f := range fs {
+			e.addFinding(f)
+		}
+	}()
+}
@@ -6,6 +6,7 @@ package enum
 
 import (
 	"context"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -14,7 +15,15 @@ import (
 	"github.com/caffix/queue"
 	"github.com/caffix/service"
 	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/findings"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/probe"
+	"github.com/owasp-amass/amass/v4/repos"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/risk"
+	"github.com/owasp-amass/amass/v4/rules"
+	"github.com/owasp-amass/amass/v4/sightings"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
 	oam "github.com/owasp-amass/open-asset-model"
@@ -23,20 +32,47 @@ import (
 
 // Enumeration is the object type used to execute a DNS enumeration.
 type Enumeration struct {
-	Config   *config.Config
-	Sys      systems.System
-	ctx      context.Context
-	graph    *netmap.Graph
-	srcs     []service.Service
-	done     chan struct{}
-	nameSrc  *enumSource
-	subTask  *subdomainTask
-	dnsTask  *dnsTask
-	valTask  *dnsTask
-	store    *dataManager
-	requests queue.Queue
-	plock    sync.Mutex
-	pending  bool
+	Config *config.Config
+	Sys    systems.System
+	// Backfill runs the selected data sources across the in-scope assets already in the
+	// graph database, instead of bringing the known names into the enumeration again
+	Backfill bool
+	// WaitForTasks keeps the enumeration running, once idle, for the tasks submitted until Finish is called
+	WaitForTasks bool
+	ctx          context.Context
+	graph        *netmap.Graph
+	srcs         []service.Service
+	done         chan struct{}
+	nameSrc      *enumSource
+	subTask      *subdomainTask
+	dnsTask      *dnsTask
+	valTask      *dnsTask
+	store        *dataManager
+	yield        *sourceYield
+	hits         *hitRates
+	findings     *findings.Log
+	sightings    *sightings.Log
+	abuse        *abuseLookups
+	reverse      *reverseLookups
+	history      *historyLookups
+	enrich       *enrichLookups
+	repoScan     *repos.Scanner
+	rules        *rules.Engine
+	certs        *certChecks
+	hosts        *hostCandidates
+	stealth      *stealthTiming
+	memory       *memoryGuard
+	ecs          *amassdns.ClientSubnets
+	probes       []*probe.Client
+	requests     queue.Queue
+	plock        sync.Mutex
+	pending      bool
+	tasks        int
+	accepting    bool
+	waiting      bool
+	envLock      sync.Mutex
+	envNames     map[string]struct{}
+	trashLock    sync.Mutex
 }
 
 // NewEnumeration returns an initialized Enumeration that has not been started yet.
@@ -58,11 +94,107 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	if err := e.Config.CheckSettings(); err != nil {
 		return err
 	}
+	var err error
+	// Setup the EDNS Client Subnet values used by the DNS queries
+	if e.ecs, err = clientSubnets(e.Config); err != nil {
+		return err
+	}
+	// The stealth timing profile and the randomize option add randomized delays to the queries and requests
+	e.stealth = newStealthTiming(e.Config)
+	// Setup the remote probes that provide additional vantage points
+	if e.probes, err = probesFromConfig(e.Config); err != nil {
+		return err
+	}
 	// This context, used throughout the enumeration, will provide the
 	// ability to pass the configuration and event bus to all the components
 	var cancel context.CancelFunc
 	e.ctx, cancel = context.WithCancel(ctx)
 	defer cancel()
+	// Mismatched probe releases are detected before the probes are tasked
+	for _, p := range e.probes {
+		if err := p.Negotiate(e.ctx); err != nil {
+			return err
+		}
+	}
+	// Findings about the assets are written alongside the graph database
+	policy, err := findings.ParsePolicy(e.Config.Options["severity"], func(name string) bool {
+		return e.Config.WhichDomain(name) == name
+	})
+	if err != nil {
+		return err
+	}
+	if l, err := findings.NewLog(filepath.Join(config.OutputDirectory(e.Config.Dir), findings.FileName)); err == nil {
+		l.SetPolicy(policy)
+		e.findings = l
+		defer func() { _ = l.Close() }()
+	}
+	// The data sources providing each name are kept, since the graph database only keeps the names
+	if l, err := sightings.NewLog(filepath.Join(config.OutputDirectory(e.Config.Dir), sightings.FileName)); err == nil {
+		e.sightings = l
+		defer func() { _ = l.Close() }()
+	}
+	// Abuse contacts are obtained for the root domain names and in-scope netblocks
+	if e.abuse == nil {
+		e.abuse = newAbuseLookups()
+	}
+	defer e.abuse.Wait()
+	// Passive DNS reveals the other domains historically hosted at the in-scope addresses
+	if e.reverse, err = newReverseLookups(e.Config); err != nil {
+		return err
+	} else if e.reverse != nil {
+		defer e.reverse.Wait()
+	}
+	// The DNS history providers reveal the past resolutions of the in-scope names
+	if e.history, err = newHistoryLookups(e.Config); err != nil {
+		return err
+	} else if e.history != nil {
+		defer e.history.Wait()
+	}
+	// The free endpoints of the internet scanning services provide the context of the in-scope addresses
+	if e.enrich, err = newEnrichLookups(e.Config); err != nil {
+		return err
+	} else if e.enrich != nil {
+		defer e.enrich.Wait()
+	}
+	// The public repositories of the target organization reference the infrastructure in their configuration files
+	if e.repoScan, err = newRepoScanner(e.Config); err != nil {
+		return err
+	}
+	// The transforms declared by the user are evaluated for each discovered asset
+	if raw, found := e.Config.Options["rules"]; found {
+		if e.rules, err = rules.Parse(raw); err != nil {
+			return err
+		}
+	}
+	// The risk weights are validated now, since the scores are only computed once the enumeration ends
+	if _, err := risk.ParseScorer(e.Config.Options["risk"]); err != nil {
+		return err
+	}
+	// The caps are applied by the data sources, which cannot report the errors of the option
+	if _, err := scripting.ParseCapSettings(e.Config.Options["source_caps"]); err != nil {
+		return err
+	}
+	// The SaaS platforms are checked for tenants named after the target organization
+	defer e.discoverTenants().Wait()
+	// The certificates served for the resolved names are checked during active enumerations
+	e.certs = newCertChecks()
+	defer e.certs.Wait()
+	// The discovered names are tried as virtual hosts on the in-scope addresses once the names are exhausted
+	e.hosts = newHostCandidates(e.Config)
+	for _, domain := range e.Config.Domains() {
+		e.lookupAbuseContacts(domain)
+	}
+	// Data sources that have historically provided unique names are queried first, and
+	// sources that never have are skipped when the enumeration has a time budget
+	e.yield = newSourceYield(e.Config)
+	_, budget := ctx.Deadline()
+	if srcs := e.yield.prioritize(e.srcs, e.Config.Domains(), budget); len(srcs) > 0 {
+		if skipped := len(e.srcs) - len(srcs); skipped > 0 && e.Config.Verbose {
+			e.Config.Log.Printf("Skipping %d data sources that have not provided unique names for the targets", skipped)
+		}
+		e.srcs = srcs
+	}
+	e.hits = newHitRates(e.Config)
 	go e.manageDataSrcRequests()
 
 	e.dnsTask = newDNSTask(e, false)
@@ -70,6 +202,9 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	e.store = newDataManager(e)
 	e.subTask = newSubdomainTask(e)
 	defer e.subTask.Stop()
+	// Load is shed when the heap approaches the memory budget, instead of exhausting the system
+	e.memory = newMemoryGuard(e)
+	defer e.memory.stop()
 	defer e.dnsTask.stop()
 	defer e.valTask.stop()
 
@@ -84,6 +219,11 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	// The pipeline input source will receive all the names
 	e.nameSrc = newEnumSource(p, e)
 	defer e.nameSrc.Stop()
+	// The tasks of external systems are accepted once the input source is setup
+	e.setAccepting(true)
+	defer e.setAccepting(false)
+	defer e.exposeQueueDepths()()
+	defer e.scanRepos().Wait()
 
 	e.submitASNs()
 	e.submitDomainNames()
@@ -92,15 +232,48 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	 * by the user and names acquired from the graph database can be brought
 	 * into the enumeration
 	 */
-	go e.submitKnownNames()
+	if e.Backfill {
+		go e.submitBackfill()
+	} else {
+		go e.submitKnownNames()
+	}
 	go e.submitProvidedNames()
 
-	err := p.ExecuteBuffered(e.ctx, e.nameSrc, e.makeOutputSink(), 50)
+	err = p.ExecuteBuffered(e.ctx, e.nameSrc, e.makeOutputSink(), 50)
+	if err == nil && !e.Backfill {
+		e.bruteForceSNI()
+		e.bruteForceVHosts()
+	}
 	// Ensure all data has been stored
 	<-e.store.Stop()
+	e.checkQuality()
+	e.refreshViews()
+	if serr := e.yield.save(e.srcs, e.Config.Domains()); serr != nil {
+		e.Config.Log.Printf("Failed to save the data source yield statistics: %v", serr)
+	}
+	for _, rate := range e.ScopeHitRates() {
+		e.Config.Log.Printf("%s: %d names observed, %d out of scope and discarded", rate.Source, rate.Observed, rate.Discarded())
+	}
+	e.saveSessionStats()
+	for _, src := range e.srcs {
+		if c, ok := src.(certificateCounter); ok {
+			if observed, collapsed := c.CertificateCounts(); observed > 0 {
+				e.Config.Log.Printf("%s: %d certificates observed, %d collapsed as precertificates or renewals", src.String(), observed, collapsed)
+			}
+		}
+	}
 	return err
 }
 
+// ScopeHitRates returns how many of the names returned by each data source were within the scope,
+// with the lowest ratio first. Data sources that did not return any names are not included.
+func (e *Enumeration) ScopeHitRates() []*SourceHitRate {
+	if e.hits == nil {
+		return nil
+	}
+	return e.hits.results(e.srcs)
+}
+
 // Release the root domain names to the input source and each data source.
 func (e *Enumeration) submitDomainNames() {
 	for _, domain := range e.Config.Domains() {
@@ -123,6 +296,9 @@ func (e *Enumeration) submitASNs() {
 }
 
 func (e *Enumeration) sendRequests(element interface{}) {
+	if e.memory.spillRequest(element) {
+		return
+	}
 	e.requests.Append(element)
 }
 
@@ -152,8 +328,8 @@ loop:
 				continue loop
 			}
 
-			for name := range nameToSrc {
-				if src := nameToSrc[name]; src != nil && src.HandlesReq(element) {
+			for _, src := range e.stealth.rotate(e.srcs) {
+				if name := src.String(); src.HandlesReq(element) {
 					if len(requestsMap[name]) == 0 && !pending[name] {
 						go e.fireRequest(src, element, finished)
 						pending[name] = true
@@ -180,7 +356,7 @@ func (e *Enumeration) requestsPending() bool {
 	e.plock.Lock()
 	defer e.plock.Unlock()
 
-	return e.pending
+	return e.pending || e.tasks > 0 || e.waiting
 }
 
 func (e *Enumeration) setRequestsPending(p map[string]bool) {
@@ -199,6 +375,8 @@ func (e *Enumeration) setRequestsPending(p map[string]bool) {
 }
 
 func (e *Enumeration) fireRequest(srv service.Service, req interface{}, finished chan string) {
+	e.stealth.delay(e.ctx)
+
 	select {
 	case <-e.done:
 	case <-e.ctx.Done():
@@ -208,6 +386,24 @@ func (e *Enumeration) fireRequest(srv service.Service, req interface{}, finished
 	finished <- srv.String()
 }
 
+func (e *Enumeration) addFinding(f *findings.Finding) {
+	if e.findings == nil {
+		return
+	}
+	if err := e.findings.Add(f); err != nil {
+		e.Config.Log.Printf("Failed to record the %s finding for %s: %v", f.Type, f.Asset, err)
+	}
+}
+
+func (e *Enumeration) addSighting(name, source string) {
+	if e.sightings == nil {
+		return
+	}
+	if err := e.sightings.Add(name, source); err != nil {
+		e.Config.Log.Printf("Failed to record the sighting of %s by %s: %v", name, source, err)
+	}
+}
+
 func (e *Enumeration) makeOutputSink() pipeline.SinkFunc {
 	return pipeline.SinkFunc(func(ctx context.Context, data pipeline.Data) error {
 		return nil
@@ -218,6 +414,10 @@ func (e *Enumeration) submitKnownNames() {
 	for _, g := range e.Sys.GraphDatabases() {
 		e.readNamesFromDatabase(g)
 	}
+	// The names confirmed by the previous sessions are verified and stored in the session database
+	if h := e.Sys.HistoryDatabase(); h != nil {
+		e.readNamesFromDatabase(h)
+	}
 }
 
 func (e *Enumeration) readNamesFromDatabase(db *netmap.Graph) {
@@ -0,0 +1,133 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/bench"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/net/rdap"
+	"github.com/owasp-amass/amass/v4/net/registry"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/resolve"
+)
+
+const benchScript = `
+	name="BenchAPI"
+	type="api"
+
+	function vertical(ctx, domain)
+		local resp, err = request(ctx, {['url']="%s%s?domain=" .. domain})
+		if (err ~= nil and err ~= "") then
+			log(ctx, "vertical request to service failed: " .. err)
+			return
+		end
+
+		send_names(ctx, resp.body)
+	end
+`
+
+// newBenchSystem returns a system that only uses the synthetic target for DNS resolution and data sources.
+func newBenchSystem(b *testing.B, cfg *config.Config, target *bench.Target) *systems.SimpleSystem {
+	sys := &systems.SimpleSystem{
+		Cfg:      cfg,
+		Pool:     resolve.NewResolvers(),
+		Trusted:  resolve.NewResolvers(),
+		Graph:    netmap.NewGraph("local", filepath.Join(cfg.Dir, "amass.sqlite"), ""),
+		ASNCache: requests.NewASNCache(),
+	}
+
+	for _, pool := range []*resolve.Resolvers{sys.Pool, sys.Trusted} {
+		pool.SetLogger(cfg.Log)
+		_ = pool.AddResolvers(1000, target.DNSAddr())
+		pool.SetDetectionResolver(1000, target.DNSAddr())
+	}
+
+	src := scripting.NewScript(fmt.Sprintf(benchScript, target.APIURL(), bench.NamesPath), sys)
+	if src == nil {
+		b.Fatal("Failed to load the benchmark data source")
+	}
+	if err := sys.AddAndStart(src); err != nil {
+		b.Fatalf("Failed to start the benchmark data source: %v", err)
+	}
+	return sys
+}
+
+// BenchmarkEnumeration measures the engine against a synthetic target of 1000 subdomains.
+func BenchmarkEnumeration(b *testing.B) {
+	target, err := bench.NewTarget("example.com", 1000)
+	if err != nil {
+		b.Fatalf("Failed to start the synthetic target: %v", err)
+	}
+	defer target.Close()
+
+	stats := bench.Start()
+	stats.StopTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		cfg := config.NewConfig()
+		cfg.Dir = b.TempDir()
+		cfg.AddDomain(target.Domain)
+
+		sys := newBenchSystem(b, cfg, target)
+		e := NewEnumeration(cfg, sys, sys.Graph)
+		e.abuse = newAbuseLookups()
+		e.abuse.client = &rdap.Client{
+			BaseURL: target.APIURL(),
+			HTTP:    e.abuse.client.HTTP,
+			Queue:   registry.NewQueue(),
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		b.StartTimer()
+		stats.StartTimer()
+		err := e.Start(ctx)
+		stats.StopTimer()
+		b.StopTimer()
+		cancel()
+		if err != nil {
+			b.Fatalf("The enumeration failed: %v", err)
+		}
+
+		names, addrs := countStored(sys.Graph, target.Domain)
+		if names < len(target.Names) {
+			b.Errorf("Only %d of the %d names were stored", names, len(target.Names))
+		}
+		stats.AddEvents(names)
+		stats.AddWrites(names + addrs)
+		_ = sys.Shutdown()
+	}
+	stats.Report(b)
+}
+
+func countStored(g *netmap.Graph, d string) (int, int) {
+	assets, err := g.DB.FindByScope([]oam.Asset{domain.FQDN{Name: d}}, time.Time{})
+	if err != nil {
+		return 0, 0
+	}
+
+	var names []string
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok {
+			names = append(names, fqdn.Name)
+		}
+	}
+
+	pairs, err := g.NamesToAddrs(context.Background(), time.Time{}, names...)
+	if err != nil {
+		return len(names), 0
+	}
+	return len(names), len(pairs)
+}
@@ -0,0 +1,53 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+
+	"github.com/owasp-amass/amass/v4/classify"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// checkEnvironment records a finding when the resolved name appears to belong to a development
+// or staging environment. These hosts are commonly forgotten and often less hardened than production.
+func (e *Enumeration) checkEnvironment(req *requests.DNSRequest) {
+	if len(req.Records) == 0 || req.Domain == "" {
+		return
+	}
+
+	env, prod := classify.Environment(req.Name, req.Domain)
+	if env == "" {
+		return
+	}
+
+	e.envLock.Lock()
+	if e.envNames == nil {
+		e.envNames = make(map[string]struct{})
+	}
+	_, found := e.envNames[req.Name]
+	e.envNames[req.Name] = struct{}{}
+	e.envLock.Unlock()
+	if found {
+		return
+	}
+
+	label := "development"
+	if env == classify.TagStaging {
+		label = "staging"
+	}
+	e.addFinding(&findings.Finding{
+		Asset:    req.Name,
+		Type:     "environment",
+		Severity: findings.SeverityMedium,
+		Title:    fmt.Sprintf("Externally resolvable %s environment", label),
+		Details:  fmt.Sprintf("The name resolves publicly and appears to be a %s environment of %s", label, prod),
+		Attributes: map[string]string{
+			"environment": env,
+			"production":  prod,
+		},
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+// SourceHitRate is the number of names returned by a data source, and how many were within the scope.
+type SourceHitRate struct {
+	Source string
+	// Observed is the number of names found by the data source, including duplicates and those out of scope
+	Observed int
+	// InScope is the number of names found within the scope, including duplicates
+	InScope int
+}
+
+// Ratio returns the fraction of the names found by the data source that were within the scope.
+func (s *SourceHitRate) Ratio() float64 {
+	if s.Observed == 0 {
+		return 0
+	}
+	return float64(s.InScope) / float64(s.Observed)
+}
+
+// Discarded returns the number of names found by the data source that were out of scope.
+func (s *SourceHitRate) Discarded() int {
+	return s.Observed - s.InScope
+}
+
+// namesObserver is implemented by the data sources that count the names found out of scope.
+type namesObserver interface {
+	NamesObserved() int
+}
+
+// certificateCounter is implemented by the data sources that collapse the certificate transparency entries.
+type certificateCounter interface {
+	CertificateCounts() (observed, collapsed int)
+}
+
+// hitRates tracks the names returned by each data source during the enumeration.
+type hitRates struct {
+	sync.Mutex
+	cfg   *config.Config
+	rates map[string]*SourceHitRate
+}
+
+func newHitRates(cfg *config.Config) *hitRates {
+	return &hitRates{
+		cfg:   cfg,
+		rates: make(map[string]*SourceHitRate),
+	}
+}
+
+// record counts the name returned by the data source, and whether it was within the scope.
+func (h *hitRates) record(src string, req *requests.DNSRequest) {
+	if req.Name == "" {
+		return
+	}
+
+	requests.SanitizeDNSRequest(req)
+	inscope := h.cfg.IsDomainInScope(req.Name) && !h.cfg.Blacklisted(req.Name)
+
+	h.Lock()
+	defer h.Unlock()
+
+	rate, found := h.rates[src]
+	if !found {
+		rate = &SourceHitRate{Source: src}
+		h.rates[src] = rate
+	}
+
+	rate.Observed++
+	if inscope {
+		rate.InScope++
+	}
+}
+
+// results returns the hit rates for the data sources, with the lowest ratio first.
+func (h *hitRates) results(srcs []service.Service) []*SourceHitRate {
+	h.Lock()
+	defer h.Unlock()
+
+	var results []*SourceHitRate
+	for _, src := range srcs {
+		rate := &SourceHitRate{Source: src.String()}
+		if r, found := h.rates[src.String()]; found {
+			*rate = *r
+		}
+		// Include the names the data source discarded before they reached the enumeration
+		if o, ok := src.(namesObserver); ok && o.NamesObserved() > rate.Observed {
+			rate.Observed = o.NamesObserved()
+		}
+		if rate.Observed > 0 {
+			results = append(results, rate)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Ratio() < results[j].Ratio()
+	})
+	return results
+}
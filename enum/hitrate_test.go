@@ -0,0 +1,36 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestHitRates(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	h := newHitRates(cfg)
+	for _, name := range []string{"www.owasp.org", "MAIL.OWASP.ORG.", "www.example.com", ""} {
+		h.record("Junk", &requests.DNSRequest{Name: name})
+	}
+	h.record("Precise", &requests.DNSRequest{Name: "dev.owasp.org"})
+
+	srcs := []service.Service{newNamedSource("Precise"), newNamedSource("Junk"), newNamedSource("Empty")}
+	rates := h.results(srcs)
+	if len(rates) != 2 {
+		t.Fatalf("Expected two hit rates, got %d", len(rates))
+	}
+	if r := rates[0]; r.Source != "Junk" || r.Observed != 3 || r.InScope != 2 || r.Discarded() != 1 {
+		t.Errorf("Unexpected hit rate for the Junk data source: %+v", r)
+	}
+	if r := rates[1]; r.Source != "Precise" || r.Ratio() != 1 {
+		t.Errorf("Unexpected hit rate for the Precise data source: %+v", r)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// maxHostCandidates is the number of names from each root domain tried against the addresses
+const maxHostCandidates = 1000
+
+// hostCandidates collects the names discovered for each root domain and the names resolved to each in-scope
+// address, so the discovered names can be tried as virtual hosts on the addresses they did not resolve to.
+type hostCandidates struct {
+	sync.Mutex
+	candidates map[string][]string
+	seen       map[string]struct{}
+	bound      map[string]map[string]struct{}
+	domains    map[string]map[string]struct{}
+}
+
+func newHostCandidates(cfg *config.Config) *hostCandidates {
+	if !sniEnabled(cfg) && !vhostEnabled(cfg) {
+		return nil
+	}
+
+	return &hostCandidates{
+		candidates: make(map[string][]string),
+		seen:       make(map[string]struct{}),
+		bound:      make(map[string]map[string]struct{}),
+		domains:    make(map[string]map[string]struct{}),
+	}
+}
+
+// addCandidate records the discovered name as a virtual host for the addresses within the root domain.
+func (s *hostCandidates) addCandidate(name, domain string) {
+	if s == nil || name == "" || domain == "" {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, found := s.seen[name]; found || len(s.candidates[domain]) >= maxHostCandidates {
+		return
+	}
+	s.seen[name] = struct{}{}
+	s.candidates[domain] = append(s.candidates[domain], name)
+}
+
+// addBinding records the name resolved to the address, which is not tried again as a virtual host on it.
+func (s *hostCandidates) addBinding(name, domain, addr string) {
+	if s == nil || name == "" || addr == "" {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, found := s.bound[addr]; !found {
+		s.bound[addr] = make(map[string]struct{})
+		s.domains[addr] = make(map[string]struct{})
+	}
+	s.bound[addr][name] = struct{}{}
+	if domain != "" {
+		s.domains[addr][domain] = struct{}{}
+	}
+}
+
+// targets returns the names to be tried against each address, which are the candidates of the root domains
+// resolved to the address that are not already resolved to it. The labels generate additional names within
+// each root domain.
+func (s *hostCandidates) targets(labels []string) map[string][]string {
+	s.Lock()
+	defer s.Unlock()
+
+	results := make(map[string][]string)
+	for addr, domains := range s.domains {
+		for domain := range domains {
+			names := s.candidates[domain]
+			for _, label := range labels {
+				if name := label + "." + domain; !s.has(domain, name) {
+					names = append(names, name)
+				}
+			}
+
+			for _, name := range names {
+				if _, found := s.bound[addr][name]; !found {
+					results[addr] = append(results[addr], name)
+				}
+			}
+		}
+		sort.Strings(results[addr])
+	}
+	return results
+}
+
+func (s *hostCandidates) has(domain, name string) bool {
+	for _, n := range s.candidates[domain] {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
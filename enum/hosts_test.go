@@ -0,0 +1,44 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestHostCandidates(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Active = true
+	cfg.Options["sni_bruteforce"] = true
+
+	s := newHostCandidates(cfg)
+	s.addCandidate("www.owasp.org", "owasp.org")
+	s.addCandidate("hidden.owasp.org", "owasp.org")
+	s.addCandidate("dev.owasp.org", "owasp.org")
+	s.addCandidate("www.example.com", "example.com")
+	s.addBinding("www.owasp.org", "owasp.org", "192.0.2.1")
+	s.addBinding("dev.owasp.org", "owasp.org", "192.0.2.2")
+
+	targets := s.targets(nil)
+	if want := []string{"dev.owasp.org", "hidden.owasp.org"}; !reflect.DeepEqual(targets["192.0.2.1"], want) {
+		t.Errorf("Expected %v for the first address, got %v", want, targets["192.0.2.1"])
+	}
+	if want := []string{"hidden.owasp.org", "www.owasp.org"}; !reflect.DeepEqual(targets["192.0.2.2"], want) {
+		t.Errorf("Expected %v for the second address, got %v", want, targets["192.0.2.2"])
+	}
+
+	generated := s.targets([]string{"www", "admin"})
+	if want := []string{"admin.owasp.org", "dev.owasp.org", "hidden.owasp.org"}; !reflect.DeepEqual(generated["192.0.2.1"], want) {
+		t.Errorf("Expected %v with the generated names, got %v", want, generated["192.0.2.1"])
+	}
+
+	cfg.Active = false
+	if newHostCandidates(cfg) != nil {
+		t.Error("Expected the candidates to require an active enumeration")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/caffix/queue"
 	"github.com/caffix/service"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/rules"
 	bf "github.com/tylertreat/BoomFilters"
 )
 
@@ -74,29 +75,67 @@ func (r *enumSource) markDone() {
 	})
 }
 
-func (r *enumSource) newName(req *requests.DNSRequest) {
+// newName returns true when the name had not been seen before in the enumeration.
+func (r *enumSource) newName(req *requests.DNSRequest) bool {
+	return r.newNameFrom(req, "")
+}
+
+// newNameFrom returns true when the name provided by the source had not been seen before in the enumeration.
+func (r *enumSource) newNameFrom(req *requests.DNSRequest, source string) bool {
 	select {
 	case <-r.done:
-		return
+		return false
 	default:
 	}
 
 	if req.Name == "" || !req.Valid() {
 		r.releaseOutput(1)
-		return
+		return false
 	}
 	// Clean up the newly discovered name and domain
 	requests.SanitizeDNSRequest(req)
 
 	if r.enum.Config.Blacklisted(req.Name) {
 		r.releaseOutput(1)
-		return
+		return false
+	}
+
+	res := r.enum.rules.Evaluate(&rules.Event{
+		Type:   rules.TypeFQDN,
+		Value:  req.Name,
+		Domain: req.Domain,
+		Source: source,
+	})
+	if res.Drop {
+		r.releaseOutput(1)
+		return false
+	}
+	// Every data source providing the name is recorded, including those after the first
+	if source != "" && source != rules.SourceRules {
+		r.enum.addSighting(req.Name, source)
 	}
 	if !r.accept(req.Name) {
 		r.releaseOutput(1)
-		return
+		return false
+	}
+	r.enum.hosts.addCandidate(req.Name, req.Domain)
+	if !r.enum.memory.spillName(req) {
+		r.queue.Append(req)
+	}
+	r.enqueueNames(res.Names)
+	return true
+}
+
+// enqueueNames submits the in-scope names produced by the rules.
+func (r *enumSource) enqueueNames(names []string) {
+	for _, name := range names {
+		if domain := r.enum.Config.WhichDomain(name); domain != "" {
+			r.newNameFrom(&requests.DNSRequest{
+				Name:   name,
+				Domain: domain,
+			}, rules.SourceRules)
+		}
 	}
-	r.queue.Append(req)
 }
 
 func (r *enumSource) newAddr(req *requests.AddrRequest) {
@@ -106,17 +145,26 @@ func (r *enumSource) newAddr(req *requests.AddrRequest) {
 	default:
 	}
 
-	if req.Valid() && req.InScope && r.accept(req.Address) {
+	if req.Valid() && req.InScope && !r.dropAddr(req.Address) && r.accept(req.Address) && !r.enum.memory.spillName(req) {
 		r.queue.Append(req)
 	}
 }
 
+// dropAddr returns true when the address is discarded by the rules.
+func (r *enumSource) dropAddr(addr string) bool {
+	return r.enum.rules.Evaluate(&rules.Event{
+		Type:  rules.TypeIP,
+		Value: addr,
+	}).Drop
+}
+
 func (r *enumSource) accept(s string) bool {
 	return !r.filter.TestAndAdd([]byte(s))
 }
 
 // Next implements the pipeline InputSource interface.
 func (r *enumSource) Next(ctx context.Context) bool {
+	r.restoreSpilled()
 	// Low if below 75%
 	if p := (float32(r.queue.Len()) / float32(r.max)) * 100; p < 75 {
 		r.fillQueue()
@@ -134,12 +182,13 @@ func (r *enumSource) Next(ctx context.Context) bool {
 			return false
 		case <-t.C:
 			count := r.pipeline.DataItemCount()
-			if !r.enum.requestsPending() && count <= 0 {
+			if !r.enum.requestsPending() && count <= 0 && r.enum.memory.spilled() == 0 {
 				if r.enum.store.queue.Len() == 0 {
 					r.markDone()
 					return false
 				}
 			}
+			r.restoreSpilled()
 			r.fillQueue()
 			t.Reset(waitForDuration)
 		case <-r.queue.Signal():
@@ -163,6 +212,21 @@ func (r *enumSource) Error() error {
 	return nil
 }
 
+// restoreSpilled moves the names spilled to disk back into the queue as room becomes available.
+func (r *enumSource) restoreSpilled() {
+	room := r.max - r.queue.Len()
+	// While load is shed, names are only restored to keep the pipeline from going idle
+	if r.enum.memory.shedding() {
+		if r.queue.Len() > 0 {
+			return
+		}
+		room = r.max/10 + 1
+	}
+	r.enum.memory.restoreNames(room, func(element interface{}) {
+		r.queue.Append(element)
+	})
+}
+
 func (r *enumSource) fillQueue() {
 	if unfilled := r.max - r.queue.Len(); unfilled > 0 {
 		if fill := unfilled - len(r.release); fill > 0 {
@@ -200,7 +264,10 @@ func (r *enumSource) monitorDataSrcOutput(srv service.Service) {
 
 			switch req := in.(type) {
 			case *requests.DNSRequest:
-				r.newName(req)
+				r.enum.hits.record(srv.String(), req)
+				if r.newNameFrom(req, srv.String()) {
+					r.enum.yield.record(srv.String(), req.Domain)
+				}
 			case *requests.AddrRequest:
 				r.newAddr(req)
 			}
@@ -0,0 +1,149 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// writeAttempts is the number of times a write to the graph database is attempted before it is abandoned
+	writeAttempts = 4
+	// writeBackoff is the delay before the first retry of a write, which doubles with each further retry
+	writeBackoff = 250 * time.Millisecond
+	// committedKeys is the number of committed idempotency keys remembered, so long enumerations do not keep
+	// every key. A write whose key was forgotten is applied again, which the upserts of the database absorb
+	committedKeys = 100000
+)
+
+// writeLedger assigns the monotonic sequence numbers to the write events of the enumeration, and tracks
+// the recently used idempotency keys of the writes committed to the graph database. The upserts only avoid duplicate
+// assets and relations when their lookups succeed, so a write is not retried until the database answers
+// reads again, and the writes sharing a key are never in flight at the same time.
+type writeLedger struct {
+	sync.Mutex
+	seq uint64
+	// committed finds the elements of the keys in order, which lists the least recently used keys last
+	committed map[string]*list.Element
+	order     *list.List
+	limit     int
+	inflight  map[string]chan struct{}
+	// ready reports whether the graph database answers reads before a write is retried
+	ready   func(ctx context.Context) error
+	backoff time.Duration
+}
+
+func newWriteLedger(ready func(ctx context.Context) error) *writeLedger {
+	return &writeLedger{
+		committed: make(map[string]*list.Element),
+		order:     list.New(),
+		limit:     committedKeys,
+		inflight:  make(map[string]chan struct{}),
+		ready:     ready,
+		backoff:   writeBackoff,
+	}
+}
+
+// Write applies the write identified by the idempotency key exactly once during the enumeration,
+// and retries it after the transient database errors.
+func (l *writeLedger) Write(ctx context.Context, key string, write func() error) error {
+	seq, claimed := l.claim(ctx, key)
+	if !claimed {
+		return nil
+	}
+
+	err := write()
+	for attempt := 1; err != nil && attempt < writeAttempts; attempt++ {
+		if !sleep(ctx, l.backoff<<(attempt-1)) {
+			break
+		}
+		if l.ready != nil {
+			if e := l.ready(ctx); e != nil {
+				err = e
+				continue
+			}
+		}
+		err = write()
+	}
+
+	l.release(key, err == nil)
+	if err != nil {
+		return fmt.Errorf("write event %d (%s) failed after the retries: %v", seq, key, err)
+	}
+	return nil
+}
+
+// Seq returns the sequence number assigned to the last write event.
+func (l *writeLedger) Seq() uint64 {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.seq
+}
+
+// claim returns the sequence number of the write event when it must be performed,
+// after waiting for another write with the same key that is in flight.
+func (l *writeLedger) claim(ctx context.Context, key string) (uint64, bool) {
+	for {
+		l.Lock()
+		if e, found := l.committed[key]; found {
+			l.order.MoveToFront(e)
+			l.Unlock()
+			return 0, false
+		}
+
+		ch, busy := l.inflight[key]
+		if !busy {
+			l.seq++
+			seq := l.seq
+			l.inflight[key] = make(chan struct{})
+			l.Unlock()
+			return seq, true
+		}
+		l.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return 0, false
+		case <-ch:
+		}
+	}
+}
+
+func (l *writeLedger) release(key string, committed bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	if committed {
+		l.committed[key] = l.order.PushFront(key)
+		// The least recently used key is forgotten once the limit is reached
+		if l.order.Len() > l.limit {
+			last := l.order.Back()
+			l.order.Remove(last)
+			delete(l.committed, last.Value.(string))
+		}
+	}
+	if ch, found := l.inflight[key]; found {
+		close(ch)
+		delete(l.inflight, key)
+	}
+}
+
+// sleep returns false when the enumeration is cancelled before the delay has passed.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteLedgerRetries(t *testing.T) {
+	var reads int
+	l := newWriteLedger(func(ctx context.Context) error {
+		reads++
+		if reads == 1 {
+			return errors.New("the database is not answering")
+		}
+		return nil
+	})
+	l.backoff = time.Millisecond
+
+	var writes int
+	write := func() error {
+		writes++
+		if writes == 1 {
+			return errors.New("the connection was reset")
+		}
+		return nil
+	}
+
+	if err := l.Write(context.Background(), "A|www.owasp.org|192.0.2.1", write); err != nil {
+		t.Fatalf("The write was not retried: %v", err)
+	}
+	// The write is not attempted again while the database is not answering reads
+	if writes != 2 || reads != 2 {
+		t.Errorf("Expected 2 writes and 2 reads, got %d writes and %d reads", writes, reads)
+	}
+	if err := l.Write(context.Background(), "A|www.owasp.org|192.0.2.1", write); err != nil || writes != 2 {
+		t.Errorf("The committed write was applied again: %v", err)
+	}
+	if seq := l.Seq(); seq != 1 {
+		t.Errorf("Expected the sequence number 1, got %d", seq)
+	}
+
+	fail := func() error { return errors.New("the disk is full") }
+	if err := l.Write(context.Background(), "A|www.owasp.org|192.0.2.2", fail); err == nil {
+		t.Error("Expected an error after the retries")
+	}
+	// The failed write can be attempted again by a later event
+	if err := l.Write(context.Background(), "A|www.owasp.org|192.0.2.2", write); err != nil || l.Seq() != 3 {
+		t.Errorf("The write was not attempted by the later event: %v", err)
+	}
+}
+
+func TestWriteLedgerConcurrentWrites(t *testing.T) {
+	l := newWriteLedger(nil)
+
+	var lock sync.Mutex
+	var writes int
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_ = l.Write(context.Background(), "CNAME|www.owasp.org|owasp.org", func() error {
+				lock.Lock()
+				writes++
+				lock.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if writes != 1 {
+		t.Errorf("Expected the write to be applied once, got %d", writes)
+	}
+}
+
+func TestWriteLedgerCancelled(t *testing.T) {
+	l := newWriteLedger(nil)
+	l.backoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var writes int
+	err := l.Write(ctx, "NS|owasp.org|ns1.owasp.org", func() error {
+		writes++
+		return errors.New("the connection was reset")
+	})
+	if err == nil || writes != 1 {
+		t.Errorf("Expected a single write and an error, got %d writes: %v", writes, err)
+	}
+}
+
+func TestWriteLedgerEviction(t *testing.T) {
+	l := newWriteLedger(nil)
+	l.limit = 2
+
+	var writes int
+	write := func() error {
+		writes++
+		return nil
+	}
+
+	for _, key := range []string{"A|a.owasp.org|192.0.2.1", "A|b.owasp.org|192.0.2.2", "A|a.owasp.org|192.0.2.1", "A|c.owasp.org|192.0.2.3"} {
+		_ = l.Write(context.Background(), key, write)
+	}
+	if writes != 3 || len(l.committed) != 2 || l.order.Len() != 2 {
+		t.Fatalf("Expected 3 writes and 2 keys remembered, got %d writes and %d keys", writes, len(l.committed))
+	}
+	// The least recently used key was forgotten, so its write is applied again
+	_ = l.Write(context.Background(), "A|a.owasp.org|192.0.2.1", write)
+	_ = l.Write(context.Background(), "A|b.owasp.org|192.0.2.2", write)
+	if writes != 4 {
+		t.Errorf("Expected only the forgotten key to be written again, got %d writes", writes)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	memoryCheckInterval = 2 * time.Second
+	// Load is shed once the heap reaches this percentage of the budget
+	memoryShedPercent = 90
+	// Normal operation resumes once the heap drops below this percentage of the budget
+	memoryResumePercent = 70
+	// The number of spilled data source requests restored during each check
+	maxRestoredRequests = 1000
+)
+
+// MemoryBudget returns the memory_limit option of the configuration in bytes, or zero when not set.
+func MemoryBudget(cfg *config.Config) uint64 {
+	if mb, ok := cfg.Options["memory_limit"].(int); ok && mb > 0 {
+		return uint64(mb) << 20
+	}
+	return 0
+}
+
+// memoryGuard keeps the enumeration within the memory budget by pausing brute forcing and
+// alterations, spilling the queued names to disk and shrinking caches while the heap is too large.
+type memoryGuard struct {
+	enum     *Enumeration
+	limit    uint64
+	prev     int64
+	pressure int32
+	names    *spillQueue
+	reqs     *spillQueue
+	done     chan struct{}
+}
+
+func newMemoryGuard(e *Enumeration) *memoryGuard {
+	limit := MemoryBudget(e.Config)
+	if limit == 0 {
+		return nil
+	}
+
+	dir := config.OutputDirectory(e.Config.Dir)
+	names, err := newSpillQueue(dir, "spill-names-*.jsonl")
+	if err != nil {
+		e.Config.Log.Printf("Failed to create the spill file for the memory budget: %v", err)
+		return nil
+	}
+	reqs, err := newSpillQueue(dir, "spill-requests-*.jsonl")
+	if err != nil {
+		names.Close()
+		e.Config.Log.Printf("Failed to create the spill file for the memory budget: %v", err)
+		return nil
+	}
+
+	limit64 := int64(math.MaxInt64)
+	if limit < uint64(limit64) {
+		limit64 = int64(limit)
+	}
+
+	m := &memoryGuard{
+		enum:  e,
+		limit: limit,
+		// The garbage collector also works harder as the budget is approached
+		prev:  debug.SetMemoryLimit(limit64),
+		names: names,
+		reqs:  reqs,
+		done:  make(chan struct{}),
+	}
+
+	go m.run()
+	return m
+}
+
+func (m *memoryGuard) stop() {
+	if m == nil {
+		return
+	}
+
+	close(m.done)
+	debug.SetMemoryLimit(m.prev)
+	m.names.Close()
+	m.reqs.Close()
+}
+
+// shedding returns true while the enumeration is shedding load to remain within the budget.
+func (m *memoryGuard) shedding() bool {
+	return m != nil && atomic.LoadInt32(&m.pressure) == 1
+}
+
+func (m *memoryGuard) run() {
+	t := time.NewTicker(memoryCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-m.enum.ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+
+		if heap := stats.HeapAlloc; !m.shedding() && heap >= m.limit*memoryShedPercent/100 {
+			atomic.StoreInt32(&m.pressure, 1)
+			m.enum.Config.Log.Printf("The heap (%d MB) is approaching the memory budget: pausing brute forcing "+
+				"and alterations, spilling queued names to disk and shrinking caches", heap>>20)
+			m.shrinkCaches()
+		} else if m.shedding() && heap < m.limit*memoryResumePercent/100 {
+			atomic.StoreInt32(&m.pressure, 0)
+			m.enum.Config.Log.Printf("The heap (%d MB) is within the memory budget: resuming normal operation", heap>>20)
+		}
+
+		if !m.shedding() {
+			m.restoreRequests(maxRestoredRequests)
+		}
+	}
+}
+
+func (m *memoryGuard) shrinkCaches() {
+	// The CNAME subdomains are only remembered to avoid sending duplicate requests
+	if st := m.enum.subTask; st != nil {
+		for _, sub := range st.cnames.Slice() {
+			st.cnames.Remove(sub)
+		}
+	}
+	debug.FreeOSMemory()
+}
+
+// spillName writes the name or address to disk when load is being shed, and returns true if it was spilled.
+func (m *memoryGuard) spillName(element interface{}) bool {
+	if !m.shedding() {
+		return false
+	}
+	return m.names.Append(element) == nil
+}
+
+// spillRequest writes the data source requests that drive brute forcing and alterations to disk when load is
+// being shed, and returns true if it was spilled. The requests are sent once the heap is within the budget.
+func (m *memoryGuard) spillRequest(element interface{}) bool {
+	if !m.shedding() {
+		return false
+	}
+
+	switch element.(type) {
+	case *requests.SubdomainRequest, *requests.ResolvedRequest:
+		return m.reqs.Append(element) == nil
+	}
+	return false
+}
+
+func (m *memoryGuard) restoreRequests(num int) {
+	for i := 0; i < num; i++ {
+		element, ok := m.reqs.Next()
+		if !ok {
+			return
+		}
+		m.enum.requests.Append(element)
+	}
+}
+
+// restoreNames moves up to num spilled names and addresses back into the queue.
+func (m *memoryGuard) restoreNames(num int, fn func(interface{})) {
+	if m == nil {
+		return
+	}
+
+	for i := 0; i < num; i++ {
+		element, ok := m.names.Next()
+		if !ok {
+			return
+		}
+		fn(element)
+	}
+}
+
+// spilled returns the number of names, addresses and requests currently on disk.
+func (m *memoryGuard) spilled() int {
+	if m == nil {
+		return 0
+	}
+	return m.names.Len() + m.reqs.Len()
+}
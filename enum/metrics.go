@@ -0,0 +1,38 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import "github.com/owasp-amass/amass/v4/metrics"
+
+// exposeQueueDepths provides the depth of the enumeration queues with the runtime metrics,
+// and returns the function that removes them once the enumeration has completed.
+func (e *Enumeration) exposeQueueDepths() func() {
+	gauges := map[string]struct {
+		help string
+		fn   func() float64
+	}{
+		"amass_names_queued": {
+			"Names waiting to enter the enumeration pipeline",
+			func() float64 { return float64(e.nameSrc.queue.Len()) },
+		},
+		"amass_datasource_requests_queued": {
+			"Requests waiting to be sent to the data sources",
+			func() float64 { return float64(e.requests.Len()) },
+		},
+		"amass_infrastructure_requests_queued": {
+			"Addresses waiting for the netblock and ASN information",
+			func() float64 { return float64(e.store.queue.Len()) },
+		},
+	}
+
+	for name, g := range gauges {
+		metrics.Default.SetGauge(name, g.help, g.fn)
+	}
+	return func() {
+		for name := range gauges {
+			metrics.Default.SetGauge(name, "", nil)
+		}
+	}
+}
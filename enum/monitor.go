@@ -5,6 +5,7 @@
 package enum
 
 import (
+	"fmt"
 	"net/netip"
 	"strings"
 
@@ -76,8 +77,13 @@ func (e *Enumeration) checkForMissedWildcards(addr string) {
 		}
 
 		e.Config.BlacklistSubdomain(sub)
+		bin, done := e.trashBin("wildcard cleanup", fmt.Sprintf("%d names under %s resolved to %s", len(assets), sub, addr))
+		if bin == nil {
+			continue
+		}
 		for _, id := range assets {
-			_ = e.graph.DB.DeleteAsset(id)
+			_ = bin.DeleteAsset(id)
 		}
+		done()
 	}
 }
@@ -0,0 +1,67 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/quality"
+	"github.com/owasp-amass/config/config"
+)
+
+// qualityMode returns the mode selected by the quality_check option: an empty string when the check
+// is not performed, "report" when the issues are only logged, and "repair" when they are also repaired.
+func qualityMode(cfg *config.Config) string {
+	switch v := cfg.Options["quality_check"].(type) {
+	case bool:
+		if v {
+			return "report"
+		}
+	case string:
+		if m := strings.ToLower(v); m == "report" || m == "repair" {
+			return m
+		}
+	}
+	return ""
+}
+
+// checkQuality checks the graph database for issues once the enumeration has stored all of its data,
+// so the databases reused by repeated enumerations and monitoring remain clean.
+func (e *Enumeration) checkQuality() {
+	mode := qualityMode(e.Config)
+	if mode == "" {
+		return
+	}
+
+	g, err := quality.Load(e.ctx, e.graph.DB)
+	if err != nil {
+		e.Config.Log.Printf("Failed to check the quality of the graph database: %v", err)
+		return
+	}
+
+	report := quality.Check(g)
+	if mode == "repair" {
+		// The removals are kept in the trash, so a repair made by mistake can be restored
+		if bin, done := e.trashBin("quality_check repair", ""); bin != nil {
+			bin.Known(g.Relations)
+			if _, err := quality.Repair(e.ctx, bin, g, report); err != nil {
+				e.Config.Log.Printf("Failed to repair the graph database: %v", err)
+			}
+			done()
+		}
+	}
+
+	for _, i := range report.Issues {
+		state := "found"
+		if i.Repaired {
+			state = "repaired"
+		}
+		e.Config.Log.Printf("Quality: %s %s: %s %s %s", state, i.Type, i.Asset, i.Relation, i.Detail)
+	}
+	counts := report.Counts()
+	e.Config.Log.Printf("Quality: %d assets and %d relations checked, %d orphaned assets, %d invalid relations, %d duplicate relations, %d duplicate assets",
+		report.Assets, report.Relations, counts[quality.IssueOrphanedAsset], counts[quality.IssueInvalidRelation],
+		counts[quality.IssueDuplicateRelation], counts[quality.IssueDuplicateAsset])
+}
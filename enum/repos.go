@@ -0,0 +1,116 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/repos"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/saas"
+	"github.com/owasp-amass/config/config"
+)
+
+// repoScanSource is the source recorded in the sightings of the names found in the repositories.
+const repoScanSource = "GitHub Repositories"
+
+// newRepoScanner returns the scanner of the public repositories selected by the repo_scan option, or nil when
+// the option is not provided or disables the scanning. The API key of the GitHub data source raises the rate limit.
+func newRepoScanner(cfg *config.Config) (*repos.Scanner, error) {
+	raw, found := cfg.Options["repo_scan"]
+	if !found {
+		return nil, nil
+	}
+
+	s, err := repos.ParseSettings(raw)
+	if err != nil || s == nil {
+		return nil, err
+	}
+
+	var token string
+	if dsc := cfg.GetDataSourceConfig("GitHub"); dsc != nil {
+		for _, creds := range dsc.Creds {
+			if creds != nil && creds.Apikey != "" {
+				token = creds.Apikey
+				break
+			}
+		}
+	}
+
+	return repos.NewScanner(s, &repos.Scope{
+		Name: func(name string) bool {
+			return cfg.IsDomainInScope(name) && !cfg.Blacklisted(name)
+		},
+		Address: cfg.IsAddressInScope,
+	}, token), nil
+}
+
+// scanRepos scans the public repositories of the organizations provided by the repo_scan option, or named
+// after the root domains, for references to the infrastructure in scope. Each reference is recorded in the
+// findings with where it was found, and the names and addresses are brought into the enumeration. The scan
+// is counted as a task, so the enumeration does not end before the repositories have been scanned.
+func (e *Enumeration) scanRepos() *sync.WaitGroup {
+	var wg sync.WaitGroup
+
+	if e.repoScan == nil || !e.startTask() {
+		return &wg
+	}
+
+	orgs := e.repoScan.Settings.Orgs
+	if len(orgs) == 0 {
+		seen := make(map[string]struct{})
+		for _, domain := range e.Config.Domains() {
+			for _, name := range saas.TenantNames(domain) {
+				if _, found := seen[name]; !found {
+					seen[name] = struct{}{}
+					orgs = append(orgs, name)
+				}
+			}
+		}
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer e.finishTask()
+
+		for _, org := range orgs {
+			refs, err := e.repoScan.Scan(e.ctx, org)
+			if err != nil {
+				e.Config.Log.Printf("Repository scan of %s: %v", org, err)
+			}
+			if len(refs) > 0 {
+				e.Config.Log.Printf("Repository scan of %s: %d references to the infrastructure found", org, len(refs))
+			}
+
+			for _, ref := range refs {
+				e.addFinding(ref.Finding())
+
+				switch ref.Kind {
+				case repos.KindName:
+					if domain := e.Config.WhichDomain(ref.Value); domain != "" {
+						e.nameSrc.newNameFrom(&requests.DNSRequest{
+							Name:   ref.Value,
+							Domain: domain,
+						}, repoScanSource)
+					}
+				case repos.KindAddress:
+					req := &requests.AddrRequest{
+						Address: ref.Value,
+						InScope: true,
+					}
+					e.nameSrc.newAddr(req)
+					e.sendRequests(req.Clone().(*requests.AddrRequest))
+				}
+			}
+
+			if errors.Is(err, repos.ErrRateLimited) || e.ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+	return &wg
+}
@@ -0,0 +1,108 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+// SourceSample is a sample of the names a data source returns for the root domain names.
+type SourceSample struct {
+	SourceHitRate
+	// Names contains the first names returned that are within the scope
+	Names []string
+}
+
+// SampleDataSources queries each of the selected data sources for the root domain names and
+// collects up to num names from each, so the scope can be reviewed before the full collection.
+// Each data source is sampled until it returns num names, or the timeout expires.
+func SampleDataSources(ctx context.Context, cfg *config.Config, sys systems.System, num int, timeout time.Duration) []*SourceSample {
+	var wg sync.WaitGroup
+	var samples []*SourceSample
+	ch := make(chan *SourceSample, 10)
+
+	req := &requests.DNSRequest{}
+	for _, src := range datasrcs.SelectedDataSources(cfg, sys.DataSources()) {
+		if !src.HandlesReq(req) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(src service.Service) {
+			defer wg.Done()
+			ch <- sampleDataSource(ctx, cfg, src, num, timeout)
+		}(src)
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+
+	for s := range ch {
+		samples = append(samples, s)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Source < samples[j].Source
+	})
+	return samples
+}
+
+func sampleDataSource(ctx context.Context, cfg *config.Config, src service.Service, num int, timeout time.Duration) *SourceSample {
+	s := &SourceSample{SourceHitRate: SourceHitRate{Source: src.String()}}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	go func() {
+		for _, domain := range cfg.Domains() {
+			select {
+			case <-ctx.Done():
+				return
+			case src.Input() <- &requests.DNSRequest{Name: domain, Domain: domain}:
+			}
+		}
+	}()
+
+	seen := make(map[string]struct{})
+loop:
+	for len(s.Names) < num {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-src.Done():
+			break loop
+		case out := <-src.Output():
+			req, ok := out.(*requests.DNSRequest)
+			if !ok || req.Name == "" {
+				continue loop
+			}
+
+			s.Observed++
+			requests.SanitizeDNSRequest(req)
+			if !cfg.IsDomainInScope(req.Name) || cfg.Blacklisted(req.Name) {
+				continue loop
+			}
+
+			s.InScope++
+			if _, found := seen[req.Name]; !found {
+				seen[req.Name] = struct{}{}
+				s.Names = append(s.Names, req.Name)
+			}
+		}
+	}
+	// Include the names the data source found outside of the scope
+	if o, ok := src.(namesObserver); ok && o.NamesObserved() > s.Observed {
+		s.Observed = o.NamesObserved()
+	}
+	return s
+}
@@ -0,0 +1,106 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// TypeSNIBinding is the type of the findings recorded for the virtual hosts confirmed by the SNI brute forcing
+	TypeSNIBinding = "sni_binding"
+	// sniBaselineName is provided to obtain the default certificate served without a matching virtual host
+	sniBaselineName = "amass-sni-baseline.invalid"
+)
+
+// sniEnabled returns true when the sni_bruteforce option enables the SNI brute forcing during active enumerations.
+func sniEnabled(cfg *config.Config) bool {
+	enabled, ok := cfg.Options["sni_bruteforce"].(bool)
+	return ok && enabled && cfg.Active
+}
+
+// bruteForceSNI connects to the in-scope addresses providing the discovered names as SNI values, and records
+// a finding for each name that is served a certificate valid for it, which differs from the default certificate
+// of the address. This uncovers the virtual hosts that do not have public DNS records pointing to the address.
+func (e *Enumeration) bruteForceSNI() {
+	s := e.hosts
+	if s == nil || !sniEnabled(e.Config) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxCertChecks)
+	for addr, names := range s.targets(nil) {
+		for _, port := range e.Config.Scope.Ports {
+			if port == 80 {
+				continue
+			}
+
+			wg.Add(1)
+			go func(addr string, port int, names []string) {
+				defer wg.Done()
+
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				for _, f := range e.sniBindings(addr, port, names) {
+					e.addFinding(f)
+				}
+			}(addr, port, names)
+		}
+	}
+	wg.Wait()
+}
+
+// sniBindings returns the findings for the names confirmed as virtual hosts served at the address and port.
+func (e *Enumeration) sniBindings(addr string, port int, names []string) []*findings.Finding {
+	// The addresses that do not complete the handshake are skipped
+	baseline := e.serverCertificate(sniBaselineName, addr, port)
+	if baseline == nil {
+		return nil
+	}
+
+	var results []*findings.Finding
+	for _, name := range names {
+		select {
+		case <-e.ctx.Done():
+			return results
+		default:
+		}
+
+		cert := e.serverCertificate(name, addr, port)
+		if cert == nil || bytes.Equal(cert.Raw, baseline.Raw) || cert.VerifyHostname(name) != nil {
+			continue
+		}
+		results = append(results, sniBindingFinding(name, addr, port, cert))
+	}
+	return results
+}
+
+func sniBindingFinding(name, addr string, port int, cert *x509.Certificate) *findings.Finding {
+	fp := sha256.Sum256(cert.Raw)
+
+	return &findings.Finding{
+		Asset: name,
+		Type:  TypeSNIBinding,
+		Title: "Virtual host confirmed using SNI",
+		Attributes: map[string]string{
+			"address":     addr,
+			"port":        strconv.Itoa(port),
+			"host":        net.JoinHostPort(addr, strconv.Itoa(port)),
+			"issuer":      cert.Issuer.CommonName,
+			"fingerprint": hex.EncodeToString(fp[:]),
+		},
+	}
+}
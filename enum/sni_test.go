@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func selfSignedCert(t *testing.T, name string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create the certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSNIBindings(t *testing.T) {
+	def := selfSignedCert(t, "www.owasp.org")
+	hidden := selfSignedCert(t, "hidden.owasp.org")
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName == "hidden.owasp.org" {
+				return &hidden, nil
+			}
+			return &def, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start the TLS server: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = conn.(*tls.Conn).Handshake()
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	host, p, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(p)
+	e := &Enumeration{ctx: context.Background()}
+
+	fs := e.sniBindings(host, port, []string{"dev.owasp.org", "hidden.owasp.org", "www.owasp.org"})
+	if len(fs) != 1 {
+		t.Fatalf("Expected one binding, got %d", len(fs))
+	}
+	if f := fs[0]; f.Asset != "hidden.owasp.org" || f.Type != TypeSNIBinding || f.Attributes["address"] != host {
+		t.Errorf("Unexpected binding: %+v", f)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// spillQueue is a FIFO queue kept on disk, so the elements do not occupy memory while waiting.
+type spillQueue struct {
+	sync.Mutex
+	path  string
+	file  *os.File
+	w     *bufio.Writer
+	r     *bufio.Reader
+	count int
+}
+
+type spillEntry struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+func newSpillQueue(dir, pattern string) (*spillQueue, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	rf, err := os.Open(f.Name())
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, err
+	}
+
+	return &spillQueue{
+		path: f.Name(),
+		file: f,
+		w:    bufio.NewWriter(f),
+		r:    bufio.NewReader(rf),
+	}, nil
+}
+
+// Append writes the element to the end of the queue.
+func (s *spillQueue) Append(element interface{}) error {
+	var kind string
+	switch element.(type) {
+	case *requests.DNSRequest:
+		kind = "dns"
+	case *requests.AddrRequest:
+		kind = "addr"
+	case *requests.SubdomainRequest:
+		kind = "subdomain"
+	case *requests.ResolvedRequest:
+		kind = "resolved"
+	default:
+		return fmt.Errorf("the %T element cannot be spilled to disk", element)
+	}
+
+	data, err := json.Marshal(element)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(&spillEntry{Kind: kind, Data: data})
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.count++
+	return nil
+}
+
+// Next removes and returns the element at the front of the queue.
+func (s *spillQueue) Next() (interface{}, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	for s.count > 0 {
+		// The lines must be on disk before they can be read back
+		if err := s.w.Flush(); err != nil {
+			return nil, false
+		}
+
+		line, err := s.r.ReadBytes('\n')
+		if err != nil {
+			return nil, false
+		}
+		s.count--
+
+		if element, err := decodeSpillEntry(line); err == nil {
+			return element, true
+		}
+	}
+	return nil, false
+}
+
+// Len returns the number of elements in the queue.
+func (s *spillQueue) Len() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.count
+}
+
+// Close releases the file used by the queue.
+func (s *spillQueue) Close() {
+	s.Lock()
+	defer s.Unlock()
+
+	_ = s.file.Close()
+	_ = os.Remove(s.path)
+	s.count = 0
+}
+
+func decodeSpillEntry(line []byte) (interface{}, error) {
+	var entry spillEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, err
+	}
+
+	var element interface{}
+	switch entry.Kind {
+	case "dns":
+		element = new(requests.DNSRequest)
+	case "addr":
+		element = new(requests.AddrRequest)
+	case "subdomain":
+		element = new(requests.SubdomainRequest)
+	case "resolved":
+		element = new(requests.ResolvedRequest)
+	default:
+		return nil, fmt.Errorf("unknown spilled element kind: %s", entry.Kind)
+	}
+
+	if err := json.Unmarshal(entry.Data, element); err != nil {
+		return nil, err
+	}
+	return element, nil
+}
@@ -0,0 +1,61 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestSpillQueue(t *testing.T) {
+	s, err := newSpillQueue(t.TempDir(), "spill-*.jsonl")
+	if err != nil {
+		t.Fatalf("Failed to create the spill queue: %v", err)
+	}
+
+	expected := []interface{}{
+		&requests.DNSRequest{Name: "www.owasp.org", Domain: "owasp.org"},
+		&requests.AddrRequest{Address: "192.0.2.1", InScope: true, Domain: "owasp.org"},
+		&requests.SubdomainRequest{Name: "dev.owasp.org", Domain: "owasp.org", Times: 2},
+	}
+	for _, element := range expected {
+		if err := s.Append(element); err != nil {
+			t.Fatalf("Failed to spill %v: %v", element, err)
+		}
+	}
+	if err := s.Append("not a request"); err == nil {
+		t.Errorf("Expected an error when spilling an unsupported element")
+	}
+	if l := s.Len(); l != len(expected) {
+		t.Errorf("Expected %d spilled elements, got %d", len(expected), l)
+	}
+
+	// Elements appended after reading began must also be returned in order
+	first, ok := s.Next()
+	if !ok || !reflect.DeepEqual(first, expected[0]) {
+		t.Errorf("Expected %v, got %v", expected[0], first)
+	}
+	last := &requests.ResolvedRequest{Name: "mail.owasp.org", Domain: "owasp.org"}
+	_ = s.Append(last)
+	expected = append(expected[1:], last)
+
+	for _, want := range expected {
+		got, ok := s.Next()
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+	if _, ok := s.Next(); ok || s.Len() != 0 {
+		t.Errorf("Expected the spill queue to be empty")
+	}
+
+	s.Close()
+	if _, err := os.Stat(s.path); !os.IsNotExist(err) {
+		t.Errorf("The spill file was not removed")
+	}
+}
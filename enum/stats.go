@@ -0,0 +1,139 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/config/config"
+)
+
+// StatsFileName is the name of the file in the output directory that stores the statistics of the last session.
+const StatsFileName = "session_stats.json"
+
+// SourceStats are the statistics of a data source during the session.
+type SourceStats struct {
+	Source   string `json:"source"`
+	Observed int    `json:"observed"`
+	InScope  int    `json:"in_scope"`
+	// Cap is the maximum number of names the data source provides for each root domain, or zero when not capped
+	Cap int `json:"cap,omitempty"`
+	// Truncated maps the root domains to the number of names dropped once the cap was reached
+	Truncated map[string]int `json:"truncated,omitempty"`
+}
+
+// SessionStats are the statistics of an enumeration, kept so the coverage of the session can be reviewed.
+type SessionStats struct {
+	Started  time.Time      `json:"started"`
+	Finished time.Time      `json:"finished"`
+	Domains  []string       `json:"domains"`
+	Sources  []*SourceStats `json:"sources"`
+}
+
+// nameCapper is implemented by the data sources that cap the names provided for each root domain.
+type nameCapper interface {
+	NameCap() int
+	Truncated() map[string]int
+}
+
+// SessionStats returns the statistics of the data sources during the enumeration, sorted by name.
+func (e *Enumeration) SessionStats() *SessionStats {
+	stats := &SessionStats{
+		Started:  e.Config.CollectionStartTime.UTC(),
+		Finished: time.Now().UTC(),
+		Domains:  e.Config.Domains(),
+	}
+
+	rates := make(map[string]*SourceHitRate)
+	for _, rate := range e.ScopeHitRates() {
+		rates[rate.Source] = rate
+	}
+	stats.Sources = sourceStats(e.srcs, rates)
+	return stats
+}
+
+func sourceStats(srcs []service.Service, rates map[string]*SourceHitRate) []*SourceStats {
+	var results []*SourceStats
+
+	for _, src := range srcs {
+		s := &SourceStats{Source: src.String()}
+		if rate, found := rates[src.String()]; found {
+			s.Observed = rate.Observed
+			s.InScope = rate.InScope
+		}
+		if c, ok := src.(nameCapper); ok {
+			s.Cap = c.NameCap()
+			if t := c.Truncated(); len(t) > 0 {
+				s.Truncated = t
+			}
+		}
+		if s.Observed > 0 || len(s.Truncated) > 0 {
+			results = append(results, s)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Source < results[j].Source
+	})
+	return results
+}
+
+// Truncated returns the data sources that reached their cap for at least one root domain.
+func (s *SessionStats) Truncated() []*SourceStats {
+	var results []*SourceStats
+
+	for _, src := range s.Sources {
+		if len(src.Truncated) > 0 {
+			results = append(results, src)
+		}
+	}
+	return results
+}
+
+// ReadSessionStats returns the statistics stored in the file.
+func ReadSessionStats(path string) (*SessionStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s SessionStats
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse the session statistics file %s: %v", path, err)
+	}
+	return &s, nil
+}
+
+// Write stores the statistics in the file.
+func (s *SessionStats) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0640)
+}
+
+// saveSessionStats writes the statistics of the enumeration to the output directory, and logs the
+// data sources that reached their cap, since their names were limited rather than exhausted.
+func (e *Enumeration) saveSessionStats() {
+	stats := e.SessionStats()
+
+	for _, src := range stats.Truncated() {
+		for domain, n := range src.Truncated {
+			e.Config.Log.Printf("%s: reached the cap of %d names for %s, %d names were truncated", src.Source, src.Cap, domain, n)
+		}
+	}
+
+	path := filepath.Join(config.OutputDirectory(e.Config.Dir), StatsFileName)
+	if err := stats.Write(path); err != nil {
+		e.Config.Log.Printf("Failed to save the session statistics: %v", err)
+	}
+}
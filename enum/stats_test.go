@@ -0,0 +1,52 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/caffix/service"
+)
+
+type cappedSource struct {
+	service.Service
+	truncated map[string]int
+}
+
+func (c *cappedSource) NameCap() int { return 100 }
+
+func (c *cappedSource) Truncated() map[string]int { return c.truncated }
+
+func TestSessionStats(t *testing.T) {
+	srcs := []service.Service{
+		&cappedSource{Service: newNamedSource("Crtsh"), truncated: map[string]int{"owasp.org": 250}},
+		newNamedSource("Idle"),
+		newNamedSource("HackerTarget"),
+	}
+	rates := map[string]*SourceHitRate{
+		"Crtsh":        {Source: "Crtsh", Observed: 120, InScope: 100},
+		"HackerTarget": {Source: "HackerTarget", Observed: 10, InScope: 10},
+	}
+
+	stats := &SessionStats{Domains: []string{"owasp.org"}, Sources: sourceStats(srcs, rates)}
+	if len(stats.Sources) != 2 || stats.Sources[0].Source != "Crtsh" || stats.Sources[1].Source != "HackerTarget" {
+		t.Fatalf("Expected the data sources providing names, got %+v", stats.Sources)
+	}
+
+	path := filepath.Join(t.TempDir(), StatsFileName)
+	if err := stats.Write(path); err != nil {
+		t.Fatalf("Failed to write the statistics: %v", err)
+	}
+	stats, err := ReadSessionStats(path)
+	if err != nil {
+		t.Fatalf("Failed to read the statistics: %v", err)
+	}
+
+	tr := stats.Truncated()
+	if len(tr) != 1 || tr[0].Cap != 100 || tr[0].Truncated["owasp.org"] != 250 || tr[0].InScope != 100 {
+		t.Errorf("Expected the truncation marker of Crtsh, got %+v", tr)
+	}
+}
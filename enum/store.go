@@ -10,15 +10,18 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/caffix/pipeline"
 	"github.com/caffix/queue"
 	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/metrics"
 	amassnet "github.com/owasp-amass/amass/v4/net"
 	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/open-asset-model/domain"
 	"github.com/owasp-amass/resolve"
 	bf "github.com/tylertreat/BoomFilters"
 	"golang.org/x/net/publicsuffix"
@@ -31,6 +34,7 @@ type dataManager struct {
 	signalDone  chan struct{}
 	confirmDone chan struct{}
 	filter      *bf.StableBloomFilter
+	ledger      *writeLedger
 }
 
 // newDataManager returns a dataManager specific to the provided Enumeration.
@@ -42,6 +46,7 @@ func newDataManager(e *Enumeration) *dataManager {
 		confirmDone: make(chan struct{}, 2),
 		filter:      bf.NewDefaultStableBloomFilter(1000000, 0.01),
 	}
+	dm.ledger = newWriteLedger(dm.graphReady)
 
 	go dm.processASNRequests()
 	return dm
@@ -93,6 +98,12 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 	if dm.enum.Config.Blacklisted(req.Name) {
 		return nil
 	}
+	// Resolvable names of development and staging environments are reported
+	dm.enum.checkEnvironment(req)
+	// The past resolutions are requested for the names that currently resolve
+	if len(req.Records) > 0 {
+		dm.enum.lookupHistory(req.Name)
+	}
 	// Check for CNAME records first
 	for i, r := range req.Records {
 		req.Records[i].Name = strings.Trim(strings.ToLower(r.Name), ".")
@@ -100,7 +111,9 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 
 		if uint16(r.Type) == dns.TypeCNAME {
 			// Do not enter more than the CNAME record
-			return dm.insertCNAME(ctx, req, i, tp)
+			err := dm.insertCNAME(ctx, req, i, tp)
+			recordWrite(dns.TypeCNAME, err)
+			return err
 		}
 	}
 
@@ -132,7 +145,10 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 			e = dm.insertSOA(ctx, req, i, tp)
 		case dns.TypeSPF:
 			e = dm.insertSPF(ctx, req, i, tp)
+		default:
+			continue
 		}
+		recordWrite(uint16(r.Type), e)
 		if err == nil {
 			err = e
 		}
@@ -140,6 +156,34 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 	return err
 }
 
+// graphReady returns an error while the graph database is not answering reads.
+func (dm *dataManager) graphReady(ctx context.Context) error {
+	_, err := dm.enum.graph.DB.FindByContent(domain.FQDN{Name: "amass.invalid"}, time.Time{})
+	return err
+}
+
+// write applies the graph database write identified by the record type and its content exactly once.
+func (dm *dataManager) write(ctx context.Context, rrtype, from, to string, fn func() error) error {
+	return dm.ledger.Write(ctx, rrtype+"|"+from+"|"+to, fn)
+}
+
+// upsertInfra applies the write of the autonomous system and netblock containing the address exactly once.
+func (dm *dataManager) upsertInfra(ctx context.Context, asn int, desc, addr, prefix string) error {
+	return dm.write(ctx, "AS", strconv.Itoa(asn)+" "+prefix, addr, func() error {
+		return dm.enum.graph.UpsertInfrastructure(ctx, asn, desc, addr, prefix)
+	})
+}
+
+// recordWrite updates the metrics for the DNS record written to the graph database.
+func recordWrite(rrtype uint16, err error) {
+	name := dns.TypeToString[rrtype]
+	if err != nil {
+		metrics.GraphWriteErrors.Inc(name)
+		return
+	}
+	metrics.GraphWrites.Inc(name)
+}
+
 func (dm *dataManager) insertCNAME(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
 	target := resolve.RemoveLastDot(req.Records[recidx].Data)
 	if target == "" {
@@ -155,7 +199,9 @@ func (dm *dataManager) insertCNAME(ctx context.Context, req *requests.DNSRequest
 		Name:   target,
 		Domain: strings.ToLower(domain),
 	})
-	if err := dm.enum.graph.UpsertCNAME(ctx, req.Name, target); err != nil {
+	if err := dm.write(ctx, "CNAME", req.Name, target, func() error {
+		return dm.enum.graph.UpsertCNAME(ctx, req.Name, target)
+	}); err != nil {
 		return fmt.Errorf("failed to insert CNAME: %v", err)
 	}
 	return nil
@@ -172,9 +218,13 @@ func (dm *dataManager) insertA(ctx context.Context, req *requests.DNSRequest, re
 		InScope: true,
 		Domain:  req.Domain,
 	})
-	if err := dm.enum.graph.UpsertA(ctx, req.Name, addr); err != nil {
+	if err := dm.write(ctx, "A", req.Name, addr, func() error {
+		return dm.enum.graph.UpsertA(ctx, req.Name, addr)
+	}); err != nil {
 		return fmt.Errorf("failed to insert A record: %v", err)
 	}
+	dm.enum.checkCertificates(req.Name, addr)
+	dm.enum.hosts.addBinding(req.Name, req.Domain, addr)
 	return nil
 }
 
@@ -189,9 +239,13 @@ func (dm *dataManager) insertAAAA(ctx context.Context, req *requests.DNSRequest,
 		InScope: true,
 		Domain:  req.Domain,
 	})
-	if err := dm.enum.graph.UpsertAAAA(ctx, req.Name, addr); err != nil {
+	if err := dm.write(ctx, "AAAA", req.Name, addr, func() error {
+		return dm.enum.graph.UpsertAAAA(ctx, req.Name, addr)
+	}); err != nil {
 		return fmt.Errorf("failed to insert AAAA record: %v", err)
 	}
+	dm.enum.checkCertificates(req.Name, addr)
+	dm.enum.hosts.addBinding(req.Name, req.Domain, addr)
 	return nil
 }
 
@@ -210,7 +264,9 @@ func (dm *dataManager) insertPTR(ctx context.Context, req *requests.DNSRequest,
 		Name:   target,
 		Domain: domain,
 	})
-	if err := dm.enum.graph.UpsertPTR(ctx, req.Name, target); err != nil {
+	if err := dm.write(ctx, "PTR", req.Name, target, func() error {
+		return dm.enum.graph.UpsertPTR(ctx, req.Name, target)
+	}); err != nil {
 		return fmt.Errorf("failed to insert PTR record: %v", err)
 	}
 	return nil
@@ -228,7 +284,9 @@ func (dm *dataManager) insertSRV(ctx context.Context, req *requests.DNSRequest,
 			Domain: domain,
 		})
 	}
-	if err := dm.enum.graph.UpsertSRV(ctx, service, target); err != nil {
+	if err := dm.write(ctx, "SRV", service, target, func() error {
+		return dm.enum.graph.UpsertSRV(ctx, service, target)
+	}); err != nil {
 		return fmt.Errorf("failed to insert SRV record: %v", err)
 	}
 	return nil
@@ -250,7 +308,9 @@ func (dm *dataManager) insertNS(ctx context.Context, req *requests.DNSRequest, r
 			Domain: d,
 		})
 	}
-	if err := dm.enum.graph.UpsertNS(ctx, req.Name, target); err != nil {
+	if err := dm.write(ctx, "NS", req.Name, target, func() error {
+		return dm.enum.graph.UpsertNS(ctx, req.Name, target)
+	}); err != nil {
 		return fmt.Errorf("failed to insert NS record: %v", err)
 	}
 	return nil
@@ -272,7 +332,9 @@ func (dm *dataManager) insertMX(ctx context.Context, req *requests.DNSRequest, r
 			Domain: d,
 		})
 	}
-	if err := dm.enum.graph.UpsertMX(ctx, req.Name, target); err != nil {
+	if err := dm.write(ctx, "MX", req.Name, target, func() error {
+		return dm.enum.graph.UpsertMX(ctx, req.Name, target)
+	}); err != nil {
 		return fmt.Errorf("failed to insert MX record: %v", err)
 	}
 	return nil
@@ -331,16 +393,19 @@ func (dm *dataManager) addrRequest(ctx context.Context, req *requests.AddrReques
 	}
 	if yes, prefix := amassnet.IsReservedAddress(req.Address); yes {
 		var err error
-		if e := dm.enum.graph.UpsertInfrastructure(ctx, 0, amassnet.ReservedCIDRDescription, req.Address, prefix); e != nil {
+		if e := dm.upsertInfra(ctx, 0, amassnet.ReservedCIDRDescription, req.Address, prefix); e != nil {
 			err = e
 		}
 		return err
 	}
+	dm.enum.lookupCoHosted(req.Address)
+	dm.enum.enrichAddress(req.Address)
 	if r := dm.enum.Sys.Cache().AddrSearch(req.Address); r != nil {
 		var err error
-		if e := dm.enum.graph.UpsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix); e != nil {
+		if e := dm.upsertInfra(ctx, r.ASN, r.Description, req.Address, r.Prefix); e != nil {
 			err = e
 		}
+		dm.enum.lookupAbuseContacts(r.Prefix)
 		return err
 	}
 
@@ -373,7 +438,8 @@ func (dm *dataManager) nextInfraInfo() {
 	ctx := context.Background()
 	req := e.(*requests.AddrRequest)
 	if r := dm.enum.Sys.Cache().AddrSearch(req.Address); r != nil {
-		_ = dm.enum.graph.UpsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
+		_ = dm.upsertInfra(ctx, r.ASN, r.Description, req.Address, r.Prefix)
+		dm.enum.lookupAbuseContacts(r.Prefix)
 		return
 	}
 
@@ -388,7 +454,8 @@ loop:
 
 		time.Sleep(2 * time.Second)
 		if r := dm.enum.Sys.Cache().AddrSearch(req.Address); r != nil {
-			_ = dm.enum.graph.UpsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
+			_ = dm.upsertInfra(ctx, r.ASN, r.Description, req.Address, r.Prefix)
+			dm.enum.lookupAbuseContacts(r.Prefix)
 			return
 		}
 	}
@@ -396,7 +463,7 @@ loop:
 	asn := 0
 	desc := "Unknown"
 	prefix := fakePrefix(req.Address)
-	_ = dm.enum.graph.UpsertInfrastructure(ctx, asn, desc, req.Address, prefix)
+	_ = dm.upsertInfra(ctx, asn, desc, req.Address, prefix)
 
 	first, cidr, _ := net.ParseCIDR(prefix)
 	dm.enum.Sys.Cache().Update(&requests.ASNRequest{
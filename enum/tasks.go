@@ -0,0 +1,493 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/format"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/probe"
+	"github.com/owasp-amass/amass/v4/relations"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// The task types accepted by the enumeration.
+const (
+	TaskResolve   = "resolve"
+	TaskAddresses = "addresses"
+	TaskCrawl     = "crawl"
+	TaskRelation  = "relation"
+)
+
+const (
+	// maxTaskItems is the number of names, addresses or URLs accepted in a single task
+	maxTaskItems = 10000
+	// defaultCrawlPages is the number of pages crawled for each URL when the task does not provide it
+	defaultCrawlPages = 50
+	maxCrawlPages     = 1000
+	crawlTimeout      = 5 * time.Minute
+)
+
+// ErrNotRunning is returned for the tasks submitted when the enumeration is not running.
+var ErrNotRunning = errors.New("the enumeration is not running")
+
+// Task is the follow-up work pushed into the running enumeration by an external system.
+type Task struct {
+	// Type is resolve, addresses, crawl or relation
+	Type string `json:"type"`
+	// Names are resolved by the resolve tasks
+	Names []string `json:"names,omitempty"`
+	// Addresses are investigated by the addresses tasks
+	Addresses []string `json:"addresses,omitempty"`
+	// URLs are crawled for names by the crawl tasks
+	URLs []string `json:"urls,omitempty"`
+	// MaxPages is the number of pages crawled for each URL
+	MaxPages int `json:"max_pages,omitempty"`
+	// Relation is asserted between two assets already in the graph database by the relation tasks
+	Relation *relations.Assertion `json:"relation,omitempty"`
+}
+
+// TaskResult reports the items of the task accepted by the enumeration, and why the others were rejected.
+type TaskResult struct {
+	Accepted int              `json:"accepted"`
+	Rejected []*TaskRejection `json:"rejected,omitempty"`
+}
+
+// TaskRejection is an item of the task that was rejected.
+type TaskRejection struct {
+	Item   string `json:"item"`
+	Reason string `json:"reason"`
+}
+
+func (r *TaskResult) reject(item, reason string) {
+	r.Rejected = append(r.Rejected, &TaskRejection{Item: item, Reason: reason})
+}
+
+// Submit validates the items of the task against the scope and brings the accepted items into the
+// running enumeration. The crawls continue in the background, and keep the enumeration running.
+func (e *Enumeration) Submit(t *Task) (*TaskResult, error) {
+	if t == nil {
+		return nil, errors.New("the task is missing")
+	}
+	if t.Type == TaskRelation {
+		return e.submitTaskRelation(t.Relation)
+	}
+	if n := len(t.Names) + len(t.Addresses) + len(t.URLs); n == 0 {
+		return nil, errors.New("the task does not provide any items")
+	} else if n > maxTaskItems {
+		return nil, fmt.Errorf("the task provides more than %d items", maxTaskItems)
+	}
+
+	switch t.Type {
+	case TaskResolve:
+		if len(t.Names) == 0 {
+			return nil, errors.New("the resolve task must provide the names")
+		}
+	case TaskAddresses:
+		if len(t.Addresses) == 0 {
+			return nil, errors.New("the addresses task must provide the addresses")
+		}
+	case TaskCrawl:
+		if len(t.URLs) == 0 {
+			return nil, errors.New("the crawl task must provide the URLs")
+		}
+		if t.MaxPages < 0 || t.MaxPages > maxCrawlPages {
+			return nil, fmt.Errorf("the crawl task can visit up to %d pages of each URL", maxCrawlPages)
+		}
+	default:
+		return nil, fmt.Errorf("the task type %q is not supported", t.Type)
+	}
+
+	if !e.startTask() {
+		return nil, ErrNotRunning
+	}
+	defer e.finishTask()
+
+	res := new(TaskResult)
+	switch t.Type {
+	case TaskResolve:
+		e.submitTaskNames(t.Names, res)
+	case TaskAddresses:
+		e.submitTaskAddrs(t.Addresses, res)
+	case TaskCrawl:
+		e.submitTaskCrawls(t.URLs, t.MaxPages, res)
+	}
+	return res, nil
+}
+
+// submitTaskRelation stores the relation asserted by the analyst and records the assertion. The asserted names
+// and addresses that are in scope are brought into the enumeration, so the relation steers the expansion.
+func (e *Enumeration) submitTaskRelation(a *relations.Assertion) (*TaskResult, error) {
+	if a == nil {
+		return nil, errors.New("the relation task must provide the relation")
+	}
+	// The assertion is recorded with the source of the relation tasks, whatever the request provided
+	a.Source = relations.ManualSource
+	a.Time = time.Time{}
+
+	aliases, err := relations.ParseMapping(e.Config.Options["relation_aliases"])
+	if err != nil {
+		return nil, err
+	}
+	if err := aliases.Validate(a); err != nil {
+		return nil, err
+	}
+
+	if !e.startTask() {
+		return nil, ErrNotRunning
+	}
+	defer e.finishTask()
+
+	if err := aliases.Assert(e.graph.DB, a, false); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(config.OutputDirectory(e.Config.Dir), relations.AssertionsFileName)
+	if err := relations.RecordAssertion(path, a); err != nil {
+		e.Config.Log.Printf("Failed to record the %s relation asserted by %s: %v", a.Relation, a.Analyst, err)
+	}
+	e.Config.Log.Printf("The %s relation from %s to %s was asserted by %s", a.Relation, a.From.ID(), a.To.ID(), a.Analyst)
+
+	res := &TaskResult{Accepted: 1}
+	for _, m := range []collections.Member{a.From, a.To} {
+		switch oam.AssetType(m.Type) {
+		case oam.FQDN:
+			if e.Config.WhichDomain(m.Key) != "" && !e.Config.Blacklisted(m.Key) {
+				e.submitTaskNames([]string{m.Key}, new(TaskResult))
+			}
+		case oam.IPAddress:
+			if e.Config.IsAddressInScope(m.Key) {
+				e.submitTaskAddrs([]string{m.Key}, new(TaskResult))
+			}
+		}
+	}
+	return res, nil
+}
+
+func (e *Enumeration) submitTaskNames(names []string, res *TaskResult) {
+	for _, n := range names {
+		name := format.NormalizeName(n)
+
+		domain := e.Config.WhichDomain(name)
+		if domain == "" {
+			res.reject(n, "out of scope")
+			continue
+		}
+		if e.Config.Blacklisted(name) {
+			res.reject(n, "blacklisted")
+			continue
+		}
+
+		res.Accepted++
+		e.nameSrc.newName(&requests.DNSRequest{
+			Name:   name,
+			Domain: domain,
+		})
+	}
+}
+
+func (e *Enumeration) submitTaskAddrs(addrs []string, res *TaskResult) {
+	for _, a := range addrs {
+		ip := net.ParseIP(strings.TrimSpace(a))
+		if ip == nil {
+			res.reject(a, "not an IP address")
+			continue
+		}
+
+		addr := ip.String()
+		if !e.Config.IsAddressInScope(addr) {
+			res.reject(a, "out of scope")
+			continue
+		}
+
+		res.Accepted++
+		req := &requests.AddrRequest{
+			Address: addr,
+			InScope: true,
+		}
+		e.nameSrc.newAddr(req)
+		e.sendRequests(req.Clone().(*requests.AddrRequest))
+	}
+}
+
+func (e *Enumeration) submitTaskCrawls(urls []string, max int, res *TaskResult) {
+	if max == 0 {
+		max = defaultCrawlPages
+	}
+
+	for _, raw := range urls {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			res.reject(raw, "not an HTTP URL")
+			continue
+		}
+		if e.Config.WhichDomain(u.Hostname()) == "" {
+			res.reject(raw, "out of scope")
+			continue
+		}
+		if !e.startTask() {
+			res.reject(raw, ErrNotRunning.Error())
+			continue
+		}
+
+		res.Accepted++
+		go e.crawlTask(u.String(), max)
+	}
+}
+
+func (e *Enumeration) crawlTask(u string, max int) {
+	defer e.finishTask()
+
+	ctx, cancel := context.WithTimeout(e.ctx, crawlTimeout)
+	defer cancel()
+
+	re := amassdns.AnySubdomainRegex()
+	err := amasshttp.Crawl(ctx, u, e.Config.Domains(), max, func(req *amasshttp.Request, resp *amasshttp.Response) {
+		names := re.FindAllString(resp.Body, -1)
+		if pu, err := url.Parse(req.URL); err == nil {
+			names = append(names, pu.Hostname())
+		}
+
+		for _, n := range names {
+			name := amasshttp.CleanName(n)
+			if domain := e.Config.WhichDomain(name); domain != "" {
+				e.nameSrc.newName(&requests.DNSRequest{
+					Name:   name,
+					Domain: domain,
+				})
+			}
+		}
+	})
+	if err != nil && e.Config.Verbose {
+		e.Config.Log.Printf("Task API: crawl of %s: %v", u, err)
+	}
+}
+
+// startTask returns false when the enumeration is not accepting tasks.
+func (e *Enumeration) startTask() bool {
+	e.plock.Lock()
+	defer e.plock.Unlock()
+
+	if !e.accepting {
+		return false
+	}
+	e.tasks++
+	return true
+}
+
+func (e *Enumeration) finishTask() {
+	e.plock.Lock()
+	defer e.plock.Unlock()
+
+	e.tasks--
+}
+
+func (e *Enumeration) setAccepting(accepting bool) {
+	e.plock.Lock()
+	defer e.plock.Unlock()
+
+	e.accepting = accepting
+	e.waiting = accepting && e.WaitForTasks
+}
+
+// Finish lets the enumeration end once the submitted tasks are completed, when it was waiting for tasks.
+func (e *Enumeration) Finish() {
+	e.plock.Lock()
+	defer e.plock.Unlock()
+
+	e.waiting = false
+}
+
+// TaskAPISettings are provided by the task_api option of the configuration.
+type TaskAPISettings struct {
+	// Listen is the address the task API is served on
+	Listen string
+	// Tokens are accepted from the external systems as bearer tokens
+	Tokens []string
+	// CertFile and KeyFile serve the task API over TLS
+	CertFile string
+	KeyFile  string
+	// ClientCAFile requires the external systems to present a certificate signed by one of its authorities
+	ClientCAFile string
+	// Wait keeps the enumeration running for the tasks until the finish endpoint is requested
+	Wait bool
+}
+
+// ParseTaskAPISettings returns the settings of the task API provided by the task_api option.
+func ParseTaskAPISettings(raw interface{}) (*TaskAPISettings, error) {
+	s := new(TaskAPISettings)
+	if raw == nil {
+		return s, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("task_api must provide the listen, token, TLS and wait settings")
+	}
+	for key, dst := range map[string]*string{
+		"listen":    &s.Listen,
+		"cert":      &s.CertFile,
+		"key":       &s.KeyFile,
+		"client_ca": &s.ClientCAFile,
+	} {
+		if v, found := m[key]; found && v != nil {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("the task_api %s setting must be a string", key)
+			}
+			*dst = str
+		}
+	}
+	switch v := m["token"].(type) {
+	case nil:
+	case string:
+		if v != "" {
+			s.Tokens = []string{v}
+		}
+	case []interface{}:
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok || str == "" {
+				return nil, errors.New("the task_api token setting must be a string or a list of strings")
+			}
+			s.Tokens = append(s.Tokens, str)
+		}
+	default:
+		return nil, errors.New("the task_api token setting must be a string or a list of strings")
+	}
+	if v, found := m["wait"]; found && v != nil {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, errors.New("the task_api wait setting must be true or false")
+		}
+		s.Wait = b
+	}
+	return s, nil
+}
+
+// TLSConfig returns the configuration serving the task API over TLS, or nil when
+// the certificate, key and client CA files are not provided.
+func (s *TaskAPISettings) TLSConfig() (*tls.Config, error) {
+	if s.CertFile == "" && s.KeyFile == "" && s.ClientCAFile == "" {
+		return nil, nil
+	}
+	return probe.ServerTLSConfig(s.CertFile, s.KeyFile, s.ClientCAFile)
+}
+
+// TaskServer exposes the API receiving the tasks for the running enumeration.
+type TaskServer struct {
+	ln     net.Listener
+	server *http.Server
+}
+
+// NewTaskServer starts serving the task API of the enumeration on the provided address, using the
+// listener and authorization of the probe API. The requests must provide one of the tokens as a bearer
+// token when any are configured, and the API is served over TLS when tlsConfig is not nil. Without the
+// tokens or client certificates, the API is only served on the loopback addresses.
+func NewTaskServer(e *Enumeration, addr string, tokens []string, tlsConfig *tls.Config) (*TaskServer, error) {
+	if len(tokens) == 0 && (tlsConfig == nil || tlsConfig.ClientCAs == nil) && !probe.LoopbackAddr(addr) {
+		return nil, fmt.Errorf("the task API requires a token or client certificates to be served on %s, which is not a loopback address", addr)
+	}
+
+	ln, err := probe.Listen(addr, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for tasks on %s: %v", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/tasks", taskHandler(e, tokens))
+	mux.Handle("/finish", finishHandler(e, tokens))
+	s := &TaskServer{
+		ln: ln,
+		server: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+
+	go func() { _ = s.server.Serve(ln) }()
+	return s, nil
+}
+
+// Addr returns the address the task API is being served on.
+func (s *TaskServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops serving the task API.
+func (s *TaskServer) Close() error {
+	return s.server.Close()
+}
+
+func taskHandler(e *Enumeration, tokens []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !checkTaskRequest(w, r, tokens) {
+			return
+		}
+
+		var t Task
+		if err := json.NewDecoder(io.LimitReader(r.Body, 8<<20)).Decode(&t); err != nil {
+			writeTaskError(w, http.StatusBadRequest, fmt.Errorf("the task is not valid JSON: %v", err))
+			return
+		}
+
+		res, err := e.Submit(&t)
+		if errors.Is(err, ErrNotRunning) {
+			writeTaskError(w, http.StatusConflict, err)
+			return
+		} else if err != nil {
+			writeTaskError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(res)
+	})
+}
+
+func finishHandler(e *Enumeration, tokens []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !checkTaskRequest(w, r, tokens) {
+			return
+		}
+
+		e.Finish()
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+func checkTaskRequest(w http.ResponseWriter, r *http.Request, tokens []string) bool {
+	if r.Method != http.MethodPost {
+		writeTaskError(w, http.StatusMethodNotAllowed, errors.New("the requests must use the POST method"))
+		return false
+	}
+	if len(tokens) > 0 && !probe.ValidToken(tokens, probe.BearerToken(r)) {
+		writeTaskError(w, http.StatusUnauthorized, errors.New("the token is missing or invalid"))
+		return false
+	}
+	return true
+}
+
+func writeTaskError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,158 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/relations"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestSubmitValidation(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg}
+
+	for _, task := range []*Task{
+		nil,
+		{Type: TaskResolve},
+		{Type: TaskResolve, Addresses: []string{"192.0.2.1"}},
+		{Type: TaskCrawl, URLs: []string{"https://www.owasp.org"}, MaxPages: maxCrawlPages + 1},
+		{Type: "scan", Names: []string{"www.owasp.org"}},
+		{Type: TaskResolve, Names: make([]string, maxTaskItems+1)},
+		{Type: TaskRelation},
+		// The relations must identify the analyst and be valid in the asset taxonomy
+		{Type: TaskRelation, Relation: &relations.Assertion{
+			From: collections.Member{Type: "ASN", Key: "64496"}, Relation: "managed_by", To: collections.Member{Type: "RIROrg", Key: "OWASP"}}},
+		{Type: TaskRelation, Relation: &relations.Assertion{Analyst: "jdoe",
+			From: collections.Member{Type: "Netblock", Key: "192.0.2.0/24"}, Relation: "associated_with", To: collections.Member{Type: "RIROrg", Key: "OWASP"}}},
+	} {
+		if _, err := e.Submit(task); err == nil || errors.Is(err, ErrNotRunning) {
+			t.Errorf("Expected the task %v to be rejected: %v", task, err)
+		}
+	}
+
+	if _, err := e.Submit(&Task{Type: TaskResolve, Names: []string{"www.owasp.org"}}); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Expected ErrNotRunning before the enumeration started, got %v", err)
+	}
+}
+
+func TestSubmitScope(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg, accepting: true}
+
+	res := new(TaskResult)
+	e.submitTaskCrawls([]string{"ftp://www.owasp.org", "https://www.example.com/", "not a url"}, 0, res)
+	if res.Accepted != 0 || len(res.Rejected) != 3 {
+		t.Fatalf("Unexpected result: %d accepted, %d rejected", res.Accepted, len(res.Rejected))
+	}
+	if res.Rejected[1].Reason != "out of scope" {
+		t.Errorf("Unexpected reason for the out of scope URL: %s", res.Rejected[1].Reason)
+	}
+
+	res = new(TaskResult)
+	e.submitTaskAddrs([]string{"192.0.2"}, res)
+	if len(res.Rejected) != 1 || res.Rejected[0].Reason != "not an IP address" {
+		t.Errorf("The invalid address was not rejected: %v", res.Rejected)
+	}
+}
+
+func TestTaskServer(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig(), WaitForTasks: true}
+	e.setAccepting(true)
+
+	srv, err := NewTaskServer(e, "127.0.0.1:0", []string{"other", "s3cr3t"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to start the task API: %v", err)
+	}
+	defer srv.Close()
+
+	post := func(path, token, body string) int {
+		req, _ := http.NewRequest(http.MethodPost, "http://"+srv.Addr()+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("The request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := post("/tasks", "", `{"type":"resolve","names":["www.owasp.org"]}`); code != http.StatusUnauthorized {
+		t.Errorf("Expected the request without the token to be unauthorized, got %d", code)
+	}
+	if code := post("/tasks", "s3cr3t", `{"type":"resolve"`); code != http.StatusBadRequest {
+		t.Errorf("Expected the malformed task to be rejected, got %d", code)
+	}
+	if code := post("/tasks", "s3cr3t", `{"type":"scan","names":["www.owasp.org"]}`); code != http.StatusBadRequest {
+		t.Errorf("Expected the unsupported task to be rejected, got %d", code)
+	}
+
+	if !e.requestsPending() {
+		t.Error("The enumeration is not waiting for the tasks")
+	}
+	if code := post("/finish", "s3cr3t", ""); code != http.StatusAccepted {
+		t.Errorf("Unexpected status of the finish request: %d", code)
+	}
+	if e.requestsPending() {
+		t.Error("The enumeration is still waiting after the finish request")
+	}
+
+	e.setAccepting(false)
+	if code := post("/tasks", "s3cr3t", `{"type":"resolve","names":["www.owasp.org"]}`); code != http.StatusConflict {
+		t.Errorf("Expected the task to be refused once the enumeration ended, got %d", code)
+	}
+}
+
+func TestTaskServerLoopback(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig()}
+
+	for _, addr := range []string{":0", "0.0.0.0:0", "192.0.2.1:0"} {
+		if srv, err := NewTaskServer(e, addr, nil, nil); err == nil {
+			srv.Close()
+			t.Errorf("Expected the task API without a token to be refused on %s", addr)
+		}
+	}
+	srv, err := NewTaskServer(e, "127.0.0.1:0", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected the task API without a token to be served on the loopback address: %v", err)
+	}
+	srv.Close()
+}
+
+func TestParseTaskAPISettings(t *testing.T) {
+	s, err := ParseTaskAPISettings(map[string]interface{}{"listen": ":8090", "token": "s3cr3t", "wait": true})
+	if err != nil || s.Listen != ":8090" || len(s.Tokens) != 1 || s.Tokens[0] != "s3cr3t" || !s.Wait {
+		t.Errorf("Unexpected settings: %v %v", s, err)
+	}
+	s, err = ParseTaskAPISettings(map[string]interface{}{
+		"token": []interface{}{"one", "two"}, "cert": "api.crt", "key": "api.key", "client_ca": "ca.crt"})
+	if err != nil || len(s.Tokens) != 2 || s.CertFile != "api.crt" || s.KeyFile != "api.key" || s.ClientCAFile != "ca.crt" {
+		t.Errorf("Unexpected settings: %v %v", s, err)
+	}
+	if s, err := ParseTaskAPISettings(nil); err != nil || s.Listen != "" {
+		t.Errorf("Unexpected settings without the option: %v %v", s, err)
+	}
+
+	for _, raw := range []interface{}{
+		":8090",
+		map[string]interface{}{"listen": 8090},
+		map[string]interface{}{"wait": "yes"},
+		map[string]interface{}{"token": []interface{}{"one", 2}},
+	} {
+		if _, err := ParseTaskAPISettings(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/saas"
+	"github.com/owasp-amass/config/config"
+)
+
+// tenantsEnabled returns false when the saas_tenants option disables the discovery of SaaS tenants.
+func tenantsEnabled(cfg *config.Config) bool {
+	if enabled, ok := cfg.Options["saas_tenants"].(bool); ok {
+		return enabled
+	}
+	return true
+}
+
+// discoverTenants checks the SaaS platforms for tenants, and the code package and container registries
+// for organizations, named after the root domains. The confirmed tenants are added to the graph database,
+// and all the discoveries are recorded in the findings with the platform.
+func (e *Enumeration) discoverTenants() *sync.WaitGroup {
+	var wg sync.WaitGroup
+
+	if !tenantsEnabled(e.Config) {
+		return &wg
+	}
+
+	checker := saas.NewChecker()
+	checked := make(map[string]struct{})
+	for _, domain := range e.Config.Domains() {
+		names := saas.TenantNames(domain)
+		// Root domains that only differ by the TLD share the tenant names
+		if len(names) == 0 {
+			continue
+		} else if _, found := checked[names[0]]; found {
+			continue
+		}
+		checked[names[0]] = struct{}{}
+
+		wg.Add(1)
+		go func(domain string) {
+			defer wg.Done()
+
+			for _, t := range checker.Discover(e.ctx, domain) {
+				ftype, title := "saas_tenant", "SaaS tenant named after the organization"
+				// Registry organizations share the host of the registry
+				if t.Registry {
+					ftype, title = "code_registry", "Registry organization named after the organization"
+				} else if _, err := e.graph.UpsertFQDN(e.ctx, t.Host); err != nil {
+					e.Config.Log.Printf("Failed to insert the %s tenant %s: %v", t.Platform, t.Host, err)
+				}
+
+				e.Config.Log.Printf("%s: %s (%s) for %s", title, t.URL, t.Platform, domain)
+				e.addFinding(&findings.Finding{
+					Asset: domain,
+					Type:  ftype,
+					Title: title,
+					Attributes: map[string]string{
+						"platform": t.Platform,
+						"tenant":   t.Name,
+						"host":     t.Host,
+						"url":      t.URL,
+						"status":   strconv.Itoa(t.Status),
+					},
+				})
+			}
+		}(domain)
+	}
+	return &wg
+}
@@ -0,0 +1,116 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+// Timing profiles selected using the timing option of the configuration.
+const (
+	TimingNormal  = "normal"
+	TimingStealth = "stealth"
+)
+
+// Provides the stealth timing with a sequence of random numbers different from other components.
+const stealthSeedSalt = 1
+
+// The longest random delay added before each DNS query and data source request in the stealth profile.
+const stealthMaxDelay = 3 * time.Second
+
+// The longest random delay added before each data source request by the randomize option.
+const randomizeMaxDelay = time.Second
+
+// TimingProfile returns the timing profile selected by the configuration.
+func TimingProfile(cfg *config.Config) string {
+	if v, ok := cfg.Options["timing"].(string); ok && strings.EqualFold(v, TimingStealth) {
+		return TimingStealth
+	}
+	return TimingNormal
+}
+
+// stealthTiming adds randomized delays and rotates the order of the data sources.
+type stealthTiming struct {
+	sync.Mutex
+	rnd *rand.Rand
+	// sourceDelay and queryDelay are the longest delays added before the data source requests and DNS queries
+	sourceDelay time.Duration
+	queryDelay  time.Duration
+}
+
+func newStealthTiming(cfg *config.Config) *stealthTiming {
+	if TimingProfile(cfg) == TimingStealth {
+		return &stealthTiming{
+			rnd:         systems.NewRand(cfg, stealthSeedSalt),
+			sourceDelay: stealthMaxDelay,
+			queryDelay:  stealthMaxDelay,
+		}
+	}
+	// The randomize option varies the timing between the data sources, without slowing the DNS queries
+	if systems.Randomized(cfg) {
+		return &stealthTiming{
+			rnd:         systems.NewRand(cfg, stealthSeedSalt),
+			sourceDelay: randomizeMaxDelay,
+		}
+	}
+	return nil
+}
+
+// delay waits for a random duration before a data source request.
+func (st *stealthTiming) delay(ctx context.Context) {
+	if st != nil {
+		st.wait(ctx, st.sourceDelay)
+	}
+}
+
+// delayQuery waits for a random duration before a DNS query.
+func (st *stealthTiming) delayQuery(ctx context.Context) {
+	if st != nil {
+		st.wait(ctx, st.queryDelay)
+	}
+}
+
+func (st *stealthTiming) wait(ctx context.Context, limit time.Duration) {
+	if limit <= 0 {
+		return
+	}
+
+	st.Lock()
+	d := time.Duration(st.rnd.Int63n(int64(limit)))
+	st.Unlock()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// rotate returns the data sources in a random order when the stealth profile or the randomize option is selected.
+func (st *stealthTiming) rotate(srcs []service.Service) []service.Service {
+	if st == nil {
+		return srcs
+	}
+
+	rotated := make([]service.Service, len(srcs))
+	copy(rotated, srcs)
+
+	st.Lock()
+	st.rnd.Shuffle(len(rotated), func(i, j int) {
+		rotated[i], rotated[j] = rotated[j], rotated[i]
+	})
+	st.Unlock()
+	return rotated
+}
@@ -0,0 +1,35 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"path/filepath"
+
+	"github.com/owasp-amass/amass/v4/trash"
+	"github.com/owasp-amass/config/config"
+)
+
+// trashBin returns the bin adding the assets and relations removed by the operation to the trash in the
+// output directory, and the function writing the trash once the removals are done. The bin is nil when
+// the trash cannot be read, in which case nothing must be removed.
+func (e *Enumeration) trashBin(operation, reason string) (*trash.Bin, func()) {
+	e.trashLock.Lock()
+
+	path := filepath.Join(config.OutputDirectory(e.Config.Dir), trash.FileName)
+	t, err := trash.Read(path)
+	if err != nil {
+		e.trashLock.Unlock()
+		e.Config.Log.Printf("The assets were not removed, since the trash could not be read: %v", err)
+		return nil, func() {}
+	}
+
+	return trash.NewBin(e.graph.DB, t, operation, trash.CurrentActor(), reason), func() {
+		defer e.trashLock.Unlock()
+
+		if err := t.Write(path); err != nil {
+			e.Config.Log.Printf("Failed to write the trash: %v", err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/probe"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+// probesFromConfig returns the clients for the remote probes listed in the probes option.
+func probesFromConfig(cfg *config.Config) ([]*probe.Client, error) {
+	raw, found := cfg.Options["probes"]
+	if !found {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("probes must be a list")
+	}
+
+	var clients []*probe.Client
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("each probe must provide the url, region, token and TLS settings")
+		}
+
+		u, _ := m["url"].(string)
+		if u == "" {
+			return nil, fmt.Errorf("the probe url setting is required")
+		}
+
+		token, _ := m["token"].(string)
+		region, _ := m["region"].(string)
+		if region == "" {
+			region = u
+		}
+		c := probe.NewClient(u, token, region)
+
+		ca, _ := m["ca"].(string)
+		cert, _ := m["cert"].(string)
+		key, _ := m["key"].(string)
+		tlsConfig, err := probe.ClientTLSConfig(ca, cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("the %s probe: %v", region, err)
+		}
+		if tlsConfig != nil {
+			c.SetTLSConfig(tlsConfig)
+		}
+		clients = append(clients, c)
+	}
+	return clients, nil
+}
+
+func (e *Enumeration) hasVantagePoints() bool {
+	return len(e.probes) > 0 || (e.ecs != nil && len(e.ecs.Subnets) > 1)
+}
+
+// vantageAnswers returns the answers for the name that were only obtained from the additional
+// client subnets or remote probes, and records a finding when the probes observe different answers.
+func (e *Enumeration) vantageAnswers(ctx context.Context, name string, qtype uint16, known []requests.DNSAnswer) []requests.DNSAnswer {
+	var answers []requests.DNSAnswer
+
+	if e.ecs != nil && len(e.ecs.Subnets) > 1 {
+		answers = append(answers, e.clientSubnetAnswers(ctx, name, qtype, known)...)
+	}
+	if len(e.probes) > 0 {
+		answers = append(answers, e.probeAnswers(ctx, name, qtype, append(known, answers...))...)
+	}
+	return answers
+}
+
+func (e *Enumeration) probeAnswers(ctx context.Context, name string, qtype uint16, known []requests.DNSAnswer) []requests.DNSAnswer {
+	local := make(map[string]struct{})
+	for _, a := range known {
+		if uint16(a.Type) == qtype {
+			local[strings.ToLower(a.Data)] = struct{}{}
+		}
+	}
+
+	seen := make(map[string]struct{})
+	var answers []requests.DNSAnswer
+	for _, p := range e.probes {
+		resp, err := p.Resolve(ctx, name, qtype)
+		if err != nil {
+			if e.Config.Verbose {
+				e.Config.Log.Printf("Probe %s: %v", p.Region, err)
+			}
+			continue
+		}
+		if resp.Rcode != dns.RcodeSuccess {
+			continue
+		}
+
+		var diff []string
+		for _, a := range resp.Answers {
+			if a.Type != qtype {
+				continue
+			}
+
+			data := strings.ToLower(a.Data)
+			if _, found := local[data]; found {
+				continue
+			}
+
+			diff = append(diff, data)
+			if _, found := seen[data]; !found {
+				seen[data] = struct{}{}
+				answers = append(answers, requests.DNSAnswer{
+					Name: name,
+					Type: int(a.Type),
+					TTL:  int(a.TTL),
+					Data: a.Data,
+				})
+			}
+		}
+
+		if len(diff) > 0 {
+			sort.Strings(diff)
+			e.addFinding(&findings.Finding{
+				Asset:   name,
+				Type:    "vantage",
+				Title:   "DNS answers differ by vantage point",
+				Details: fmt.Sprintf("The probe in %s observed %s records not returned to the engine", resp.Region, dns.TypeToString[qtype]),
+				Attributes: map[string]string{
+					"region":  resp.Region,
+					"answers": strings.Join(diff, ","),
+				},
+			})
+		}
+	}
+	return answers
+}
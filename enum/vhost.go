@@ -0,0 +1,169 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/net/differ"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// TypeHTTPVirtualHost is the type of the findings recorded for the virtual hosts confirmed using the Host header
+	TypeHTTPVirtualHost = "http_vhost"
+	vhostTimeout        = 10 * time.Second
+)
+
+// vhostBaselines are the hosts provided to obtain the default response of the server, twice.
+var vhostBaselines = []string{"amass-vhost-baseline.invalid", "amass-vhost-check.invalid"}
+
+// vhostLabels are combined with the root domains to generate the names of common virtual hosts,
+// which are tried along with the discovered names.
+var vhostLabels = []string{
+	"admin", "api", "beta", "dev", "internal", "intranet", "jenkins", "portal", "stage", "staging", "test", "uat",
+}
+
+// vhostEnabled returns true when the vhost_bruteforce option enables the Host header brute forcing during active enumerations.
+func vhostEnabled(cfg *config.Config) bool {
+	enabled, ok := cfg.Options["vhost_bruteforce"].(bool)
+	return ok && enabled && cfg.Active
+}
+
+// bruteForceVHosts sends requests to the in-scope web servers with the discovered and generated names in the
+// Host header, and records a finding for each name receiving a response that differs from the default response
+// of the server. The confirmed virtual hosts are added to the graph database, since they commonly have no DNS records.
+func (e *Enumeration) bruteForceVHosts() {
+	s := e.hosts
+	if s == nil || !vhostEnabled(e.Config) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxCertChecks)
+	for addr, names := range s.targets(vhostLabels) {
+		for _, port := range e.Config.Scope.Ports {
+			wg.Add(1)
+			go func(addr string, port int, names []string) {
+				defer wg.Done()
+
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				for _, f := range e.vhostBindings(addr, port, names) {
+					if _, err := e.graph.UpsertFQDN(e.ctx, f.Asset); err != nil {
+						e.Config.Log.Printf("Failed to insert the virtual host %s: %v", f.Asset, err)
+					}
+					e.addFinding(f)
+				}
+			}(addr, port, names)
+		}
+	}
+	wg.Wait()
+}
+
+// vhostBindings returns the findings for the names confirmed as virtual hosts served at the address and port.
+func (e *Enumeration) vhostBindings(addr string, port int, names []string) []*findings.Finding {
+	client := vhostClient(addr, port)
+
+	schemes := []string{"https", "http"}
+	switch port {
+	case 80, 8080:
+		schemes = []string{"http"}
+	case 443, 8443:
+		schemes = []string{"https"}
+	}
+
+	var scheme string
+	var first *differ.Signature
+	for _, sch := range schemes {
+		if sig, err := e.vhostRequest(client, sch, vhostBaselines[0], port); err == nil {
+			scheme, first = sch, sig
+			break
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	// The servers providing another response for each unknown host cannot reveal the virtual hosts
+	again, err := e.vhostRequest(client, scheme, vhostBaselines[1], port)
+	if err != nil {
+		return nil
+	}
+	baseline := differ.NewBaseline(first, again)
+	if !baseline.Stable() {
+		return nil
+	}
+
+	var results []*findings.Finding
+	for _, name := range names {
+		select {
+		case <-e.ctx.Done():
+			return results
+		default:
+		}
+
+		sig, err := e.vhostRequest(client, scheme, name, port)
+		if err != nil || !baseline.Differs(sig) {
+			continue
+		}
+
+		results = append(results, &findings.Finding{
+			Asset: name,
+			Type:  TypeHTTPVirtualHost,
+			Title: "Virtual host confirmed using the Host header",
+			Attributes: map[string]string{
+				"address": addr,
+				"port":    strconv.Itoa(port),
+				"url":     scheme + "://" + net.JoinHostPort(name, strconv.Itoa(port)) + "/",
+				"status":  strconv.Itoa(sig.Status),
+				"length":  strconv.Itoa(sig.Length),
+			},
+		})
+	}
+	return results
+}
+
+// vhostClient returns a client connecting to the address for any host, so the name is provided in both
+// the Host header and the SNI extension. Redirects are not followed, since they distinguish the virtual hosts.
+func vhostClient(addr string, port int) *http.Client {
+	target := net.JoinHostPort(addr, strconv.Itoa(port))
+
+	return amasshttp.NewClient("vhost", &amasshttp.ClientOptions{
+		Timeout: vhostTimeout,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return amassnet.DialContext(ctx, network, target)
+		},
+		Insecure:     true,
+		NoRedirects:  true,
+		NoKeepAlives: true,
+	})
+}
+
+func (e *Enumeration) vhostRequest(client *http.Client, scheme, name string, port int) (*differ.Signature, error) {
+	u := scheme + "://" + net.JoinHostPort(name, strconv.Itoa(port)) + "/"
+
+	req, err := http.NewRequestWithContext(e.ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", amasshttp.UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// The servers commonly reflect the requested host in the content and redirects
+	return differ.FromResponse(resp, name)
+}
@@ -0,0 +1,48 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestVHostBindings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.Host)
+
+		switch host {
+		case "hidden.owasp.org":
+			fmt.Fprint(w, "<html><title>Internal dashboard</title>"+strings.Repeat("<div>metrics</div>", 20)+"</html>")
+		case "moved.owasp.org":
+			http.Redirect(w, r, "https://sso.owasp.org/login", http.StatusFound)
+		default:
+			// The default site reflects the requested host
+			fmt.Fprintf(w, "<html>Welcome to %s</html>", host)
+		}
+	}))
+	defer srv.Close()
+
+	addr, p, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	port, _ := strconv.Atoi(p)
+	e := &Enumeration{ctx: context.Background()}
+
+	fs := e.vhostBindings(addr, port, []string{"dev.owasp.org", "hidden.owasp.org", "moved.owasp.org", "www.owasp.org"})
+	if len(fs) != 2 {
+		t.Fatalf("Expected two virtual hosts, got %d", len(fs))
+	}
+	if f := fs[0]; f.Asset != "hidden.owasp.org" || f.Type != TypeHTTPVirtualHost || f.Attributes["status"] != "200" || f.Attributes["address"] != addr {
+		t.Errorf("Unexpected virtual host: %+v", f)
+	}
+	if f := fs[1]; f.Asset != "moved.owasp.org" || f.Attributes["status"] != "302" {
+		t.Errorf("Unexpected virtual host: %+v", f)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/risk"
+	"github.com/owasp-amass/amass/v4/views"
+	"github.com/owasp-amass/config/config"
+)
+
+// refreshViews rebuilds the views read by the resolutions and services reports once the enumeration has
+// stored all of its data, when the views option is enabled, so the reports do not walk the graph database.
+// The risk scores are computed from the views when the risk option is enabled.
+func (e *Enumeration) refreshViews() {
+	enabled, _ := e.Config.Options["views"].(bool)
+	// The risk option was already validated by the enumeration
+	scorer, _ := risk.ParseScorer(e.Config.Options["risk"])
+	if !enabled && scorer == nil {
+		return
+	}
+
+	dir := config.OutputDirectory(e.Config.Dir)
+	fs, err := findings.Read(filepath.Join(dir, findings.FileName))
+	if err != nil {
+		e.Config.Log.Printf("Failed to read the findings for the views: %v", err)
+	}
+
+	v, err := views.Build(e.ctx, e.graph.DB, fs, func(name string) bool {
+		return e.Config.IsDomainInScope(name)
+	})
+	if err != nil {
+		e.Config.Log.Printf("Failed to build the views: %v", err)
+		return
+	}
+	if enabled {
+		if err := views.Save(filepath.Join(dir, views.FileName), v); err != nil {
+			e.Config.Log.Printf("Failed to save the views: %v", err)
+		}
+	}
+
+	if scorer != nil {
+		if err := risk.Save(filepath.Join(dir, risk.FileName), scorer.Build(v, fs, time.Now())); err != nil {
+			e.Config.Log.Printf("Failed to save the risk scores: %v", err)
+		}
+	}
+}
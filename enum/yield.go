@@ -0,0 +1,154 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	yieldFileName = "source_yield.json"
+	// The number of enumerations a data source must have participated in before it can be skipped.
+	minRunsBeforeSkip = 3
+)
+
+// yieldStats is the historical yield of a data source for a single root domain name.
+type yieldStats struct {
+	Runs   int `json:"runs"`
+	Unique int `json:"unique"`
+}
+
+// sourceYield learns which data sources provide unique names for the root domains and TLDs in scope.
+type sourceYield struct {
+	sync.Mutex
+	path    string
+	history map[string]map[string]*yieldStats // root domain -> data source -> stats
+	current map[string]map[string]int         // root domain -> data source -> unique names
+}
+
+func newSourceYield(cfg *config.Config) *sourceYield {
+	y := &sourceYield{
+		path:    filepath.Join(config.OutputDirectory(cfg.Dir), yieldFileName),
+		history: make(map[string]map[string]*yieldStats),
+		current: make(map[string]map[string]int),
+	}
+
+	if data, err := os.ReadFile(y.path); err == nil {
+		_ = json.Unmarshal(data, &y.history)
+	}
+	return y
+}
+
+// record counts a unique name provided by the named data source for the root domain.
+func (y *sourceYield) record(src, domain string) {
+	y.Lock()
+	defer y.Unlock()
+
+	if _, found := y.current[domain]; !found {
+		y.current[domain] = make(map[string]int)
+	}
+	y.current[domain][src]++
+}
+
+// stats returns the historical yield of the data source for the root domain. When the
+// root domain has not been enumerated before, the stats for the whole TLD are returned.
+func (y *sourceYield) stats(src, domain string) *yieldStats {
+	if srcs, found := y.history[domain]; found {
+		if s, found := srcs[src]; found {
+			return s
+		}
+	}
+
+	agg := new(yieldStats)
+	tld := domainTLD(domain)
+	for d, srcs := range y.history {
+		if s, found := srcs[src]; found && domainTLD(d) == tld {
+			agg.Runs += s.Runs
+			agg.Unique += s.Unique
+		}
+	}
+	return agg
+}
+
+// prioritize sorts the data sources by historical yield for the root domains, highest first.
+// When skipEmpty is true, data sources that have consistently provided no unique names are removed.
+func (y *sourceYield) prioritize(srcs []service.Service, domains []string, skipEmpty bool) []service.Service {
+	y.Lock()
+	defer y.Unlock()
+
+	score := make(map[string]float64, len(srcs))
+	var selected []service.Service
+	for _, src := range srcs {
+		var runs, unique int
+
+		empty := len(domains) > 0
+		for _, d := range domains {
+			s := y.stats(src.String(), d)
+
+			runs += s.Runs
+			unique += s.Unique
+			if s.Runs < minRunsBeforeSkip || s.Unique > 0 {
+				empty = false
+			}
+		}
+		if skipEmpty && empty {
+			continue
+		}
+		// Data sources without a history are given the benefit of the doubt
+		score[src.String()] = 1
+		if runs > 0 {
+			score[src.String()] = float64(unique) / float64(runs)
+		}
+		selected = append(selected, src)
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return score[selected[i].String()] > score[selected[j].String()]
+	})
+	return selected
+}
+
+// save merges the yield of the current enumeration into the history and writes it to the output directory.
+func (y *sourceYield) save(srcs []service.Service, domains []string) error {
+	y.Lock()
+	defer y.Unlock()
+
+	for _, d := range domains {
+		if _, found := y.history[d]; !found {
+			y.history[d] = make(map[string]*yieldStats)
+		}
+
+		for _, src := range srcs {
+			name := src.String()
+			if _, found := y.history[d][name]; !found {
+				y.history[d][name] = new(yieldStats)
+			}
+
+			y.history[d][name].Runs++
+			y.history[d][name].Unique += y.current[d][name]
+		}
+	}
+
+	data, err := json.Marshal(y.history)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(y.path, data, 0640)
+}
+
+func domainTLD(domain string) string {
+	if i := strings.LastIndex(domain, "."); i >= 0 {
+		return domain[i+1:]
+	}
+	return domain
+}
@@ -0,0 +1,55 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/config/config"
+)
+
+type namedSource struct {
+	*service.BaseService
+}
+
+func newNamedSource(name string) service.Service {
+	return &namedSource{BaseService: service.NewBaseService(nil, name)}
+}
+
+func TestSourceYieldPrioritize(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+
+	srcs := []service.Service{
+		newNamedSource("Empty"),
+		newNamedSource("Fruitful"),
+		newNamedSource("Unknown"),
+	}
+	domains := []string{"owasp.org"}
+
+	for i := 0; i < minRunsBeforeSkip; i++ {
+		y := newSourceYield(cfg)
+
+		y.record("Fruitful", "owasp.org")
+		if err := y.save(srcs[:2], domains); err != nil {
+			t.Fatalf("Failed to save the yield statistics: %v", err)
+		}
+	}
+
+	y := newSourceYield(cfg)
+	if got := y.prioritize(srcs, domains, false); len(got) != 3 || got[2].String() != "Empty" {
+		t.Errorf("Expected the empty data source to be last, got %v", got)
+	}
+	for _, src := range y.prioritize(srcs, domains, true) {
+		if src.String() == "Empty" {
+			t.Errorf("Expected the empty data source to be skipped")
+		}
+	}
+	// The history for the TLD is used for new root domains
+	if got := y.prioritize(srcs, []string{"example.org"}, true); len(got) != 2 {
+		t.Errorf("Expected two data sources for the new root domain, got %d", len(got))
+	}
+}
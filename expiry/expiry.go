@@ -0,0 +1,288 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package expiry tracks when the registrations of the root domains expire, so the domains
+// approaching their expiration can be renewed before they are accidentally lost.
+package expiry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/rdap"
+	"github.com/owasp-amass/amass/v4/net/whois"
+	"golang.org/x/net/publicsuffix"
+)
+
+// TypeDomainExpiration is the type of the findings recorded for the domains approaching their expiration.
+const TypeDomainExpiration = "domain_expiration"
+
+// DefaultWithin is the number of days before the expiration when the domains are reported by default.
+const DefaultWithin = 30
+
+// DefaultWorkers is the number of domains checked concurrently by default.
+const DefaultWorkers = 8
+
+// Domain is the registration of a root domain name.
+type Domain struct {
+	Domain    string    `json:"domain"`
+	TLD       string    `json:"tld"`
+	Registrar string    `json:"registrar,omitempty"`
+	Created   time.Time `json:"created,omitempty"`
+	Expires   time.Time `json:"expires,omitempty"`
+	// Source is the protocol that provided the registration data
+	Source string `json:"source"`
+}
+
+// Checker obtains the registrations of the domains using RDAP, and WHOIS for the TLDs that do not provide RDAP.
+type Checker struct {
+	RDAP  *rdap.Client
+	WHOIS *whois.Client
+	// Workers is the number of domains checked concurrently, or zero for the DefaultWorkers.
+	// The queries sent to each registry are still rate limited by the registry queue
+	Workers int
+}
+
+// NewChecker returns a Checker using the default RDAP and WHOIS clients.
+func NewChecker() *Checker {
+	return &Checker{
+		RDAP:  rdap.NewClient(),
+		WHOIS: whois.NewClient(),
+	}
+}
+
+// Lookup returns the registration of the domain.
+func (c *Checker) Lookup(ctx context.Context, domain string) (*Domain, error) {
+	d := &Domain{
+		Domain: domain,
+		Source: "rdap",
+	}
+	if tld, _ := publicsuffix.PublicSuffix(domain); tld != "" {
+		d.TLD = tld
+	}
+
+	reg, err := c.RDAP.DomainRegistration(ctx, domain)
+	// Fallback to WHOIS for the TLDs that do not provide RDAP or the expiration event
+	if err != nil || reg.Expires.IsZero() {
+		resp, werr := c.WHOIS.Query(ctx, domain)
+		if werr != nil {
+			if err == nil {
+				err = werr
+			}
+			return nil, err
+		}
+
+		d.Source = "whois"
+		reg = resp.Registration()
+	}
+
+	d.Registrar = reg.Registrar
+	d.Created = reg.Created
+	d.Expires = reg.Expires
+	if d.Expires.IsZero() {
+		return d, fmt.Errorf("the registration of %s does not provide the expiration date", domain)
+	}
+	return d, nil
+}
+
+// Check returns the registrations of the domains sorted by the expiration date, and the errors that occurred.
+// The domains without an expiration date are included last, so the registrations can be verified manually.
+func (c *Checker) Check(ctx context.Context, domains []string) ([]*Domain, error) {
+	workers := c.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	// The results are kept in the order of the domains, so the errors do not depend on the timing
+	regs := make([]*Domain, len(domains))
+	errs := make([]error, len(domains))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for i, name := range domains {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			regs[i], errs[i] = c.Lookup(ctx, name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	var results []*Domain
+	var msgs []string
+	for i, name := range domains {
+		if errs[i] != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", name, errs[i]))
+		}
+		if regs[i] != nil {
+			results = append(results, regs[i])
+		}
+	}
+
+	Sort(results)
+	if len(msgs) > 0 {
+		return results, errors.New(strings.Join(msgs, "; "))
+	}
+	return results, nil
+}
+
+// Sort orders the domains by the expiration date, with the domains without an expiration date last.
+func Sort(domains []*Domain) {
+	sort.SliceStable(domains, func(i, j int) bool {
+		a, b := domains[i], domains[j]
+
+		if a.Expires.IsZero() != b.Expires.IsZero() {
+			return b.Expires.IsZero()
+		}
+		if !a.Expires.Equal(b.Expires) {
+			return a.Expires.Before(b.Expires)
+		}
+		return a.Domain < b.Domain
+	})
+}
+
+// DaysLeft returns the number of whole days remaining before the domain expires, which is negative once it expired.
+func (d *Domain) DaysLeft(now time.Time) int {
+	left := d.Expires.Sub(now)
+	days := int(left.Hours() / 24)
+	if left < 0 {
+		days--
+	}
+	return days
+}
+
+// ExpiresWithin returns true when the expiration date is known and falls within the number of days.
+func (d *Domain) ExpiresWithin(days int, now time.Time) bool {
+	return !d.Expires.IsZero() && !d.Expires.After(now.AddDate(0, 0, days))
+}
+
+// Finding returns the finding recorded for the domain approaching its expiration. The domains that
+// expire within a week are assigned a higher severity, and the expired domains the highest severity.
+func (d *Domain) Finding(now time.Time) *findings.Finding {
+	days := d.DaysLeft(now)
+
+	f := &findings.Finding{
+		Asset:    d.Domain,
+		Type:     TypeDomainExpiration,
+		Severity: findings.SeverityMedium,
+		Title:    fmt.Sprintf("%s expires in %d days", d.Domain, days),
+		Attributes: map[string]string{
+			"not_after": d.Expires.UTC().Format(time.RFC3339),
+			"registrar": d.Registrar,
+			"tld":       d.TLD,
+			"source":    d.Source,
+		},
+	}
+
+	switch {
+	case days < 0:
+		f.Severity = findings.SeverityCritical
+		f.Title = fmt.Sprintf("%s expired %d days ago", d.Domain, -days)
+	case days < 7:
+		f.Severity = findings.SeverityHigh
+	}
+	return f
+}
+
+// key identifies the finding, so each expiration date of a domain is only reported once.
+func key(f *findings.Finding) string {
+	return f.Asset + " " + f.Attributes["not_after"]
+}
+
+// NewFindings returns the findings for the domains expiring within the number of days that are not
+// among the findings already recorded. A renewed domain is reported again when it approaches the new date.
+func NewFindings(recorded []*findings.Finding, domains []*Domain, within int, now time.Time) []*findings.Finding {
+	seen := make(map[string]struct{})
+	for _, f := range recorded {
+		if f.Type == TypeDomainExpiration {
+			seen[key(f)] = struct{}{}
+		}
+	}
+
+	var results []*findings.Finding
+	for _, d := range domains {
+		if !d.ExpiresWithin(within, now) {
+			continue
+		}
+		f := d.Finding(now)
+
+		k := key(f)
+		if _, found := seen[k]; found {
+			continue
+		}
+		seen[k] = struct{}{}
+		results = append(results, f)
+	}
+	return results
+}
+
+// Summary describes the registrations sharing a TLD or registrar.
+type Summary struct {
+	Key     string `json:"key"`
+	Domains int    `json:"domains"`
+	// Expiring is the number of domains expiring within the days provided
+	Expiring int `json:"expiring"`
+	// Next is the earliest expiration date among the domains
+	Next time.Time `json:"next,omitempty"`
+}
+
+// ByTLD returns the summaries of the registrations for each TLD, with the earliest expirations first.
+func ByTLD(domains []*Domain, within int, now time.Time) []*Summary {
+	return summarize(domains, within, now, func(d *Domain) string { return d.TLD })
+}
+
+// ByRegistrar returns the summaries of the registrations for each registrar, with the earliest expirations first.
+func ByRegistrar(domains []*Domain, within int, now time.Time) []*Summary {
+	return summarize(domains, within, now, func(d *Domain) string { return d.Registrar })
+}
+
+func summarize(domains []*Domain, within int, now time.Time, keyFn func(d *Domain) string) []*Summary {
+	byKey := make(map[string]*Summary)
+
+	var results []*Summary
+	for _, d := range domains {
+		k := keyFn(d)
+		if k == "" {
+			k = "unknown"
+		}
+
+		s, found := byKey[k]
+		if !found {
+			s = &Summary{Key: k}
+			byKey[k] = s
+			results = append(results, s)
+		}
+
+		s.Domains++
+		if d.ExpiresWithin(within, now) {
+			s.Expiring++
+		}
+		if !d.Expires.IsZero() && (s.Next.IsZero() || d.Expires.Before(s.Next)) {
+			s.Next = d.Expires
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+
+		if a.Next.IsZero() != b.Next.IsZero() {
+			return b.Next.IsZero()
+		}
+		if !a.Next.Equal(b.Next) {
+			return a.Next.Before(b.Next)
+		}
+		return a.Key < b.Key
+	})
+	return results
+}
@@ -0,0 +1,156 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package expiry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/rdap"
+	"github.com/owasp-amass/amass/v4/net/registry"
+	"github.com/owasp-amass/amass/v4/net/whois"
+)
+
+func TestCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/domain/owasp.org" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"events": [{"eventAction": "expiration", "eventDate": "2027-09-13T04:00:00Z"}],
+			"entities": [{"roles": ["registrar"], "vcardArray": ["vcard", [["fn", {}, "text", "Example Registrar"]]]}]
+		}`))
+	}))
+	defer srv.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start the WHOIS server: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			if strings.TrimSpace(line) == "owasp.se" {
+				_, _ = conn.Write([]byte("registrar: Other Registrar\nexpires: 2026-11-01\n"))
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	c := &Checker{RDAP: rdap.NewClient(), WHOIS: whois.NewClient()}
+	c.RDAP.BaseURL = srv.URL
+	c.RDAP.Queue = registry.NewQueue()
+	c.WHOIS.Queue = c.RDAP.Queue
+	c.WHOIS.SetServer("se", ln.Addr().String())
+	c.WHOIS.SetServer("net", ln.Addr().String())
+
+	domains, err := c.Check(context.Background(), []string{"owasp.org", "owasp.net", "owasp.se"})
+	if err == nil {
+		t.Error("Expected an error for the domain without an expiration date")
+	}
+	if len(domains) != 3 {
+		t.Fatalf("Expected three domains, got %d", len(domains))
+	}
+	if d := domains[0]; d.Domain != "owasp.se" || d.Source != "whois" || d.TLD != "se" || d.Registrar != "Other Registrar" {
+		t.Errorf("Unexpected first domain: %+v", d)
+	}
+	if d := domains[1]; d.Domain != "owasp.org" || d.Source != "rdap" || d.Registrar != "Example Registrar" {
+		t.Errorf("Unexpected second domain: %+v", d)
+	}
+	if d := domains[2]; d.Domain != "owasp.net" || !d.Expires.IsZero() {
+		t.Errorf("Expected the domain without an expiration date last: %+v", d)
+	}
+}
+
+func TestCheckWorkers(t *testing.T) {
+	var inflight, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"events": [{"eventAction": "expiration", "eventDate": "2027-09-13T04:00:00Z"}]}`))
+	}))
+	defer srv.Close()
+
+	c := &Checker{RDAP: rdap.NewClient(), WHOIS: whois.NewClient(), Workers: 4}
+	c.RDAP.BaseURL = srv.URL
+	c.RDAP.Queue = registry.NewQueue()
+	c.RDAP.Queue.SetInterval("127.0.0.1", time.Millisecond)
+
+	var names []string
+	for i := 0; i < 8; i++ {
+		names = append(names, fmt.Sprintf("owasp%d.org", i))
+	}
+
+	domains, err := c.Check(context.Background(), names)
+	if err != nil || len(domains) != len(names) {
+		t.Fatalf("Expected %d domains, got %d: %v", len(names), len(domains), err)
+	}
+	for i, d := range domains {
+		if d.Domain != names[i] {
+			t.Errorf("Expected %s at position %d, got %s", names[i], i, d.Domain)
+		}
+	}
+	if p := atomic.LoadInt32(&peak); p < 2 || p > 4 {
+		t.Errorf("Expected between 2 and 4 concurrent lookups, got %d", p)
+	}
+}
+
+func TestNewFindings(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	domains := []*Domain{
+		{Domain: "expired.com", TLD: "com", Expires: now.AddDate(0, 0, -2)},
+		{Domain: "soon.com", TLD: "com", Registrar: "A", Expires: now.AddDate(0, 0, 3)},
+		{Domain: "later.org", TLD: "org", Registrar: "A", Expires: now.AddDate(0, 0, 20)},
+		{Domain: "distant.org", TLD: "org", Registrar: "B", Expires: now.AddDate(1, 0, 0)},
+		{Domain: "unknown.io", TLD: "io"},
+	}
+
+	fs := NewFindings(nil, domains, DefaultWithin, now)
+	if len(fs) != 3 {
+		t.Fatalf("Expected three findings, got %d", len(fs))
+	}
+	if fs[0].Severity != findings.SeverityCritical || fs[1].Severity != findings.SeverityHigh || fs[2].Severity != findings.SeverityMedium {
+		t.Errorf("Unexpected severities: %s %s %s", fs[0].Severity, fs[1].Severity, fs[2].Severity)
+	}
+	if fs[2].Title != "later.org expires in 20 days" || fs[2].Attributes["not_after"] != "2026-10-21T00:00:00Z" {
+		t.Errorf("Unexpected finding: %+v", fs[2])
+	}
+	if again := NewFindings(fs, domains, DefaultWithin, now); len(again) != 0 {
+		t.Errorf("Expected the recorded findings to be skipped, got %d", len(again))
+	}
+
+	tlds := ByTLD(domains, DefaultWithin, now)
+	if len(tlds) != 3 || tlds[0].Key != "com" || tlds[0].Domains != 2 || tlds[0].Expiring != 2 || tlds[2].Key != "io" {
+		t.Errorf("Unexpected TLD summaries: %+v %+v %+v", tlds[0], tlds[1], tlds[2])
+	}
+	regs := ByRegistrar(domains, DefaultWithin, now)
+	if len(regs) != 3 || regs[0].Key != "unknown" || regs[1].Key != "A" || regs[1].Expiring != 2 || regs[2].Key != "B" {
+		t.Errorf("Unexpected registrar summaries: %+v %+v %+v", regs[0], regs[1], regs[2])
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// SnapshotDir is the name of the directory in the output directory that stores the tagged snapshots.
+const SnapshotDir = "snapshots"
+
+// FormatTable writes the diff as plain text tables.
+const FormatTable = "table"
+
+// DiffFormats are the formats supported by WriteDiff.
+var DiffFormats = []string{FormatTable, FormatJSON, FormatHTML}
+
+var tagRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidTag returns an error when the snapshot tag cannot be used as a file name.
+func ValidTag(tag string) error {
+	if !tagRE.MatchString(tag) {
+		return fmt.Errorf("%s is not a valid snapshot tag: use letters, digits, dots, dashes and underscores", tag)
+	}
+	return nil
+}
+
+// SnapshotPath returns the path of the file storing the snapshot tag within the output directory.
+func SnapshotPath(dir, tag string) string {
+	return filepath.Join(dir, SnapshotDir, tag+".json")
+}
+
+// SaveSnapshot stores the Snapshot under the tag within the output directory, replacing
+// a previous snapshot with the same tag only once the file is complete.
+func SaveSnapshot(dir, tag string, s *Snapshot) error {
+	if err := ValidTag(tag); err != nil {
+		return err
+	}
+
+	path := SnapshotPath(dir, tag)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), tag+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := WriteSnapshot(tmp, FormatJSON, s); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot reads the snapshot tag from the output directory.
+func LoadSnapshot(dir, tag string) (*Snapshot, error) {
+	if err := ValidTag(tag); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(SnapshotPath(dir, tag))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("the snapshot %s was not found", tag)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse the snapshot %s: %v", tag, err)
+	}
+	return &s, nil
+}
+
+// SnapshotTags returns the tags of the snapshots stored in the output directory, sorted by name.
+func SnapshotTags(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, SnapshotDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasSuffix(name, ".json") {
+			tags = append(tags, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// SnapshotDiff is the difference between two snapshots of the graph.
+type SnapshotDiff struct {
+	From             string              `json:"from"`
+	To               string              `json:"to"`
+	FromGenerated    time.Time           `json:"from_generated"`
+	ToGenerated      time.Time           `json:"to_generated"`
+	AddedAssets      []*SnapshotAsset    `json:"added_assets"`
+	RemovedAssets    []*SnapshotAsset    `json:"removed_assets"`
+	ChangedAssets    []*AssetChange      `json:"changed_assets"`
+	AddedRelations   []*SnapshotRelation `json:"added_relations"`
+	RemovedRelations []*SnapshotRelation `json:"removed_relations"`
+}
+
+// AssetChange is an asset present in both snapshots whose tags changed, or that was
+// recreated between the snapshots.
+type AssetChange struct {
+	Type        string    `json:"type"`
+	Key         string    `json:"key"`
+	AddedTags   []string  `json:"added_tags,omitempty"`
+	RemovedTags []string  `json:"removed_tags,omitempty"`
+	FirstSeen   time.Time `json:"first_seen"`
+	// Recreated is true when the asset disappeared and was discovered again between the snapshots
+	Recreated bool `json:"recreated,omitempty"`
+}
+
+// Empty returns true when the snapshots provide the same assets and relations.
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.AddedAssets) == 0 && len(d.RemovedAssets) == 0 && len(d.ChangedAssets) == 0 &&
+		len(d.AddedRelations) == 0 && len(d.RemovedRelations) == 0
+}
+
+// DiffSnapshots returns the assets and relations added, removed and changed from the first
+// snapshot to the second. The snapshots must not have been generated with only the statistics.
+func DiffSnapshots(from, to *Snapshot, fromTag, toTag string) *SnapshotDiff {
+	d := &SnapshotDiff{
+		From:          fromTag,
+		To:            toTag,
+		FromGenerated: from.Generated,
+		ToGenerated:   to.Generated,
+	}
+
+	before := make(map[string]*SnapshotAsset, len(from.Assets))
+	for _, a := range from.Assets {
+		before[a.Type+":"+a.Key] = a
+	}
+	after := make(map[string]*SnapshotAsset, len(to.Assets))
+	for _, a := range to.Assets {
+		after[a.Type+":"+a.Key] = a
+	}
+
+	for id, a := range after {
+		prev, found := before[id]
+		if !found {
+			d.AddedAssets = append(d.AddedAssets, a)
+			continue
+		}
+
+		added, removed := diffTags(prev.Tags, a.Tags)
+		recreated := !prev.FirstSeen.IsZero() && a.FirstSeen.After(prev.FirstSeen)
+		if len(added) > 0 || len(removed) > 0 || recreated {
+			d.ChangedAssets = append(d.ChangedAssets, &AssetChange{
+				Type:        a.Type,
+				Key:         a.Key,
+				AddedTags:   added,
+				RemovedTags: removed,
+				FirstSeen:   a.FirstSeen,
+				Recreated:   recreated,
+			})
+		}
+	}
+	for id, a := range before {
+		if _, found := after[id]; !found {
+			d.RemovedAssets = append(d.RemovedAssets, a)
+		}
+	}
+
+	d.AddedRelations = diffRelations(to.Links, from.Links)
+	d.RemovedRelations = diffRelations(from.Links, to.Links)
+
+	sortSnapshotAssets(d.AddedAssets)
+	sortSnapshotAssets(d.RemovedAssets)
+	sort.Slice(d.ChangedAssets, func(i, j int) bool {
+		if d.ChangedAssets[i].Type != d.ChangedAssets[j].Type {
+			return d.ChangedAssets[i].Type < d.ChangedAssets[j].Type
+		}
+		return d.ChangedAssets[i].Key < d.ChangedAssets[j].Key
+	})
+	return d
+}
+
+// diffRelations returns the relations in a that are not in b, in the order of a.
+func diffRelations(a, b []*SnapshotRelation) []*SnapshotRelation {
+	set := make(map[SnapshotRelation]struct{}, len(b))
+	for _, rel := range b {
+		set[*rel] = struct{}{}
+	}
+
+	var results []*SnapshotRelation
+	for _, rel := range a {
+		if _, found := set[*rel]; !found {
+			set[*rel] = struct{}{}
+			results = append(results, rel)
+		}
+	}
+	return results
+}
+
+func diffTags(before, after []string) ([]string, []string) {
+	var added, removed []string
+	for _, t := range after {
+		if !containsString(before, t) {
+			added = append(added, t)
+		}
+	}
+	for _, t := range before {
+		if !containsString(after, t) {
+			removed = append(removed, t)
+		}
+	}
+	return added, removed
+}
+
+func sortSnapshotAssets(assets []*SnapshotAsset) {
+	sort.Slice(assets, func(i, j int) bool {
+		if assets[i].Type != assets[j].Type {
+			return assets[i].Type < assets[j].Type
+		}
+		return assets[i].Key < assets[j].Key
+	})
+}
+
+// WriteDiff writes the SnapshotDiff to w in the named format: table, json or html.
+func WriteDiff(w io.Writer, name string, d *SnapshotDiff) error {
+	switch strings.ToLower(name) {
+	case FormatTable:
+		return writeDiffTable(w, d)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case FormatHTML:
+		return diffTemplate.Execute(w, d)
+	}
+	return fmt.Errorf("%s is not a supported diff format", name)
+}
+
+func writeDiffTable(w io.Writer, d *SnapshotDiff) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Snapshot %s (%s) to %s (%s)\n", d.From, d.FromGenerated.Format("2006-01-02 15:04"),
+		d.To, d.ToGenerated.Format("2006-01-02 15:04"))
+	fmt.Fprintf(tw, "%d added, %d removed and %d changed assets; %d added and %d removed relations\n",
+		len(d.AddedAssets), len(d.RemovedAssets), len(d.ChangedAssets), len(d.AddedRelations), len(d.RemovedRelations))
+
+	if len(d.AddedAssets) > 0 || len(d.RemovedAssets) > 0 || len(d.ChangedAssets) > 0 {
+		fmt.Fprintf(tw, "\nCHANGE\tTYPE\tASSET\tDETAILS\n")
+		for _, a := range d.AddedAssets {
+			fmt.Fprintf(tw, "+\t%s\t%s\t%s\n", a.Type, a.Key, strings.Join(a.Tags, ", "))
+		}
+		for _, a := range d.RemovedAssets {
+			fmt.Fprintf(tw, "-\t%s\t%s\t%s\n", a.Type, a.Key, strings.Join(a.Tags, ", "))
+		}
+		for _, c := range d.ChangedAssets {
+			fmt.Fprintf(tw, "~\t%s\t%s\t%s\n", c.Type, c.Key, c.Details())
+		}
+	}
+	if len(d.AddedRelations) > 0 || len(d.RemovedRelations) > 0 {
+		fmt.Fprintf(tw, "\nCHANGE\tFROM\tRELATION\tTO\n")
+		for _, rel := range d.AddedRelations {
+			fmt.Fprintf(tw, "+\t%s\t%s\t%s\n", rel.From, rel.Relation, rel.To)
+		}
+		for _, rel := range d.RemovedRelations {
+			fmt.Fprintf(tw, "-\t%s\t%s\t%s\n", rel.From, rel.Relation, rel.To)
+		}
+	}
+	return tw.Flush()
+}
+
+// Details describes the change of the asset.
+func (c *AssetChange) Details() string {
+	var parts []string
+	if c.Recreated {
+		parts = append(parts, "rediscovered "+c.FirstSeen.Format("2006-01-02"))
+	}
+	for _, t := range c.AddedTags {
+		parts = append(parts, "+"+t)
+	}
+	for _, t := range c.RemovedTags {
+		parts = append(parts, "-"+t)
+	}
+	return strings.Join(parts, ", ")
+}
+
+var diffTemplate = template.Must(template.New("diff").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>OWASP Amass Snapshot Diff</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+th { background: #f0f0f0; }
+.added { background: #e6ffed; }
+.removed { background: #ffeef0; }
+.changed { background: #fff8c5; }
+</style>
+</head>
+<body>
+<h1>OWASP Amass Snapshot Diff</h1>
+<p>From {{ .From }} ({{ .FromGenerated.Format "2006-01-02 15:04:05 MST" }}) to {{ .To }} ({{ .ToGenerated.Format "2006-01-02 15:04:05 MST" }})</p>
+<table>
+<tr><th>Added Assets</th><th>Removed Assets</th><th>Changed Assets</th><th>Added Relations</th><th>Removed Relations</th></tr>
+<tr><td>{{ len .AddedAssets }}</td><td>{{ len .RemovedAssets }}</td><td>{{ len .ChangedAssets }}</td><td>{{ len .AddedRelations }}</td><td>{{ len .RemovedRelations }}</td></tr>
+</table>
+{{ if or .AddedAssets .RemovedAssets .ChangedAssets }}<h2>Assets</h2>
+<table>
+<tr><th>Change</th><th>Type</th><th>Asset</th><th>Details</th></tr>
+{{ range .AddedAssets }}<tr class="added"><td>Added</td><td>{{ .Type }}</td><td>{{ .Key }}</td><td>{{ join .Tags ", " }}</td></tr>
+{{ end }}{{ range .RemovedAssets }}<tr class="removed"><td>Removed</td><td>{{ .Type }}</td><td>{{ .Key }}</td><td>{{ join .Tags ", " }}</td></tr>
+{{ end }}{{ range .ChangedAssets }}<tr class="changed"><td>Changed</td><td>{{ .Type }}</td><td>{{ .Key }}</td><td>{{ .Details }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ if or .AddedRelations .RemovedRelations }}<h2>Relations</h2>
+<table>
+<tr><th>Change</th><th>From</th><th>Relation</th><th>To</th></tr>
+{{ range .AddedRelations }}<tr class="added"><td>Added</td><td>{{ .From }}</td><td>{{ .Relation }}</td><td>{{ .To }}</td></tr>
+{{ end }}{{ range .RemovedRelations }}<tr class="removed"><td>Removed</td><td>{{ .From }}</td><td>{{ .Relation }}</td><td>{{ .To }}</td></tr>
+{{ end }}</table>
+{{ end }}</body>
+</html>
+`))
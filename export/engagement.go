@@ -0,0 +1,62 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// EngagementFileName is the name of the file in the output directory that stores the engagement
+// metadata of the last session performing active techniques.
+const EngagementFileName = "engagement.json"
+
+// Engagement describes the authorization under which the assets were collected, so the exports
+// and reports shared with the client document it.
+type Engagement struct {
+	Client        string `json:"client,omitempty"`
+	Authorization string `json:"authorization,omitempty"`
+	Tester        string `json:"tester,omitempty"`
+}
+
+// Empty returns true when the Engagement does not provide any metadata.
+func (e *Engagement) Empty() bool {
+	return e == nil || (e.Client == "" && e.Authorization == "" && e.Tester == "")
+}
+
+func (e *Engagement) String() string {
+	return fmt.Sprintf("Client: %s, Authorization: %s, Tester: %s", e.Client, e.Authorization, e.Tester)
+}
+
+// ReadEngagement returns the engagement metadata stored in the file, or nil when the file does not exist.
+func ReadEngagement(path string) (*Engagement, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	e := new(Engagement)
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("failed to parse the engagement file %s: %v", path, err)
+	}
+	return e, nil
+}
+
+// Write stores the engagement metadata in the file.
+func (e *Engagement) Write(path string) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,169 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package export writes the asset graph in the formats loaded by visualization and graph
+// analysis tools, such as Gephi, Neo4j and Maltego.
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/asset-db/types"
+)
+
+// The supported export formats.
+const (
+	FormatGraphML = "graphml"
+	FormatGEXF    = "gexf"
+	FormatDOT     = "dot"
+	FormatCypher  = "cypher"
+)
+
+// Formats are the names of the supported export formats.
+var Formats = []string{FormatGraphML, FormatGEXF, FormatDOT, FormatCypher, FormatHTML, FormatJSON}
+
+// Graph is the set of assets and the relations between them to be exported.
+type Graph struct {
+	Assets    []*format.AssetRecord
+	Relations []*format.RelationRecord
+	// Engagement is written by all the formats when provided, so the authorization travels with the graph
+	Engagement *Engagement
+	ids        map[string]*format.AssetRecord
+	rels       map[string]struct{}
+}
+
+// NewGraph returns an empty Graph.
+func NewGraph() *Graph {
+	return &Graph{
+		ids:  make(map[string]*format.AssetRecord),
+		rels: make(map[string]struct{}),
+	}
+}
+
+// NodeID returns the identifier of the asset in the exported graph.
+func NodeID(rec *format.AssetRecord) string {
+	return rec.Type + ":" + rec.Key
+}
+
+// AddAsset adds the asset to the graph, unless it is already present.
+func (g *Graph) AddAsset(a *types.Asset) {
+	g.addRecord(format.NewAssetRecord(a))
+}
+
+// AddRelation adds the relation and both of the assets to the graph, unless they are already present.
+func (g *Graph) AddRelation(from *types.Asset, rel *types.Relation, to *types.Asset) {
+	rec := format.NewRelationRecord(from, rel, to)
+
+	key := NodeID(rec.From) + " " + rec.Relation + " " + NodeID(rec.To)
+	if _, found := g.rels[key]; found {
+		return
+	}
+	g.rels[key] = struct{}{}
+
+	g.addRecord(rec.From)
+	g.addRecord(rec.To)
+	g.Relations = append(g.Relations, rec)
+}
+
+func (g *Graph) addRecord(rec *format.AssetRecord) {
+	id := NodeID(rec)
+	if _, found := g.ids[id]; found {
+		return
+	}
+
+	g.ids[id] = rec
+	g.Assets = append(g.Assets, rec)
+}
+
+// Merge adds the assets and relations of the other graph, labeling each asset with the origin of the graph.
+// The assets present in both graphs keep the origins of both, the earliest first seen time and the latest
+// last seen time. The other graph is not modified.
+func (g *Graph) Merge(other *Graph, origin string) {
+	merge := func(rec *format.AssetRecord) *format.AssetRecord {
+		existing, found := g.ids[NodeID(rec)]
+		if !found {
+			c := *rec
+			c.Origins = []string{origin}
+			g.addRecord(&c)
+			return &c
+		}
+
+		if !containsString(existing.Origins, origin) {
+			existing.Origins = append(existing.Origins, origin)
+		}
+		if !rec.CreatedAt.IsZero() && (existing.CreatedAt.IsZero() || rec.CreatedAt.Before(existing.CreatedAt)) {
+			existing.CreatedAt = rec.CreatedAt
+		}
+		if rec.LastSeen.After(existing.LastSeen) {
+			existing.LastSeen = rec.LastSeen
+		}
+		return existing
+	}
+
+	for _, a := range other.Assets {
+		merge(a)
+	}
+	for _, rel := range other.Relations {
+		from, to := merge(rel.From), merge(rel.To)
+
+		key := NodeID(from) + " " + rel.Relation + " " + NodeID(to)
+		if _, found := g.rels[key]; found {
+			continue
+		}
+		g.rels[key] = struct{}{}
+
+		c := *rel
+		c.From, c.To = from, to
+		g.Relations = append(g.Relations, &c)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sort orders the assets and relations, so the same graph is always written the same way.
+func (g *Graph) sort() {
+	sort.Slice(g.Assets, func(i, j int) bool {
+		return NodeID(g.Assets[i]) < NodeID(g.Assets[j])
+	})
+	sort.Slice(g.Relations, func(i, j int) bool {
+		a, b := g.Relations[i], g.Relations[j]
+		if x, y := NodeID(a.From), NodeID(b.From); x != y {
+			return x < y
+		}
+		if a.Relation != b.Relation {
+			return a.Relation < b.Relation
+		}
+		return NodeID(a.To) < NodeID(b.To)
+	})
+}
+
+// Write writes the graph to w in the named format.
+func Write(w io.Writer, name string, g *Graph) error {
+	g.sort()
+
+	switch strings.ToLower(name) {
+	case FormatGraphML:
+		return WriteGraphML(w, g)
+	case FormatGEXF:
+		return WriteGEXF(w, g)
+	case FormatDOT:
+		return WriteDOT(w, g)
+	case FormatCypher:
+		return WriteCypher(w, g)
+	case FormatHTML, FormatJSON:
+		return WriteSnapshot(w, name, NewSnapshot(g, nil, false))
+	}
+	return fmt.Errorf("%s is not a supported export format; supported formats: %s", name, strings.Join(Formats, ", "))
+}
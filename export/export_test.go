@@ -0,0 +1,468 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func testGraph() *Graph {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	www := &types.Asset{ID: "1", CreatedAt: now, LastSeen: now, Asset: domain.FQDN{Name: "www.owasp.org"}}
+	addr := &types.Asset{ID: "2", CreatedAt: now, LastSeen: now, Asset: network.IPAddress{
+		Address: netip.MustParseAddr("192.0.2.1"),
+		Type:    "IPv4",
+	}}
+	quoted := &types.Asset{ID: "3", CreatedAt: now, LastSeen: now, Asset: domain.FQDN{Name: `a"b'c.owasp.org`}}
+	rel := &types.Relation{ID: "4", Type: "a_record", CreatedAt: now, LastSeen: now}
+
+	g := NewGraph()
+	g.AddRelation(www, rel, addr)
+	g.AddRelation(www, rel, addr)
+	g.AddAsset(www)
+	g.AddAsset(quoted)
+	return g
+}
+
+func TestGraph(t *testing.T) {
+	g := testGraph()
+
+	if len(g.Assets) != 3 || len(g.Relations) != 1 {
+		t.Errorf("Expected three assets and one relation, got %d and %d", len(g.Assets), len(g.Relations))
+	}
+	if err := Write(new(bytes.Buffer), "csv", g); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	local := testGraph()
+
+	later := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	www := &types.Asset{ID: "5", CreatedAt: later, LastSeen: later, Asset: domain.FQDN{Name: "www.owasp.org"}}
+	mail := &types.Asset{ID: "6", CreatedAt: later, LastSeen: later, Asset: domain.FQDN{Name: "mail.owasp.org"}}
+	other := NewGraph()
+	other.AddRelation(www, &types.Relation{ID: "7", Type: "cname_record", CreatedAt: later, LastSeen: later}, mail)
+
+	g := NewGraph()
+	g.Merge(local, "local")
+	g.Merge(other, "emea")
+	g.Merge(other, "emea")
+
+	if len(g.Assets) != 4 || len(g.Relations) != 2 {
+		t.Fatalf("Expected four assets and two relations, got %d and %d", len(g.Assets), len(g.Relations))
+	}
+
+	rec := g.ids["FQDN:www.owasp.org"]
+	if len(rec.Origins) != 2 || rec.Origins[0] != "local" || rec.Origins[1] != "emea" {
+		t.Errorf("Unexpected origins: %v", rec.Origins)
+	}
+	if !rec.LastSeen.Equal(later) || rec.CreatedAt.Equal(later) {
+		t.Errorf("Unexpected times: %v %v", rec.CreatedAt, rec.LastSeen)
+	}
+	for _, rel := range g.Relations {
+		if g.ids[NodeID(rel.From)] != rel.From || g.ids[NodeID(rel.To)] != rel.To {
+			t.Errorf("The relation %s does not refer to the merged assets", rel.Relation)
+		}
+	}
+	if len(other.Assets[0].Origins) != 0 || len(local.Assets[0].Origins) != 0 {
+		t.Error("The merged graphs were modified")
+	}
+}
+
+func TestWriteXML(t *testing.T) {
+	for _, name := range []string{FormatGraphML, FormatGEXF} {
+		var buf bytes.Buffer
+
+		if err := Write(&buf, name, testGraph()); err != nil {
+			t.Fatalf("Failed to write the %s graph: %v", name, err)
+		}
+
+		out := buf.String()
+		if err := xml.Unmarshal(buf.Bytes(), new(interface{})); err != nil {
+			t.Errorf("The %s output is not well-formed: %v", name, err)
+		}
+		for _, s := range []string{`"FQDN:www.owasp.org"`, `"IPAddress:192.0.2.1"`, "a_record", "2023-06-01T12:00:00Z"} {
+			if !strings.Contains(out, s) {
+				t.Errorf("The %s output is missing %s", name, s)
+			}
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := Write(&buf, FormatDOT, testGraph()); err != nil {
+		t.Fatalf("Failed to write the DOT graph: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "digraph amass {") || !strings.HasSuffix(out, "}\n") {
+		t.Errorf("Unexpected DOT output: %s", out)
+	}
+	if !strings.Contains(out, `"FQDN:www.owasp.org" -> "IPAddress:192.0.2.1" [label="a_record"`) {
+		t.Errorf("The DOT output is missing the relation: %s", out)
+	}
+	if !strings.Contains(out, `"FQDN:a\"b'c.owasp.org"`) {
+		t.Errorf("The DOT output did not escape the quotes: %s", out)
+	}
+}
+
+func TestWriteCypher(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := Write(&buf, FormatCypher, testGraph()); err != nil {
+		t.Fatalf("Failed to write the Cypher statements: %v", err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{
+		"CREATE INDEX IF NOT EXISTS FOR (n:`FQDN`) ON (n.key);",
+		"CREATE (:`FQDN` {key: 'www.owasp.org'",
+		"CREATE (:`FQDN` {key: 'a\"b\\'c.owasp.org'",
+		"MATCH (a:`FQDN` {key: 'www.owasp.org'}), (b:`IPAddress` {key: '192.0.2.1'}) CREATE (a)-[:`a_record`",
+		"created_at: datetime('2023-06-01T12:00:00Z')",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("The Cypher output is missing %s", s)
+		}
+	}
+	if n := strings.Count(out, "\n"); n != 6 {
+		t.Errorf("Expected six statements, got %d", n)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	g := testGraph()
+
+	r, err := NewRedaction([]string{`^a"`}, true)
+	if err != nil {
+		t.Fatalf("Failed to create the redaction: %v", err)
+	}
+	g.Redact(r)
+
+	s := NewSnapshot(g, []string{"owasp.org"}, false)
+	if len(s.Stats) != 2 || s.Stats[0].Type != "FQDN" || s.Stats[0].Count != 2 || s.Relations != 1 {
+		t.Fatalf("Unexpected statistics: %+v", s.Stats)
+	}
+	if len(s.Links) != 1 || s.Links[0].From != "FQDN:www.owasp.org" || !strings.HasPrefix(s.Links[0].To, "IPAddress:redacted-") {
+		t.Errorf("Unexpected relations: %+v", s.Links[0])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, FormatJSON, s); err != nil {
+		t.Fatalf("Failed to write the JSON snapshot: %v", err)
+	}
+	for _, leaked := range []string{"192.0.2.1", `a\"b'c`, `"asset"`} {
+		if strings.Contains(buf.String(), leaked) {
+			t.Errorf("The snapshot contains %s", leaked)
+		}
+	}
+
+	buf.Reset()
+	if err := Write(&buf, FormatHTML, g); err != nil {
+		t.Fatalf("Failed to write the HTML snapshot: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "<td>www.owasp.org</td>") || !strings.Contains(out, "<td>Relations</td><td>1</td>") {
+		t.Errorf("Unexpected HTML snapshot: %s", out)
+	}
+
+	if s := NewSnapshot(testGraph(), nil, true); len(s.Assets) != 0 || len(s.Links) != 0 || len(s.Stats) != 2 {
+		t.Errorf("Expected only the statistics: %+v", s)
+	}
+	if _, err := NewRedaction([]string{"("}, false); err == nil {
+		t.Error("Expected an error for an invalid redaction pattern")
+	}
+}
+
+func TestEngagement(t *testing.T) {
+	path := filepath.Join(t.TempDir(), EngagementFileName)
+	if e, err := ReadEngagement(path); err != nil || e != nil {
+		t.Fatalf("Expected no engagement for the missing file: %v", err)
+	}
+
+	e := &Engagement{Client: "Example Corp", Authorization: "SOW-42", Tester: "jdoe"}
+	if err := e.Write(path); err != nil {
+		t.Fatalf("Failed to write the engagement: %v", err)
+	}
+	e, err := ReadEngagement(path)
+	if err != nil || e == nil || e.Authorization != "SOW-42" {
+		t.Fatalf("Failed to read the engagement: %v", err)
+	}
+
+	// Every format documents the authorization under which the assets were collected
+	for _, name := range Formats {
+		g := testGraph()
+		g.Engagement = e
+
+		var buf bytes.Buffer
+		if err := Write(&buf, name, g); err != nil {
+			t.Fatalf("Failed to write the %s export: %v", name, err)
+		}
+		if out := buf.String(); !strings.Contains(out, "SOW-42") || !strings.Contains(out, "Example Corp") {
+			t.Errorf("The %s export does not provide the engagement metadata", name)
+		}
+
+		buf.Reset()
+		_ = Write(&buf, name, testGraph())
+		if strings.Contains(buf.String(), "Authorization") || strings.Contains(buf.String(), "authorization") {
+			t.Errorf("The %s export provides the engagement without the metadata", name)
+		}
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	g := testGraph()
+	data := []byte("exported graph")
+
+	digest, err := ConfigDigest(map[string]interface{}{"mode": "passive", "domains": []string{"owasp.org"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProvenance(g, "v4.2.0", &ExportParameters{Format: FormatGraphML}, digest, []string{"crtsh", "AlienVault"}, time.Now())
+	if s := p.BuildDefinition.InternalParameters; s.Sources[0] != "AlienVault" || s.FirstSeen == nil || !s.FirstSeen.Equal(g.Assets[0].CreatedAt) {
+		t.Errorf("Unexpected provenance settings: %+v", s)
+	}
+
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	env, err := Sign(NewStatement("amass.graphml", data, p), priv)
+	if err != nil {
+		t.Fatalf("Failed to sign the statement: %v", err)
+	}
+
+	st, err := Verify(env, pub, data)
+	if err != nil {
+		t.Fatalf("Failed to verify the envelope: %v", err)
+	}
+	if st.Predicate.RunDetails.Builder.Version["amass"] != "v4.2.0" || st.Predicate.BuildDefinition.InternalParameters.ConfigDigest["sha256"] != digest {
+		t.Errorf("Unexpected predicate: %+v", st.Predicate)
+	}
+
+	if _, err := Verify(env, pub, []byte("modified graph")); err == nil {
+		t.Error("Expected an error for the modified data")
+	}
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := Verify(env, other, data); err == nil {
+		t.Error("Expected an error for the wrong key")
+	}
+	if unsigned, _ := Sign(NewStatement("amass.graphml", data, p), nil); len(unsigned.Signatures) != 0 {
+		t.Error("Expected the envelope to be unsigned without a key")
+	}
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := LoadSigningKey(path); err != nil || !key.Equal(priv) {
+		t.Errorf("Failed to load the signing key: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSigningKey(path); err == nil {
+		t.Error("Expected an error for the invalid key file")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	fqdn := func(name string) *types.Asset {
+		return &types.Asset{ID: name, CreatedAt: now, LastSeen: now, Asset: domain.FQDN{Name: name}}
+	}
+	ip := func(addr string) *types.Asset {
+		return &types.Asset{ID: addr, CreatedAt: now, LastSeen: now, Asset: network.IPAddress{
+			Address: netip.MustParseAddr(addr),
+			Type:    "IPv4",
+		}}
+	}
+	rel := func(t string) *types.Relation {
+		return &types.Relation{Type: t, CreatedAt: now, LastSeen: now}
+	}
+
+	build := func() *Graph {
+		g := NewGraph()
+		g.AddRelation(fqdn("owasp.org"), rel("node"), fqdn("www.owasp.org"))
+		g.AddRelation(fqdn("www.owasp.org"), rel("a_record"), ip("192.0.2.1"))
+		g.AddRelation(fqdn("docs.owasp.org"), rel("cname_record"), fqdn("www.owasp.org"))
+		g.AddAsset(fqdn("old.owasp.org"))
+		g.AddAsset(fqdn("leak.owasp.org"))
+		// The names answered by the wildcard of the dev subdomain
+		for i := 0; i < WildcardMinNames; i++ {
+			g.AddRelation(fqdn(fmt.Sprintf("host%d.dev.owasp.org", i)), rel("a_record"), ip("192.0.2.99"))
+		}
+		return g
+	}
+
+	names := func(g *Graph) map[string]bool {
+		keys := make(map[string]bool)
+		for _, a := range g.Assets {
+			if a.Type == "FQDN" {
+				keys[a.Key] = true
+			}
+		}
+		return keys
+	}
+
+	g := build()
+	if n := g.Filter(&NameFilter{ResolvedOnly: true}, []string{"owasp.org"}); n != 2 {
+		t.Errorf("Expected two unresolved names to be removed, got %d", n)
+	}
+	if got := names(g); got["old.owasp.org"] || !got["docs.owasp.org"] || !got["owasp.org"] {
+		t.Errorf("Unexpected names after the resolved filter: %v", got)
+	}
+	for _, r := range g.Relations {
+		if r.From.Key == "old.owasp.org" || r.To.Key == "old.owasp.org" {
+			t.Error("The relations of the removed name were kept")
+		}
+	}
+
+	g = build()
+	if n := g.Filter(&NameFilter{NoWildcards: true}, []string{"owasp.org"}); n != WildcardMinNames {
+		t.Errorf("Expected the %d wildcard names to be removed, got %d", WildcardMinNames, n)
+	}
+	if got := names(g); got["host0.dev.owasp.org"] || !got["www.owasp.org"] {
+		t.Errorf("Unexpected names after the wildcard filter: %v", got)
+	}
+
+	g = build()
+	f := &NameFilter{
+		LowConfidence: []string{"pastebin"},
+		Sources: map[string][]string{
+			"leak.owasp.org": {"Pastebin"},
+			"www.owasp.org":  {"Pastebin", "crtsh"},
+		},
+	}
+	if n := g.Filter(f, []string{"owasp.org"}); n != 1 {
+		t.Errorf("Expected one low confidence name to be removed, got %d", n)
+	}
+	if got := names(g); got["leak.owasp.org"] || !got["www.owasp.org"] || !got["old.owasp.org"] {
+		t.Errorf("Unexpected names after the low confidence filter: %v", got)
+	}
+
+	if n := build().Filter(&NameFilter{}, nil); n != 0 {
+		t.Errorf("The inactive filter removed %d names", n)
+	}
+}
+
+func TestKeep(t *testing.T) {
+	g := testGraph()
+
+	if n := g.Keep(map[string]struct{}{"FQDN:www.owasp.org": {}, "IPAddress:192.0.2.1": {}}); n != 1 {
+		t.Errorf("Expected one asset to be removed, got %d", n)
+	}
+	if len(g.Assets) != 2 || len(g.Relations) != 1 {
+		t.Errorf("Expected two assets and their relation, got %d and %d", len(g.Assets), len(g.Relations))
+	}
+
+	if g.Keep(map[string]struct{}{"FQDN:www.owasp.org": {}}); len(g.Relations) != 0 {
+		t.Error("The relation to the removed address was kept")
+	}
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	first := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	later := first.Add(48 * time.Hour)
+
+	from := &Snapshot{
+		Generated: first,
+		Assets: []*SnapshotAsset{
+			{Type: "FQDN", Key: "www.owasp.org", Tags: []string{"prod"}, FirstSeen: first},
+			{Type: "FQDN", Key: "old.owasp.org", FirstSeen: first},
+			{Type: "FQDN", Key: "api.owasp.org", FirstSeen: first},
+		},
+		Links: []*SnapshotRelation{
+			{From: "FQDN:www.owasp.org", Relation: "a_record", To: "IPAddress:192.0.2.1"},
+			{From: "FQDN:old.owasp.org", Relation: "a_record", To: "IPAddress:192.0.2.2"},
+		},
+	}
+	to := &Snapshot{
+		Generated: later,
+		Assets: []*SnapshotAsset{
+			{Type: "FQDN", Key: "www.owasp.org", Tags: []string{"admin"}, FirstSeen: first},
+			{Type: "FQDN", Key: "new.owasp.org", FirstSeen: later},
+			{Type: "FQDN", Key: "api.owasp.org", FirstSeen: later},
+		},
+		Links: []*SnapshotRelation{
+			{From: "FQDN:www.owasp.org", Relation: "a_record", To: "IPAddress:192.0.2.1"},
+			{From: "FQDN:new.owasp.org", Relation: "a_record", To: "IPAddress:192.0.2.3"},
+		},
+	}
+
+	dir := t.TempDir()
+	if err := SaveSnapshot(dir, "before", from); err != nil {
+		t.Fatalf("Failed to save the snapshot: %v", err)
+	}
+	if err := SaveSnapshot(dir, "../escape", from); err == nil {
+		t.Error("Expected an error for an invalid snapshot tag")
+	}
+	loaded, err := LoadSnapshot(dir, "before")
+	if err != nil || len(loaded.Assets) != 3 {
+		t.Fatalf("Failed to load the snapshot: %v", err)
+	}
+	if _, err := LoadSnapshot(dir, "missing"); err == nil {
+		t.Error("Expected an error for a missing snapshot")
+	}
+	if tags, err := SnapshotTags(dir); err != nil || len(tags) != 1 || tags[0] != "before" {
+		t.Errorf("Unexpected snapshot tags: %v %v", tags, err)
+	}
+
+	d := DiffSnapshots(loaded, to, "before", "after")
+	if len(d.AddedAssets) != 1 || d.AddedAssets[0].Key != "new.owasp.org" {
+		t.Errorf("Unexpected added assets: %+v", d.AddedAssets)
+	}
+	if len(d.RemovedAssets) != 1 || d.RemovedAssets[0].Key != "old.owasp.org" {
+		t.Errorf("Unexpected removed assets: %+v", d.RemovedAssets)
+	}
+	if len(d.ChangedAssets) != 2 || d.ChangedAssets[0].Key != "api.owasp.org" || !d.ChangedAssets[0].Recreated ||
+		d.ChangedAssets[1].Details() != "+admin, -prod" {
+		t.Errorf("Unexpected changed assets: %+v", d.ChangedAssets)
+	}
+	if len(d.AddedRelations) != 1 || len(d.RemovedRelations) != 1 || d.RemovedRelations[0].From != "FQDN:old.owasp.org" {
+		t.Errorf("Unexpected relations: %+v %+v", d.AddedRelations, d.RemovedRelations)
+	}
+	if d.Empty() || !DiffSnapshots(to, to, "after", "after").Empty() {
+		t.Error("Unexpected result from Empty")
+	}
+
+	for _, f := range DiffFormats {
+		var buf bytes.Buffer
+		if err := WriteDiff(&buf, f, d); err != nil {
+			t.Fatalf("Failed to write the %s diff: %v", f, err)
+		}
+		if !strings.Contains(buf.String(), "new.owasp.org") {
+			t.Errorf("The %s diff does not provide the added asset: %s", f, buf.String())
+		}
+	}
+	if err := WriteDiff(new(bytes.Buffer), "xml", d); err == nil {
+		t.Error("Expected an error for an unsupported diff format")
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"sort"
+	"strings"
+
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// WildcardMinNames is the number of names under the same subdomain, resolving to the same
+// addresses, that are considered to be answered by a DNS wildcard.
+const WildcardMinNames = 20
+
+// NameFilter removes the noisy names from the graph before it is written or queried,
+// since the raw passive results contain many names that are not worth scanning.
+type NameFilter struct {
+	// ResolvedOnly keeps only the names with address records, directly or through their CNAME records
+	ResolvedOnly bool
+	// NoWildcards removes the names that appear to be answered by a DNS wildcard
+	NoWildcards bool
+	// LowConfidence are the data sources whose names are removed, unless another data source provided them
+	LowConfidence []string
+	// Sources maps the names to the data sources that provided them
+	Sources map[string][]string
+}
+
+// Active returns true when the NameFilter removes any names.
+func (f *NameFilter) Active() bool {
+	return f != nil && (f.ResolvedOnly || f.NoWildcards || len(f.LowConfidence) > 0)
+}
+
+// Names returns the names of the filters applied by the NameFilter, as recorded by the provenance.
+func (f *NameFilter) Names() []string {
+	var names []string
+
+	if f == nil {
+		return names
+	}
+	if f.ResolvedOnly {
+		names = append(names, "resolved")
+	}
+	if f.NoWildcards {
+		names = append(names, "no-wildcards")
+	}
+	for _, src := range f.LowConfidence {
+		names = append(names, "low-confidence:"+src)
+	}
+	return names
+}
+
+// Filter removes the names rejected by the NameFilter from the graph, along with their relations,
+// and returns the number of names removed. The root domain names are always kept.
+func (g *Graph) Filter(f *NameFilter, domains []string) int {
+	if !f.Active() {
+		return 0
+	}
+
+	roots := make(map[string]struct{}, len(domains))
+	for _, d := range domains {
+		roots[d] = struct{}{}
+	}
+
+	var resolving, wildcards map[string]bool
+	if f.ResolvedOnly {
+		resolving = ResolvingNames(g)
+	}
+	if f.NoWildcards {
+		wildcards = WildcardNames(g)
+	}
+	low := make(map[string]struct{}, len(f.LowConfidence))
+	for _, src := range f.LowConfidence {
+		low[strings.ToLower(src)] = struct{}{}
+	}
+
+	removed := make(map[string]struct{})
+	for _, a := range g.Assets {
+		if a.Type != string(oam.FQDN) {
+			continue
+		}
+		if _, found := roots[a.Key]; found {
+			continue
+		}
+
+		if (f.ResolvedOnly && !resolving[a.Key]) || (f.NoWildcards && wildcards[a.Key]) || f.lowConfidence(a.Key, low) {
+			removed[NodeID(a)] = struct{}{}
+		}
+	}
+	if len(removed) == 0 {
+		return 0
+	}
+
+	assets := g.Assets[:0]
+	for _, a := range g.Assets {
+		if _, found := removed[NodeID(a)]; !found {
+			assets = append(assets, a)
+		}
+	}
+	g.Assets = assets
+
+	rels := g.Relations[:0]
+	for _, rel := range g.Relations {
+		_, from := removed[NodeID(rel.From)]
+		_, to := removed[NodeID(rel.To)]
+		if !from && !to {
+			rels = append(rels, rel)
+		}
+	}
+	g.Relations = rels
+	return len(removed)
+}
+
+// lowConfidence returns true when the name was only provided by one of the low confidence data sources.
+func (f *NameFilter) lowConfidence(name string, low map[string]struct{}) bool {
+	if len(low) == 0 {
+		return false
+	}
+
+	srcs := f.Sources[name]
+	if len(srcs) != 1 {
+		return false
+	}
+	_, found := low[strings.ToLower(srcs[0])]
+	return found
+}
+
+// ResolvingNames returns the names with address records, directly or through their CNAME records.
+func ResolvingNames(g *Graph) map[string]bool {
+	resolving := make(map[string]bool)
+	// Names are followed back through the CNAME records that point at them
+	aliases := make(map[string][]string)
+	var queue []string
+	for _, rel := range g.Relations {
+		if rel.From.Type != string(oam.FQDN) {
+			continue
+		}
+
+		switch rel.Relation {
+		case "a_record", "aaaa_record":
+			if !resolving[rel.From.Key] {
+				resolving[rel.From.Key] = true
+				queue = append(queue, rel.From.Key)
+			}
+		case "cname_record":
+			aliases[rel.To.Key] = append(aliases[rel.To.Key], rel.From.Key)
+		}
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		for _, alias := range aliases[name] {
+			if !resolving[alias] {
+				resolving[alias] = true
+				queue = append(queue, alias)
+			}
+		}
+	}
+	return resolving
+}
+
+// WildcardNames returns the names that appear to be answered by a DNS wildcard, since at least
+// WildcardMinNames names under the same subdomain resolve to exactly the same addresses.
+func WildcardNames(g *Graph) map[string]bool {
+	addrs := make(map[string][]string)
+	for _, rel := range g.Relations {
+		if rel.From.Type == string(oam.FQDN) && (rel.Relation == "a_record" || rel.Relation == "aaaa_record") {
+			addrs[rel.From.Key] = append(addrs[rel.From.Key], rel.To.Key)
+		}
+	}
+
+	groups := make(map[string][]string)
+	for name, list := range addrs {
+		parts := strings.SplitN(name, ".", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		sort.Strings(list)
+		key := parts[1] + " " + strings.Join(list, ",")
+		groups[key] = append(groups[key], name)
+	}
+
+	wildcards := make(map[string]bool)
+	for _, names := range groups {
+		if len(names) < WildcardMinNames {
+			continue
+		}
+		for _, name := range names {
+			wildcards[name] = true
+		}
+	}
+	return wildcards
+}
+
+// Keep removes the assets without the provided IDs from the graph, along with their relations, and
+// returns the number of assets removed, so only a selection of the assets, such as a collection, remains.
+func (g *Graph) Keep(ids map[string]struct{}) int {
+	assets := g.Assets[:0]
+	for _, a := range g.Assets {
+		if _, found := ids[NodeID(a)]; found {
+			assets = append(assets, a)
+		}
+	}
+	removed := len(g.Assets) - len(assets)
+	g.Assets = assets
+
+	rels := g.Relations[:0]
+	for _, rel := range g.Relations {
+		_, from := ids[NodeID(rel.From)]
+		_, to := ids[NodeID(rel.To)]
+		if from && to {
+			rels = append(rels, rel)
+		}
+	}
+	g.Relations = rels
+	return removed
+}
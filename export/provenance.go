@@ -0,0 +1,258 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// The identifiers of the in-toto attestation produced for the exports.
+const (
+	StatementType       = "https://in-toto.io/Statement/v1"
+	ProvenanceType      = "https://slsa.dev/provenance/v1"
+	ProvenanceBuildType = "https://github.com/owasp-amass/amass/export/v1"
+	ProvenanceBuilder   = "https://github.com/owasp-amass/amass"
+	PayloadType         = "application/vnd.in-toto+json"
+)
+
+// Statement is the in-toto statement binding the provenance to the exported file.
+type Statement struct {
+	Type          string      `json:"_type"`
+	Subject       []*Subject  `json:"subject"`
+	PredicateType string      `json:"predicateType"`
+	Predicate     *Provenance `json:"predicate"`
+}
+
+// Subject identifies the exported file by its name and digest.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Provenance is the SLSA provenance predicate describing how the export was produced.
+type Provenance struct {
+	BuildDefinition struct {
+		BuildType          string              `json:"buildType"`
+		ExternalParameters *ExportParameters   `json:"externalParameters"`
+		InternalParameters *ProvenanceSettings `json:"internalParameters"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID      string            `json:"id"`
+			Version map[string]string `json:"version"`
+		} `json:"builder"`
+		Metadata struct {
+			StartedOn  time.Time `json:"startedOn"`
+			FinishedOn time.Time `json:"finishedOn"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+// ExportParameters are the parameters of the export provided by the user.
+type ExportParameters struct {
+	Format   string     `json:"format"`
+	Domains  []string   `json:"domains,omitempty"`
+	Since    *time.Time `json:"since,omitempty"`
+	Redacted bool       `json:"redacted,omitempty"`
+	Filters  []string   `json:"filters,omitempty"`
+}
+
+// ProvenanceSettings describe the configuration and the data that the export was produced from.
+type ProvenanceSettings struct {
+	// ConfigDigest is the SHA-256 digest of the scope and options of the configuration
+	ConfigDigest map[string]string `json:"configDigest"`
+	// Sources are the data sources selected by the configuration
+	Sources []string `json:"sources,omitempty"`
+	// FirstSeen and LastSeen are the time range of the exported assets
+	FirstSeen *time.Time `json:"firstSeen,omitempty"`
+	LastSeen  *time.Time `json:"lastSeen,omitempty"`
+}
+
+// Envelope is the DSSE envelope carrying the signed statement.
+type Envelope struct {
+	PayloadType string       `json:"payloadType"`
+	Payload     string       `json:"payload"`
+	Signatures  []*Signature `json:"signatures"`
+}
+
+// Signature is a signature of the envelope payload.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// NewProvenance returns the Provenance of the export for the Amass version, the configuration digest and
+// the data sources. The time range of the exported assets is taken from the graph.
+func NewProvenance(g *Graph, version string, params *ExportParameters, digest string, sources []string, started time.Time) *Provenance {
+	p := &Provenance{}
+	p.BuildDefinition.BuildType = ProvenanceBuildType
+	p.BuildDefinition.ExternalParameters = params
+
+	srcs := append([]string(nil), sources...)
+	sort.Strings(srcs)
+	settings := &ProvenanceSettings{
+		ConfigDigest: map[string]string{"sha256": digest},
+		Sources:      srcs,
+	}
+	for _, a := range g.Assets {
+		if first, last := a.CreatedAt, a.LastSeen; settings.FirstSeen == nil {
+			settings.FirstSeen, settings.LastSeen = &first, &last
+		} else {
+			if first.Before(*settings.FirstSeen) {
+				settings.FirstSeen = &first
+			}
+			if last.After(*settings.LastSeen) {
+				settings.LastSeen = &last
+			}
+		}
+	}
+	p.BuildDefinition.InternalParameters = settings
+
+	p.RunDetails.Builder.ID = ProvenanceBuilder
+	p.RunDetails.Builder.Version = map[string]string{"amass": version}
+	p.RunDetails.Metadata.StartedOn = started.UTC()
+	p.RunDetails.Metadata.FinishedOn = time.Now().UTC()
+	return p
+}
+
+// ConfigDigest returns the hex-encoded SHA-256 digest of the configuration settings, which must be
+// serializable as JSON. The keys of maps are sorted, so the digest does not depend on their order.
+func ConfigDigest(settings interface{}) (string, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize the configuration: %v", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// NewStatement returns the Statement binding the Provenance to the exported data.
+func NewStatement(name string, data []byte, p *Provenance) *Statement {
+	sum := sha256.Sum256(data)
+
+	return &Statement{
+		Type: StatementType,
+		Subject: []*Subject{{
+			Name:   name,
+			Digest: map[string]string{"sha256": hex.EncodeToString(sum[:])},
+		}},
+		PredicateType: ProvenanceType,
+		Predicate:     p,
+	}
+}
+
+// Sign returns the DSSE envelope of the Statement. The envelope is not signed when the key is nil.
+func Sign(st *Statement, key ed25519.PrivateKey) (*Envelope, error) {
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []*Signature{},
+	}
+	if key != nil {
+		pub, ok := key.Public().(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("the signing key is not an ed25519 key")
+		}
+
+		env.Signatures = append(env.Signatures, &Signature{
+			KeyID: KeyID(pub),
+			Sig:   base64.StdEncoding.EncodeToString(ed25519.Sign(key, pae(PayloadType, payload))),
+		})
+	}
+	return env, nil
+}
+
+// Verify checks the signature of the envelope with the public key, and that the Statement describes
+// the exported data. The Statement is returned when the envelope can be trusted.
+func Verify(env *Envelope, pub ed25519.PublicKey, data []byte) (*Statement, error) {
+	if env.PayloadType != PayloadType {
+		return nil, fmt.Errorf("%s is not a supported payload type", env.PayloadType)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, errors.New("the envelope payload is not valid base64")
+	}
+
+	var verified bool
+	id := KeyID(pub)
+	for _, s := range env.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err == nil && (s.KeyID == "" || s.KeyID == id) && ed25519.Verify(pub, pae(env.PayloadType, payload), sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("the envelope was not signed by the key")
+	}
+
+	var st Statement
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse the statement: %v", err)
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	for _, sub := range st.Subject {
+		if sub.Digest["sha256"] == digest {
+			return &st, nil
+		}
+	}
+	return nil, errors.New("the statement does not describe the exported data")
+}
+
+// KeyID returns the identifier of the public key, which is the hex-encoded SHA-256 digest of the key.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadSigningKey returns the ed25519 private key from the PEM-encoded PKCS #8 file,
+// such as the key generated by 'openssl genpkey -algorithm ed25519'.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the signing key: %v", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the signing key is not PEM-encoded")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the signing key: %v", err)
+	}
+
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("the signing key is not an ed25519 key")
+	}
+	return priv, nil
+}
+
+// pae returns the DSSE pre-authentication encoding of the payload, which is what gets signed.
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
@@ -0,0 +1,227 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/format"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// The snapshot formats, which are sanitized for sharing outside of the team performing the assessment.
+const (
+	FormatHTML = "html"
+	FormatJSON = "json"
+)
+
+// Snapshot is the sanitized, read-only view of the graph that is suitable for sharing with clients or
+// publishing program scope statistics. The data collected for each asset is not included.
+type Snapshot struct {
+	Generated  time.Time           `json:"generated"`
+	Domains    []string            `json:"domains,omitempty"`
+	Engagement *Engagement         `json:"engagement,omitempty"`
+	Stats      []*SnapshotStat     `json:"stats"`
+	Relations  int                 `json:"relations"`
+	Assets     []*SnapshotAsset    `json:"assets,omitempty"`
+	Links      []*SnapshotRelation `json:"links,omitempty"`
+}
+
+// SnapshotStat is the number of assets of a type in the Snapshot.
+type SnapshotStat struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// SnapshotAsset is an asset in the Snapshot.
+type SnapshotAsset struct {
+	Type      string    `json:"type"`
+	Key       string    `json:"key"`
+	Tags      []string  `json:"tags,omitempty"`
+	Origins   []string  `json:"origins,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// SnapshotRelation is a relation between two assets in the Snapshot.
+type SnapshotRelation struct {
+	From     string `json:"from"`
+	Relation string `json:"relation"`
+	To       string `json:"to"`
+}
+
+// IsSnapshot returns true when the format produces a Snapshot rather than the complete graph.
+func IsSnapshot(name string) bool {
+	name = strings.ToLower(name)
+	return name == FormatHTML || name == FormatJSON
+}
+
+// NewSnapshot returns the Snapshot of the graph for the root domain names. When statsOnly is
+// true, only the number of assets of each type and the number of relations are provided.
+func NewSnapshot(g *Graph, domains []string, statsOnly bool) *Snapshot {
+	g.sort()
+
+	s := &Snapshot{
+		Generated: time.Now().UTC(),
+		Domains:   domains,
+		Relations: len(g.Relations),
+	}
+	if !g.Engagement.Empty() {
+		s.Engagement = g.Engagement
+	}
+
+	counts := make(map[string]int)
+	for _, a := range g.Assets {
+		counts[a.Type]++
+		if !statsOnly {
+			s.Assets = append(s.Assets, &SnapshotAsset{
+				Type:      a.Type,
+				Key:       a.Key,
+				Tags:      a.Tags,
+				Origins:   a.Origins,
+				FirstSeen: a.CreatedAt,
+				LastSeen:  a.LastSeen,
+			})
+		}
+	}
+	for t, n := range counts {
+		s.Stats = append(s.Stats, &SnapshotStat{Type: t, Count: n})
+	}
+	sort.Slice(s.Stats, func(i, j int) bool { return s.Stats[i].Type < s.Stats[j].Type })
+
+	if !statsOnly {
+		for _, rel := range g.Relations {
+			s.Links = append(s.Links, &SnapshotRelation{
+				From:     NodeID(rel.From),
+				Relation: rel.Relation,
+				To:       NodeID(rel.To),
+			})
+		}
+	}
+	return s
+}
+
+// WriteSnapshot writes the Snapshot to w in the named snapshot format.
+func WriteSnapshot(w io.Writer, name string, s *Snapshot) error {
+	switch strings.ToLower(name) {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	case FormatHTML:
+		return snapshotTemplate.Execute(w, s)
+	}
+	return fmt.Errorf("%s is not a supported snapshot format", name)
+}
+
+var snapshotTemplate = template.Must(template.New("snapshot").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("2006-01-02") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>OWASP Amass Snapshot</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+th { background: #f0f0f0; }
+</style>
+</head>
+<body>
+<h1>OWASP Amass Snapshot</h1>
+<p>Generated {{ .Generated.Format "2006-01-02 15:04:05 MST" }}{{ if .Domains }} for {{ range $i, $d := .Domains }}{{ if $i }}, {{ end }}{{ $d }}{{ end }}{{ end }}</p>
+{{ with .Engagement }}<p>Client: {{ .Client }}, Authorization: {{ .Authorization }}, Tester: {{ .Tester }}</p>
+{{ end }}<h2>Statistics</h2>
+<table>
+<tr><th>Type</th><th>Count</th></tr>
+{{ range .Stats }}<tr><td>{{ .Type }}</td><td>{{ .Count }}</td></tr>
+{{ end }}<tr><td>Relations</td><td>{{ .Relations }}</td></tr>
+</table>
+{{ if .Assets }}<h2>Assets</h2>
+<table>
+<tr><th>Type</th><th>Asset</th><th>Tags</th><th>First Seen</th><th>Last Seen</th></tr>
+{{ range .Assets }}<tr><td>{{ .Type }}</td><td>{{ .Key }}</td><td>{{ range $i, $t := .Tags }}{{ if $i }}, {{ end }}{{ $t }}{{ end }}</td><td>{{ date .FirstSeen }}</td><td>{{ date .LastSeen }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ if .Links }}<h2>Relations</h2>
+<table>
+<tr><th>From</th><th>Relation</th><th>To</th></tr>
+{{ range .Links }}<tr><td>{{ .From }}</td><td>{{ .Relation }}</td><td>{{ .To }}</td></tr>
+{{ end }}</table>
+{{ end }}</body>
+</html>
+`))
+
+// Redaction replaces the identifying values of the assets before the graph is shared.
+type Redaction struct {
+	// Patterns are matched against the asset keys, and the matching keys are replaced
+	Patterns []*regexp.Regexp
+	// Addresses replaces all the IP addresses and netblocks
+	Addresses bool
+}
+
+// NewRedaction returns the Redaction for the regular expressions.
+func NewRedaction(patterns []string, addresses bool) (*Redaction, error) {
+	r := &Redaction{Addresses: addresses}
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %s: %v", p, err)
+		}
+		r.Patterns = append(r.Patterns, re)
+	}
+	return r, nil
+}
+
+// Redact replaces the keys of the assets matched by the Redaction, and removes the data collected for
+// all the assets. The same key is always replaced with the same value, so the relations are preserved.
+func (g *Graph) Redact(r *Redaction) {
+	// The records of the relations can be the same as the records of the assets
+	done := make(map[*format.AssetRecord]struct{})
+	redact := func(rec *format.AssetRecord) {
+		if _, found := done[rec]; found {
+			return
+		}
+		done[rec] = struct{}{}
+
+		rec.Asset = nil
+		if r != nil && r.matches(rec) {
+			sum := sha256.Sum256([]byte(rec.Type + ":" + rec.Key))
+			rec.Key = "redacted-" + hex.EncodeToString(sum[:4])
+			rec.Tags = nil
+		}
+	}
+
+	for _, a := range g.Assets {
+		redact(a)
+	}
+	for _, rel := range g.Relations {
+		redact(rel.From)
+		redact(rel.To)
+	}
+}
+
+func (r *Redaction) matches(rec *format.AssetRecord) bool {
+	if r.Addresses && (rec.Type == string(oam.IPAddress) || rec.Type == string(oam.Netblock)) {
+		return true
+	}
+
+	for _, re := range r.Patterns {
+		if re.MatchString(rec.Key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/format"
+)
+
+var (
+	dotEscaper    = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	cypherEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`)
+)
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+func WriteDOT(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph amass {")
+	if e := g.Engagement; !e.Empty() {
+		fmt.Fprintf(bw, "\tgraph [client=%s authorization=%s tester=%s];\n",
+			dotQuote(e.Client), dotQuote(e.Authorization), dotQuote(e.Tester))
+	}
+	for _, a := range g.Assets {
+		fmt.Fprintf(bw, "\t%s [label=%s type=%s created_at=%s last_seen=%s];\n", dotQuote(NodeID(a)),
+			dotQuote(a.Key), dotQuote(a.Type), dotQuote(timestamp(a.CreatedAt)), dotQuote(timestamp(a.LastSeen)))
+	}
+	for _, rel := range g.Relations {
+		fmt.Fprintf(bw, "\t%s -> %s [label=%s created_at=%s last_seen=%s];\n", dotQuote(NodeID(rel.From)),
+			dotQuote(NodeID(rel.To)), dotQuote(rel.Relation), dotQuote(timestamp(rel.CreatedAt)), dotQuote(timestamp(rel.LastSeen)))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// WriteCypher writes the graph as Neo4j Cypher statements that create the assets as nodes labeled
+// with the asset type, and the relations between them, one statement per line.
+func WriteCypher(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+
+	if e := g.Engagement; !e.Empty() {
+		fmt.Fprintf(bw, "// Engagement: %s\n", strings.ReplaceAll(e.String(), "\n", " "))
+	}
+	// The indexes avoid scanning all the nodes when matching the assets of each relation
+	labels := make(map[string]struct{})
+	for _, a := range g.Assets {
+		if _, found := labels[a.Type]; !found {
+			labels[a.Type] = struct{}{}
+			fmt.Fprintf(bw, "CREATE INDEX IF NOT EXISTS FOR (n:%s) ON (n.key);\n", cypherName(a.Type))
+		}
+	}
+	for _, a := range g.Assets {
+		fmt.Fprintf(bw, "CREATE (:%s %s);\n", cypherName(a.Type), cypherAssetProperties(a))
+	}
+	for _, rel := range g.Relations {
+		fmt.Fprintf(bw, "MATCH (a:%s {key: %s}), (b:%s {key: %s}) CREATE (a)-[:%s {created_at: datetime(%s), last_seen: datetime(%s)}]->(b);\n",
+			cypherName(rel.From.Type), cypherQuote(rel.From.Key), cypherName(rel.To.Type), cypherQuote(rel.To.Key),
+			cypherName(rel.Relation), cypherQuote(timestamp(rel.CreatedAt)), cypherQuote(timestamp(rel.LastSeen)))
+	}
+	return bw.Flush()
+}
+
+func cypherAssetProperties(a *format.AssetRecord) string {
+	props := []string{
+		"key: " + cypherQuote(a.Key),
+		"asset: " + cypherQuote(string(a.Asset)),
+		"created_at: datetime(" + cypherQuote(timestamp(a.CreatedAt)) + ")",
+		"last_seen: datetime(" + cypherQuote(timestamp(a.LastSeen)) + ")",
+	}
+
+	if len(a.Tags) > 0 {
+		var tags []string
+		for _, t := range a.Tags {
+			tags = append(tags, cypherQuote(t))
+		}
+		props = append(props, "tags: ["+strings.Join(tags, ", ")+"]")
+	}
+	return "{" + strings.Join(props, ", ") + "}"
+}
+
+// cypherName returns the label or relationship type, quoted so that any characters are permitted.
+func cypherName(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+func cypherQuote(s string) string {
+	return "'" + cypherEscaper.Replace(s) + "'"
+}
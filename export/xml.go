@@ -0,0 +1,224 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/format"
+)
+
+// The attributes written for each asset and relation, in the order they are declared.
+var (
+	assetAttrs    = []string{"type", "key", "tags", "asset", "created_at", "last_seen"}
+	relationAttrs = []string{"relation", "created_at", "last_seen"}
+	// The attributes of the graph written for the engagement
+	engagementAttrs = []string{"client", "authorization", "tester"}
+)
+
+func assetValues(rec *format.AssetRecord) []string {
+	return []string{rec.Type, rec.Key, strings.Join(rec.Tags, ","),
+		string(rec.Asset), timestamp(rec.CreatedAt), timestamp(rec.LastSeen)}
+}
+
+func relationValues(rec *format.RelationRecord) []string {
+	return []string{rec.Relation, timestamp(rec.CreatedAt), timestamp(rec.LastSeen)}
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string         `xml:"id,attr"`
+		EdgeDefault string         `xml:"edgedefault,attr"`
+		Data        []graphMLData  `xml:"data"`
+		Nodes       []graphMLEntry `xml:"node"`
+		Edges       []graphMLEntry `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLEntry struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as a GraphML document.
+func WriteGraphML(w io.Writer, g *Graph) error {
+	doc := graphML{XMLNS: "http://graphml.graphdrawing.org/xmlns"}
+	doc.Graph.ID = "amass"
+	doc.Graph.EdgeDefault = "directed"
+
+	for _, name := range assetAttrs {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "n_" + name, For: "node", AttrName: name, AttrType: "string"})
+	}
+	for _, name := range relationAttrs {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "e_" + name, For: "edge", AttrName: name, AttrType: "string"})
+	}
+	if e := g.Engagement; !e.Empty() {
+		for i, v := range []string{e.Client, e.Authorization, e.Tester} {
+			name := engagementAttrs[i]
+			doc.Keys = append(doc.Keys, graphMLKey{ID: "g_" + name, For: "graph", AttrName: name, AttrType: "string"})
+			doc.Graph.Data = append(doc.Graph.Data, graphMLData{Key: "g_" + name, Value: v})
+		}
+	}
+
+	for _, a := range g.Assets {
+		node := graphMLEntry{ID: NodeID(a)}
+		for i, v := range assetValues(a) {
+			node.Data = append(node.Data, graphMLData{Key: "n_" + assetAttrs[i], Value: v})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for i, rel := range g.Relations {
+		edge := graphMLEntry{ID: "e" + strconv.Itoa(i), Source: NodeID(rel.From), Target: NodeID(rel.To)}
+		for j, v := range relationValues(rel) {
+			edge.Data = append(edge.Data, graphMLData{Key: "e_" + relationAttrs[j], Value: v})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+	return writeXML(w, &doc)
+}
+
+type gexf struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Meta    *gexfMeta `xml:"meta,omitempty"`
+	Graph   struct {
+		Mode            string           `xml:"mode,attr"`
+		DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+		TimeFormat      string           `xml:"timeformat,attr"`
+		Attributes      []gexfAttributes `xml:"attributes"`
+		Nodes           []gexfEntry      `xml:"nodes>node"`
+		Edges           []gexfEntry      `xml:"edges>edge"`
+	} `xml:"graph"`
+}
+
+type gexfMeta struct {
+	Creator     string `xml:"creator"`
+	Description string `xml:"description"`
+}
+
+type gexfAttributes struct {
+	Class string          `xml:"class,attr"`
+	Attrs []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfEntry struct {
+	ID     string `xml:"id,attr"`
+	Label  string `xml:"label,attr"`
+	Source string `xml:"source,attr,omitempty"`
+	Target string `xml:"target,attr,omitempty"`
+	// Start and End allow the graph to be explored over time using the Gephi timeline
+	Start  string      `xml:"start,attr"`
+	End    string      `xml:"end,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+type gexfValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// WriteGEXF writes the graph as a GEXF document, with the time each asset and relation was first and
+// last seen providing the period it exists in the dynamic graph.
+func WriteGEXF(w io.Writer, g *Graph) error {
+	doc := gexf{XMLNS: "http://gexf.net/1.3", Version: "1.3"}
+	doc.Graph.Mode = "dynamic"
+	doc.Graph.DefaultEdgeType = "directed"
+	doc.Graph.TimeFormat = "dateTime"
+	if !g.Engagement.Empty() {
+		doc.Meta = &gexfMeta{Creator: "OWASP Amass", Description: "Engagement: " + g.Engagement.String()}
+	}
+	doc.Graph.Attributes = []gexfAttributes{
+		newGEXFAttributes("node", assetAttrs),
+		newGEXFAttributes("edge", relationAttrs),
+	}
+
+	for _, a := range g.Assets {
+		node := gexfEntry{
+			ID:    NodeID(a),
+			Label: a.Key,
+			Start: timestamp(a.CreatedAt),
+			End:   timestamp(a.LastSeen),
+		}
+		node.Values = gexfValues(assetValues(a))
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for i, rel := range g.Relations {
+		edge := gexfEntry{
+			ID:     "e" + strconv.Itoa(i),
+			Label:  rel.Relation,
+			Source: NodeID(rel.From),
+			Target: NodeID(rel.To),
+			Start:  timestamp(rel.CreatedAt),
+			End:    timestamp(rel.LastSeen),
+		}
+		edge.Values = gexfValues(relationValues(rel))
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+	return writeXML(w, &doc)
+}
+
+func newGEXFAttributes(class string, names []string) gexfAttributes {
+	attrs := gexfAttributes{Class: class}
+
+	for i, name := range names {
+		attrs.Attrs = append(attrs.Attrs, gexfAttribute{ID: strconv.Itoa(i), Title: name, Type: "string"})
+	}
+	return attrs
+}
+
+func gexfValues(values []string) []gexfValue {
+	var list []gexfValue
+
+	for i, v := range values {
+		list = append(list, gexfValue{For: strconv.Itoa(i), Value: v})
+	}
+	return list
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,80 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Condition selects the findings by one of their fields or attributes, such as the registrar
+// or the statuses of the domain_registration findings.
+type Condition struct {
+	// Key is type, severity, asset or title, or the name of an attribute
+	Key   string
+	Value string
+	// Contains matches the values containing Value, rather than equal to it
+	Contains bool
+}
+
+// ParseCondition returns the Condition for the expression KEY=VALUE, which matches the findings with the value,
+// or one of the comma-separated values, equal to VALUE, or KEY~VALUE, which matches the values containing VALUE.
+func ParseCondition(expr string) (*Condition, error) {
+	i := strings.IndexAny(expr, "=~")
+	if i <= 0 {
+		return nil, fmt.Errorf("%s is not a KEY=VALUE or KEY~VALUE condition", expr)
+	}
+
+	return &Condition{
+		Key:      strings.ToLower(strings.TrimSpace(expr[:i])),
+		Value:    strings.TrimSpace(expr[i+1:]),
+		Contains: expr[i] == '~',
+	}, nil
+}
+
+// Match returns true when the finding satisfies the condition. The comparisons are not case sensitive.
+func (c *Condition) Match(f *Finding) bool {
+	var v string
+	switch c.Key {
+	case "type":
+		v = f.Type
+	case "severity":
+		v = f.Severity
+	case "asset":
+		v = f.Asset
+	case "title":
+		v = f.Title
+	default:
+		var found bool
+		if v, found = f.Attributes[c.Key]; !found {
+			return false
+		}
+	}
+
+	want := strings.ToLower(c.Value)
+	v = strings.ToLower(v)
+	if c.Contains {
+		return strings.Contains(v, want)
+	}
+	if v == want {
+		return true
+	}
+	for _, elem := range strings.Split(v, ",") {
+		if strings.TrimSpace(elem) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAll returns true when the finding satisfies all the conditions.
+func MatchAll(f *Finding, conds []*Condition) bool {
+	for _, c := range conds {
+		if !c.Match(f) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import "testing"
+
+func TestConditions(t *testing.T) {
+	f := &Finding{
+		Asset:    "owasp.org",
+		Type:     "domain_registration",
+		Severity: SeverityInfo,
+		Attributes: map[string]string{
+			"registrar":   "Example Registrar, Inc.",
+			"nameservers": "ns1.example.net,ns2.example.net",
+			"statuses":    "client transfer prohibited,active",
+			"dnssec":      "true",
+		},
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"type=domain_registration", true},
+		{"Type=DOMAIN_REGISTRATION", true},
+		{"severity=high", false},
+		{"nameservers=ns2.example.net", true},
+		{"nameservers=example.net", false},
+		{"nameservers~example.net", true},
+		{"statuses=Active", true},
+		{"registrar~example registrar", true},
+		{"dnssec=false", false},
+		{"expires~2026", false},
+	}
+	for _, test := range tests {
+		c, err := ParseCondition(test.expr)
+		if err != nil {
+			t.Errorf("Failed to parse %s: %v", test.expr, err)
+			continue
+		}
+		if got := c.Match(f); got != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.expr, test.expected, got)
+		}
+	}
+
+	for _, expr := range []string{"", "registrar", "=value"} {
+		if _, err := ParseCondition(expr); err == nil {
+			t.Errorf("Expected an error for %q", expr)
+		}
+	}
+
+	a, _ := ParseCondition("type=domain_registration")
+	b, _ := ParseCondition("dnssec=false")
+	if !MatchAll(f, []*Condition{a}) || MatchAll(f, []*Condition{a, b}) {
+		t.Error("MatchAll did not require all the conditions")
+	}
+}
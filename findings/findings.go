@@ -0,0 +1,115 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the file in the output directory that stores the findings.
+const FileName = "findings.json"
+
+// Severity levels assigned to the findings.
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Finding is an observation about an asset that is not represented by the
+// relationships in the graph database, such as a misconfiguration.
+type Finding struct {
+	Time       time.Time         `json:"time"`
+	Asset      string            `json:"asset"`
+	Type       string            `json:"type"`
+	Severity   string            `json:"severity"`
+	Title      string            `json:"title"`
+	Details    string            `json:"details,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Log appends findings to a file with one JSON object per line.
+type Log struct {
+	sync.Mutex
+	f      *os.File
+	enc    *json.Encoder
+	policy *Policy
+}
+
+// NewLog opens the findings file at the provided path for appending.
+func NewLog(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Log{
+		f:   f,
+		enc: json.NewEncoder(f),
+	}, nil
+}
+
+// SetPolicy sets the Policy assigning the severity of the findings added to the log.
+func (l *Log) SetPolicy(p *Policy) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.policy = p
+}
+
+// Add writes the finding to the log, after the severity is assigned by the Policy.
+func (l *Log) Add(f *Finding) error {
+	if f.Time.IsZero() {
+		f.Time = time.Now()
+	}
+
+	l.Lock()
+	l.policy.Apply(f)
+	l.Unlock()
+	if f.Severity == "" {
+		f.Severity = SeverityInfo
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	return l.enc.Encode(f)
+}
+
+// Close flushes the findings to disk and closes the file.
+func (l *Log) Close() error {
+	l.Lock()
+	defer l.Unlock()
+
+	_ = l.f.Sync()
+	return l.f.Close()
+}
+
+// Read returns all the findings stored in the file at the provided path.
+func Read(path string) ([]*Finding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []*Finding
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var finding Finding
+
+		if err := json.Unmarshal(scanner.Bytes(), &finding); err == nil {
+			results = append(results, &finding)
+		}
+	}
+	return results, scanner.Err()
+}
@@ -0,0 +1,123 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	l, err := NewLog(path)
+	if err != nil {
+		t.Fatalf("Failed to open the findings log: %v", err)
+	}
+
+	expected := []*Finding{
+		{Asset: "owasp.org", Type: "dnssec", Title: "Zone is DNSSEC-signed"},
+		{Asset: "www.owasp.org", Type: "dnssec", Severity: SeverityMedium, Title: "DNSSEC validation failed"},
+	}
+	for _, f := range expected {
+		if err := l.Add(f); err != nil {
+			t.Errorf("Failed to add the finding: %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Failed to close the findings log: %v", err)
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read the findings: %v", err)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d findings, got %d", len(expected), len(got))
+	}
+	if got[0].Severity != SeverityInfo {
+		t.Errorf("Expected the default severity to be %s, got %s", SeverityInfo, got[0].Severity)
+	}
+	if got[1].Asset != "www.owasp.org" || got[1].Time.IsZero() {
+		t.Errorf("The finding was not read back correctly: %+v", got[1])
+	}
+}
+
+func BenchmarkLogAdd(b *testing.B) {
+	l, err := NewLog(filepath.Join(b.TempDir(), FileName))
+	if err != nil {
+		b.Fatalf("Failed to open the findings log: %v", err)
+	}
+	defer func() { _ = l.Close() }()
+
+	f := &Finding{Asset: "www.owasp.org", Type: "dnssec", Title: "Zone is DNSSEC-signed"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := l.Add(f); err != nil {
+			b.Fatalf("Failed to add the finding: %v", err)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	var raw interface{}
+	rules := `
+- type: takeover
+  severity: critical
+- type: wildcard_certificate
+  apex: true
+  expires_within: 30
+  severity: medium
+- type: saas_tenant
+  pattern: '\.org$'
+  severity: LOW
+`
+	if err := yaml.Unmarshal([]byte(rules), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := ParsePolicy(raw, func(name string) bool { return name == "owasp.org" })
+	if err != nil {
+		t.Fatalf("Failed to parse the severity rules: %v", err)
+	}
+
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	soon := map[string]string{"not_after": now.AddDate(0, 0, 10).Format(time.RFC3339)}
+	later := map[string]string{"not_after": now.AddDate(1, 0, 0).Format(time.RFC3339)}
+	for _, test := range []struct {
+		f        *Finding
+		expected string
+	}{
+		{&Finding{Type: "takeover", Asset: "www.owasp.org"}, SeverityCritical},
+		{&Finding{Type: "wildcard_certificate", Asset: "*.owasp.org", Time: now, Attributes: soon}, SeverityMedium},
+		{&Finding{Type: "wildcard_certificate", Asset: "*.www.owasp.org", Time: now, Attributes: soon}, ""},
+		{&Finding{Type: "wildcard_certificate", Asset: "*.owasp.org", Time: now, Attributes: later}, ""},
+		{&Finding{Type: "saas_tenant", Asset: "owasp.org"}, SeverityLow},
+		{&Finding{Type: "dnssec", Asset: "owasp.org"}, ""},
+	} {
+		p.Apply(test.f)
+		if test.f.Severity != test.expected {
+			t.Errorf("Expected the %s finding for %s to be %q, got %q", test.f.Type, test.f.Asset, test.expected, test.f.Severity)
+		}
+	}
+
+	if _, err := ParsePolicy([]interface{}{map[string]interface{}{"type": "dnssec", "severity": "urgent"}}, nil); err == nil {
+		t.Error("Expected an error for an invalid severity")
+	}
+
+	fs := []*Finding{
+		{Severity: SeverityLow, Time: now},
+		{Severity: SeverityCritical, Time: now},
+		{Severity: SeverityLow, Time: now.Add(time.Hour)},
+	}
+	SortBySeverity(fs)
+	if fs[0].Severity != SeverityCritical || !fs[1].Time.After(fs[2].Time) {
+		t.Errorf("The findings were not sorted by severity")
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severities are the severity levels from the lowest to the highest.
+var Severities = []string{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// SeverityRank returns the position of the severity level in Severities, or -1 when it is not a valid level.
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// SortBySeverity orders the findings with the highest severity first, and the most recent first within a level.
+func SortBySeverity(fs []*Finding) {
+	sort.SliceStable(fs, func(i, j int) bool {
+		if ri, rj := SeverityRank(fs[i].Severity), SeverityRank(fs[j].Severity); ri != rj {
+			return ri > rj
+		}
+		return fs[i].Time.After(fs[j].Time)
+	})
+}
+
+// SeverityRule assigns the severity to the findings of a type, optionally limited to the matching assets.
+type SeverityRule struct {
+	Type     string `yaml:"type"`
+	Severity string `yaml:"severity"`
+	// Pattern is the regular expression matched against the asset of the finding
+	Pattern string `yaml:"pattern,omitempty"`
+	// Apex limits the rule to the findings about the root domain names
+	Apex bool `yaml:"apex,omitempty"`
+	// ExpiresWithin limits the rule to the findings with a not_after attribute within the number of days
+	ExpiresWithin int `yaml:"expires_within,omitempty"`
+	re            *regexp.Regexp
+}
+
+// Policy assigns the severity of the findings as they are recorded. The first matching rule is used.
+type Policy struct {
+	rules  []*SeverityRule
+	isApex func(name string) bool
+}
+
+// ParsePolicy returns the Policy for the severity option, which is a list of the severity rules.
+// The isApex function identifies the root domain names for the rules limited to them.
+func ParsePolicy(raw interface{}, isApex func(name string) bool) (*Policy, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the severity rules: %v", err)
+	}
+
+	var rules []*SeverityRule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, errors.New("the severity option must be a list of severity rules")
+	}
+
+	p := &Policy{isApex: isApex}
+	for _, r := range rules {
+		if r == nil {
+			continue
+		}
+		if r.Type == "" {
+			return nil, errors.New("each severity rule must provide the finding type")
+		}
+
+		r.Severity = strings.ToLower(r.Severity)
+		if SeverityRank(r.Severity) == -1 {
+			return nil, fmt.Errorf("%s is not a valid severity for the %s findings", r.Severity, r.Type)
+		}
+		if r.Pattern != "" {
+			if r.re, err = regexp.Compile(r.Pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern for the %s findings: %v", r.Type, err)
+			}
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p, nil
+}
+
+// Apply assigns the severity of the first matching rule to the finding.
+func (p *Policy) Apply(f *Finding) {
+	if p == nil {
+		return
+	}
+
+	for _, r := range p.rules {
+		if p.matches(r, f) {
+			f.Severity = r.Severity
+			return
+		}
+	}
+}
+
+func (p *Policy) matches(r *SeverityRule, f *Finding) bool {
+	if r.Type != f.Type {
+		return false
+	}
+	if r.re != nil && !r.re.MatchString(f.Asset) {
+		return false
+	}
+	if r.Apex && (p.isApex == nil || !p.isApex(strings.TrimPrefix(f.Asset, "*."))) {
+		return false
+	}
+	if r.ExpiresWithin > 0 {
+		expires, err := time.Parse(time.RFC3339, f.Attributes["not_after"])
+		if err != nil {
+			return false
+		}
+
+		now := f.Time
+		if now.IsZero() {
+			now = time.Now()
+		}
+		if expires.After(now.AddDate(0, 0, r.ExpiresWithin)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// TypeWildcardCertificate is the type of the findings recorded for wildcard certificates.
+const TypeWildcardCertificate = "wildcard_certificate"
+
+// WildcardCertificate summarizes the wildcard certificates sharing the same key and the hosts
+// serving them. A key shared across many hosts allows the compromise of one to affect them all.
+type WildcardCertificate struct {
+	Key          string
+	Names        []string
+	Fingerprints []string
+	Hosts        []string
+	NotBefore    time.Time
+	NotAfter     time.Time
+}
+
+// KeyAge returns how long the key has been in use, based on the oldest certificate issued for it.
+func (w *WildcardCertificate) KeyAge(now time.Time) time.Duration {
+	if w.NotBefore.IsZero() {
+		return 0
+	}
+	return now.Sub(w.NotBefore)
+}
+
+// WildcardCertificates returns the wildcard certificates found in the findings, grouped by the public
+// key, with the keys served by the most hosts listed first. The matches function selects the wildcard
+// names of interest, and all the findings are included when it is nil.
+func WildcardCertificates(fs []*Finding, matches func(name string) bool) []*WildcardCertificate {
+	byKey := make(map[string]*WildcardCertificate)
+
+	for _, f := range fs {
+		if f.Type != TypeWildcardCertificate || (matches != nil && !matches(f.Asset)) {
+			continue
+		}
+
+		key := f.Attributes["key"]
+		if key == "" {
+			key = f.Attributes["fingerprint"]
+		}
+		w, found := byKey[key]
+		if !found {
+			w = &WildcardCertificate{Key: key}
+			byKey[key] = w
+		}
+
+		w.Names = appendUnique(w.Names, f.Asset)
+		w.Fingerprints = appendUnique(w.Fingerprints, f.Attributes["fingerprint"])
+		w.Hosts = appendUnique(w.Hosts, f.Attributes["host"])
+
+		if t, err := time.Parse(time.RFC3339, f.Attributes["not_before"]); err == nil &&
+			(w.NotBefore.IsZero() || t.Before(w.NotBefore)) {
+			w.NotBefore = t
+		}
+		if t, err := time.Parse(time.RFC3339, f.Attributes["not_after"]); err == nil && t.After(w.NotAfter) {
+			w.NotAfter = t
+		}
+	}
+
+	var results []*WildcardCertificate
+	for _, w := range byKey {
+		sort.Strings(w.Names)
+		sort.Strings(w.Fingerprints)
+		sort.Strings(w.Hosts)
+		results = append(results, w)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if len(results[i].Hosts) != len(results[j].Hosts) {
+			return len(results[i].Hosts) > len(results[j].Hosts)
+		}
+		return strings.Join(results[i].Names, ",") < strings.Join(results[j].Names, ",")
+	})
+	return results
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,60 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWildcardCertificates(t *testing.T) {
+	cert := func(name, key, fp, host, notBefore string) *Finding {
+		return &Finding{
+			Asset: name,
+			Type:  TypeWildcardCertificate,
+			Attributes: map[string]string{
+				"key":         key,
+				"fingerprint": fp,
+				"host":        host,
+				"not_before":  notBefore,
+				"not_after":   "2030-01-01T00:00:00Z",
+			},
+		}
+	}
+
+	fs := []*Finding{
+		cert("*.owasp.org", "k1", "f1", "www.owasp.org:443", "2022-01-01T00:00:00Z"),
+		cert("*.owasp.org", "k1", "f2", "api.owasp.org:443", "2024-01-01T00:00:00Z"),
+		cert("*.dev.owasp.org", "k1", "f3", "app.dev.owasp.org:443", "2023-01-01T00:00:00Z"),
+		cert("*.owasp.org", "k1", "f1", "www.owasp.org:443", "2022-01-01T00:00:00Z"),
+		cert("*.example.com", "k2", "f4", "www.example.com:443", "2024-01-01T00:00:00Z"),
+		{Asset: "owasp.org", Type: "dnssec", Title: "Zone is DNSSEC-signed"},
+	}
+
+	got := WildcardCertificates(fs, func(name string) bool {
+		return strings.HasSuffix(name, ".owasp.org")
+	})
+	if len(got) != 1 {
+		t.Fatalf("Expected one key, got %d", len(got))
+	}
+
+	w := got[0]
+	if !reflect.DeepEqual(w.Names, []string{"*.dev.owasp.org", "*.owasp.org"}) {
+		t.Errorf("Unexpected names: %v", w.Names)
+	}
+	if len(w.Hosts) != 3 || len(w.Fingerprints) != 3 {
+		t.Errorf("Expected three hosts and certificates, got %v and %v", w.Hosts, w.Fingerprints)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if age := w.KeyAge(now); age != now.Sub(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("The key age was not based on the oldest certificate: %s", age)
+	}
+
+	if all := WildcardCertificates(fs, nil); len(all) != 2 || all[0].Key != "k1" {
+		t.Errorf("Expected the key served by the most hosts first, got %d keys", len(all))
+	}
+}
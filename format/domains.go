@@ -0,0 +1,112 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/caffix/stringset"
+)
+
+const (
+	maxNameLen  = 253
+	maxLabelLen = 63
+)
+
+// CleanDomain returns the root domain name provided by the entry of a domain list, which may be copied from
+// a URL or include a port (e.g. https://www.example.com:8443/login), or an error explaining why it was rejected.
+func CleanDomain(entry string) (string, error) {
+	host := TrimLine(entry)
+	if host == "" {
+		return "", fmt.Errorf("the entry %q is empty", entry)
+	}
+	if _, _, err := net.ParseCIDR(host); err == nil {
+		return "", fmt.Errorf("%s is a netblock, not a domain name", host)
+	}
+
+	if strings.Contains(host, "://") {
+		u, err := url.Parse(host)
+		if err != nil || u.Hostname() == "" {
+			return "", fmt.Errorf("%s is not a valid URL", host)
+		}
+		host = u.Hostname()
+	} else {
+		if i := strings.IndexAny(host, "/?#"); i >= 0 {
+			host = host[:i]
+		}
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			host = host[i+1:]
+		}
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+
+	host = strings.Trim(host, "[]")
+	if ip := net.ParseIP(host); ip != nil {
+		return "", fmt.Errorf("%s is an IP address, not a domain name", TrimLine(entry))
+	}
+
+	name := NormalizeName(strings.TrimPrefix(strings.TrimLeft(host, "*"), "."))
+	if err := checkDomainName(name); err != nil {
+		return "", fmt.Errorf("%s %v", TrimLine(entry), err)
+	}
+	return name, nil
+}
+
+func checkDomainName(name string) error {
+	if len(name) > maxNameLen {
+		return fmt.Errorf("is longer than %d characters", maxNameLen)
+	}
+
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return errors.New("does not have at least two labels")
+	}
+
+	for _, label := range labels {
+		if label == "" {
+			return errors.New("has an empty label")
+		}
+		if len(label) > maxLabelLen {
+			return fmt.Errorf("has the label %s longer than %d characters", label, maxLabelLen)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("has the label %s starting or ending with a hyphen", label)
+		}
+
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return fmt.Errorf("has the invalid character %q in the label %s", c, label)
+			}
+		}
+	}
+	return nil
+}
+
+// CleanDomains returns the unique root domain names provided by the entries, and the errors of the rejected
+// entries. The empty entries are skipped.
+func CleanDomains(entries []string) ([]string, []error) {
+	var errs []error
+	var domains []string
+
+	for _, entry := range entries {
+		if TrimLine(entry) == "" {
+			continue
+		}
+
+		d, err := CleanDomain(entry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		domains = append(domains, d)
+	}
+	return stringset.Deduplicate(domains), errs
+}
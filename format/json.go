@@ -0,0 +1,109 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/classify"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// AssetRecord is the machine-readable representation of an asset in the graph database.
+type AssetRecord struct {
+	Type  string          `json:"type"`
+	Key   string          `json:"key"`
+	Asset json.RawMessage `json:"asset,omitempty"`
+	Tags  []string        `json:"tags,omitempty"`
+	// Origins are the names of the federated databases providing the asset
+	Origins   []string  `json:"origins,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// RelationRecord is the machine-readable representation of a relation between two assets.
+type RelationRecord struct {
+	From      *AssetRecord `json:"from"`
+	Relation  string       `json:"relation"`
+	To        *AssetRecord `json:"to"`
+	CreatedAt time.Time    `json:"created_at"`
+	LastSeen  time.Time    `json:"last_seen"`
+}
+
+// NewAssetRecord returns the AssetRecord for the asset, including all the data it carries.
+func NewAssetRecord(a *types.Asset) *AssetRecord {
+	rec := &AssetRecord{
+		Type:      string(a.Asset.AssetType()),
+		Key:       AssetKey(a.Asset),
+		CreatedAt: a.CreatedAt,
+		LastSeen:  a.LastSeen,
+	}
+
+	if data, err := a.Asset.JSON(); err == nil {
+		rec.Asset = data
+	}
+	// The likely function of the host helps to prioritize the attack surface
+	if fqdn, ok := a.Asset.(domain.FQDN); ok {
+		rec.Tags = classify.Name(fqdn.Name)
+	}
+	return rec
+}
+
+// NewRelationRecord returns the RelationRecord for the relation between the from and to assets.
+func NewRelationRecord(from *types.Asset, rel *types.Relation, to *types.Asset) *RelationRecord {
+	return &RelationRecord{
+		From:      NewAssetRecord(from),
+		Relation:  rel.Type,
+		To:        NewAssetRecord(to),
+		CreatedAt: rel.CreatedAt,
+		LastSeen:  rel.LastSeen,
+	}
+}
+
+// AssetKey returns the value that identifies the asset, such as the name of an FQDN.
+func AssetKey(a oam.Asset) string {
+	switch v := a.(type) {
+	case domain.FQDN:
+		return v.Name
+	case network.IPAddress:
+		return v.Address.String()
+	case network.AutonomousSystem:
+		return strconv.Itoa(v.Number)
+	case network.RIROrganization:
+		return v.RIRId + v.Name
+	case network.Netblock:
+		return v.Cidr.String()
+	}
+	return ""
+}
+
+// RecordWriter writes records as newline-delimited JSON (NDJSON), so the output can be
+// consumed by other tools while it is being produced.
+type RecordWriter struct {
+	sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecordWriter returns a RecordWriter that writes the records to w.
+func NewRecordWriter(w io.Writer) *RecordWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &RecordWriter{enc: enc}
+}
+
+// Write encodes the record as a single line of JSON.
+func (w *RecordWriter) Write(rec interface{}) error {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.enc.Encode(rec)
+}
@@ -0,0 +1,63 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/classify"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func TestRecordWriter(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	from := &types.Asset{ID: "1", CreatedAt: now, LastSeen: now, Asset: domain.FQDN{Name: "vpn.owasp.org"}}
+	to := &types.Asset{ID: "2", CreatedAt: now, LastSeen: now, Asset: network.IPAddress{
+		Address: netip.MustParseAddr("192.0.2.1"),
+		Type:    "IPv4",
+	}}
+	rel := &types.Relation{ID: "3", Type: "a_record", CreatedAt: now, LastSeen: now, FromAsset: from, ToAsset: to}
+
+	var buf bytes.Buffer
+	w := NewRecordWriter(&buf)
+	for i := 0; i < 2; i++ {
+		if err := w.Write(NewRelationRecord(from, rel, to)); err != nil {
+			t.Fatalf("Failed to write the record: %v", err)
+		}
+	}
+
+	var lines int
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		lines++
+
+		var rec RelationRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Failed to decode line %d: %v", lines, err)
+		}
+		if rec.From.Type != "FQDN" || rec.From.Key != "vpn.owasp.org" || rec.Relation != "a_record" {
+			t.Errorf("Line %d: unexpected record: %+v", lines, rec)
+		}
+		if len(rec.From.Tags) != 1 || rec.From.Tags[0] != classify.TagVPN {
+			t.Errorf("Line %d: unexpected tags: %v", lines, rec.From.Tags)
+		}
+		if rec.To.Type != "IPAddress" || rec.To.Key != "192.0.2.1" || !rec.To.CreatedAt.Equal(now) {
+			t.Errorf("Line %d: unexpected destination asset: %+v", lines, rec.To)
+		}
+		if len(rec.To.Asset) == 0 {
+			t.Errorf("Line %d: the asset data was not included", lines)
+		}
+	}
+	if lines != 2 {
+		t.Errorf("Expected two lines of output, got %d", lines)
+	}
+}
@@ -5,6 +5,7 @@
 package format
 
 import (
+	"flag"
 	"fmt"
 	"math"
 	"net"
@@ -99,6 +100,9 @@ func (p *ParseIPs) Set(s string) error {
 	if s == "" {
 		return fmt.Errorf("IP address parsing failed")
 	}
+	if s == StdinPath {
+		return setFromStdin(p)
+	}
 
 	for _, v := range strings.Split(s, ",") {
 		if start, end, ok := parseRange(v); ok {
@@ -120,6 +124,24 @@ func (p *ParseIPs) Set(s string) error {
 	return nil
 }
 
+// setFromStdin sets the flag value to each line piped to the standard input.
+func setFromStdin(v flag.Value) error {
+	list, err := StdinList()
+	if err != nil {
+		return err
+	}
+
+	for _, line := range list {
+		if line == StdinPath {
+			continue
+		}
+		if err := v.Set(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func parseRange(s string) (start net.IP, end net.IP, ok bool) {
 	twoIPs := strings.Split(s, "-")
 	if len(twoIPs) != 2 {
@@ -163,6 +185,9 @@ func (p *ParseCIDRs) Set(s string) error {
 	if s == "" {
 		return fmt.Errorf("%s is not a valid CIDR", s)
 	}
+	if s == StdinPath {
+		return setFromStdin(p)
+	}
 
 	cidrs := strings.Split(s, ",")
 	for _, cidr := range cidrs {
@@ -195,6 +220,9 @@ func (p *ParseASNs) Set(s string) error {
 	if s == "" {
 		return fmt.Errorf("ASN parsing failed")
 	}
+	if s == StdinPath {
+		return setFromStdin(p)
+	}
 
 	asns := strings.Split(s, ",")
 	for _, asn := range asns {
@@ -0,0 +1,41 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// StdinPath is the file path and flag value that read the list piped to the standard input,
+// such as cat domains.txt | amass assoc -d - or cat addrs.txt | amass intel -addr -.
+const StdinPath = "-"
+
+// Stdin is the reader providing the lists piped to the commands.
+var Stdin io.Reader = os.Stdin
+
+var stdin struct {
+	sync.Mutex
+	read bool
+	list []string
+	err  error
+}
+
+// StdinList returns the unique lines piped to the standard input that are not empty, as described by ReadList.
+// The input is only read once, so all the flags and files set to StdinPath are provided the same list.
+func StdinList() ([]string, error) {
+	stdin.Lock()
+	defer stdin.Unlock()
+
+	if !stdin.read {
+		stdin.read = true
+		if stdin.list, stdin.err = ReadList(Stdin); stdin.err != nil {
+			stdin.err = fmt.Errorf("error reading the standard input: %v", stdin.err)
+		}
+	}
+	return stdin.list, stdin.err
+}
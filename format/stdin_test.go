@@ -0,0 +1,67 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func pipeStdin(t *testing.T, input string) {
+	prev := Stdin
+	Stdin = strings.NewReader(input)
+	stdin.read, stdin.list, stdin.err = false, nil, nil
+
+	t.Cleanup(func() {
+		Stdin = prev
+		stdin.read, stdin.list, stdin.err = false, nil, nil
+	})
+}
+
+func TestListFromStdin(t *testing.T) {
+	pipeStdin(t, "owasp.org\r\n\nexample.com\nowasp.org\n")
+
+	expected := []string{"example.com", "owasp.org"}
+	// The second read is provided the same list, since the input is only read once
+	for i := 0; i < 2; i++ {
+		list, err := ListFromFile(StdinPath)
+		if err != nil {
+			t.Fatalf("Failed to read the standard input: %v", err)
+		}
+		sort.Strings(list)
+		if !reflect.DeepEqual(list, expected) {
+			t.Errorf("Expected %v, got %v", expected, list)
+		}
+	}
+}
+
+func TestParseFromStdin(t *testing.T) {
+	pipeStdin(t, "192.0.2.10-12\n")
+
+	var ips ParseIPs
+	if err := ips.Set(StdinPath); err != nil {
+		t.Fatalf("Failed to parse the addresses: %v", err)
+	}
+	if got := ips.String(); got != "192.0.2.10,192.0.2.11,192.0.2.12" {
+		t.Errorf("Unexpected addresses: %s", got)
+	}
+
+	pipeStdin(t, "198.51.100.0/24\n")
+
+	var cidrs ParseCIDRs
+	if err := cidrs.Set(StdinPath); err != nil {
+		t.Fatalf("Failed to parse the CIDRs: %v", err)
+	}
+	if got := cidrs.String(); got != "198.51.100.0/24" {
+		t.Errorf("Unexpected CIDRs: %s", got)
+	}
+
+	pipeStdin(t, "not an address\n")
+	if err := new(ParseIPs).Set(StdinPath); err == nil {
+		t.Error("Expected the invalid line to fail the parsing")
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// The asset type names accepted by the output_templates option, in addition to the type names of the assets.
+var templateTypes = map[string]oam.AssetType{
+	"fqdn":     oam.FQDN,
+	"ip":       oam.IPAddress,
+	"netblock": oam.Netblock,
+	"asn":      oam.ASN,
+	"org":      oam.RIROrg,
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Fields are the values provided to the output templates. The fields that do not apply to the asset are empty.
+type Fields struct {
+	Type      string
+	Key       string
+	Name      string
+	Domain    string
+	IP        string
+	IPs       []string
+	Netblock  string
+	ASN       int
+	Org       string
+	Tags      []string
+	Origins   []string
+	Relation  string
+	To        *Fields
+	CreatedAt time.Time
+	LastSeen  time.Time
+}
+
+// NewFields returns the Fields for the asset record.
+func NewFields(rec *AssetRecord) *Fields {
+	f := &Fields{
+		Type:      rec.Type,
+		Key:       rec.Key,
+		Tags:      rec.Tags,
+		Origins:   rec.Origins,
+		CreatedAt: rec.CreatedAt,
+		LastSeen:  rec.LastSeen,
+	}
+	f.set(rec.Type, rec.Key)
+	return f
+}
+
+// NewRelationFields returns the Fields for the relation record, selected by the type of the asset the
+// relation is from. The fields of the other asset fill in the fields that do not apply to the first,
+// so '{{.Name}},{{.IP}}' provides both sides of an a_record relation.
+func NewRelationFields(rec *RelationRecord) *Fields {
+	f := NewFields(rec.From)
+	f.Relation = rec.Relation
+	f.To = NewFields(rec.To)
+
+	if rec.To.Type != rec.From.Type {
+		f.set(rec.To.Type, rec.To.Key)
+	}
+	return f
+}
+
+// NewOutputFields returns the Fields for a name discovered by the intelligence collection.
+func NewOutputFields(out *requests.Output) *Fields {
+	f := &Fields{
+		Type:   string(oam.FQDN),
+		Key:    out.Name,
+		Name:   out.Name,
+		Domain: out.Domain,
+	}
+
+	for _, addr := range out.Addresses {
+		f.IPs = append(f.IPs, addr.Address.String())
+	}
+	if len(out.Addresses) > 0 {
+		f.IP = f.IPs[0]
+		f.Netblock = out.Addresses[0].CIDRStr
+		f.ASN = out.Addresses[0].ASN
+		f.Org = out.Addresses[0].Description
+	}
+	return f
+}
+
+func (f *Fields) set(atype, key string) {
+	switch oam.AssetType(atype) {
+	case oam.FQDN:
+		f.Name = key
+	case oam.IPAddress:
+		f.IP = key
+		f.IPs = []string{key}
+	case oam.Netblock:
+		f.Netblock = key
+	case oam.ASN:
+		f.ASN, _ = strconv.Atoi(key)
+	case oam.RIROrg:
+		f.Org = key
+	}
+}
+
+// Templates formats the output lines using Go templates, such as '{{.Name}},{{.IP}}', selected by the asset type.
+type Templates struct {
+	def   *template.Template
+	types map[oam.AssetType]*template.Template
+}
+
+// NewTemplates returns the Templates using the default template and the templates for each asset
+// type, which take precedence over the default. Nil is returned when no templates are provided.
+func NewTemplates(def string, byType map[string]string) (*Templates, error) {
+	if def == "" && len(byType) == 0 {
+		return nil, nil
+	}
+
+	t := &Templates{types: make(map[oam.AssetType]*template.Template)}
+	if def != "" {
+		tmpl, err := parseTemplate("format", def)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output template: %v", err)
+		}
+		t.def = tmpl
+	}
+
+	for name, text := range byType {
+		atype, err := templateType(name)
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl, err := parseTemplate(name, text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output template for the %s assets: %v", name, err)
+		}
+		t.types[atype] = tmpl
+	}
+	return t, nil
+}
+
+// ParseTemplateSettings returns the templates for each asset type from the output_templates option.
+func ParseTemplateSettings(raw interface{}) (map[string]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the output_templates option must map the asset types to the templates")
+	}
+
+	byType := make(map[string]string, len(m))
+	for name, v := range m {
+		text, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("the output template for the %s assets must be a string", name)
+		}
+		byType[name] = text
+	}
+	return byType, nil
+}
+
+// Line returns the output line for the fields, and false when no template applies to the asset type.
+func (t *Templates) Line(f *Fields) (string, bool, error) {
+	if t == nil {
+		return "", false, nil
+	}
+
+	tmpl, found := t.types[oam.AssetType(f.Type)]
+	if !found {
+		tmpl = t.def
+	}
+	if tmpl == nil {
+		return "", false, nil
+	}
+
+	// The fields of assets without a relation can also be used with templates referring to .To
+	if f.To == nil {
+		c := *f
+		c.To = &Fields{}
+		f = &c
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, f); err != nil {
+		return "", false, err
+	}
+	return buf.String(), true, nil
+}
+
+// parseTemplate parses the template and executes it with empty fields, so the fields that do not exist
+// are reported before any output is produced.
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err == nil {
+		err = tmpl.Execute(io.Discard, &Fields{To: &Fields{}})
+	}
+	return tmpl, err
+}
+
+func templateType(name string) (oam.AssetType, error) {
+	if atype, found := templateTypes[strings.ToLower(name)]; found {
+		return atype, nil
+	}
+
+	for _, atype := range templateTypes {
+		if strings.EqualFold(name, string(atype)) {
+			return atype, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a supported asset type for the output templates", name)
+}
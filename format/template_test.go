@@ -0,0 +1,80 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"net"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestTemplates(t *testing.T) {
+	tmpls, err := NewTemplates("{{.Name}},{{.IP}}", map[string]string{
+		"netblock": "{{.Netblock}} {{.Relation}} {{.To.IP}}",
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the templates: %v", err)
+	}
+
+	name := &AssetRecord{Type: "FQDN", Key: "www.owasp.org"}
+	addr := &AssetRecord{Type: "IPAddress", Key: "192.0.2.1"}
+	block := &AssetRecord{Type: "Netblock", Key: "192.0.2.0/24"}
+	for _, tc := range []struct {
+		fields *Fields
+		want   string
+	}{
+		{NewRelationFields(&RelationRecord{From: name, Relation: "a_record", To: addr}), "www.owasp.org,192.0.2.1"},
+		{NewRelationFields(&RelationRecord{From: block, Relation: "contains", To: addr}), "192.0.2.0/24 contains 192.0.2.1"},
+		{NewFields(addr), ",192.0.2.1"},
+		{NewFields(block), "192.0.2.0/24  "},
+		{NewOutputFields(&requests.Output{
+			Name:      "mail.owasp.org",
+			Domain:    "owasp.org",
+			Addresses: []requests.AddressInfo{{Address: net.ParseIP("198.51.100.1")}},
+		}), "mail.owasp.org,198.51.100.1"},
+	} {
+		if line, ok, err := tmpls.Line(tc.fields); err != nil || !ok || line != tc.want {
+			t.Errorf("Expected %q, got %q: %v", tc.want, line, err)
+		}
+	}
+}
+
+func TestTemplatesByType(t *testing.T) {
+	tmpls, err := NewTemplates("", map[string]string{"IPAddress": `{{join .IPs " "}}`})
+	if err != nil {
+		t.Fatalf("Failed to parse the templates: %v", err)
+	}
+
+	if _, ok, _ := tmpls.Line(&Fields{Type: "FQDN", Name: "www.owasp.org"}); ok {
+		t.Error("Expected no template to apply to the names")
+	}
+	if line, ok, _ := tmpls.Line(&Fields{Type: "IPAddress", IPs: []string{"192.0.2.1", "192.0.2.2"}}); !ok || line != "192.0.2.1 192.0.2.2" {
+		t.Errorf("Unexpected line: %q", line)
+	}
+
+	var none *Templates
+	if _, ok, _ := none.Line(&Fields{Type: "FQDN"}); ok {
+		t.Error("Expected no line without templates")
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	if _, err := NewTemplates("{{.Name", nil); err == nil {
+		t.Error("Expected an error for the invalid template")
+	}
+	if _, err := NewTemplates("{{.Port}}", nil); err == nil {
+		t.Error("Expected an error for the missing field")
+	}
+	if _, err := NewTemplates("", map[string]string{"port": "{{.Key}}"}); err == nil {
+		t.Error("Expected an error for the unsupported asset type")
+	}
+	if _, err := ParseTemplateSettings([]interface{}{"{{.Name}}"}); err == nil {
+		t.Error("Expected an error for the invalid option")
+	}
+	if tmpls, err := NewTemplates("", nil); tmpls != nil || err != nil {
+		t.Error("Expected no templates without a template")
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/caffix/stringset"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/encoding"
+	unicodeenc "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const byteOrderMark = '\ufeff'
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// NewTextReader returns a reader providing the text as UTF-8. The byte order mark is removed, and the
+// UTF-16 text written by Windows tools, such as PowerShell and Notepad, is decoded.
+func NewTextReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	// The short reads are detected by the length of the peeked bytes
+	head, _ := br.Peek(2)
+
+	var dec *encoding.Decoder
+	switch {
+	case len(head) < 2:
+		return br
+	case head[0] == utf8BOM[0] && head[1] == utf8BOM[1]:
+		if h, _ := br.Peek(3); bytes.Equal(h, utf8BOM) {
+			_, _ = br.Discard(3)
+		}
+		return br
+	case head[0] == 0xff && head[1] == 0xfe:
+		dec = unicodeenc.UTF16(unicodeenc.LittleEndian, unicodeenc.ExpectBOM).NewDecoder()
+	case head[0] == 0xfe && head[1] == 0xff:
+		dec = unicodeenc.UTF16(unicodeenc.BigEndian, unicodeenc.ExpectBOM).NewDecoder()
+	// The lists never contain NUL characters, so they reveal the UTF-16 text without a byte order mark
+	case head[0] != 0 && head[1] == 0:
+		dec = unicodeenc.UTF16(unicodeenc.LittleEndian, unicodeenc.IgnoreBOM).NewDecoder()
+	case head[0] == 0 && head[1] != 0:
+		dec = unicodeenc.UTF16(unicodeenc.BigEndian, unicodeenc.IgnoreBOM).NewDecoder()
+	default:
+		return br
+	}
+	return transform.NewReader(br, dec)
+}
+
+// ScanLines is a bufio.SplitFunc that splits the text at the LF, CRLF and CR line endings.
+func ScanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		// The CR could be followed by the LF of a CRLF line ending
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// TrimLine removes the whitespace and the byte order marks, which remain in the lines of concatenated files.
+func TrimLine(line string) string {
+	return strings.TrimFunc(line, func(r rune) bool {
+		return unicode.IsSpace(r) || r == byteOrderMark
+	})
+}
+
+// ReadList returns the unique lines of the text that are not empty. The text may be gzip compressed,
+// UTF-16 encoded or start with a byte order mark, and may use any line endings.
+func ReadList(r io.Reader) ([]string, error) {
+	br := bufio.NewReader(r)
+
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	var list []string
+	scanner := bufio.NewScanner(NewTextReader(r))
+	scanner.Split(ScanLines)
+	for scanner.Scan() {
+		if w := TrimLine(scanner.Text()); w != "" {
+			list = append(list, w)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stringset.Deduplicate(list), nil
+}
+
+// ListFromFile returns the unique lines of the file that are not empty, as described by ReadList.
+// The lines piped to the standard input are returned for the StdinPath.
+func ListFromFile(path string) ([]string, error) {
+	if path == StdinPath {
+		return StdinList()
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the file %s: %v", absPath, err)
+	}
+	defer f.Close()
+
+	list, err := ReadList(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the file %s: %v", absPath, err)
+	}
+	return list, nil
+}
+
+// NormalizeName returns the DNS name in lowercase without the trailing dot, and the
+// internationalized labels converted to their ASCII form (e.g. bücher.example to xn--bcher-kva.example).
+func NormalizeName(name string) string {
+	name = strings.TrimSuffix(TrimLine(name), ".")
+
+	if !isASCII(name) {
+		if ascii, err := idna.Lookup.ToASCII(name); err == nil {
+			name = ascii
+		}
+	}
+	return strings.ToLower(name)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeNames returns the unique normalized DNS names, as described by NormalizeName.
+func NormalizeNames(names []string) []string {
+	var normalized []string
+
+	for _, name := range names {
+		if n := NormalizeName(name); n != "" {
+			normalized = append(normalized, n)
+		}
+	}
+	return stringset.Deduplicate(normalized)
+}
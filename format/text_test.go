@@ -0,0 +1,124 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, bigEndian, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xfeff}, units...)
+	}
+
+	var buf bytes.Buffer
+	for _, u := range units {
+		if bigEndian {
+			buf.Write([]byte{byte(u >> 8), byte(u)})
+		} else {
+			buf.Write([]byte{byte(u), byte(u >> 8)})
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestReadList(t *testing.T) {
+	const text = "owasp.org\r\nexample.com\r\n\r\n  bücher.example \r\nowasp.org\r\n"
+	expected := []string{"bücher.example", "example.com", "owasp.org"}
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write(append([]byte{0xef, 0xbb, 0xbf}, text...))
+	w.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"UTF-8 with CRLF", []byte(text)},
+		{"UTF-8 with a BOM", append([]byte{0xef, 0xbb, 0xbf}, text...)},
+		{"UTF-16LE with a BOM", encodeUTF16(text, false, true)},
+		{"UTF-16BE with a BOM", encodeUTF16(text, true, true)},
+		{"UTF-16LE without a BOM", encodeUTF16(text, false, false)},
+		{"CR line endings", []byte(strings.ReplaceAll(text, "\r\n", "\r"))},
+		{"concatenated files", []byte("owasp.org\n\ufeffexample.com\n\ufeffbücher.example")},
+		{"gzip compressed", gz.Bytes()},
+	}
+
+	for _, test := range tests {
+		list, err := ReadList(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: failed to read the list: %v", test.name, err)
+			continue
+		}
+
+		sort.Strings(list)
+		if strings.Join(list, ",") != strings.Join(expected, ",") {
+			t.Errorf("%s: expected %v, got %q", test.name, expected, list)
+		}
+	}
+}
+
+func TestListFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	if err := os.WriteFile(path, encodeUTF16("owasp.org\r\n", false, true), 0600); err != nil {
+		t.Fatalf("Failed to write the file: %v", err)
+	}
+
+	if list, err := ListFromFile(path); err != nil || len(list) != 1 || list[0] != "owasp.org" {
+		t.Errorf("Unexpected list %q: %v", list, err)
+	}
+	if _, err := ListFromFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected an error for the missing file")
+	}
+}
+
+func TestScanLines(t *testing.T) {
+	// The small buffer splits the CRLF line endings across the reads
+	scanner := bufio.NewScanner(bufio.NewReaderSize(strings.NewReader("a\r\nbb\rccc\ndddd\r\n\r\neeeee"), 16))
+	scanner.Buffer(make([]byte, 4), 64)
+	scanner.Split(ScanLines)
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if got := strings.Join(lines, "|"); got != "a|bb|ccc|dddd||eeeee" {
+		t.Errorf("Unexpected lines: %s", got)
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"WWW.OWASP.org.", "www.owasp.org"},
+		{"\ufeffowasp.org\r", "owasp.org"},
+		{"Bücher.Example", "xn--bcher-kva.example"},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example"},
+		{"_dmarc.Example.com", "_dmarc.example.com"},
+		{"*.example.com", "*.example.com"},
+	}
+
+	for _, test := range tests {
+		if got := NormalizeName(test.name); got != test.expected {
+			t.Errorf("NormalizeName(%q): expected %s, got %s", test.name, test.expected, got)
+		}
+	}
+
+	if got := NormalizeNames([]string{"OWASP.org", "owasp.org.", " "}); len(got) != 1 || got[0] != "owasp.org" {
+		t.Errorf("Unexpected names: %q", got)
+	}
+}
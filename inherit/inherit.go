@@ -0,0 +1,248 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package inherit bootstraps the scope of a session from the assets already confirmed in the graph database,
+// such as the apex domains and netblocks of an organization, so the monitoring runs stay in sync with the
+// scope of the previous sessions without maintaining the lists of domains by hand.
+package inherit
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/assoc"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Database is the subset of the graph database read to collect the inherited scope.
+type Database interface {
+	FindByType(atype oam.AssetType, since time.Time) ([]*types.Asset, error)
+	FindById(id string, since time.Time) (*types.Asset, error)
+	IncomingRelations(asset *types.Asset, since time.Time, relationTypes ...string) ([]*types.Relation, error)
+	OutgoingRelations(asset *types.Asset, since time.Time, relationTypes ...string) ([]*types.Relation, error)
+}
+
+// Settings select the organization the scope is inherited from, and the parts of the scope inherited.
+type Settings struct {
+	// Organization is the name of the organization registering the infrastructure in scope
+	Organization string
+	Domains      bool
+	Netblocks    bool
+}
+
+// ParseSettings returns the settings provided by the inherit_scope option, which is either the name of the
+// organization, or a map providing the organization and whether the domains and netblocks are inherited.
+// Nil is returned when the option is not provided.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	s := &Settings{Domains: true, Netblocks: true}
+	switch v := raw.(type) {
+	case string:
+		s.Organization = v
+	case map[string]interface{}:
+		for key, val := range v {
+			switch key {
+			case "organization":
+				name, ok := val.(string)
+				if !ok {
+					return nil, errors.New("the inherit_scope organization must be a string")
+				}
+				s.Organization = name
+			case "domains", "netblocks":
+				enabled, ok := val.(bool)
+				if !ok {
+					return nil, fmt.Errorf("the inherit_scope %s setting must be a boolean", key)
+				}
+				if key == "domains" {
+					s.Domains = enabled
+				} else {
+					s.Netblocks = enabled
+				}
+			default:
+				return nil, fmt.Errorf("%s is not a valid inherit_scope setting", key)
+			}
+		}
+	default:
+		return nil, errors.New("the inherit_scope option must be the name of an organization or a map of settings")
+	}
+
+	s.Organization = strings.TrimSpace(s.Organization)
+	if s.Organization == "" {
+		return nil, errors.New("the inherit_scope option must provide the name of the organization")
+	}
+	if assoc.RegistrantKey(s.Organization) == "" {
+		return nil, fmt.Errorf("%s is the name of a privacy service and cannot provide the scope", s.Organization)
+	}
+	if assoc.SharedProvider(s.Organization) {
+		return nil, fmt.Errorf("%s operates shared infrastructure and cannot provide the scope", s.Organization)
+	}
+	if !s.Domains && !s.Netblocks {
+		return nil, errors.New("the inherit_scope option must inherit the domains or the netblocks")
+	}
+	return s, nil
+}
+
+// Scope is the scope inherited from the graph database.
+type Scope struct {
+	// Organizations are the names of the registrants matching the organization
+	Organizations []string
+	ASNs          []int
+	Netblocks     []string
+	Domains       []string
+}
+
+// Collect returns the scope of the organization in the database. The registrants are matched using their
+// normalized names, so the variations of the name and the subsidiaries carrying the name of the organization
+// are included. The netblocks announced by the autonomous systems of the registrants are inherited, along
+// with the registered domains of the names resolving to the addresses in those netblocks.
+func Collect(db Database, s *Settings) (*Scope, error) {
+	var since time.Time
+	key := assoc.RegistrantKey(s.Organization)
+
+	orgs, err := db.FindByType(oam.RIROrg, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the graph database: %v", err)
+	}
+
+	scope := new(Scope)
+	asns := make(map[int]struct{})
+	cidrs := make(map[string]struct{})
+	domains := make(map[string]struct{})
+	for _, org := range orgs {
+		rir, ok := org.Asset.(network.RIROrganization)
+		if !ok || !matchesKey(assoc.RegistrantKey(rir.Name), key) {
+			continue
+		}
+		scope.Organizations = appendUnique(scope.Organizations, rir.Name)
+
+		rels, err := db.IncomingRelations(org, since, "managed_by")
+		if err != nil {
+			continue
+		}
+		for _, rel := range rels {
+			as, err := db.FindById(rel.FromAsset.ID, since)
+			if err != nil {
+				continue
+			}
+			if a, ok := as.Asset.(network.AutonomousSystem); ok {
+				asns[a.Number] = struct{}{}
+			}
+			announced(db, as, since, cidrs, domains)
+		}
+	}
+
+	for asn := range asns {
+		scope.ASNs = append(scope.ASNs, asn)
+	}
+	if s.Netblocks {
+		scope.Netblocks = keys(cidrs)
+	} else {
+		scope.ASNs = nil
+	}
+	if s.Domains {
+		scope.Domains = keys(domains)
+	}
+	sort.Ints(scope.ASNs)
+	sort.Strings(scope.Organizations)
+	return scope, nil
+}
+
+// announced adds the netblocks announced by the autonomous system, and the registered domains
+// of the names resolving to the addresses in the netblocks.
+func announced(db Database, as *types.Asset, since time.Time, cidrs, domains map[string]struct{}) {
+	rels, err := db.OutgoingRelations(as, since, "announces")
+	if err != nil {
+		return
+	}
+
+	for _, rel := range rels {
+		nb, err := db.FindById(rel.ToAsset.ID, since)
+		if err != nil {
+			continue
+		}
+		netblock, ok := nb.Asset.(network.Netblock)
+		if !ok {
+			continue
+		}
+		cidrs[netblock.Cidr.String()] = struct{}{}
+
+		contains, err := db.OutgoingRelations(nb, since, "contains")
+		if err != nil {
+			continue
+		}
+		for _, c := range contains {
+			addr, err := db.FindById(c.ToAsset.ID, since)
+			if err != nil {
+				continue
+			}
+
+			records, err := db.IncomingRelations(addr, since, "a_record", "aaaa_record")
+			if err != nil {
+				continue
+			}
+			for _, r := range records {
+				name, err := db.FindById(r.FromAsset.ID, since)
+				if err != nil {
+					continue
+				}
+				if fqdn, ok := name.Asset.(domain.FQDN); ok {
+					if d, err := publicsuffix.EffectiveTLDPlusOne(fqdn.Name); err == nil {
+						domains[d] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+}
+
+// Empty returns true when nothing was inherited.
+func (s *Scope) Empty() bool {
+	return len(s.Domains) == 0 && len(s.Netblocks) == 0
+}
+
+// String returns the summary of the inherited scope for the log.
+func (s *Scope) String() string {
+	asns := make([]string, 0, len(s.ASNs))
+	for _, asn := range s.ASNs {
+		asns = append(asns, strconv.Itoa(asn))
+	}
+
+	return fmt.Sprintf("Organizations: [%s], ASNs: [%s], Netblocks: [%s], Domains: [%s]",
+		strings.Join(s.Organizations, ", "), strings.Join(asns, ", "),
+		strings.Join(s.Netblocks, ", "), strings.Join(s.Domains, ", "))
+}
+
+// matchesKey returns true when the registrant is the organization, or a subsidiary carrying its name.
+func matchesKey(registrant, key string) bool {
+	return registrant != "" && (registrant == key || strings.HasPrefix(registrant, key+" "))
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func keys(m map[string]struct{}) []string {
+	results := make([]string, 0, len(m))
+	for k := range m {
+		results = append(results, k)
+	}
+	sort.Strings(results)
+	return results
+}
@@ -0,0 +1,85 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package inherit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/assettest"
+)
+
+func TestParseSettings(t *testing.T) {
+	if s, err := ParseSettings(nil); err != nil || s != nil {
+		t.Errorf("Expected no settings when the option is not provided")
+	}
+
+	s, err := ParseSettings("Example Corp")
+	if err != nil || s.Organization != "Example Corp" || !s.Domains || !s.Netblocks {
+		t.Errorf("Failed to parse the organization name: %v", err)
+	}
+
+	s, err = ParseSettings(map[string]interface{}{"organization": "Example Corp", "netblocks": false})
+	if err != nil || !s.Domains || s.Netblocks {
+		t.Errorf("Failed to parse the map of settings: %v", err)
+	}
+
+	for _, raw := range []interface{}{
+		"",
+		5,
+		"Cloudflare, Inc.",
+		"Domains By Proxy, LLC",
+		map[string]interface{}{"organization": "Example Corp", "depth": 2},
+		map[string]interface{}{"organization": "Example Corp", "domains": "yes"},
+		map[string]interface{}{"organization": "Example Corp", "domains": false, "netblocks": false},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected the inherit_scope option %v to be rejected", raw)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	g := assettest.NewGraph(t)
+	assettest.Populate(t, g,
+		assettest.Infrastructure(64500, "Example Corp", "192.0.2.10", "192.0.2.0/24"),
+		assettest.Infrastructure(64501, "EXAMPLE CORPORATION", "198.51.100.10", "198.51.100.0/24"),
+		assettest.Infrastructure(64502, "Example Labs Ltd", "203.0.113.10", "203.0.113.0/24"),
+		assettest.Infrastructure(64503, "Examples Unrelated", "100.64.0.10", "100.64.0.0/24"),
+		assettest.A("www.example.com", "192.0.2.10"),
+		assettest.A("mail.example.com", "192.0.2.10"),
+		assettest.A("shop.example.net", "198.51.100.10"),
+		assettest.A("research.examplelabs.org", "203.0.113.10"),
+		assettest.A("www.unrelated.com", "100.64.0.10"),
+	)
+
+	scope, err := Collect(g.DB, &Settings{Organization: "Example, Inc.", Domains: true, Netblocks: true})
+	if err != nil {
+		t.Fatalf("Failed to collect the scope: %v", err)
+	}
+
+	expected := &Scope{
+		Organizations: []string{"EXAMPLE CORPORATION", "Example Corp", "Example Labs Ltd"},
+		ASNs:          []int{64500, 64501, 64502},
+		Netblocks:     []string{"192.0.2.0/24", "198.51.100.0/24", "203.0.113.0/24"},
+		Domains:       []string{"example.com", "example.net", "examplelabs.org"},
+	}
+	if !reflect.DeepEqual(scope, expected) {
+		t.Errorf("Expected the scope %v, got %v", expected, scope)
+	}
+
+	scope, err = Collect(g.DB, &Settings{Organization: "Example Labs", Domains: true})
+	if err != nil {
+		t.Fatalf("Failed to collect the scope: %v", err)
+	}
+	if len(scope.Netblocks) != 0 || len(scope.ASNs) != 0 || !reflect.DeepEqual(scope.Domains, []string{"examplelabs.org"}) {
+		t.Errorf("Expected only the domains of the subsidiary, got %v", scope)
+	}
+
+	scope, err = Collect(g.DB, &Settings{Organization: "Missing Org", Domains: true, Netblocks: true})
+	if err != nil || !scope.Empty() {
+		t.Errorf("Expected an empty scope for an unknown organization, got %v", scope)
+	}
+}
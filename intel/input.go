@@ -57,7 +57,21 @@ func (r *intelSource) Next(ctx context.Context) bool {
 	default:
 	}
 
-	return !r.queue.Empty()
+	if !r.queue.Empty() {
+		return true
+	}
+	// Wait for the addresses still to be released by the netblock sweep
+	if s := r.collection.sweep; s != nil {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+		case <-r.queue.Signal():
+			return true
+		case <-s.finished:
+			return !r.queue.Empty()
+		}
+	}
+	return false
 }
 
 // Data implements the pipeline InputSource interface.
@@ -16,7 +16,6 @@ import (
 	"github.com/caffix/service"
 	"github.com/caffix/stringset"
 	"github.com/owasp-amass/amass/v4/datasrcs"
-	amassnet "github.com/owasp-amass/amass/v4/net"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
@@ -42,6 +41,7 @@ type Collection struct {
 	doneAlreadyClosed bool
 	filter            *bf.StableBloomFilter
 	timeChan          chan time.Time
+	sweep             *sweep
 }
 
 // NewCollection returns an initialized Collection object that has not been started yet.
@@ -94,16 +94,9 @@ func (c *Collection) HostedDomains(ctx context.Context) error {
 	for _, addr := range c.Config.Scope.Addresses {
 		source.InputAddress(&requests.AddrRequest{Address: addr.String()})
 	}
-	for _, cidr := range append(c.Config.Scope.CIDRs, c.asnsToCIDRs()...) {
-		// Skip IPv6 netblocks, since they are simply too large
-		if ip := cidr.IP.Mask(cidr.Mask); amassnet.IsIPv6(ip) {
-			continue
-		}
-
-		for _, addr := range amassnet.AllHosts(cidr) {
-			source.InputAddress(&requests.AddrRequest{Address: addr.String()})
-		}
-	}
+	// Sweep the netblocks in a random order that can be resumed after an interruption
+	c.sweep = newSweep(c.Config, append(c.Config.Scope.CIDRs, c.asnsToCIDRs()...))
+	go c.sweep.run(c.ctx, source)
 
 	return pipeline.NewPipeline(stages...).Execute(ctx, source, c.makeOutputSink())
 }
@@ -132,6 +125,7 @@ func (c *Collection) makeDNSTaskFunc() pipeline.TaskFunc {
 		if req == nil {
 			return nil, nil
 		}
+		defer c.sweep.complete(req.Address)
 
 		ip := net.ParseIP(req.Address)
 		if ip == nil {
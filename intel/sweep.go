@@ -0,0 +1,238 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package intel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/progress"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/session"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	sweepFileName = "sweep_progress.json"
+	// The default number of addresses per second sent to each /24 netblock.
+	defaultSweepPace = 10
+	// Provides the scan ordering with a sequence of random numbers different from other components.
+	sweepSeedSalt = 2
+)
+
+// sweepKind is the versioned format of the progress file, which is documented by the session package.
+var sweepKind = &session.Kind{Name: "sweep", Version: 1}
+
+// sweepProgress is the state of a netblock sweep persisted in the output directory.
+type sweepProgress struct {
+	ID        string   `json:"id"`
+	Completed []string `json:"completed"`
+}
+
+type sweepBlock struct {
+	name  string
+	hosts []net.IP
+	next  int
+}
+
+// sweep releases the addresses within the netblocks in a random order, while limiting the
+// rate of requests sent to each /24 and tracking the completed /24s so it can be resumed.
+type sweep struct {
+	sync.Mutex
+	path      string
+	id        string
+	pace      time.Duration
+	blocks    []*sweepBlock
+	remaining map[string]int
+	completed map[string]struct{}
+	finished  chan struct{}
+}
+
+func newSweep(cfg *config.Config, cidrs []*net.IPNet) *sweep {
+	s := &sweep{
+		path:      filepath.Join(config.OutputDirectory(cfg.Dir), sweepFileName),
+		id:        sweepID(cidrs),
+		pace:      time.Second / time.Duration(sweepPace(cfg)),
+		remaining: make(map[string]int),
+		completed: make(map[string]struct{}),
+		finished:  make(chan struct{}),
+	}
+
+	var p sweepProgress
+	if found, err := sweepKind.Read(s.path, &p); err == nil && found && p.ID == s.id {
+		for _, b := range p.Completed {
+			s.completed[b] = struct{}{}
+		}
+	}
+
+	s.blocks = splitBlocks(cidrs, s.completed)
+	var total int
+	for _, b := range s.blocks {
+		s.remaining[b.name] = len(b.hosts)
+		total += len(b.hosts)
+	}
+	progress.Default.Tracker(progress.Sweep).Add(total)
+
+	rnd := systems.NewRand(cfg, sweepSeedSalt)
+	rnd.Shuffle(len(s.blocks), func(i, j int) {
+		s.blocks[i], s.blocks[j] = s.blocks[j], s.blocks[i]
+	})
+	for _, b := range s.blocks {
+		rnd.Shuffle(len(b.hosts), func(i, j int) {
+			b.hosts[i], b.hosts[j] = b.hosts[j], b.hosts[i]
+		})
+	}
+	return s
+}
+
+func sweepPace(cfg *config.Config) int {
+	if v, ok := cfg.Options["sweep_pace"].(int); ok && v > 0 {
+		return v
+	}
+	return defaultSweepPace
+}
+
+// sweepID identifies the set of netblocks, so progress is only resumed for the same sweep.
+func sweepID(cidrs []*net.IPNet) string {
+	var names []string
+	for _, cidr := range cidrs {
+		names = append(names, cidr.String())
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, n := range names {
+		h.Write([]byte(n + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// splitBlocks groups the host addresses of the IPv4 netblocks into /24 blocks,
+// skipping the blocks that have already been completed.
+func splitBlocks(cidrs []*net.IPNet, completed map[string]struct{}) []*sweepBlock {
+	var blocks []*sweepBlock
+	byName := make(map[string]*sweepBlock)
+	seen := make(map[string]struct{})
+
+	for _, cidr := range cidrs {
+		// Skip IPv6 netblocks, since they are simply too large
+		if ip := cidr.IP.Mask(cidr.Mask); amassnet.IsIPv6(ip) {
+			continue
+		}
+
+		for _, addr := range amassnet.AllHosts(cidr) {
+			name := blockName(addr)
+			if _, found := completed[name]; found {
+				continue
+			}
+			if _, found := seen[addr.String()]; found {
+				continue
+			}
+			seen[addr.String()] = struct{}{}
+
+			b, found := byName[name]
+			if !found {
+				b = &sweepBlock{name: name}
+				byName[name] = b
+				blocks = append(blocks, b)
+			}
+			b.hosts = append(b.hosts, addr)
+		}
+	}
+	return blocks
+}
+
+func blockName(addr net.IP) string {
+	return (&net.IPNet{
+		IP:   addr.Mask(net.CIDRMask(24, 32)),
+		Mask: net.CIDRMask(24, 32),
+	}).String()
+}
+
+// run releases one address from each /24 per round, and waits between rounds
+// so no /24 receives more addresses per second than the configured pace.
+func (s *sweep) run(ctx context.Context, source *intelSource) {
+	defer close(s.finished)
+
+	t := time.NewTimer(0)
+	defer t.Stop()
+
+	active := s.blocks
+	for len(active) > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-source.done:
+			return
+		case <-t.C:
+		}
+
+		start := time.Now()
+		var next []*sweepBlock
+		for _, b := range active {
+			source.InputAddress(&requests.AddrRequest{Address: b.hosts[b.next].String()})
+
+			b.next++
+			if b.next < len(b.hosts) {
+				next = append(next, b)
+			}
+		}
+
+		active = next
+		t.Reset(s.pace - time.Since(start))
+	}
+}
+
+// complete marks the address as processed and saves the progress when the /24 has been swept.
+func (s *sweep) complete(addr string) {
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	name := blockName(ip)
+	n, found := s.remaining[name]
+	if !found {
+		return
+	}
+	progress.Default.Tracker(progress.Sweep).Done(1)
+
+	if n--; n > 0 {
+		s.remaining[name] = n
+		return
+	}
+
+	delete(s.remaining, name)
+	s.completed[name] = struct{}{}
+	s.save()
+}
+
+func (s *sweep) save() {
+	// The progress is no longer required once the sweep has finished
+	if len(s.remaining) == 0 {
+		_ = os.Remove(s.path)
+		return
+	}
+
+	p := sweepProgress{ID: s.id}
+	for name := range s.completed {
+		p.Completed = append(p.Completed, name)
+	}
+	sort.Strings(p.Completed)
+
+	_ = sweepKind.Write(s.path, &p)
+}
@@ -0,0 +1,49 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package intel
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestSweepResume(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+
+	_, cidr, _ := net.ParseCIDR("192.0.2.0/23")
+	s := newSweep(cfg, []*net.IPNet{cidr})
+	if len(s.blocks) != 2 {
+		t.Fatalf("Expected the /23 to be split into two blocks, got %d", len(s.blocks))
+	}
+
+	first := s.blocks[0]
+	for _, addr := range first.hosts {
+		s.complete(addr.String())
+	}
+	if _, err := os.Stat(s.path); err != nil {
+		t.Fatalf("The sweep progress was not saved: %v", err)
+	}
+
+	resumed := newSweep(cfg, []*net.IPNet{cidr})
+	if len(resumed.blocks) != 1 || resumed.blocks[0].name == first.name {
+		t.Errorf("The resumed sweep did not skip the completed block %s", first.name)
+	}
+
+	_, other, _ := net.ParseCIDR("198.51.100.0/24")
+	if fresh := newSweep(cfg, []*net.IPNet{other}); len(fresh.blocks) != 1 || len(fresh.blocks[0].hosts) != 254 {
+		t.Errorf("The progress of a different sweep was applied")
+	}
+
+	for _, addr := range resumed.blocks[0].hosts {
+		resumed.complete(addr.String())
+	}
+	if _, err := os.Stat(resumed.path); !os.IsNotExist(err) {
+		t.Errorf("The sweep progress was not removed after the sweep finished")
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package intel
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/dataset"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	tldListURL      = "https://data.iana.org/TLD/tlds-alpha-by-domain.txt"
+	tldListFileName = "tlds.txt"
+	tldListMaxAge   = 7 * 24 * time.Hour
+	maxTLDQueries   = 50
+)
+
+// ExpandTLDs discovers registrations of the second-level labels of the provided domains across all
+// TLDs (e.g. example.* for example.com), which identifies forgotten regional registrations.
+func (c *Collection) ExpandTLDs(ctx context.Context) error {
+	defer close(c.Output)
+
+	if err := c.Config.CheckSettings(); err != nil {
+		return err
+	}
+
+	tlds, err := c.tldList(ctx)
+	if err != nil {
+		return err
+	}
+
+	candidates := make(map[string][]string) // TLD -> candidate names
+	for _, domain := range c.Config.Domains() {
+		label := brandLabel(domain)
+		if label == "" {
+			continue
+		}
+
+		for _, tld := range tlds {
+			if name := label + "." + tld; name != domain {
+				candidates[tld] = append(candidates[tld], name)
+			}
+		}
+	}
+
+	names := make(chan string, maxTLDQueries)
+	var wg sync.WaitGroup
+	for i := 0; i < maxTLDQueries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for name := range names {
+				if c.registered(ctx, name) {
+					c.outputRegistration(name)
+				}
+			}
+		}()
+	}
+
+	dir := config.OutputDirectory(c.Config.Dir)
+loop:
+	for tld, list := range candidates {
+		// Zone files downloaded from CZDS show the presence of the names without any queries
+		if path := dataset.CZDSZoneFile(dir, tld); fileExists(path) {
+			for _, name := range zonePresence(path, list) {
+				c.outputRegistration(name)
+			}
+			continue
+		}
+
+		for _, name := range list {
+			select {
+			case <-ctx.Done():
+				break loop
+			case names <- name:
+			}
+		}
+	}
+
+	close(names)
+	wg.Wait()
+	return nil
+}
+
+func (c *Collection) outputRegistration(name string) {
+	if !c.filter.TestAndAdd([]byte(name)) {
+		c.Output <- &requests.Output{
+			Name:   name,
+			Domain: name,
+		}
+	}
+}
+
+// registered returns true when the registry has delegated the name.
+func (c *Collection) registered(ctx context.Context, name string) bool {
+	msg := resolve.QueryMsg(name, dns.TypeNS)
+
+	resp, err := c.Sys.TrustedResolvers().QueryBlocking(ctx, msg)
+	if err != nil || resp.Rcode != dns.RcodeSuccess {
+		return false
+	}
+
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == dns.TypeNS {
+			return true
+		}
+	}
+	return false
+}
+
+// tldList returns the TLDs published by IANA, using the copy in the output directory when it is recent.
+func (c *Collection) tldList(ctx context.Context) ([]string, error) {
+	path := filepath.Join(config.OutputDirectory(c.Config.Dir), tldListFileName)
+
+	if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) < tldListMaxAge {
+		if data, err := os.ReadFile(path); err == nil {
+			if tlds := parseTLDList(string(data)); len(tlds) > 0 {
+				return tlds, nil
+			}
+		}
+	}
+
+	resp, err := http.RequestWebPage(ctx, &http.Request{URL: tldListURL})
+	if err != nil {
+		return nil, err
+	}
+
+	tlds := parseTLDList(resp.Body)
+	if len(tlds) == 0 {
+		return nil, errors.New("failed to obtain the list of TLDs")
+	}
+
+	_ = os.WriteFile(path, []byte(resp.Body), 0640)
+	return tlds, nil
+}
+
+func parseTLDList(data string) []string {
+	var tlds []string
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			tlds = append(tlds, strings.ToLower(line))
+		}
+	}
+	return tlds
+}
+
+// brandLabel returns the label registered below the public suffix of the domain (e.g. example for example.co.uk).
+func brandLabel(domain string) string {
+	etld1, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return ""
+	}
+	return strings.Split(etld1, ".")[0]
+}
+
+// zonePresence returns the names that are delegated by the zone file.
+func zonePresence(path string, names []string) []string {
+	want := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		want[name] = struct{}{}
+	}
+
+	var found []string
+	_ = dataset.ParseFile(path, dataset.FormatZone, func(rec *dataset.Record) error {
+		if rec.Type != "NS" {
+			return nil
+		}
+		if _, ok := want[rec.Name]; ok {
+			delete(want, rec.Name)
+			found = append(found, rec.Name)
+		}
+		return nil
+	})
+	return found
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
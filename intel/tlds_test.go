@@ -0,0 +1,39 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package intel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBrandLabel(t *testing.T) {
+	for domain, expected := range map[string]string{
+		"owasp.org":       "owasp",
+		"example.co.uk":   "example",
+		"www.example.com": "example",
+	} {
+		if got := brandLabel(domain); got != expected {
+			t.Errorf("Expected the label %s for %s, got %s", expected, domain, got)
+		}
+	}
+}
+
+func TestZonePresence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example.zone")
+	zone := "owasp.example.\t86400\tin\tns\tns1.owasp.example.\nother.example.\t86400\tin\tns\tns1.other.example.\n"
+	if err := os.WriteFile(path, []byte(zone), 0600); err != nil {
+		t.Fatalf("Failed to write the zone file: %v", err)
+	}
+
+	found := zonePresence(path, []string{"owasp.example", "missing.example"})
+	if len(found) != 1 || found[0] != "owasp.example" {
+		t.Errorf("Unexpected names found in the zone file: %v", found)
+	}
+	if tlds := parseTLDList("# Version 2023\nCOM\nORG\n"); len(tlds) != 2 || tlds[0] != "com" {
+		t.Errorf("Unexpected TLD list: %v", tlds)
+	}
+}
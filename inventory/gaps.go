@@ -0,0 +1,149 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+// Types of the gaps between the intended inventory and the discovered assets.
+const (
+	// TypeShadow is the type of the gaps for the discovered names missing from the inventory
+	TypeShadow = "shadow_asset"
+	// TypeStale is the type of the gaps for the entries of the inventory never observed externally
+	TypeStale = "stale_inventory_entry"
+)
+
+// Discovered is an in-scope name discovered by the enumerations, with the aliases followed to its addresses.
+type Discovered struct {
+	Name      string
+	Aliases   []string
+	Addresses []string
+}
+
+// Gaps returns the findings for the gaps between the inventory and the discovered names. The discovered
+// names missing from the inventory are shadow assets: the high severity is assigned when none of their
+// aliases and addresses are in the inventory either, since they point to infrastructure unknown to the
+// organization, and the low severity otherwise. The entries of the inventory never observed externally are
+// possibly stale: the medium severity is assigned to the names accepted by the inScope function, since
+// their records may be left dangling, and the low severity to the addresses. The severity policy adjusts
+// the severities when provided.
+func Gaps(inv *Inventory, discovered []*Discovered, inScope func(name string) bool, policy *findings.Policy) []*findings.Finding {
+	intended := make(map[string][]*Entry)
+	for _, e := range inv.Entries {
+		intended[e.Key] = append(intended[e.Key], e)
+	}
+
+	var fs []*findings.Finding
+	observed := make(map[string]struct{})
+	for _, d := range discovered {
+		name := normalize(d.Name)
+		observed[name] = struct{}{}
+
+		var linked []string
+		for _, v := range append(append([]string{}, d.Aliases...), d.Addresses...) {
+			v = normalize(v)
+			observed[v] = struct{}{}
+			if _, found := intended[v]; found {
+				linked = append(linked, v)
+			}
+		}
+		if _, found := intended[name]; found {
+			continue
+		}
+
+		f := &findings.Finding{
+			Asset:    name,
+			Type:     TypeShadow,
+			Severity: findings.SeverityHigh,
+			Title:    "Discovered but missing from the intended inventory",
+			Attributes: map[string]string{
+				"addresses": strings.Join(d.Addresses, ","),
+			},
+		}
+		if len(d.Aliases) > 0 {
+			f.Attributes["aliases"] = strings.Join(d.Aliases, ",")
+		}
+		if len(linked) > 0 {
+			f.Severity = findings.SeverityLow
+			f.Title = "Discovered but missing from the intended inventory, which holds its aliases or addresses"
+			f.Attributes["inventory"] = strings.Join(linked, ",")
+		}
+		fs = append(fs, f)
+	}
+
+	var keys []string
+	for key := range intended {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, found := observed[key]; found {
+			continue
+		}
+
+		entries := intended[key]
+		f := &findings.Finding{
+			Asset:    key,
+			Type:     TypeStale,
+			Severity: findings.SeverityLow,
+			Title:    "Address in the intended inventory never resolved by the discovered names",
+			Attributes: map[string]string{
+				"origins": origins(entries, func(e *Entry) string { return e.Origin }),
+				"sources": origins(entries, func(e *Entry) string { return e.Source }),
+			},
+		}
+		if net.ParseIP(key) == nil {
+			if !inScope(key) {
+				continue
+			}
+			f.Severity = findings.SeverityMedium
+			f.Title = "Name in the intended inventory never observed externally"
+		}
+		fs = append(fs, f)
+	}
+
+	for _, f := range fs {
+		policy.Apply(f)
+	}
+	sort.SliceStable(fs, func(i, j int) bool {
+		if fs[i].Type != fs[j].Type {
+			return fs[i].Type < fs[j].Type
+		}
+		return fs[i].Asset < fs[j].Asset
+	})
+	findings.SortBySeverity(fs)
+	return fs
+}
+
+// origins returns the unique values of the entries, sorted and separated by commas.
+func origins(entries []*Entry, value func(*Entry) string) string {
+	seen := make(map[string]struct{})
+
+	var results []string
+	for _, e := range entries {
+		if v := value(e); v != "" {
+			if _, found := seen[v]; !found {
+				seen[v] = struct{}{}
+				results = append(results, v)
+			}
+		}
+	}
+	sort.Strings(results)
+	return strings.Join(results, ",")
+}
+
+func normalize(v string) string {
+	v = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(v), "."))
+	if ip := net.ParseIP(v); ip != nil {
+		return ip.String()
+	}
+	return v
+}
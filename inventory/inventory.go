@@ -0,0 +1,247 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package inventory maintains the intended inventory of the user, which lists the names and addresses the
+// organization knows it operates. The inventory is imported from the Terraform state files and the DNS zone
+// files managing the infrastructure, so it can be compared against what the enumerations actually discover.
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/format"
+)
+
+// FileName is the name of the file in the output directory that stores the intended inventory.
+const FileName = "intended_inventory.json"
+
+// Supported inventory formats.
+const (
+	FormatTerraform = "terraform"
+	FormatZone      = "zone"
+	// Formats of the records exported by the DNS providers
+	FormatRoute53  = "route53"
+	FormatCloudDNS = "clouddns"
+)
+
+// Formats returns the names of the supported inventory formats.
+func Formats() []string {
+	return []string{FormatTerraform, FormatZone, FormatRoute53, FormatCloudDNS}
+}
+
+// Entry is a name or address of the intended inventory.
+type Entry struct {
+	collections.Member
+	// Source is the file the entry was imported from
+	Source string `json:"source"`
+	// Origin is the Terraform resource or the DNS record providing the entry
+	Origin string `json:"origin"`
+}
+
+// Inventory is the set of entries imported from the files.
+type Inventory struct {
+	Updated time.Time `json:"updated"`
+	Entries []*Entry  `json:"entries"`
+}
+
+// Read returns the inventory stored in the file, or an empty Inventory when the file does not exist.
+func Read(path string) (*Inventory, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return new(Inventory), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	inv := new(Inventory)
+	if err := json.Unmarshal(data, inv); err != nil {
+		return nil, fmt.Errorf("failed to parse the intended inventory %s: %v", path, err)
+	}
+	return inv, nil
+}
+
+// Write stores the inventory in the file.
+func (inv *Inventory) Write(path string) error {
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Import replaces the entries of the source with the entries provided, so the files can be imported again
+// after the infrastructure changes. It returns the number of unique entries imported.
+func (inv *Inventory) Import(source string, entries []*Entry) int {
+	inv.Remove(source)
+
+	seen := make(map[string]struct{})
+	for _, e := range entries {
+		if _, found := seen[e.ID()]; found {
+			continue
+		}
+
+		seen[e.ID()] = struct{}{}
+		e.Source = source
+		inv.Entries = append(inv.Entries, e)
+	}
+
+	sort.SliceStable(inv.Entries, func(i, j int) bool {
+		if inv.Entries[i].Source != inv.Entries[j].Source {
+			return inv.Entries[i].Source < inv.Entries[j].Source
+		}
+		return inv.Entries[i].ID() < inv.Entries[j].ID()
+	})
+	inv.Updated = time.Now().UTC()
+	return len(seen)
+}
+
+// Remove removes the entries imported from the source, and returns the number of entries removed.
+func (inv *Inventory) Remove(source string) int {
+	kept := inv.Entries[:0]
+	for _, e := range inv.Entries {
+		if e.Source != source {
+			kept = append(kept, e)
+		}
+	}
+
+	count := len(inv.Entries) - len(kept)
+	inv.Entries = kept
+	if count > 0 {
+		inv.Updated = time.Now().UTC()
+	}
+	return count
+}
+
+// Sources returns the number of entries imported from each source.
+func (inv *Inventory) Sources() map[string]int {
+	sources := make(map[string]int)
+	for _, e := range inv.Entries {
+		sources[e.Source]++
+	}
+	return sources
+}
+
+// ParseFile returns the entries of the file in the provided format, or the format detected from the
+// contents when none is provided. The origin completes the relative names of the zone files.
+func ParseFile(path, format, origin string) ([]*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the inventory file %s: %v", path, err)
+	}
+	if format == "" {
+		format = Detect(data)
+	}
+
+	entries, err := Parse(data, format, origin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the inventory file %s: %v", path, err)
+	}
+	return entries, nil
+}
+
+// Detect returns the format of the inventory data. The data that is not JSON is expected to be a zone file.
+func Detect(data []byte) string {
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("[")) {
+		return FormatCloudDNS
+	}
+	if bytes.HasPrefix(data, []byte("{")) {
+		var probe struct {
+			RRSets json.RawMessage `json:"ResourceRecordSets"`
+		}
+		if err := json.Unmarshal(data, &probe); err == nil && probe.RRSets != nil {
+			return FormatRoute53
+		}
+		return FormatTerraform
+	}
+	return FormatZone
+}
+
+// Parse returns the entries of the data in the provided format.
+func Parse(data []byte, format, origin string) ([]*Entry, error) {
+	switch format {
+	case FormatTerraform:
+		return parseTerraform(data)
+	case FormatZone:
+		return parseZone(data, origin)
+	case FormatRoute53:
+		return parseRoute53(data)
+	case FormatCloudDNS:
+		return parseCloudDNS(data)
+	}
+	return nil, fmt.Errorf("the inventory format %s is not supported", format)
+}
+
+// nameEntry returns the entry of the host name, or nil when the value is not the name of a host,
+// such as the wildcard names and the names of the service records.
+func nameEntry(value, origin string) *Entry {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "*") || strings.HasPrefix(value, `\052`) {
+		return nil
+	}
+
+	name, err := format.CleanDomain(value)
+	if err != nil {
+		return nil
+	}
+	m, err := collections.ParseMember("fqdn:" + name)
+	if err != nil {
+		return nil
+	}
+	return &Entry{Member: m, Origin: origin}
+}
+
+// addrEntry returns the entry of the address, or nil when the value is not a public IP address.
+// The private addresses are never observed externally, so they are not part of the inventory.
+func addrEntry(value, origin string) *Entry {
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+		return nil
+	}
+
+	m, err := collections.ParseMember("ip:" + ip.String())
+	if err != nil {
+		return nil
+	}
+	return &Entry{Member: m, Origin: origin}
+}
+
+// recordEntries returns the entries of the DNS record set. The names of the A, AAAA and CNAME records
+// are hosts of the organization, and the addresses of the A and AAAA records are part of the inventory.
+func recordEntries(name, rtype string, data []string) []*Entry {
+	rtype = strings.ToUpper(rtype)
+	if rtype != "A" && rtype != "AAAA" && rtype != "CNAME" {
+		return nil
+	}
+
+	origin := strings.TrimSuffix(strings.ToLower(name), ".") + " " + rtype
+	e := nameEntry(name, origin)
+	if e == nil {
+		return nil
+	}
+
+	entries := []*Entry{e}
+	if rtype != "CNAME" {
+		for _, d := range data {
+			if a := addrEntry(d, origin); a != nil {
+				entries = append(entries, a)
+			}
+		}
+	}
+	return entries
+}
@@ -0,0 +1,193 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func ids(entries []*Entry) string {
+	var results []string
+	for _, e := range entries {
+		results = append(results, e.ID())
+	}
+	return strings.Join(results, " ")
+}
+
+func TestParseTerraform(t *testing.T) {
+	state := `{
+  "version": 4,
+  "terraform_version": "1.5.7",
+  "resources": [
+    {"mode": "managed", "type": "aws_route53_record", "name": "www", "instances": [
+      {"attributes": {"fqdn": "www.example.com", "name": "www.example.com", "type": "A", "records": ["192.0.2.10"]}}]},
+    {"mode": "managed", "type": "aws_route53_record", "name": "alias", "module": "module.dns", "instances": [
+      {"index_key": "api", "attributes": {"fqdn": "api.example.com", "type": "CNAME", "records": ["lb.example.net"]}}]},
+    {"mode": "managed", "type": "google_compute_instance", "name": "vm", "instances": [
+      {"index_key": 0, "attributes": {"network_interface": [{"network_ip": "10.0.0.2", "access_config": [{"nat_ip": "198.51.100.7"}]}]}}]},
+    {"mode": "managed", "type": "aws_instance", "name": "internal", "instances": [
+      {"attributes": {"public_ip": "", "private_ip": "10.0.0.3"}}]},
+    {"mode": "data", "type": "aws_eip", "name": "lookup", "instances": [
+      {"attributes": {"public_ip": "203.0.113.1"}}]}
+  ]
+}`
+	if f := Detect([]byte(state)); f != FormatTerraform {
+		t.Fatalf("Expected the terraform format, got %s", f)
+	}
+
+	entries, err := Parse([]byte(state), FormatTerraform, "")
+	if err != nil {
+		t.Fatalf("Failed to parse the state: %v", err)
+	}
+	if got, want := ids(entries), "FQDN:www.example.com FQDN:www.example.com IPAddress:192.0.2.10 FQDN:api.example.com IPAddress:198.51.100.7"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if entries[3].Origin != `module.dns.aws_route53_record.alias["api"]` || entries[4].Origin != "google_compute_instance.vm[0]" {
+		t.Errorf("Unexpected origins: %s, %s", entries[3].Origin, entries[4].Origin)
+	}
+
+	if _, err := Parse([]byte(`{"version": 3, "modules": []}`), FormatTerraform, ""); err == nil {
+		t.Error("Expected an error for the version 3 state")
+	}
+}
+
+func TestParseZone(t *testing.T) {
+	zone := `$TTL 3600
+@	IN	SOA	ns1.example.com. admin.example.com. 1 7200 3600 1209600 3600
+@	IN	NS	ns1.example.com.
+www	IN	A	192.0.2.10
+www	IN	A	192.0.2.11
+mail	IN	CNAME	www
+*	IN	A	192.0.2.12
+_dmarc	IN	TXT	"v=DMARC1; p=none"
+`
+	if f := Detect([]byte(zone)); f != FormatZone {
+		t.Fatalf("Expected the zone format, got %s", f)
+	}
+
+	entries, err := Parse([]byte(zone), FormatZone, "example.com")
+	if err != nil {
+		t.Fatalf("Failed to parse the zone: %v", err)
+	}
+	if got, want := ids(entries), "FQDN:www.example.com IPAddress:192.0.2.10 IPAddress:192.0.2.11 FQDN:mail.example.com"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestParseExports(t *testing.T) {
+	route53 := `{"ResourceRecordSets": [
+  {"Name": "www.example.com.", "Type": "A", "ResourceRecords": [{"Value": "192.0.2.10"}]},
+  {"Name": "cdn.example.com.", "Type": "A", "AliasTarget": {"DNSName": "d111111abcdef8.cloudfront.net."}},
+  {"Name": "\\052.example.com.", "Type": "A", "ResourceRecords": [{"Value": "192.0.2.12"}]},
+  {"Name": "example.com.", "Type": "MX", "ResourceRecords": [{"Value": "10 mail.example.com"}]}
+]}`
+	clouddns := `[
+  {"kind": "dns#resourceRecordSet", "name": "api.example.com.", "type": "AAAA", "rrdatas": ["2001:db8::1", "fe80::1"]},
+  {"kind": "dns#resourceRecordSet", "name": "example.com.", "type": "NS", "rrdatas": ["ns-cloud-a1.googledomains.com."]}
+]`
+
+	for _, test := range []struct {
+		data   string
+		format string
+		want   string
+	}{
+		{route53, FormatRoute53, "FQDN:www.example.com IPAddress:192.0.2.10 FQDN:cdn.example.com"},
+		{clouddns, FormatCloudDNS, "FQDN:api.example.com IPAddress:2001:db8::1"},
+	} {
+		if f := Detect([]byte(test.data)); f != test.format {
+			t.Errorf("Expected the %s format, got %s", test.format, f)
+			continue
+		}
+
+		entries, err := Parse([]byte(test.data), test.format, "")
+		if err != nil {
+			t.Errorf("Failed to parse the %s export: %v", test.format, err)
+		} else if got := ids(entries); got != test.want {
+			t.Errorf("Expected %s, got %s", test.want, got)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	inv, err := Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read the missing inventory: %v", err)
+	}
+
+	a, _ := Parse([]byte(`[{"name": "www.example.com.", "type": "A", "rrdatas": ["192.0.2.10"]}]`), FormatCloudDNS, "")
+	b, _ := Parse([]byte(`[{"name": "api.example.com.", "type": "CNAME", "rrdatas": ["www.example.com."]}]`), FormatCloudDNS, "")
+	if n := inv.Import("a.json", a); n != 2 {
+		t.Errorf("Expected 2 entries imported, got %d", n)
+	}
+	inv.Import("b.json", b)
+	// Importing the file again replaces its previous entries
+	inv.Import("a.json", a[:1])
+
+	if err := inv.Write(path); err != nil {
+		t.Fatalf("Failed to write the inventory: %v", err)
+	}
+	inv, err = Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read the inventory: %v", err)
+	}
+
+	sources := inv.Sources()
+	if len(sources) != 2 || sources["a.json"] != 1 || sources["b.json"] != 1 {
+		t.Errorf("Unexpected sources: %v", sources)
+	}
+	if n := inv.Remove("b.json"); n != 1 || len(inv.Entries) != 1 {
+		t.Errorf("Expected the entries of b.json to be removed: %d", n)
+	}
+}
+
+func TestGaps(t *testing.T) {
+	inv := new(Inventory)
+	entries, _ := Parse([]byte(`[
+  {"name": "www.example.com.", "type": "A", "rrdatas": ["192.0.2.10"]},
+  {"name": "old.example.com.", "type": "A", "rrdatas": ["192.0.2.20"]},
+  {"name": "lb.example.net.", "type": "A", "rrdatas": ["192.0.2.30"]}
+]`), FormatCloudDNS, "")
+	inv.Import("zone.json", entries)
+
+	discovered := []*Discovered{
+		{Name: "www.example.com", Addresses: []string{"192.0.2.10"}},
+		{Name: "api.example.com", Addresses: []string{"192.0.2.10"}},
+		{Name: "dev.example.com", Aliases: []string{"dev.herokuapp.com"}, Addresses: []string{"198.51.100.1"}},
+	}
+	inScope := func(name string) bool { return strings.HasSuffix(name, ".example.com") }
+
+	var got []string
+	for _, f := range Gaps(inv, discovered, inScope, nil) {
+		got = append(got, f.Severity+" "+f.Type+" "+f.Asset)
+	}
+	want := []string{
+		"high shadow_asset dev.example.com",
+		"medium stale_inventory_entry old.example.com",
+		"low shadow_asset api.example.com",
+		"low stale_inventory_entry 192.0.2.20",
+		"low stale_inventory_entry 192.0.2.30",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected the gaps %v, got %v", want, got)
+	}
+
+	policy, err := findings.ParsePolicy([]interface{}{
+		map[string]interface{}{"type": TypeStale, "severity": "info"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to parse the policy: %v", err)
+	}
+	for _, f := range Gaps(inv, discovered, inScope, policy) {
+		if f.Type == TypeStale && f.Severity != findings.SeverityInfo {
+			t.Errorf("Expected the policy to assign the info severity to %s", f.Asset)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// tfResource names the attributes of a resource type providing the names and the addresses. The nested
+// attributes are separated by dots, such as network_interface.access_config.nat_ip.
+type tfResource struct {
+	Names     []string
+	Addresses []string
+}
+
+// tfResources are the resource types of the providers read from the state files.
+var tfResources = map[string]tfResource{
+	"aws_route53_record":                 {Names: []string{"fqdn", "name"}, Addresses: []string{"records"}},
+	"aws_eip":                            {Addresses: []string{"public_ip"}},
+	"aws_instance":                       {Addresses: []string{"public_ip", "ipv6_addresses"}},
+	"aws_cloudfront_distribution":        {Names: []string{"aliases"}},
+	"aws_api_gateway_domain_name":        {Names: []string{"domain_name"}},
+	"aws_apigatewayv2_domain_name":       {Names: []string{"domain_name"}},
+	"aws_acm_certificate":                {Names: []string{"domain_name", "subject_alternative_names"}},
+	"azurerm_public_ip":                  {Names: []string{"fqdn"}, Addresses: []string{"ip_address"}},
+	"azurerm_dns_a_record":               {Names: []string{"fqdn"}, Addresses: []string{"records"}},
+	"azurerm_dns_aaaa_record":            {Names: []string{"fqdn"}, Addresses: []string{"records"}},
+	"azurerm_dns_cname_record":           {Names: []string{"fqdn"}},
+	"azurerm_cdn_endpoint_custom_domain": {Names: []string{"host_name"}},
+	"google_dns_record_set":              {Names: []string{"name"}, Addresses: []string{"rrdatas"}},
+	"google_compute_address":             {Addresses: []string{"address"}},
+	"google_compute_global_address":      {Addresses: []string{"address"}},
+	"google_compute_instance":            {Addresses: []string{"network_interface.access_config.nat_ip"}},
+	"google_compute_forwarding_rule":     {Addresses: []string{"ip_address"}},
+	"cloudflare_record":                  {Names: []string{"hostname"}, Addresses: []string{"value", "content"}},
+	"digitalocean_droplet":               {Addresses: []string{"ipv4_address", "ipv6_address"}},
+	"digitalocean_record":                {Names: []string{"fqdn"}, Addresses: []string{"value"}},
+}
+
+// tfState is the version 4 format of the Terraform state files.
+type tfState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Mode      string `json:"mode"`
+		Module    string `json:"module"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   interface{}            `json:"index_key"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// parseTerraform returns the entries of the managed resources in a Terraform state file, such as
+// the output of 'terraform state pull'.
+func parseTerraform(data []byte) ([]*Entry, error) {
+	var state tfState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf("version %d of the Terraform state format is not supported", state.Version)
+	}
+
+	var entries []*Entry
+	for _, res := range state.Resources {
+		attrs, found := tfResources[res.Type]
+		if !found || res.Mode != "managed" {
+			continue
+		}
+
+		for _, inst := range res.Instances {
+			addr := tfAddress(res.Module, res.Type, res.Name, inst.IndexKey)
+
+			for _, key := range attrs.Names {
+				for _, v := range tfValues(inst.Attributes, key) {
+					if e := nameEntry(v, addr); e != nil {
+						entries = append(entries, e)
+					}
+				}
+			}
+			for _, key := range attrs.Addresses {
+				for _, v := range tfValues(inst.Attributes, key) {
+					if e := addrEntry(v, addr); e != nil {
+						entries = append(entries, e)
+					}
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+// tfAddress returns the address of the resource instance, such as module.dns.aws_route53_record.www["api"].
+func tfAddress(module, rtype, name string, index interface{}) string {
+	addr := rtype + "." + name
+	if module != "" {
+		addr = module + "." + addr
+	}
+
+	switch key := index.(type) {
+	case float64:
+		addr += fmt.Sprintf("[%d]", int(key))
+	case string:
+		addr += fmt.Sprintf("[%q]", key)
+	}
+	return addr
+}
+
+// tfValues returns the strings of the attribute at the path, flattening the lists along the way.
+func tfValues(v interface{}, path string) []string {
+	if path == "" {
+		switch val := v.(type) {
+		case string:
+			return []string{val}
+		case []interface{}:
+			var results []string
+			for _, item := range val {
+				results = append(results, tfValues(item, "")...)
+			}
+			return results
+		}
+		return nil
+	}
+
+	key, rest, _ := strings.Cut(path, ".")
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return tfValues(val[key], rest)
+	case []interface{}:
+		var results []string
+		for _, item := range val {
+			results = append(results, tfValues(item, path)...)
+		}
+		return results
+	}
+	return nil
+}
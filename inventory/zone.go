@@ -0,0 +1,90 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/dataset"
+)
+
+// parseZone returns the entries of a zone file in the BIND format, such as exported by Route 53 and Cloud DNS.
+// The origin is required when the names are relative and the file does not provide the $ORIGIN directive.
+func parseZone(data []byte, origin string) ([]*Entry, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if origin = strings.Trim(strings.TrimSpace(origin), "."); origin != "" {
+		r = io.MultiReader(strings.NewReader("$ORIGIN "+origin+".\n"), r)
+	}
+
+	sets := make(map[string][]string)
+	var keys []string
+	if err := dataset.Parse(r, dataset.FormatZone, func(rec *dataset.Record) error {
+		key := rec.Name + " " + rec.Type
+		if _, found := sets[key]; !found {
+			keys = append(keys, key)
+		}
+		sets[key] = append(sets[key], rec.Data)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, key := range keys {
+		name, rtype, _ := strings.Cut(key, " ")
+		entries = append(entries, recordEntries(name, rtype, sets[key])...)
+	}
+	return entries, nil
+}
+
+// parseRoute53 returns the entries of the record sets listed by the Route 53 API, such as the
+// output of 'aws route53 list-resource-record-sets'.
+func parseRoute53(data []byte) ([]*Entry, error) {
+	var resp struct {
+		Sets []struct {
+			Name    string `json:"Name"`
+			Type    string `json:"Type"`
+			Records []struct {
+				Value string `json:"Value"`
+			} `json:"ResourceRecords"`
+		} `json:"ResourceRecordSets"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, set := range resp.Sets {
+		var values []string
+		for _, rec := range set.Records {
+			values = append(values, rec.Value)
+		}
+		// The alias records provide the name alone
+		entries = append(entries, recordEntries(set.Name, set.Type, values)...)
+	}
+	return entries, nil
+}
+
+// parseCloudDNS returns the entries of the record sets listed by the Cloud DNS API, such as the
+// output of 'gcloud dns record-sets list --format=json'.
+func parseCloudDNS(data []byte) ([]*Entry, error) {
+	var sets []struct {
+		Name    string   `json:"name"`
+		Type    string   `json:"type"`
+		Rrdatas []string `json:"rrdatas"`
+	}
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, set := range sets {
+		entries = append(entries, recordEntries(set.Name, set.Type, set.Rrdatas)...)
+	}
+	return entries, nil
+}
@@ -0,0 +1,164 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package leaks watches the paste and leak aggregation services for mentions of the root domains
+// and the email addresses within them, which can reveal exposed credentials and internal data.
+package leaks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+// TypeLeakMention is the type of the findings recorded for the mentions.
+const TypeLeakMention = "leak_mention"
+
+var emailRE = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)
+
+// Mention is a reference to a root domain or an email address found by a leak source.
+type Mention struct {
+	Source string
+	Domain string
+	// Email is the address within the domain that was mentioned, if any
+	Email string
+	// ID identifies the document, such as a paste, on the source
+	ID    string
+	URL   string
+	Title string
+	// Time is when the document was published, if the source provides it
+	Time time.Time
+}
+
+// Source is a service that can be searched for mentions of a domain.
+type Source interface {
+	// Name returns the name of the source used in the configuration.
+	Name() string
+	// Search returns the mentions of the domain and the email addresses within it.
+	Search(ctx context.Context, domain string) ([]*Mention, error)
+}
+
+// Sources are the constructors of the leak sources that can be selected by name.
+var Sources = map[string]func() Source{
+	"psbdmp": func() Source { return NewPSBDMP() },
+}
+
+// SourcesByName returns the leak sources with the provided names.
+func SourcesByName(names []string) ([]Source, error) {
+	var srcs []Source
+
+	for _, name := range names {
+		fn, found := Sources[strings.ToLower(strings.TrimSpace(name))]
+		if !found {
+			return nil, fmt.Errorf("%s is not a supported leak source", name)
+		}
+		srcs = append(srcs, fn())
+	}
+	return srcs, nil
+}
+
+// Emails returns the email addresses within the domain or its subdomains that appear in the text.
+func Emails(text, domain string) []string {
+	domain = strings.ToLower(domain)
+
+	seen := make(map[string]struct{})
+	var emails []string
+	for _, e := range emailRE.FindAllString(text, -1) {
+		e = strings.ToLower(e)
+		if _, found := seen[e]; found {
+			continue
+		}
+
+		host := e[strings.LastIndex(e, "@")+1:]
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			seen[e] = struct{}{}
+			emails = append(emails, e)
+		}
+	}
+	sort.Strings(emails)
+	return emails
+}
+
+// Finding returns the finding recorded for the mention. Mentions of email addresses are assigned
+// a higher severity, since the documents commonly provide the credentials of the accounts.
+func (m *Mention) Finding() *findings.Finding {
+	f := &findings.Finding{
+		Asset:    m.Domain,
+		Type:     TypeLeakMention,
+		Severity: findings.SeverityMedium,
+		Title:    fmt.Sprintf("%s was mentioned by %s", m.Domain, m.Source),
+		Details:  m.Title,
+		Attributes: map[string]string{
+			"source": m.Source,
+			"id":     m.ID,
+			"url":    m.URL,
+		},
+	}
+
+	if !m.Time.IsZero() {
+		f.Attributes["published"] = m.Time.UTC().Format(time.RFC3339)
+	}
+	if m.Email != "" {
+		f.Asset = m.Email
+		f.Severity = findings.SeverityHigh
+		f.Title = fmt.Sprintf("%s was mentioned by %s", m.Email, m.Source)
+		f.Attributes["domain"] = m.Domain
+	}
+	return f
+}
+
+// key identifies the finding, so each mention is only reported once.
+func key(f *findings.Finding) string {
+	return f.Attributes["source"] + " " + f.Attributes["id"] + " " + f.Asset
+}
+
+// NewFindings returns the findings for the mentions that are not among the findings already recorded.
+func NewFindings(recorded []*findings.Finding, mentions []*Mention) []*findings.Finding {
+	seen := make(map[string]struct{})
+	for _, f := range recorded {
+		if f.Type == TypeLeakMention {
+			seen[key(f)] = struct{}{}
+		}
+	}
+
+	var results []*findings.Finding
+	for _, m := range mentions {
+		f := m.Finding()
+
+		k := key(f)
+		if _, found := seen[k]; found {
+			continue
+		}
+		seen[k] = struct{}{}
+		results = append(results, f)
+	}
+	return results
+}
+
+// Search returns the mentions of the domains found by all the sources, and the errors that occurred.
+func Search(ctx context.Context, srcs []Source, domains []string) ([]*Mention, error) {
+	var mentions []*Mention
+	var msgs []string
+
+	for _, src := range srcs {
+		for _, d := range domains {
+			m, err := src.Search(ctx, d)
+			if err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s: %s: %v", src.Name(), d, err))
+				continue
+			}
+			mentions = append(mentions, m...)
+		}
+	}
+	if len(msgs) > 0 {
+		return mentions, errors.New(strings.Join(msgs, "; "))
+	}
+	return mentions, nil
+}
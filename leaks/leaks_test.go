@@ -0,0 +1,93 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package leaks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestEmails(t *testing.T) {
+	text := "admin@owasp.org:hunter2 Dev@Mail.OWASP.org admin@owasp.org user@notowasp.org x@example.com"
+
+	got := Emails(text, "owasp.org")
+	if want := []string{"admin@owasp.org", "dev@mail.owasp.org"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestSourcesByName(t *testing.T) {
+	if srcs, err := SourcesByName([]string{"PSBDMP"}); err != nil || len(srcs) != 1 || srcs[0].Name() != "psbdmp" {
+		t.Errorf("Failed to select the psbdmp source: %v", err)
+	}
+	if _, err := SourcesByName([]string{"pastebin"}); err == nil {
+		t.Error("Expected an error for an unsupported source")
+	}
+}
+
+func TestPSBDMP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/owasp.org" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `[
+			{"id": "abc123", "tags": "combolist", "time": "2023-06-01 12:00:00", "text": "admin@owasp.org:hunter2 root@owasp.org:toor"},
+			{"id": "def456", "tags": "config", "time": "2023-06-02 12:00:00", "text": "https://vpn.owasp.org"}
+		]`)
+	}))
+	defer ts.Close()
+
+	p := NewPSBDMP()
+	p.BaseURL = ts.URL + "/search/"
+	p.HTTP = ts.Client()
+
+	mentions, err := Search(context.Background(), []Source{p}, []string{"owasp.org"})
+	if err != nil {
+		t.Fatalf("The search failed: %v", err)
+	}
+	if len(mentions) != 3 {
+		t.Fatalf("Expected three mentions, got %d", len(mentions))
+	}
+	if m := mentions[0]; m.Email != "admin@owasp.org" || m.URL != "https://pastebin.com/abc123" || m.Time.IsZero() {
+		t.Errorf("Unexpected mention: %+v", m)
+	}
+	if m := mentions[2]; m.Email != "" || m.ID != "def456" {
+		t.Errorf("Unexpected domain mention: %+v", m)
+	}
+
+	if none, err := p.Search(context.Background(), "example.com"); err != nil || len(none) != 0 {
+		t.Errorf("Expected no mentions for example.com: %v", err)
+	}
+}
+
+func TestNewFindings(t *testing.T) {
+	mentions := []*Mention{
+		{Source: "psbdmp", Domain: "owasp.org", Email: "admin@owasp.org", ID: "abc123"},
+		{Source: "psbdmp", Domain: "owasp.org", Email: "admin@owasp.org", ID: "abc123"},
+		{Source: "psbdmp", Domain: "owasp.org", ID: "def456"},
+	}
+
+	fs := NewFindings(nil, mentions)
+	if len(fs) != 2 {
+		t.Fatalf("Expected two findings, got %d", len(fs))
+	}
+	if f := fs[0]; f.Asset != "admin@owasp.org" || f.Severity != findings.SeverityHigh || f.Attributes["domain"] != "owasp.org" {
+		t.Errorf("Unexpected email finding: %+v", f)
+	}
+	if f := fs[1]; f.Asset != "owasp.org" || f.Severity != findings.SeverityMedium {
+		t.Errorf("Unexpected domain finding: %+v", f)
+	}
+
+	if again := NewFindings(fs, mentions); len(again) != 0 {
+		t.Errorf("Expected the recorded mentions to be skipped, got %d findings", len(again))
+	}
+}
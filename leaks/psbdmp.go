@@ -0,0 +1,117 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package leaks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	maxBodySize    = 16 * 1024 * 1024
+)
+
+// PSBDMP searches the pastes collected from Pastebin by the psbdmp.ws service.
+type PSBDMP struct {
+	// BaseURL is the address of the search API, with the query appended to it
+	BaseURL string
+	HTTP    *http.Client
+}
+
+type psbdmpPaste struct {
+	ID   string `json:"id"`
+	Tags string `json:"tags"`
+	Time string `json:"time"`
+	Text string `json:"text"`
+}
+
+// NewPSBDMP returns the psbdmp.ws leak source.
+func NewPSBDMP() *PSBDMP {
+	return &PSBDMP{
+		BaseURL: "https://psbdmp.ws/api/v3/search/",
+		HTTP: amasshttp.NewClient("psbdmp", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Cache:   true,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// Name implements the Source interface.
+func (p *PSBDMP) Name() string {
+	return "psbdmp"
+}
+
+// Search implements the Source interface.
+func (p *PSBDMP) Search(ctx context.Context, domain string) ([]*Mention, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.BaseURL+url.PathEscape(domain), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the search returned status %d", resp.StatusCode)
+	}
+
+	var pastes []psbdmpPaste
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&pastes); err != nil {
+		return nil, fmt.Errorf("failed to decode the search results: %v", err)
+	}
+
+	var mentions []*Mention
+	for _, paste := range pastes {
+		if paste.ID == "" {
+			continue
+		}
+
+		m := Mention{
+			Source: p.Name(),
+			Domain: domain,
+			ID:     paste.ID,
+			URL:    "https://pastebin.com/" + paste.ID,
+			Title:  paste.Tags,
+			Time:   pasteTime(paste.Time),
+		}
+
+		emails := Emails(paste.Text, domain)
+		if len(emails) == 0 {
+			mentions = append(mentions, &m)
+			continue
+		}
+		for _, e := range emails {
+			em := m
+			em.Email = e
+			mentions = append(mentions, &em)
+		}
+	}
+	return mentions, nil
+}
+
+func pasteTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
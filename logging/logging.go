@@ -0,0 +1,242 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package logging provides the outputs receiving the log messages of long-running deployments,
+// such as syslog and files rotated by size and age, in text or JSON format.
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+const (
+	LevelInfo = "info"
+	LevelWarn = "warn"
+)
+
+// Settings are provided by the logging option of the configuration.
+type Settings struct {
+	// Format is the format of the messages sent to the outputs: text or json
+	Format string          `yaml:"format,omitempty"`
+	Syslog *SyslogSettings `yaml:"syslog,omitempty"`
+	File   *FileSettings   `yaml:"file,omitempty"`
+}
+
+// SyslogSettings select the syslog daemon receiving the messages. The local daemon is used when the address is not provided.
+type SyslogSettings struct {
+	// Network is udp, tcp or unix, and is required with the address
+	Network string `yaml:"network,omitempty"`
+	Address string `yaml:"address,omitempty"`
+	// Tag is the name of the program in the messages (default: amass)
+	Tag string `yaml:"tag,omitempty"`
+	// Facility is the syslog facility, such as daemon or local0 (default: daemon)
+	Facility string `yaml:"facility,omitempty"`
+}
+
+// FileSettings select the log file and when it is rotated.
+type FileSettings struct {
+	Path string `yaml:"path"`
+	// MaxSize is the size in megabytes the file reaches before it is rotated
+	MaxSize int `yaml:"max_size,omitempty"`
+	// MaxAge is the duration, such as 24h, the file is written to before it is rotated
+	MaxAge string `yaml:"max_age,omitempty"`
+	// MaxBackups is the number of rotated files kept, or all of them when zero
+	MaxBackups int `yaml:"max_backups,omitempty"`
+}
+
+// ParseSettings returns the Settings for the logging option, with the text format selected by default.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	s := &Settings{}
+
+	if raw != nil {
+		data, err := yaml.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the logging settings: %v", err)
+		}
+		if err := yaml.Unmarshal(data, s); err != nil {
+			return nil, errors.New("the logging option must be a map of the logging settings")
+		}
+	}
+
+	s.Format = strings.ToLower(strings.TrimSpace(s.Format))
+	if s.Format == "" {
+		s.Format = FormatText
+	}
+	if s.Format != FormatText && s.Format != FormatJSON {
+		return nil, fmt.Errorf("the logging format must be %s or %s", FormatText, FormatJSON)
+	}
+
+	if sl := s.Syslog; sl != nil {
+		sl.Network = strings.ToLower(sl.Network)
+		if (sl.Network == "") != (sl.Address == "") {
+			return nil, errors.New("the syslog network and address must be provided together")
+		}
+		if sl.Network != "" && sl.Network != "udp" && sl.Network != "tcp" && sl.Network != "unix" {
+			return nil, fmt.Errorf("%s is not a valid syslog network", sl.Network)
+		}
+		if sl.Tag == "" {
+			sl.Tag = "amass"
+		}
+		sl.Facility = strings.ToLower(sl.Facility)
+		if sl.Facility == "" {
+			sl.Facility = "daemon"
+		}
+		if !validFacility(sl.Facility) {
+			return nil, fmt.Errorf("%s is not a valid syslog facility", sl.Facility)
+		}
+	}
+
+	if f := s.File; f != nil {
+		if f.Path == "" {
+			return nil, errors.New("the logging file path is required")
+		}
+		if f.MaxSize < 0 || f.MaxBackups < 0 {
+			return nil, errors.New("the logging file max_size and max_backups cannot be negative")
+		}
+		if f.MaxAge != "" {
+			if d, err := time.ParseDuration(f.MaxAge); err != nil || d <= 0 {
+				return nil, fmt.Errorf("%s is not a valid logging file max_age", f.MaxAge)
+			}
+		}
+	}
+	return s, nil
+}
+
+// facilities are the names of the syslog facilities that can be selected.
+var facilities = []string{"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron",
+	"authpriv", "ftp", "local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7"}
+
+func validFacility(name string) bool {
+	for _, f := range facilities {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Level returns the level of the log message, which is warn for the messages about failures.
+func Level(msg string) string {
+	if strings.Contains(strings.ToLower(msg), "failed") {
+		return LevelWarn
+	}
+	return LevelInfo
+}
+
+// Output receives each log message.
+type Output interface {
+	Write(t time.Time, level, msg string) error
+	Close() error
+}
+
+// Outputs sends each log message to the outputs selected by the Settings.
+type Outputs struct {
+	sync.Mutex
+	outputs []Output
+}
+
+// New returns the Outputs selected by the Settings.
+func New(s *Settings) (*Outputs, error) {
+	o := new(Outputs)
+
+	if s.Syslog != nil {
+		w, err := NewSyslog(s.Syslog, s.Format)
+		if err != nil {
+			return nil, err
+		}
+		o.outputs = append(o.outputs, w)
+	}
+	if s.File != nil {
+		var age time.Duration
+		if s.File.MaxAge != "" {
+			age, _ = time.ParseDuration(s.File.MaxAge)
+		}
+
+		f, err := NewRotatingFile(s.File.Path, int64(s.File.MaxSize)*1024*1024, age, s.File.MaxBackups)
+		if err != nil {
+			o.Close()
+			return nil, err
+		}
+		o.outputs = append(o.outputs, &fileOutput{format: s.Format, file: f})
+	}
+	return o, nil
+}
+
+// Log sends the message to all the outputs, and is safe to call on a nil Outputs.
+func (o *Outputs) Log(msg string) {
+	if o == nil || msg == "" {
+		return
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	now := time.Now()
+	level := Level(msg)
+	for _, out := range o.outputs {
+		_ = out.Write(now, level, msg)
+	}
+}
+
+// Close closes all the outputs.
+func (o *Outputs) Close() {
+	if o == nil {
+		return
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	for _, out := range o.outputs {
+		_ = out.Close()
+	}
+	o.outputs = nil
+}
+
+// Format returns the log message in the text or JSON format.
+func Format(format string, t time.Time, level, msg string) string {
+	if format != FormatJSON {
+		return t.Format("2006-01-02T15:04:05.000000Z07:00") + " " + msg
+	}
+
+	data, err := json.Marshal(struct {
+		Time    string `json:"time"`
+		Level   string `json:"level"`
+		Message string `json:"msg"`
+	}{
+		Time:    t.Format(time.RFC3339Nano),
+		Level:   level,
+		Message: msg,
+	})
+	if err != nil {
+		return msg
+	}
+	return string(data)
+}
+
+type fileOutput struct {
+	format string
+	file   *RotatingFile
+}
+
+func (f *fileOutput) Write(t time.Time, level, msg string) error {
+	_, err := f.file.Write([]byte(Format(f.format, t, level, msg) + "\n"))
+	return err
+}
+
+func (f *fileOutput) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,145 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings(nil)
+	if err != nil || s.Format != FormatText || s.Syslog != nil || s.File != nil {
+		t.Errorf("Unexpected default settings: %+v: %v", s, err)
+	}
+
+	s, err = ParseSettings(map[string]interface{}{
+		"format": "JSON",
+		"syslog": map[string]interface{}{"network": "udp", "address": "192.0.2.1:514"},
+		"file":   map[string]interface{}{"path": "/var/log/amass.log", "max_size": 100, "max_age": "24h", "max_backups": 7},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the settings: %v", err)
+	}
+	if s.Format != FormatJSON || s.Syslog.Tag != "amass" || s.Syslog.Facility != "daemon" {
+		t.Errorf("Unexpected settings: %+v %+v", s, s.Syslog)
+	}
+	if f := s.File; f.MaxSize != 100 || f.MaxAge != "24h" || f.MaxBackups != 7 {
+		t.Errorf("Unexpected file settings: %+v", f)
+	}
+
+	for _, raw := range []interface{}{
+		"syslog",
+		map[string]interface{}{"format": "xml"},
+		map[string]interface{}{"syslog": map[string]interface{}{"address": "192.0.2.1:514"}},
+		map[string]interface{}{"syslog": map[string]interface{}{"network": "udp", "address": "192.0.2.1:514", "facility": "local9"}},
+		map[string]interface{}{"file": map[string]interface{}{"max_size": 100}},
+		map[string]interface{}{"file": map[string]interface{}{"path": "amass.log", "max_age": "daily"}},
+		map[string]interface{}{"file": map[string]interface{}{"path": "amass.log", "max_backups": -1}},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2023, time.September, 1, 12, 30, 0, 0, time.UTC)
+
+	if line := Format(FormatText, now, LevelInfo, "Querying DNSDumpster"); line != "2023-09-01T12:30:00.000000Z Querying DNSDumpster" {
+		t.Errorf("Unexpected text line: %s", line)
+	}
+
+	var rec map[string]string
+	if err := json.Unmarshal([]byte(Format(FormatJSON, now, Level("Failed to reach the API"), "Failed to reach the API")), &rec); err != nil {
+		t.Fatalf("The JSON line did not parse: %v", err)
+	}
+	if rec["time"] != "2023-09-01T12:30:00Z" || rec["level"] != LevelWarn || rec["msg"] != "Failed to reach the API" {
+		t.Errorf("Unexpected JSON record: %v", rec)
+	}
+}
+
+func TestRotatingFileBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "amass.log")
+	r, err := NewRotatingFile(path, 10, 0, 2)
+	if err != nil {
+		t.Fatalf("Failed to open the file: %v", err)
+	}
+	defer r.Close()
+
+	now := time.Now()
+	r.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	backups := r.Backups()
+	if len(backups) != 2 {
+		t.Fatalf("Expected two backups, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "second\n" {
+		t.Errorf("Expected the oldest backup to be removed, the oldest kept provided %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "fourth\n" {
+		t.Errorf("Unexpected current file: %q", data)
+	}
+}
+
+func TestRotatingFileByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amass.log")
+	r, err := NewRotatingFile(path, 0, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("Failed to open the file: %v", err)
+	}
+	defer r.Close()
+
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	r.opened = now
+
+	_, _ = r.Write([]byte("first\n"))
+	_, _ = r.Write([]byte("second\n"))
+	now = now.Add(time.Hour)
+	_, _ = r.Write([]byte("third\n"))
+
+	if backups := r.Backups(); len(backups) != 1 {
+		t.Errorf("Expected one backup, got %v", backups)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "third\n" {
+		t.Errorf("Unexpected current file: %q", data)
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amass.log")
+	o, err := New(&Settings{Format: FormatJSON, File: &FileSettings{Path: path}})
+	if err != nil {
+		t.Fatalf("Failed to create the outputs: %v", err)
+	}
+
+	o.Log("Querying DNSDumpster")
+	o.Log("")
+	o.Close()
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"msg":"Querying DNSDumpster"`) {
+		t.Errorf("Unexpected log file: %q", data)
+	}
+
+	var nilOutputs *Outputs
+	nilOutputs.Log("ignored")
+	nilOutputs.Close()
+}
@@ -0,0 +1,64 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package logging
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces the secrets in the log messages.
+const Redacted = "REDACTED"
+
+// minSecretLen keeps short credentials, such as usernames like admin, from masking common words in the messages.
+const minSecretLen = 6
+
+var (
+	// The query parameters that carry API keys and tokens, such as the apikey parameter of the VirusTotal requests
+	secretParam = regexp.MustCompile(`(?i)([?&;](?:api[_-]?key|apikey|key|x-apikey|token|access[_-]?token|auth|secret|client[_-]?secret|password|passwd|signature|sig)=)[^&\s"'#]+`)
+	// The headers that carry credentials, keeping the authentication scheme
+	secretHeader = regexp.MustCompile(`(?i)\b((?:proxy-)?authorization|x-api-key|x-apikey|api-key|x-auth-token)(["']?\s*[:=]\s*["']?)((?:bearer|basic|token|apikey)\s+)?[^\s"',;}\]]+`)
+	// The passwords provided by the user information of URLs, such as the proxy URLs
+	secretUserinfo = regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.-]*://[^/\s:@]+):[^@\s/]+@`)
+)
+
+// Redactor masks the API keys, tokens, passwords and Authorization headers in the log messages, along
+// with the configured secrets wherever they appear, such as the API keys in the paths of request URLs.
+type Redactor struct {
+	secrets []string
+}
+
+// NewRedactor returns the Redactor masking the secrets, in addition to the secrets recognized in the messages.
+func NewRedactor(secrets ...string) *Redactor {
+	r := new(Redactor)
+
+	seen := make(map[string]struct{})
+	for _, s := range secrets {
+		s = strings.TrimSpace(s)
+		if _, found := seen[s]; !found && len(s) >= minSecretLen {
+			seen[s] = struct{}{}
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	// The longest secrets are masked first, so a secret containing another is not partially masked
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+	return r
+}
+
+// Redact returns the message with the secrets masked, and is safe to call on a nil Redactor.
+func (r *Redactor) Redact(msg string) string {
+	if r != nil {
+		for _, s := range r.secrets {
+			msg = strings.ReplaceAll(msg, s, Redacted)
+		}
+	}
+
+	msg = secretParam.ReplaceAllString(msg, "${1}"+Redacted)
+	msg = secretHeader.ReplaceAllString(msg, "${1}${2}${3}"+Redacted)
+	return secretUserinfo.ReplaceAllString(msg, "${1}:"+Redacted+"@")
+}
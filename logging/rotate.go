@@ -0,0 +1,143 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is appended to the name of the rotated files, so they are sorted by the time of the rotation.
+const backupTimeFormat = "20060102T150405.000000"
+
+// RotatingFile is an io.Writer appending to the file, which is renamed and replaced by an empty file
+// once it reaches the maximum size or has been written to for the maximum age.
+type RotatingFile struct {
+	sync.Mutex
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	file       *os.File
+	size       int64
+	opened     time.Time
+	now        func() time.Time
+}
+
+// NewRotatingFile opens the file for appending. A maxSize or maxAge of zero disables that rotation, and
+// all the rotated files are kept when maxBackups is zero.
+func NewRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the log file directory: %v", err)
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open the log file: %v", err)
+	}
+
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	r.file = f
+	r.size = size
+	r.opened = r.now()
+	return nil
+}
+
+// Write implements the io.Writer interface, rotating the file before the data would exceed the limits.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	bySize := r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize
+	byAge := r.maxAge > 0 && r.now().Sub(r.opened) >= r.maxAge
+	if bySize || byAge {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file using the time of the rotation, opens an empty file, and removes the oldest backups.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close the log file: %v", err)
+	}
+	r.file = nil
+
+	backup := r.path + "." + r.now().UTC().Format(backupTimeFormat)
+	if err := os.Rename(r.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate the log file: %v", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	if r.maxBackups > 0 {
+		backups := r.Backups()
+		for len(backups) > r.maxBackups {
+			_ = os.Remove(backups[0])
+			backups = backups[1:]
+		}
+	}
+	return nil
+}
+
+// Backups returns the paths of the rotated files, with the oldest first.
+func (r *RotatingFile) Backups() []string {
+	matches, _ := filepath.Glob(r.path + ".*")
+
+	var backups []string
+	for _, m := range matches {
+		if _, err := time.Parse(backupTimeFormat, m[len(r.path)+1:]); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	sort.Strings(backups)
+	return backups
+}
+
+// Close closes the file.
+func (r *RotatingFile) Close() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
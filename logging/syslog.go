@@ -0,0 +1,73 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"fmt"
+	"log/syslog"
+	"time"
+)
+
+var priorities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// Syslog is the Output sending the messages to a syslog daemon.
+type Syslog struct {
+	format string
+	writer *syslog.Writer
+}
+
+// NewSyslog returns the Output connected to the syslog daemon selected by the settings.
+func NewSyslog(s *SyslogSettings, format string) (*Syslog, error) {
+	facility, found := priorities[s.Facility]
+	if !found {
+		return nil, fmt.Errorf("%s is not a valid syslog facility", s.Facility)
+	}
+
+	w, err := syslog.Dial(s.Network, s.Address, facility|syslog.LOG_INFO, s.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the syslog daemon: %v", err)
+	}
+	return &Syslog{format: format, writer: w}, nil
+}
+
+// Write implements the Output interface. The syslog daemon adds the timestamp to the messages in the text format.
+func (s *Syslog) Write(t time.Time, level, msg string) error {
+	if s.format == FormatJSON {
+		msg = Format(s.format, t, level, msg)
+	}
+
+	if level == LevelWarn {
+		return s.writer.Warning(msg)
+	}
+	return s.writer.Info(msg)
+}
+
+// Close implements the Output interface.
+func (s *Syslog) Close() error {
+	return s.writer.Close()
+}
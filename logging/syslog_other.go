@@ -0,0 +1,30 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows || plan9
+
+package logging
+
+import (
+	"errors"
+	"time"
+)
+
+// Syslog is the Output sending the messages to a syslog daemon, which is not supported on this operating system.
+type Syslog struct{}
+
+// NewSyslog returns an error, since syslog is not supported on this operating system.
+func NewSyslog(s *SyslogSettings, format string) (*Syslog, error) {
+	return nil, errors.New("syslog is not supported on this operating system")
+}
+
+// Write implements the Output interface.
+func (s *Syslog) Write(t time.Time, level, msg string) error {
+	return nil
+}
+
+// Close implements the Output interface.
+func (s *Syslog) Close() error {
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package metrics collects the runtime metrics of the engine, such as the depth of the request queues,
+// the requests and callbacks of each data source, and the writes to the graph database, and exposes
+// them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry contains the metrics exposed together.
+type Registry struct {
+	sync.Mutex
+	counters map[string]*Counter
+	gauges   map[string]*gaugeFunc
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[string]*Counter),
+		gauges:   make(map[string]*gaugeFunc),
+	}
+}
+
+// Default is the Registry containing the metrics of the engine.
+var Default = NewRegistry()
+
+// The metrics of the engine.
+var (
+	DataSourceRequests = Default.NewCounter("amass_datasource_requests_total",
+		"HTTP requests made by the data sources", "source")
+	DataSourceErrors = Default.NewCounter("amass_datasource_request_errors_total",
+		"HTTP requests made by the data sources that failed", "source")
+	DataSourceCacheHits = Default.NewCounter("amass_datasource_cache_hits_total",
+		"Data source responses provided by the response cache", "source")
+	Callbacks = Default.NewCounter("amass_datasource_callbacks_total",
+		"Callbacks executed by the data sources", "source", "callback")
+	CallbackErrors = Default.NewCounter("amass_datasource_callback_errors_total",
+		"Callbacks executed by the data sources that failed", "source", "callback")
+	CallbackSeconds = Default.NewCounter("amass_datasource_callback_seconds_total",
+		"Time spent executing the callbacks of the data sources", "source", "callback")
+	GraphWrites = Default.NewCounter("amass_graph_writes_total",
+		"DNS records written to the graph database", "record")
+	GraphWriteErrors = Default.NewCounter("amass_graph_write_errors_total",
+		"DNS records that failed to be written to the graph database", "record")
+)
+
+// Counter is a metric that only increases, partitioned by the values of its labels.
+type Counter struct {
+	sync.Mutex
+	name   string
+	help   string
+	labels []string
+	values map[string]float64
+}
+
+// NewCounter returns the Counter with the name registered, creating it when necessary.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	r.Lock()
+	defer r.Unlock()
+
+	if c, found := r.counters[name]; found {
+		return c
+	}
+
+	c := &Counter{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+	}
+	r.counters[name] = c
+	return c
+}
+
+// Add increases the counter partition identified by the label values, which are provided in the order
+// the labels were registered. Negative values are ignored, since counters cannot decrease.
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 || len(values) != len(c.labels) {
+		return
+	}
+
+	key := labelString(c.labels, values)
+	c.Lock()
+	c.values[key] += v
+	c.Unlock()
+}
+
+// Inc increases the counter partition identified by the label values by one.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Value returns the current value of the counter partition identified by the label values.
+func (c *Counter) Value(values ...string) float64 {
+	if len(values) != len(c.labels) {
+		return 0
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	return c.values[labelString(c.labels, values)]
+}
+
+type gaugeFunc struct {
+	name string
+	help string
+	fn   func() float64
+}
+
+// SetGauge registers the function providing the value of the gauge when the metrics are collected,
+// replacing the function previously registered with the name. A nil function removes the gauge.
+func (r *Registry) SetGauge(name, help string, fn func() float64) {
+	r.Lock()
+	defer r.Unlock()
+
+	if fn == nil {
+		delete(r.gauges, name)
+		return
+	}
+	r.gauges[name] = &gaugeFunc{name: name, help: help, fn: fn}
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.Lock()
+	var counters []*Counter
+	for _, c := range r.counters {
+		counters = append(counters, c)
+	}
+	var gauges []*gaugeFunc
+	for _, g := range r.gauges {
+		gauges = append(gauges, g)
+	}
+	r.Unlock()
+
+	sort.Slice(counters, func(i, j int) bool { return counters[i].name < counters[j].name })
+	sort.Slice(gauges, func(i, j int) bool { return gauges[i].name < gauges[j].name })
+
+	bw := bufio.NewWriter(w)
+	for _, g := range gauges {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		fmt.Fprintf(bw, "%s %s\n", g.name, formatValue(g.fn()))
+	}
+	for _, c := range counters {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+
+		c.Lock()
+		var keys []string
+		for k := range c.values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(bw, "%s%s %s\n", c.name, k, formatValue(c.values[k]))
+		}
+		c.Unlock()
+	}
+	return bw.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelString(labels, values []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		pairs[i] = l + `="` + labelEscaper.Replace(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
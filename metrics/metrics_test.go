@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounter("amass_test_total", "Test counter", "source")
+	c.Inc("Shodan")
+	c.Add(2, "Shodan")
+	c.Inc(`Say "hi"`)
+	// Counters cannot decrease, and the label values must match the labels
+	c.Add(-5, "Shodan")
+	c.Inc()
+
+	if reg.NewCounter("amass_test_total", "Other") != c {
+		t.Error("Expected the registered counter to be returned")
+	}
+	if v := c.Value("Shodan"); v != 3 {
+		t.Errorf("Expected a value of 3, got %f", v)
+	}
+
+	depth := 7
+	reg.SetGauge("amass_test_queued", "Test gauge", func() float64 { return float64(depth) })
+
+	var b strings.Builder
+	if err := reg.WriteText(&b); err != nil {
+		t.Fatalf("Failed to write the metrics: %v", err)
+	}
+
+	want := `# HELP amass_test_queued Test gauge
+# TYPE amass_test_queued gauge
+amass_test_queued 7
+# HELP amass_test_total Test counter
+# TYPE amass_test_total counter
+amass_test_total{source="Say \"hi\""} 1
+amass_test_total{source="Shodan"} 3
+`
+	if got := b.String(); got != want {
+		t.Errorf("Unexpected metrics:\n%s", got)
+	}
+
+	reg.SetGauge("amass_test_queued", "", nil)
+	b.Reset()
+	_ = reg.WriteText(&b)
+	if strings.Contains(b.String(), "amass_test_queued") {
+		t.Error("Expected the gauge to be removed")
+	}
+}
+
+func TestServer(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounter("amass_test_total", "Test counter").Inc()
+
+	s, err := NewServer(reg, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start the metrics server: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	resp, err := http.Get("http://" + s.Addr() + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to request the metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "amass_test_total 1") {
+		t.Errorf("Unexpected response: %d %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type: %s", ct)
+	}
+}
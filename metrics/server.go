@@ -0,0 +1,57 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Server exposes the /metrics endpoint scraped by Prometheus.
+type Server struct {
+	ln     net.Listener
+	server *http.Server
+}
+
+// NewServer starts serving the metrics of the registry on the provided address.
+func NewServer(reg *Registry, addr string) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics requests on %s: %v", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(reg))
+	s := &Server{
+		ln: ln,
+		server: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+
+	go func() { _ = s.server.Serve(ln) }()
+	return s, nil
+}
+
+// Addr returns the address the metrics are being served on.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops serving the metrics.
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// Handler returns the HTTP handler writing the metrics of the registry in the text exposition format.
+func Handler(reg *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = reg.WriteText(w)
+	})
+}
@@ -0,0 +1,261 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mockinet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SourcesPath is the path of the data source APIs, followed by the name of the data source.
+const SourcesPath = "/sources/"
+
+// Registration is the registration of a domain name served by the RDAP service.
+type Registration struct {
+	Registrar string
+	Created   time.Time
+	Expires   time.Time
+	// AbuseEmail is the email address of the contact with the abuse role, if any
+	AbuseEmail string
+}
+
+// Network is the allocation of a netblock served by the RDAP service for the addresses it contains.
+type Network struct {
+	CIDR       *net.IPNet
+	Handle     string
+	Name       string
+	AbuseEmail string
+}
+
+// source is a data source API that returns the names it knows under the domain provided by the domain parameter.
+type source struct {
+	names []string
+	// status is the HTTP status returned instead of the names, such as 429 to test the backoff
+	status   int
+	requests int
+}
+
+// AddRegistration adds the registration served by the RDAP service for the domain name.
+func (in *Internet) AddRegistration(domain string, reg *Registration) {
+	in.Lock()
+	defer in.Unlock()
+
+	in.registrations[canonical(domain)] = reg
+}
+
+// AddNetwork adds the allocation served by the RDAP service for the netblock and the addresses it contains.
+func (in *Internet) AddNetwork(cidr, handle, name, abuseEmail string) error {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+
+	in.Lock()
+	defer in.Unlock()
+
+	in.networks = append(in.networks, &Network{CIDR: ipnet, Handle: handle, Name: name, AbuseEmail: abuseEmail})
+	return nil
+}
+
+// AddSource adds the data source API providing the names.
+func (in *Internet) AddSource(name string, names ...string) {
+	in.Lock()
+	defer in.Unlock()
+
+	in.sources[strings.ToLower(name)] = &source{names: names}
+}
+
+// SetStatus sets the HTTP status returned by the data source API instead of the names, or the names are returned again when it is 200.
+func (in *Internet) SetStatus(name string, status int) {
+	in.Lock()
+	defer in.Unlock()
+
+	if src, found := in.sources[strings.ToLower(name)]; found {
+		src.status = status
+	}
+}
+
+// Requests returns the number of requests received by the data source API.
+func (in *Internet) Requests(name string) int {
+	in.Lock()
+	defer in.Unlock()
+
+	if src, found := in.sources[strings.ToLower(name)]; found {
+		return src.requests
+	}
+	return 0
+}
+
+// SourceURL returns the URL of the data source API, which is followed by the domain name.
+func (in *Internet) SourceURL(name string) string {
+	return in.URL() + SourcesPath + url.PathEscape(strings.ToLower(name)) + "?domain="
+}
+
+// Script returns the data source script querying the API of the named data source for each root domain name.
+func (in *Internet) Script(name string) string {
+	return fmt.Sprintf(`
+name = %q
+type = "api"
+
+function vertical(ctx, domain)
+	local resp, err = request(ctx, {['url']=%q .. domain})
+	if (err ~= nil and err ~= "") then
+		log(ctx, "vertical request to service failed: " .. err)
+		return
+	end
+
+	send_names(ctx, resp.body)
+end
+`, name, in.SourceURL(name))
+}
+
+func (in *Internet) serveAPI(w http.ResponseWriter, r *http.Request) {
+	switch p := r.URL.Path; {
+	case strings.HasPrefix(p, SourcesPath):
+		in.serveSource(w, r, strings.TrimPrefix(p, SourcesPath))
+	case strings.HasPrefix(p, "/domain/"):
+		in.serveDomain(w, strings.TrimPrefix(p, "/domain/"))
+	case strings.HasPrefix(p, "/ip/"):
+		in.serveIP(w, strings.TrimPrefix(p, "/ip/"))
+	case strings.HasPrefix(p, "/autnum/"):
+		writeRDAP(w, map[string]interface{}{"objectClassName": "autnum", "entities": []interface{}{}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (in *Internet) serveSource(w http.ResponseWriter, r *http.Request, name string) {
+	in.Lock()
+	var status int
+	var all []string
+	src, found := in.sources[strings.ToLower(name)]
+	if found {
+		src.requests++
+		status, all = src.status, src.names
+	}
+	in.Unlock()
+
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	if status != 0 && status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	domain := canonical(r.URL.Query().Get("domain"))
+	var names []string
+	for _, n := range all {
+		if c := canonical(n); c == domain || strings.HasSuffix(c, "."+domain) {
+			names = append(names, n)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(strings.Join(names, "\n")))
+}
+
+func (in *Internet) serveDomain(w http.ResponseWriter, domain string) {
+	in.Lock()
+	reg, found := in.registrations[canonical(domain)]
+	in.Unlock()
+
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var events []interface{}
+	if !reg.Created.IsZero() {
+		events = append(events, map[string]string{"eventAction": "registration", "eventDate": reg.Created.UTC().Format(time.RFC3339)})
+	}
+	if !reg.Expires.IsZero() {
+		events = append(events, map[string]string{"eventAction": "expiration", "eventDate": reg.Expires.UTC().Format(time.RFC3339)})
+	}
+
+	entities := []interface{}{}
+	if reg.Registrar != "" {
+		entities = append(entities, entity("", "registrar", reg.Registrar, ""))
+	}
+	if reg.AbuseEmail != "" {
+		entities = append(entities, entity("", "abuse", "Abuse Contact", reg.AbuseEmail))
+	}
+
+	writeRDAP(w, map[string]interface{}{
+		"objectClassName": "domain",
+		"ldhName":         canonical(domain),
+		"events":          events,
+		"entities":        entities,
+	})
+}
+
+func (in *Internet) serveIP(w http.ResponseWriter, object string) {
+	ip := net.ParseIP(strings.SplitN(object, "/", 2)[0])
+	if ip == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	in.Lock()
+	var match *Network
+	for _, n := range in.networks {
+		if n.CIDR.Contains(ip) {
+			if match == nil || prefixLen(n.CIDR) > prefixLen(match.CIDR) {
+				match = n
+			}
+		}
+	}
+	in.Unlock()
+
+	if match == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	entities := []interface{}{}
+	if match.AbuseEmail != "" {
+		entities = append(entities, entity(match.Handle+"-ABUSE", "abuse", match.Name+" Abuse", match.AbuseEmail))
+	}
+	writeRDAP(w, map[string]interface{}{
+		"objectClassName": "ip network",
+		"handle":          match.Handle,
+		"name":            match.Name,
+		"entities":        entities,
+	})
+}
+
+func prefixLen(n *net.IPNet) int {
+	ones, _ := n.Mask.Size()
+	return ones
+}
+
+// entity returns the RDAP entity with the role, providing the name and email address in the jCard.
+func entity(handle, role, name, email string) map[string]interface{} {
+	props := []interface{}{
+		[]interface{}{"version", map[string]string{}, "text", "4.0"},
+		[]interface{}{"fn", map[string]string{}, "text", name},
+	}
+	if email != "" {
+		props = append(props, []interface{}{"email", map[string]string{}, "text", email})
+	}
+
+	return map[string]interface{}{
+		"objectClassName": "entity",
+		"handle":          handle,
+		"roles":           []string{role},
+		"vcardArray":      []interface{}{"vcard", props},
+	}
+}
+
+func writeRDAP(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/rdap+json")
+	_ = json.NewEncoder(w).Encode(v)
+}
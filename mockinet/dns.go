@@ -0,0 +1,213 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mockinet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// maxChain is the number of CNAME records followed within the mock zones for an answer.
+const maxChain = 8
+
+type zone struct {
+	origin  string
+	soa     *dns.SOA
+	records map[string][]dns.RR
+}
+
+// AddZone adds the authoritative zone for the origin using the records in zone file format. The names can
+// be relative to the origin, and the SOA and NS records are generated when they are not provided. A record
+// for the *.origin name makes the zone a wildcard, answering for all the names without records.
+func (in *Internet) AddZone(origin string, records ...string) error {
+	origin = canonical(origin)
+	z := &zone{
+		origin:  origin,
+		records: make(map[string][]dns.RR),
+	}
+
+	text := fmt.Sprintf("$ORIGIN %s.\n$TTL 300\n%s\n", origin, strings.Join(records, "\n"))
+	zp := dns.NewZoneParser(strings.NewReader(text), origin+".", "")
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		name := canonical(rr.Header().Name)
+		if name != origin && !strings.HasSuffix(name, "."+origin) {
+			return fmt.Errorf("the %s record is outside of the %s zone", rr.Header().Name, origin)
+		}
+
+		if soa, ok := rr.(*dns.SOA); ok {
+			z.soa = soa
+		}
+		z.records[name] = append(z.records[name], rr)
+	}
+	if err := zp.Err(); err != nil {
+		return fmt.Errorf("failed to parse the records of the %s zone: %v", origin, err)
+	}
+
+	if z.soa == nil {
+		z.soa = &dns.SOA{
+			Hdr:     dns.RR_Header{Name: origin + ".", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+			Ns:      "ns1." + origin + ".",
+			Mbox:    "hostmaster." + origin + ".",
+			Serial:  1,
+			Refresh: 7200,
+			Retry:   3600,
+			Expire:  1209600,
+			Minttl:  300,
+		}
+		z.records[origin] = append(z.records[origin], z.soa)
+	}
+	if len(z.rrset(origin, dns.TypeNS)) == 0 {
+		z.records[origin] = append(z.records[origin], &dns.NS{
+			Hdr: dns.RR_Header{Name: origin + ".", Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 300},
+			Ns:  z.soa.Ns,
+		})
+	}
+
+	in.Lock()
+	defer in.Unlock()
+
+	in.zones[origin] = z
+	return nil
+}
+
+// zone returns the most specific zone containing the name, or nil when the mock is not authoritative for it.
+func (in *Internet) zone(name string) *zone {
+	for n := name; n != ""; {
+		if z, found := in.zones[n]; found {
+			return z
+		}
+
+		i := strings.Index(n, ".")
+		if i < 0 {
+			break
+		}
+		n = n[i+1:]
+	}
+	return nil
+}
+
+func (in *Internet) serveDNS(w dns.ResponseWriter, req *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(req)
+	m.Authoritative = true
+
+	if len(req.Question) == 0 {
+		m.Rcode = dns.RcodeFormatError
+		_ = w.WriteMsg(m)
+		return
+	}
+
+	q := req.Question[0]
+	name := canonical(q.Name)
+
+	in.Lock()
+	defer in.Unlock()
+
+	in.queries[name]++
+	z := in.zone(name)
+	if z == nil {
+		m.Rcode = dns.RcodeRefused
+		_ = w.WriteMsg(m)
+		return
+	}
+
+	owner := q.Name
+	for i := 0; i < maxChain; i++ {
+		rrs, exists := z.lookup(name)
+		if !exists {
+			if len(m.Answer) == 0 {
+				m.Rcode = dns.RcodeNameError
+			}
+			m.Ns = append(m.Ns, z.soa)
+			break
+		}
+
+		answers := synthesize(filter(rrs, q.Qtype), owner)
+		if len(answers) > 0 || q.Qtype == dns.TypeCNAME {
+			m.Answer = append(m.Answer, answers...)
+			if len(answers) == 0 {
+				m.Ns = append(m.Ns, z.soa)
+			}
+			break
+		}
+
+		cnames := synthesize(filter(rrs, dns.TypeCNAME), owner)
+		if len(cnames) == 0 {
+			m.Ns = append(m.Ns, z.soa)
+			break
+		}
+		m.Answer = append(m.Answer, cnames[0])
+		// The alias is only followed within the zones of the mock
+		owner = cnames[0].(*dns.CNAME).Target
+		name = canonical(owner)
+		if z = in.zone(name); z == nil {
+			break
+		}
+	}
+	_ = w.WriteMsg(m)
+}
+
+// lookup returns the records of the name, or of the wildcard covering it, and whether the name exists.
+func (z *zone) lookup(name string) ([]dns.RR, bool) {
+	if rrs, found := z.records[name]; found {
+		return rrs, true
+	}
+	// Empty non-terminals exist without records
+	for n := range z.records {
+		if strings.HasSuffix(n, "."+name) {
+			return nil, true
+		}
+	}
+
+	if i := strings.Index(name, "."); i >= 0 && name != z.origin {
+		for parent := name[i+1:]; ; {
+			if rrs, found := z.records["*."+parent]; found {
+				return rrs, true
+			}
+			if parent == z.origin {
+				break
+			}
+			j := strings.Index(parent, ".")
+			if j < 0 {
+				break
+			}
+			parent = parent[j+1:]
+		}
+	}
+	return nil, false
+}
+
+func (z *zone) rrset(name string, qtype uint16) []dns.RR {
+	return filter(z.records[name], qtype)
+}
+
+func filter(rrs []dns.RR, qtype uint16) []dns.RR {
+	var results []dns.RR
+
+	for _, rr := range rrs {
+		if rr.Header().Rrtype == qtype || qtype == dns.TypeANY {
+			results = append(results, rr)
+		}
+	}
+	return results
+}
+
+// synthesize returns copies of the records owned by the name queried, so the wildcard records are answered for the name.
+func synthesize(rrs []dns.RR, owner string) []dns.RR {
+	var results []dns.RR
+
+	for _, rr := range rrs {
+		c := dns.Copy(rr)
+		c.Header().Name = owner
+		results = append(results, c)
+	}
+	return results
+}
+
+func canonical(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
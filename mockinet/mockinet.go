@@ -0,0 +1,93 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mockinet provides a hermetic mock of the Internet services the engine depends on: authoritative
+// DNS servers for the zones provided by the test, an RDAP service for the registrations, and the APIs of the
+// data sources. The whole engine can run against it in CI, so the enumeration behavior is tested end-to-end
+// without any traffic leaving the machine.
+package mockinet
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// Internet is the mock of the DNS, RDAP and data source services. The zones, registrations and
+// data sources can be added before or after it is started, and are served once they are added.
+type Internet struct {
+	sync.Mutex
+	zones         map[string]*zone
+	registrations map[string]*Registration
+	networks      []*Network
+	sources       map[string]*source
+	server        *dns.Server
+	conn          net.PacketConn
+	api           *httptest.Server
+	queries       map[string]int
+}
+
+// New returns the mock Internet without any zones, registrations or data sources.
+func New() *Internet {
+	return &Internet{
+		zones:         make(map[string]*zone),
+		registrations: make(map[string]*Registration),
+		sources:       make(map[string]*source),
+		queries:       make(map[string]int),
+	}
+}
+
+// Start begins serving DNS queries on a local UDP port and the RDAP and data source APIs on a local HTTP server.
+func (in *Internet) Start() error {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to start the DNS server: %v", err)
+	}
+	in.conn = conn
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	in.server = &dns.Server{
+		PacketConn:        conn,
+		Handler:           dns.HandlerFunc(in.serveDNS),
+		NotifyStartedFunc: wg.Done,
+	}
+	go func() { _ = in.server.ActivateAndServe() }()
+	wg.Wait()
+
+	in.api = httptest.NewServer(http.HandlerFunc(in.serveAPI))
+	return nil
+}
+
+// Close stops the DNS server and the APIs.
+func (in *Internet) Close() {
+	if in.api != nil {
+		in.api.Close()
+	}
+	if in.server != nil {
+		_ = in.server.Shutdown()
+	}
+}
+
+// DNSAddr returns the address of the DNS server, which is used as the resolver by the engine.
+func (in *Internet) DNSAddr() string {
+	return in.conn.LocalAddr().String()
+}
+
+// URL returns the base URL of the RDAP and data source APIs.
+func (in *Internet) URL() string {
+	return in.api.URL
+}
+
+// Queries returns the number of DNS queries received for the name, so tests can verify which names the engine resolved.
+func (in *Internet) Queries(name string) int {
+	in.Lock()
+	defer in.Unlock()
+
+	return in.queries[canonical(name)]
+}
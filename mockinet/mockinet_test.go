@@ -0,0 +1,149 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mockinet
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/net/rdap"
+	"github.com/owasp-amass/amass/v4/net/registry"
+)
+
+func startInternet(t *testing.T) *Internet {
+	in := New()
+	if err := in.AddZone("owasp.org",
+		"@ IN A 192.0.2.1",
+		"www IN A 192.0.2.2",
+		"docs IN CNAME www",
+		"@ IN MX 10 mail.owasp.org.",
+		"mail IN A 192.0.2.3",
+		"host.internal IN A 192.0.2.4",
+	); err != nil {
+		t.Fatalf("Failed to add the zone: %v", err)
+	}
+	if err := in.AddZone("wild.example", "*.wild.example. IN A 198.51.100.1"); err != nil {
+		t.Fatalf("Failed to add the wildcard zone: %v", err)
+	}
+	if err := in.Start(); err != nil {
+		t.Fatalf("Failed to start the mock Internet: %v", err)
+	}
+	t.Cleanup(in.Close)
+	return in
+}
+
+func TestDNS(t *testing.T) {
+	in := startInternet(t)
+	c := new(dns.Client)
+
+	tests := []struct {
+		name    string
+		qtype   uint16
+		rcode   int
+		answers int
+	}{
+		{"www.owasp.org", dns.TypeA, dns.RcodeSuccess, 1},
+		{"docs.owasp.org", dns.TypeA, dns.RcodeSuccess, 2},
+		{"docs.owasp.org", dns.TypeCNAME, dns.RcodeSuccess, 1},
+		{"owasp.org", dns.TypeMX, dns.RcodeSuccess, 1},
+		{"owasp.org", dns.TypeNS, dns.RcodeSuccess, 1},
+		{"www.owasp.org", dns.TypeAAAA, dns.RcodeSuccess, 0},
+		{"internal.owasp.org", dns.TypeA, dns.RcodeSuccess, 0},
+		{"missing.owasp.org", dns.TypeA, dns.RcodeNameError, 0},
+		{"anything.wild.example", dns.TypeA, dns.RcodeSuccess, 1},
+		{"example.com", dns.TypeA, dns.RcodeRefused, 0},
+	}
+
+	for _, test := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion(dns.Fqdn(test.name), test.qtype)
+
+		resp, _, err := c.Exchange(m, in.DNSAddr())
+		if err != nil {
+			t.Fatalf("%s: the query failed: %v", test.name, err)
+		}
+		if resp.Rcode != test.rcode || len(resp.Answer) != test.answers {
+			t.Errorf("%s %s: expected rcode %d with %d answers, got rcode %d with %d answers", test.name,
+				dns.TypeToString[test.qtype], test.rcode, test.answers, resp.Rcode, len(resp.Answer))
+		}
+		if test.name == "anything.wild.example" && len(resp.Answer) > 0 && resp.Answer[0].Header().Name != "anything.wild.example." {
+			t.Errorf("Expected the wildcard answer to be owned by the name queried, got %s", resp.Answer[0].Header().Name)
+		}
+	}
+	if n := in.Queries("WWW.owasp.org."); n != 2 {
+		t.Errorf("Expected two queries for www.owasp.org, got %d", n)
+	}
+}
+
+func TestAddZoneOutsideOrigin(t *testing.T) {
+	if err := New().AddZone("owasp.org", "www.example.com. IN A 192.0.2.1"); err == nil {
+		t.Error("Expected an error for the record outside of the zone")
+	}
+}
+
+func TestRDAP(t *testing.T) {
+	in := startInternet(t)
+	created := time.Date(2001, time.September, 24, 0, 0, 0, 0, time.UTC)
+	in.AddRegistration("owasp.org", &Registration{
+		Registrar: "Example Registrar, Inc.",
+		Created:   created,
+		Expires:   created.AddDate(30, 0, 0),
+	})
+	if err := in.AddNetwork("192.0.2.0/24", "NET-192-0-2-0-1", "TEST-NET-1", "abuse@example.net"); err != nil {
+		t.Fatalf("Failed to add the network: %v", err)
+	}
+
+	c := &rdap.Client{BaseURL: in.URL(), HTTP: http.DefaultClient, Queue: registry.NewQueue()}
+	reg, err := c.DomainRegistration(context.Background(), "owasp.org")
+	if err != nil {
+		t.Fatalf("Failed to obtain the registration: %v", err)
+	}
+	if reg.Registrar != "Example Registrar, Inc." || !reg.Created.Equal(created) || reg.Expires.Year() != 2031 {
+		t.Errorf("Unexpected registration: %+v", reg)
+	}
+	if _, err := c.DomainRegistration(context.Background(), "example.com"); err == nil {
+		t.Error("Expected an error for the domain without a registration")
+	}
+
+	contacts, err := c.AbuseContacts(context.Background(), "192.0.2.25")
+	if err != nil || len(contacts) != 1 || contacts[0].Email != "abuse@example.net" {
+		t.Errorf("Unexpected abuse contacts: %v: %v", contacts, err)
+	}
+}
+
+func TestSources(t *testing.T) {
+	in := startInternet(t)
+	in.AddSource("MockAPI", "www.owasp.org", "docs.owasp.org", "www.example.com")
+
+	get := func() (int, string) {
+		resp, err := http.Get(in.SourceURL("MockAPI") + "owasp.org")
+		if err != nil {
+			t.Fatalf("The request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, body := get(); status != http.StatusOK || body != "www.owasp.org\ndocs.owasp.org" {
+		t.Errorf("Unexpected response: %d %q", status, body)
+	}
+	in.SetStatus("MockAPI", http.StatusTooManyRequests)
+	if status, _ := get(); status != http.StatusTooManyRequests {
+		t.Errorf("Expected the configured status, got %d", status)
+	}
+	if n := in.Requests("mockapi"); n != 2 {
+		t.Errorf("Expected two requests, got %d", n)
+	}
+	if s := in.Script("MockAPI"); !strings.Contains(s, in.SourceURL("MockAPI")) || !strings.Contains(s, `name = "MockAPI"`) {
+		t.Errorf("The script does not query the mock API:\n%s", s)
+	}
+}
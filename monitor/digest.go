@@ -0,0 +1,170 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/session"
+	"github.com/owasp-amass/asset-db/types"
+)
+
+// The periods of the digests sent instead of the alerts of each cycle.
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestStateFileName is the name of the file in the output directory that keeps the digest periods.
+const DigestStateFileName = "digest_state.json"
+
+// digestStateKind is the versioned format of the state file, which is documented by the session package.
+var digestStateKind = &session.Kind{Name: "digest", Version: 1}
+
+// DigestPeriod returns the duration of the digest period, or zero when the period is not valid.
+func DigestPeriod(period string) time.Duration {
+	switch period {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// Digest summarizes the changes since the previous digest: the assets that appeared, the assets
+// that were observed during the previous period but not since, and the findings recorded.
+type Digest struct {
+	Period   string                `json:"period"`
+	Domains  []string              `json:"domains"`
+	Since    time.Time             `json:"since"`
+	Until    time.Time             `json:"until"`
+	Added    []*format.AssetRecord `json:"added"`
+	Removed  []*format.AssetRecord `json:"removed"`
+	Findings []*findings.Finding   `json:"findings,omitempty"`
+}
+
+// Empty returns true when the digest has no changes to report.
+func (d *Digest) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Findings) == 0
+}
+
+// Summary returns a short description of the digest that is suitable for chat messages.
+func (d *Digest) Summary() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Amass %s digest for %s from %s to %s: %d new assets, %d removed assets, %d new findings",
+		d.Period, strings.Join(d.Domains, ", "), d.Since.Format("2006-01-02 15:04"),
+		d.Until.Format("2006-01-02 15:04"), len(d.Added), len(d.Removed), len(d.Findings))
+	for _, rec := range d.Added {
+		fmt.Fprintf(&b, "\n+ %s (%s)", rec.Key, rec.Type)
+	}
+	for _, rec := range d.Removed {
+		fmt.Fprintf(&b, "\n- %s (%s)", rec.Key, rec.Type)
+	}
+	for _, f := range d.Findings {
+		fmt.Fprintf(&b, "\n[%s] %s", f.Severity, f.Title)
+		if u := f.Attributes["url"]; u != "" {
+			fmt.Fprintf(&b, " %s", u)
+		}
+	}
+	return b.String()
+}
+
+// DigestPeriodState is the start of the current period of a digest, and the assets observed during the previous period.
+type DigestPeriodState struct {
+	Since  time.Time             `json:"since"`
+	Assets []*format.AssetRecord `json:"assets"`
+}
+
+// DigestState keeps the state of each digest period between the cycles and sessions.
+type DigestState map[string]*DigestPeriodState
+
+// ReadDigestState returns the digest state kept in the file, or an empty state when the file does not exist.
+func ReadDigestState(path string) (DigestState, error) {
+	s := make(DigestState)
+	if _, err := digestStateKind.Read(path, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write stores the digest state in the file.
+func (s DigestState) Write(path string) error {
+	return digestStateKind.Write(path, s)
+}
+
+// Due returns true when the period has elapsed since the previous digest, or the period was never started.
+func (s DigestState) Due(period string, now time.Time) bool {
+	ps, found := s[period]
+	return !found || !now.Before(ps.Since.Add(DigestPeriod(period)))
+}
+
+// Next returns the digest of the period, given the assets observed since the start of the period and the
+// findings recorded, and starts the next period. The first time the period is started, the assets become the
+// baseline and nil is returned, so the first digest only reports changes.
+func (s DigestState) Next(period string, domains []string, assets []*types.Asset, fs []*findings.Finding, now time.Time) *Digest {
+	seen := make(map[string]*format.AssetRecord)
+	for _, a := range assets {
+		if a == nil || a.Asset == nil || !reported(a.Asset.AssetType()) {
+			continue
+		}
+		rec := format.NewAssetRecord(a)
+		seen[rec.Type+" "+rec.Key] = rec
+	}
+
+	current := make([]*format.AssetRecord, 0, len(seen))
+	for _, rec := range seen {
+		current = append(current, &format.AssetRecord{Type: rec.Type, Key: rec.Key})
+	}
+	sortRecords(current)
+
+	prev, found := s[period]
+	s[period] = &DigestPeriodState{Since: now, Assets: current}
+	if !found {
+		return nil
+	}
+
+	d := &Digest{
+		Period:  period,
+		Domains: domains,
+		Since:   prev.Since,
+		Until:   now,
+	}
+	for _, rec := range seen {
+		if !rec.CreatedAt.Before(prev.Since) {
+			d.Added = append(d.Added, rec)
+		}
+	}
+	for _, rec := range prev.Assets {
+		if _, found := seen[rec.Type+" "+rec.Key]; !found {
+			d.Removed = append(d.Removed, rec)
+		}
+	}
+	for _, f := range fs {
+		if !f.Time.Before(prev.Since) && f.Time.Before(now) {
+			d.Findings = append(d.Findings, f)
+		}
+	}
+
+	sortRecords(d.Added)
+	sortRecords(d.Removed)
+	findings.SortBySeverity(d.Findings)
+	return d
+}
+
+func sortRecords(recs []*format.AssetRecord) {
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Type != recs[j].Type {
+			return recs[i].Type < recs[j].Type
+		}
+		return recs[i].Key < recs[j].Key
+	})
+}
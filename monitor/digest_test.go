@@ -0,0 +1,102 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+func TestDigestState(t *testing.T) {
+	start := time.Now().Add(-8 * 24 * time.Hour)
+	domains := []string{"owasp.org"}
+
+	s := make(DigestState)
+	if !s.Due(DigestWeekly, start) {
+		t.Error("Expected a period that was never started to be due")
+	}
+
+	old := start.Add(-time.Hour)
+	first := []*types.Asset{
+		{ID: "1", CreatedAt: old, LastSeen: start, Asset: domain.FQDN{Name: "www.owasp.org"}},
+		{ID: "2", CreatedAt: old, LastSeen: start, Asset: domain.FQDN{Name: "ftp.owasp.org"}},
+	}
+	if d := s.Next(DigestWeekly, domains, first, nil, start); d != nil {
+		t.Errorf("Expected the first period to only record the baseline, got %+v", d)
+	}
+	if s.Due(DigestWeekly, start.Add(24*time.Hour)) {
+		t.Error("Expected the weekly digest not to be due after a day")
+	}
+
+	now := time.Now()
+	if !s.Due(DigestWeekly, now) {
+		t.Error("Expected the weekly digest to be due after a week")
+	}
+
+	path := filepath.Join(t.TempDir(), DigestStateFileName)
+	if err := s.Write(path); err != nil {
+		t.Fatalf("Failed to write the digest state: %v", err)
+	}
+	s, err := ReadDigestState(path)
+	if err != nil {
+		t.Fatalf("Failed to read the digest state: %v", err)
+	}
+
+	second := []*types.Asset{
+		{ID: "1", CreatedAt: old, LastSeen: now, Asset: domain.FQDN{Name: "www.owasp.org"}},
+		{ID: "3", CreatedAt: now.Add(-time.Hour), LastSeen: now, Asset: domain.FQDN{Name: "vpn.owasp.org"}},
+	}
+	fs := []*findings.Finding{
+		{Time: old, Severity: findings.SeverityHigh, Title: "reported by the previous digest"},
+		{Time: now.Add(-time.Minute), Severity: findings.SeverityLow, Title: "low"},
+		{Time: now.Add(-time.Minute), Severity: findings.SeverityCritical, Title: "critical"},
+	}
+	d := s.Next(DigestWeekly, domains, second, fs, now)
+	if d == nil {
+		t.Fatal("Expected a digest for the second period")
+	}
+	if len(d.Added) != 1 || d.Added[0].Key != "vpn.owasp.org" {
+		t.Errorf("Unexpected added assets: %+v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Key != "ftp.owasp.org" {
+		t.Errorf("Unexpected removed assets: %+v", d.Removed)
+	}
+	if len(d.Findings) != 2 || d.Findings[0].Title != "critical" {
+		t.Errorf("Unexpected findings: %+v", d.Findings)
+	}
+
+	var got Digest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = json.NewDecoder(req.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	hook := NewWebhook(srv.URL, "")
+	hook.Digest = DigestWeekly
+	hook.MinSeverity = findings.SeverityHigh
+	daily := NewWebhook(srv.URL+"/daily", "")
+	daily.Digest = DigestDaily
+	if err := NotifyDigest(context.Background(), []Channel{hook, daily}, d); err != nil {
+		t.Fatalf("Failed to send the digest: %v", err)
+	}
+	if got.Period != DigestWeekly || len(got.Added) != 1 || len(got.Removed) != 1 || len(got.Findings) != 1 {
+		t.Errorf("Unexpected digest payload: %+v", got)
+	}
+
+	// The webhooks configured for digests do not receive the alerts of each cycle
+	got = Digest{}
+	if err := Notify(context.Background(), []Channel{hook}, &Alert{Assets: d.Added}); err != nil || got.Period != "" {
+		t.Errorf("Expected the alert to skip the digest webhook: %v %+v", err, got)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The modes of the connections to the SMTP server.
+const (
+	// TLSStartTLS upgrades the connection with the STARTTLS command, usually on port 587.
+	TLSStartTLS = "starttls"
+	// TLSImplicit establishes the TLS session before the SMTP conversation, usually on port 465.
+	TLSImplicit = "tls"
+	// TLSNone sends the messages without encryption, which is only suitable for local relays.
+	TLSNone = "none"
+)
+
+// Email is an SMTP server that the alerts and digests are sent to as HTML messages.
+type Email struct {
+	// Name identifies the email in the routing rules
+	Name     string
+	Host     string
+	Port     int
+	TLS      string
+	Username string
+	Password string
+	From     string
+	To       []string
+	// MinSeverity is the lowest severity of the findings sent, or all of them when empty
+	MinSeverity string
+	// Digest is the period of the digests sent instead of the alerts of each cycle, or empty for the alerts
+	Digest string
+	// Template renders the body of the messages, from the EmailData
+	Template *template.Template
+}
+
+// EmailData is provided to the template of the messages, with either the alert or the digest set.
+type EmailData struct {
+	Subject string
+	Alert   *Alert
+	Digest  *Digest
+}
+
+// NewEmail returns an Email that sends the messages through the SMTP server using STARTTLS and the default template.
+func NewEmail(host string, port int, from string, to []string) *Email {
+	return &Email{
+		Host:     host,
+		Port:     port,
+		TLS:      TLSStartTLS,
+		From:     from,
+		To:       to,
+		Template: DefaultEmailTemplate,
+	}
+}
+
+// ChannelName returns the name identifying the email in the routing rules.
+func (e *Email) ChannelName() string {
+	return e.Name
+}
+
+// DigestPeriod returns the period of the digests sent instead of the alerts, or empty for the alerts.
+func (e *Email) DigestPeriod() string {
+	return e.Digest
+}
+
+// Notify sends the alert, without the findings below the minimum severity of the email.
+func (e *Email) Notify(ctx context.Context, alert *Alert) error {
+	routed := *alert
+	routed.Findings = filterSeverity(alert.Findings, e.MinSeverity)
+	if routed.Empty() {
+		return nil
+	}
+
+	subject := fmt.Sprintf("Amass discovered %d new assets for %s", len(routed.Assets), strings.Join(routed.Domains, ", "))
+	return e.send(ctx, &EmailData{Subject: subject, Alert: &routed})
+}
+
+// NotifyDigest sends the digest, without the findings below the minimum severity of the email.
+func (e *Email) NotifyDigest(ctx context.Context, d *Digest) error {
+	routed := *d
+	routed.Findings = filterSeverity(d.Findings, e.MinSeverity)
+	if routed.Empty() {
+		return nil
+	}
+
+	subject := fmt.Sprintf("Amass %s digest for %s", d.Period, strings.Join(d.Domains, ", "))
+	return e.send(ctx, &EmailData{Subject: subject, Digest: &routed})
+}
+
+func (e *Email) send(ctx context.Context, data *EmailData) error {
+	msg, err := e.message(data, time.Now())
+	if err != nil {
+		return err
+	}
+
+	c, err := e.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the SMTP server %s: %v", e.Host, err)
+	}
+	defer c.Close()
+
+	if e.TLS == TLSStartTLS {
+		if err := c.StartTLS(&tls.Config{ServerName: e.Host}); err != nil {
+			return fmt.Errorf("the SMTP server %s failed to start TLS: %v", e.Host, err)
+		}
+	}
+	if e.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate with the SMTP server %s: %v", e.Host, err)
+		}
+	}
+
+	if err := c.Mail(e.From); err != nil {
+		return err
+	}
+	for _, rcpt := range e.To {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("the SMTP server %s rejected the recipient %s: %v", e.Host, rcpt, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (e *Email) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	d := &net.Dialer{Timeout: notifyTimeout}
+
+	var conn net.Conn
+	var err error
+	if e.TLS == TLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: d, Config: &tls.Config{ServerName: e.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The deadline keeps an unresponsive server from blocking the monitor
+	_ = conn.SetDeadline(time.Now().Add(notifyTimeout))
+
+	c, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// message returns the headers and the HTML body of the message.
+func (e *Email) message(data *EmailData, now time.Time) ([]byte, error) {
+	tmpl := e.Template
+	if tmpl == nil {
+		tmpl = DefaultEmailTemplate
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("failed to render the email template: %v", err)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", data.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.Write(bytes.ReplaceAll(body.Bytes(), []byte("\n"), []byte("\r\n")))
+	return b.Bytes(), nil
+}
+
+// ParseEmailTemplate returns the template of the messages read from the HTML file,
+// which is provided the EmailData.
+func ParseEmailTemplate(path string) (*template.Template, error) {
+	tmpl, err := template.New("email").Funcs(emailFuncs).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the email template %s: %v", path, err)
+	}
+	return tmpl.Lookup(filepath.Base(path)), nil
+}
+
+var emailFuncs = template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+}
+
+// DefaultEmailTemplate renders the assets and findings of the alerts and digests as tables.
+var DefaultEmailTemplate = template.Must(template.New("email").Funcs(emailFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Subject }}</title>
+</head>
+<body style="font-family: sans-serif; color: #222;">
+<h2>{{ .Subject }}</h2>
+{{ with .Alert }}<p>Cycle from {{ date .Started }} to {{ date .Finished }}</p>
+{{ if .Assets }}<h3>New Assets</h3>
+<table style="border-collapse: collapse;">
+{{ range .Assets }}<tr><td style="border: 1px solid #ccc; padding: 0.2em 0.6em;">{{ .Type }}</td><td style="border: 1px solid #ccc; padding: 0.2em 0.6em;">{{ .Key }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ template "findings" .Findings }}{{ end }}{{ with .Digest }}<p>From {{ date .Since }} to {{ date .Until }}</p>
+{{ if .Added }}<h3>New Assets</h3>
+<table style="border-collapse: collapse;">
+{{ range .Added }}<tr><td style="border: 1px solid #ccc; padding: 0.2em 0.6em;">{{ .Type }}</td><td style="border: 1px solid #ccc; padding: 0.2em 0.6em;">{{ .Key }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ if .Removed }}<h3>Removed Assets</h3>
+<table style="border-collapse: collapse;">
+{{ range .Removed }}<tr><td style="border: 1px solid #ccc; padding: 0.2em 0.6em;">{{ .Type }}</td><td style="border: 1px solid #ccc; padding: 0.2em 0.6em;">{{ .Key }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ template "findings" .Findings }}{{ end }}</body>
+</html>
+{{ define "findings" }}{{ if . }}<h3>Findings</h3>
+<table style="border-collapse: collapse;">
+{{ range . }}<tr><td style="border: 1px solid #ccc; padding: 0.2em 0.6em;">{{ .Severity }}</td><td style="border: 1px solid #ccc; padding: 0.2em 0.6em;">{{ .Title }}</td><td style="border: 1px solid #ccc; padding: 0.2em 0.6em;">{{ with index .Attributes "url" }}<a href="{{ . }}">{{ . }}</a>{{ end }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ end }}`))
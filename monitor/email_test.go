@@ -0,0 +1,177 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// smtpServer accepts a single message and returns the recipients and data received.
+func smtpServer(t *testing.T) (int, <-chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var received []string
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250-localhost")
+				reply("250 8BITMIME")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				received = append(received, strings.TrimSpace(line[8:]))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 Go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				received = append(received, data.String())
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				ch <- received
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+		ch <- received
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, ch
+}
+
+func TestEmailNotify(t *testing.T) {
+	port, ch := smtpServer(t)
+
+	e := NewEmail("127.0.0.1", port, "amass@example.com", []string{"soc@example.com", "ops@example.com"})
+	e.TLS = TLSNone
+	e.MinSeverity = findings.SeverityHigh
+
+	now := time.Now()
+	alert := &Alert{
+		Domains:  []string{"owasp.org"},
+		Started:  now,
+		Finished: now,
+		Assets: NewAssets([]*types.Asset{
+			{ID: "1", CreatedAt: now, LastSeen: now, Asset: domain.FQDN{Name: "vpn.owasp.org"}},
+		}, now),
+		Findings: []*findings.Finding{
+			{Severity: findings.SeverityCritical, Title: "<script>critical</script>"},
+			{Severity: findings.SeverityLow, Title: "routed away"},
+		},
+	}
+	if err := Notify(context.Background(), []Channel{e}, alert); err != nil {
+		t.Fatalf("Failed to send the email: %v", err)
+	}
+
+	var received []string
+	select {
+	case received = <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The SMTP server did not receive the message")
+	}
+	if len(received) != 3 || received[0] != "<soc@example.com>" || received[1] != "<ops@example.com>" {
+		t.Fatalf("Unexpected SMTP conversation: %v", received)
+	}
+
+	msg := received[2]
+	for _, s := range []string{
+		"Subject: Amass discovered 1 new assets for owasp.org",
+		"Content-Type: text/html; charset=UTF-8",
+		"vpn.owasp.org",
+		"&lt;script&gt;critical&lt;/script&gt;",
+	} {
+		if !strings.Contains(msg, s) {
+			t.Errorf("Expected the message to contain %q:\n%s", s, msg)
+		}
+	}
+	if strings.Contains(msg, "routed away") {
+		t.Error("Expected the finding below the minimum severity to be left out")
+	}
+}
+
+func TestParseEmails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "email.html")
+	if err := os.WriteFile(path, []byte(`<p>{{ .Subject }}</p>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := ParseSettings(map[string]interface{}{
+		"emails": []interface{}{
+			map[string]interface{}{"host": "smtp.example.com", "from": "amass@example.com", "to": "soc@example.com",
+				"username": "amass", "password": "secret", "digest": "daily", "template": path},
+			map[string]interface{}{"host": "smtp.example.com", "tls": "TLS", "from": "amass@example.com",
+				"to": []interface{}{"a@example.com", "b@example.com"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the settings: %v", err)
+	}
+	if len(s.Emails) != 2 || len(s.Channels()) != 2 {
+		t.Fatalf("Expected two emails, got %d", len(s.Emails))
+	}
+	if e := s.Emails[0]; e.Port != 587 || e.TLS != TLSStartTLS || e.Username != "amass" || e.Digest != DigestDaily {
+		t.Errorf("Unexpected email settings: %+v", e)
+	}
+	if e := s.Emails[1]; e.Port != 465 || e.TLS != TLSImplicit || len(e.To) != 2 {
+		t.Errorf("Unexpected email settings: %+v", e)
+	}
+	if d := s.Digests(); len(d) != 1 || d[0] != DigestDaily {
+		t.Errorf("Expected the daily digest, got %v", d)
+	}
+
+	msg, err := s.Emails[0].message(&EmailData{Subject: "digest"}, time.Now())
+	if err != nil || !strings.Contains(string(msg), "<p>digest</p>") {
+		t.Errorf("Expected the template to render the message: %v %s", err, msg)
+	}
+
+	for _, raw := range []interface{}{
+		map[string]interface{}{"emails": "soc@example.com"},
+		map[string]interface{}{"emails": []interface{}{map[string]interface{}{"from": "a@example.com", "to": "b@example.com"}}},
+		map[string]interface{}{"emails": []interface{}{map[string]interface{}{"host": "smtp", "from": "a@example.com"}}},
+		map[string]interface{}{"emails": []interface{}{map[string]interface{}{"host": "smtp", "from": "a", "to": "b", "tls": "ssl"}}},
+		map[string]interface{}{"emails": []interface{}{map[string]interface{}{"host": "smtp", "from": "a", "to": "b", "port": 70000}}},
+		map[string]interface{}{"emails": []interface{}{map[string]interface{}{"host": "smtp", "from": "a", "to": "b", "template": path + ".missing"}}},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for the settings %v", raw)
+		}
+	}
+}
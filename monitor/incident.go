@@ -0,0 +1,236 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// The endpoints of the incident management services.
+const (
+	PagerDutyURL  = "https://events.pagerduty.com/v2/enqueue"
+	OpsgenieURL   = "https://api.opsgenie.com/v2/alerts"
+	OpsgenieEUURL = "https://api.eu.opsgenie.com/v2/alerts"
+)
+
+// DedupKey returns the key identifying the incident of the finding, which is the same each time the
+// finding is observed, so a finding that disappears and appears again updates the open incident
+// instead of creating another one.
+func DedupKey(f *findings.Finding) string {
+	sum := sha256.Sum256([]byte(f.Type + "\x00" + f.Asset))
+	return "amass-" + f.Type + "-" + hex.EncodeToString(sum[:8])
+}
+
+// PagerDuty creates incidents for the findings using the Events API v2.
+type PagerDuty struct {
+	Name       string
+	URL        string
+	RoutingKey string
+	// MinSeverity is the lowest severity of the findings creating incidents
+	MinSeverity string
+	HTTP        *http.Client
+}
+
+// NewPagerDuty returns a PagerDuty that creates incidents for the critical findings
+// using the integration with the routing key.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{
+		URL:         PagerDutyURL,
+		RoutingKey:  routingKey,
+		MinSeverity: findings.SeverityCritical,
+		HTTP:        amasshttp.NewClient("pagerduty", &amasshttp.ClientOptions{Timeout: notifyTimeout}),
+	}
+}
+
+// ChannelName returns the name identifying the channel in the routing rules.
+func (p *PagerDuty) ChannelName() string {
+	return p.Name
+}
+
+// DigestPeriod returns empty, since the incidents are created as the findings are recorded.
+func (p *PagerDuty) DigestPeriod() string {
+	return ""
+}
+
+// NotifyDigest does nothing, since the incidents are created as the findings are recorded.
+func (p *PagerDuty) NotifyDigest(ctx context.Context, d *Digest) error {
+	return nil
+}
+
+type pdPayload struct {
+	Summary   string            `json:"summary"`
+	Source    string            `json:"source"`
+	Severity  string            `json:"severity"`
+	Timestamp string            `json:"timestamp,omitempty"`
+	Component string            `json:"component,omitempty"`
+	Class     string            `json:"class,omitempty"`
+	Details   map[string]string `json:"custom_details,omitempty"`
+}
+
+type pdEvent struct {
+	RoutingKey  string     `json:"routing_key"`
+	EventAction string     `json:"event_action"`
+	DedupKey    string     `json:"dedup_key"`
+	Payload     *pdPayload `json:"payload"`
+}
+
+// Notify triggers an incident for each of the findings that meet the minimum severity.
+func (p *PagerDuty) Notify(ctx context.Context, alert *Alert) error {
+	var msgs []string
+	for _, f := range filterSeverity(alert.Findings, p.MinSeverity) {
+		event := &pdEvent{
+			RoutingKey:  p.RoutingKey,
+			EventAction: "trigger",
+			DedupKey:    DedupKey(f),
+			Payload: &pdPayload{
+				Summary:   truncate(f.Title, 1024),
+				Source:    "amass",
+				Severity:  pagerDutySeverity(f.Severity),
+				Component: f.Asset,
+				Class:     f.Type,
+				Details:   incidentDetails(f),
+			},
+		}
+		if !f.Time.IsZero() {
+			event.Payload.Timestamp = f.Time.UTC().Format(time.RFC3339)
+		}
+
+		if err := postJSON(ctx, p.HTTP, "PagerDuty", p.URL, nil, event); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case findings.SeverityCritical:
+		return "critical"
+	case findings.SeverityHigh:
+		return "error"
+	case findings.SeverityMedium:
+		return "warning"
+	}
+	return "info"
+}
+
+// Opsgenie creates alerts for the findings using the Alert API.
+type Opsgenie struct {
+	Name   string
+	URL    string
+	APIKey string
+	// MinSeverity is the lowest severity of the findings creating alerts
+	MinSeverity string
+	HTTP        *http.Client
+}
+
+// NewOpsgenie returns an Opsgenie that creates alerts for the critical findings using the API key.
+func NewOpsgenie(apiKey string) *Opsgenie {
+	return &Opsgenie{
+		URL:         OpsgenieURL,
+		APIKey:      apiKey,
+		MinSeverity: findings.SeverityCritical,
+		HTTP:        amasshttp.NewClient("opsgenie", &amasshttp.ClientOptions{Timeout: notifyTimeout}),
+	}
+}
+
+// ChannelName returns the name identifying the channel in the routing rules.
+func (o *Opsgenie) ChannelName() string {
+	return o.Name
+}
+
+// DigestPeriod returns empty, since the alerts are created as the findings are recorded.
+func (o *Opsgenie) DigestPeriod() string {
+	return ""
+}
+
+// NotifyDigest does nothing, since the alerts are created as the findings are recorded.
+func (o *Opsgenie) NotifyDigest(ctx context.Context, d *Digest) error {
+	return nil
+}
+
+type ogAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// Notify creates an alert for each of the findings that meet the minimum severity.
+func (o *Opsgenie) Notify(ctx context.Context, alert *Alert) error {
+	headers := map[string]string{"Authorization": "GenieKey " + o.APIKey}
+
+	var msgs []string
+	for _, f := range filterSeverity(alert.Findings, o.MinSeverity) {
+		a := &ogAlert{
+			Message:     truncate(f.Title, 130),
+			Alias:       DedupKey(f),
+			Description: f.Details,
+			Priority:    opsgeniePriority(f.Severity),
+			Source:      "amass",
+			Entity:      f.Asset,
+			Tags:        []string{"amass", f.Type},
+			Details:     incidentDetails(f),
+		}
+
+		if err := postJSON(ctx, o.HTTP, "Opsgenie", o.URL, headers, a); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case findings.SeverityCritical:
+		return "P1"
+	case findings.SeverityHigh:
+		return "P2"
+	case findings.SeverityMedium:
+		return "P3"
+	case findings.SeverityLow:
+		return "P4"
+	}
+	return "P5"
+}
+
+// incidentDetails returns the attributes of the finding, along with its asset and type.
+func incidentDetails(f *findings.Finding) map[string]string {
+	details := map[string]string{
+		"asset":    f.Asset,
+		"type":     f.Type,
+		"severity": f.Severity,
+	}
+	for k, v := range f.Attributes {
+		details[k] = v
+	}
+	return details
+}
+
+func truncate(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
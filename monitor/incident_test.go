@@ -0,0 +1,110 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestIncidents(t *testing.T) {
+	var mu sync.Mutex
+	var events []*pdEvent
+	var alerts []*ogAlert
+	var auth string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.URL.Path == "/pagerduty" {
+			e := new(pdEvent)
+			_ = json.NewDecoder(req.Body).Decode(e)
+			events = append(events, e)
+		} else {
+			auth = req.Header.Get("Authorization")
+			a := new(ogAlert)
+			_ = json.NewDecoder(req.Body).Decode(a)
+			alerts = append(alerts, a)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s, err := ParseSettings(map[string]interface{}{
+		"pagerduty": []interface{}{map[string]interface{}{"routing_key": "R0UT1NG"}},
+		"opsgenie":  []interface{}{map[string]interface{}{"api_key": "genie", "region": "EU", "min_severity": "high"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the settings: %v", err)
+	}
+	if len(s.PagerDuty) != 1 || len(s.Opsgenie) != 1 || s.Opsgenie[0].URL != OpsgenieEUURL {
+		t.Fatalf("Unexpected incident settings: %+v", s)
+	}
+	s.PagerDuty[0].URL = srv.URL + "/pagerduty"
+	s.Opsgenie[0].URL = srv.URL + "/opsgenie"
+
+	takeover := &findings.Finding{
+		Time:       time.Now(),
+		Asset:      "shop.owasp.org",
+		Type:       "subdomain_takeover",
+		Severity:   findings.SeverityCritical,
+		Title:      "shop.owasp.org can be taken over",
+		Attributes: map[string]string{"service": "github"},
+	}
+	alert := &Alert{
+		Domains: []string{"owasp.org"},
+		Findings: []*findings.Finding{
+			takeover,
+			{Asset: "admin@owasp.org", Type: "leak_mention", Severity: findings.SeverityHigh, Title: "leak"},
+			{Asset: "owasp.org", Type: "dnssec", Severity: findings.SeverityMedium, Title: "dnssec"},
+		},
+	}
+	// The same finding observed during the next cycle must update the same incident
+	for i := 0; i < 2; i++ {
+		if err := s.Notify(context.Background(), alert); err != nil {
+			t.Fatalf("Failed to create the incidents: %v", err)
+		}
+	}
+
+	if len(events) != 2 || events[0].DedupKey != events[1].DedupKey || events[0].DedupKey != DedupKey(takeover) {
+		t.Fatalf("Expected the critical finding to trigger the same PagerDuty incident twice: %+v", events)
+	}
+	if e := events[0]; e.RoutingKey != "R0UT1NG" || e.EventAction != "trigger" || e.Payload.Severity != "critical" ||
+		e.Payload.Component != "shop.owasp.org" || e.Payload.Details["service"] != "github" {
+		t.Errorf("Unexpected PagerDuty event: %+v %+v", e, e.Payload)
+	}
+
+	if len(alerts) != 4 || auth != "GenieKey genie" {
+		t.Fatalf("Expected the critical and high findings to create Opsgenie alerts: %d %q", len(alerts), auth)
+	}
+	if a := alerts[0]; a.Alias != DedupKey(takeover) || a.Priority != "P1" || alerts[1].Priority != "P2" {
+		t.Errorf("Unexpected Opsgenie alert: %+v", a)
+	}
+
+	other := *takeover
+	other.Asset = "blog.owasp.org"
+	if DedupKey(&other) == DedupKey(takeover) {
+		t.Error("Expected the findings for different assets to have different dedup keys")
+	}
+
+	for _, raw := range []interface{}{
+		map[string]interface{}{"pagerduty": map[string]interface{}{"routing_key": "R"}},
+		map[string]interface{}{"pagerduty": []interface{}{map[string]interface{}{"name": "oncall"}}},
+		map[string]interface{}{"opsgenie": []interface{}{map[string]interface{}{"api_key": "k", "region": "apac"}}},
+		map[string]interface{}{"opsgenie": []interface{}{map[string]interface{}{"api_key": "k", "min_severity": "urgent"}}},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for the settings %v", raw)
+		}
+	}
+}
@@ -0,0 +1,490 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package monitor supports repeating the collection on a schedule and sending
+// notifications when assets that were not previously in the graph database appear.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/ctlog"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/leaks"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// AssetTypes are the types of assets reported by the monitor: names, IP ranges and registration records.
+var AssetTypes = []oam.AssetType{oam.FQDN, oam.Netblock, oam.ASN, oam.RIROrg}
+
+// Channel is a destination of the notifications, such as a webhook or an email address.
+type Channel interface {
+	// Notify sends the alert of a cycle of the collection
+	Notify(ctx context.Context, alert *Alert) error
+	// NotifyDigest sends the digest of a period
+	NotifyDigest(ctx context.Context, d *Digest) error
+	// DigestPeriod returns the period of the digests sent instead of the alerts, or empty for the alerts
+	DigestPeriod() string
+	// ChannelName returns the name identifying the channel in the routing rules, or empty when it has none
+	ChannelName() string
+}
+
+// Settings control how often the collection is repeated and where the notifications are sent.
+type Settings struct {
+	Interval  time.Duration
+	Webhooks  []*Webhook
+	Emails    []*Email
+	PagerDuty []*PagerDuty
+	Opsgenie  []*Opsgenie
+	// Routes select the assets and findings sent to the named channels
+	Routes []*Route
+	// Leaks are the sources watched for mentions of the root domains during each cycle
+	Leaks []leaks.Source
+	// BGP enables checking the routes of the netblocks for origin and upstream changes
+	BGP bool
+	// Expiration is the number of days before the expiration of the root domains when they are reported
+	Expiration int
+	// CTLogs are the Certificate Transparency logs read directly for the new certificates of the root domains
+	CTLogs []*ctlog.Log
+}
+
+// ParseSettings returns the Settings provided by the monitor option.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	s := new(Settings)
+	if raw == nil {
+		return s, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("monitor must provide the interval, webhooks and emails settings")
+	}
+
+	switch v := m["interval"].(type) {
+	case nil:
+	case int:
+		s.Interval = time.Duration(v) * time.Minute
+	case float64:
+		s.Interval = time.Duration(v * float64(time.Minute))
+	default:
+		return nil, fmt.Errorf("the monitor interval must be a number of minutes")
+	}
+	if s.Interval < 0 {
+		return nil, fmt.Errorf("the monitor interval cannot be negative")
+	}
+
+	if hooks, found := m["webhooks"]; found && hooks != nil {
+		list, ok := hooks.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the monitor webhooks must be a list")
+		}
+
+		for _, item := range list {
+			hm, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("each webhook must provide the url, format, token, min_severity and digest settings")
+			}
+
+			u, _ := hm["url"].(string)
+			if u == "" {
+				return nil, fmt.Errorf("the webhook url setting is required")
+			}
+
+			fmtName, _ := hm["format"].(string)
+			fmtName = strings.ToLower(fmtName)
+			if fmtName != "" && fmtName != FormatJSON && fmtName != FormatSlack {
+				return nil, fmt.Errorf("the webhook format must be %s or %s", FormatJSON, FormatSlack)
+			}
+
+			minSeverity, _ := hm["min_severity"].(string)
+			minSeverity = strings.ToLower(minSeverity)
+			if minSeverity != "" && findings.SeverityRank(minSeverity) == -1 {
+				return nil, fmt.Errorf("%s is not a valid webhook min_severity", minSeverity)
+			}
+
+			digest, _ := hm["digest"].(string)
+			digest = strings.ToLower(digest)
+			if digest != "" && DigestPeriod(digest) == 0 {
+				return nil, fmt.Errorf("the webhook digest must be %s or %s", DigestDaily, DigestWeekly)
+			}
+
+			token, _ := hm["token"].(string)
+			w := NewWebhook(u, token)
+			w.Name, _ = hm["name"].(string)
+			if fmtName != "" {
+				w.Format = fmtName
+			}
+			w.MinSeverity = minSeverity
+			w.Digest = digest
+			s.Webhooks = append(s.Webhooks, w)
+		}
+	}
+
+	if raw, found := m["emails"]; found && raw != nil {
+		emails, err := parseEmails(raw)
+		if err != nil {
+			return nil, err
+		}
+		s.Emails = emails
+	}
+
+	if raw, found := m["pagerduty"]; found && raw != nil {
+		list, err := parseIncidents(raw, "pagerduty", "routing_key")
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			p := NewPagerDuty(item.key)
+			p.Name = item.name
+			if item.minSeverity != "" {
+				p.MinSeverity = item.minSeverity
+			}
+			s.PagerDuty = append(s.PagerDuty, p)
+		}
+	}
+
+	if raw, found := m["opsgenie"]; found && raw != nil {
+		list, err := parseIncidents(raw, "opsgenie", "api_key")
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			o := NewOpsgenie(item.key)
+			o.Name = item.name
+			if item.minSeverity != "" {
+				o.MinSeverity = item.minSeverity
+			}
+			switch strings.ToLower(item.region) {
+			case "", "us":
+			case "eu":
+				o.URL = OpsgenieEUURL
+			default:
+				return nil, fmt.Errorf("the opsgenie region must be us or eu")
+			}
+			s.Opsgenie = append(s.Opsgenie, o)
+		}
+	}
+
+	if raw, found := m["routes"]; found && raw != nil {
+		routes, err := parseRoutes(raw, s.Channels())
+		if err != nil {
+			return nil, err
+		}
+		s.Routes = routes
+	}
+
+	switch v := m["bgp"].(type) {
+	case nil:
+	case bool:
+		s.BGP = v
+	default:
+		return nil, fmt.Errorf("the monitor bgp setting must be true or false")
+	}
+
+	switch v := m["expiration"].(type) {
+	case nil:
+	case int:
+		s.Expiration = v
+	default:
+		return nil, fmt.Errorf("the monitor expiration must be a number of days")
+	}
+	if s.Expiration < 0 {
+		return nil, fmt.Errorf("the monitor expiration cannot be negative")
+	}
+
+	if raw, found := m["leaks"]; found && raw != nil {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the monitor leaks must be a list of leak source names")
+		}
+
+		var names []string
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("the monitor leaks must be a list of leak source names")
+			}
+			names = append(names, name)
+		}
+
+		srcs, err := leaks.SourcesByName(names)
+		if err != nil {
+			return nil, err
+		}
+		s.Leaks = srcs
+	}
+
+	if raw, found := m["ct_logs"]; found && raw != nil {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the monitor ct_logs must be a list of CT log names or URLs")
+		}
+
+		var names []string
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("the monitor ct_logs must be a list of CT log names or URLs")
+			}
+			names = append(names, name)
+		}
+
+		logs, err := ctlog.LogsByName(names)
+		if err != nil {
+			return nil, err
+		}
+		s.CTLogs = logs
+	}
+	return s, nil
+}
+
+// parseEmails returns the Emails provided by the monitor emails setting.
+func parseEmails(raw interface{}) ([]*Email, error) {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the monitor emails must be a list")
+	}
+
+	var emails []*Email
+	for _, item := range list {
+		em, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("each email must provide the host, port, from and to settings")
+		}
+
+		host, _ := em["host"].(string)
+		from, _ := em["from"].(string)
+		if host == "" || from == "" {
+			return nil, fmt.Errorf("the email host and from settings are required")
+		}
+
+		var to []string
+		switch v := em["to"].(type) {
+		case string:
+			to = append(to, v)
+		case []interface{}:
+			for _, addr := range v {
+				if a, ok := addr.(string); ok && a != "" {
+					to = append(to, a)
+				}
+			}
+		}
+		if len(to) == 0 {
+			return nil, fmt.Errorf("the email to setting must provide at least one address")
+		}
+
+		mode, _ := em["tls"].(string)
+		mode = strings.ToLower(mode)
+		if mode == "" {
+			mode = TLSStartTLS
+		}
+		if mode != TLSStartTLS && mode != TLSImplicit && mode != TLSNone {
+			return nil, fmt.Errorf("the email tls setting must be %s, %s or %s", TLSStartTLS, TLSImplicit, TLSNone)
+		}
+
+		port := 587
+		if mode == TLSImplicit {
+			port = 465
+		}
+		switch v := em["port"].(type) {
+		case nil:
+		case int:
+			port = v
+		default:
+			return nil, fmt.Errorf("the email port must be a number")
+		}
+		if port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("%d is not a valid email port", port)
+		}
+
+		minSeverity, _ := em["min_severity"].(string)
+		minSeverity = strings.ToLower(minSeverity)
+		if minSeverity != "" && findings.SeverityRank(minSeverity) == -1 {
+			return nil, fmt.Errorf("%s is not a valid email min_severity", minSeverity)
+		}
+
+		digest, _ := em["digest"].(string)
+		digest = strings.ToLower(digest)
+		if digest != "" && DigestPeriod(digest) == 0 {
+			return nil, fmt.Errorf("the email digest must be %s or %s", DigestDaily, DigestWeekly)
+		}
+
+		e := NewEmail(host, port, from, to)
+		e.Name, _ = em["name"].(string)
+		e.TLS = mode
+		e.Username, _ = em["username"].(string)
+		e.Password, _ = em["password"].(string)
+		e.MinSeverity = minSeverity
+		e.Digest = digest
+		if path, _ := em["template"].(string); path != "" {
+			tmpl, err := ParseEmailTemplate(path)
+			if err != nil {
+				return nil, err
+			}
+			e.Template = tmpl
+		}
+		emails = append(emails, e)
+	}
+	return emails, nil
+}
+
+type incidentSettings struct {
+	name        string
+	key         string
+	region      string
+	minSeverity string
+}
+
+// parseIncidents returns the settings of the incident management integrations, which require the key setting.
+func parseIncidents(raw interface{}, service, key string) ([]*incidentSettings, error) {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the monitor %s setting must be a list", service)
+	}
+
+	var results []*incidentSettings
+	for _, item := range list {
+		im, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("each %s integration must provide the %s setting", service, key)
+		}
+
+		is := new(incidentSettings)
+		is.key, _ = im[key].(string)
+		if is.key == "" {
+			return nil, fmt.Errorf("the %s %s setting is required", service, key)
+		}
+		is.name, _ = im["name"].(string)
+		is.region, _ = im["region"].(string)
+
+		is.minSeverity, _ = im["min_severity"].(string)
+		is.minSeverity = strings.ToLower(is.minSeverity)
+		if is.minSeverity != "" && findings.SeverityRank(is.minSeverity) == -1 {
+			return nil, fmt.Errorf("%s is not a valid %s min_severity", is.minSeverity, service)
+		}
+		results = append(results, is)
+	}
+	return results, nil
+}
+
+// Channels returns the webhooks, emails and incident management integrations that the notifications are sent to.
+func (s *Settings) Channels() []Channel {
+	var channels []Channel
+	for _, w := range s.Webhooks {
+		channels = append(channels, w)
+	}
+	for _, e := range s.Emails {
+		channels = append(channels, e)
+	}
+	for _, p := range s.PagerDuty {
+		channels = append(channels, p)
+	}
+	for _, o := range s.Opsgenie {
+		channels = append(channels, o)
+	}
+	return channels
+}
+
+// Digests returns the periods of the digests sent to the channels.
+func (s *Settings) Digests() []string {
+	var periods []string
+	for _, period := range []string{DigestDaily, DigestWeekly} {
+		for _, c := range s.Channels() {
+			if c.DigestPeriod() == period {
+				periods = append(periods, period)
+				break
+			}
+		}
+	}
+	return periods
+}
+
+// Alert describes the assets that appeared during a cycle of the collection, and the
+// findings recorded during the cycle, such as the mentions found by the leak sources.
+type Alert struct {
+	Domains  []string              `json:"domains"`
+	Started  time.Time             `json:"started"`
+	Finished time.Time             `json:"finished"`
+	Assets   []*format.AssetRecord `json:"assets"`
+	Findings []*findings.Finding   `json:"findings,omitempty"`
+}
+
+// Empty returns true when the alert has no new assets or findings to report.
+func (a *Alert) Empty() bool {
+	return len(a.Assets) == 0 && len(a.Findings) == 0
+}
+
+// Summary returns a short description of the alert that is suitable for chat messages.
+func (a *Alert) Summary() string {
+	counts := make(map[string]int)
+	for _, rec := range a.Assets {
+		counts[rec.Type]++
+	}
+
+	var kinds []string
+	for t := range counts {
+		kinds = append(kinds, t)
+	}
+	sort.Strings(kinds)
+
+	var parts []string
+	for _, t := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[t], t))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Amass discovered %d new assets for %s", len(a.Assets), strings.Join(a.Domains, ", "))
+	if len(parts) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(parts, ", "))
+	}
+	for _, rec := range a.Assets {
+		fmt.Fprintf(&b, "\n%s (%s)", rec.Key, rec.Type)
+	}
+	if len(a.Findings) > 0 {
+		fmt.Fprintf(&b, "\n%d new findings:", len(a.Findings))
+	}
+	for _, f := range a.Findings {
+		fmt.Fprintf(&b, "\n[%s] %s", f.Severity, f.Title)
+		if u := f.Attributes["url"]; u != "" {
+			fmt.Fprintf(&b, " %s", u)
+		}
+	}
+	return b.String()
+}
+
+// NewAssets returns the records for the assets that were first seen after the provided time,
+// which are the assets that did not already exist in the graph database.
+func NewAssets(assets []*types.Asset, since time.Time) []*format.AssetRecord {
+	var results []*format.AssetRecord
+
+	for _, a := range assets {
+		if a == nil || a.Asset == nil || a.CreatedAt.Before(since) || !reported(a.Asset.AssetType()) {
+			continue
+		}
+		results = append(results, format.NewAssetRecord(a))
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Type != results[j].Type {
+			return results[i].Type < results[j].Type
+		}
+		return results[i].Key < results[j].Key
+	})
+	return results
+}
+
+func reported(atype oam.AssetType) bool {
+	for _, t := range AssetTypes {
+		if t == atype {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/ctlog"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings(map[string]interface{}{
+		"interval": 60,
+		"webhooks": []interface{}{
+			map[string]interface{}{"url": "https://hooks.example.com/a", "format": "Slack"},
+			map[string]interface{}{"url": "https://siem.example.com/amass", "token": "secret", "min_severity": "High"},
+			map[string]interface{}{"url": "https://hooks.example.com/b", "digest": "Weekly"},
+		},
+		"leaks":      []interface{}{"psbdmp"},
+		"bgp":        true,
+		"expiration": 30,
+		"ct_logs":    []interface{}{"oak2026h1", "https://ct.example.com/2026/"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the settings: %v", err)
+	}
+	if s.Interval != time.Hour {
+		t.Errorf("Expected an interval of one hour, got %s", s.Interval)
+	}
+	if len(s.Webhooks) != 3 || s.Webhooks[0].Format != FormatSlack ||
+		s.Webhooks[1].Format != FormatJSON || s.Webhooks[1].Token != "secret" || s.Webhooks[1].MinSeverity != findings.SeverityHigh {
+		t.Errorf("Unexpected webhooks: %+v", s.Webhooks)
+	}
+	if d := s.Digests(); len(d) != 1 || d[0] != DigestWeekly {
+		t.Errorf("Expected the weekly digest, got %v", d)
+	}
+	if !s.BGP {
+		t.Error("Expected the BGP monitoring to be enabled")
+	}
+	if len(s.CTLogs) != 2 || s.CTLogs[0].URL != ctlog.KnownLogs["oak2026h1"] || s.CTLogs[1].URL != "https://ct.example.com/2026/" {
+		t.Errorf("Unexpected CT logs: %+v", s.CTLogs)
+	}
+	if s.Expiration != 30 {
+		t.Errorf("Expected the expiration to be 30 days, got %d", s.Expiration)
+	}
+	if len(s.Leaks) != 1 || s.Leaks[0].Name() != "psbdmp" {
+		t.Errorf("Unexpected leak sources: %+v", s.Leaks)
+	}
+
+	for _, raw := range []interface{}{
+		"hourly",
+		map[string]interface{}{"interval": "60"},
+		map[string]interface{}{"webhooks": []interface{}{map[string]interface{}{"format": "json"}}},
+		map[string]interface{}{"webhooks": []interface{}{map[string]interface{}{"url": "https://a", "format": "xml"}}},
+		map[string]interface{}{"webhooks": []interface{}{map[string]interface{}{"url": "https://a", "min_severity": "urgent"}}},
+		map[string]interface{}{"webhooks": []interface{}{map[string]interface{}{"url": "https://a", "digest": "monthly"}}},
+		map[string]interface{}{"leaks": []interface{}{"pastebin"}},
+		map[string]interface{}{"bgp": "yes"},
+		map[string]interface{}{"expiration": "30d"},
+		map[string]interface{}{"expiration": -1},
+		map[string]interface{}{"ct_logs": []interface{}{"ct.example.com"}},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for the settings %v", raw)
+		}
+	}
+}
+
+func TestNewAssets(t *testing.T) {
+	start := time.Now()
+	before := start.Add(-time.Hour)
+	after := start.Add(time.Minute)
+
+	assets := []*types.Asset{
+		{ID: "1", CreatedAt: before, LastSeen: after, Asset: domain.FQDN{Name: "www.owasp.org"}},
+		{ID: "2", CreatedAt: after, LastSeen: after, Asset: domain.FQDN{Name: "vpn.owasp.org"}},
+		{ID: "3", CreatedAt: after, LastSeen: after, Asset: network.IPAddress{
+			Address: netip.MustParseAddr("192.0.2.1"),
+			Type:    "IPv4",
+		}},
+		{ID: "4", CreatedAt: after, LastSeen: after, Asset: network.Netblock{
+			Cidr: netip.MustParsePrefix("192.0.2.0/24"),
+			Type: "IPv4",
+		}},
+	}
+
+	got := NewAssets(assets, start)
+	if len(got) != 2 {
+		t.Fatalf("Expected two new assets, got %d", len(got))
+	}
+	if got[0].Key != "vpn.owasp.org" || got[1].Key != "192.0.2.0/24" {
+		t.Errorf("Unexpected new assets: %s and %s", got[0].Key, got[1].Key)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var slack map[string]string
+	var alert Alert
+	var auth string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var err error
+		if req.URL.Path == "/slack" {
+			err = json.NewDecoder(req.Body).Decode(&slack)
+		} else {
+			auth = req.Header.Get("Authorization")
+			err = json.NewDecoder(req.Body).Decode(&alert)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	hook := NewWebhook(srv.URL+"/slack", "")
+	hook.Format = FormatSlack
+	hooks := []Channel{hook, NewWebhook(srv.URL+"/json", "secret")}
+
+	now := time.Now()
+	a := &Alert{
+		Domains:  []string{"owasp.org"},
+		Started:  now,
+		Finished: now,
+		Assets: NewAssets([]*types.Asset{
+			{ID: "1", CreatedAt: now, LastSeen: now, Asset: domain.FQDN{Name: "vpn.owasp.org"}},
+		}, now),
+	}
+	if err := Notify(context.Background(), hooks, a); err != nil {
+		t.Fatalf("Failed to send the notifications: %v", err)
+	}
+
+	if !strings.Contains(slack["text"], "1 new assets for owasp.org") || !strings.Contains(slack["text"], "vpn.owasp.org") {
+		t.Errorf("Unexpected Slack message: %q", slack["text"])
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected the token to be sent, got %q", auth)
+	}
+	if len(alert.Assets) != 1 || alert.Assets[0].Key != "vpn.owasp.org" || len(alert.Assets[0].Asset) == 0 {
+		t.Errorf("Unexpected alert payload: %+v", alert)
+	}
+
+	slack = nil
+	leak := &Alert{
+		Domains: []string{"owasp.org"},
+		Findings: []*findings.Finding{{
+			Asset:      "admin@owasp.org",
+			Severity:   findings.SeverityHigh,
+			Title:      "admin@owasp.org was mentioned by psbdmp",
+			Attributes: map[string]string{"url": "https://pastebin.com/abc123"},
+		}},
+	}
+	if err := Notify(context.Background(), []Channel{hook}, leak); err != nil {
+		t.Fatalf("Failed to send the leak notification: %v", err)
+	}
+	if !strings.Contains(slack["text"], "[high] admin@owasp.org was mentioned by psbdmp https://pastebin.com/abc123") {
+		t.Errorf("Unexpected Slack message for the finding: %q", slack["text"])
+	}
+
+	// The finding is below the minimum severity of the webhook, so nothing is posted
+	slack = nil
+	hook.MinSeverity = findings.SeverityCritical
+	if err := Notify(context.Background(), []Channel{hook}, leak); err != nil || slack != nil {
+		t.Errorf("Expected the finding to be routed away from the webhook: %v %v", err, slack)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	if err := Notify(context.Background(), []Channel{NewWebhook(failing.URL, "")}, a); err == nil {
+		t.Error("Expected an error when the webhook fails")
+	}
+}
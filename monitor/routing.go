@@ -0,0 +1,315 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+)
+
+// Route sends the assets and findings it matches to the named channels. A route providing asset types only
+// matches assets, a route providing finding types or a minimum severity only matches findings, and a route
+// providing neither matches everything. A route providing collections only matches their members and the
+// findings about them.
+type Route struct {
+	Channels     []string
+	AssetTypes   []string
+	FindingTypes []string
+	MinSeverity  string
+	Collections  []string
+	// members and keys identify the assets of the collections, once provided by SetCollections
+	members map[string]struct{}
+	keys    map[string]struct{}
+}
+
+// MatchAsset returns true when the route selects the asset.
+func (r *Route) MatchAsset(rec *format.AssetRecord) bool {
+	if len(r.Collections) > 0 {
+		if _, found := r.members[collections.MemberOf(rec).ID()]; !found {
+			return false
+		}
+	}
+	if len(r.AssetTypes) == 0 {
+		return len(r.FindingTypes) == 0 && r.MinSeverity == ""
+	}
+	return containsFold(r.AssetTypes, rec.Type)
+}
+
+// MatchFinding returns true when the route selects the finding.
+func (r *Route) MatchFinding(f *findings.Finding) bool {
+	if len(r.AssetTypes) > 0 && len(r.FindingTypes) == 0 && r.MinSeverity == "" {
+		return false
+	}
+	if len(r.Collections) > 0 {
+		if _, found := r.keys[f.Asset]; !found {
+			return false
+		}
+	}
+	if len(r.FindingTypes) > 0 && !containsFold(r.FindingTypes, f.Type) {
+		return false
+	}
+	return findings.SeverityRank(f.Severity) >= findings.SeverityRank(r.MinSeverity)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRoutes returns the Routes provided by the monitor routes setting, which name the channels.
+func parseRoutes(raw interface{}, channels []Channel) ([]*Route, error) {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the monitor routes must be a list")
+	}
+
+	names := make(map[string]struct{})
+	for _, c := range channels {
+		name := c.ChannelName()
+		if name == "" {
+			continue
+		}
+		if _, found := names[name]; found {
+			return nil, fmt.Errorf("the channel name %s is used more than once", name)
+		}
+		names[name] = struct{}{}
+	}
+
+	var routes []*Route
+	for _, item := range list {
+		rm, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("each route must provide the channels, asset_types, finding_types, min_severity and collections settings")
+		}
+
+		r := &Route{
+			Channels:     stringList(rm["channels"]),
+			AssetTypes:   stringList(rm["asset_types"]),
+			FindingTypes: stringList(rm["finding_types"]),
+			Collections:  stringList(rm["collections"]),
+		}
+		if len(r.Channels) == 0 {
+			return nil, fmt.Errorf("each route must name at least one channel")
+		}
+		for _, name := range r.Channels {
+			if _, found := names[name]; !found {
+				return nil, fmt.Errorf("the route names the channel %s, which is not defined", name)
+			}
+		}
+		for _, atype := range r.AssetTypes {
+			if !containsFold(assetTypeNames(), atype) {
+				return nil, fmt.Errorf("%s is not an asset type reported by the monitor", atype)
+			}
+		}
+
+		r.MinSeverity, _ = rm["min_severity"].(string)
+		r.MinSeverity = strings.ToLower(r.MinSeverity)
+		if r.MinSeverity != "" && findings.SeverityRank(r.MinSeverity) == -1 {
+			return nil, fmt.Errorf("%s is not a valid route min_severity", r.MinSeverity)
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// SetCollections provides the members of the collections named by the routes, which are read from the
+// collections file on each cycle, so the changes to the collections are applied without a restart. The
+// routes naming collections that do not exist match nothing, and the missing collections are reported.
+func (s *Settings) SetCollections(store collections.Store) error {
+	var missing []string
+
+	for _, r := range s.Routes {
+		if len(r.Collections) == 0 {
+			continue
+		}
+
+		r.members = make(map[string]struct{})
+		r.keys = make(map[string]struct{})
+		for _, name := range r.Collections {
+			c, found := store[name]
+			if !found {
+				missing = append(missing, name)
+				continue
+			}
+			for id := range c.IDs() {
+				r.members[id] = struct{}{}
+			}
+			for key := range c.Keys() {
+				r.keys[key] = struct{}{}
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the routes name the %s collections, which do not exist", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func stringList(raw interface{}) []string {
+	switch v := raw.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func assetTypeNames() []string {
+	var names []string
+	for _, t := range AssetTypes {
+		names = append(names, string(t))
+	}
+	return names
+}
+
+// routesFor returns the routes naming the channel, or nil when the channel is not named by any
+// route, in which case the channel receives all the assets and findings.
+func routesFor(routes []*Route, c Channel) []*Route {
+	name := c.ChannelName()
+	if name == "" {
+		return nil
+	}
+
+	var results []*Route
+	for _, r := range routes {
+		for _, n := range r.Channels {
+			if n == name {
+				results = append(results, r)
+				break
+			}
+		}
+	}
+	return results
+}
+
+func routeAssets(routes []*Route, recs []*format.AssetRecord) []*format.AssetRecord {
+	var results []*format.AssetRecord
+	for _, rec := range recs {
+		for _, r := range routes {
+			if r.MatchAsset(rec) {
+				results = append(results, rec)
+				break
+			}
+		}
+	}
+	return results
+}
+
+func routeFindings(routes []*Route, fs []*findings.Finding) []*findings.Finding {
+	var results []*findings.Finding
+	for _, f := range fs {
+		for _, r := range routes {
+			if r.MatchFinding(f) {
+				results = append(results, f)
+				break
+			}
+		}
+	}
+	return results
+}
+
+// Notify sends the alert to each of the channels not configured for digests, and returns the errors that occurred.
+func Notify(ctx context.Context, channels []Channel, alert *Alert) error {
+	return notify(ctx, channels, nil, alert)
+}
+
+// NotifyDigest sends the digest to each of the channels configured for the period of the
+// digest, and returns the errors that occurred.
+func NotifyDigest(ctx context.Context, channels []Channel, d *Digest) error {
+	return notifyDigest(ctx, channels, nil, d)
+}
+
+// Notify sends each channel not configured for digests the assets and findings of the alert selected
+// by the routes naming the channel, and returns the errors that occurred.
+func (s *Settings) Notify(ctx context.Context, alert *Alert) error {
+	return notify(ctx, s.Channels(), s.Routes, alert)
+}
+
+// NotifyDigest sends each channel configured for the period of the digest the assets and findings
+// selected by the routes naming the channel, and returns the errors that occurred.
+func (s *Settings) NotifyDigest(ctx context.Context, d *Digest) error {
+	return notifyDigest(ctx, s.Channels(), s.Routes, d)
+}
+
+func notify(ctx context.Context, channels []Channel, routes []*Route, alert *Alert) error {
+	if alert.Empty() {
+		return nil
+	}
+
+	var msgs []string
+	for _, c := range channels {
+		if c.DigestPeriod() != "" {
+			continue
+		}
+
+		routed := alert
+		if rs := routesFor(routes, c); len(rs) > 0 {
+			a := *alert
+			a.Assets = routeAssets(rs, alert.Assets)
+			a.Findings = routeFindings(rs, alert.Findings)
+			routed = &a
+		}
+		if routed.Empty() {
+			continue
+		}
+		if err := c.Notify(ctx, routed); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+func notifyDigest(ctx context.Context, channels []Channel, routes []*Route, d *Digest) error {
+	if d.Empty() {
+		return nil
+	}
+
+	var msgs []string
+	for _, c := range channels {
+		if c.DigestPeriod() != d.Period {
+			continue
+		}
+
+		routed := d
+		if rs := routesFor(routes, c); len(rs) > 0 {
+			rd := *d
+			rd.Added = routeAssets(rs, d.Added)
+			rd.Removed = routeAssets(rs, d.Removed)
+			rd.Findings = routeFindings(rs, d.Findings)
+			routed = &rd
+		}
+		if routed.Empty() {
+			continue
+		}
+		if err := c.NotifyDigest(ctx, routed); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
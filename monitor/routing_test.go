@@ -0,0 +1,124 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+)
+
+func TestRoutes(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]*Alert)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var a Alert
+		_ = json.NewDecoder(req.Body).Decode(&a)
+		mu.Lock()
+		received[req.URL.Path] = &a
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s, err := ParseSettings(map[string]interface{}{
+		"webhooks": []interface{}{
+			map[string]interface{}{"name": "pager", "url": srv.URL + "/pager"},
+			map[string]interface{}{"name": "chat", "url": srv.URL + "/chat"},
+			map[string]interface{}{"url": srv.URL + "/all"},
+		},
+		"routes": []interface{}{
+			map[string]interface{}{"channels": "pager", "finding_types": []interface{}{"subdomain_takeover"}},
+			map[string]interface{}{"channels": []interface{}{"pager"}, "min_severity": "critical"},
+			map[string]interface{}{"channels": []interface{}{"chat"}, "asset_types": []interface{}{"fqdn"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the settings: %v", err)
+	}
+
+	now := time.Now()
+	alert := &Alert{
+		Domains:  []string{"owasp.org"},
+		Started:  now,
+		Finished: now,
+		Assets: []*format.AssetRecord{
+			{Type: "FQDN", Key: "vpn.owasp.org"},
+			{Type: "Netblock", Key: "192.0.2.0/24"},
+		},
+		Findings: []*findings.Finding{
+			{Type: "subdomain_takeover", Severity: findings.SeverityHigh, Title: "takeover"},
+			{Type: "leak_mention", Severity: findings.SeverityCritical, Title: "leak"},
+			{Type: "leak_mention", Severity: findings.SeverityMedium, Title: "mention"},
+		},
+	}
+	if err := s.Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Failed to send the notifications: %v", err)
+	}
+
+	if a := received["/pager"]; a == nil || len(a.Assets) != 0 || len(a.Findings) != 2 ||
+		a.Findings[0].Title != "takeover" || a.Findings[1].Title != "leak" {
+		t.Errorf("Unexpected alert routed to the pager: %+v", a)
+	}
+	if a := received["/chat"]; a == nil || len(a.Assets) != 1 || a.Assets[0].Key != "vpn.owasp.org" || len(a.Findings) != 0 {
+		t.Errorf("Unexpected alert routed to the chat: %+v", a)
+	}
+	if a := received["/all"]; a == nil || len(a.Assets) != 2 || len(a.Findings) != 3 {
+		t.Errorf("Expected the channel without routes to receive the complete alert: %+v", a)
+	}
+
+	cs := make(collections.Store)
+	c, _ := cs.Create("vpn", "", "")
+	vpn, _ := collections.ParseMember("vpn.owasp.org")
+	c.Add(vpn)
+
+	s, err = ParseSettings(map[string]interface{}{
+		"webhooks": []interface{}{map[string]interface{}{"name": "vpn", "url": srv.URL + "/vpn"}},
+		"routes":   []interface{}{map[string]interface{}{"channels": "vpn", "collections": "vpn"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the collection route: %v", err)
+	}
+	if err := s.SetCollections(cs); err != nil {
+		t.Fatalf("Failed to set the collections: %v", err)
+	}
+
+	alert.Findings[0].Asset = "vpn.owasp.org"
+	if err := s.Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Failed to send the notifications: %v", err)
+	}
+	if a := received["/vpn"]; a == nil || len(a.Assets) != 1 || a.Assets[0].Key != "vpn.owasp.org" ||
+		len(a.Findings) != 1 || a.Findings[0].Title != "takeover" {
+		t.Errorf("Unexpected alert routed by the collection: %+v", a)
+	}
+	if err := s.SetCollections(make(collections.Store)); err == nil {
+		t.Error("Expected an error for the missing collection")
+	}
+
+	for _, raw := range []interface{}{
+		map[string]interface{}{"routes": "pager"},
+		map[string]interface{}{"routes": []interface{}{map[string]interface{}{"asset_types": "FQDN"}}},
+		map[string]interface{}{"routes": []interface{}{map[string]interface{}{"channels": "missing"}}},
+		map[string]interface{}{
+			"webhooks": []interface{}{map[string]interface{}{"name": "chat", "url": "https://a"}},
+			"routes":   []interface{}{map[string]interface{}{"channels": "chat", "asset_types": "IPAddress"}},
+		},
+		map[string]interface{}{
+			"webhooks": []interface{}{map[string]interface{}{"name": "chat", "url": "https://a"}},
+			"routes":   []interface{}{map[string]interface{}{"channels": "chat", "min_severity": "urgent"}},
+		},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for the settings %v", raw)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/session"
+)
+
+// SessionFileName is the name of the file in the output directory that stores the progress of the monitoring session.
+const SessionFileName = "monitor_session.json"
+
+// sessionKind is the versioned format of the session file, which is documented by the session package.
+var sessionKind = &session.Kind{Name: "monitor", Version: 1}
+
+// Session is the progress of a monitoring session, kept so the session is resumed when the
+// engine is restarted or upgraded instead of starting over from the first cycle.
+type Session struct {
+	Domains []string  `json:"domains"`
+	Started time.Time `json:"started"`
+	// Cycle is the number of the last cycle started
+	Cycle        int       `json:"cycle"`
+	CycleStarted time.Time `json:"cycle_started"`
+	// CycleFinished is before CycleStarted when the last cycle was interrupted
+	CycleFinished time.Time `json:"cycle_finished"`
+}
+
+// ReadSession returns the session stored in the file, or nil when the file does not exist.
+func ReadSession(path string) (*Session, error) {
+	s := new(Session)
+	if found, err := sessionKind.Read(path, s); err != nil || !found {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write stores the session in the file.
+func (s *Session) Write(path string) error {
+	return sessionKind.Write(path, s)
+}
+
+// Resume returns the previous session when it monitored the same domains, or a new session otherwise.
+func Resume(prev *Session, domains []string, now time.Time) *Session {
+	if prev != nil && sameDomains(prev.Domains, domains) {
+		return prev
+	}
+	return &Session{
+		Domains: domains,
+		Started: now.UTC(),
+	}
+}
+
+// Interrupted returns true when the last cycle started was not finished.
+func (s *Session) Interrupted() bool {
+	return s.Cycle > 0 && s.CycleFinished.Before(s.CycleStarted)
+}
+
+// Wait returns the time remaining before the next cycle is due. An interrupted cycle is due at once.
+func (s *Session) Wait(interval time.Duration, now time.Time) time.Duration {
+	if s.Cycle == 0 || s.Interrupted() {
+		return 0
+	}
+	if wait := s.CycleFinished.Add(interval).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Begin starts the next cycle, or repeats the cycle that was interrupted, and returns its number.
+func (s *Session) Begin(domains []string, now time.Time) int {
+	if !s.Interrupted() {
+		s.Cycle++
+	}
+	s.Domains = domains
+	s.CycleStarted = now.UTC()
+	return s.Cycle
+}
+
+// Finish marks the current cycle as finished.
+func (s *Session) Finish(now time.Time) {
+	s.CycleFinished = now.UTC()
+}
+
+func sameDomains(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	normalize := func(domains []string) []string {
+		n := make([]string, len(domains))
+		for i, d := range domains {
+			n[i] = strings.ToLower(d)
+		}
+		sort.Strings(n)
+		return n
+	}
+	na, nb := normalize(a), normalize(b)
+	for i := range na {
+		if na[i] != nb[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SessionFileName)
+	if s, err := ReadSession(path); s != nil || err != nil {
+		t.Fatalf("Expected no session for the missing file, got %v %v", s, err)
+	}
+
+	now := time.Now()
+	domains := []string{"owasp.org", "example.com"}
+	s := Resume(nil, domains, now)
+	if wait := s.Wait(time.Hour, now); wait != 0 {
+		t.Errorf("Expected the first cycle to start at once, got %v", wait)
+	}
+	if cycle := s.Begin(domains, now); cycle != 1 {
+		t.Errorf("Expected the first cycle, got %d", cycle)
+	}
+	s.Finish(now.Add(10 * time.Minute))
+	if err := s.Write(path); err != nil {
+		t.Fatalf("Failed to write the session: %v", err)
+	}
+
+	// The engine is restarted with the same domains in a different order
+	prev, err := ReadSession(path)
+	if err != nil || prev == nil {
+		t.Fatalf("Failed to read the session: %v", err)
+	}
+	s = Resume(prev, []string{"example.com", "OWASP.org"}, now.Add(30*time.Minute))
+	if s.Cycle != 1 || !s.Started.Equal(now.UTC()) {
+		t.Errorf("The session was not resumed: %+v", s)
+	}
+	if wait := s.Wait(time.Hour, now.Add(30*time.Minute)); wait != 40*time.Minute {
+		t.Errorf("Expected the remainder of the interval, got %v", wait)
+	}
+
+	// The second cycle is interrupted, and repeated by the resumed session
+	if cycle := s.Begin(domains, now.Add(70*time.Minute)); cycle != 2 {
+		t.Errorf("Expected the second cycle, got %d", cycle)
+	}
+	if !s.Interrupted() || s.Wait(time.Hour, now.Add(75*time.Minute)) != 0 {
+		t.Error("Expected the interrupted cycle to be due at once")
+	}
+	if cycle := s.Begin(domains, now.Add(80*time.Minute)); cycle != 2 {
+		t.Errorf("Expected the interrupted cycle to be repeated, got %d", cycle)
+	}
+
+	if s = Resume(s, []string{"example.com"}, now); s.Cycle != 0 {
+		t.Errorf("Expected a new session for the different domains, got %+v", s)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// Formats of the notification payloads.
+const (
+	// FormatJSON posts the Alert, including the data of each new asset and finding.
+	FormatJSON = "json"
+	// FormatSlack posts the summary of the Alert as a Slack incoming webhook message.
+	FormatSlack = "slack"
+)
+
+const notifyTimeout = 30 * time.Second
+
+// Webhook is an HTTP endpoint that the alerts are posted to.
+type Webhook struct {
+	// Name identifies the webhook in the routing rules
+	Name   string
+	URL    string
+	Format string
+	Token  string
+	// MinSeverity is the lowest severity of the findings posted to the webhook, or all of them when empty
+	MinSeverity string
+	// Digest is the period of the digests posted instead of the alerts of each cycle, or empty for the alerts
+	Digest string
+	HTTP   *http.Client
+}
+
+// NewWebhook returns a Webhook that posts the alerts as JSON to the provided URL.
+func NewWebhook(u, token string) *Webhook {
+	return &Webhook{
+		URL:    u,
+		Format: FormatJSON,
+		Token:  token,
+		HTTP:   amasshttp.NewClient("webhook", &amasshttp.ClientOptions{Timeout: notifyTimeout}),
+	}
+}
+
+// Notify posts the alert to the webhook, without the findings below the minimum severity of the webhook.
+func (w *Webhook) Notify(ctx context.Context, alert *Alert) error {
+	if alert = w.route(alert); alert.Empty() {
+		return nil
+	}
+
+	var body interface{} = alert
+	if w.Format == FormatSlack {
+		body = map[string]string{"text": alert.Summary()}
+	}
+	return w.post(ctx, body)
+}
+
+// ChannelName returns the name identifying the webhook in the routing rules.
+func (w *Webhook) ChannelName() string {
+	return w.Name
+}
+
+// DigestPeriod returns the period of the digests posted instead of the alerts, or empty for the alerts.
+func (w *Webhook) DigestPeriod() string {
+	return w.Digest
+}
+
+// NotifyDigest posts the digest to the webhook, without the findings below the minimum severity of the webhook.
+func (w *Webhook) NotifyDigest(ctx context.Context, d *Digest) error {
+	routed := *d
+	routed.Findings = filterSeverity(d.Findings, w.MinSeverity)
+	if routed.Empty() {
+		return nil
+	}
+
+	var body interface{} = &routed
+	if w.Format == FormatSlack {
+		body = map[string]string{"text": routed.Summary()}
+	}
+	return w.post(ctx, body)
+}
+
+func (w *Webhook) post(ctx context.Context, body interface{}) error {
+	var headers map[string]string
+	if w.Token != "" {
+		headers = map[string]string{"Authorization": "Bearer " + w.Token}
+	}
+
+	return postJSON(ctx, w.HTTP, "the webhook "+w.URL, w.URL, headers, body)
+}
+
+// postJSON posts the body as JSON to the URL with the additional headers, and returns an
+// error naming the endpoint when it does not accept the body.
+func postJSON(ctx context.Context, client *http.Client, name, u string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", name, resp.StatusCode)
+	}
+	return nil
+}
+
+// route returns the alert with the findings that meet the minimum severity of the webhook.
+func (w *Webhook) route(alert *Alert) *Alert {
+	if findings.SeverityRank(w.MinSeverity) <= 0 {
+		return alert
+	}
+
+	routed := *alert
+	routed.Findings = filterSeverity(alert.Findings, w.MinSeverity)
+	return &routed
+}
+
+// filterSeverity returns the findings that meet the minimum severity, or all of them when it is empty.
+func filterSeverity(fs []*findings.Finding, min string) []*findings.Finding {
+	lowest := findings.SeverityRank(min)
+	if lowest <= 0 {
+		return fs
+	}
+
+	var results []*findings.Finding
+	for _, f := range fs {
+		if findings.SeverityRank(f.Severity) >= lowest {
+			results = append(results, f)
+		}
+	}
+	return results
+}
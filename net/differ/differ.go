@@ -0,0 +1,159 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package differ decides whether an HTTP response is meaningfully different from the default responses of a
+// server, such as the wildcard and catch-all responses, using the status, the redirect, a bucket of the body
+// length and a simhash of the body. The checks probing for hosts, tenants and resources share these decisions.
+package differ
+
+import (
+	"bytes"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/bits"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// MaxBodySize is the number of bytes of the response body included in the signature.
+const MaxBodySize = 1 << 20
+
+// MaxDistance is the number of the simhash bits that can differ between the bodies considered similar.
+const MaxDistance = 6
+
+// bucketGrowth is the factor between the lengths of the consecutive buckets, so the length changing
+// by a small fraction, such as tokens and timestamps in the body, remains within the neighboring bucket.
+const bucketGrowth = 1.25
+
+// Signature describes a response for the comparison with other responses.
+type Signature struct {
+	Status int
+	// Location is the redirect provided by the response, if any
+	Location string
+	Length   int
+	Bucket   int
+	SimHash  uint64
+}
+
+// NewSignature returns the signature of the response. The reflected strings, such as the host requested,
+// are removed from the body and the redirect, since servers commonly include them in the default responses.
+func NewSignature(status int, location string, body []byte, reflected ...string) *Signature {
+	body = bytes.ToLower(body)
+	location = strings.ToLower(location)
+
+	for _, r := range reflected {
+		if r = strings.ToLower(r); r != "" {
+			body = bytes.ReplaceAll(body, []byte(r), nil)
+			location = strings.ReplaceAll(location, r, "")
+		}
+	}
+
+	return &Signature{
+		Status:   status,
+		Location: location,
+		Length:   len(body),
+		Bucket:   bucket(len(body)),
+		SimHash:  simHash(body),
+	}
+}
+
+// FromResponse reads the body of the response and returns its signature.
+func FromResponse(resp *http.Response, reflected ...string) (*Signature, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	return NewSignature(resp.StatusCode, resp.Header.Get("Location"), body, reflected...), nil
+}
+
+// Differs returns true when the responses have another status or redirect, or when the bodies have
+// lengths in buckets that are not neighbors or simhashes differing by more than MaxDistance bits.
+func (s *Signature) Differs(o *Signature) bool {
+	if s.Status != o.Status || s.Location != o.Location {
+		return true
+	}
+
+	delta := s.Bucket - o.Bucket
+	if delta < 0 {
+		delta = -delta
+	}
+	return delta > 1 || bits.OnesCount64(s.SimHash^o.SimHash) > MaxDistance
+}
+
+// Baseline is the set of the default responses of a server.
+type Baseline struct {
+	Signatures []*Signature
+}
+
+// NewBaseline returns the Baseline built from the responses to the requests that no resource or host is
+// expected to satisfy. At least two responses should be provided, so unstable servers can be detected.
+func NewBaseline(sigs ...*Signature) *Baseline {
+	return &Baseline{Signatures: sigs}
+}
+
+// Stable returns true when the default responses do not differ from each other. The servers providing
+// another response to each request cannot reveal the resources or hosts by the differences.
+func (b *Baseline) Stable() bool {
+	if len(b.Signatures) == 0 {
+		return false
+	}
+
+	for _, s := range b.Signatures[1:] {
+		if s.Differs(b.Signatures[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Differs returns true when the response differs from all the default responses of the server.
+func (b *Baseline) Differs(s *Signature) bool {
+	for _, d := range b.Signatures {
+		if !s.Differs(d) {
+			return false
+		}
+	}
+	return len(b.Signatures) > 0
+}
+
+// bucket returns the index of the range containing the length, with each range larger than the previous.
+func bucket(length int) int {
+	return int(math.Log(float64(length)+1) / math.Log(bucketGrowth))
+}
+
+// simHash returns the 64-bit simhash of the words in the body, so the bodies sharing most of their
+// words have hashes that only differ by a few bits.
+func simHash(body []byte) uint64 {
+	words := strings.FieldsFunc(string(body), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	for _, w := range words {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(w))
+		sum := h.Sum64()
+
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var hash uint64
+	for i, w := range weights {
+		if w > 0 {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
@@ -0,0 +1,61 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package differ
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+const page = `<html><head><title>Example Domain</title></head><body><h1>Example Domain</h1>
+<p>This domain is for use in illustrative examples in documents. You may use this domain in literature
+without prior coordination or asking for permission.</p><p>Request %s served at %s</p></body></html>`
+
+func TestSignatureDiffers(t *testing.T) {
+	def := NewSignature(200, "", []byte(fmt.Sprintf(page, "a1b2c3", "www.owasp.org")), "www.owasp.org")
+
+	// The tokens changing between requests and the reflected host are not differences
+	same := NewSignature(200, "", []byte(fmt.Sprintf(page, "d4e5f6", "dev.owasp.org")), "dev.owasp.org")
+	if same.Differs(def) {
+		t.Errorf("Expected the responses to be similar: %d bits differ", bits.OnesCount64(same.SimHash^def.SimHash))
+	}
+
+	for _, s := range []*Signature{
+		NewSignature(404, "", []byte(fmt.Sprintf(page, "a1b2c3", "www.owasp.org")), "www.owasp.org"),
+		NewSignature(200, "https://sso.owasp.org/", []byte(fmt.Sprintf(page, "a1b2c3", "www.owasp.org")), "www.owasp.org"),
+		NewSignature(200, "", []byte("<html><title>Grafana</title>"+strings.Repeat("<div>dashboard panel metrics</div>", 30)+"</html>")),
+		NewSignature(200, "", []byte("<html>Welcome</html>")),
+	} {
+		if !s.Differs(def) {
+			t.Errorf("Expected the response to differ: %+v", s)
+		}
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	b := NewBaseline(
+		NewSignature(200, "", []byte(fmt.Sprintf(page, "a1b2c3", "x.invalid")), "x.invalid"),
+		NewSignature(200, "", []byte(fmt.Sprintf(page, "g7h8i9", "y.invalid")), "y.invalid"),
+	)
+	if !b.Stable() {
+		t.Error("Expected the baseline to be stable")
+	}
+	if b.Differs(NewSignature(200, "", []byte(fmt.Sprintf(page, "j1k2l3", "www.owasp.org")), "www.owasp.org")) {
+		t.Error("Expected the default response not to differ from the baseline")
+	}
+	if !b.Differs(NewSignature(302, "/login", nil)) {
+		t.Error("Expected the redirect to differ from the baseline")
+	}
+
+	unstable := NewBaseline(NewSignature(200, "", []byte("a")), NewSignature(500, "", []byte("a")))
+	if unstable.Stable() {
+		t.Error("Expected the baseline to be unstable")
+	}
+	if NewBaseline().Stable() || NewBaseline().Differs(NewSignature(200, "", nil)) {
+		t.Error("Expected the empty baseline to be unstable and never differ")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// ClientSubnetDisabled is the setting value that removes the EDNS Client Subnet option from queries.
+const ClientSubnetDisabled = "disabled"
+
+// ClientSubnets contains the EDNS Client Subnet values used for outgoing DNS queries.
+type ClientSubnets struct {
+	Disabled bool
+	Subnets  []*net.IPNet
+}
+
+// ParseClientSubnets parses the EDNS Client Subnet setting, which is either the ClientSubnetDisabled
+// value or one or more CIDRs. An empty setting returns nil, so the default option can be used.
+func ParseClientSubnets(values ...string) (*ClientSubnets, error) {
+	ecs := new(ClientSubnets)
+
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if strings.EqualFold(v, ClientSubnetDisabled) {
+			ecs.Disabled = true
+			continue
+		}
+
+		_, ipnet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid client subnet: %v", v, err)
+		}
+		ecs.Subnets = append(ecs.Subnets, ipnet)
+	}
+
+	if ecs.Disabled && len(ecs.Subnets) > 0 {
+		return nil, fmt.Errorf("the client subnet cannot be disabled and set at the same time")
+	}
+	if !ecs.Disabled && len(ecs.Subnets) == 0 {
+		return nil, nil
+	}
+	return ecs, nil
+}
+
+// QueryMsg returns a DNS query message with the EDNS options for the subnet at the provided index.
+func (c *ClientSubnets) QueryMsg(name string, qtype uint16, idx int) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+
+	opt := &dns.OPT{
+		Hdr: dns.RR_Header{
+			Name:   ".",
+			Rrtype: dns.TypeOPT,
+			Class:  dns.DefaultMsgSize,
+		},
+	}
+	if !c.Disabled && idx >= 0 && idx < len(c.Subnets) {
+		opt.Option = append(opt.Option, ClientSubnetOption(c.Subnets[idx]))
+	}
+
+	m.Extra = append(m.Extra, opt)
+	return m
+}
+
+// ClientSubnetOption returns the EDNS0 Client Subnet option for the provided subnet.
+func ClientSubnetOption(subnet *net.IPNet) *dns.EDNS0_SUBNET {
+	family := uint16(1)
+	addr := subnet.IP.To4()
+	if addr == nil {
+		family = 2
+		addr = subnet.IP.To16()
+	}
+
+	ones, _ := subnet.Mask.Size()
+	return &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        family,
+		SourceNetmask: uint8(ones),
+		SourceScope:   0,
+		Address:       addr,
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestParseClientSubnets(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		err      bool
+		disabled bool
+		subnets  int
+	}{
+		{"Empty setting", []string{""}, false, false, 0},
+		{"Disabled", []string{"disabled"}, false, true, 0},
+		{"Multiple subnets", []string{"198.51.100.0/24", "2001:db8::/56"}, false, false, 2},
+		{"Invalid subnet", []string{"198.51.100.0"}, true, false, 0},
+		{"Disabled and set", []string{"disabled", "198.51.100.0/24"}, true, false, 0},
+	}
+
+	for _, tt := range tests {
+		ecs, err := ParseClientSubnets(tt.values...)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: unexpected error value: %v", tt.name, err)
+			continue
+		}
+		if ecs == nil {
+			if tt.disabled || tt.subnets > 0 {
+				t.Errorf("%s: expected the client subnets to be returned", tt.name)
+			}
+			continue
+		}
+		if ecs.Disabled != tt.disabled || len(ecs.Subnets) != tt.subnets {
+			t.Errorf("%s: expected disabled %t and %d subnets, got %t and %d",
+				tt.name, tt.disabled, tt.subnets, ecs.Disabled, len(ecs.Subnets))
+		}
+	}
+}
+
+func TestClientSubnetsQueryMsg(t *testing.T) {
+	ecs, _ := ParseClientSubnets("198.51.100.0/24", "2001:db8::/56")
+
+	msg := ecs.QueryMsg("owasp.org", dns.TypeA, 1)
+	opt := msg.IsEdns0()
+	if opt == nil || len(opt.Option) != 1 {
+		t.Fatalf("Expected the query to include the client subnet option")
+	}
+
+	subnet, ok := opt.Option[0].(*dns.EDNS0_SUBNET)
+	if !ok || subnet.Family != 2 || subnet.SourceNetmask != 56 {
+		t.Errorf("The client subnet option was not setup correctly: %v", opt.Option[0])
+	}
+
+	disabled, _ := ParseClientSubnets(ClientSubnetDisabled)
+	if opt := disabled.QueryMsg("owasp.org", dns.TypeA, 0).IsEdns0(); opt == nil || len(opt.Option) != 0 {
+		t.Errorf("Expected the query to not include the client subnet option")
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResponseCache keeps the responses in a directory, so they persist across executions. Requests with
+// a TTL reuse the stored responses until they expire, without contacting the server. GET requests for
+// responses that provided validators (ETag or Last-Modified) are made conditional, and only transfer
+// the content when it has changed.
+type ResponseCache struct {
+	sync.Mutex
+	dir    string
+	shared CacheStore
+}
+
+// CacheStore keeps the cached responses outside of the process, so they are shared by the engine instances.
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration) error
+}
+
+// sharedRetention is how long the responses are kept by the CacheStore when the request TTL is shorter,
+// so the validators of the responses can still be used by the conditional requests.
+const sharedRetention = 7 * 24 * time.Hour
+
+type cachedResponse struct {
+	Stored       time.Time `json:"stored"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Response     Response  `json:"response"`
+}
+
+// NewResponseCache returns a ResponseCache that stores the responses in the directory.
+func NewResponseCache(dir string) (*ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &ResponseCache{dir: dir}, nil
+}
+
+// NewSharedResponseCache returns a ResponseCache that keeps the responses in the store.
+func NewSharedResponseCache(store CacheStore) *ResponseCache {
+	return &ResponseCache{shared: store}
+}
+
+// Fresh returns the stored response for the request when it was stored within the request TTL,
+// and nil otherwise.
+func (c *ResponseCache) Fresh(r *Request) *Response {
+	if r == nil || r.TTL <= 0 {
+		return nil
+	}
+
+	method := r.Method
+	if method == "" {
+		method = "GET"
+	}
+	return c.load(cacheKey(method, r.URL, r.Body)).fresh(r.TTL)
+}
+
+// cacheKey returns the key of the request, so equivalent URLs that only differ in the case of
+// the scheme and host, default ports, fragments or the order of the query parameters share it.
+func cacheKey(method, rawURL, body string) string {
+	key := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+			u.Host = u.Hostname()
+		}
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		u.RawQuery = u.Query().Encode()
+		u.Fragment = ""
+		key = u.String()
+	}
+	return method + " " + key + "\n" + body
+}
+
+func hashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.dir, hashKey(key)+".json")
+}
+
+func (c *ResponseCache) load(key string) *cachedResponse {
+	var data []byte
+	if c.shared != nil {
+		var found bool
+		if data, found = c.shared.Get("http:" + hashKey(key)); !found {
+			return nil
+		}
+	} else {
+		c.Lock()
+		defer c.Unlock()
+
+		var err error
+		if data, err = os.ReadFile(c.path(key)); err != nil {
+			return nil
+		}
+	}
+
+	var entry cachedResponse
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+func (c *ResponseCache) store(key string, resp *Response, ttl time.Duration) {
+	entry := &cachedResponse{
+		Stored:       time.Now(),
+		ETag:         resp.Header["Etag"],
+		LastModified: resp.Header["Last-Modified"],
+		Response:     *resp,
+	}
+	if ttl <= 0 && entry.ETag == "" && entry.LastModified == "" {
+		return
+	}
+	// The connection state cannot be reused by later responses
+	entry.Response.TLS = nil
+	entry.Response.NotModified = false
+	entry.Response.Cached = false
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	if c.shared != nil {
+		retain := sharedRetention
+		if ttl > retain {
+			retain = ttl
+		}
+		_ = c.shared.Set("http:"+hashKey(key), data, retain)
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	tmp := c.path(key) + ".part"
+	if err := os.WriteFile(tmp, data, 0644); err == nil {
+		_ = os.Rename(tmp, c.path(key))
+	}
+}
+
+// fresh returns the stored response when it has not expired.
+func (e *cachedResponse) fresh(ttl time.Duration) *Response {
+	if e == nil || ttl <= 0 || time.Since(e.Stored) > ttl {
+		return nil
+	}
+
+	cached := e.Response
+	cached.Cached = true
+	return &cached
+}
+
+// prepare adds the conditional headers to the request, when the cached response provided validators.
+func (c *ResponseCache) prepare(req *http.Request, entry *cachedResponse) {
+	if entry == nil {
+		return
+	}
+
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+}
+
+// update returns the cached response when the content has not been modified, and otherwise stores the new response.
+func (c *ResponseCache) update(key string, entry *cachedResponse, resp *Response, ttl time.Duration) *Response {
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		cached := entry.Response
+		cached.NotModified = true
+		// The content has been confirmed, so the TTL starts over
+		c.store(key, &entry.Response, ttl)
+		return &cached
+	}
+	if resp.StatusCode == http.StatusOK {
+		c.store(key, resp, ttl)
+	}
+	return resp
+}
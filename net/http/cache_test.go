@@ -0,0 +1,196 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompressedResponse(t *testing.T) {
+	content := "www.owasp.org mail.owasp.org"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			fmt.Fprint(w, content)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		fmt.Fprint(gz, content)
+		_ = gz.Close()
+	}))
+	defer ts.Close()
+
+	resp, err := RequestWebPage(context.TODO(), &Request{URL: ts.URL})
+	if err != nil || resp.Body != content {
+		t.Errorf("Failed to decode the compressed response: %v: %s", err, resp.Body)
+	}
+}
+
+func TestConditionalRequest(t *testing.T) {
+	etag := `"v1"`
+	content := "www.owasp.org mail.owasp.org"
+
+	var transfers int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		transfers++
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, content)
+	}))
+	defer ts.Close()
+
+	cache, err := NewResponseCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create the response cache: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		resp, err := RequestWebPage(context.TODO(), &Request{URL: ts.URL, Cache: cache})
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i+1, err)
+		}
+		if resp.StatusCode != http.StatusOK || resp.Body != content {
+			t.Errorf("Request %d: unexpected response: %d: %s", i+1, resp.StatusCode, resp.Body)
+		}
+		if resp.NotModified != (i > 0) {
+			t.Errorf("Request %d: expected NotModified to be %t", i+1, i > 0)
+		}
+	}
+	if transfers != 1 {
+		t.Errorf("Expected the content to be transferred once, but it was transferred %d times", transfers)
+	}
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	content := "www.owasp.org mail.owasp.org"
+
+	var transfers int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transfers++
+		fmt.Fprint(w, content)
+	}))
+	defer ts.Close()
+
+	cache, err := NewResponseCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create the response cache: %v", err)
+	}
+
+	urls := []string{ts.URL + "/api?domain=owasp.org&page=1", ts.URL + "/api?page=1&domain=owasp.org#results"}
+	for i, u := range urls {
+		resp, err := RequestWebPage(context.TODO(), &Request{URL: u, Cache: cache, TTL: time.Hour})
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i+1, err)
+		}
+		if resp.Body != content || resp.Cached != (i > 0) {
+			t.Errorf("Request %d: unexpected response: cached %t: %s", i+1, resp.Cached, resp.Body)
+		}
+	}
+	if transfers != 1 {
+		t.Errorf("Expected the server to be contacted once, but it was contacted %d times", transfers)
+	}
+	if resp := cache.Fresh(&Request{URL: urls[0], TTL: time.Hour}); resp == nil || resp.Body != content {
+		t.Errorf("Expected the stored response to be fresh")
+	}
+
+	// Expired responses and requests with a different body are not reused
+	if resp := cache.Fresh(&Request{URL: urls[0], TTL: time.Nanosecond}); resp != nil {
+		t.Errorf("Expected the stored response to be expired")
+	}
+	if _, err := RequestWebPage(context.TODO(), &Request{
+		URL:    urls[0],
+		Method: "POST",
+		Body:   "domain=owasp.org",
+		Cache:  cache,
+		TTL:    time.Hour,
+	}); err != nil || transfers != 2 {
+		t.Errorf("Expected the POST request to contact the server: %v", err)
+	}
+}
+
+type mapStore struct {
+	sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+}
+
+func (m *mapStore) Get(key string) ([]byte, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	v, found := m.values[key]
+	return v, found
+}
+
+func (m *mapStore) Set(key string, value []byte, ttl time.Duration) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.values[key] = value
+	m.ttls[key] = ttl
+	return nil
+}
+
+func TestSharedResponseCache(t *testing.T) {
+	var transfers int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transfers++
+		fmt.Fprint(w, "www.owasp.org")
+	}))
+	defer ts.Close()
+
+	store := &mapStore{values: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+	// Each engine instance has its own cache using the same store
+	for i, cache := range []*ResponseCache{NewSharedResponseCache(store), NewSharedResponseCache(store)} {
+		resp, err := RequestWebPage(context.TODO(), &Request{URL: ts.URL, Cache: cache, TTL: time.Hour})
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i+1, err)
+		}
+		if resp.Cached != (i > 0) {
+			t.Errorf("Request %d: unexpected response: cached %t", i+1, resp.Cached)
+		}
+	}
+	if transfers != 1 {
+		t.Errorf("Expected the server to be contacted once, but it was contacted %d times", transfers)
+	}
+	for key, ttl := range store.ttls {
+		if ttl != sharedRetention {
+			t.Errorf("The response %s is kept for %v", key, ttl)
+		}
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	equal := [][2]string{
+		{"HTTPS://API.Example.com:443/v1?b=2&a=1", "https://api.example.com/v1?a=1&b=2"},
+		{"http://example.com", "http://example.com:80/"},
+	}
+	for _, pair := range equal {
+		if cacheKey("GET", pair[0], "") != cacheKey("GET", pair[1], "") {
+			t.Errorf("Expected %s and %s to share the cache key", pair[0], pair[1])
+		}
+	}
+
+	if cacheKey("GET", "https://example.com/v1", "") == cacheKey("POST", "https://example.com/v1", "") {
+		t.Error("Expected the method to be part of the cache key")
+	}
+	if cacheKey("GET", "https://example.com/v1?a=1", "") == cacheKey("GET", "https://example.com/v1?a=2", "") {
+		t.Error("Expected the query parameters to be part of the cache key")
+	}
+}
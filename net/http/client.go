@@ -0,0 +1,245 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+)
+
+// DefaultClientTimeout is the time allowed for each request of the clients returned by NewClient,
+// when the ClientOptions do not provide the timeout.
+const DefaultClientTimeout = 30 * time.Second
+
+// ClientOptions adjust the clients returned by NewClient to the requests they are used for.
+type ClientOptions struct {
+	// Timeout is the time allowed for each request, including the transfer of the response body
+	Timeout time.Duration
+	// Dial makes the connections instead of amassnet.DialContext, such as to connect to a checked address.
+	// The proxies are not used, since the connections are made to the addresses chosen by Dial
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Insecure accepts any certificate chain, unless the TLS settings of the source are configured
+	Insecure bool
+	// NoRedirects provides the redirect responses instead of following them
+	NoRedirects bool
+	// NoKeepAlives closes the connection after each request
+	NoKeepAlives bool
+	// Cache makes the GET requests conditional on the responses stored in the response cache
+	Cache bool
+	// TTL is how long the cached responses are reused without contacting the server
+	TTL time.Duration
+	// Quota delays the requests while the API is overloaded or its quota is exhausted
+	Quota *QuotaManager
+}
+
+// clientSettings are the settings of the configuration applied by the clients returned by NewClient.
+type clientSettings struct {
+	sync.Mutex
+	proxies *amassnet.ProxySettings
+	tls     *amassnet.TLSOptions
+	cache   *ResponseCache
+	// cacheDir is where the response cache is created once a client requires it
+	cacheDir string
+}
+
+var settings clientSettings
+
+// Configure provides the values of the proxy and tls options of the configuration, and the response cache
+// settings, to the clients returned by NewClient. The responses are kept by the store when it is not nil,
+// so they are shared with the other engine instances, and in the output directory otherwise, once a client
+// uses the cache.
+func Configure(proxy, tlsOpt interface{}, outputDir string, store CacheStore) error {
+	proxies, err := amassnet.ParseProxySettings(proxy)
+	if err != nil {
+		return err
+	}
+	opts, err := amassnet.ParseTLSOptions(tlsOpt)
+	if err != nil {
+		return err
+	}
+
+	settings.Lock()
+	defer settings.Unlock()
+
+	settings.proxies = proxies
+	settings.tls = opts
+	settings.cache = nil
+	settings.cacheDir = ""
+	if outputDir != "" {
+		settings.cacheDir = filepath.Join(outputDir, "http_cache")
+	}
+	if store != nil {
+		settings.cache = NewSharedResponseCache(store)
+	}
+	return nil
+}
+
+// NewClient returns the HTTP client making the requests of the named data source or feature. The client
+// uses the proxy and the TLS settings configured for the source, dials through amassnet.DialContext, and
+// applies the response cache and the QuotaManager when the options request them.
+func NewClient(source string, opts *ClientOptions) *http.Client {
+	if opts == nil {
+		opts = new(ClientOptions)
+	}
+
+	settings.Lock()
+	proxy := settings.proxies.ForSource(source)
+	tlsSettings := settings.tls.ForSource(source)
+	if opts.Cache && settings.cache == nil && settings.cacheDir != "" {
+		if c, err := NewResponseCache(settings.cacheDir); err == nil {
+			settings.cache = c
+		}
+	}
+	cache := settings.cache
+	h3 := http3Enabled
+	settings.Unlock()
+
+	t := &http.Transport{
+		Proxy:                 amassnet.ProxyFunc,
+		DialContext:           amassnet.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 5 * time.Second,
+		DisableKeepAlives:     opts.NoKeepAlives,
+		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+	if proxy != nil {
+		t.Proxy = http.ProxyURL(proxy)
+	}
+	if opts.Dial != nil {
+		t.Proxy = nil
+		t.DialContext = opts.Dial
+	}
+	// The settings were validated when the configuration was loaded
+	if cfg, err := tlsSettings.Config(); err == nil && cfg != nil {
+		t.TLSClientConfig = cfg
+	} else if opts.Insecure {
+		t.TLSClientConfig.InsecureSkipVerify = true
+	}
+
+	var rt http.RoundTripper = t
+	// QUIC is not routed through the proxy of the source, nor dialed by the Dial option
+	if h3 && proxy == nil && opts.Dial == nil {
+		rt = newAltSvcTransport(t, newHTTP3Transport(t.TLSClientConfig))
+	}
+	if opts.Cache && cache != nil {
+		rt = &cacheTransport{next: rt, cache: cache, ttl: opts.TTL}
+	}
+	if opts.Quota != nil {
+		rt = &quotaTransport{next: rt, quota: opts.Quota}
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultClientTimeout
+	}
+
+	c := &http.Client{
+		Timeout:   timeout,
+		Transport: rt,
+	}
+	if opts.NoRedirects {
+		c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return c
+}
+
+// quotaTransport waits for the QuotaManager before each request, and provides it the responses.
+type quotaTransport struct {
+	next  http.RoundTripper
+	quota *QuotaManager
+}
+
+func (t *quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := t.quota.Acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		t.quota.Update(key, resp.StatusCode, HdrToAmassHeader(resp.Header))
+	}
+	return resp, err
+}
+
+// cacheTransport makes the GET requests conditional on the responses stored in the cache, and provides the
+// stored responses within the TTL without contacting the server.
+type cacheTransport struct {
+	next  http.RoundTripper
+	cache *ResponseCache
+	ttl   time.Duration
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	key := cacheKey(req.Method, req.URL.String(), "")
+	entry := t.cache.load(key)
+	if cached := entry.fresh(t.ttl); cached != nil {
+		return cachedHTTPResponse(req, cached), nil
+	}
+	// The request provided by the caller must not be modified
+	if entry != nil {
+		req = req.Clone(req.Context())
+		t.cache.prepare(req, entry)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified) {
+		return resp, err
+	}
+	if err := decodeBody(resp); err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+
+	aresp := t.cache.update(key, entry, RespToAmassResponse(resp), t.ttl)
+	if aresp.NotModified {
+		return cachedHTTPResponse(req, aresp), nil
+	}
+
+	resp.Body = io.NopCloser(strings.NewReader(aresp.Body))
+	resp.ContentLength = int64(len(aresp.Body))
+	resp.Header.Del("Content-Encoding")
+	return resp, nil
+}
+
+// cachedHTTPResponse returns the response stored in the cache as a response to the request.
+func cachedHTTPResponse(req *http.Request, cached *Response) *http.Response {
+	hdr := make(http.Header)
+	for k, v := range cached.Header {
+		hdr.Set(k, v)
+	}
+	hdr.Del("Content-Encoding")
+
+	body := []byte(cached.Body)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         cached.Proto,
+		ProtoMajor:    cached.ProtoMajor,
+		ProtoMinor:    cached.ProtoMinor,
+		Header:        hdr,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewClientCache(t *testing.T) {
+	etag := `"v1"`
+	content := "www.owasp.org mail.owasp.org"
+
+	var transfers int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		transfers++
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, content)
+	}))
+	defer ts.Close()
+
+	cache, err := NewResponseCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create the response cache: %v", err)
+	}
+	settings.Lock()
+	settings.cache = cache
+	settings.Unlock()
+	defer func() {
+		settings.Lock()
+		settings.cache = nil
+		settings.Unlock()
+	}()
+
+	client := NewClient("test", &ClientOptions{Cache: true})
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i+1, err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != content {
+			t.Errorf("Request %d: unexpected response: %d: %s", i+1, resp.StatusCode, body)
+		}
+	}
+	if transfers != 1 {
+		t.Errorf("Expected the content to be transferred once, but it was transferred %d times", transfers)
+	}
+
+	// The clients without the option do not use the cache
+	resp, err := NewClient("test", nil).Get(ts.URL)
+	if err != nil {
+		t.Fatalf("The request failed: %v", err)
+	}
+	resp.Body.Close()
+	if transfers != 2 {
+		t.Errorf("Expected the client without the cache to transfer the content")
+	}
+}
+
+func TestNewClientQuota(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	client := NewClient("test", &ClientOptions{Quota: NewQuotaManager()})
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("The request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Error("Expected the request to wait for the time requested by the API")
+	}
+}
+
+func TestNewClientOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://www.owasp.org/", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	var dialed bool
+	client := NewClient("test", &ClientOptions{
+		// The connections are made to the test server for any name
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialed = true
+			return (&net.Dialer{}).DialContext(ctx, network, ts.Listener.Addr().String())
+		},
+		NoRedirects: true,
+	})
+
+	resp, err := client.Get("http://www.owasp.org/")
+	if err != nil {
+		t.Fatalf("The request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if !dialed {
+		t.Error("The connection was not made by the Dial option")
+	}
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected the redirect response, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Decoder returns a reader that decompresses the response body for a content encoding.
+type Decoder func(r io.Reader) (io.ReadCloser, error)
+
+var (
+	decodersLock sync.RWMutex
+	decoders     = map[string]Decoder{
+		"gzip": func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		"deflate": func(r io.Reader) (io.ReadCloser, error) {
+			return flate.NewReader(r), nil
+		},
+	}
+)
+
+// RegisterDecoder adds support for a content encoding, such as br, to the requests made by the package.
+func RegisterDecoder(encoding string, d Decoder) {
+	decodersLock.Lock()
+	defer decodersLock.Unlock()
+
+	decoders[strings.ToLower(encoding)] = d
+}
+
+// acceptEncoding returns the value of the Accept-Encoding header listing the supported content encodings.
+func acceptEncoding() string {
+	decodersLock.RLock()
+	defer decodersLock.RUnlock()
+
+	var encodings []string
+	for e := range decoders {
+		encodings = append(encodings, e)
+	}
+	sort.Strings(encodings)
+	return strings.Join(encodings, ", ")
+}
+
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	var err error
+	// Close the decoders before the original body
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		if cerr := d.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// decodeBody replaces the response body with one that provides the decompressed content.
+func decodeBody(resp *http.Response) error {
+	ce := resp.Header.Get("Content-Encoding")
+	if ce == "" || resp.Body == nil {
+		return nil
+	}
+
+	body := &decodedBody{
+		Reader:  resp.Body,
+		closers: []io.Closer{resp.Body},
+	}
+	// The encodings are listed in the order they were applied, so they are removed in reverse
+	encodings := strings.Split(ce, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		e := strings.ToLower(strings.TrimSpace(encodings[i]))
+		if e == "" || e == "identity" {
+			continue
+		}
+
+		decodersLock.RLock()
+		d, found := decoders[e]
+		decodersLock.RUnlock()
+		if !found {
+			_ = body.Close()
+			return fmt.Errorf("unsupported content encoding: %s", e)
+		}
+
+		r, err := d(body.Reader)
+		if err != nil {
+			_ = body.Close()
+			return fmt.Errorf("failed to decode the %s content: %v", e, err)
+		}
+		body.Reader = r
+		body.closers = append(body.closers, r)
+	}
+
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
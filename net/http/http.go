@@ -63,6 +63,10 @@ type Request struct {
 	Header Header
 	Body   string
 	Auth   *BasicAuth
+	// Cache makes GET requests conditional on the responses previously stored in the cache
+	Cache *ResponseCache
+	// TTL is how long the responses stored in the cache are reused without contacting the server
+	TTL time.Duration
 }
 
 // Response represents the HTTP response in the Amass preferred format.
@@ -76,6 +80,10 @@ type Response struct {
 	Body       string
 	Length     int64
 	TLS        *tls.ConnectionState
+	// NotModified is true when the content was provided by the response cache
+	NotModified bool
+	// Cached is true when the response was stored within the TTL and the server was not contacted
+	Cached bool
 }
 
 // BasicAuth contains the data used for HTTP basic authentication.
@@ -89,7 +97,7 @@ func init() {
 	DefaultClient = &http.Client{
 		Timeout: httpTimeout,
 		Transport: &http.Transport{
-			Proxy:                 http.ProxyFromEnvironment,
+			Proxy:                 amassnet.ProxyFunc,
 			DialContext:           amassnet.DialContext,
 			MaxIdleConns:          200,
 			MaxConnsPerHost:       50,
@@ -196,6 +204,101 @@ func CheckCookie(urlString string, cookieName string) bool {
 
 // RequestWebPage returns the response headers, body, and status code for the provided URL when successful.
 func RequestWebPage(ctx context.Context, r *Request) (*Response, error) {
+	req, err := newRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var key string
+	var entry *cachedResponse
+	if r.Cache != nil {
+		key = cacheKey(req.Method, r.URL, r.Body)
+		entry = r.Cache.load(key)
+		if cached := entry.fresh(r.TTL); cached != nil {
+			return cached, nil
+		}
+		if req.Method == "GET" {
+			r.Cache.prepare(req, entry)
+		}
+	}
+
+	resp, err := clientFor(ctx).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeBody(resp); err != nil {
+		return nil, err
+	}
+
+	aresp := RespToAmassResponse(resp)
+	if r.Cache != nil && (req.Method == "GET" || r.TTL > 0) {
+		aresp = r.Cache.update(key, entry, aresp, r.TTL)
+	}
+	return aresp, nil
+}
+
+// StreamWebPage provides the response body to the callback as it is received, instead of reading it
+// into memory. The Body field of the Response provided to the callback is always empty, and the
+// response cache is not used, since the content is not held in memory.
+func StreamWebPage(ctx context.Context, r *Request, fn func(*Response, io.Reader) error) error {
+	req, err := newRequest(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	resp, err := clientFor(ctx).Do(req)
+	if err != nil {
+		return err
+	}
+	if err := decodeBody(resp); err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body := resp.Body
+	resp.Body = nil
+	return fn(RespToAmassResponse(resp), body)
+}
+
+// tlsClients holds the client of each TLS configuration provided by the request contexts, so the connections are reused.
+var tlsClients sync.Map
+
+// clientFor returns the client applying the TLS configuration provided by the context, or the DefaultClient.
+func clientFor(ctx context.Context) *http.Client {
+	cfg := amassnet.TLSFromContext(ctx)
+	if cfg == nil {
+		return DefaultClient
+	}
+	if c, found := tlsClients.Load(cfg); found {
+		return c.(*http.Client)
+	}
+
+	var t *http.Transport
+	switch dt := DefaultClient.Transport.(type) {
+	case *http.Transport:
+		t = dt.Clone()
+	case *altSvcTransport:
+		t = dt.tcp.Clone()
+	default:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	t.TLSClientConfig = cfg
+
+	var rt http.RoundTripper = t
+	// The servers are reached over HTTP/3 with the same TLS configuration when it is enabled
+	if _, ok := DefaultClient.Transport.(*altSvcTransport); ok {
+		rt = newAltSvcTransport(t, newHTTP3Transport(cfg))
+	}
+
+	c, _ := tlsClients.LoadOrStore(cfg, &http.Client{
+		Timeout:   DefaultClient.Timeout,
+		Transport: rt,
+		Jar:       DefaultClient.Jar,
+	})
+	return c.(*http.Client)
+}
+
+func newRequest(ctx context.Context, r *Request) (*http.Request, error) {
 	if r == nil {
 		return nil, errors.New("failed to provide a valid Amass HTTP request")
 	}
@@ -219,25 +322,34 @@ func RequestWebPage(ctx context.Context, r *Request) (*Response, error) {
 	req.Header.Set("User-Agent", UserAgent)
 	req.Header.Set("Accept", Accept)
 	req.Header.Set("Accept-Language", AcceptLang)
+	req.Header.Set("Accept-Encoding", acceptEncoding())
 	for k, v := range r.Header {
 		req.Header.Set(k, v)
 	}
-
-	resp, err := DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	return RespToAmassResponse(resp), nil
+	return req, nil
 }
 
+// DefaultCrawlRequests is the number of concurrent requests made by the crawler when the caller does not limit them.
+const DefaultCrawlRequests = 5
+
 // Crawl will spider the web page at the URL argument looking while staying within the scope provided.
 func Crawl(ctx context.Context, u string, scope []string, max int, callback func(*Request, *Response)) error {
+	return CrawlConcurrent(ctx, u, scope, max, DefaultCrawlRequests, callback)
+}
+
+// CrawlConcurrent performs the crawl of Crawl, limiting the requests made concurrently to the concurrent
+// argument. DefaultCrawlRequests is used when it is not positive.
+func CrawlConcurrent(ctx context.Context, u string, scope []string, max, concurrent int, callback func(*Request, *Response)) error {
 	select {
 	case <-ctx.Done():
 		return fmt.Errorf("the context expired")
 	default:
 	}
 
+	if concurrent <= 0 {
+		concurrent = DefaultCrawlRequests
+	}
+
 	var count int
 	var m sync.Mutex
 	filter := bf.NewDefaultStableBloomFilter(10000, 0.01)
@@ -253,7 +365,7 @@ func Crawl(ctx context.Context, u string, scope []string, max int, callback func
 		RobotsTxtDisabled:     true,
 		UserAgent:             UserAgent,
 		LogDisabled:           true,
-		ConcurrentRequests:    5,
+		ConcurrentRequests:    concurrent,
 		RequestDelay:          50 * time.Millisecond,
 		RequestDelayRandomize: true,
 		ParseFunc: func(g *geziyor.Geziyor, r *client.Response) {
@@ -312,7 +424,7 @@ func Crawl(ctx context.Context, u string, scope []string, max int, callback func
 		RetryTimes:     2,
 		RetryHTTPCodes: []int{408, 500, 502, 503, 504, 522, 524},
 	})
-	g.Client.Client = DefaultClient
+	g.Client.Client = clientFor(ctx)
 
 	g.Start()
 	return nil
@@ -0,0 +1,226 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+const (
+	// altSvcMaxAge is the freshness of the alternative services without the ma parameter (RFC 7838)
+	altSvcMaxAge = 24 * time.Hour
+	// h3BrokenTimeout is how long the requests to a server skip HTTP/3 after it failed
+	h3BrokenTimeout = 5 * time.Minute
+)
+
+// http3Enabled is set by EnableHTTP3, so the clients returned by NewClient also use HTTP/3.
+var http3Enabled bool
+
+// EnableHTTP3 sends the requests of the DefaultClient, and of the clients returned by NewClient, over HTTP/3
+// to the servers advertising it in the Alt-Svc header of previous responses. The requests are sent again
+// over HTTP/2, or HTTP/1.1 when the server does not negotiate it, when HTTP/3 fails.
+func EnableHTTP3() {
+	settings.Lock()
+	defer settings.Unlock()
+
+	http3Enabled = true
+	if t, ok := DefaultClient.Transport.(*http.Transport); ok {
+		DefaultClient.Transport = newAltSvcTransport(t, newHTTP3Transport(t.TLSClientConfig))
+	}
+}
+
+// newHTTP3Transport returns the round tripper sending the requests over QUIC.
+func newHTTP3Transport(cfg *tls.Config) http.RoundTripper {
+	return &http3.Transport{
+		TLSClientConfig: cfg,
+		QUICConfig:      &quic.Config{HandshakeIdleTimeout: handshakeTimeout},
+	}
+}
+
+// altSvcEntry tracks the HTTP/3 availability of a server.
+type altSvcEntry struct {
+	// expires is when the advertisement of HTTP/3 by the server is no longer fresh
+	expires time.Time
+	// broken is when HTTP/3 can be attempted again after a failure
+	broken time.Time
+}
+
+// altSvcTransport sends the requests to the servers that advertised HTTP/3 over the h3 round tripper,
+// and all other requests, along with the requests that failed over HTTP/3, over the TCP transport,
+// which attempts HTTP/2 even when it dials the connections itself.
+type altSvcTransport struct {
+	tcp *http.Transport
+	h3  http.RoundTripper
+	sync.Mutex
+	servers map[string]*altSvcEntry
+}
+
+func newAltSvcTransport(tcp *http.Transport, h3 http.RoundTripper) *altSvcTransport {
+	tcp = tcp.Clone()
+	tcp.ForceAttemptHTTP2 = true
+
+	return &altSvcTransport{
+		tcp:     tcp,
+		h3:      h3,
+		servers: make(map[string]*altSvcEntry),
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *altSvcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := originKey(req)
+
+	if key != "" && t.useHTTP3(req, key) {
+		resp, err := t.h3.RoundTrip(req)
+		if err == nil {
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		t.markBroken(key)
+
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+
+	resp, err := t.tcp.RoundTrip(req)
+	if err == nil && key != "" {
+		t.learn(key, resp.Header.Get("Alt-Svc"))
+	}
+	return resp, err
+}
+
+func (t *altSvcTransport) useHTTP3(req *http.Request, key string) bool {
+	// The request body cannot be sent again over TCP when HTTP/3 fails
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	// QUIC cannot be routed through the proxies or bound to the selected source addresses
+	if amassnet.LocalAddr != nil || amassnet.SourceAddrs != nil {
+		return false
+	}
+	if u, err := amassnet.ProxyFunc(req); err != nil || u != nil {
+		return false
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	e, found := t.servers[key]
+	if !found {
+		return false
+	}
+
+	now := time.Now()
+	if now.After(e.expires) {
+		delete(t.servers, key)
+		return false
+	}
+	return now.After(e.broken)
+}
+
+func (t *altSvcTransport) markBroken(key string) {
+	t.Lock()
+	defer t.Unlock()
+
+	if e, found := t.servers[key]; found {
+		e.broken = time.Now().Add(h3BrokenTimeout)
+	}
+}
+
+// learn records the HTTP/3 availability advertised by the Alt-Svc header of the server.
+func (t *altSvcTransport) learn(key, altsvc string) {
+	if altsvc == "" {
+		return
+	}
+
+	_, port, _ := net.SplitHostPort(key)
+	cleared, maxAge, found := parseAltSvc(altsvc, port)
+
+	t.Lock()
+	defer t.Unlock()
+
+	if cleared {
+		delete(t.servers, key)
+		return
+	}
+	if !found {
+		return
+	}
+
+	e, ok := t.servers[key]
+	if !ok {
+		e = new(altSvcEntry)
+		t.servers[key] = e
+	}
+	e.expires = time.Now().Add(maxAge)
+}
+
+// originKey returns the host and port of the HTTPS server receiving the request, or an empty string.
+func originKey(req *http.Request) string {
+	if req.URL == nil || !strings.EqualFold(req.URL.Scheme, "https") {
+		return ""
+	}
+
+	port := req.URL.Port()
+	if port == "" {
+		port = "443"
+	}
+	return net.JoinHostPort(strings.ToLower(req.URL.Hostname()), port)
+}
+
+// parseAltSvc returns whether the Alt-Svc header value clears the alternative services, and the freshness of the
+// h3 alternative offered on the port of the origin. Alternatives on other hosts or ports are not used.
+func parseAltSvc(value, port string) (cleared bool, maxAge time.Duration, found bool) {
+	if strings.TrimSpace(value) == "clear" {
+		return true, 0, false
+	}
+
+	for _, alt := range strings.Split(value, ",") {
+		params := strings.Split(alt, ";")
+
+		proto, authority, ok := strings.Cut(strings.TrimSpace(params[0]), "=")
+		if !ok || proto != "h3" {
+			continue
+		}
+
+		host, p, err := net.SplitHostPort(strings.Trim(authority, `"`))
+		if err != nil || host != "" || p != port {
+			continue
+		}
+
+		maxAge = altSvcMaxAge
+		for _, param := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(k) != "ma" {
+				continue
+			}
+			if secs, err := strconv.Atoi(strings.Trim(v, `"`)); err == nil && secs >= 0 {
+				maxAge = time.Duration(secs) * time.Second
+			}
+		}
+		if maxAge > 0 {
+			return false, maxAge, true
+		}
+	}
+	return false, 0, false
+}
@@ -0,0 +1,159 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestParseAltSvc(t *testing.T) {
+	tests := []struct {
+		value   string
+		cleared bool
+		maxAge  time.Duration
+		found   bool
+	}{
+		{value: `h3=":443"; ma=86400, h3-29=":443"; ma=86400`, maxAge: 24 * time.Hour, found: true},
+		{value: `h2=":443", h3=":443";ma=60`, maxAge: time.Minute, found: true},
+		{value: `h3=":443"`, maxAge: altSvcMaxAge, found: true},
+		{value: `h3=":8443"; ma=60`},
+		{value: `h3="alt.example.com:443"; ma=60`},
+		{value: `h3=":443"; ma=0`},
+		{value: `h3-29=":443"; ma=60`},
+		{value: `clear`, cleared: true},
+	}
+
+	for _, test := range tests {
+		cleared, maxAge, found := parseAltSvc(test.value, "443")
+		if cleared != test.cleared || maxAge != test.maxAge || found != test.found {
+			t.Errorf("%s: expected (%t, %v, %t), got (%t, %v, %t)", test.value,
+				test.cleared, test.maxAge, test.found, cleared, maxAge, found)
+		}
+	}
+}
+
+type fakeH3 struct {
+	requests int
+	err      error
+}
+
+func (f *fakeH3) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.requests++
+	if f.err != nil {
+		if req.Body != nil {
+			_, _ = io.ReadAll(req.Body)
+		}
+		return nil, f.err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/3.0",
+		ProtoMajor: 3,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("h3")),
+		Request:    req,
+	}, nil
+}
+
+func TestAltSvcTransport(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		_, port, _ := strings.Cut(r.Host, ":")
+		w.Header().Set("Alt-Svc", `h3=":`+port+`"; ma=3600`)
+		_, _ = w.Write([]byte("tcp"))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	// The connections are dialed by the transport, like amassnet.DialContext, which disables HTTP/2 by default
+	tcp := &http.Transport{
+		DialContext:     (&net.Dialer{}).DialContext,
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}
+
+	h3 := new(fakeH3)
+	client := &http.Client{Transport: newAltSvcTransport(tcp, h3)}
+
+	get := func() (string, int) {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("The request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		b, _ := io.ReadAll(resp.Body)
+		return string(b), resp.ProtoMajor
+	}
+
+	if body, major := get(); body != "tcp" || major != 2 || h3.requests != 0 {
+		t.Errorf("The first request was not sent over HTTP/2: %s, HTTP/%d", body, major)
+	}
+	if body, _ := get(); body != "h3" || h3.requests != 1 {
+		t.Errorf("The request to the server advertising HTTP/3 was not sent over HTTP/3: %s", body)
+	}
+
+	h3.err = errors.New("the QUIC handshake failed")
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("The request did not fall back to HTTP/2: %v", err)
+	}
+	resp.Body.Close()
+	if h3.requests != 2 || resp.ProtoMajor != 2 || bodies[len(bodies)-1] != "payload" {
+		t.Errorf("The request body was not sent again over HTTP/2: %v", bodies)
+	}
+
+	h3.err = nil
+	if body, _ := get(); body != "tcp" || h3.requests != 2 {
+		t.Errorf("HTTP/3 was attempted again after the failure: %s", body)
+	}
+}
+
+func TestHTTP3Transport(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+
+	srv := httptest.NewTLSServer(handler)
+	defer srv.Close()
+	// The QUIC server listens on the UDP port matching the TCP port of the server advertising it
+	conn, err := net.ListenPacket("udp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Skipf("Failed to listen on the UDP port of the server: %v", err)
+	}
+	h3srv := &http3.Server{Handler: handler, TLSConfig: http3.ConfigureTLSConfig(srv.TLS)}
+	go func() { _ = h3srv.Serve(conn) }()
+	defer h3srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	cfg := &tls.Config{RootCAs: pool}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := newHTTP3Transport(cfg).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("The request over QUIC failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if b, _ := io.ReadAll(resp.Body); string(b) != "HTTP/3.0" {
+		t.Errorf("The request was not received over HTTP/3: %s", b)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrStopStream can be returned by the StreamJSON callback to stop processing the array without an error.
+var ErrStopStream = errors.New("stop the JSON stream")
+
+// StreamJSON decodes the elements of a JSON array one at a time, so large responses can be processed
+// with bounded memory. The path provides the dot-separated object keys leading to the array,
+// and an empty path selects an array at the top level of the document.
+func StreamJSON(r io.Reader, path string, fn func(json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			if err := findKey(dec, key); err != nil {
+				return err
+			}
+		}
+	}
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		var element json.RawMessage
+
+		if err := dec.Decode(&element); err != nil {
+			return err
+		}
+		if err := fn(element); err != nil {
+			if errors.Is(err, ErrStopStream) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// findKey advances the decoder to the value of the key within the next object.
+func findKey(dec *json.Decoder, key string) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if k, ok := t.(string); ok && k == key {
+			return nil
+		}
+		if err := skipValue(dec); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("the JSON key %s was not found", key)
+}
+
+func expectDelim(dec *json.Decoder, d json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := t.(json.Delim); !ok || delim != d {
+		return fmt.Errorf("expected the JSON delimiter %s, but found %v", d, t)
+	}
+	return nil
+}
+
+// skipValue consumes the next value without holding it in memory.
+func skipValue(dec *json.Decoder) error {
+	var depth int
+
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if d, ok := t.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
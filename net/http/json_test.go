@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStreamJSON(t *testing.T) {
+	doc := `{"meta": {"skip": [1, {"a": [2, 3]}], "count": 2}, "data": {"subdomains": [{"name": "www.owasp.org"}, {"name": "mail.owasp.org"}]}}`
+
+	tests := []struct {
+		doc      string
+		path     string
+		expected []string
+		err      bool
+	}{
+		{doc: doc, path: "data.subdomains", expected: []string{`{"name": "www.owasp.org"}`, `{"name": "mail.owasp.org"}`}},
+		{doc: `["www.owasp.org", "mail.owasp.org"]`, expected: []string{`"www.owasp.org"`, `"mail.owasp.org"`}},
+		{doc: doc, path: "data.missing", err: true},
+		{doc: doc, path: "meta.count", err: true},
+		{doc: `[1, 2`, err: true, expected: []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		err := StreamJSON(strings.NewReader(tt.doc), tt.path, func(element json.RawMessage) error {
+			got = append(got, string(element))
+			return nil
+		})
+		if tt.err != (err != nil) {
+			t.Errorf("%s: unexpected error result: %v", tt.path, err)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, got)
+		}
+	}
+
+	var count int
+	if err := StreamJSON(strings.NewReader(`[1, 2, 3]`), "", func(element json.RawMessage) error {
+		count++
+		return ErrStopStream
+	}); err != nil || count != 1 {
+		t.Errorf("Failed to stop the stream after the first element: %v", err)
+	}
+}
+
+func TestStreamWebPage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"results": ["www.owasp.org", "mail.owasp.org"]}`)
+	}))
+	defer ts.Close()
+
+	var names []string
+	err := StreamWebPage(context.TODO(), &Request{URL: ts.URL}, func(resp *Response, body io.Reader) error {
+		if resp.StatusCode != http.StatusOK || resp.Body != "" {
+			t.Errorf("Unexpected response: %d: %s", resp.StatusCode, resp.Body)
+		}
+
+		return StreamJSON(body, "results", func(element json.RawMessage) error {
+			var name string
+			if err := json.Unmarshal(element, &name); err != nil {
+				return err
+			}
+			names = append(names, name)
+			return nil
+		})
+	})
+	if err != nil || len(names) != 2 {
+		t.Errorf("Failed to stream the response: %v: %v", err, names)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// MinBackoff is the first delay of the requests once the API responds that it is overloaded
+	MinBackoff = time.Second
+	// MaxBackoff is the longest delay of the requests, which doubles with each failure
+	MaxBackoff = 5 * time.Minute
+)
+
+// QuotaManager tracks the requests made with each API key of a data source, rotates to the
+// next key when the quota of the current key is exhausted, and backs off exponentially when
+// the API responds that it is overloaded. The clients returned by NewClient apply it to each
+// request when provided.
+type QuotaManager struct {
+	sync.Mutex
+	keys    []*APIKey
+	backoff time.Duration
+	until   time.Time
+}
+
+// Credentials are the values of an API key managed by the QuotaManager.
+type Credentials struct {
+	Name     string
+	Username string
+	Password string
+	Apikey   string
+	Secret   string
+}
+
+// APIKey is an API key of the data source managed by the QuotaManager.
+type APIKey struct {
+	Creds     *Credentials
+	requests  int
+	remaining string
+	disabled  bool
+	exhausted time.Time
+}
+
+// NewQuotaManager returns the QuotaManager managing the API keys of a data source, which are always
+// tried in the order of their names. Without the keys, the QuotaManager only backs off the requests.
+func NewQuotaManager(keys ...*Credentials) *QuotaManager {
+	q := new(QuotaManager)
+
+	for _, creds := range keys {
+		if creds != nil {
+			q.keys = append(q.keys, &APIKey{Creds: creds})
+		}
+	}
+	sort.Slice(q.keys, func(i, j int) bool {
+		return q.keys[i].Creds.Name < q.keys[j].Creds.Name
+	})
+	return q
+}
+
+// Credentials returns the credentials of the API key in use, or nil when all the keys are exhausted.
+func (q *QuotaManager) Credentials() *Credentials {
+	q.Lock()
+	defer q.Unlock()
+
+	if k := q.active(); k != nil {
+		return k.Creds
+	}
+	return nil
+}
+
+func (q *QuotaManager) active() *APIKey {
+	now := time.Now()
+
+	for _, k := range q.keys {
+		if !k.disabled && now.After(k.exhausted) {
+			return k
+		}
+	}
+	return nil
+}
+
+// Acquire blocks until the API can be sent another request, and returns the API key in use.
+func (q *QuotaManager) Acquire(ctx context.Context) (*APIKey, error) {
+	q.Lock()
+	delay := time.Until(q.until)
+	q.Unlock()
+
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	k := q.active()
+	if k != nil {
+		k.requests++
+	}
+	return k, nil
+}
+
+// Update adjusts the state of the API key and the backoff using the response to the request.
+// A message describing the change is returned when the API key can no longer be used.
+func (q *QuotaManager) Update(k *APIKey, status int, hdr Header) string {
+	q.Lock()
+	defer q.Unlock()
+
+	if k != nil {
+		if r := rateLimitRemaining(hdr); r != "" {
+			k.remaining = r
+		}
+	}
+
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusPaymentRequired:
+		wait := retryAfter(hdr)
+		if wait <= 0 {
+			wait = q.increaseBackoff()
+		}
+		if k == nil || len(q.keys) <= 1 {
+			q.until = time.Now().Add(wait)
+			return ""
+		}
+
+		k.exhausted = time.Now().Add(wait)
+		return q.rotated(k, fmt.Sprintf("exhausted its quota for %s", wait.Round(time.Second)))
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		if k == nil || len(q.keys) <= 1 {
+			return ""
+		}
+
+		k.disabled = true
+		return q.rotated(k, "was rejected")
+	case status >= 500:
+		q.until = time.Now().Add(q.increaseBackoff())
+	case status >= 200 && status < 400:
+		q.backoff = 0
+	}
+	return ""
+}
+
+func (q *QuotaManager) increaseBackoff() time.Duration {
+	if q.backoff < MinBackoff {
+		q.backoff = MinBackoff
+	} else if q.backoff *= 2; q.backoff > MaxBackoff {
+		q.backoff = MaxBackoff
+	}
+	return q.backoff
+}
+
+func (q *QuotaManager) rotated(k *APIKey, reason string) string {
+	var avail int
+	now := time.Now()
+	for _, key := range q.keys {
+		if !key.disabled && now.After(key.exhausted) {
+			avail++
+		}
+	}
+	return fmt.Sprintf("API key %s %s, %d of %d keys remaining", k.Creds.Name, reason, avail, len(q.keys))
+}
+
+// Usage returns a description of the requests made with each API key and their remaining quota.
+func (q *QuotaManager) Usage() []string {
+	q.Lock()
+	defer q.Unlock()
+
+	var lines []string
+	for _, k := range q.keys {
+		if k.requests == 0 {
+			continue
+		}
+
+		line := fmt.Sprintf("API key %s: %d requests", k.Creds.Name, k.requests)
+		if k.remaining != "" {
+			line += ", " + k.remaining + " remaining"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func rateLimitRemaining(hdr Header) string {
+	for _, key := range []string{"X-Ratelimit-Remaining", "Ratelimit-Remaining"} {
+		if v, found := hdr[key]; found && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func retryAfter(hdr Header) time.Duration {
+	v, found := hdr["Retry-After"]
+	if !found || v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestQuotaManagerRotation(t *testing.T) {
+	q := NewQuotaManager(&Credentials{Name: "second", Apikey: "key2"}, &Credentials{Name: "first", Apikey: "key1"})
+
+	k, err := q.Acquire(context.Background())
+	if err != nil || k == nil || k.Creds.Apikey != "key1" {
+		t.Fatalf("Expected the first key to be used, got %v: %v", k, err)
+	}
+	if msg := q.Update(k, http.StatusOK, Header{"X-Ratelimit-Remaining": "99"}); msg != "" {
+		t.Errorf("Unexpected message for the successful response: %s", msg)
+	}
+	if msg := q.Update(k, http.StatusTooManyRequests, Header{"Retry-After": "3600"}); msg == "" {
+		t.Error("Expected a message when the quota of the key was exhausted")
+	}
+	if c := q.Credentials(); c == nil || c.Apikey != "key2" {
+		t.Fatalf("Expected the second key after the rotation, got %v", c)
+	}
+
+	k, _ = q.Acquire(context.Background())
+	if msg := q.Update(k, http.StatusForbidden, nil); msg == "" {
+		t.Error("Expected a message when the key was rejected")
+	}
+	if c := q.Credentials(); c != nil {
+		t.Errorf("Expected no credentials once all the keys are exhausted, got %v", c)
+	}
+	if usage := q.Usage(); len(usage) != 2 {
+		t.Errorf("Expected the usage of both keys, got %v", usage)
+	}
+}
+
+func TestQuotaManagerBackoff(t *testing.T) {
+	q := NewQuotaManager()
+
+	for i := 0; i < 3; i++ {
+		q.Update(nil, http.StatusServiceUnavailable, nil)
+	}
+	if q.backoff != 4*MinBackoff {
+		t.Errorf("Expected the backoff to double with each failure, got %s", q.backoff)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := q.Acquire(ctx); err == nil {
+		t.Error("Expected the request to wait for the backoff")
+	}
+
+	q.Update(nil, http.StatusOK, nil)
+	if q.backoff != 0 {
+		t.Errorf("Expected the backoff to be reset by the successful response, got %s", q.backoff)
+	}
+}
@@ -55,37 +55,71 @@ func init() {
 	}
 }
 
-// DialContext performs the dial using global variables (e.g. LocalAddr).
+// DialContext performs the dial using global variables (e.g. LocalAddr and SourceAddrs). When several
+// source addresses can be used, the next address is tried after a failure.
 func DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
-	d := &net.Dialer{DualStack: true}
-
-	_, p, err := net.SplitHostPort(addr)
+	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, err
 	}
 
-	port, err := strconv.Atoi(p)
-	if err != nil {
-		return nil, err
+	ips := sourceIPs(host)
+	for i, ip := range ips {
+		d := &net.Dialer{DualStack: true, LocalAddr: localAddr(network, ip)}
+
+		conn, err := d.DialContext(ctx, network, addr)
+		if err == nil || ctx.Err() != nil || i == len(ips)-1 {
+			return conn, err
+		}
 	}
+	return nil, nil
+}
 
+// sourceIPs returns the local addresses tried for the connection to the host, where nil selects the address
+// using the routing table. The network interface selected by LocalAddr takes precedence over SourceAddrs.
+func sourceIPs(host string) []net.IP {
 	if LocalAddr != nil {
-		addr, _, err := net.ParseCIDR(LocalAddr.String())
-
-		if err == nil && strings.HasPrefix(network, "tcp") {
-			d.LocalAddr = &net.TCPAddr{
-				IP:   addr,
-				Port: port,
-			}
-		} else if err == nil && strings.HasPrefix(network, "udp") {
-			d.LocalAddr = &net.UDPAddr{
-				IP:   addr,
-				Port: port,
-			}
+		if ip, _, err := net.ParseCIDR(LocalAddr.String()); err == nil {
+			return []net.IP{ip}
+		}
+		if ip := net.ParseIP(LocalAddr.String()); ip != nil {
+			return []net.IP{ip}
+		}
+	}
+
+	src := SourceAddrs
+	if src == nil {
+		return []net.IP{nil}
+	}
+	// The family of the host is only known in advance for address literals
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{src.Next(IsIPv6(ip))}
+	}
+
+	var ips []net.IP
+	for _, v6 := range []bool{false, true} {
+		if ip := src.Next(v6); ip != nil {
+			ips = append(ips, ip)
 		}
 	}
+	if len(ips) == 0 {
+		return []net.IP{nil}
+	}
+	return ips
+}
 
-	return d.DialContext(ctx, network, addr)
+func localAddr(network string, ip net.IP) net.Addr {
+	if ip == nil {
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(network, "tcp"):
+		return &net.TCPAddr{IP: ip}
+	case strings.HasPrefix(network, "udp"):
+		return &net.UDPAddr{IP: ip}
+	}
+	return nil
 }
 
 // IsIPv4 returns true when the provided net.IP address is an IPv4 address.
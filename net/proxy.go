@@ -0,0 +1,126 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Proxy is the global option for routing the outbound HTTP requests through a proxy.
+var Proxy *url.URL
+
+type proxyKey struct{}
+
+// ProxySettings contains the proxy used by default, and the proxies used for specific data sources.
+type ProxySettings struct {
+	Default *url.URL
+	Sources map[string]*url.URL
+}
+
+// ParseProxy returns the proxy URL when it uses the http, https or socks5 scheme. The
+// credentials for proxy authentication are provided in the user information of the URL.
+func ParseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the proxy URL: %v", err)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("the proxy URL %s must use the http, https or socks5 scheme", u.Redacted())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("the proxy URL %s does not include a host", u.Redacted())
+	}
+	return u, nil
+}
+
+// ParseProxySettings returns the settings from the proxy option of the configuration, which is either
+// the URL of the proxy used for all requests, or a map with the url key and the sources key mapping
+// data source names to the proxies they use instead.
+func ParseProxySettings(raw interface{}) (*ProxySettings, error) {
+	settings := &ProxySettings{Sources: make(map[string]*url.URL)}
+	if raw == nil {
+		return settings, nil
+	}
+
+	var m map[string]interface{}
+	switch v := raw.(type) {
+	case string:
+		m = map[string]interface{}{"url": v}
+	case map[string]interface{}:
+		m = v
+	default:
+		return nil, errors.New("the proxy option must be a URL or a map")
+	}
+
+	if v, found := m["url"]; found {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("the proxy url must be a string")
+		}
+
+		u, err := ParseProxy(s)
+		if err != nil {
+			return nil, err
+		}
+		settings.Default = u
+	}
+
+	if v, found := m["sources"]; found {
+		srcs, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("the proxy sources must map data source names to URLs")
+		}
+
+		for name, v := range srcs {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("the proxy for the %s data source must be a string", name)
+			}
+
+			u, err := ParseProxy(s)
+			if err != nil {
+				return nil, fmt.Errorf("the %s data source: %v", name, err)
+			}
+			settings.Sources[strings.ToLower(name)] = u
+		}
+	}
+	return settings, nil
+}
+
+// ForSource returns the proxy configured for the named data source, or nil when it uses the default.
+func (p *ProxySettings) ForSource(name string) *url.URL {
+	if p == nil {
+		return nil
+	}
+	return p.Sources[strings.ToLower(name)]
+}
+
+// WithProxy returns a copy of the context that routes the HTTP requests made with it through the proxy.
+func WithProxy(ctx context.Context, u *url.URL) context.Context {
+	if u == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, proxyKey{}, u)
+}
+
+// ProxyFunc selects the proxy for the HTTP request, and is used as the Proxy of HTTP transports. The proxy
+// provided by the request context is used first, then the global Proxy, then the environment variables.
+func ProxyFunc(req *http.Request) (*url.URL, error) {
+	if u, ok := req.Context().Value(proxyKey{}).(*url.URL); ok && u != nil {
+		return u, nil
+	}
+	if Proxy != nil {
+		return Proxy, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
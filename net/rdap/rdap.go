@@ -0,0 +1,369 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rdap queries the Registration Data Access Protocol services for registration contacts.
+package rdap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/net/registry"
+)
+
+// DefaultBaseURL is the RDAP service that redirects queries to the authoritative registry.
+const DefaultBaseURL = "https://rdap.org"
+
+const (
+	maxRetries        = 3
+	defaultRetryAfter = 30 * time.Second
+)
+
+// Contact is an entity obtained from the RDAP response.
+type Contact struct {
+	Handle string `json:"handle,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Phone  string `json:"phone,omitempty"`
+}
+
+// Client performs RDAP queries. The queries sent to each registry,
+// including those redirected to the authoritative registry, are scheduled by the Queue.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	Queue   *registry.Queue
+}
+
+// NewClient returns a Client using the DefaultBaseURL and the registry.DefaultQueue.
+func NewClient() *Client {
+	c := &Client{
+		BaseURL: DefaultBaseURL,
+		Queue:   registry.DefaultQueue,
+	}
+
+	c.HTTP = &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               amassnet.ProxyFunc,
+			DialContext:         amassnet.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after %d redirects", len(via))
+			}
+			return c.Queue.Wait(req.Context(), req.URL.Hostname())
+		},
+	}
+	return c
+}
+
+type entity struct {
+	Handle     string          `json:"handle"`
+	Roles      []string        `json:"roles"`
+	VCardArray json.RawMessage `json:"vcardArray"`
+	PublicIDs  []publicID      `json:"publicIds"`
+	Entities   []entity        `json:"entities"`
+}
+
+type publicID struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+}
+
+type event struct {
+	Action string `json:"eventAction"`
+	Date   string `json:"eventDate"`
+}
+
+type nameserver struct {
+	LDHName string `json:"ldhName"`
+}
+
+type secureDNS struct {
+	DelegationSigned *bool `json:"delegationSigned"`
+}
+
+type response struct {
+	Handle      string       `json:"handle"`
+	Status      []string     `json:"status"`
+	Entities    []entity     `json:"entities"`
+	Events      []event      `json:"events"`
+	Nameservers []nameserver `json:"nameservers"`
+	SecureDNS   *secureDNS   `json:"secureDNS"`
+}
+
+// Registration is the registration data of a domain name obtained from the RDAP response.
+type Registration struct {
+	Handle    string `json:"handle,omitempty"`
+	Registrar string `json:"registrar,omitempty"`
+	// RegistrarIANAID is the identifier assigned to the registrar by IANA
+	RegistrarIANAID string    `json:"registrar_iana_id,omitempty"`
+	Created         time.Time `json:"created"`
+	Updated         time.Time `json:"updated"`
+	Expires         time.Time `json:"expires"`
+	Nameservers     []string  `json:"nameservers,omitempty"`
+	// Statuses are the EPP status codes of the domain, such as clientTransferProhibited
+	Statuses []string `json:"statuses,omitempty"`
+	// DNSSEC is nil when the registry does not report whether the delegation is signed
+	DNSSEC *bool `json:"dnssec,omitempty"`
+	// Abuse are the abuse contacts provided with the registration
+	Abuse []*Contact `json:"-"`
+}
+
+// TypeDomainRegistration is the type of the findings recorded for the registrations of the root domains.
+const TypeDomainRegistration = "domain_registration"
+
+// Attributes returns the fields of the registration as the attributes of a finding, so they can be
+// filtered by the query and report subcommands. The lists are sorted and joined by commas, and the
+// fields that were not provided are left out.
+func (r *Registration) Attributes() map[string]string {
+	attrs := make(map[string]string)
+
+	set := func(key, value string) {
+		if value != "" {
+			attrs[key] = value
+		}
+	}
+	date := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	set("handle", r.Handle)
+	set("registrar", r.Registrar)
+	set("registrar_iana_id", r.RegistrarIANAID)
+	set("created", date(r.Created))
+	set("updated", date(r.Updated))
+	set("expires", date(r.Expires))
+	set("nameservers", joinSorted(r.Nameservers))
+	set("statuses", joinSorted(r.Statuses))
+	if r.DNSSEC != nil {
+		set("dnssec", strconv.FormatBool(*r.DNSSEC))
+	}
+	return attrs
+}
+
+// Finding returns the finding recording the registration of the domain name, obtained using the source protocol.
+func (r *Registration) Finding(domain, source string) *findings.Finding {
+	attrs := r.Attributes()
+	attrs["source"] = source
+
+	return &findings.Finding{
+		Asset:      domain,
+		Type:       TypeDomainRegistration,
+		Severity:   findings.SeverityInfo,
+		Title:      "Domain registration",
+		Attributes: attrs,
+	}
+}
+
+func joinSorted(list []string) string {
+	seen := make(map[string]struct{}, len(list))
+
+	var values []string
+	for _, v := range list {
+		if _, found := seen[v]; v != "" && !found {
+			seen[v] = struct{}{}
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+// AbuseContacts returns the contacts with the abuse role for the IP address, netblock or domain name.
+func (c *Client) AbuseContacts(ctx context.Context, object string) ([]*Contact, error) {
+	path := "/domain/" + url.PathEscape(object)
+	if ip, _, err := net.ParseCIDR(object); err == nil {
+		path = "/ip/" + ip.String() + "/" + strings.SplitN(object, "/", 2)[1]
+	} else if ip := net.ParseIP(object); ip != nil {
+		path = "/ip/" + ip.String()
+	}
+
+	r, err := c.query(ctx, object, path)
+	if err != nil {
+		return nil, err
+	}
+	return abuseEntities(r.Entities), nil
+}
+
+// DomainRegistration returns the registration data of the domain name, including the registrar, the
+// dates, the name servers, the statuses, the DNSSEC delegation and the abuse contacts.
+func (c *Client) DomainRegistration(ctx context.Context, domain string) (*Registration, error) {
+	r, err := c.query(ctx, domain, "/domain/"+url.PathEscape(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	reg := &Registration{
+		Handle:   r.Handle,
+		Statuses: r.Status,
+		Abuse:    abuseEntities(r.Entities),
+	}
+	for _, e := range r.Events {
+		t, err := time.Parse(time.RFC3339, e.Date)
+		if err != nil {
+			continue
+		}
+
+		switch strings.ToLower(e.Action) {
+		case "registration":
+			reg.Created = t
+		case "last changed":
+			reg.Updated = t
+		case "expiration":
+			reg.Expires = t
+		}
+	}
+	for _, e := range r.Entities {
+		if hasRole(e, "registrar") {
+			reg.Registrar = parseVCard(e.VCardArray).Name
+			for _, id := range e.PublicIDs {
+				if strings.EqualFold(id.Type, "IANA Registrar ID") {
+					reg.RegistrarIANAID = id.Identifier
+				}
+			}
+			break
+		}
+	}
+	for _, ns := range r.Nameservers {
+		if name := strings.ToLower(strings.TrimSuffix(ns.LDHName, ".")); name != "" {
+			reg.Nameservers = append(reg.Nameservers, name)
+		}
+	}
+	if r.SecureDNS != nil {
+		reg.DNSSEC = r.SecureDNS.DelegationSigned
+	}
+	return reg, nil
+}
+
+func (c *Client) query(ctx context.Context, object, path string) (*response, error) {
+	resp, err := c.get(ctx, strings.TrimSuffix(c.BaseURL, "/")+path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the RDAP query for %s returned status %d", object, resp.StatusCode)
+	}
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode the RDAP response for %s: %v", object, err)
+	}
+	return &r, nil
+}
+
+// get sends the request once the registry permits it, and retries when the registry asks the client to slow down.
+func (c *Client) get(ctx context.Context, u string) (*http.Response, error) {
+	for i := 0; ; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/rdap+json")
+
+		if err := c.Queue.Wait(ctx, req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) || i >= maxRetries {
+			return resp, nil
+		}
+		resp.Body.Close()
+		// The response may have come from the registry the query was redirected to
+		c.Queue.RetryAfter(resp.Request.URL.Hostname(), retryAfter(resp.Header.Get("Retry-After")))
+	}
+}
+
+// retryAfter returns the duration provided by the Retry-After header, which can be seconds or an HTTP date.
+func retryAfter(value string) time.Duration {
+	if secs, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return defaultRetryAfter
+}
+
+// abuseEntities walks the nested entities and returns the contacts that have the abuse role.
+func abuseEntities(entities []entity) []*Contact {
+	var contacts []*Contact
+
+	for _, e := range entities {
+		if hasRole(e, "abuse") {
+			c := parseVCard(e.VCardArray)
+			c.Handle = e.Handle
+			contacts = append(contacts, c)
+		}
+		contacts = append(contacts, abuseEntities(e.Entities)...)
+	}
+	return contacts
+}
+
+func hasRole(e entity, role string) bool {
+	for _, r := range e.Roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVCard extracts the contact details from a jCard (RFC 7095).
+func parseVCard(raw json.RawMessage) *Contact {
+	c := new(Contact)
+
+	var card []interface{}
+	if err := json.Unmarshal(raw, &card); err != nil || len(card) != 2 {
+		return c
+	}
+
+	props, ok := card[1].([]interface{})
+	if !ok {
+		return c
+	}
+
+	for _, p := range props {
+		prop, ok := p.([]interface{})
+		if !ok || len(prop) < 4 {
+			continue
+		}
+
+		name, _ := prop[0].(string)
+		value, _ := prop[3].(string)
+		switch strings.ToLower(name) {
+		case "fn":
+			c.Name = value
+		case "email":
+			c.Email = value
+		case "tel":
+			c.Phone = strings.TrimPrefix(value, "tel:")
+		}
+	}
+	return c
+}
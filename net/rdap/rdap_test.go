@@ -0,0 +1,169 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package rdap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/net/registry"
+)
+
+const ipResponse = `{
+  "objectClassName": "ip network",
+  "handle": "NET-192-0-2-0-1",
+  "entities": [{
+    "handle": "EXAMPLE-ORG",
+    "roles": ["registrant"],
+    "entities": [{
+      "handle": "ABUSE-EX",
+      "roles": ["abuse"],
+      "vcardArray": ["vcard", [
+        ["version", {}, "text", "4.0"],
+        ["fn", {}, "text", "Example Abuse Team"],
+        ["email", {}, "text", "abuse@example.com"],
+        ["tel", {"type": ["work", "voice"]}, "uri", "tel:+1-555-0100"]
+      ]]
+    }]
+  }]
+}`
+
+func TestAbuseContacts(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = w.Write([]byte(ipResponse))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	c.BaseURL = srv.URL
+	c.Queue = registry.NewQueue()
+
+	contacts, err := c.AbuseContacts(context.Background(), "192.0.2.0/24")
+	if err != nil {
+		t.Fatalf("Failed to obtain the abuse contacts: %v", err)
+	}
+	if path != "/ip/192.0.2.0/24" {
+		t.Errorf("Unexpected RDAP query path: %s", path)
+	}
+	if len(contacts) != 1 {
+		t.Fatalf("Expected one abuse contact, got %d", len(contacts))
+	}
+
+	expected := Contact{
+		Handle: "ABUSE-EX",
+		Name:   "Example Abuse Team",
+		Email:  "abuse@example.com",
+		Phone:  "+1-555-0100",
+	}
+	if *contacts[0] != expected {
+		t.Errorf("Expected %v, got %v", expected, *contacts[0])
+	}
+
+	if _, err := c.AbuseContacts(context.Background(), "owasp.org"); err != nil || path != "/domain/owasp.org" {
+		t.Errorf("Unexpected RDAP domain query: %s: %v", path, err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var count int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count++; count == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(ipResponse))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	c.BaseURL = srv.URL
+	c.Queue = registry.NewQueue()
+
+	start := time.Now()
+	if contacts, err := c.AbuseContacts(context.Background(), "192.0.2.1"); err != nil || len(contacts) != 1 {
+		t.Fatalf("The query was not retried after the rate limit: %v", err)
+	}
+	if count != 2 || time.Since(start) < time.Second {
+		t.Errorf("The Retry-After header was not respected")
+	}
+}
+
+const domainResponse = `{
+  "objectClassName": "domain",
+  "ldhName": "OWASP.ORG",
+  "events": [
+    {"eventAction": "registration", "eventDate": "2001-09-13T04:00:00Z"},
+    {"eventAction": "expiration", "eventDate": "2027-09-13T04:00:00Z"},
+    {"eventAction": "last changed", "eventDate": "not a date"}
+  ],
+  "status": ["client transfer prohibited", "server delete prohibited"],
+  "nameservers": [
+    {"objectClassName": "nameserver", "ldhName": "NS2.EXAMPLE.NET"},
+    {"objectClassName": "nameserver", "ldhName": "ns1.example.net."}
+  ],
+  "secureDNS": {"delegationSigned": false},
+  "entities": [{
+    "handle": "292",
+    "roles": ["registrar"],
+    "publicIds": [{"type": "IANA Registrar ID", "identifier": "292"}],
+    "vcardArray": ["vcard", [["fn", {}, "text", "Example Registrar, Inc."]]],
+    "entities": [{
+      "roles": ["abuse"],
+      "vcardArray": ["vcard", [["email", {}, "text", "abuse@registrar.example"]]]
+    }]
+  }]
+}`
+
+func TestDomainRegistration(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(domainResponse))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	c.BaseURL = srv.URL
+	c.Queue = registry.NewQueue()
+
+	reg, err := c.DomainRegistration(context.Background(), "owasp.org")
+	if err != nil {
+		t.Fatalf("Failed to obtain the registration: %v", err)
+	}
+	if reg.Registrar != "Example Registrar, Inc." {
+		t.Errorf("Unexpected registrar: %s", reg.Registrar)
+	}
+	if want := time.Date(2027, 9, 13, 4, 0, 0, 0, time.UTC); !reg.Expires.Equal(want) {
+		t.Errorf("Unexpected expiration: %v", reg.Expires)
+	}
+	if reg.Created.Year() != 2001 {
+		t.Errorf("Unexpected registration date: %v", reg.Created)
+	}
+	if len(reg.Abuse) != 1 || reg.Abuse[0].Email != "abuse@registrar.example" {
+		t.Errorf("Unexpected abuse contacts: %v", reg.Abuse)
+	}
+
+	attrs := reg.Finding("owasp.org", "rdap").Attributes
+	for key, want := range map[string]string{
+		"registrar_iana_id": "292",
+		"nameservers":       "ns1.example.net,ns2.example.net",
+		"statuses":          "client transfer prohibited,server delete prohibited",
+		"dnssec":            "false",
+		"expires":           "2027-09-13T04:00:00Z",
+		"source":            "rdap",
+	} {
+		if attrs[key] != want {
+			t.Errorf("Expected the %s attribute %q, got %q", key, want, attrs[key])
+		}
+	}
+	// The invalid dates are left out of the attributes
+	if _, found := attrs["updated"]; found {
+		t.Errorf("Unexpected updated attribute: %s", attrs["updated"])
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package registry schedules the RDAP and WHOIS queries sent to each registry, so the
+// registry-specific rate limits are respected and the user's address is not banned.
+package registry
+
+import (
+	"container/heap"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priorities used when registration lookups are waiting for the same registry.
+const (
+	PriorityLow    = 0
+	PriorityNormal = 5
+	PriorityHigh   = 10
+)
+
+// DefaultInterval is the minimum time between queries sent to a registry without a known limit.
+const DefaultInterval = time.Second
+
+// intervals provides the minimum time between queries for the registries with known rate limits.
+var intervals = map[string]time.Duration{
+	"rdap.org":               time.Second,
+	"whois.iana.org":         time.Second,
+	"rdap.verisign.com":      500 * time.Millisecond,
+	"whois.verisign-grs.com": time.Second,
+	"rdap.arin.net":          time.Second,
+	"whois.arin.net":         time.Second,
+	"rdap.db.ripe.net":       time.Second,
+	"whois.ripe.net":         2 * time.Second,
+	"rdap.apnic.net":         time.Second,
+	"whois.apnic.net":        2 * time.Second,
+	"rdap.lacnic.net":        5 * time.Second,
+	"whois.lacnic.net":       5 * time.Second,
+	"rdap.afrinic.net":       2 * time.Second,
+	"whois.afrinic.net":      2 * time.Second,
+	"whois.denic.de":         2 * time.Second,
+	"whois.nic.uk":           2 * time.Second,
+}
+
+// DefaultQueue is the queue shared by the registration lookups of the process.
+var DefaultQueue = NewQueue()
+
+type priorityKey struct{}
+
+// WithPriority returns a context that provides the priority of the registration lookup.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// Priority returns the priority provided by the context, or PriorityNormal.
+func Priority(ctx context.Context) int {
+	if p, ok := ctx.Value(priorityKey{}).(int); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// Queue releases the queries for each registry in priority order at the rate permitted by the registry.
+type Queue struct {
+	sync.Mutex
+	hosts map[string]*host
+}
+
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	released bool
+	canceled bool
+}
+
+type waiters []*waiter
+
+func (w waiters) Len() int { return len(w) }
+func (w waiters) Less(i, j int) bool {
+	if w[i].priority == w[j].priority {
+		return w[i].seq < w[j].seq
+	}
+	return w[i].priority > w[j].priority
+}
+func (w waiters) Swap(i, j int)       { w[i], w[j] = w[j], w[i] }
+func (w *waiters) Push(x interface{}) { *w = append(*w, x.(*waiter)) }
+func (w *waiters) Pop() interface{} {
+	old := *w
+	n := len(old)
+	x := old[n-1]
+	*w = old[:n-1]
+	return x
+}
+
+type host struct {
+	interval time.Duration
+	next     time.Time
+	seq      uint64
+	waiting  waiters
+	timer    *time.Timer
+}
+
+// NewQueue returns an initialized Queue.
+func NewQueue() *Queue {
+	return &Queue{hosts: make(map[string]*host)}
+}
+
+// SetInterval sets the minimum time between queries sent to the registry.
+func (q *Queue) SetInterval(name string, d time.Duration) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.host(name).interval = d
+}
+
+// Wait blocks until the query can be sent to the registry, releasing
+// the waiting queries with the highest priority provided by the context first.
+func (q *Queue) Wait(ctx context.Context, name string) error {
+	w := &waiter{
+		priority: Priority(ctx),
+		ready:    make(chan struct{}),
+	}
+
+	q.Lock()
+	h := q.host(name)
+	h.seq++
+	w.seq = h.seq
+	heap.Push(&h.waiting, w)
+	q.schedule(h)
+	q.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		q.Lock()
+		defer q.Unlock()
+		// The query may have been released while the context was being cancelled
+		if w.released {
+			return nil
+		}
+		w.canceled = true
+		return ctx.Err()
+	}
+}
+
+// RetryAfter prevents queries from being sent to the registry for the provided duration,
+// such as when the registry responded with HTTP status 429 and the Retry-After header.
+func (q *Queue) RetryAfter(name string, d time.Duration) {
+	q.Lock()
+	defer q.Unlock()
+
+	h := q.host(name)
+	if until := time.Now().Add(d); until.After(h.next) {
+		h.next = until
+	}
+	if h.timer != nil && h.timer.Stop() {
+		h.timer = nil
+		q.schedule(h)
+	}
+}
+
+func (q *Queue) host(name string) *host {
+	name = strings.ToLower(name)
+
+	h, found := q.hosts[name]
+	if !found {
+		h = &host{interval: DefaultInterval}
+		if d, ok := intervals[name]; ok {
+			h.interval = d
+		}
+		q.hosts[name] = h
+	}
+	return h
+}
+
+// schedule arms the timer that releases the next waiting query. The lock must be held.
+func (q *Queue) schedule(h *host) {
+	if h.timer != nil || h.waiting.Len() == 0 {
+		return
+	}
+
+	h.timer = time.AfterFunc(time.Until(h.next), func() {
+		q.Lock()
+		defer q.Unlock()
+
+		h.timer = nil
+		for h.waiting.Len() > 0 {
+			w := heap.Pop(&h.waiting).(*waiter)
+			if w.canceled {
+				continue
+			}
+
+			w.released = true
+			close(w.ready)
+			h.next = time.Now().Add(h.interval)
+			break
+		}
+		q.schedule(h)
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueueInterval(t *testing.T) {
+	q := NewQueue()
+	q.SetInterval("rdap.example", 50*time.Millisecond)
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := q.Wait(ctx, "rdap.example"); err != nil {
+			t.Fatalf("Wait returned an error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Three queries were released within %v", elapsed)
+	}
+	// Queries for other registries are not delayed
+	start = time.Now()
+	if err := q.Wait(ctx, "whois.example"); err != nil || time.Since(start) > 50*time.Millisecond {
+		t.Errorf("The query for a different registry was delayed")
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	q := NewQueue()
+	q.SetInterval("rdap.example", 20*time.Millisecond)
+	// Hold the registry, so the following queries have to wait
+	q.RetryAfter("rdap.example", 50*time.Millisecond)
+
+	var lock sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for _, p := range []int{PriorityLow, PriorityHigh, PriorityNormal} {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+
+			if err := q.Wait(WithPriority(context.Background(), p), "rdap.example"); err == nil {
+				lock.Lock()
+				order = append(order, p)
+				lock.Unlock()
+			}
+		}(p)
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	if len(order) != 3 || order[0] != PriorityHigh || order[1] != PriorityNormal || order[2] != PriorityLow {
+		t.Errorf("The queries were not released in priority order: %v", order)
+	}
+}
+
+func TestQueueCancel(t *testing.T) {
+	q := NewQueue()
+	q.RetryAfter("rdap.example", time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := q.Wait(ctx, "rdap.example"); err == nil {
+		t.Errorf("Expected the cancelled query to return an error")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// SourceAddrs is the global option for the local addresses that the outbound connections are made from.
+var SourceAddrs *SourceAddresses
+
+// SourceAddresses selects the local addresses of the outbound connections in rotation,
+// distributing the connections across the addresses of a multi-homed host.
+type SourceAddresses struct {
+	v4    []net.IP
+	v6    []net.IP
+	next4 uint32
+	next6 uint32
+}
+
+// NewSourceAddresses returns the SourceAddresses rotating across the provided addresses.
+func NewSourceAddresses(ips ...net.IP) *SourceAddresses {
+	s := new(SourceAddresses)
+	seen := make(map[string]struct{})
+
+	for _, ip := range ips {
+		if ip == nil {
+			continue
+		}
+		if _, found := seen[ip.String()]; found {
+			continue
+		}
+		seen[ip.String()] = struct{}{}
+
+		if IsIPv4(ip) {
+			s.v4 = append(s.v4, ip)
+		} else {
+			s.v6 = append(s.v6, ip)
+		}
+	}
+	return s
+}
+
+// ParseSourceAddresses returns the SourceAddresses from the source_addresses option of the configuration,
+// which lists IP addresses and the names of the network interfaces providing their assigned addresses.
+// Nil is returned when the option is not provided.
+func ParseSourceAddresses(raw interface{}) (*SourceAddresses, error) {
+	var entries []string
+
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		entries = []string{v}
+	case []interface{}:
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, errors.New("the source_addresses must be IP addresses or network interface names")
+			}
+			entries = append(entries, s)
+		}
+	default:
+		return nil, errors.New("the source_addresses option must be a list")
+	}
+
+	var ips []net.IP
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if ip := net.ParseIP(e); ip != nil {
+			ips = append(ips, ip)
+			continue
+		}
+
+		addrs, err := interfaceAddrs(e)
+		if err != nil {
+			return nil, err
+		}
+		ips = append(ips, addrs...)
+	}
+
+	s := NewSourceAddresses(ips...)
+	if s.Len() == 0 {
+		return nil, errors.New("the source_addresses option does not provide any addresses")
+	}
+	return s, nil
+}
+
+func interfaceAddrs(name string) ([]net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not an IP address or a network interface", name)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain the addresses of the network interface %s: %v", name, err)
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		// The link-local addresses cannot reach the data sources
+		if a, ok := addr.(*net.IPNet); ok && !a.IP.IsLinkLocalUnicast() && !a.IP.IsMulticast() {
+			ips = append(ips, a.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("the network interface %s does not have assigned IP addresses", name)
+	}
+	return ips, nil
+}
+
+// Len returns the number of addresses in the rotation.
+func (s *SourceAddresses) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.v4) + len(s.v6)
+}
+
+// Next returns the next IPv6 or IPv4 address in the rotation, or nil when no address of the family is available.
+func (s *SourceAddresses) Next(v6 bool) net.IP {
+	if s == nil {
+		return nil
+	}
+
+	ips, next := s.v4, &s.next4
+	if v6 {
+		ips, next = s.v6, &s.next6
+	}
+	if len(ips) == 0 {
+		return nil
+	}
+	return ips[(atomic.AddUint32(next, 1)-1)%uint32(len(ips))]
+}
@@ -0,0 +1,104 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"testing"
+)
+
+func TestParseSourceAddresses(t *testing.T) {
+	s, err := ParseSourceAddresses([]interface{}{"192.0.2.10", "2001:db8::10", "192.0.2.11", "192.0.2.10"})
+	if err != nil {
+		t.Fatalf("Failed to parse the addresses: %v", err)
+	}
+	if s.Len() != 3 {
+		t.Errorf("Expected 3 addresses, got %d", s.Len())
+	}
+
+	if s, err := ParseSourceAddresses(nil); err != nil || s != nil {
+		t.Errorf("Expected no addresses without the option: %v", err)
+	}
+	if s, err := ParseSourceAddresses("192.0.2.10"); err != nil || s.Len() != 1 {
+		t.Errorf("Failed to parse a single address: %v", err)
+	}
+	for _, raw := range []interface{}{
+		42,
+		[]interface{}{},
+		[]interface{}{1},
+		[]interface{}{"not-an-interface0"},
+	} {
+		if _, err := ParseSourceAddresses(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+func TestSourceAddressesNext(t *testing.T) {
+	s := NewSourceAddresses(net.ParseIP("192.0.2.10"), net.ParseIP("192.0.2.11"), net.ParseIP("2001:db8::10"))
+
+	for i, expected := range []string{"192.0.2.10", "192.0.2.11", "192.0.2.10"} {
+		if ip := s.Next(false); ip.String() != expected {
+			t.Errorf("Selection %d: expected %s, got %s", i, expected, ip)
+		}
+	}
+	if ip := s.Next(true); ip.String() != "2001:db8::10" {
+		t.Errorf("Expected the IPv6 address, got %s", ip)
+	}
+	if ip := NewSourceAddresses(net.ParseIP("2001:db8::10")).Next(false); ip != nil {
+		t.Errorf("Expected no IPv4 address, got %s", ip)
+	}
+
+	var none *SourceAddresses
+	if none.Next(false) != nil || none.Len() != 0 {
+		t.Error("The nil SourceAddresses provided an address")
+	}
+}
+
+func TestDialContextSourceAddrs(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	srcs := []string{"127.0.0.1"}
+	// The entire loopback network is assigned to the interface on Linux
+	if runtime.GOOS == "linux" {
+		srcs = append(srcs, "127.0.0.2")
+	}
+
+	var ips []net.IP
+	for _, src := range srcs {
+		ips = append(ips, net.ParseIP(src))
+	}
+	SourceAddrs = NewSourceAddresses(ips...)
+	defer func() { SourceAddrs = nil }()
+
+	for i := 0; i < 2*len(srcs); i++ {
+		conn, err := DialContext(context.Background(), "tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+
+		expected := srcs[i%len(srcs)]
+		if got := conn.LocalAddr().(*net.TCPAddr).IP.String(); got != expected {
+			t.Errorf("Dial %d: expected the source address %s, got %s", i, expected, got)
+		}
+		conn.Close()
+	}
+}
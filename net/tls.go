@@ -0,0 +1,174 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// pinPrefix is the optional prefix of the certificate pins, as used by HPKP and curl.
+const pinPrefix = "sha256/"
+
+type tlsKey struct{}
+
+// TLSSettings are the TLS options applied to the HTTPS requests of a data source.
+type TLSSettings struct {
+	// CAFile is the path to the PEM-encoded certificates trusted in addition to the system roots,
+	// such as the certificate of a TLS-intercepting proxy
+	CAFile string `yaml:"ca_file,omitempty"`
+	// Pins are the base64-encoded SHA-256 hashes of the public keys, one of which the certificate chain must provide
+	Pins []string `yaml:"pins,omitempty"`
+	// InsecureSkipVerify accepts any certificate chain, which should only be used with lab proxies. The pins are still checked
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// TLSOptions are provided by the tls option of the configuration.
+type TLSOptions struct {
+	// Default is applied to the data sources without their own settings, or is nil
+	Default *TLSSettings
+	Sources map[string]*TLSSettings
+}
+
+type tlsOption struct {
+	TLSSettings `yaml:",inline"`
+	Sources     map[string]*TLSSettings `yaml:"sources,omitempty"`
+}
+
+// ParseTLSOptions returns the options from the tls option of the configuration, which provides the settings
+// applied to all the data sources, and the sources key mapping data source names to the settings they use instead.
+func ParseTLSOptions(raw interface{}) (*TLSOptions, error) {
+	opts := &TLSOptions{Sources: make(map[string]*TLSSettings)}
+	if raw == nil {
+		return opts, nil
+	}
+
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the tls settings: %v", err)
+	}
+
+	var o tlsOption
+	if err := yaml.Unmarshal(data, &o); err != nil {
+		return nil, errors.New("the tls option must be a map of the TLS settings")
+	}
+
+	if !o.TLSSettings.empty() {
+		def := o.TLSSettings
+		if _, err := def.Config(); err != nil {
+			return nil, err
+		}
+		opts.Default = &def
+	}
+	for name, s := range o.Sources {
+		if s == nil {
+			continue
+		}
+		if _, err := s.Config(); err != nil {
+			return nil, fmt.Errorf("the %s data source: %v", name, err)
+		}
+		opts.Sources[strings.ToLower(name)] = s
+	}
+	return opts, nil
+}
+
+// ForSource returns the settings of the named data source, the default settings, or nil when neither is configured.
+func (o *TLSOptions) ForSource(name string) *TLSSettings {
+	if o == nil {
+		return nil
+	}
+	if s, found := o.Sources[strings.ToLower(name)]; found {
+		return s
+	}
+	return o.Default
+}
+
+func (s *TLSSettings) empty() bool {
+	return s.CAFile == "" && len(s.Pins) == 0 && !s.InsecureSkipVerify
+}
+
+// Config returns the TLS client configuration implementing the settings.
+func (s *TLSSettings) Config() (*tls.Config, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: s.InsecureSkipVerify,
+	}
+
+	if s.CAFile != "" {
+		pem, err := os.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA file: %v", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("the CA file %s does not provide any PEM-encoded certificates", s.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if len(s.Pins) > 0 {
+		pins := make(map[string]struct{}, len(s.Pins))
+		for _, p := range s.Pins {
+			p = strings.TrimPrefix(strings.TrimSpace(p), pinPrefix)
+			if b, err := base64.StdEncoding.DecodeString(p); err != nil || len(b) != sha256.Size {
+				return nil, fmt.Errorf("the pin %s is not a base64-encoded SHA-256 hash", p)
+			}
+			pins[p] = struct{}{}
+		}
+		// The certificates are verified before this is called, unless the verification is skipped
+		cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			for _, raw := range rawCerts {
+				if cert, err := x509.ParseCertificate(raw); err == nil {
+					if _, found := pins[CertificatePin(cert)]; found {
+						return nil
+					}
+				}
+			}
+			return errors.New("the certificate chain does not match any of the pinned public keys")
+		}
+	}
+	return cfg, nil
+}
+
+// CertificatePin returns the base64-encoded SHA-256 hash of the public key of the certificate.
+func CertificatePin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WithTLS returns a copy of the context that applies the TLS configuration to the HTTPS requests made with it.
+func WithTLS(ctx context.Context, cfg *tls.Config) context.Context {
+	if cfg == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tlsKey{}, cfg)
+}
+
+// TLSFromContext returns the TLS configuration provided by the context, or nil.
+func TLSFromContext(ctx context.Context) *tls.Config {
+	if ctx == nil {
+		return nil
+	}
+
+	cfg, _ := ctx.Value(tlsKey{}).(*tls.Config)
+	return cfg
+}
@@ -0,0 +1,118 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCAFile(t *testing.T, srv *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed to write the CA file: %v", err)
+	}
+	return path
+}
+
+func TestParseTLSOptions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	ca := writeCAFile(t, srv)
+
+	opts, err := ParseTLSOptions(map[string]interface{}{
+		"ca_file": ca,
+		"sources": map[string]interface{}{
+			"VirusTotal": map[string]interface{}{"pins": []interface{}{"sha256/" + CertificatePin(srv.Certificate())}},
+			"LabAPI":     map[string]interface{}{"insecure_skip_verify": true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the options: %v", err)
+	}
+	if s := opts.ForSource("virustotal"); s == nil || len(s.Pins) != 1 || s.CAFile != "" {
+		t.Errorf("Unexpected VirusTotal settings: %+v", s)
+	}
+	if s := opts.ForSource("LabAPI"); s == nil || !s.InsecureSkipVerify {
+		t.Errorf("Unexpected LabAPI settings: %+v", s)
+	}
+	if s := opts.ForSource("Shodan"); s == nil || s.CAFile != ca {
+		t.Errorf("Expected the default settings for Shodan, got %+v", s)
+	}
+
+	if opts, err := ParseTLSOptions(nil); err != nil || opts.ForSource("Shodan") != nil {
+		t.Errorf("Expected no settings without the option: %v", err)
+	}
+	for _, raw := range []interface{}{
+		"insecure",
+		map[string]interface{}{"ca_file": filepath.Join(t.TempDir(), "missing.pem")},
+		map[string]interface{}{"sources": map[string]interface{}{"VirusTotal": map[string]interface{}{"pins": []interface{}{"not a pin"}}}},
+	} {
+		if _, err := ParseTLSOptions(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+func TestTLSSettingsConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	ca := writeCAFile(t, srv)
+	pin := CertificatePin(srv.Certificate())
+	// The pin of 32 zero bytes does not match any public key
+	other := strings.Repeat("A", 43) + "="
+
+	tests := []struct {
+		name     string
+		settings *TLSSettings
+		success  bool
+	}{
+		{"system roots", &TLSSettings{Pins: []string{pin}}, false},
+		{"custom CA", &TLSSettings{CAFile: ca}, true},
+		{"custom CA and pin", &TLSSettings{CAFile: ca, Pins: []string{other, "sha256/" + pin}}, true},
+		{"wrong pin", &TLSSettings{CAFile: ca, Pins: []string{other}}, false},
+		{"insecure", &TLSSettings{InsecureSkipVerify: true}, true},
+		{"insecure with the wrong pin", &TLSSettings{InsecureSkipVerify: true, Pins: []string{other}}, false},
+	}
+
+	for _, test := range tests {
+		cfg, err := test.settings.Config()
+		if err != nil {
+			t.Fatalf("%s: failed to build the configuration: %v", test.name, err)
+		}
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if success := err == nil; success != test.success {
+			t.Errorf("%s: expected success %t, got the error %v", test.name, test.success, err)
+		}
+	}
+}
+
+func TestWithTLS(t *testing.T) {
+	ctx := context.Background()
+	if WithTLS(ctx, nil) != ctx || TLSFromContext(ctx) != nil {
+		t.Error("The context changed without a TLS configuration")
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if TLSFromContext(WithTLS(ctx, cfg)) != cfg {
+		t.Error("The TLS configuration was not provided by the context")
+	}
+}
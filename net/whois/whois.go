@@ -0,0 +1,327 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package whois implements a port 43 WHOIS client for the registries that do not provide RDAP.
+package whois
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/net/rdap"
+	"github.com/owasp-amass/amass/v4/net/registry"
+)
+
+// IANAServer is the WHOIS server that refers queries to the server for each TLD.
+const IANAServer = "whois.iana.org"
+
+const (
+	maxResponseSize = 1 << 20
+	// The time queries are held for the registry after it reports the rate limit was exceeded.
+	rateLimitBackoff = time.Minute
+)
+
+// rateLimitMessages are found in the responses of registries that are rate limiting the client.
+var rateLimitMessages = []string{
+	"limit exceeded",
+	"rate limit",
+	"too many queries",
+	"too many requests",
+	"query limit",
+}
+
+// tldServers provides the WHOIS servers for TLDs that are commonly missing from RDAP bootstrap data.
+var tldServers = map[string]string{
+	"at": "whois.nic.at",
+	"au": "whois.auda.org.au",
+	"be": "whois.dns.be",
+	"ch": "whois.nic.ch",
+	"cn": "whois.cnnic.cn",
+	"de": "whois.denic.de",
+	"dk": "whois.punktum.dk",
+	"es": "whois.nic.es",
+	"eu": "whois.eu",
+	"fi": "whois.fi",
+	"fr": "whois.nic.fr",
+	"hk": "whois.hkirc.hk",
+	"it": "whois.nic.it",
+	"jp": "whois.jprs.jp",
+	"kr": "whois.kr",
+	"nl": "whois.domain-registry.nl",
+	"pl": "whois.dns.pl",
+	"ru": "whois.tcinet.ru",
+	"se": "whois.iis.se",
+	"uk": "whois.nic.uk",
+}
+
+// Response is a parsed WHOIS response.
+type Response struct {
+	Server string
+	Raw    string
+	Fields map[string][]string
+}
+
+// Client performs WHOIS queries over TCP port 43. The queries sent to each server are scheduled by the Queue.
+type Client struct {
+	sync.Mutex
+	IANA    string
+	Timeout time.Duration
+	Queue   *registry.Queue
+	servers map[string]string
+}
+
+// NewClient returns a Client that knows the WHOIS servers for many ccTLDs.
+func NewClient() *Client {
+	servers := make(map[string]string, len(tldServers))
+	for tld, server := range tldServers {
+		servers[tld] = server
+	}
+
+	return &Client{
+		IANA:    IANAServer,
+		Timeout: 15 * time.Second,
+		Queue:   registry.DefaultQueue,
+		servers: servers,
+	}
+}
+
+// SetServer sets the WHOIS server used for the TLD.
+func (c *Client) SetServer(tld, server string) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.servers[strings.ToLower(tld)] = server
+}
+
+// Query obtains the WHOIS response for the domain name from the server of the TLD, and
+// follows the referral to the registrar WHOIS server provided by thin registries.
+func (c *Client) Query(ctx context.Context, domain string) (*Response, error) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+
+	server, err := c.server(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.query(ctx, server, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref := resp.First("registrar whois server"); ref != "" && !strings.EqualFold(ref, server) {
+		if r, err := c.query(ctx, ref, domain); err == nil && len(r.Fields) > 0 {
+			return r, nil
+		}
+	}
+	return resp, nil
+}
+
+func (c *Client) server(ctx context.Context, domain string) (string, error) {
+	tld := domain
+	if i := strings.LastIndex(domain, "."); i >= 0 {
+		tld = domain[i+1:]
+	}
+
+	c.Lock()
+	server, found := c.servers[tld]
+	c.Unlock()
+	if found {
+		return server, nil
+	}
+
+	resp, err := c.query(ctx, c.IANA, tld)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain the WHOIS server for %s: %v", tld, err)
+	}
+
+	server = resp.First("refer", "whois")
+	if server == "" {
+		return "", fmt.Errorf("no WHOIS server is known for %s", tld)
+	}
+
+	c.SetServer(tld, server)
+	return server, nil
+}
+
+func (c *Client) query(ctx context.Context, server, query string) (*Response, error) {
+	addr, hostname := server, server
+	if h, _, err := net.SplitHostPort(server); err == nil {
+		hostname = h
+	} else {
+		addr = net.JoinHostPort(server, "43")
+	}
+	if err := c.Queue.Wait(ctx, hostname); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write([]byte(query + "\r\n")); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(conn, maxResponseSize))
+	if err != nil && len(data) == 0 {
+		return nil, err
+	}
+
+	raw := string(data)
+	if rateLimited(raw) {
+		c.Queue.RetryAfter(hostname, rateLimitBackoff)
+		return nil, fmt.Errorf("the WHOIS server %s is rate limiting the queries", server)
+	}
+
+	resp := Parse(raw)
+	resp.Server = server
+	return resp, nil
+}
+
+func rateLimited(raw string) bool {
+	// Rate limit messages are short, so a complete registration record is not checked
+	if len(raw) > 1024 {
+		return false
+	}
+
+	lower := strings.ToLower(raw)
+	for _, msg := range rateLimitMessages {
+		if strings.Contains(lower, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse extracts the key / value fields from the WHOIS response.
+func Parse(raw string) *Response {
+	resp := &Response{
+		Raw:    raw,
+		Fields: make(map[string][]string),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">>>") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		if value = strings.TrimSpace(value); key != "" && value != "" {
+			resp.Fields[key] = append(resp.Fields[key], value)
+		}
+	}
+	return resp
+}
+
+// First returns the first value found for the keys, in the order they were provided.
+func (r *Response) First(keys ...string) string {
+	for _, key := range keys {
+		if values := r.Fields[key]; len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// AbuseContacts returns the abuse contacts provided by the WHOIS response.
+func (r *Response) AbuseContacts() []*rdap.Contact {
+	email := r.First("registrar abuse contact email", "abuse-mailbox", "abuse contact email", "abuse-c email")
+	if email == "" {
+		return nil
+	}
+
+	return []*rdap.Contact{{
+		Name:  r.First("registrar", "registrar name", "sponsoring registrar"),
+		Email: email,
+		Phone: r.First("registrar abuse contact phone", "abuse contact phone"),
+	}}
+}
+
+// The layouts of the dates found in the WHOIS responses of the registries and registrars.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02-Jan-2006",
+	"2006.01.02",
+	"2006/01/02",
+	"02.01.2006",
+}
+
+// Registration returns the registration data provided by the WHOIS response, including the registrar,
+// the dates, the name servers, the statuses, the DNSSEC delegation and the abuse contacts.
+func (r *Response) Registration() *rdap.Registration {
+	reg := &rdap.Registration{
+		Handle:          r.First("registry domain id"),
+		Registrar:       r.First("registrar", "registrar name", "sponsoring registrar"),
+		RegistrarIANAID: r.First("registrar iana id"),
+		Created:         parseDate(r.First("creation date", "created", "registered", "registered on", "domain registration date")),
+		Updated:         parseDate(r.First("updated date", "last updated", "last-update", "last modified", "changed")),
+		Expires: parseDate(r.First("registry expiry date", "registrar registration expiration date",
+			"expiration date", "expiry date", "expires", "expires on", "paid-till", "renewal date")),
+		Abuse: r.AbuseContacts(),
+	}
+
+	for _, key := range []string{"name server", "nserver", "nameserver", "nameservers"} {
+		for _, v := range r.Fields[key] {
+			// Some registries append the addresses of the glue records to the name
+			if fields := strings.Fields(v); len(fields) > 0 {
+				reg.Nameservers = append(reg.Nameservers, strings.ToLower(strings.TrimSuffix(fields[0], ".")))
+			}
+		}
+	}
+	for _, key := range []string{"domain status", "status"} {
+		for _, v := range r.Fields[key] {
+			// The status codes are commonly followed by the URL of their description
+			if fields := strings.Fields(v); len(fields) > 0 && !strings.HasPrefix(fields[0], "http") {
+				reg.Statuses = append(reg.Statuses, fields[0])
+			}
+		}
+	}
+	if v := strings.ToLower(r.First("dnssec", "dnssec status")); v != "" {
+		signed := !strings.Contains(v, "unsigned") && !strings.HasPrefix(v, "no") &&
+			(strings.Contains(v, "signed") || strings.HasPrefix(v, "yes") || strings.Contains(v, "ds data"))
+		reg.DNSSEC = &signed
+	}
+	return reg
+}
+
+func parseDate(value string) time.Time {
+	// Some registries append the time zone name to the date
+	fields := strings.Fields(value)
+	if len(fields) > 2 {
+		value = strings.Join(fields[:2], " ")
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,147 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package whois
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/net/registry"
+)
+
+// startServer returns the address of a WHOIS server that replies using the responses map.
+func startServer(t *testing.T, responses map[string]string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start the WHOIS server: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			_, _ = conn.Write([]byte(responses[strings.TrimSpace(line)]))
+			_ = conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestQueryWithReferral(t *testing.T) {
+	registrar := startServer(t, map[string]string{
+		"owasp.example": `% Registry WHOIS
+Domain Name: OWASP.EXAMPLE
+Registrar: Example Registrar, Inc.
+Registrar Abuse Contact Email: abuse@registrar.example
+Registrar Abuse Contact Phone: +1.5550100
+`,
+	})
+	iana := startServer(t, map[string]string{
+		"example": "domain:       EXAMPLE\nrefer:        " + registrar + "\n",
+	})
+
+	c := NewClient()
+	c.IANA = iana
+	c.Queue = registry.NewQueue()
+
+	resp, err := c.Query(context.Background(), "OWASP.example.")
+	if err != nil {
+		t.Fatalf("The WHOIS query failed: %v", err)
+	}
+	if resp.Server != registrar {
+		t.Errorf("Expected the referred server %s, got %s", registrar, resp.Server)
+	}
+
+	contacts := resp.AbuseContacts()
+	if len(contacts) != 1 {
+		t.Fatalf("Expected one abuse contact, got %d", len(contacts))
+	}
+	if c := contacts[0]; c.Email != "abuse@registrar.example" || c.Phone != "+1.5550100" || c.Name != "Example Registrar, Inc." {
+		t.Errorf("Unexpected abuse contact: %v", *c)
+	}
+}
+
+func TestParse(t *testing.T) {
+	resp := Parse("# comment\nabuse-mailbox: abuse@example.net\nremarks: one\nremarks: two\nnot a field\n")
+
+	if got := resp.First("abuse-mailbox"); got != "abuse@example.net" {
+		t.Errorf("Unexpected abuse mailbox: %s", got)
+	}
+	if len(resp.Fields["remarks"]) != 2 {
+		t.Errorf("Expected both remarks values, got %v", resp.Fields["remarks"])
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	server := startServer(t, map[string]string{
+		"owasp.example": "%ERROR:201: access denied - query rate limit exceeded\n",
+	})
+
+	c := NewClient()
+	c.Queue = registry.NewQueue()
+	c.SetServer("example", server)
+
+	if _, err := c.Query(context.Background(), "owasp.example"); err == nil {
+		t.Errorf("Expected the rate limited query to return an error")
+	}
+}
+
+func TestRegistration(t *testing.T) {
+	for raw, want := range map[string]string{
+		"Registrar: Example Registrar\nRegistry Expiry Date: 2027-09-13T04:00:00Z\n":         "2027-09-13",
+		"registrar: Example Registrar\nexpire date: none\npaid-till: 2026-03-01T21:00:00Z\n": "2026-03-01",
+		"Registrar: Example Registrar\nExpiry date: 05-Jan-2027\n":                           "2027-01-05",
+		"Registrar: Example Registrar\nExpiration Date: 2027-02-10 15:04:05 CLST\n":          "2027-02-10",
+	} {
+		reg := Parse(raw).Registration()
+		if reg.Registrar != "Example Registrar" || reg.Expires.Format("2006-01-02") != want {
+			t.Errorf("Unexpected registration for %q: %+v", raw, reg)
+		}
+	}
+
+	if reg := Parse("Registrar: Example Registrar\n").Registration(); !reg.Expires.IsZero() || reg.DNSSEC != nil {
+		t.Errorf("Expected no expiration date and DNSSEC delegation: %+v", reg)
+	}
+
+	reg := Parse(`Registry Domain ID: 2336799_DOMAIN_COM-VRSN
+Registrar: Example Registrar
+Registrar IANA ID: 292
+Updated Date: 2023-08-14T07:01:38Z
+Domain Status: clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited
+Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+Name Server: NS1.EXAMPLE.NET
+Name Server: ns2.example.net 192.0.2.53
+DNSSEC: signedDelegation
+Registrar Abuse Contact Email: abuse@registrar.example
+`).Registration()
+	attrs := reg.Attributes()
+	for key, want := range map[string]string{
+		"handle":            "2336799_DOMAIN_COM-VRSN",
+		"registrar_iana_id": "292",
+		"updated":           "2023-08-14T07:01:38Z",
+		"statuses":          "clientDeleteProhibited,clientTransferProhibited",
+		"nameservers":       "ns1.example.net,ns2.example.net",
+		"dnssec":            "true",
+	} {
+		if attrs[key] != want {
+			t.Errorf("Expected the %s attribute %q, got %q", key, want, attrs[key])
+		}
+	}
+	if len(reg.Abuse) != 1 || reg.Abuse[0].Email != "abuse@registrar.example" {
+		t.Errorf("Unexpected abuse contacts: %v", reg.Abuse)
+	}
+
+	if reg := Parse("DNSSEC: unsigned\n").Registration(); reg.DNSSEC == nil || *reg.DNSSEC {
+		t.Error("Expected the unsigned delegation")
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package pdns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// TypeDNSHistory is the type of the findings recorded for the historical resolutions of the in-scope names.
+const TypeDNSHistory = "dns_history"
+
+// HistoryTypes are the record types requested from the DNS history providers.
+var HistoryTypes = []string{"A", "AAAA", "NS", "MX"}
+
+// HistorySource is a DNS history provider that can be queried for the past resolutions of a name.
+type HistorySource interface {
+	// Name returns the name of the source used in the configuration.
+	Name() string
+	// History returns the resolutions of the name observed by the provider.
+	History(ctx context.Context, name string) ([]*Resolution, error)
+}
+
+// HistorySources are the constructors of the DNS history sources that can be selected by name.
+// The API key is provided by the credentials of the data source with the same name.
+var HistorySources = map[string]func(key string) HistorySource{
+	"validin": func(key string) HistorySource { return NewValidin(key) },
+}
+
+// HistorySourcesByName returns the DNS history sources with the provided names, using the API keys
+// returned by the key function for each name.
+func HistorySourcesByName(names []string, key func(name string) string) ([]HistorySource, error) {
+	var srcs []HistorySource
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		fn, found := HistorySources[name]
+		if !found {
+			return nil, fmt.Errorf("%s is not a supported DNS history source", name)
+		}
+
+		k := key(name)
+		if k == "" {
+			return nil, fmt.Errorf("the %s DNS history source requires an API key", name)
+		}
+		srcs = append(srcs, fn(k))
+	}
+	return srcs, nil
+}
+
+// Resolution is a record of the name observed by a DNS history provider between the two dates.
+type Resolution struct {
+	Name      string
+	Type      string
+	Value     string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Sources   []string
+}
+
+// History returns the historical resolutions of the name reported by the sources, with the resolutions
+// of the same record merged across the sources, sorted by the type, the first date and the value.
+func History(ctx context.Context, srcs []HistorySource, name string) ([]*Resolution, error) {
+	byRecord := make(map[string]*Resolution)
+	var msgs []string
+
+	for _, src := range srcs {
+		res, err := src.History(ctx, name)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %s: %v", src.Name(), name, err))
+			continue
+		}
+
+		for _, r := range res {
+			value := strings.ToLower(strings.Trim(strings.TrimSpace(r.Value), "."))
+			rrtype := strings.ToUpper(r.Type)
+			if value == "" || rrtype == "" {
+				continue
+			}
+
+			key := rrtype + "|" + value
+			m, found := byRecord[key]
+			if !found {
+				m = &Resolution{Name: name, Type: rrtype, Value: value, FirstSeen: r.FirstSeen, LastSeen: r.LastSeen}
+				byRecord[key] = m
+			}
+			if !r.FirstSeen.IsZero() && (m.FirstSeen.IsZero() || r.FirstSeen.Before(m.FirstSeen)) {
+				m.FirstSeen = r.FirstSeen
+			}
+			if r.LastSeen.After(m.LastSeen) {
+				m.LastSeen = r.LastSeen
+			}
+			m.Sources = appendUnique(m.Sources, src.Name())
+		}
+	}
+
+	results := make([]*Resolution, 0, len(byRecord))
+	for _, r := range byRecord {
+		sort.Strings(r.Sources)
+		results = append(results, r)
+	}
+	sortResolutions(results)
+
+	if len(msgs) > 0 {
+		return results, errors.New(strings.Join(msgs, "; "))
+	}
+	return results, nil
+}
+
+// Finding returns the finding recorded for the historical resolution.
+func (r *Resolution) Finding() *findings.Finding {
+	return &findings.Finding{
+		Asset:    r.Name,
+		Type:     TypeDNSHistory,
+		Severity: findings.SeverityInfo,
+		Title:    fmt.Sprintf("%s record %s observed from %s to %s", r.Type, r.Value, day(r.FirstSeen), day(r.LastSeen)),
+		Attributes: map[string]string{
+			"type":       r.Type,
+			"value":      r.Value,
+			"first_seen": r.FirstSeen.UTC().Format(time.RFC3339),
+			"last_seen":  r.LastSeen.UTC().Format(time.RFC3339),
+			"sources":    strings.Join(r.Sources, ","),
+		},
+	}
+}
+
+// Timeline returns the historical resolutions of the names accepted by the inScope function, from the
+// dns_history findings, sorted by the name, the type and the first date. The findings recorded by
+// later enumerations extend the dates of the same records.
+func Timeline(fs []*findings.Finding, inScope func(name string) bool) []*Resolution {
+	byRecord := make(map[string]*Resolution)
+
+	for _, f := range fs {
+		if f.Type != TypeDNSHistory || (inScope != nil && !inScope(f.Asset)) {
+			continue
+		}
+
+		first, err := time.Parse(time.RFC3339, f.Attributes["first_seen"])
+		if err != nil {
+			continue
+		}
+		last, err := time.Parse(time.RFC3339, f.Attributes["last_seen"])
+		if err != nil {
+			continue
+		}
+
+		key := strings.Join([]string{f.Asset, f.Attributes["type"], f.Attributes["value"]}, "|")
+		r, found := byRecord[key]
+		if !found {
+			r = &Resolution{Name: f.Asset, Type: f.Attributes["type"], Value: f.Attributes["value"], FirstSeen: first, LastSeen: last}
+			byRecord[key] = r
+		}
+		if first.Before(r.FirstSeen) {
+			r.FirstSeen = first
+		}
+		if last.After(r.LastSeen) {
+			r.LastSeen = last
+		}
+		for _, src := range strings.Split(f.Attributes["sources"], ",") {
+			if src != "" {
+				r.Sources = appendUnique(r.Sources, src)
+			}
+		}
+	}
+
+	results := make([]*Resolution, 0, len(byRecord))
+	for _, r := range byRecord {
+		sort.Strings(r.Sources)
+		results = append(results, r)
+	}
+	sortResolutions(results)
+	return results
+}
+
+func sortResolutions(res []*Resolution) {
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if !a.FirstSeen.Equal(b.FirstSeen) {
+			return a.FirstSeen.Before(b.FirstSeen)
+		}
+		return a.Value < b.Value
+	})
+}
+
+func day(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.UTC().Format("2006-01-02")
+}
+
+// Validin queries the DNS history of the Validin service.
+type Validin struct {
+	// BaseURL is the address of the DNS history API, with the name appended to it
+	BaseURL string
+	Key     string
+	HTTP    *http.Client
+}
+
+type validinRecord struct {
+	Value     string `json:"value"`
+	FirstSeen int64  `json:"first_seen"`
+	LastSeen  int64  `json:"last_seen"`
+}
+
+type validinResponse struct {
+	Records map[string][]validinRecord `json:"records"`
+}
+
+// NewValidin returns the Validin DNS history source.
+func NewValidin(key string) *Validin {
+	return &Validin{
+		BaseURL: "https://app.validin.com/api/axon/domain/dns/history/",
+		Key:     key,
+		HTTP: amasshttp.NewClient("validin", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Cache:   true,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// Name implements the HistorySource interface.
+func (v *Validin) Name() string {
+	return "validin"
+}
+
+// History implements the HistorySource interface.
+func (v *Validin) History(ctx context.Context, name string) ([]*Resolution, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.BaseURL+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+v.Key)
+
+	resp, err := v.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the lookup returned status %d", resp.StatusCode)
+	}
+
+	var body validinResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode the DNS history: %v", err)
+	}
+
+	var results []*Resolution
+	for rrtype, records := range body.Records {
+		rrtype = strings.ToUpper(rrtype)
+		if !historyType(rrtype) {
+			continue
+		}
+
+		for _, rec := range records {
+			results = append(results, &Resolution{
+				Name:      name,
+				Type:      rrtype,
+				Value:     rec.Value,
+				FirstSeen: unixTime(rec.FirstSeen),
+				LastSeen:  unixTime(rec.LastSeen),
+			})
+		}
+	}
+	return results, nil
+}
+
+func historyType(rrtype string) bool {
+	for _, t := range HistoryTypes {
+		if t == rrtype {
+			return true
+		}
+	}
+	return false
+}
+
+func unixTime(sec int64) time.Time {
+	if sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
@@ -0,0 +1,179 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pdns queries the reverse endpoints of passive DNS providers for the names historically
+// hosted at an address. The registered domains sharing an address with the target are candidates
+// for association, weighted by how exclusively the address was used by them.
+package pdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Types of the findings recorded from the passive DNS results.
+const (
+	// TypeCoHosted is the type of the findings recorded for the candidate associations
+	TypeCoHosted = "cohosted_domain"
+	// TypeSharedHosting is the type of the findings recorded for the addresses used by
+	// more than MaxCoHosted registered domains
+	TypeSharedHosting = "shared_hosting"
+)
+
+// MaxCoHosted is the number of registered domains hosted at an address, beyond which the address
+// is considered shared hosting and does not provide candidates for association.
+const MaxCoHosted = 25
+
+// Source is a passive DNS provider that can be queried for the names hosted at an address.
+type Source interface {
+	// Name returns the name of the source used in the configuration.
+	Name() string
+	// Reverse returns the names that have resolved to the address.
+	Reverse(ctx context.Context, addr string) ([]string, error)
+}
+
+// Sources are the constructors of the passive DNS sources that can be selected by name.
+var Sources = map[string]func() Source{
+	"hackertarget": func() Source { return NewHackerTarget("") },
+	"mnemonic":     func() Source { return NewMnemonic() },
+}
+
+// SourcesByName returns the passive DNS sources with the provided names.
+func SourcesByName(names []string) ([]Source, error) {
+	var srcs []Source
+
+	for _, name := range names {
+		fn, found := Sources[strings.ToLower(strings.TrimSpace(name))]
+		if !found {
+			return nil, fmt.Errorf("%s is not a supported passive DNS source", name)
+		}
+		srcs = append(srcs, fn())
+	}
+	return srcs, nil
+}
+
+// Hosted returns the names historically hosted at the address, mapped to the sources that reported them.
+func Hosted(ctx context.Context, srcs []Source, addr string) (map[string][]string, error) {
+	hosted := make(map[string][]string)
+	var msgs []string
+
+	for _, src := range srcs {
+		names, err := src.Reverse(ctx, addr)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %s: %v", src.Name(), addr, err))
+			continue
+		}
+
+		for _, n := range names {
+			n = strings.ToLower(strings.Trim(strings.TrimSpace(n), "."))
+			if n == "" || strings.Contains(n, " ") {
+				continue
+			}
+			hosted[n] = appendUnique(hosted[n], src.Name())
+		}
+	}
+	if len(msgs) > 0 {
+		return hosted, errors.New(strings.Join(msgs, "; "))
+	}
+	return hosted, nil
+}
+
+// RegisteredDomains returns the number of registered domains the hosted names belong to.
+func RegisteredDomains(hosted map[string][]string) int {
+	domains := make(map[string]struct{})
+
+	for name := range hosted {
+		if domain, err := publicsuffix.EffectiveTLDPlusOne(name); err == nil {
+			domains[domain] = struct{}{}
+		}
+	}
+	return len(domains)
+}
+
+// Candidate is a registered domain that shares an address with the target.
+type Candidate struct {
+	Address string
+	Domain  string
+	// Names are the names within the domain hosted at the address
+	Names   []string
+	Sources []string
+	// Exclusivity is the share of the registered domains hosted at the address held by the
+	// domain, which is highest when the target and the domain are the only ones using it
+	Exclusivity float64
+}
+
+// Candidates returns the registered domains hosted at the address that are not in scope, sorted by
+// the name. Nil is returned when the address hosts more than MaxCoHosted registered domains.
+func Candidates(addr string, hosted map[string][]string, inScope func(name string) bool) []*Candidate {
+	byDomain := make(map[string]*Candidate)
+
+	for name, srcs := range hosted {
+		domain, err := publicsuffix.EffectiveTLDPlusOne(name)
+		if err != nil {
+			continue
+		}
+
+		c, found := byDomain[domain]
+		if !found {
+			c = &Candidate{Address: addr, Domain: domain}
+			byDomain[domain] = c
+		}
+		c.Names = appendUnique(c.Names, name)
+		for _, src := range srcs {
+			c.Sources = appendUnique(c.Sources, src)
+		}
+	}
+	if len(byDomain) > MaxCoHosted {
+		return nil
+	}
+
+	var candidates []*Candidate
+	for domain, c := range byDomain {
+		// The in-scope domains count towards the co-hosting, but are not candidates
+		if inScope != nil && inScope(domain) {
+			continue
+		}
+
+		sort.Strings(c.Names)
+		sort.Strings(c.Sources)
+		c.Exclusivity = 1 / float64(len(byDomain))
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Domain < candidates[j].Domain
+	})
+	return candidates
+}
+
+// Finding returns the finding recorded for the candidate association.
+func (c *Candidate) Finding() *findings.Finding {
+	return &findings.Finding{
+		Asset:    c.Domain,
+		Type:     TypeCoHosted,
+		Severity: findings.SeverityInfo,
+		Title:    fmt.Sprintf("%s was hosted at %s with the target", c.Domain, c.Address),
+		Attributes: map[string]string{
+			"address":     c.Address,
+			"names":       strings.Join(c.Names, ","),
+			"sources":     strings.Join(c.Sources, ","),
+			"exclusivity": strconv.FormatFloat(c.Exclusivity, 'f', 3, 64),
+		},
+	}
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,167 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package pdns
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestHosted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasPrefix(req.URL.Path, "/reverseiplookup/"):
+			if req.URL.Query().Get("q") != "192.0.2.1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, "www.owasp.org\nmail.owasp-foundation.org\n")
+		case req.URL.Path == "/pdns/v3/192.0.2.1":
+			fmt.Fprint(w, `{"responseCode":200,"data":[
+				{"query":"Shop.Owasp-Foundation.org.","answer":"192.0.2.1","rrtype":"a"},
+				{"query":"www.owasp.org","answer":"192.0.2.1","rrtype":"a"},
+				{"query":"other.example.com","answer":"192.0.2.9","rrtype":"a"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ht := NewHackerTarget("")
+	ht.BaseURL = srv.URL + "/reverseiplookup/"
+	mn := NewMnemonic()
+	mn.BaseURL = srv.URL + "/pdns/v3/"
+
+	hosted, err := Hosted(context.Background(), []Source{ht, mn}, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Failed to query the passive DNS sources: %v", err)
+	}
+	if len(hosted) != 3 {
+		t.Fatalf("Expected three hosted names, got %v", hosted)
+	}
+	if srcs := hosted["www.owasp.org"]; len(srcs) != 2 {
+		t.Errorf("Expected both sources to report www.owasp.org, got %v", srcs)
+	}
+	if _, found := hosted["shop.owasp-foundation.org"]; !found {
+		t.Error("Expected the names to be normalized")
+	}
+
+	if _, err := Hosted(context.Background(), []Source{ht}, "192.0.2.2"); err == nil {
+		t.Error("Expected an error when the source fails")
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	hosted := map[string][]string{
+		"www.owasp.org":             {"mnemonic"},
+		"mail.owasp-foundation.org": {"hackertarget"},
+		"shop.owasp-foundation.org": {"mnemonic"},
+	}
+	inScope := func(name string) bool { return name == "owasp.org" }
+
+	got := Candidates("192.0.2.1", hosted, inScope)
+	if len(got) != 1 || got[0].Domain != "owasp-foundation.org" {
+		t.Fatalf("Unexpected candidates: %+v", got)
+	}
+	if c := got[0]; c.Exclusivity != 0.5 || len(c.Names) != 2 || len(c.Sources) != 2 {
+		t.Errorf("Unexpected candidate: %+v", c)
+	}
+	if f := got[0].Finding(); f.Type != TypeCoHosted || f.Attributes["exclusivity"] != "0.500" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+
+	shared := make(map[string][]string)
+	for i := 0; i <= MaxCoHosted; i++ {
+		shared[fmt.Sprintf("www.tenant%d.com", i)] = []string{"mnemonic"}
+	}
+	if n := RegisteredDomains(shared); n != MaxCoHosted+1 {
+		t.Errorf("Expected %d registered domains, got %d", MaxCoHosted+1, n)
+	}
+	if got := Candidates("192.0.2.1", shared, inScope); got != nil {
+		t.Errorf("Expected no candidates for shared hosting, got %d", len(got))
+	}
+
+	if _, err := SourcesByName([]string{"Mnemonic", "hackertarget"}); err != nil {
+		t.Errorf("Failed to select the sources: %v", err)
+	}
+	if _, err := SourcesByName([]string{"dnsdb"}); err == nil {
+		t.Error("Expected an error for an unsupported source")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req.URL.Path != "/history/www.owasp.org" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"records":{
+			"A":[{"value":"192.0.2.1","first_seen":1577836800,"last_seen":1609459200},
+				{"value":"192.0.2.2","first_seen":1609459200,"last_seen":1640995200}],
+			"NS":[{"value":"NS1.OWASP.ORG.","first_seen":1577836800,"last_seen":1640995200}],
+			"TXT":[{"value":"v=spf1 -all","first_seen":1577836800,"last_seen":1640995200}]}}`)
+	}))
+	defer srv.Close()
+
+	v := NewValidin("secret")
+	v.BaseURL = srv.URL + "/history/"
+
+	res, err := History(context.Background(), []HistorySource{v}, "www.owasp.org")
+	if err != nil {
+		t.Fatalf("Failed to query the DNS history: %v", err)
+	}
+	if len(res) != 3 {
+		t.Fatalf("Expected three historical resolutions, got %d", len(res))
+	}
+	if res[0].Type != "A" || res[0].Value != "192.0.2.1" || res[1].Value != "192.0.2.2" {
+		t.Errorf("Expected the A records sorted by the first date, got %v and %v", res[0], res[1])
+	}
+	if res[2].Type != "NS" || res[2].Value != "ns1.owasp.org" {
+		t.Errorf("Expected the normalized name server, got %v", res[2])
+	}
+
+	f := res[0].Finding()
+	if f.Type != TypeDNSHistory || f.Attributes["first_seen"] != "2020-01-01T00:00:00Z" || f.Attributes["sources"] != "validin" {
+		t.Errorf("Unexpected finding for the historical resolution: %v", f)
+	}
+
+	v.Key = "wrong"
+	if _, err := History(context.Background(), []HistorySource{v}, "www.owasp.org"); err == nil {
+		t.Error("Expected an error when the source rejects the key")
+	}
+	if _, err := HistorySourcesByName([]string{"validin"}, func(string) string { return "" }); err == nil {
+		t.Error("Expected an error when the API key is missing")
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	earlier := (&Resolution{Name: "www.owasp.org", Type: "A", Value: "192.0.2.1", Sources: []string{"validin"},
+		FirstSeen: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), LastSeen: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}).Finding()
+	later := (&Resolution{Name: "www.owasp.org", Type: "A", Value: "192.0.2.1", Sources: []string{"validin"},
+		FirstSeen: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), LastSeen: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}).Finding()
+	other := (&Resolution{Name: "www.example.com", Type: "MX", Value: "mail.example.com",
+		FirstSeen: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), LastSeen: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}).Finding()
+
+	timeline := Timeline([]*findings.Finding{earlier, later, other}, func(name string) bool {
+		return strings.HasSuffix(name, "owasp.org")
+	})
+	if len(timeline) != 1 {
+		t.Fatalf("Expected one resolution in the timeline, got %d", len(timeline))
+	}
+	if r := timeline[0]; r.FirstSeen.Year() != 2020 || r.FirstSeen.Month() != time.January || r.LastSeen.Year() != 2022 {
+		t.Errorf("Expected the findings to extend the dates of the record, got %v to %v", r.FirstSeen, r.LastSeen)
+	}
+}
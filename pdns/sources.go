@@ -0,0 +1,151 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package pdns
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	maxBodySize    = 16 * 1024 * 1024
+)
+
+// HackerTarget queries the reverse IP lookup of the HackerTarget service.
+type HackerTarget struct {
+	// BaseURL is the address of the reverse IP lookup API
+	BaseURL string
+	// Key is the optional API key that raises the daily quota
+	Key  string
+	HTTP *http.Client
+}
+
+// NewHackerTarget returns the HackerTarget passive DNS source.
+func NewHackerTarget(key string) *HackerTarget {
+	return &HackerTarget{
+		BaseURL: "https://api.hackertarget.com/reverseiplookup/",
+		Key:     key,
+		HTTP: amasshttp.NewClient("hackertarget", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Cache:   true,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// Name implements the Source interface.
+func (h *HackerTarget) Name() string {
+	return "hackertarget"
+}
+
+// Reverse implements the Source interface.
+func (h *HackerTarget) Reverse(ctx context.Context, addr string) ([]string, error) {
+	q := url.Values{"q": []string{addr}}
+	if h.Key != "" {
+		q.Set("apikey", h.Key)
+	}
+
+	body, err := get(ctx, h.HTTP, h.BaseURL+"?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// The service provides errors, such as an exceeded quota, in the body of successful responses
+		if strings.HasPrefix(strings.ToLower(line), "api count exceeded") || strings.HasPrefix(line, "error") {
+			return nil, fmt.Errorf("the lookup failed: %s", line)
+		}
+		if line != "" && !strings.Contains(line, " ") {
+			names = append(names, line)
+		}
+	}
+	return names, scanner.Err()
+}
+
+// Mnemonic queries the passive DNS service of Mnemonic.
+type Mnemonic struct {
+	// BaseURL is the address of the passive DNS API, with the address appended to it
+	BaseURL string
+	HTTP    *http.Client
+}
+
+type mnemonicResponse struct {
+	ResponseCode int `json:"responseCode"`
+	Data         []struct {
+		Query  string `json:"query"`
+		Answer string `json:"answer"`
+		RRType string `json:"rrtype"`
+	} `json:"data"`
+}
+
+// NewMnemonic returns the Mnemonic passive DNS source.
+func NewMnemonic() *Mnemonic {
+	return &Mnemonic{
+		BaseURL: "https://api.mnemonic.no/pdns/v3/",
+		HTTP: amasshttp.NewClient("mnemonic", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Cache:   true,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// Name implements the Source interface.
+func (m *Mnemonic) Name() string {
+	return "mnemonic"
+}
+
+// Reverse implements the Source interface.
+func (m *Mnemonic) Reverse(ctx context.Context, addr string) ([]string, error) {
+	body, err := get(ctx, m.HTTP, m.BaseURL+url.PathEscape(addr)+"?limit=1000")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var resp mnemonicResponse
+	if err := json.NewDecoder(io.LimitReader(body, maxBodySize)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode the passive DNS records: %v", err)
+	}
+
+	var names []string
+	for _, rec := range resp.Data {
+		if (rec.RRType == "a" || rec.RRType == "aaaa") && rec.Answer == addr && rec.Query != "" {
+			names = append(names, rec.Query)
+		}
+	}
+	return names, nil
+}
+
+func get(ctx context.Context, client *http.Client, u string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("the lookup returned status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
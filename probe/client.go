@@ -0,0 +1,131 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package probe
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errNotFound = errors.New("the probe does not serve the path")
+
+// Client is used by the engine to task a remote probe agent.
+type Client struct {
+	URL    string
+	Token  string
+	Region string
+	HTTP   *http.Client
+	// Version is the negotiated protocol version, which is zero until Negotiate succeeds
+	Version int
+}
+
+// NewClient returns a Client for the probe agent at the provided base URL.
+func NewClient(baseURL, token, region string) *Client {
+	return &Client{
+		URL:    strings.TrimSuffix(baseURL, "/"),
+		Token:  token,
+		Region: region,
+		HTTP:   &http.Client{Timeout: requestTimeout + requestTimeout/2},
+	}
+}
+
+// SetTLSConfig sets the configuration used to verify the probe and authenticate the engine over TLS.
+func (c *Client) SetTLSConfig(cfg *tls.Config) {
+	c.HTTP.Transport = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: cfg,
+	}
+}
+
+// Negotiate agrees on the protocol version with the probe, so mismatched releases fail before the
+// probe is tasked. Probes that predate the negotiation do not serve the version path, and speak version 1.
+func (c *Client) Negotiate(ctx context.Context) error {
+	remote := &VersionInfo{Version: 1, MinVersion: 1}
+
+	if err := c.post(ctx, VersionPath, LocalVersion(), remote); err != nil && err != errNotFound {
+		return err
+	}
+
+	version, err := Negotiate(LocalVersion(), remote)
+	if err != nil {
+		return fmt.Errorf("the probe at %s: %v", c.URL, err)
+	}
+
+	c.Version = version
+	return nil
+}
+
+// Resolve asks the probe to resolve the name for the record type from its vantage point.
+func (c *Client) Resolve(ctx context.Context, name string, qtype uint16) (*DNSResponse, error) {
+	var resp DNSResponse
+
+	if err := c.post(ctx, DNSPath, &DNSRequest{Name: name, Type: qtype}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Region == "" {
+		resp.Region = c.Region
+	}
+	return &resp, nil
+}
+
+// Fetch asks the probe to perform a GET request for the URL from its vantage point.
+func (c *Client) Fetch(ctx context.Context, u string) (*HTTPResponse, error) {
+	var resp HTTPResponse
+
+	if err := c.post(ctx, HTTPPath, &HTTPRequest{URL: u}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Region == "" {
+		resp.Region = c.Region
+	}
+	return &resp, nil
+}
+
+func (c *Client) post(ctx context.Context, path string, body, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Version > 0 {
+		req.Header.Set(VersionHeader, strconv.Itoa(c.Version))
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && path == VersionPath {
+		return errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e Error
+
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("the probe at %s returned status %d: %s", c.URL, resp.StatusCode, e.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("the probe at %s returned a response that could not be decoded (protocol version %s): %v",
+			c.URL, resp.Header.Get(VersionHeader), err)
+	}
+	return nil
+}
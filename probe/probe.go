@@ -0,0 +1,96 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package probe implements the lightweight agents that perform DNS and HTTP requests
+// from other vantage points, and the client used by the engine to task them.
+package probe
+
+import "fmt"
+
+// API paths served by the probe agents.
+const (
+	DNSPath     = "/v1/dns"
+	HTTPPath    = "/v1/http"
+	VersionPath = "/v1/version"
+)
+
+// The range of protocol versions spoken by this release. The protocol version is increased
+// whenever the requests or responses change in a way that older releases cannot decode.
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+)
+
+// VersionHeader carries the protocol version used by the requests and responses.
+const VersionHeader = "Amass-Probe-Version"
+
+// VersionInfo is exchanged by the engine and the probe agents to negotiate the protocol version.
+type VersionInfo struct {
+	Version    int `json:"version"`
+	MinVersion int `json:"min_version"`
+}
+
+// LocalVersion returns the range of protocol versions spoken by this release.
+func LocalVersion() *VersionInfo {
+	return &VersionInfo{Version: ProtocolVersion, MinVersion: MinProtocolVersion}
+}
+
+// Negotiate returns the highest protocol version spoken by both parties, or an error
+// explaining which party must be upgraded when there is no such version.
+func Negotiate(local, remote *VersionInfo) (int, error) {
+	version := local.Version
+	if remote.Version < version {
+		version = remote.Version
+	}
+
+	if version < local.MinVersion {
+		return 0, fmt.Errorf("the probe speaks protocol versions %d to %d, but this release of Amass requires version %d or later: upgrade the probe",
+			remote.MinVersion, remote.Version, local.MinVersion)
+	}
+	if version < remote.MinVersion {
+		return 0, fmt.Errorf("the probe requires protocol version %d or later, but this release of Amass speaks versions %d to %d: upgrade Amass",
+			remote.MinVersion, local.MinVersion, local.Version)
+	}
+	return version, nil
+}
+
+// Answer is a DNS resource record returned by a probe.
+type Answer struct {
+	Name string `json:"name"`
+	Type uint16 `json:"type"`
+	TTL  uint32 `json:"ttl"`
+	Data string `json:"data"`
+}
+
+// DNSRequest asks the probe to resolve the name for the record type.
+type DNSRequest struct {
+	Name string `json:"name"`
+	Type uint16 `json:"type"`
+}
+
+// DNSResponse contains the answers obtained from the vantage point of the probe.
+type DNSResponse struct {
+	Region  string   `json:"region"`
+	Rcode   int      `json:"rcode"`
+	Answers []Answer `json:"answers,omitempty"`
+}
+
+// HTTPRequest asks the probe to perform a GET request for the URL.
+type HTTPRequest struct {
+	URL string `json:"url"`
+}
+
+// HTTPResponse contains the results of the request made from the vantage point of the probe.
+type HTTPResponse struct {
+	Region     string              `json:"region"`
+	StatusCode int                 `json:"status_code"`
+	Header     map[string][]string `json:"header,omitempty"`
+	Names      []string            `json:"names,omitempty"`
+	Length     int                 `json:"length"`
+}
+
+// Error is the response body returned when a request cannot be performed.
+type Error struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,239 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package probe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func startDNSServer(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start the DNS server: %v", err)
+	}
+
+	mux := dns.NewServeMux()
+	mux.HandleFunc("owasp.org.", func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		rr, _ := dns.NewRR("owasp.org. 300 IN A 192.0.2.10")
+		m.Answer = append(m.Answer, rr)
+		_ = w.WriteMsg(m)
+	})
+
+	srv := &dns.Server{PacketConn: pc, Handler: mux}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+func TestProbeResolve(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0", "eu-west", startDNSServer(t), []string{"secret", "other"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to start the probe: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c := NewClient("http://"+s.Addr(), "secret", "")
+	resp, err := c.Resolve(ctx, "owasp.org", dns.TypeA)
+	if err != nil {
+		t.Fatalf("The probe failed to resolve the name: %v", err)
+	}
+	if resp.Region != "eu-west" {
+		t.Errorf("Expected the region eu-west, got %s", resp.Region)
+	}
+	if len(resp.Answers) != 1 || resp.Answers[0].Data != "192.0.2.10" {
+		t.Errorf("Unexpected answers returned by the probe: %v", resp.Answers)
+	}
+
+	other := NewClient("http://"+s.Addr(), "other", "")
+	if _, err := other.Resolve(ctx, "owasp.org", dns.TypeA); err != nil {
+		t.Errorf("Expected the probe to accept each of the tokens: %v", err)
+	}
+
+	bad := NewClient("http://"+s.Addr(), "wrong", "")
+	if _, err := bad.Resolve(ctx, "owasp.org", dns.TypeA); err == nil {
+		t.Errorf("Expected the probe to reject the request with the wrong token")
+	}
+}
+
+func TestProbeLoopback(t *testing.T) {
+	resolver := startDNSServer(t)
+
+	for _, addr := range []string{":0", "0.0.0.0:0", "[::]:0", "192.0.2.1:0"} {
+		if s, err := NewServer(addr, "eu-west", resolver, nil, nil); err == nil {
+			_ = s.Close()
+			t.Errorf("Expected the probe without a token to be refused on %s", addr)
+		}
+	}
+	for _, addr := range []string{"127.0.0.1:0", "[::1]:0", "localhost:0"} {
+		if !LoopbackAddr(addr) {
+			t.Errorf("Expected %s to be a loopback address", addr)
+		}
+	}
+
+	s, err := NewServer("127.0.0.1:0", "eu-west", resolver, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected the probe without a token to be served on the loopback address: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c := NewClient("http://"+s.Addr(), "", "")
+	if _, err := c.Resolve(ctx, "owasp.org", dns.TypeA); err != nil {
+		t.Errorf("The probe failed to resolve the name on the loopback address: %v", err)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0", "eu-west", startDNSServer(t), []string{"secret"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to start the probe: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c := NewClient("http://"+s.Addr(), "secret", "")
+	if err := c.Negotiate(ctx); err != nil || c.Version != ProtocolVersion {
+		t.Fatalf("Failed to negotiate the protocol version: %v", err)
+	}
+	if _, err := c.Resolve(ctx, "owasp.org", dns.TypeA); err != nil {
+		t.Errorf("The probe failed to resolve the name using the negotiated version: %v", err)
+	}
+
+	c.Version = ProtocolVersion + 1
+	if _, err := c.Resolve(ctx, "owasp.org", dns.TypeA); err == nil {
+		t.Error("Expected the probe to reject the unsupported protocol version")
+	}
+
+	// Probes that predate the negotiation do not serve the version path
+	legacy := httptest.NewServer(http.NotFoundHandler())
+	defer legacy.Close()
+
+	old := NewClient(legacy.URL, "", "")
+	if err := old.Negotiate(ctx); err != nil || old.Version != 1 {
+		t.Errorf("Expected the legacy probe to speak version 1: %v", err)
+	}
+
+	if _, err := Negotiate(&VersionInfo{Version: 3, MinVersion: 2}, &VersionInfo{Version: 1, MinVersion: 1}); err == nil {
+		t.Error("Expected an error when the probe is too old")
+	}
+	if _, err := Negotiate(&VersionInfo{Version: 1, MinVersion: 1}, &VersionInfo{Version: 3, MinVersion: 2}); err == nil {
+		t.Error("Expected an error when the engine is too old")
+	}
+	if v, err := Negotiate(&VersionInfo{Version: 3, MinVersion: 1}, &VersionInfo{Version: 2, MinVersion: 1}); err != nil || v != 2 {
+		t.Errorf("Expected version 2 to be negotiated, got %d: %v", v, err)
+	}
+}
+
+// writeCert creates a certificate signed by the parent, or a self-signed CA when the parent is nil,
+// and writes the certificate and key files to the directory.
+func writeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the %s key: %v", name, err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create the %s certificate: %v", name, err)
+	}
+	kder, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal the %s key: %v", name, err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kder})
+	if err := os.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0600); err != nil {
+		t.Fatalf("Failed to write the %s certificate: %v", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600); err != nil {
+		t.Fatalf("Failed to write the %s key: %v", name, err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func TestProbeMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", nil, nil)
+	writeCert(t, dir, "probe", ca, caKey)
+	writeCert(t, dir, "engine", ca, caKey)
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	scfg, err := ServerTLSConfig(path("probe.crt"), path("probe.key"), path("ca.crt"))
+	if err != nil {
+		t.Fatalf("Failed to create the server TLS configuration: %v", err)
+	}
+	s, err := NewServer("127.0.0.1:0", "eu-west", startDNSServer(t), []string{"secret"}, scfg)
+	if err != nil {
+		t.Fatalf("Failed to start the probe: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ccfg, err := ClientTLSConfig(path("ca.crt"), path("engine.crt"), path("engine.key"))
+	if err != nil {
+		t.Fatalf("Failed to create the client TLS configuration: %v", err)
+	}
+	c := NewClient("https://"+s.Addr(), "secret", "")
+	c.SetTLSConfig(ccfg)
+	if _, err := c.Resolve(ctx, "owasp.org", dns.TypeA); err != nil {
+		t.Fatalf("The probe failed to resolve the name over mutual TLS: %v", err)
+	}
+
+	nocert, err := ClientTLSConfig(path("ca.crt"), "", "")
+	if err != nil {
+		t.Fatalf("Failed to create the client TLS configuration: %v", err)
+	}
+	bad := NewClient("https://"+s.Addr(), "secret", "")
+	bad.SetTLSConfig(nocert)
+	if _, err := bad.Resolve(ctx, "owasp.org", dns.TypeA); err == nil {
+		t.Errorf("Expected the probe to reject the engine without a client certificate")
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package probe
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	requestTimeout  = 20 * time.Second
+	maxBodySize     = 1 << 20
+	maxRequestBytes = 4096
+)
+
+// Server is a probe agent that performs DNS and HTTP requests on behalf of the engine.
+type Server struct {
+	region   string
+	tokens   []string
+	resolver string
+	ln       net.Listener
+	server   *http.Server
+	client   *http.Client
+}
+
+// NewServer starts the probe agent API on the provided address. The resolver is the
+// address of the DNS server used by the probe, and the engine must provide one of the
+// tokens when any are configured. The API is served over TLS when tlsConfig is not nil.
+// Without the tokens or client certificates, the API is only served on the loopback addresses.
+func NewServer(addr, region, resolver string, tokens []string, tlsConfig *tls.Config) (*Server, error) {
+	if len(tokens) == 0 && (tlsConfig == nil || tlsConfig.ClientCAs == nil) && !LoopbackAddr(addr) {
+		return nil, fmt.Errorf("the probe requires a token or client certificates to be served on %s, which is not a loopback address", addr)
+	}
+	if resolver == "" {
+		return nil, errors.New("the probe requires a DNS resolver")
+	}
+	if _, _, err := net.SplitHostPort(resolver); err != nil {
+		resolver = net.JoinHostPort(resolver, "53")
+	}
+
+	ln, err := Listen(addr, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for probe requests on %s: %v", addr, err)
+	}
+
+	s := &Server{
+		region:   region,
+		tokens:   tokens,
+		resolver: resolver,
+		ln:       ln,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(DNSPath, s.authorize(s.handleDNS))
+	mux.HandleFunc(HTTPPath, s.authorize(s.handleHTTP))
+	mux.HandleFunc(VersionPath, s.authorize(s.handleVersion))
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() { _ = s.server.Serve(ln) }()
+	return s, nil
+}
+
+// Addr returns the address the probe API is being served on.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops the probe agent.
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+func (s *Server) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, &Error{Error: "only the POST method is supported"})
+			return
+		}
+
+		if len(s.tokens) > 0 && !ValidToken(s.tokens, BearerToken(r)) {
+			writeJSON(w, http.StatusUnauthorized, &Error{Error: "the request was not authorized"})
+			return
+		}
+
+		w.Header().Set(VersionHeader, strconv.Itoa(ProtocolVersion))
+		// Requests without the header were sent by releases that predate the negotiation
+		if v := r.Header.Get(VersionHeader); v != "" {
+			if version, err := strconv.Atoi(v); err != nil || version < MinProtocolVersion || version > ProtocolVersion {
+				writeJSON(w, http.StatusUpgradeRequired, &Error{Error: fmt.Sprintf(
+					"the probe speaks protocol versions %d to %d, but the request uses version %s", MinProtocolVersion, ProtocolVersion, v)})
+				return
+			}
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		next(w, r)
+	}
+}
+
+// handleVersion returns the range of protocol versions spoken by the probe. The engine
+// provides its own range in the request, which is not required to respond.
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, LocalVersion())
+}
+
+// Listen returns the listener for an API served on the provided address, over TLS when
+// tlsConfig is not nil. The task API of the enumeration is served the same way.
+func Listen(addr string, tlsConfig *tls.Config) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+	return ln, nil
+}
+
+// LoopbackAddr returns true when the host of the address can only be reached from the local system.
+// The addresses without a host, such as :8443, are served on all the interfaces. The task API of the
+// enumeration applies the same restriction.
+func LoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// BearerToken returns the bearer token provided by the Authorization header of the request.
+func BearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// ValidToken returns true when the token matches one of the accepted tokens. Each comparison
+// is performed in constant time, so the tokens cannot be guessed incrementally.
+func ValidToken(tokens []string, token string) bool {
+	var valid bool
+
+	for _, t := range tokens {
+		if t != "" && subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+func (s *Server) handleDNS(w http.ResponseWriter, r *http.Request) {
+	var req DNSRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &Error{Error: err.Error()})
+		return
+	}
+	if _, ok := dns.IsDomainName(req.Name); !ok || req.Type == 0 {
+		writeJSON(w, http.StatusBadRequest, &Error{Error: "a valid name and record type are required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(req.Name), req.Type)
+	msg.SetEdns0(dns.DefaultMsgSize, false)
+
+	c := &dns.Client{Timeout: 5 * time.Second}
+	resp, _, err := c.ExchangeContext(ctx, msg, s.resolver)
+	if err == nil && resp.Truncated {
+		c.Net = "tcp"
+		resp, _, err = c.ExchangeContext(ctx, msg, s.resolver)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, &Error{Error: err.Error()})
+		return
+	}
+
+	result := &DNSResponse{
+		Region: s.region,
+		Rcode:  resp.Rcode,
+	}
+	for _, rr := range resp.Answer {
+		hdr := rr.Header()
+
+		result.Answers = append(result.Answers, Answer{
+			Name: strings.TrimSuffix(hdr.Name, "."),
+			Type: hdr.Rrtype,
+			TTL:  hdr.Ttl,
+			Data: strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(rr.String(), hdr.String())), "."),
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	var req HTTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &Error{Error: err.Error()})
+		return
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		writeJSON(w, http.StatusBadRequest, &Error{Error: "a valid HTTP or HTTPS URL is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
+
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &Error{Error: err.Error()})
+		return
+	}
+
+	resp, err := s.client.Do(hreq)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, &Error{Error: err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+
+	n, _ := io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodySize))
+	result := &HTTPResponse{
+		Region:     s.region,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Length:     int(n),
+	}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		cert := resp.TLS.PeerCertificates[0]
+
+		if cert.Subject.CommonName != "" {
+			result.Names = append(result.Names, cert.Subject.CommonName)
+		}
+		result.Names = append(result.Names, cert.DNSNames...)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,186 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package progress tracks the bounded operations of the engine, such as brute forcing with a wordlist
+// and sweeping netblocks, and estimates when they will be completed from the rate observed so far.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The operations tracked by the engine.
+const (
+	BruteForce = "brute_force"
+	Sweep      = "sweep"
+)
+
+// Tracker counts the units of work planned and completed by an operation.
+type Tracker struct {
+	sync.Mutex
+	name    string
+	started time.Time
+	total   int64
+	done    int64
+}
+
+// Add plans n more units of work, such as the names generated from a wordlist. The operation
+// is considered started when the first units are planned.
+func (t *Tracker) Add(n int) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.started.IsZero() {
+		t.started = time.Now()
+	}
+	t.total += int64(n)
+}
+
+// Done marks n units of work as completed.
+func (t *Tracker) Done(n int) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.done += int64(n)
+}
+
+// Reset forgets the work of the operation, so the next units planned start a new estimate.
+func (t *Tracker) Reset() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.started = time.Time{}
+	t.total = 0
+	t.done = 0
+}
+
+// Status returns the progress of the operation at the provided time, or nil when no work was planned.
+func (t *Tracker) Status(now time.Time) *Status {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.total == 0 {
+		return nil
+	}
+
+	done := t.done
+	if done > t.total {
+		done = t.total
+	}
+	s := &Status{
+		Time:      now.UTC(),
+		Operation: t.name,
+		Done:      done,
+		Total:     t.total,
+		Percent:   float64(done) * 100 / float64(t.total),
+		Elapsed:   now.Sub(t.started).Seconds(),
+	}
+	// The rate is not known until the first units are completed
+	if s.Elapsed > 0 && done > 0 {
+		s.Rate = float64(done) / s.Elapsed
+		s.ETA = float64(t.total-done) / s.Rate
+	}
+	return s
+}
+
+// Status is the progress of an operation, as written to the progress stream.
+type Status struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Done      int64     `json:"done"`
+	Total     int64     `json:"total"`
+	Percent   float64   `json:"percent"`
+	// Elapsed is the number of seconds since the operation started
+	Elapsed float64 `json:"elapsed_seconds"`
+	// Rate is the number of units completed per second
+	Rate float64 `json:"rate"`
+	// ETA is the estimated number of seconds remaining, or zero when the rate is not known yet
+	ETA float64 `json:"eta_seconds"`
+}
+
+// Completed returns true when all the work planned has been completed.
+func (s *Status) Completed() bool {
+	return s.Done >= s.Total
+}
+
+// String returns the progress for the terminal, such as "brute_force 45.2% (1200/2655) ETA 4m12s".
+func (s *Status) String() string {
+	eta := "unknown"
+	if s.Completed() {
+		eta = "done"
+	} else if s.Rate > 0 {
+		eta = (time.Duration(s.ETA) * time.Second).String()
+	}
+	return fmt.Sprintf("%s %.1f%% (%d/%d) ETA %s", s.Operation, s.Percent, s.Done, s.Total, eta)
+}
+
+// Registry contains the trackers of the operations.
+type Registry struct {
+	sync.Mutex
+	trackers map[string]*Tracker
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{trackers: make(map[string]*Tracker)}
+}
+
+// Default is the Registry containing the operations of the engine.
+var Default = NewRegistry()
+
+// Tracker returns the tracker of the named operation, which is created the first time it is requested.
+func (r *Registry) Tracker(name string) *Tracker {
+	r.Lock()
+	defer r.Unlock()
+
+	t, found := r.trackers[name]
+	if !found {
+		t = &Tracker{name: name}
+		r.trackers[name] = t
+	}
+	return t
+}
+
+// Statuses returns the progress of the operations with planned work, sorted by name.
+func (r *Registry) Statuses(now time.Time) []*Status {
+	r.Lock()
+	trackers := make([]*Tracker, 0, len(r.trackers))
+	for _, t := range r.trackers {
+		trackers = append(trackers, t)
+	}
+	r.Unlock()
+
+	var statuses []*Status
+	for _, t := range trackers {
+		if s := t.Status(now); s != nil {
+			statuses = append(statuses, s)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Operation < statuses[j].Operation })
+	return statuses
+}
+
+// Writer writes the progress stream, one JSON object per line.
+type Writer struct {
+	sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriter returns a Writer of the progress stream to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{enc: json.NewEncoder(w)}
+}
+
+// Write writes the status as a line of the progress stream.
+func (w *Writer) Write(s *Status) error {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.enc.Encode(s)
+}
@@ -0,0 +1,67 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	r := NewRegistry()
+	if statuses := r.Statuses(time.Now()); len(statuses) != 0 {
+		t.Errorf("Expected no statuses before the work is planned, got %v", statuses)
+	}
+
+	tr := r.Tracker(BruteForce)
+	if r.Tracker(BruteForce) != tr {
+		t.Error("Expected the same tracker for the operation")
+	}
+	tr.Add(1000)
+	start := tr.started
+
+	s := tr.Status(start.Add(10 * time.Second))
+	if s.Rate != 0 || s.String() != "brute_force 0.0% (0/1000) ETA unknown" {
+		t.Errorf("Unexpected status before the first units were completed: %s", s)
+	}
+
+	tr.Done(250)
+	s = tr.Status(start.Add(10 * time.Second))
+	if s.Percent != 25 || s.Rate != 25 || s.ETA != 30 {
+		t.Errorf("Unexpected estimate: %+v", s)
+	}
+	if str := s.String(); str != "brute_force 25.0% (250/1000) ETA 30s" {
+		t.Errorf("Unexpected status line: %s", str)
+	}
+
+	tr.Done(800)
+	if s = tr.Status(start.Add(20 * time.Second)); !s.Completed() || s.Done != 1000 || s.String() != "brute_force 100.0% (1000/1000) ETA done" {
+		t.Errorf("Unexpected status once completed: %s", s)
+	}
+
+	r.Tracker(Sweep).Add(10)
+	if statuses := r.Statuses(time.Now()); len(statuses) != 2 || statuses[1].Operation != Sweep {
+		t.Errorf("Unexpected statuses: %v", statuses)
+	}
+	tr.Reset()
+	if tr.Status(time.Now()) != nil {
+		t.Error("Expected no status once the tracker was reset")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.Write(&Status{Operation: Sweep, Done: 5, Total: 10, Percent: 50, ETA: 5}); err != nil {
+		t.Fatalf("Failed to write the status: %v", err)
+	}
+
+	var s Status
+	if err := json.Unmarshal(buf.Bytes(), &s); err != nil || s.Operation != Sweep || s.ETA != 5 {
+		t.Errorf("Unexpected line of the progress stream: %s", buf.String())
+	}
+}
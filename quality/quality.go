@@ -0,0 +1,332 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package quality checks the graph database for the issues that accumulate in long-lived databases, such
+// as the assets left without relations, the relation types outside the asset taxonomy, and the assets and
+// relations stored more than once, and repairs the issues that can be fixed without losing information.
+package quality
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// The types of the issues found in the graph database.
+const (
+	IssueOrphanedAsset     = "orphaned_asset"
+	IssueInvalidRelation   = "invalid_relation"
+	IssueDuplicateRelation = "duplicate_relation"
+	IssueDuplicateAsset    = "duplicate_asset"
+)
+
+// MaxTypoDistance is the number of edits between an invalid relation type and a valid relation
+// type, at or below which the invalid type is considered a typo of the valid type.
+const MaxTypoDistance = 2
+
+// relationTypes are the relation types of the asset taxonomy.
+var relationTypes = []string{
+	"a_record", "aaaa_record", "announces", "cname_record", "contains",
+	"managed_by", "mx_record", "node", "ns_record", "ptr_record", "srv_record",
+}
+
+var assetTypes = []oam.AssetType{oam.FQDN, oam.IPAddress, oam.Netblock, oam.ASN, oam.RIROrg}
+
+// Database is the subset of the graph database used to read the assets and relations and repair the issues.
+type Database interface {
+	FindByType(atype oam.AssetType, since time.Time) ([]*types.Asset, error)
+	OutgoingRelations(asset *types.Asset, since time.Time, relationTypes ...string) ([]*types.Relation, error)
+	Create(source *types.Asset, relation string, discovered oam.Asset) (*types.Asset, error)
+	DeleteAsset(id string) error
+	DeleteRelation(id string) error
+}
+
+// Graph is the content of the graph database checked for issues.
+type Graph struct {
+	Assets    []*types.Asset
+	Relations []*types.Relation
+}
+
+// Load reads all the assets and their outgoing relations from the graph database.
+func Load(ctx context.Context, db Database) (*Graph, error) {
+	g := new(Graph)
+
+	for _, atype := range assetTypes {
+		assets, err := db.FindByType(atype, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the %s assets: %v", atype, err)
+		}
+		g.Assets = append(g.Assets, assets...)
+	}
+
+	for _, a := range g.Assets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rels, err := db.OutgoingRelations(a, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the relations of %s: %v", format.AssetKey(a.Asset), err)
+		}
+		g.Relations = append(g.Relations, rels...)
+	}
+	return g, nil
+}
+
+// Issue is a problem found in the graph database.
+type Issue struct {
+	Type       string `json:"type"`
+	AssetID    string `json:"asset_id,omitempty"`
+	Asset      string `json:"asset,omitempty"`
+	RelationID string `json:"relation_id,omitempty"`
+	Relation   string `json:"relation,omitempty"`
+	Detail     string `json:"detail"`
+	// Fix is the valid relation type replacing the typo, or the ID of the asset replacing the duplicate
+	Fix      string `json:"fix,omitempty"`
+	Repaired bool   `json:"repaired"`
+}
+
+// Report contains the issues found in the graph database.
+type Report struct {
+	Assets    int      `json:"assets"`
+	Relations int      `json:"relations"`
+	Issues    []*Issue `json:"issues"`
+}
+
+// Counts returns the number of issues of each type.
+func (r *Report) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, i := range r.Issues {
+		counts[i.Type]++
+	}
+	return counts
+}
+
+// Check returns the issues found in the graph.
+func Check(g *Graph) *Report {
+	ids := make(map[string]*types.Asset, len(g.Assets))
+	for _, a := range g.Assets {
+		ids[a.ID] = a
+	}
+
+	report := &Report{Assets: len(g.Assets), Relations: len(g.Relations)}
+	report.Issues = append(report.Issues, duplicateRelations(g)...)
+	report.Issues = append(report.Issues, invalidRelations(g, ids)...)
+	report.Issues = append(report.Issues, duplicateAssets(g)...)
+	report.Issues = append(report.Issues, orphanedAssets(g)...)
+	return report
+}
+
+// duplicateRelations returns the relations linking the same assets with the same type as an earlier relation.
+func duplicateRelations(g *Graph) []*Issue {
+	rels := make([]*types.Relation, len(g.Relations))
+	copy(rels, g.Relations)
+	sort.SliceStable(rels, func(i, j int) bool {
+		return rels[i].CreatedAt.Before(rels[j].CreatedAt)
+	})
+
+	var issues []*Issue
+	seen := make(map[string]string)
+	for _, rel := range rels {
+		key := rel.FromAsset.ID + " " + rel.Type + " " + rel.ToAsset.ID
+		if first, found := seen[key]; found {
+			issues = append(issues, &Issue{
+				Type:       IssueDuplicateRelation,
+				AssetID:    rel.FromAsset.ID,
+				RelationID: rel.ID,
+				Relation:   rel.Type,
+				Detail:     fmt.Sprintf("duplicates the relation %s", first),
+			})
+			continue
+		}
+		seen[key] = rel.ID
+	}
+	return issues
+}
+
+// invalidRelations returns the relations with types that are not valid between the types of the assets,
+// providing the valid relation type as the fix when the type is a typo of exactly one of them.
+func invalidRelations(g *Graph, ids map[string]*types.Asset) []*Issue {
+	var issues []*Issue
+
+	for _, rel := range g.Relations {
+		from, to := ids[rel.FromAsset.ID], ids[rel.ToAsset.ID]
+		if from == nil || to == nil {
+			issues = append(issues, &Issue{
+				Type:       IssueInvalidRelation,
+				AssetID:    rel.FromAsset.ID,
+				RelationID: rel.ID,
+				Relation:   rel.Type,
+				Detail:     "links an asset that does not exist",
+			})
+			continue
+		}
+
+		ftype, ttype := from.Asset.AssetType(), to.Asset.AssetType()
+		if oam.ValidRelationship(ftype, rel.Type, ttype) {
+			continue
+		}
+
+		issues = append(issues, &Issue{
+			Type:       IssueInvalidRelation,
+			AssetID:    from.ID,
+			Asset:      format.AssetKey(from.Asset),
+			RelationID: rel.ID,
+			Relation:   rel.Type,
+			Detail:     fmt.Sprintf("is not a valid relation from %s to %s %s", ftype, ttype, format.AssetKey(to.Asset)),
+			Fix:        correctRelation(rel.Type, ftype, ttype),
+		})
+	}
+	return issues
+}
+
+// correctRelation returns the valid relation type between the asset types that is closest to the invalid type,
+// or an empty string when no valid type is within MaxTypoDistance edits or several types are equally close.
+func correctRelation(rtype string, from, to oam.AssetType) string {
+	var best string
+	min, ties := MaxTypoDistance+1, 0
+
+	for _, valid := range relationTypes {
+		if !oam.ValidRelationship(from, valid, to) {
+			continue
+		}
+
+		if d := distance(strings.ToLower(rtype), valid); d < min {
+			best, min, ties = valid, d, 0
+		} else if d == min {
+			ties++
+		}
+	}
+	if ties > 0 {
+		return ""
+	}
+	return best
+}
+
+// distance returns the Levenshtein distance between the strings.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j] + 1
+			if v := cur[j-1] + 1; v < cur[j] {
+				cur[j] = v
+			}
+			if v := prev[j-1] + cost; v < cur[j] {
+				cur[j] = v
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// duplicateAssets returns the assets that only differ from another asset by case or a trailing dot, such as the
+// FQDNs and the RIR organizations stored by different data sources. The asset with the normalized content, or
+// else the oldest asset, is kept as the fix for the others.
+func duplicateAssets(g *Graph) []*Issue {
+	groups := make(map[string][]*types.Asset)
+	for _, a := range g.Assets {
+		if key := normalizedKey(a.Asset); key != "" {
+			groups[key] = append(groups[key], a)
+		}
+	}
+
+	var keys []string
+	for key, group := range groups {
+		if len(group) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var issues []*Issue
+	for _, key := range keys {
+		group := groups[key]
+		sort.SliceStable(group, func(i, j int) bool {
+			ni, nj := normalized(group[i].Asset), normalized(group[j].Asset)
+			if ni != nj {
+				return ni
+			}
+			return group[i].CreatedAt.Before(group[j].CreatedAt)
+		})
+
+		keep := group[0]
+		for _, a := range group[1:] {
+			issues = append(issues, &Issue{
+				Type:    IssueDuplicateAsset,
+				AssetID: a.ID,
+				Asset:   format.AssetKey(a.Asset),
+				Detail:  fmt.Sprintf("duplicates the %s asset %s", a.Asset.AssetType(), format.AssetKey(keep.Asset)),
+				Fix:     keep.ID,
+			})
+		}
+	}
+	return issues
+}
+
+// normalizedKey returns the key shared by the assets that represent the same entity, or an empty string for the
+// asset types that are already stored uniquely, such as the addresses and netblocks parsed before being stored.
+func normalizedKey(a oam.Asset) string {
+	switch v := a.(type) {
+	case domain.FQDN:
+		return string(oam.FQDN) + " " + strings.TrimSuffix(strings.ToLower(strings.TrimSpace(v.Name)), ".")
+	case network.RIROrganization:
+		return string(oam.RIROrg) + " " + strings.ToLower(strings.TrimSpace(v.RIRId)) + " " +
+			strings.ToLower(strings.Join(strings.Fields(v.Name), " "))
+	}
+	return ""
+}
+
+func normalized(a oam.Asset) bool {
+	if v, ok := a.(domain.FQDN); ok {
+		return v.Name == strings.TrimSuffix(strings.ToLower(strings.TrimSpace(v.Name)), ".")
+	}
+	return false
+}
+
+// orphanedAssets returns the assets without any relations. The FQDNs are not included,
+// since the root domains and the names without DNS records are expected to have none.
+func orphanedAssets(g *Graph) []*Issue {
+	linked := make(map[string]struct{})
+	for _, rel := range g.Relations {
+		linked[rel.FromAsset.ID] = struct{}{}
+		linked[rel.ToAsset.ID] = struct{}{}
+	}
+
+	var issues []*Issue
+	for _, a := range g.Assets {
+		if _, found := linked[a.ID]; found || a.Asset.AssetType() == oam.FQDN {
+			continue
+		}
+
+		issues = append(issues, &Issue{
+			Type:    IssueOrphanedAsset,
+			AssetID: a.ID,
+			Asset:   format.AssetKey(a.Asset),
+			Detail:  fmt.Sprintf("the %s asset has no relations", a.Asset.AssetType()),
+		})
+	}
+	return issues
+}
@@ -0,0 +1,324 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package quality
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/assettest"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/trash"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// memoryDB is the in-memory Database used to verify the repairs.
+type memoryDB struct {
+	next      int
+	assets    map[string]*types.Asset
+	relations map[string]*types.Relation
+}
+
+func newMemoryDB() *memoryDB {
+	return &memoryDB{
+		assets:    make(map[string]*types.Asset),
+		relations: make(map[string]*types.Relation),
+	}
+}
+
+func (m *memoryDB) id() string {
+	m.next++
+	return strconv.Itoa(m.next)
+}
+
+func (m *memoryDB) asset(a oam.Asset, created time.Time) *types.Asset {
+	ta := &types.Asset{ID: m.id(), CreatedAt: created, Asset: a}
+	m.assets[ta.ID] = ta
+	return ta
+}
+
+func (m *memoryDB) link(from *types.Asset, rtype string, to *types.Asset) *types.Relation {
+	rel := &types.Relation{
+		ID:        m.id(),
+		Type:      rtype,
+		FromAsset: &types.Asset{ID: from.ID},
+		ToAsset:   &types.Asset{ID: to.ID},
+	}
+	m.relations[rel.ID] = rel
+	return rel
+}
+
+func (m *memoryDB) FindByType(atype oam.AssetType, since time.Time) ([]*types.Asset, error) {
+	var assets []*types.Asset
+	for _, a := range m.assets {
+		if a.Asset.AssetType() == atype {
+			assets = append(assets, a)
+		}
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].ID < assets[j].ID })
+	return assets, nil
+}
+
+func (m *memoryDB) FindById(id string, since time.Time) (*types.Asset, error) {
+	if a, found := m.assets[id]; found {
+		return a, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *memoryDB) IncomingRelations(asset *types.Asset, since time.Time, relationTypes ...string) ([]*types.Relation, error) {
+	var rels []*types.Relation
+	for _, rel := range m.relations {
+		if rel.ToAsset.ID == asset.ID {
+			rels = append(rels, rel)
+		}
+	}
+	return rels, nil
+}
+
+func (m *memoryDB) OutgoingRelations(asset *types.Asset, since time.Time, relationTypes ...string) ([]*types.Relation, error) {
+	var rels []*types.Relation
+	for _, rel := range m.relations {
+		if rel.FromAsset.ID == asset.ID {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Slice(rels, func(i, j int) bool { return rels[i].ID < rels[j].ID })
+	return rels, nil
+}
+
+func (m *memoryDB) Create(source *types.Asset, relation string, discovered oam.Asset) (*types.Asset, error) {
+	var found *types.Asset
+	for _, a := range m.assets {
+		if a.Asset == discovered {
+			found = a
+		}
+	}
+	if found == nil {
+		found = m.asset(discovered, time.Now())
+	}
+
+	for _, rel := range m.relations {
+		if rel.FromAsset.ID == source.ID && rel.Type == relation && rel.ToAsset.ID == found.ID {
+			return found, nil
+		}
+	}
+	m.link(source, relation, found)
+	return found, nil
+}
+
+func (m *memoryDB) DeleteAsset(id string) error {
+	delete(m.assets, id)
+	for rid, rel := range m.relations {
+		if rel.FromAsset.ID == id || rel.ToAsset.ID == id {
+			delete(m.relations, rid)
+		}
+	}
+	return nil
+}
+
+func (m *memoryDB) DeleteRelation(id string) error {
+	delete(m.relations, id)
+	return nil
+}
+
+func testDB() *memoryDB {
+	db := newMemoryDB()
+	now := time.Now()
+	ip := func(addr string) oam.Asset {
+		return network.IPAddress{Address: netip.MustParseAddr(addr), Type: "IPv4"}
+	}
+
+	www := db.asset(domain.FQDN{Name: "www.owasp.org"}, now)
+	upper := db.asset(domain.FQDN{Name: "WWW.owasp.org."}, now.Add(-time.Hour))
+	mail := db.asset(domain.FQDN{Name: "mail.owasp.org"}, now)
+	addr1 := db.asset(ip("192.0.2.1"), now)
+	addr2 := db.asset(ip("192.0.2.2"), now)
+	_ = db.asset(ip("198.51.100.1"), now)
+	_ = db.asset(domain.FQDN{Name: "owasp.org"}, now)
+
+	db.link(www, "a_record", addr1)
+	db.link(www, "a_record", addr1)
+	db.link(upper, "a_record", addr2)
+	db.link(mail, "a_recod", addr2)
+	db.link(mail, "announces", addr1)
+	return db
+}
+
+func TestCheck(t *testing.T) {
+	g, err := Load(context.Background(), testDB())
+	if err != nil {
+		t.Fatalf("Failed to load the graph: %v", err)
+	}
+
+	report := Check(g)
+	if report.Assets != 7 || report.Relations != 5 {
+		t.Errorf("Expected seven assets and five relations, got %d and %d", report.Assets, report.Relations)
+	}
+
+	counts := report.Counts()
+	for itype, expected := range map[string]int{
+		IssueDuplicateRelation: 1,
+		IssueInvalidRelation:   2,
+		IssueDuplicateAsset:    1,
+		IssueOrphanedAsset:     1,
+	} {
+		if counts[itype] != expected {
+			t.Errorf("Expected %d %s issues, got %d", expected, itype, counts[itype])
+		}
+	}
+
+	for _, i := range report.Issues {
+		switch {
+		case i.Type == IssueInvalidRelation && i.Relation == "a_recod" && i.Fix != "a_record":
+			t.Errorf("Expected the typo to be fixed as a_record, got %s", i.Fix)
+		case i.Type == IssueInvalidRelation && i.Relation == "announces" && i.Fix != "":
+			t.Errorf("Expected no fix for the announces relation, got %s", i.Fix)
+		case i.Type == IssueDuplicateAsset && i.Asset != "WWW.owasp.org.":
+			t.Errorf("Expected the normalized name to be kept, got %s as the duplicate", i.Asset)
+		case i.Type == IssueOrphanedAsset && i.Asset != "198.51.100.1":
+			t.Errorf("Unexpected orphaned asset: %s", i.Asset)
+		}
+	}
+}
+
+func TestCorrectRelation(t *testing.T) {
+	tests := []struct {
+		rtype    string
+		from, to oam.AssetType
+		expected string
+	}{
+		{"A_Record", oam.FQDN, oam.IPAddress, "a_record"},
+		{"cname_recrd", oam.FQDN, oam.FQDN, "cname_record"},
+		{"contain", oam.Netblock, oam.IPAddress, "contains"},
+		{"resolves_to", oam.FQDN, oam.IPAddress, ""},
+		{"a_record", oam.FQDN, oam.FQDN, ""},
+	}
+
+	for _, test := range tests {
+		if fix := correctRelation(test.rtype, test.from, test.to); fix != test.expected {
+			t.Errorf("Expected %s to be corrected as %q, got %q", test.rtype, test.expected, fix)
+		}
+	}
+}
+
+func TestRepair(t *testing.T) {
+	db := testDB()
+	g, _ := Load(context.Background(), db)
+	report := Check(g)
+
+	count, err := Repair(context.Background(), db, g, report)
+	if err != nil {
+		t.Fatalf("Failed to repair the issues: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Expected four issues to be repaired, got %d", count)
+	}
+
+	g, _ = Load(context.Background(), db)
+	report = Check(g)
+	if len(report.Issues) != 1 || report.Issues[0].Relation != "announces" {
+		t.Fatalf("Expected only the invalid relation without a fix to remain, got %d issues", len(report.Issues))
+	}
+
+	var names []string
+	for _, a := range g.Assets {
+		names = append(names, format.AssetKey(a.Asset))
+	}
+	sort.Strings(names)
+	if len(names) != 5 || names[4] != "www.owasp.org" {
+		t.Errorf("Unexpected assets after the repair: %v", names)
+	}
+
+	var links int
+	for _, rel := range g.Relations {
+		if rel.FromAsset.ID == "1" && rel.Type == "a_record" {
+			links++
+		}
+	}
+	if links != 2 {
+		t.Errorf("Expected the relations of the duplicate to be moved, got %d address relations", links)
+	}
+}
+
+func TestLoadGraphDatabase(t *testing.T) {
+	g := assettest.NewGraph(t)
+	assettest.Populate(t, g,
+		assettest.A("www.owasp.org", "192.0.2.1"),
+		assettest.Infrastructure(64496, "OWASP", "192.0.2.1", "192.0.2.0/24"),
+	)
+	assettest.Store(t, g, assettest.IP("198.51.100.1"))
+
+	graph, err := Load(context.Background(), g.DB)
+	if err != nil {
+		t.Fatalf("Failed to load the graph database: %v", err)
+	}
+
+	report := Check(graph)
+	if len(report.Issues) != 1 || report.Issues[0].Type != IssueOrphanedAsset || report.Issues[0].Asset != "198.51.100.1" {
+		t.Errorf("Expected only the stored address to be orphaned, got %d issues", len(report.Issues))
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	db := testDB()
+	// Remove the address without cascading to the relations, as left behind by a purge
+	delete(db.assets, "4")
+	g, _ := Load(context.Background(), db)
+
+	tr := new(trash.Trash)
+	bin := trash.NewBin(db, tr, "report storage -vacuum", "jdoe", "unreferenced relation")
+	bin.Known(g.Relations)
+
+	count, err := Vacuum(context.Background(), bin, g)
+	if err != nil {
+		t.Fatalf("Failed to vacuum the graph database: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected three unreferenced relations to be removed, got %d", count)
+	}
+	if len(g.Relations) != 2 || len(db.relations) != 2 {
+		t.Errorf("Expected two relations to remain, got %d in the graph and %d stored", len(g.Relations), len(db.relations))
+	}
+	if len(tr.Entries) != 3 {
+		t.Fatalf("Expected the removed relations to be added to the trash, got %d entries", len(tr.Entries))
+	}
+	if s := tr.Entries[0].Subject(); s != "www.owasp.org a_record 4" {
+		t.Errorf("Expected the missing address to be recorded by its identifier, got %s", s)
+	}
+}
+
+func TestStats(t *testing.T) {
+	g, _ := Load(context.Background(), testDB())
+
+	stats := Stats(g)
+	if len(stats) != 2 {
+		t.Fatalf("Expected the statistics of two asset types, got %d", len(stats))
+	}
+
+	for _, s := range stats {
+		switch s.Type {
+		case oam.FQDN:
+			if s.Assets != 4 || s.Relations != 5 {
+				t.Errorf("Expected four names with five relations, got %d and %d", s.Assets, s.Relations)
+			}
+		case oam.IPAddress:
+			if s.Assets != 3 || s.Relations != 0 {
+				t.Errorf("Expected three addresses without relations, got %d and %d", s.Assets, s.Relations)
+			}
+		}
+		if s.Bytes <= 0 {
+			t.Errorf("Expected the %s assets to use storage", s.Type)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package quality
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// reasoner is implemented by the databases recording why the assets and relations are removed, such as the trash.
+type reasoner interface {
+	SetReason(reason string)
+}
+
+// Repair fixes the issues of the report in the graph database and marks them as repaired. The duplicate
+// relations and orphaned assets are removed, the typos in the relation types are replaced by the valid types,
+// and the relations of the duplicate assets are moved to the asset kept before the duplicates are removed.
+// The invalid relations without a fix are left for the analyst to review. The number of issues repaired is returned.
+func Repair(ctx context.Context, db Database, g *Graph, report *Report) (int, error) {
+	ids := make(map[string]*types.Asset, len(g.Assets))
+	for _, a := range g.Assets {
+		ids[a.ID] = a
+	}
+	rels := make(map[string]*types.Relation, len(g.Relations))
+	for _, rel := range g.Relations {
+		rels[rel.ID] = rel
+	}
+
+	var count int
+	removed := make(map[string]struct{})
+	for _, issue := range report.Issues {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+
+		if r, ok := db.(reasoner); ok {
+			r.SetReason(strings.TrimSpace(issue.Type + ": " + issue.Detail))
+		}
+
+		var err error
+		switch issue.Type {
+		case IssueDuplicateRelation:
+			err = db.DeleteRelation(issue.RelationID)
+			removed[issue.RelationID] = struct{}{}
+		case IssueInvalidRelation:
+			if issue.Fix == "" {
+				continue
+			}
+			err = replaceRelation(db, rels[issue.RelationID], issue.Fix, ids)
+			removed[issue.RelationID] = struct{}{}
+		case IssueDuplicateAsset:
+			err = mergeAsset(db, g, ids[issue.AssetID], ids[issue.Fix], ids, removed)
+		case IssueOrphanedAsset:
+			err = db.DeleteAsset(issue.AssetID)
+		default:
+			continue
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to repair the %s issue of %s: %v", issue.Type, issue.AssetID, err)
+		}
+
+		issue.Repaired = true
+		count++
+	}
+	return count, nil
+}
+
+// replaceRelation links the assets of the relation using the relation type before the relation is removed.
+func replaceRelation(db Database, rel *types.Relation, rtype string, ids map[string]*types.Asset) error {
+	if rel == nil {
+		return fmt.Errorf("the relation was not loaded")
+	}
+
+	from, to := ids[rel.FromAsset.ID], ids[rel.ToAsset.ID]
+	if from == nil || to == nil {
+		return fmt.Errorf("the assets of the relation were not loaded")
+	}
+	if _, err := db.Create(from, rtype, to.Asset); err != nil {
+		return err
+	}
+	return db.DeleteRelation(rel.ID)
+}
+
+// mergeAsset moves the relations of the duplicate asset to the asset kept, and removes the duplicate.
+func mergeAsset(db Database, g *Graph, dup, keep *types.Asset, ids map[string]*types.Asset, removed map[string]struct{}) error {
+	if dup == nil || keep == nil {
+		return fmt.Errorf("the assets were not loaded")
+	}
+
+	for _, rel := range g.Relations {
+		if _, found := removed[rel.ID]; found {
+			continue
+		}
+
+		from, to := ids[rel.FromAsset.ID], ids[rel.ToAsset.ID]
+		if from == nil || to == nil || (from.ID != dup.ID && to.ID != dup.ID) {
+			continue
+		}
+		// The invalid relations cannot be linked again, and are removed along with the duplicate
+		if !oam.ValidRelationship(from.Asset.AssetType(), rel.Type, to.Asset.AssetType()) {
+			continue
+		}
+
+		var err error
+		if from.ID == dup.ID {
+			_, err = db.Create(keep, rel.Type, to.Asset)
+		} else {
+			_, err = db.Create(from, rel.Type, keep.Asset)
+		}
+		if err != nil {
+			return err
+		}
+		removed[rel.ID] = struct{}{}
+	}
+	return db.DeleteAsset(dup.ID)
+}
@@ -0,0 +1,121 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package quality
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// Vacuum removes the relations that link an asset no longer stored in the graph database, such as the relations
+// left behind after the assets were purged or merged, and removes them from the graph. The database should be
+// a trash.Bin knowing the relations of the graph, so the removals are kept in the trash. The number of relations
+// removed is returned.
+func Vacuum(ctx context.Context, db Database, g *Graph) (int, error) {
+	ids := make(map[string]struct{}, len(g.Assets))
+	for _, a := range g.Assets {
+		ids[a.ID] = struct{}{}
+	}
+
+	var count int
+	kept := g.Relations[:0]
+	for i, rel := range g.Relations {
+		if err := ctx.Err(); err != nil {
+			g.Relations = append(kept, g.Relations[i:]...)
+			return count, err
+		}
+
+		_, from := ids[rel.FromAsset.ID]
+		_, to := ids[rel.ToAsset.ID]
+		if from && to {
+			kept = append(kept, rel)
+			continue
+		}
+
+		if err := db.DeleteRelation(rel.ID); err != nil {
+			g.Relations = append(kept, g.Relations[i:]...)
+			return count, fmt.Errorf("failed to remove the unreferenced relation %s: %v", rel.ID, err)
+		}
+		count++
+	}
+	g.Relations = kept
+	return count, nil
+}
+
+// Names returns the FQDNs stored in the graph, so the sightings of the names purged from the graph database can be removed.
+func (g *Graph) Names() map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, a := range g.Assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok {
+			names[fqdn.Name] = struct{}{}
+		}
+	}
+	return names
+}
+
+// TypeStats describes the storage used by the assets of one type in the graph database.
+type TypeStats struct {
+	Type      oam.AssetType `json:"type"`
+	Assets    int           `json:"assets"`
+	Relations int           `json:"relations"`
+	// Bytes is the approximate size of the asset content and the outgoing relations
+	Bytes int64 `json:"bytes"`
+}
+
+// relationBytes is the approximate size of a relation row without its type: the ID, the IDs
+// of the assets and the timestamps.
+const relationBytes = 64
+
+// Stats returns the storage used by each asset type in the graph, with the largest types first.
+func Stats(g *Graph) []*TypeStats {
+	atypes := make(map[string]oam.AssetType, len(g.Assets))
+	stats := make(map[oam.AssetType]*TypeStats)
+	get := func(atype oam.AssetType) *TypeStats {
+		if s, found := stats[atype]; found {
+			return s
+		}
+		s := &TypeStats{Type: atype}
+		stats[atype] = s
+		return s
+	}
+
+	for _, a := range g.Assets {
+		atype := a.Asset.AssetType()
+		atypes[a.ID] = atype
+
+		s := get(atype)
+		s.Assets++
+		if content, err := json.Marshal(a.Asset); err == nil {
+			s.Bytes += int64(len(content))
+		}
+	}
+	for _, rel := range g.Relations {
+		atype, found := atypes[rel.FromAsset.ID]
+		if !found {
+			continue
+		}
+
+		s := get(atype)
+		s.Relations++
+		s.Bytes += int64(relationBytes + len(rel.Type))
+	}
+
+	results := make([]*TypeStats, 0, len(stats))
+	for _, s := range stats {
+		results = append(results, s)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Bytes != results[j].Bytes {
+			return results[i].Bytes > results[j].Bytes
+		}
+		return results[i].Type < results[j].Type
+	})
+	return results
+}
@@ -0,0 +1,98 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package query
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Page selects part of the listed results, so large graph databases can be inspected
+// without writing every asset to the terminal.
+type Page struct {
+	// Limit is the maximum number of results, or zero for all of them
+	Limit int
+	// Offset is the number of results skipped
+	Offset int
+	// Cursor continues the listing after the last result of the previous page
+	Cursor string
+	// Sample is the number of results selected at random, or zero to select them in order
+	Sample int
+}
+
+// Validate checks that the settings of the page can be used together.
+func (p *Page) Validate() error {
+	if p.Limit < 0 || p.Offset < 0 || p.Sample < 0 {
+		return errors.New("the limit, offset and sample must not be negative")
+	}
+	if p.Offset > 0 && p.Cursor != "" {
+		return errors.New("the offset and cursor cannot be used together")
+	}
+	if p.Sample > 0 && (p.Offset > 0 || p.Cursor != "") {
+		return errors.New("the sample cannot be paginated using the offset or cursor")
+	}
+	if p.Cursor != "" {
+		if _, err := base64.RawURLEncoding.DecodeString(p.Cursor); err != nil {
+			return fmt.Errorf("invalid cursor %s", p.Cursor)
+		}
+	}
+	return nil
+}
+
+// Select returns the indices of the n results selected by the page, in their original order, where key
+// returns the key identifying the result at the index. The cursor of the next page is also returned
+// when more results follow the page.
+func (p *Page) Select(n int, key func(int) string) ([]int, string) {
+	if p.Sample > 0 {
+		return p.sample(n), ""
+	}
+
+	start := p.Offset
+	if p.Cursor != "" {
+		start = n
+
+		after, _ := base64.RawURLEncoding.DecodeString(p.Cursor)
+		for i := 0; i < n; i++ {
+			if key(i) == string(after) {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if start > n {
+		start = n
+	}
+
+	end := n
+	if p.Limit > 0 && start+p.Limit < n {
+		end = start + p.Limit
+	}
+
+	indices := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		indices = append(indices, i)
+	}
+
+	var next string
+	if end < n && end > 0 {
+		next = base64.RawURLEncoding.EncodeToString([]byte(key(end - 1)))
+	}
+	return indices, next
+}
+
+func (p *Page) sample(n int) []int {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	indices := rnd.Perm(n)
+	if p.Sample < n {
+		indices = indices[:p.Sample]
+	}
+	sort.Ints(indices)
+	return indices
+}
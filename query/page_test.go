@@ -0,0 +1,69 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package query
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPageSelect(t *testing.T) {
+	keys := strings.Fields("a b c d e f g")
+	key := func(i int) string { return keys[i] }
+	selected := func(indices []int) string {
+		var s []string
+		for _, i := range indices {
+			s = append(s, keys[i])
+		}
+		return strings.Join(s, "")
+	}
+
+	var pages []string
+	p := &Page{Limit: 3}
+	for {
+		indices, next := p.Select(len(keys), key)
+		pages = append(pages, selected(indices))
+		if next == "" {
+			break
+		}
+		p.Cursor = next
+	}
+	if got := strings.Join(pages, " "); got != "abc def g" {
+		t.Errorf("Unexpected pages using the cursor: %s", got)
+	}
+
+	if indices, next := (&Page{Offset: 5, Limit: 3}).Select(len(keys), key); selected(indices) != "fg" || next != "" {
+		t.Errorf("Unexpected page using the offset: %s %s", selected(indices), next)
+	}
+	if indices, _ := (&Page{Offset: 10}).Select(len(keys), key); len(indices) != 0 {
+		t.Errorf("Expected no results past the end: %v", indices)
+	}
+
+	indices, _ := (&Page{Sample: 4}).Select(len(keys), key)
+	if len(indices) != 4 || !sort.IntsAreSorted(indices) {
+		t.Errorf("Unexpected sample: %v", indices)
+	}
+	if indices, _ := (&Page{Sample: 10}).Select(len(keys), key); len(indices) != len(keys) {
+		t.Errorf("Expected the sample to include all the results: %v", indices)
+	}
+}
+
+func TestPageValidate(t *testing.T) {
+	for _, p := range []*Page{
+		{Limit: -1},
+		{Offset: 1, Cursor: "YQ"},
+		{Sample: 5, Offset: 1},
+		{Cursor: "not a cursor!"},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Expected an error for the page: %+v", p)
+		}
+	}
+
+	if err := (&Page{Limit: 10, Cursor: "YQ"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package query selects assets from the exported graph using short expressions, such as
+// 'fqdn resolving under $domain', which can be saved in the configuration and shared by a team.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/export"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/relations"
+	oam "github.com/owasp-amass/open-asset-model"
+	"gopkg.in/yaml.v3"
+)
+
+// The asset types selected by the expressions.
+var assetTypes = map[string]oam.AssetType{
+	"fqdn":     oam.FQDN,
+	"ip":       oam.IPAddress,
+	"netblock": oam.Netblock,
+	"asn":      oam.ASN,
+	"org":      oam.RIROrg,
+}
+
+var paramRE = regexp.MustCompile(`\$([A-Za-z][A-Za-z0-9_-]*)`)
+
+// Query selects the assets of a type matching all of its clauses.
+type Query struct {
+	Type oam.AssetType
+	// Resolving requires the names to have address records, directly or through CNAME records
+	Resolving bool
+	// Under requires the names to be the domain name or one of its subdomains
+	Under string
+	// Within requires the addresses and netblocks to be contained by the netblock
+	Within netip.Prefix
+	// Matching is the regular expression matched against the asset keys
+	Matching *regexp.Regexp
+	// With are the relations the assets must have to other assets
+	With []string
+	// Where are the conditions satisfied by at least one finding about the assets, such as registrar~example
+	Where []*findings.Condition
+	// Findings are the findings checked by the Where conditions, provided by the caller
+	Findings []*findings.Finding
+	// Aliases map the relation types used by previous releases to the current types, so the With
+	// clauses match the relations using either name. The default aliases are used when it is nil
+	Aliases relations.Mapping
+}
+
+// Saved is a named query from the configuration.
+type Saved struct {
+	Name        string `yaml:"-"`
+	Description string `yaml:"description,omitempty"`
+	Expression  string `yaml:"query"`
+}
+
+// Params returns the names of the parameters in the expression of the saved query.
+func (s *Saved) Params() []string {
+	return Params(s.Expression)
+}
+
+// ParseSaved returns the saved queries from the queries option, which maps each name to the expression,
+// or to a map providing the query and its description.
+func ParseSaved(raw interface{}) (map[string]*Saved, error) {
+	saved := make(map[string]*Saved)
+	if raw == nil {
+		return saved, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the queries option must map the query names to the queries")
+	}
+
+	for name, v := range m {
+		s := &Saved{Name: name}
+
+		if expr, ok := v.(string); ok {
+			s.Expression = expr
+		} else {
+			data, err := yaml.Marshal(v)
+			if err == nil {
+				err = yaml.Unmarshal(data, s)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("the %s query must be a query or a map providing the query", name)
+			}
+		}
+
+		// The queries with parameters are checked once the values are provided
+		if len(s.Params()) == 0 {
+			if _, err := Parse(s.Expression); err != nil {
+				return nil, fmt.Errorf("the %s query: %v", name, err)
+			}
+		}
+		saved[name] = s
+	}
+	return saved, nil
+}
+
+// Params returns the names of the parameters in the expression, in the order they first appear.
+func Params(expr string) []string {
+	var params []string
+
+	seen := make(map[string]struct{})
+	for _, m := range paramRE.FindAllStringSubmatch(expr, -1) {
+		if _, found := seen[m[1]]; !found {
+			seen[m[1]] = struct{}{}
+			params = append(params, m[1])
+		}
+	}
+	return params
+}
+
+// Expand replaces the parameters in the expression with the provided values.
+func Expand(expr string, values map[string]string) (string, error) {
+	var missing []string
+	for _, p := range Params(expr) {
+		if values[p] == "" {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("the query requires the %s parameters", strings.Join(missing, ", "))
+	}
+
+	return paramRE.ReplaceAllStringFunc(expr, func(p string) string {
+		return values[p[1:]]
+	}), nil
+}
+
+// Parse returns the Query for the expression, which starts with the asset type (fqdn, ip, netblock, asn
+// or org) followed by the clauses: resolving, under DOMAIN, within CIDR, matching REGEX, with RELATION
+// and where KEY=VALUE.
+func Parse(expr string) (*Query, error) {
+	if params := Params(expr); len(params) > 0 {
+		return nil, fmt.Errorf("the query requires the %s parameters", strings.Join(params, ", "))
+	}
+
+	tokens := strings.Fields(expr)
+	if len(tokens) == 0 {
+		return nil, errors.New("the query is empty")
+	}
+
+	t, found := assetTypes[strings.ToLower(tokens[0])]
+	if !found {
+		return nil, fmt.Errorf("%s is not a supported asset type", tokens[0])
+	}
+
+	q := &Query{Type: t}
+	for i := 1; i < len(tokens); i++ {
+		clause := strings.ToLower(tokens[i])
+		if clause == "resolving" {
+			if q.Type != oam.FQDN {
+				return nil, errors.New("only names can be resolving")
+			}
+			q.Resolving = true
+			continue
+		}
+
+		if i+1 >= len(tokens) {
+			return nil, fmt.Errorf("the %s clause requires a value", clause)
+		}
+		i++
+		arg := tokens[i]
+
+		switch clause {
+		case "under":
+			if q.Type != oam.FQDN {
+				return nil, errors.New("only names can be under a domain name")
+			}
+			q.Under = strings.ToLower(strings.Trim(arg, "."))
+		case "within":
+			if q.Type != oam.IPAddress && q.Type != oam.Netblock {
+				return nil, errors.New("only addresses and netblocks can be within a netblock")
+			}
+
+			prefix, err := netip.ParsePrefix(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid netblock %s: %v", arg, err)
+			}
+			q.Within = prefix.Masked()
+		case "matching":
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %v", arg, err)
+			}
+			q.Matching = re
+		case "with":
+			q.With = append(q.With, arg)
+		case "where":
+			c, err := findings.ParseCondition(arg)
+			if err != nil {
+				return nil, err
+			}
+			q.Where = append(q.Where, c)
+		default:
+			return nil, fmt.Errorf("%s is not a supported clause", tokens[i-1])
+		}
+	}
+	return q, nil
+}
+
+// Run returns the assets in the graph selected by the Query, ordered by their keys.
+func (q *Query) Run(g *export.Graph) []*format.AssetRecord {
+	out := make(map[string]map[string]struct{})
+	for _, rel := range g.Relations {
+		id := export.NodeID(rel.From)
+
+		if _, found := out[id]; !found {
+			out[id] = make(map[string]struct{})
+		}
+		out[id][q.Aliases.Canonical(rel.Relation)] = struct{}{}
+	}
+
+	resolving := q.resolvingNames(g)
+	matched := q.matchedAssets()
+	var results []*format.AssetRecord
+	for _, a := range g.Assets {
+		if a.Type != string(q.Type) {
+			continue
+		}
+		if q.Resolving && !resolving[a.Key] {
+			continue
+		}
+		if q.Under != "" && a.Key != q.Under && !strings.HasSuffix(a.Key, "."+q.Under) {
+			continue
+		}
+		if q.Within.IsValid() && !q.within(a) {
+			continue
+		}
+		if q.Matching != nil && !q.Matching.MatchString(a.Key) {
+			continue
+		}
+		if len(q.Where) > 0 && !matched[a.Key] {
+			continue
+		}
+
+		var missing bool
+		for _, rel := range q.With {
+			if _, found := out[export.NodeID(a)][q.Aliases.Canonical(rel)]; !found {
+				missing = true
+				break
+			}
+		}
+		if !missing {
+			results = append(results, a)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+	return results
+}
+
+func (q *Query) within(a *format.AssetRecord) bool {
+	if a.Type == string(oam.IPAddress) {
+		addr, err := netip.ParseAddr(a.Key)
+		return err == nil && q.Within.Contains(addr)
+	}
+
+	prefix, err := netip.ParsePrefix(a.Key)
+	return err == nil && prefix.Bits() >= q.Within.Bits() && q.Within.Contains(prefix.Addr())
+}
+
+// matchedAssets returns the assets with at least one finding satisfying all the Where conditions.
+func (q *Query) matchedAssets() map[string]bool {
+	matched := make(map[string]bool)
+	if len(q.Where) == 0 {
+		return matched
+	}
+
+	for _, f := range q.Findings {
+		if findings.MatchAll(f, q.Where) {
+			matched[f.Asset] = true
+		}
+	}
+	return matched
+}
+
+// resolvingNames returns the names with address records, directly or through their CNAME records.
+func (q *Query) resolvingNames(g *export.Graph) map[string]bool {
+	if !q.Resolving {
+		return make(map[string]bool)
+	}
+	return export.ResolvingNames(g)
+}
@@ -0,0 +1,174 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package query
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/export"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/relations"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+	"gopkg.in/yaml.v3"
+)
+
+func testGraph() *export.Graph {
+	fqdn := func(id, name string) *types.Asset {
+		return &types.Asset{ID: id, Asset: domain.FQDN{Name: name}}
+	}
+	ip := func(id, addr string) *types.Asset {
+		return &types.Asset{ID: id, Asset: network.IPAddress{Address: netip.MustParseAddr(addr), Type: "IPv4"}}
+	}
+	rel := func(t string) *types.Relation { return &types.Relation{Type: t} }
+
+	www, cdn, mail := fqdn("1", "www.owasp.org"), fqdn("2", "cdn.owasp.org"), fqdn("3", "mail.owasp.org")
+	edge, stale, apex := fqdn("4", "edge.cdn.example"), fqdn("5", "old.owasp.org"), fqdn("6", "owasp.org")
+	addr1, addr2 := ip("7", "192.0.2.1"), ip("8", "198.51.100.1")
+
+	g := export.NewGraph()
+	g.AddRelation(www, rel("a_record"), addr1)
+	g.AddRelation(cdn, rel("cname_record"), edge)
+	g.AddRelation(edge, rel("a_record"), addr2)
+	g.AddRelation(apex, rel("mx_record"), mail)
+	g.AddAsset(stale)
+	return g
+}
+
+func keys(t *testing.T, expr string) string {
+	q, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", expr, err)
+	}
+
+	var names []string
+	for _, a := range q.Run(testGraph()) {
+		names = append(names, a.Key)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestRun(t *testing.T) {
+	for expr, want := range map[string]string{
+		"fqdn resolving under owasp.org":     "cdn.owasp.org www.owasp.org",
+		"fqdn under owasp.org":               "cdn.owasp.org mail.owasp.org old.owasp.org owasp.org www.owasp.org",
+		"fqdn with mx_record":                "owasp.org",
+		"fqdn matching ^(www|mail)\\.":       "mail.owasp.org www.owasp.org",
+		"ip within 192.0.2.0/24":             "192.0.2.1",
+		"IP":                                 "192.0.2.1 198.51.100.1",
+		"fqdn resolving with cname_record":   "cdn.owasp.org",
+		"fqdn resolving under cdn.example":   "edge.cdn.example",
+		"fqdn resolving under example.com":   "",
+		"fqdn under owasp.org matching ^old": "old.owasp.org",
+	} {
+		if got := keys(t, expr); got != want {
+			t.Errorf("%s: expected %q, got %q", expr, want, got)
+		}
+	}
+}
+
+func TestAliases(t *testing.T) {
+	g := testGraph()
+	legacy := &types.Asset{ID: "9", Asset: domain.FQDN{Name: "legacy.owasp.org"}}
+	addr := &types.Asset{ID: "10", Asset: network.IPAddress{Address: netip.MustParseAddr("192.0.2.2"), Type: "IPv4"}}
+	g.AddRelation(legacy, &types.Relation{Type: "dns_a"}, addr)
+
+	for expr, want := range map[string]string{
+		"fqdn under owasp.org with a_record": "legacy.owasp.org www.owasp.org",
+		"fqdn under owasp.org with dns_a":    "legacy.owasp.org www.owasp.org",
+	} {
+		q, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", expr, err)
+		}
+		q.Aliases = relations.Mapping{"dns_a": "a_record"}
+
+		var names []string
+		for _, a := range q.Run(g) {
+			names = append(names, a.Key)
+		}
+		if got := strings.Join(names, " "); got != want {
+			t.Errorf("%s: expected %q, got %q", expr, want, got)
+		}
+	}
+}
+
+func TestWhere(t *testing.T) {
+	q, err := Parse("fqdn where type=domain_registration where nameservers~example.net")
+	if err != nil {
+		t.Fatalf("Failed to parse the query: %v", err)
+	}
+
+	q.Findings = []*findings.Finding{
+		{Asset: "owasp.org", Type: "domain_registration", Attributes: map[string]string{"nameservers": "ns1.example.net"}},
+		// Both conditions must be satisfied by the same finding
+		{Asset: "www.owasp.org", Type: "domain_registration"},
+		{Asset: "www.owasp.org", Type: "abuse_contact", Attributes: map[string]string{"nameservers": "ns1.example.net"}},
+	}
+
+	var names []string
+	for _, a := range q.Run(testGraph()) {
+		names = append(names, a.Key)
+	}
+	if got := strings.Join(names, " "); got != "owasp.org" {
+		t.Errorf("Expected only the registered domain, got %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"service resolving",
+		"ip resolving",
+		"fqdn under",
+		"fqdn within 192.0.2.0/24",
+		"ip within 192.0.2.0",
+		"fqdn matching (",
+		"fqdn exposing 443",
+		"fqdn under $domain",
+		"fqdn where registrar",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Expected an error for the query: %s", expr)
+		}
+	}
+}
+
+func TestSaved(t *testing.T) {
+	var raw interface{}
+	if err := yaml.Unmarshal([]byte(`
+live-web:
+  description: Names resolving under the domain
+  query: fqdn resolving under $domain matching ^$prefix
+mail: fqdn with mx_record
+`), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := ParseSaved(raw)
+	if err != nil {
+		t.Fatalf("Failed to parse the saved queries: %v", err)
+	}
+
+	live := saved["live-web"]
+	if live == nil || live.Description == "" || strings.Join(live.Params(), ",") != "domain,prefix" {
+		t.Fatalf("Unexpected saved query: %+v", live)
+	}
+	if _, err := Expand(live.Expression, map[string]string{"domain": "owasp.org"}); err == nil {
+		t.Error("Expected an error for the missing parameter")
+	}
+
+	expr, err := Expand(live.Expression, map[string]string{"domain": "owasp.org", "prefix": "www"})
+	if err != nil || expr != "fqdn resolving under owasp.org matching ^www" {
+		t.Errorf("Unexpected expansion %q: %v", expr, err)
+	}
+
+	if _, err := ParseSaved(map[string]interface{}{"broken": "fqdn exposing 443"}); err == nil {
+		t.Error("Expected an error for the invalid saved query")
+	}
+}
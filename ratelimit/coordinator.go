@@ -0,0 +1,178 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/probe"
+)
+
+const (
+	reservePath = "/reserve"
+	// maxInterval is the longest interval between the requests accepted by the coordinator
+	maxInterval = time.Hour
+)
+
+type reservation struct {
+	Key        string `json:"key"`
+	IntervalMS int64  `json:"interval_ms"`
+}
+
+type reserved struct {
+	WaitMS int64 `json:"wait_ms"`
+}
+
+// Server is the coordinator reserving the time slots of the requests for the engine instances.
+type Server struct {
+	ln     net.Listener
+	server *http.Server
+	// Limiter is also used by the engine instance running the coordinator
+	Limiter *Local
+}
+
+// NewServer starts serving the reservations on the provided address. The requests must provide
+// the secret as a bearer token when it is not empty. Without the secret, the reservations are only
+// served on the loopback addresses, like the task API.
+func NewServer(addr, secret string) (*Server, error) {
+	if secret == "" && !probe.LoopbackAddr(addr) {
+		return nil, fmt.Errorf("the rate limit coordinator requires a secret to be served on %s, which is not a loopback address", addr)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for rate limit reservations on %s: %v", addr, err)
+	}
+
+	s := &Server{
+		ln:      ln,
+		Limiter: NewLocal(),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(reservePath, Handler(s.Limiter, secret))
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() { _ = s.server.Serve(ln) }()
+	return s, nil
+}
+
+// Addr returns the address the reservations are being served on.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops serving the reservations.
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// Handler returns the HTTP handler reserving the time slots of the requests using the limiter.
+func Handler(l Limiter, secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "the reservations must be posted", http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorized(r, secret) {
+			http.Error(w, "the secret is missing or invalid", http.StatusUnauthorized)
+			return
+		}
+
+		var req reservation
+		if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil || req.Key == "" {
+			http.Error(w, "the reservation must provide the key and interval", http.StatusBadRequest)
+			return
+		}
+
+		interval := time.Duration(req.IntervalMS) * time.Millisecond
+		if interval <= 0 || interval > maxInterval {
+			http.Error(w, "the interval is out of range", http.StatusBadRequest)
+			return
+		}
+
+		delay, err := l.Reserve(r.Context(), req.Key, interval)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&reserved{WaitMS: delay.Milliseconds()})
+	})
+}
+
+func authorized(r *http.Request, secret string) bool {
+	if secret == "" {
+		return true
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// Client is the Limiter reserving the time slots from the coordinator served by another engine instance.
+type Client struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewClient returns a Client sending the reservations to the coordinator at the URL.
+func NewClient(url, secret string) *Client {
+	return &Client{
+		url:    strings.TrimSuffix(url, "/") + reservePath,
+		secret: secret,
+		client: amasshttp.NewClient("ratelimit", &amasshttp.ClientOptions{Timeout: 5 * time.Second}),
+	}
+}
+
+// Reserve implements the Limiter interface.
+func (c *Client) Reserve(ctx context.Context, key string, interval time.Duration) (time.Duration, error) {
+	body, err := json.Marshal(&reservation{Key: key, IntervalMS: interval.Milliseconds()})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+c.secret)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach the rate limit coordinator: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return 0, fmt.Errorf("the rate limit coordinator responded with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var res reserved
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return 0, errors.New("the rate limit coordinator provided an invalid response")
+	}
+	return time.Duration(res.WaitMS) * time.Millisecond, nil
+}
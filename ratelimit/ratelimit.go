@@ -0,0 +1,98 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ratelimit coordinates the request rates of the API keys shared by several engine instances,
+// so the combined rate of the requests made with each key stays within the limits of the provider.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Limiter reserves the time slots of the requests made with the API keys.
+type Limiter interface {
+	// Reserve returns how long the caller must wait before sending the request made with the key,
+	// so the requests made with the key are sent at most once per interval.
+	Reserve(ctx context.Context, key string, interval time.Duration) (time.Duration, error)
+}
+
+// Shared is the limiter coordinating the requests with the other engine instances, or nil when
+// the instances are not coordinated.
+var Shared Limiter
+
+// maxIdleKeys is the number of keys tracked before the keys without reserved slots are removed
+const maxIdleKeys = 1024
+
+// Local is the Limiter reserving the time slots within the process. The coordinator serves it
+// to the other engine instances.
+type Local struct {
+	sync.Mutex
+	next map[string]time.Time
+}
+
+// NewLocal returns an initialized Local limiter.
+func NewLocal() *Local {
+	return &Local{next: make(map[string]time.Time)}
+}
+
+// Reserve implements the Limiter interface.
+func (l *Local) Reserve(ctx context.Context, key string, interval time.Duration) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	if len(l.next) >= maxIdleKeys {
+		for k, t := range l.next {
+			if t.Before(now) {
+				delete(l.next, k)
+			}
+		}
+	}
+
+	slot := now
+	if t, found := l.next[key]; found && t.After(now) {
+		slot = t
+	}
+	l.next[key] = slot.Add(interval)
+	return slot.Sub(now), nil
+}
+
+// Wait blocks until the request made with the key can be sent, as reserved by the limiter.
+func Wait(ctx context.Context, l Limiter, key string, interval time.Duration) error {
+	delay, err := l.Reserve(ctx, key, interval)
+	if err != nil || delay <= 0 {
+		return err
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+	}
+	return nil
+}
+
+// KeyID returns the identifier of the API key used by the data source, which is the same for all the
+// engine instances sharing the key and does not reveal the credentials to the coordinator.
+func KeyID(source string, creds ...string) string {
+	h := sha256.New()
+
+	h.Write([]byte(source))
+	for _, c := range creds {
+		h.Write([]byte{0})
+		h.Write([]byte(c))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
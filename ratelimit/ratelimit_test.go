@@ -0,0 +1,124 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLocalReserve(t *testing.T) {
+	l := NewLocal()
+	ctx := context.Background()
+
+	for i, expected := range []time.Duration{0, time.Second, 2 * time.Second} {
+		delay, err := l.Reserve(ctx, "key", time.Second)
+		if err != nil {
+			t.Fatalf("Reservation %d failed: %v", i, err)
+		}
+		if diff := expected - delay; diff < 0 || diff > 100*time.Millisecond {
+			t.Errorf("Reservation %d: expected a wait of %v, got %v", i, expected, delay)
+		}
+	}
+
+	if delay, _ := l.Reserve(ctx, "other", time.Second); delay != 0 {
+		t.Errorf("The other key had to wait %v", delay)
+	}
+}
+
+func TestCoordinator(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:0", "s3cr3t")
+	if err != nil {
+		t.Fatalf("Failed to start the coordinator: %v", err)
+	}
+	defer srv.Close()
+
+	ctx := context.Background()
+	url := "http://" + srv.Addr()
+	// Two engine instances sharing the API key
+	a, b := NewClient(url, "s3cr3t"), NewClient(url, "s3cr3t")
+
+	if delay, err := a.Reserve(ctx, "key", time.Minute); err != nil || delay != 0 {
+		t.Fatalf("Unexpected first reservation: %v %v", delay, err)
+	}
+	if delay, err := b.Reserve(ctx, "key", time.Minute); err != nil || delay < 59*time.Second {
+		t.Errorf("The second instance was not given the next slot: %v %v", delay, err)
+	}
+	if delay, err := srv.Limiter.Reserve(ctx, "key", time.Minute); err != nil || delay < 119*time.Second {
+		t.Errorf("The coordinator instance was not given the third slot: %v %v", delay, err)
+	}
+
+	if _, err := NewClient(url, "wrong").Reserve(ctx, "key", time.Minute); err == nil {
+		t.Error("The reservation with the wrong secret was accepted")
+	}
+	if _, err := a.Reserve(ctx, "key", 2*maxInterval); err == nil {
+		t.Error("The reservation with the interval out of range was accepted")
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := NewLocal()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := Wait(ctx, l, "key", time.Hour); err != nil {
+		t.Errorf("The first request had to wait: %v", err)
+	}
+
+	cancel()
+	if err := Wait(ctx, l, "key", time.Hour); err == nil {
+		t.Error("Expected an error once the context was cancelled")
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	if KeyID("Shodan", "key1") != KeyID("Shodan", "key1") {
+		t.Error("The identifiers of the same key are not equal")
+	}
+	if KeyID("Shodan", "key1") == KeyID("Shodan", "key2") || KeyID("Shodan", "ab", "c") == KeyID("Shodan", "a", "bc") {
+		t.Error("The identifiers of different keys are equal")
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	if s, err := ParseSettings(nil); s != nil || err != nil {
+		t.Errorf("Unexpected settings without the option: %v %v", s, err)
+	}
+
+	s, err := ParseSettings(map[string]interface{}{"url": "http://10.0.0.5:7071", "secret": "s3cr3t"})
+	if err != nil || s.URL != "http://10.0.0.5:7071" || s.Secret != "s3cr3t" {
+		t.Errorf("Unexpected settings: %v %v", s, err)
+	}
+
+	for _, raw := range []interface{}{
+		"http://10.0.0.5:7071",
+		map[string]interface{}{"secret": "s3cr3t"},
+		map[string]interface{}{"url": "10.0.0.5:7071"},
+		map[string]interface{}{"listen": 7071},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+func TestCoordinatorLoopback(t *testing.T) {
+	for _, addr := range []string{":0", "0.0.0.0:0", "192.0.2.1:0"} {
+		if srv, err := NewServer(addr, ""); err == nil {
+			srv.Close()
+			t.Errorf("Expected the coordinator without a secret to be refused on %s", addr)
+		}
+	}
+
+	srv, err := NewServer("127.0.0.1:0", "")
+	if err != nil {
+		t.Fatalf("Expected the coordinator without a secret to be served on the loopback address: %v", err)
+	}
+	defer srv.Close()
+
+	if _, err := NewClient("http://"+srv.Addr(), "").Reserve(context.Background(), "key", time.Minute); err != nil {
+		t.Errorf("The reservation on the loopback address failed: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Settings are provided by the rate_limit_coordinator option of the configuration.
+type Settings struct {
+	// Listen is the address the coordinator is served on by this engine instance
+	Listen string
+	// URL is the coordinator served by another engine instance
+	URL string
+	// Secret must be provided by the engine instances to the coordinator
+	Secret string
+}
+
+// ParseSettings returns the settings of the rate limit coordinator, or nil when the option is not provided.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("rate_limit_coordinator must provide the listen or url settings")
+	}
+
+	s := new(Settings)
+	for key, dst := range map[string]*string{"listen": &s.Listen, "url": &s.URL, "secret": &s.Secret} {
+		if v, found := m[key]; found && v != nil {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("the rate_limit_coordinator %s setting must be a string", key)
+			}
+			*dst = str
+		}
+	}
+
+	if s.Listen == "" && s.URL == "" {
+		return nil, errors.New("rate_limit_coordinator must provide the listen or url settings")
+	}
+	if s.URL != "" {
+		if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("the rate_limit_coordinator url %s is not a valid HTTP URL", s.URL)
+		}
+	}
+	return s, nil
+}
+
+// Setup sets the Shared limiter using the settings, and returns the coordinator when it is served
+// by this engine instance. The instance serving the coordinator reserves the time slots directly.
+func Setup(s *Settings) (*Server, error) {
+	if s == nil {
+		Shared = nil
+		return nil, nil
+	}
+
+	if s.Listen != "" {
+		srv, err := NewServer(s.Listen, s.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		Shared = srv.Limiter
+		return srv, nil
+	}
+
+	Shared = NewClient(s.URL, s.Secret)
+	return nil, nil
+}
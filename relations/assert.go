@@ -0,0 +1,151 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package relations
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// AssertionsFileName is the name of the file in the output directory that records the asserted relations.
+const AssertionsFileName = "assertions.json"
+
+// ManualSource is the source of the relations asserted by the analysts.
+const ManualSource = "manual"
+
+// Assertion is a relation between two assets stated by an analyst, such as the autonomous system managed by an
+// organization according to the contract documents. The graph database only keeps the relation, so the source,
+// the analyst and the reason are recorded in the assertions file.
+type Assertion struct {
+	Time     time.Time          `json:"time"`
+	From     collections.Member `json:"from"`
+	Relation string             `json:"relation"`
+	To       collections.Member `json:"to"`
+	Source   string             `json:"source"`
+	Analyst  string             `json:"analyst"`
+	Reason   string             `json:"reason,omitempty"`
+}
+
+// AssertionDatabase is the subset of the graph database used to store the asserted relations.
+type AssertionDatabase interface {
+	Create(source *types.Asset, relation string, discovered oam.Asset) (*types.Asset, error)
+	FindByContent(asset oam.Asset, since time.Time) ([]*types.Asset, error)
+}
+
+// Validate checks that the assertion names the analyst and two assets, and that the relation, once the
+// aliases are resolved, is valid between the types of the assets in the asset taxonomy. The relation
+// is replaced by its canonical type, and the source defaults to ManualSource.
+func (m Mapping) Validate(a *Assertion) error {
+	if strings.TrimSpace(a.Analyst) == "" {
+		return errors.New("the analyst asserting the relation must be identified")
+	}
+	if a.From.Asset() == nil {
+		return fmt.Errorf("%s is not a valid asset", a.From.ID())
+	}
+	if a.To.Asset() == nil {
+		return fmt.Errorf("%s is not a valid asset", a.To.ID())
+	}
+
+	rtype := m.Canonical(strings.ToLower(strings.TrimSpace(a.Relation)))
+	if !oam.ValidRelationship(oam.AssetType(a.From.Type), rtype, oam.AssetType(a.To.Type)) {
+		return fmt.Errorf("the %s relation from the %s asset to the %s asset is not valid in the asset taxonomy",
+			rtype, a.From.Type, a.To.Type)
+	}
+
+	a.Relation = rtype
+	if a.Source == "" {
+		a.Source = ManualSource
+	}
+	return nil
+}
+
+// Assert validates the assertion and stores the relation in the graph database. Unless create is true,
+// both assets must already be in the graph database, so a mistyped key is not added as a new asset.
+func (m Mapping) Assert(db AssertionDatabase, a *Assertion, create bool) error {
+	if err := m.Validate(a); err != nil {
+		return err
+	}
+
+	from, err := findAsset(db, a.From, create)
+	if err != nil {
+		return err
+	}
+	if _, err := findAsset(db, a.To, create); err != nil {
+		return err
+	}
+
+	if _, err := db.Create(from, a.Relation, a.To.Asset()); err != nil {
+		return fmt.Errorf("failed to store the %s relation: %v", a.Relation, err)
+	}
+	if a.Time.IsZero() {
+		a.Time = time.Now().UTC()
+	}
+	return nil
+}
+
+func findAsset(db AssertionDatabase, m collections.Member, create bool) (*types.Asset, error) {
+	assets, err := db.FindByContent(m.Asset(), time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the graph database: %v", err)
+	}
+	if len(assets) > 0 {
+		return assets[0], nil
+	}
+	if !create {
+		return nil, fmt.Errorf("%s was not found in the graph database", m.ID())
+	}
+
+	a, err := db.Create(nil, "", m.Asset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to store the %s asset: %v", m.ID(), err)
+	}
+	return a, nil
+}
+
+// RecordAssertion appends the assertion to the assertions file at the provided path.
+func RecordAssertion(path string, a *Assertion) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(f).Encode(a); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadAssertions returns the assertions recorded in the file at the provided path.
+func ReadAssertions(path string) ([]*Assertion, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var as []*Assertion
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var a Assertion
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			continue
+		}
+		as = append(as, &a)
+	}
+	return as, scanner.Err()
+}
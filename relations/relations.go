@@ -0,0 +1,196 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package relations maps the relation types written by previous releases to the types of the current
+// asset taxonomy, so the graph databases populated by older releases remain queryable after the names
+// of the relations change, and rewrites the relations stored using the previous names.
+package relations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// Aliases maps the relation types used by previous releases to the current relation types.
+var Aliases = map[string]string{
+	// The autonomous systems announced their netblocks using the prefix relations
+	"prefix": "announces",
+	// The contacts of the registrations were related without the contact suffix
+	"registrant": "registrant_contact",
+	"admin":      "admin_contact",
+	"technical":  "technical_contact",
+	"billing":    "billing_contact",
+}
+
+// Mapping maps the relation type aliases to the canonical relation types. A nil Mapping provides the Aliases.
+type Mapping map[string]string
+
+// ParseMapping returns the Aliases extended by the relation_aliases option, which maps each alias to the
+// canonical relation type. The option can also replace the canonical type of the default aliases.
+func ParseMapping(raw interface{}) (Mapping, error) {
+	m := make(Mapping, len(Aliases))
+	for alias, rtype := range Aliases {
+		m[alias] = rtype
+	}
+	if raw == nil {
+		return m, nil
+	}
+
+	opts, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the relation_aliases option must map the aliases to the relation types")
+	}
+
+	for alias, v := range opts {
+		rtype, ok := v.(string)
+		if !ok || strings.TrimSpace(rtype) == "" {
+			return nil, fmt.Errorf("the %s alias must provide a relation type", alias)
+		}
+
+		alias, rtype = strings.ToLower(strings.TrimSpace(alias)), strings.ToLower(strings.TrimSpace(rtype))
+		if alias == rtype {
+			return nil, fmt.Errorf("the %s alias cannot map to itself", alias)
+		}
+		m[alias] = rtype
+	}
+
+	for alias := range m {
+		if _, err := m.resolve(alias); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m Mapping) aliases() map[string]string {
+	if m == nil {
+		return Aliases
+	}
+	return m
+}
+
+// resolve follows the aliases to the canonical type, since an alias can map to another alias.
+func (m Mapping) resolve(rtype string) (string, error) {
+	aliases := m.aliases()
+
+	seen := map[string]struct{}{rtype: {}}
+	for {
+		next, found := aliases[rtype]
+		if !found {
+			return rtype, nil
+		}
+		if _, loop := seen[next]; loop {
+			return "", fmt.Errorf("the %s relation alias is part of a loop", next)
+		}
+
+		seen[next] = struct{}{}
+		rtype = next
+	}
+}
+
+// Canonical returns the current relation type of the alias, or the relation type when it is not an alias.
+func (m Mapping) Canonical(rtype string) string {
+	if c, err := m.resolve(rtype); err == nil {
+		return c
+	}
+	return rtype
+}
+
+// Variants returns the canonical type of the relation type followed by all of its aliases, sorted,
+// so the graph database can be asked for the relations stored using any of them.
+func (m Mapping) Variants(rtype string) []string {
+	canonical := m.Canonical(rtype)
+
+	var aliases []string
+	for alias := range m.aliases() {
+		if alias != canonical && m.Canonical(alias) == canonical {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return append([]string{canonical}, aliases...)
+}
+
+// Database is the subset of the graph database used to rewrite the relations.
+type Database interface {
+	Create(source *types.Asset, relation string, discovered oam.Asset) (*types.Asset, error)
+	DeleteRelation(id string) error
+}
+
+// Change is a relation stored using an alias, and the canonical type replacing it.
+type Change struct {
+	Relation  *types.Relation
+	From      *types.Asset
+	To        *types.Asset
+	Canonical string
+	// Skipped is true when the canonical type is not valid between the types of the assets in the
+	// asset taxonomy, so the relation cannot be stored using it and is left for review
+	Skipped bool
+	// Migrated is true once the relation was replaced by the relation using the canonical type
+	Migrated bool
+}
+
+// Plan returns the changes normalizing the relations stored using the aliases. The assets provide
+// the content of the relations, which only reference the assets by their IDs.
+func (m Mapping) Plan(assets []*types.Asset, rels []*types.Relation) []*Change {
+	ids := make(map[string]*types.Asset, len(assets))
+	for _, a := range assets {
+		ids[a.ID] = a
+	}
+
+	var changes []*Change
+	for _, rel := range rels {
+		canonical := m.Canonical(rel.Type)
+		if canonical == rel.Type {
+			continue
+		}
+
+		from, to := ids[rel.FromAsset.ID], ids[rel.ToAsset.ID]
+		if from == nil || to == nil {
+			continue
+		}
+
+		changes = append(changes, &Change{
+			Relation:  rel,
+			From:      from,
+			To:        to,
+			Canonical: canonical,
+			Skipped:   !oam.ValidRelationship(from.Asset.AssetType(), canonical, to.Asset.AssetType()),
+		})
+	}
+	return changes
+}
+
+// Migrate links the assets of each change using the canonical type before the relation stored using
+// the alias is removed. The changes that were skipped are not applied. The database should be a trash.Bin knowing
+// the relations of the changes, so the relations removed are kept in the trash. The number of relations migrated is returned.
+func Migrate(ctx context.Context, db Database, changes []*Change) (int, error) {
+	var count int
+
+	for _, c := range changes {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		if c.Skipped {
+			continue
+		}
+
+		if _, err := db.Create(c.From, c.Canonical, c.To.Asset); err != nil {
+			return count, fmt.Errorf("failed to migrate the %s relation %s: %v", c.Relation.Type, c.Relation.ID, err)
+		}
+		if err := db.DeleteRelation(c.Relation.ID); err != nil {
+			return count, fmt.Errorf("failed to remove the %s relation %s: %v", c.Relation.Type, c.Relation.ID, err)
+		}
+
+		c.Migrated = true
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,157 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package relations
+
+import (
+	"context"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/collections"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func TestMapping(t *testing.T) {
+	m, err := ParseMapping(map[string]interface{}{
+		"Announced_By": "prefix",
+		"dns_a":        "a_record",
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the aliases: %v", err)
+	}
+
+	for rtype, want := range map[string]string{
+		"prefix":       "announces",
+		"announced_by": "announces",
+		"dns_a":        "a_record",
+		"registrant":   "registrant_contact",
+		"a_record":     "a_record",
+	} {
+		if got := m.Canonical(rtype); got != want {
+			t.Errorf("%s: expected %s, got %s", rtype, want, got)
+		}
+	}
+	if got := strings.Join(m.Variants("prefix"), ","); got != "announces,announced_by,prefix" {
+		t.Errorf("Unexpected variants: %s", got)
+	}
+	if got := Mapping(nil).Canonical("prefix"); got != "announces" {
+		t.Errorf("Expected the nil Mapping to provide the default aliases, got %s", got)
+	}
+
+	for _, raw := range []interface{}{
+		"prefix",
+		map[string]interface{}{"a": 1},
+		map[string]interface{}{"a": "a"},
+		map[string]interface{}{"a": "b", "b": "a"},
+	} {
+		if _, err := ParseMapping(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+type memoryDB struct {
+	created []string
+	deleted []string
+}
+
+func (m *memoryDB) Create(source *types.Asset, relation string, discovered oam.Asset) (*types.Asset, error) {
+	m.created = append(m.created, relation)
+	return &types.Asset{Asset: discovered}, nil
+}
+
+func (m *memoryDB) DeleteRelation(id string) error {
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func TestMigrate(t *testing.T) {
+	asn := &types.Asset{ID: "1", Asset: network.AutonomousSystem{Number: 64496}}
+	netblock := &types.Asset{ID: "2", Asset: network.Netblock{Cidr: netip.MustParsePrefix("192.0.2.0/24"), Type: "IPv4"}}
+	fqdn := &types.Asset{ID: "3", Asset: domain.FQDN{Name: "owasp.org"}}
+	rel := func(id, rtype string, from, to *types.Asset) *types.Relation {
+		return &types.Relation{ID: id, Type: rtype, FromAsset: &types.Asset{ID: from.ID}, ToAsset: &types.Asset{ID: to.ID}}
+	}
+
+	changes := Mapping(nil).Plan([]*types.Asset{asn, netblock, fqdn}, []*types.Relation{
+		rel("10", "prefix", asn, netblock),
+		rel("11", "announces", asn, netblock),
+		// The registrant contacts are not part of the asset taxonomy yet
+		rel("12", "registrant", fqdn, fqdn),
+	})
+	if len(changes) != 2 || changes[0].Skipped || !changes[1].Skipped {
+		t.Fatalf("Unexpected changes: %+v", changes)
+	}
+
+	db := new(memoryDB)
+	n, err := Migrate(context.Background(), db, changes)
+	if err != nil || n != 1 {
+		t.Fatalf("Expected one relation to be migrated, got %d: %v", n, err)
+	}
+	if !changes[0].Migrated || strings.Join(db.created, ",") != "announces" || strings.Join(db.deleted, ",") != "10" {
+		t.Errorf("Unexpected migration: created %v, deleted %v", db.created, db.deleted)
+	}
+}
+
+type assertDB struct {
+	memoryDB
+	assets map[string]*types.Asset
+}
+
+func (m *assertDB) FindByContent(asset oam.Asset, since time.Time) ([]*types.Asset, error) {
+	if a, found := m.assets[format.AssetKey(asset)]; found {
+		return []*types.Asset{a}, nil
+	}
+	return nil, nil
+}
+
+func TestAssert(t *testing.T) {
+	asn, _ := collections.ParseMember("AS64496")
+	org, _ := collections.ParseMember("org:Example Corp")
+	fqdn, _ := collections.ParseMember("www.owasp.org")
+	db := &assertDB{assets: map[string]*types.Asset{
+		"64496": {ID: "1", Asset: asn.Asset()},
+	}}
+
+	for _, a := range []*Assertion{
+		{From: asn, Relation: "managed_by", To: org},
+		{From: asn, Relation: "managed_by", To: fqdn, Analyst: "jdoe"},
+		{From: org, Relation: "managed_by", To: asn, Analyst: "jdoe"},
+	} {
+		if err := Mapping(nil).Validate(a); err == nil {
+			t.Errorf("Expected an error for %+v", a)
+		}
+	}
+
+	a := &Assertion{From: asn, Relation: "Managed_By", To: org, Analyst: "jdoe", Reason: "contract 42"}
+	if err := Mapping(nil).Assert(db, a, false); err == nil {
+		t.Error("Expected an error for the organization missing from the graph database")
+	}
+	if err := Mapping(nil).Assert(db, a, true); err != nil {
+		t.Fatalf("Failed to assert the relation: %v", err)
+	}
+	if a.Relation != "managed_by" || a.Source != ManualSource || a.Time.IsZero() {
+		t.Errorf("Unexpected assertion: %+v", a)
+	}
+	if got := strings.Join(db.created, ","); got != ",managed_by" {
+		t.Errorf("Unexpected relations created: %s", got)
+	}
+
+	path := filepath.Join(t.TempDir(), AssertionsFileName)
+	if err := RecordAssertion(path, a); err != nil {
+		t.Fatalf("Failed to record the assertion: %v", err)
+	}
+	as, err := ReadAssertions(path)
+	if err != nil || len(as) != 1 || as[0].Analyst != "jdoe" || as[0].To != org {
+		t.Errorf("Unexpected assertions: %+v, %v", as, err)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package repos
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+const (
+	requestTimeout = 5 * time.Minute
+	maxListSize    = 4 * 1024 * 1024
+)
+
+var (
+	// ErrRateLimited is returned once the code hosting API refuses the requests until the limit is reset.
+	ErrRateLimited = errors.New("the rate limit of the GitHub API was exceeded")
+	// ErrTooLarge is returned for the repositories exceeding the max_repo_size setting.
+	ErrTooLarge = errors.New("the repository exceeds the size limit")
+)
+
+// Repo is a public repository of the organization.
+type Repo struct {
+	Name          string `json:"full_name"`
+	URL           string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+	// Size is in kilobytes
+	Size     int64 `json:"size"`
+	Fork     bool  `json:"fork"`
+	Archived bool  `json:"archived"`
+}
+
+// Scanner downloads the public repositories of the organizations from GitHub, and extracts the references
+// to the infrastructure in scope from the configuration files. The repositories are downloaded as archives of
+// the default branch, so a git client is not required, and the requests are spaced by the Interval setting.
+type Scanner struct {
+	sync.Mutex
+	// BaseURL is the address of the GitHub API
+	BaseURL  string
+	Token    string
+	Settings *Settings
+	Scope    *Scope
+	HTTP     *http.Client
+	last     time.Time
+}
+
+// NewScanner returns a Scanner for the settings, using the optional GitHub API token.
+func NewScanner(s *Settings, scope *Scope, token string) *Scanner {
+	return &Scanner{
+		BaseURL:  "https://api.github.com",
+		Token:    token,
+		Settings: s,
+		Scope:    scope,
+		HTTP: amasshttp.NewClient("github", &amasshttp.ClientOptions{
+			Timeout: requestTimeout,
+			Quota:   amasshttp.NewQuotaManager(),
+		}),
+	}
+}
+
+// Scan returns the references found in the repositories of the organization, and none when the organization
+// does not exist. The forks, archived repositories and those exceeding the size limit are not downloaded. The
+// errors of the individual repositories are returned along with the references found in the others.
+func (s *Scanner) Scan(ctx context.Context, org string) ([]*Reference, error) {
+	repos, err := s.Repos(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []*Reference
+	var msgs []string
+	for _, repo := range repos {
+		found, err := s.ScanRepo(ctx, repo)
+		refs = append(refs, found...)
+		if errors.Is(err, ErrRateLimited) || ctx.Err() != nil {
+			return refs, err
+		} else if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", repo.Name, err))
+		}
+	}
+
+	if len(msgs) > 0 {
+		return refs, errors.New(strings.Join(msgs, "; "))
+	}
+	return refs, nil
+}
+
+// Repos returns the public repositories of the organization selected for scanning, with the most
+// recently pushed first.
+func (s *Scanner) Repos(ctx context.Context, org string) ([]*Repo, error) {
+	u := s.BaseURL + "/orgs/" + url.PathEscape(org) + "/repos?type=public&sort=pushed&per_page=100"
+
+	resp, err := s.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	var all []*Repo
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxListSize)).Decode(&all); err != nil {
+		return nil, fmt.Errorf("failed to parse the repositories of %s: %v", org, err)
+	}
+
+	var repos []*Repo
+	for _, r := range all {
+		if r.Fork || r.Archived || r.Size*1024 > s.Settings.MaxRepoSize {
+			continue
+		}
+		repos = append(repos, r)
+		if len(repos) >= s.Settings.MaxRepos {
+			break
+		}
+	}
+	return repos, nil
+}
+
+// ScanRepo downloads the archive of the default branch and returns the references found in its
+// configuration files.
+func (s *Scanner) ScanRepo(ctx context.Context, repo *Repo) ([]*Reference, error) {
+	u := s.BaseURL + "/repos/" + repo.Name + "/tarball/" + url.PathEscape(repo.DefaultBranch)
+
+	resp, err := s.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	// The size reported by the API is only an estimate, so the download is also limited
+	body := &limitedReader{r: resp.Body, remaining: s.Settings.MaxRepoSize}
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the archive: %v", err)
+	}
+	defer gz.Close()
+
+	var refs []*Reference
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return refs, err
+		}
+
+		// The paths are prefixed by a directory named after the repository and the commit
+		_, file, ok := strings.Cut(hdr.Name, "/")
+		if !ok || hdr.Typeflag != tar.TypeReg || hdr.Size > MaxFileSize || !ConfigFile(file) {
+			continue
+		}
+
+		found, err := Extract(tr, file, s.Scope)
+		if err != nil {
+			continue
+		}
+		for _, ref := range found {
+			ref.Repo = repo.Name
+			ref.URL = repo.URL + "/blob/" + repo.DefaultBranch + "/" + file + "#L" + strconv.Itoa(ref.Line)
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// get sends the request once the interval since the previous request has passed. The responses other
+// than success and not found are returned as errors.
+func (s *Scanner) get(ctx context.Context, u string) (*http.Response, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Amass)")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound:
+		return resp, nil
+	case resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode == http.StatusForbidden &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0"):
+		resp.Body.Close()
+		return nil, ErrRateLimited
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("the request returned status %d", resp.StatusCode)
+}
+
+func (s *Scanner) wait(ctx context.Context) error {
+	s.Lock()
+	next := s.last.Add(s.Settings.Interval)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	s.last = next
+	s.Unlock()
+
+	t := time.NewTimer(time.Until(next))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+	}
+	return nil
+}
+
+// limitedReader returns ErrTooLarge once more than the remaining bytes have been read.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// The archive may end exactly at the limit
+		var b [1]byte
+		if _, err := l.r.Read(b[:]); err == io.EOF {
+			return 0, io.EOF
+		}
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
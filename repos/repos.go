@@ -0,0 +1,271 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package repos scans the public source code repositories of the target organization for references to
+// its infrastructure. The infrastructure as code and configuration files commonly name the hosts, the
+// addresses and the cloud resources of internal and staging environments that are not found elsewhere.
+package repos
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/dns"
+)
+
+// TypeReference is the type of the findings recorded for the references found in the repositories.
+const TypeReference = "repo_reference"
+
+// The kinds of references found in the files.
+const (
+	KindName    = "fqdn"
+	KindAddress = "ip"
+	KindCloud   = "cloud_resource"
+)
+
+// Defaults of the repo_scan settings.
+const (
+	DefaultMaxRepos    = 20
+	DefaultMaxRepoSize = 25 * 1024 * 1024
+	DefaultInterval    = time.Second
+	// MaxFileSize is the size of the largest file scanned, since larger files are rarely written by hand
+	MaxFileSize = 1024 * 1024
+)
+
+// Settings are provided by the repo_scan option.
+type Settings struct {
+	// Orgs are the organizations whose repositories are scanned, instead of those named after the root domains
+	Orgs     []string
+	MaxRepos int
+	// MaxRepoSize is the size in bytes of the largest repository downloaded
+	MaxRepoSize int64
+	// Interval is the time between the requests sent to the code hosting API
+	Interval time.Duration
+}
+
+// ParseSettings returns the Settings provided by the repo_scan option, or nil when the option disables
+// the scanning. The option is either true or a section providing the settings.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	s := &Settings{
+		MaxRepos:    DefaultMaxRepos,
+		MaxRepoSize: DefaultMaxRepoSize,
+		Interval:    DefaultInterval,
+	}
+
+	if raw == nil {
+		return s, nil
+	}
+	if enabled, ok := raw.(bool); ok {
+		if !enabled {
+			return nil, nil
+		}
+		return s, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the repo_scan option must be true or a section providing the settings")
+	}
+
+	if v, found := m["orgs"]; found {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, errors.New("the repo_scan orgs must be a list")
+		}
+		for _, item := range list {
+			org, ok := item.(string)
+			if !ok || strings.TrimSpace(org) == "" {
+				return nil, fmt.Errorf("%v is not the name of an organization", item)
+			}
+			s.Orgs = append(s.Orgs, strings.TrimSpace(org))
+		}
+	}
+	if v, found := m["max_repos"]; found {
+		n, ok := v.(int)
+		if !ok || n <= 0 {
+			return nil, errors.New("the repo_scan max_repos must be a number greater than zero")
+		}
+		s.MaxRepos = n
+	}
+	if v, found := m["max_repo_size"]; found {
+		n, ok := v.(int)
+		if !ok || n <= 0 {
+			return nil, errors.New("the repo_scan max_repo_size must be a number of megabytes greater than zero")
+		}
+		s.MaxRepoSize = int64(n) * 1024 * 1024
+	}
+	if v, found := m["interval"]; found {
+		n, ok := v.(int)
+		if !ok || n < 0 {
+			return nil, errors.New("the repo_scan interval must be a number of seconds")
+		}
+		s.Interval = time.Duration(n) * time.Second
+	}
+	return s, nil
+}
+
+// Reference is a name, address or cloud resource found in a file of a repository.
+type Reference struct {
+	Kind  string
+	Value string
+	// Provider is the service of the cloud resources, such as aws_s3
+	Provider string
+	Repo     string
+	Path     string
+	Line     int
+	// URL is the address of the line on the code hosting service
+	URL string
+}
+
+// Finding returns the finding recorded for the reference.
+func (r *Reference) Finding() *findings.Finding {
+	attrs := map[string]string{
+		"kind": r.Kind,
+		"repo": r.Repo,
+		"path": r.Path,
+		"line": strconv.Itoa(r.Line),
+		"url":  r.URL,
+	}
+	if r.Provider != "" {
+		attrs["provider"] = r.Provider
+	}
+
+	return &findings.Finding{
+		Asset:      r.Value,
+		Type:       TypeReference,
+		Severity:   findings.SeverityInfo,
+		Title:      fmt.Sprintf("Referenced by %s/%s:%d", r.Repo, r.Path, r.Line),
+		Attributes: attrs,
+	}
+}
+
+// Scope decides which of the names and addresses found in the files are references to the infrastructure
+// of the target. The cloud resources are not checked, since the repositories belong to the organization.
+type Scope struct {
+	Name    func(name string) bool
+	Address func(addr string) bool
+}
+
+// configExts are the extensions of the infrastructure as code and configuration files.
+var configExts = map[string]struct{}{
+	".tf": {}, ".tfvars": {}, ".hcl": {}, ".yaml": {}, ".yml": {}, ".json": {}, ".toml": {},
+	".ini": {}, ".cfg": {}, ".conf": {}, ".config": {}, ".env": {}, ".properties": {}, ".xml": {},
+	".j2": {}, ".tpl": {}, ".template": {}, ".bicep": {}, ".pp": {},
+}
+
+// configNames are the names of the configuration files without such extensions.
+var configNames = map[string]struct{}{
+	"dockerfile": {}, "vagrantfile": {}, "jenkinsfile": {}, "caddyfile": {}, "hosts": {}, "inventory": {},
+}
+
+// skipDirs are the directories of third-party code and generated files.
+var skipDirs = []string{"node_modules/", "vendor/", ".terraform/", "third_party/"}
+
+// ConfigFile returns true when the file at the path is an infrastructure as code or configuration file.
+func ConfigFile(p string) bool {
+	lower := strings.ToLower(p)
+	for _, dir := range skipDirs {
+		if strings.HasPrefix(lower, dir) || strings.Contains(lower, "/"+dir) {
+			return false
+		}
+	}
+
+	base := path.Base(lower)
+	if base == "package-lock.json" || base == "composer.lock" {
+		return false
+	}
+	if _, found := configNames[base]; found {
+		return true
+	}
+	// Such as .env.production and docker-compose.override.yml
+	if strings.HasPrefix(base, ".env") || strings.HasPrefix(base, "dockerfile.") {
+		return true
+	}
+	_, found := configExts[path.Ext(base)]
+	return found
+}
+
+type cloudPattern struct {
+	provider string
+	re       *regexp.Regexp
+}
+
+// cloudPatterns match the names of the cloud resources, which are the first submatch.
+var cloudPatterns = []*cloudPattern{
+	{"aws_s3", regexp.MustCompile(`(?i)s3://([a-z0-9][a-z0-9.-]{1,61}[a-z0-9])`)},
+	{"aws_s3", regexp.MustCompile(`(?i)arn:aws:s3:::([a-z0-9][a-z0-9.-]{1,61}[a-z0-9])`)},
+	{"aws_s3", regexp.MustCompile(`(?i)([a-z0-9][a-z0-9.-]{1,61}[a-z0-9])\.s3[.-](?:[a-z0-9-]+\.)?amazonaws\.com`)},
+	{"aws_cloudfront", regexp.MustCompile(`(?i)\b([a-z0-9]{13,14})\.cloudfront\.net`)},
+	{"azure_blob", regexp.MustCompile(`(?i)\b([a-z0-9]{3,24})\.blob\.core\.windows\.net`)},
+	{"azure_app", regexp.MustCompile(`(?i)\b([a-z0-9][a-z0-9-]{0,58}[a-z0-9])\.azurewebsites\.net`)},
+	{"gcp_storage", regexp.MustCompile(`(?i)gs://([a-z0-9][a-z0-9._-]{1,61}[a-z0-9])`)},
+	{"gcp_storage", regexp.MustCompile(`(?i)storage\.googleapis\.com/([a-z0-9][a-z0-9._-]{1,61}[a-z0-9])`)},
+}
+
+var (
+	nameRegex = dns.AnySubdomainRegex()
+	ipv4Regex = regexp.MustCompile(`\b(?:[0-9]{1,3}\.){3}[0-9]{1,3}\b`)
+	ipv6Regex = regexp.MustCompile(`(?i)[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}`)
+)
+
+// Extract returns the references found in the file, with the location of the first occurrence of each.
+// The Repo and URL fields are left for the caller.
+func Extract(r io.Reader, file string, scope *Scope) ([]*Reference, error) {
+	var refs []*Reference
+	seen := make(map[string]struct{})
+	add := func(kind, value, provider string, line int) {
+		key := kind + "|" + value
+		if _, found := seen[key]; found {
+			return
+		}
+		seen[key] = struct{}{}
+		refs = append(refs, &Reference{
+			Kind:     kind,
+			Value:    value,
+			Provider: provider,
+			Path:     file,
+			Line:     line,
+		})
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxFileSize)
+	for num := 1; scanner.Scan(); num++ {
+		line := scanner.Text()
+
+		for _, p := range cloudPatterns {
+			for _, m := range p.re.FindAllStringSubmatch(line, -1) {
+				add(KindCloud, strings.ToLower(m[1]), p.provider, num)
+			}
+		}
+		for _, m := range nameRegex.FindAllString(line, -1) {
+			name := strings.ToLower(strings.Trim(m, "."))
+			if scope != nil && scope.Name != nil && scope.Name(name) {
+				add(KindName, name, "", num)
+			}
+		}
+		for _, re := range []*regexp.Regexp{ipv4Regex, ipv6Regex} {
+			for _, m := range re.FindAllString(line, -1) {
+				ip := net.ParseIP(m)
+				if ip == nil {
+					continue
+				}
+				addr := ip.String()
+				if scope != nil && scope.Address != nil && scope.Address(addr) {
+					add(KindAddress, addr, "", num)
+				}
+			}
+		}
+	}
+	return refs, scanner.Err()
+}
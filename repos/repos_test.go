@@ -0,0 +1,179 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package repos
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testScope = &Scope{
+	Name:    func(name string) bool { return strings.HasSuffix(name, "example.com") },
+	Address: func(addr string) bool { return strings.HasPrefix(addr, "192.0.2.") },
+}
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings(map[string]interface{}{
+		"orgs":          []interface{}{"example"},
+		"max_repos":     5,
+		"max_repo_size": 10,
+		"interval":      2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the settings: %v", err)
+	}
+	if len(s.Orgs) != 1 || s.MaxRepos != 5 || s.MaxRepoSize != 10*1024*1024 || s.Interval != 2*time.Second {
+		t.Errorf("Unexpected settings: %+v", s)
+	}
+
+	if s, err := ParseSettings(true); err != nil || s.MaxRepos != DefaultMaxRepos {
+		t.Errorf("Expected the default settings: %+v %v", s, err)
+	}
+	if s, err := ParseSettings(false); err != nil || s != nil {
+		t.Errorf("Expected the scanning to be disabled: %+v %v", s, err)
+	}
+	for _, raw := range []interface{}{
+		"example",
+		map[string]interface{}{"orgs": "example"},
+		map[string]interface{}{"max_repos": 0},
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	file := `resource "aws_s3_bucket" "logs" {
+  bucket = "example-logs"
+}
+backend = "https://api.staging.example.com:8443/v1"
+origin  = "d111111abcdef8.cloudfront.net"
+hosts   = ["192.0.2.10", "203.0.113.5", "db.internal.example.com"]
+policy  = "arn:aws:s3:::example-logs/*"
+mirror  = "https://www.example.org/"
+`
+
+	refs, err := Extract(strings.NewReader(file), "infra/main.tf", testScope)
+	if err != nil {
+		t.Fatalf("Failed to extract the references: %v", err)
+	}
+
+	got := make(map[string]*Reference)
+	for _, r := range refs {
+		got[r.Kind+" "+r.Value] = r
+	}
+	for key, line := range map[string]int{
+		"fqdn api.staging.example.com":  4,
+		"fqdn db.internal.example.com":  6,
+		"ip 192.0.2.10":                 6,
+		"cloud_resource d111111abcdef8": 5,
+		"cloud_resource example-logs":   7,
+	} {
+		if r, found := got[key]; !found || r.Line != line || r.Path != "infra/main.tf" {
+			t.Errorf("%s: expected a reference on line %d, got %+v", key, line, r)
+		}
+	}
+	if len(refs) != 5 {
+		t.Errorf("Expected five references, got %d", len(refs))
+	}
+
+	for p, expected := range map[string]bool{
+		"deploy/values.yaml":             true,
+		"Dockerfile":                     true,
+		".env.production":                true,
+		"src/main.go":                    false,
+		"web/node_modules/pkg/conf.json": false,
+		"package-lock.json":              false,
+	} {
+		if ConfigFile(p) != expected {
+			t.Errorf("%s: expected %t", p, expected)
+		}
+	}
+}
+
+func archive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for name, content := range files {
+		hdr := &tar.Header{
+			Name:     "example-infra-1a2b3c/" + name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	return buf.Bytes()
+}
+
+func TestScan(t *testing.T) {
+	tarball := archive(t, map[string]string{
+		"terraform/dns.tf": "records = [\"vpn.example.com\"]\n",
+		"README.md":        "See docs.example.com\n",
+	})
+
+	var requests []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, time.Now())
+		switch req.URL.Path {
+		case "/orgs/example/repos":
+			fmt.Fprint(w, `[
+				{"full_name": "example/infra", "html_url": "https://github.com/example/infra", "default_branch": "main", "size": 10},
+				{"full_name": "example/fork", "html_url": "https://github.com/example/fork", "default_branch": "main", "size": 10, "fork": true},
+				{"full_name": "example/huge", "html_url": "https://github.com/example/huge", "default_branch": "main", "size": 900000}
+			]`)
+		case "/repos/example/infra/tarball/main":
+			_, _ = w.Write(tarball)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewScanner(&Settings{MaxRepos: 5, MaxRepoSize: 1024 * 1024, Interval: 100 * time.Millisecond}, testScope, "")
+	s.BaseURL = srv.URL
+
+	refs, err := s.Scan(context.Background(), "example")
+	if err != nil {
+		t.Fatalf("Failed to scan the repositories: %v", err)
+	}
+	if len(refs) != 1 || refs[0].Value != "vpn.example.com" || refs[0].Repo != "example/infra" ||
+		refs[0].URL != "https://github.com/example/infra/blob/main/terraform/dns.tf#L1" {
+		t.Errorf("Unexpected references: %+v", refs)
+	}
+	// The forks and the repositories exceeding the size limit are not downloaded. The requests are
+	// received less than the interval apart when the first one waited for the connection to be dialed
+	if len(requests) != 2 || requests[1].Sub(requests[0]) < 90*time.Millisecond {
+		t.Errorf("Unexpected requests: %v", requests)
+	}
+
+	if refs, err := s.Scan(context.Background(), "unknown"); err != nil || len(refs) != 0 {
+		t.Errorf("Expected no references for the unknown organization: %v %v", refs, err)
+	}
+
+	s.Settings.MaxRepoSize = 64
+	repo := &Repo{Name: "example/infra", URL: "https://github.com/example/infra", DefaultBranch: "main"}
+	if _, err := s.ScanRepo(context.Background(), repo); err == nil {
+		t.Error("Expected an error for the archive exceeding the size limit")
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package risk combines the exposure signals observed for each name, such as the open administrative ports and
+// the takeover candidates, into a risk score used to put the riskiest assets first in the reports and digests.
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/views"
+)
+
+// FileName is the name of the file in the output directory that stores the risk scores.
+const FileName = "risk.json"
+
+// MaxScore is the highest risk score of an asset.
+const MaxScore = 100
+
+// Asset is the exposure of a name: the addresses it resolves to, the ports confirmed
+// to serve it and the findings recorded for it.
+type Asset struct {
+	Name      string
+	Addresses []string
+	Ports     []int
+	Findings  []*findings.Finding
+}
+
+// Signal is an exposure signal contributing to the risk score of the assets.
+type Signal interface {
+	// Name identifies the signal in the weights and the factors of the scores
+	Name() string
+	// Evaluate returns the reason the asset presents the signal, or empty when it does not
+	Evaluate(a *Asset, now time.Time) string
+}
+
+// Factor is a signal presented by an asset, and the points it added to the risk score.
+type Factor struct {
+	Signal string `json:"signal"`
+	Points int    `json:"points"`
+	Reason string `json:"reason"`
+}
+
+// Score is the risk score of an asset, and the factors that contributed to it.
+type Score struct {
+	Asset   string    `json:"asset"`
+	Score   int       `json:"score"`
+	Factors []*Factor `json:"factors"`
+}
+
+// Scores are the risk scores of the assets presenting at least one signal, with the highest scores first.
+type Scores struct {
+	Generated time.Time `json:"generated"`
+	Assets    []*Score  `json:"assets"`
+	byName    map[string]int
+}
+
+// Of returns the risk score of the named asset, or zero when the asset presented no signals.
+func (s *Scores) Of(name string) int {
+	if s == nil {
+		return 0
+	}
+	if s.byName == nil {
+		s.byName = make(map[string]int, len(s.Assets))
+		for _, a := range s.Assets {
+			s.byName[a.Asset] = a.Score
+		}
+	}
+	return s.byName[strings.ToLower(name)]
+}
+
+// OrderRecords sorts the asset records by the risk score of the asset, with the highest scores
+// first, while keeping the existing order of the records with the same score.
+func (s *Scores) OrderRecords(recs []*format.AssetRecord) {
+	if s == nil || len(s.Assets) == 0 {
+		return
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool {
+		return s.Of(recs[i].Key) > s.Of(recs[j].Key)
+	})
+}
+
+// Scorer weights the signals presented by the assets.
+type Scorer struct {
+	Signals []Signal
+	// Weights are the points added by each signal, keyed by the signal name
+	Weights map[string]int
+}
+
+// NewScorer returns a Scorer evaluating the DefaultSignals with the DefaultWeights.
+func NewScorer() *Scorer {
+	weights := make(map[string]int, len(DefaultWeights))
+	for k, v := range DefaultWeights {
+		weights[k] = v
+	}
+	return &Scorer{Signals: DefaultSignals(), Weights: weights}
+}
+
+// ParseScorer returns the Scorer provided by the risk option, which is either true to enable the risk scores
+// with the default weights, or provides the weights of the signals. Nil is returned when the option is not enabled.
+func ParseScorer(raw interface{}) (*Scorer, error) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if !v {
+			return nil, nil
+		}
+		return NewScorer(), nil
+	case map[string]interface{}:
+		s := NewScorer()
+
+		weights, ok := v["weights"].(map[string]interface{})
+		if !ok && v["weights"] != nil {
+			return nil, fmt.Errorf("the risk weights must map the signal names to points")
+		}
+		for name, w := range weights {
+			if _, found := s.Weights[name]; !found {
+				return nil, fmt.Errorf("%s is not a risk signal", name)
+			}
+
+			points, ok := w.(int)
+			if !ok || points < 0 || points > MaxScore {
+				return nil, fmt.Errorf("the weight of the %s risk signal must be between 0 and %d", name, MaxScore)
+			}
+			s.Weights[name] = points
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("the risk option must be true or provide the weights of the signals")
+}
+
+// Score returns the risk score of the asset, which is the sum of the weights of the signals
+// it presents, up to MaxScore.
+func (s *Scorer) Score(a *Asset, now time.Time) *Score {
+	score := &Score{Asset: a.Name}
+
+	for _, sig := range s.Signals {
+		points := s.Weights[sig.Name()]
+		if points == 0 {
+			continue
+		}
+
+		if reason := sig.Evaluate(a, now); reason != "" {
+			score.Factors = append(score.Factors, &Factor{Signal: sig.Name(), Points: points, Reason: reason})
+			score.Score += points
+		}
+	}
+	if score.Score > MaxScore {
+		score.Score = MaxScore
+	}
+	return score
+}
+
+// Build returns the risk scores of the names in the views and the findings, as of the provided time.
+func (s *Scorer) Build(v *views.Views, fs []*findings.Finding, now time.Time) *Scores {
+	assets := Assets(v, fs)
+
+	scores := &Scores{Generated: now}
+	for _, a := range assets {
+		if score := s.Score(a, now); score.Score > 0 {
+			scores.Assets = append(scores.Assets, score)
+		}
+	}
+
+	sort.SliceStable(scores.Assets, func(i, j int) bool {
+		if scores.Assets[i].Score != scores.Assets[j].Score {
+			return scores.Assets[i].Score > scores.Assets[j].Score
+		}
+		return scores.Assets[i].Asset < scores.Assets[j].Asset
+	})
+	return scores
+}
+
+// Assets returns the exposure of the names resolved in the views, served by the services
+// in the views, or named by the findings.
+func Assets(v *views.Views, fs []*findings.Finding) []*Asset {
+	byName := make(map[string]*Asset)
+	get := func(name string) *Asset {
+		name = strings.ToLower(name)
+		a, found := byName[name]
+		if !found {
+			a = &Asset{Name: name}
+			byName[name] = a
+		}
+		return a
+	}
+
+	if v != nil {
+		for _, r := range v.Resolutions {
+			get(r.Name).Addresses = r.Addresses
+		}
+		for _, svc := range v.Services {
+			for _, name := range svc.Names {
+				a := get(name)
+				if !containsInt(a.Ports, svc.Port) {
+					a.Ports = append(a.Ports, svc.Port)
+				}
+			}
+		}
+	}
+	for _, f := range fs {
+		if f.Asset != "" {
+			a := get(f.Asset)
+			a.Findings = append(a.Findings, f)
+		}
+	}
+
+	results := make([]*Asset, 0, len(byName))
+	for _, a := range byName {
+		sort.Ints(a.Ports)
+		results = append(results, a)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+func containsInt(list []int, n int) bool {
+	for _, item := range list {
+		if item == n {
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes the scores to the file at the provided path, replacing the previous scores
+// only once the file is complete, so the reports never read partial scores.
+func Save(path string, s *Scores) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), FileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(s); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads the scores from the file at the provided path.
+func Load(path string) (*Scores, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Scores
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse the risk scores: %v", err)
+	}
+	return &s, nil
+}
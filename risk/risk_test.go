@@ -0,0 +1,109 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package risk
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/views"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Now()
+	v := &views.Views{
+		Resolutions: []*views.Resolution{
+			{Name: "www.owasp.org", Addresses: []string{"192.0.2.1"}},
+			{Name: "jenkins.dev.owasp.org", Addresses: []string{"192.0.2.2"}},
+			{Name: "shop.owasp.org", Addresses: []string{"192.0.2.3"}},
+		},
+		Services: []*views.Service{
+			{Address: "192.0.2.1", Port: 443, Names: []string{"www.owasp.org"}},
+			{Address: "192.0.2.2", Port: 22, Names: []string{"jenkins.dev.owasp.org"}},
+		},
+	}
+	fs := []*findings.Finding{
+		{Asset: "shop.owasp.org", Type: TypeSubdomainTakeover, Attributes: map[string]string{"service": "github"}},
+		{Asset: "*.owasp.org", Type: findings.TypeWildcardCertificate,
+			Attributes: map[string]string{"not_after": now.Add(-24 * time.Hour).UTC().Format(time.RFC3339)}},
+	}
+
+	scores := NewScorer().Build(v, fs, now)
+	expected := []struct {
+		asset   string
+		score   int
+		factors int
+	}{
+		{"jenkins.dev.owasp.org", 55, 3},
+		{"shop.owasp.org", 50, 1},
+		{"*.owasp.org", 20, 1},
+	}
+	if len(scores.Assets) != len(expected) {
+		t.Fatalf("Expected %d scored assets, got %d", len(expected), len(scores.Assets))
+	}
+	for i, e := range expected {
+		if s := scores.Assets[i]; s.Asset != e.asset || s.Score != e.score || len(s.Factors) != e.factors {
+			t.Errorf("Expected %s to score %d from %d factors, got %+v", e.asset, e.score, e.factors, s)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := Save(path, scores); err != nil {
+		t.Fatalf("Failed to save the scores: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load the scores: %v", err)
+	}
+	if loaded.Of("SHOP.owasp.org") != 50 || loaded.Of("www.owasp.org") != 0 {
+		t.Errorf("Unexpected scores of the loaded assets: %+v", loaded.Assets)
+	}
+
+	recs := []*format.AssetRecord{
+		{Type: "FQDN", Key: "api.owasp.org"},
+		{Type: "FQDN", Key: "shop.owasp.org"},
+		{Type: "FQDN", Key: "jenkins.dev.owasp.org"},
+	}
+	loaded.OrderRecords(recs)
+	if recs[0].Key != "jenkins.dev.owasp.org" || recs[1].Key != "shop.owasp.org" || recs[2].Key != "api.owasp.org" {
+		t.Errorf("Unexpected order of the records: %s, %s, %s", recs[0].Key, recs[1].Key, recs[2].Key)
+	}
+}
+
+func TestParseScorer(t *testing.T) {
+	if s, err := ParseScorer(nil); s != nil || err != nil {
+		t.Errorf("Expected the risk scores to be disabled without the option: %v %v", s, err)
+	}
+	if s, err := ParseScorer(true); s == nil || err != nil || s.Weights[SignalTakeover] != DefaultWeights[SignalTakeover] {
+		t.Errorf("Expected the default weights: %v %v", s, err)
+	}
+
+	s, err := ParseScorer(map[string]interface{}{
+		"weights": map[string]interface{}{SignalAdminPorts: 60, SignalNonProdName: 0},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the weights: %v", err)
+	}
+	if s.Weights[SignalAdminPorts] != 60 || s.Weights[SignalNonProdName] != 0 {
+		t.Errorf("Unexpected weights: %v", s.Weights)
+	}
+	if score := s.Score(&Asset{Name: "dev.owasp.org"}, time.Now()); score.Score != 0 {
+		t.Errorf("Expected the signal with no weight to be ignored, got %+v", score)
+	}
+
+	for _, raw := range []interface{}{
+		"yes",
+		map[string]interface{}{"weights": []interface{}{"admin_ports"}},
+		map[string]interface{}{"weights": map[string]interface{}{"open_ports": 10}},
+		map[string]interface{}{"weights": map[string]interface{}{SignalAdminPorts: 101}},
+	} {
+		if _, err := ParseScorer(raw); err == nil {
+			t.Errorf("Expected an error for the option %v", raw)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package risk
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/classify"
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+// The names of the default signals.
+const (
+	SignalTakeover    = "takeover_candidate"
+	SignalAdminPorts  = "admin_ports"
+	SignalExpiredCert = "expired_certificate"
+	SignalAdminName   = "admin_interface"
+	SignalNonProdName = "non_production"
+)
+
+// TypeSubdomainTakeover is the type of the findings for the names that can be taken over.
+const TypeSubdomainTakeover = "subdomain_takeover"
+
+// DefaultWeights are the points added by each of the default signals.
+var DefaultWeights = map[string]int{
+	SignalTakeover:    50,
+	SignalAdminPorts:  30,
+	SignalExpiredCert: 20,
+	SignalAdminName:   15,
+	SignalNonProdName: 10,
+}
+
+// AdminPorts are the ports of the remote administration, database and orchestration services
+// that should rarely be reachable from the Internet.
+var AdminPorts = map[int]string{
+	22:    "ssh",
+	23:    "telnet",
+	445:   "smb",
+	1433:  "mssql",
+	2375:  "docker",
+	3306:  "mysql",
+	3389:  "rdp",
+	5432:  "postgres",
+	5900:  "vnc",
+	5985:  "winrm",
+	6379:  "redis",
+	6443:  "kubernetes",
+	9200:  "elasticsearch",
+	10250: "kubelet",
+	27017: "mongodb",
+}
+
+// DefaultSignals returns the signals evaluated by the risk scores.
+func DefaultSignals() []Signal {
+	return []Signal{
+		takeoverSignal{},
+		adminPortsSignal{},
+		expiredCertSignal{},
+		adminNameSignal{},
+		nonProdNameSignal{},
+	}
+}
+
+type takeoverSignal struct{}
+
+func (takeoverSignal) Name() string { return SignalTakeover }
+
+// Evaluate returns the service of the takeover findings, since the dangling records can be claimed by anyone.
+func (takeoverSignal) Evaluate(a *Asset, now time.Time) string {
+	for _, f := range a.Findings {
+		if f.Type == TypeSubdomainTakeover {
+			if svc := f.Attributes["service"]; svc != "" {
+				return "dangling record for " + svc
+			}
+			return "dangling record"
+		}
+	}
+	return ""
+}
+
+type adminPortsSignal struct{}
+
+func (adminPortsSignal) Name() string { return SignalAdminPorts }
+
+// Evaluate returns the administrative services among the ports confirmed to serve the name.
+func (adminPortsSignal) Evaluate(a *Asset, now time.Time) string {
+	var open []string
+	for _, port := range a.Ports {
+		if svc, found := AdminPorts[port]; found {
+			open = append(open, svc+"/"+strconv.Itoa(port))
+		}
+	}
+	if len(open) == 0 {
+		return ""
+	}
+	return "open " + strings.Join(open, ", ")
+}
+
+type expiredCertSignal struct{}
+
+func (expiredCertSignal) Name() string { return SignalExpiredCert }
+
+// Evaluate returns the expiration of the certificates served for the name that are no longer valid.
+func (expiredCertSignal) Evaluate(a *Asset, now time.Time) string {
+	var expired []time.Time
+	for _, f := range a.Findings {
+		if f.Type != findings.TypeWildcardCertificate {
+			continue
+		}
+
+		if t, err := time.Parse(time.RFC3339, f.Attributes["not_after"]); err == nil && t.Before(now) {
+			expired = append(expired, t)
+		}
+	}
+	if len(expired) == 0 {
+		return ""
+	}
+
+	sort.Slice(expired, func(i, j int) bool { return expired[i].After(expired[j]) })
+	return fmt.Sprintf("certificate expired %s", expired[0].Format("2006-01-02"))
+}
+
+type adminNameSignal struct{}
+
+func (adminNameSignal) Name() string { return SignalAdminName }
+
+// Evaluate returns the labels of the name suggesting an administrative interface.
+func (adminNameSignal) Evaluate(a *Asset, now time.Time) string {
+	for _, tag := range classify.Name(a.Name) {
+		if tag == classify.TagAdmin {
+			return "administrative naming"
+		}
+	}
+	return ""
+}
+
+type nonProdNameSignal struct{}
+
+func (nonProdNameSignal) Name() string { return SignalNonProdName }
+
+// Evaluate returns the environment of the names suggesting a development or staging host,
+// which are commonly less hardened than the production hosts.
+func (nonProdNameSignal) Evaluate(a *Asset, now time.Time) string {
+	for _, tag := range classify.Name(a.Name) {
+		if tag == classify.TagDev || tag == classify.TagStaging {
+			return tag + " naming"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,239 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rules evaluates the transforms declared by users in YAML, such as enqueuing additional names
+// when a name matching a pattern is discovered, or discarding the names provided by a data source.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The types of the assets the rules are evaluated against.
+const (
+	TypeFQDN = "fqdn"
+	TypeIP   = "ip"
+)
+
+// The actions taken when a rule matches an event.
+const (
+	// ActionDrop discards the asset, so it is not expanded by the enumeration
+	ActionDrop = "drop"
+	// ActionEnqueue submits the names built from the templates of the rule
+	ActionEnqueue = "enqueue"
+)
+
+// SourceRules is the source of the events for the names enqueued by the rules, which are only
+// evaluated against the drop rules, so the rules cannot generate names indefinitely.
+const SourceRules = "rules"
+
+// Rule is a transform declared by the user.
+type Rule struct {
+	Name string `yaml:"name"`
+	// Type is the type of asset matched by the rule, which is fqdn when not provided
+	Type string `yaml:"type,omitempty"`
+	// Pattern is the regular expression matched against the name or address
+	Pattern string `yaml:"pattern,omitempty"`
+	// CIDR is the netblock containing the addresses matched by the rule
+	CIDR string `yaml:"cidr,omitempty"`
+	// Source is the name of the data source that provided the asset, case-insensitive
+	Source string `yaml:"source,omitempty"`
+	Action string `yaml:"action"`
+	// Names are the templates of the names enqueued, where {name} is replaced with the matched name,
+	// {domain} with its root domain, {label} with its first label and {parent} with the rest of the name
+	Names  []string `yaml:"names,omitempty"`
+	re     *regexp.Regexp
+	prefix netip.Prefix
+}
+
+// Event is an asset discovered during the enumeration.
+type Event struct {
+	Type   string
+	Value  string
+	Domain string
+	Source string
+}
+
+// Result is the outcome of evaluating the rules against an event.
+type Result struct {
+	// Drop is true when the asset must be discarded
+	Drop bool
+	// Rule is the name of the rule that discarded the asset
+	Rule string
+	// Names are the names to be enqueued
+	Names []string
+}
+
+// Engine evaluates the rules in the order they were declared.
+type Engine struct {
+	rules []*Rule
+}
+
+// Parse returns the Engine for the rules option, which is either the list of rules
+// or the path to the YAML file providing them.
+func Parse(raw interface{}) (*Engine, error) {
+	if path, ok := raw.(string); ok {
+		return Load(path)
+	}
+
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the rules: %v", err)
+	}
+	return parse(data)
+}
+
+// Load returns the Engine for the rules provided by the YAML file.
+func Load(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the rules file: %v", err)
+	}
+	return parse(data)
+}
+
+func parse(data []byte) (*Engine, error) {
+	var list []*Rule
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse the rules: %v", err)
+	}
+
+	e := &Engine{}
+	for i, r := range list {
+		if r == nil {
+			continue
+		}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("%s: %v", r.Name, err)
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e, nil
+}
+
+func (r *Rule) compile() error {
+	r.Type = strings.ToLower(strings.TrimSpace(r.Type))
+	if r.Type == "" {
+		r.Type = TypeFQDN
+	}
+	if r.Type != TypeFQDN && r.Type != TypeIP {
+		return fmt.Errorf("%s is not a supported asset type", r.Type)
+	}
+
+	if r.Pattern != "" {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+		r.re = re
+	}
+	if r.CIDR != "" {
+		if r.Type != TypeIP {
+			return errors.New("the cidr can only be matched against addresses")
+		}
+
+		prefix, err := netip.ParsePrefix(r.CIDR)
+		if err != nil {
+			return fmt.Errorf("invalid cidr: %v", err)
+		}
+		r.prefix = prefix.Masked()
+	}
+
+	r.Action = strings.ToLower(strings.TrimSpace(r.Action))
+	switch r.Action {
+	case ActionDrop:
+	case ActionEnqueue:
+		if r.Type != TypeFQDN {
+			return errors.New("names can only be enqueued for the matched names")
+		}
+		if len(r.Names) == 0 {
+			return errors.New("the enqueue action requires the names to be provided")
+		}
+	default:
+		return fmt.Errorf("%s is not a supported action", r.Action)
+	}
+	return nil
+}
+
+// Len returns the number of rules in the Engine.
+func (e *Engine) Len() int {
+	if e == nil {
+		return 0
+	}
+	return len(e.rules)
+}
+
+// Evaluate returns the outcome of the rules matching the event. The first matching drop rule discards
+// the asset, and the names of all the matching enqueue rules are returned otherwise.
+func (e *Engine) Evaluate(ev *Event) *Result {
+	res := &Result{}
+	if e == nil || ev == nil {
+		return res
+	}
+
+	for _, r := range e.rules {
+		if !r.matches(ev) {
+			continue
+		}
+
+		switch r.Action {
+		case ActionDrop:
+			return &Result{Drop: true, Rule: r.Name}
+		case ActionEnqueue:
+			if ev.Source == SourceRules {
+				continue
+			}
+			for _, t := range r.Names {
+				if n := expand(t, ev); n != "" && n != ev.Value {
+					res.Names = append(res.Names, n)
+				}
+			}
+		}
+	}
+	return res
+}
+
+func (r *Rule) matches(ev *Event) bool {
+	if r.Type != ev.Type {
+		return false
+	}
+	if r.Source != "" && !strings.EqualFold(r.Source, ev.Source) {
+		return false
+	}
+	if r.re != nil && !r.re.MatchString(ev.Value) {
+		return false
+	}
+	if r.prefix.IsValid() {
+		addr, err := netip.ParseAddr(ev.Value)
+		if err != nil || !r.prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func expand(t string, ev *Event) string {
+	label, parent := ev.Value, ""
+	if i := strings.Index(ev.Value, "."); i != -1 {
+		label, parent = ev.Value[:i], ev.Value[i+1:]
+	}
+
+	name := strings.NewReplacer(
+		"{name}", ev.Value,
+		"{domain}", ev.Domain,
+		"{label}", label,
+		"{parent}", parent,
+	).Replace(t)
+	return strings.ToLower(strings.Trim(strings.TrimSpace(name), "."))
+}
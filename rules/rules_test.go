@@ -0,0 +1,100 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testRules = `
+- name: staging
+  pattern: '^stg-'
+  action: enqueue
+  names:
+    - '{name}'
+    - 'admin.{name}'
+    - 'prod-{label}.{parent}'
+- name: noisy source
+  source: NoisySource
+  action: drop
+- type: ip
+  cidr: 192.0.2.0/24
+  action: drop
+`
+
+func TestEvaluate(t *testing.T) {
+	var raw interface{}
+	// The rules option is provided as the data decoded from the configuration file
+	if err := yaml.Unmarshal([]byte(testRules), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Failed to parse the rules: %v", err)
+	}
+	if e.Len() != 3 {
+		t.Fatalf("Expected three rules, got %d", e.Len())
+	}
+
+	res := e.Evaluate(&Event{Type: TypeFQDN, Value: "stg-api.owasp.org", Domain: "owasp.org", Source: "crtsh"})
+	if res.Drop || len(res.Names) != 2 {
+		t.Fatalf("Unexpected result: %+v", res)
+	}
+	if res.Names[0] != "admin.stg-api.owasp.org" || res.Names[1] != "prod-stg-api.owasp.org" {
+		t.Errorf("Unexpected names: %v", res.Names)
+	}
+	// The names enqueued by the rules do not enqueue more names
+	if res := e.Evaluate(&Event{Type: TypeFQDN, Value: "stg-web.owasp.org", Source: SourceRules}); len(res.Names) != 0 {
+		t.Errorf("Expected no names for the names enqueued by the rules, got %v", res.Names)
+	}
+
+	if res := e.Evaluate(&Event{Type: TypeFQDN, Value: "stg-api.owasp.org", Source: "noisysource"}); !res.Drop || res.Rule != "noisy source" {
+		t.Errorf("Expected the name from the noisy source to be dropped: %+v", res)
+	}
+	if res := e.Evaluate(&Event{Type: TypeIP, Value: "192.0.2.10"}); !res.Drop || res.Rule != "rule 3" {
+		t.Errorf("Expected the address to be dropped: %+v", res)
+	}
+	if res := e.Evaluate(&Event{Type: TypeIP, Value: "198.51.100.1"}); res.Drop {
+		t.Error("Expected the address outside of the netblock to be kept")
+	}
+
+	var none *Engine
+	if res := none.Evaluate(&Event{Type: TypeFQDN, Value: "www.owasp.org"}); res.Drop || len(res.Names) != 0 {
+		t.Errorf("Expected no outcome without rules: %+v", res)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, rules := range []string{
+		"- action: explode",
+		"- type: url\n  action: drop",
+		"- pattern: '('\n  action: drop",
+		"- cidr: 192.0.2.0/24\n  action: drop",
+		"- type: ip\n  action: enqueue\n  names: ['{name}']",
+		"- action: enqueue",
+	} {
+		if _, err := Load(writeRules(t, rules)); err == nil {
+			t.Errorf("Expected an error for the rules: %s", rules)
+		}
+	}
+
+	if _, err := Parse(writeRules(t, testRules)); err != nil {
+		t.Errorf("Failed to load the rules file: %v", err)
+	}
+}
+
+func writeRules(t *testing.T, rules string) string {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+
+	if err := os.WriteFile(path, []byte(rules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,243 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package saas discovers the tenants of common SaaS platforms, and the organizations on the code
+// package and container registries, that are named after the target organization. This extends
+// the attack surface beyond the DNS it controls, and provides software supply chain visibility.
+package saas
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	requestTimeout = 15 * time.Second
+	maxBodySize    = 64 * 1024
+)
+
+// Platform describes how the tenants of a SaaS platform are addressed and how the
+// platform responds when the tenant does not exist.
+type Platform struct {
+	Name string
+	// URL is the address of the tenant, with %s replaced by the tenant name
+	URL string
+	// Absent are the phrases in the response body that indicate the tenant does not exist
+	Absent []string
+	// Require are the phrases, one of which must be in the response body to confirm the tenant
+	Require []string
+	// Page is the address of the tenant shown to users when it differs from URL
+	Page string
+	// Registry is true for the code package and container registries, where the tenant is an
+	// organization or namespace on a shared host instead of a host of its own
+	Registry bool
+}
+
+// Platforms are the SaaS platforms checked for tenants by default.
+var Platforms = []*Platform{
+	{
+		Name:   "Atlassian",
+		URL:    "https://%s.atlassian.net/",
+		Absent: []string{"site is currently unavailable", "site doesn't exist"},
+	},
+	{
+		Name:   "Slack",
+		URL:    "https://%s.slack.com/",
+		Absent: []string{"workspace doesn't exist", "no longer exists"},
+	},
+	{
+		Name:   "Okta",
+		URL:    "https://%s.okta.com/",
+		Absent: []string{"organization does not exist", "org does not exist"},
+	},
+	{
+		Name: "SharePoint",
+		URL:  "https://%s.sharepoint.com/",
+	},
+	{
+		Name:   "Zendesk",
+		URL:    "https://%s.zendesk.com/",
+		Absent: []string{"help center closed", "no longer exists"},
+	},
+}
+
+// Registries are the code package and container registries checked for the artifacts
+// published under the names of the organization.
+var Registries = []*Platform{
+	{
+		Name:     "npm",
+		URL:      "https://registry.npmjs.org/-/v1/search?text=scope:%s&size=1",
+		Require:  []string{`"package":`},
+		Page:     "https://www.npmjs.com/org/%s",
+		Registry: true,
+	},
+	{
+		Name:     "PyPI",
+		URL:      "https://pypi.org/pypi/%s/json",
+		Require:  []string{`"info":`},
+		Page:     "https://pypi.org/project/%s/",
+		Registry: true,
+	},
+	{
+		Name:     "Docker Hub",
+		URL:      "https://hub.docker.com/v2/users/%s/",
+		Require:  []string{`"username":`},
+		Page:     "https://hub.docker.com/u/%s",
+		Registry: true,
+	},
+	{
+		Name:     "GitHub",
+		URL:      "https://api.github.com/orgs/%s",
+		Require:  []string{`"login":`},
+		Page:     "https://github.com/%s",
+		Registry: true,
+	},
+}
+
+// Tenant is a confirmed tenant of a SaaS platform.
+type Tenant struct {
+	Platform string
+	Name     string
+	Host     string
+	URL      string
+	Status   int
+	Registry bool
+}
+
+// Checker confirms the existence of tenants on the SaaS platforms.
+type Checker struct {
+	Platforms []*Platform
+	HTTP      *http.Client
+}
+
+// NewChecker returns a Checker for the default Platforms and Registries.
+func NewChecker() *Checker {
+	return &Checker{
+		Platforms: append(append([]*Platform{}, Platforms...), Registries...),
+		HTTP: amasshttp.NewClient("saas", &amasshttp.ClientOptions{
+			Timeout:  requestTimeout,
+			Insecure: true,
+			// The redirects are examined, since platforms commonly redirect away from absent tenants
+			NoRedirects: true,
+		}),
+	}
+}
+
+// TenantNames returns the tenant names likely to be used by the organization owning the domain,
+// such as "owasp" for owasp.org, and "acmecorp" as well as "acme-corp" for acme-corp.co.uk.
+func TenantNames(domain string) []string {
+	domain = strings.ToLower(strings.Trim(domain, "."))
+
+	etld1, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return nil
+	}
+	suffix, _ := publicsuffix.PublicSuffix(etld1)
+
+	label := strings.TrimSuffix(strings.TrimSuffix(etld1, suffix), ".")
+	if label == "" {
+		return nil
+	}
+
+	names := []string{label}
+	if compact := strings.ReplaceAll(label, "-", ""); compact != label {
+		names = append(names, compact)
+	}
+	return names
+}
+
+// Discover returns the confirmed tenants named after the organization owning the domain.
+func (c *Checker) Discover(ctx context.Context, domain string) []*Tenant {
+	var tenants []*Tenant
+
+	for _, name := range TenantNames(domain) {
+		for _, p := range c.Platforms {
+			select {
+			case <-ctx.Done():
+				return tenants
+			default:
+			}
+
+			if t, err := c.Check(ctx, p, name); err == nil && t != nil {
+				tenants = append(tenants, t)
+			}
+		}
+	}
+	return tenants
+}
+
+// Check returns the Tenant when the named tenant exists on the platform, and nil when it does not.
+func (c *Checker) Check(ctx context.Context, p *Platform, name string) (*Tenant, error) {
+	u := fmt.Sprintf(p.URL, name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Amass)")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		// The tenant names of some platforms do not resolve when the tenant does not exist
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !present(req.URL, resp, p.Absent, p.Require) {
+		return nil, nil
+	}
+	if p.Page != "" {
+		u = fmt.Sprintf(p.Page, name)
+	}
+	return &Tenant{
+		Platform: p.Name,
+		Name:     name,
+		Host:     req.URL.Hostname(),
+		URL:      u,
+		Status:   resp.StatusCode,
+		Registry: p.Registry,
+	}, nil
+}
+
+func present(u *url.URL, resp *http.Response, absent, require []string) bool {
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return false
+	case resp.StatusCode >= 500:
+		return false
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		// Redirects that no longer reference the tenant, such as to the platform
+		// home page, indicate that the platform did not recognize the tenant
+		loc, err := resp.Location()
+		if err != nil || !strings.Contains(strings.ToLower(loc.String()), strings.ToLower(u.Hostname())) {
+			return false
+		}
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	lower := strings.ToLower(string(body))
+	for _, phrase := range absent {
+		if strings.Contains(lower, phrase) {
+			return false
+		}
+	}
+	if len(require) == 0 {
+		return true
+	}
+	// Responses such as rate limiting errors do not include the required phrases
+	for _, phrase := range require {
+		if strings.Contains(lower, strings.ToLower(phrase)) {
+			return true
+		}
+	}
+	return false
+}
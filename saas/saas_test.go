@@ -0,0 +1,83 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package saas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestTenantNames(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected []string
+	}{
+		{"owasp.org", []string{"owasp"}},
+		{"www.owasp.org.", []string{"owasp"}},
+		{"acme-corp.co.uk", []string{"acme-corp", "acmecorp"}},
+		{"co.uk", nil},
+	}
+
+	for _, test := range tests {
+		if got := TenantNames(test.domain); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.domain, test.expected, got)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/wiki/owasp/":
+			fmt.Fprint(w, "<html>Log in to continue</html>")
+		case "/chat/owasp/":
+			http.Redirect(w, req, "https://chat.example.com/get-started", http.StatusFound)
+		case "/sso/owasp/":
+			http.Redirect(w, req, "/sso/owasp/login", http.StatusFound)
+		case "/help/owasp/":
+			fmt.Fprint(w, "<html>Oops! Help Center Closed</html>")
+		case "/orgs/owasp":
+			fmt.Fprint(w, `{"login": "owasp", "type": "Organization"}`)
+		case "/users/owasp/":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "API rate limit exceeded"}`)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewChecker()
+	c.Platforms = []*Platform{
+		{Name: "Wiki", URL: srv.URL + "/wiki/%s/"},
+		{Name: "Chat", URL: srv.URL + "/chat/%s/"},
+		{Name: "SSO", URL: srv.URL + "/sso/%s/"},
+		{Name: "Help", URL: srv.URL + "/help/%s/", Absent: []string{"help center closed"}},
+		{Name: "Tickets", URL: srv.URL + "/tickets/%s/"},
+		{Name: "Code", URL: srv.URL + "/orgs/%s", Require: []string{`"login":`}, Page: "https://code.example.com/%s", Registry: true},
+		{Name: "Images", URL: srv.URL + "/users/%s/", Require: []string{`"username":`}, Registry: true},
+	}
+
+	tenants := c.Discover(context.Background(), "owasp.org")
+	if len(tenants) != 3 {
+		t.Fatalf("Expected three tenants, got %d", len(tenants))
+	}
+	if tenants[0].Platform != "Wiki" || tenants[1].Platform != "SSO" || tenants[2].Platform != "Code" {
+		t.Errorf("Unexpected tenants: %s, %s and %s", tenants[0].Platform, tenants[1].Platform, tenants[2].Platform)
+	}
+	if !tenants[2].Registry || tenants[2].URL != "https://code.example.com/owasp" {
+		t.Errorf("Unexpected registry details: %+v", tenants[2])
+	}
+
+	u, _ := url.Parse(srv.URL)
+	if tenants[0].Host != u.Hostname() || tenants[0].Name != "owasp" || tenants[0].Status != http.StatusOK {
+		t.Errorf("Unexpected tenant details: %+v", tenants[0])
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package session defines the versioned format of the files that persist the long-running sessions in the
+// output directory, such as the positions reached in the Certificate Transparency logs and the progress of
+// the monitoring cycles. Each file is an envelope identifying the kind and version of the data it holds, so
+// an upgraded engine migrates the files written by the previous versions and resumes the sessions, and
+// refuses the files written by newer versions instead of overwriting them.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/format"
+)
+
+// Format is the value of the format field identifying the envelopes.
+const Format = "amass-session"
+
+// Envelope is the content of a file persisting a session.
+type Envelope struct {
+	Format string `json:"format"`
+	// Kind identifies the data held by the file, such as ct_logs or monitor
+	Kind string `json:"kind"`
+	// Version is the version of the data, which is incremented when its layout changes
+	Version int `json:"version"`
+	// Engine is the version of the engine that wrote the file
+	Engine  string          `json:"engine,omitempty"`
+	Written time.Time       `json:"written"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Migration converts the data of a version to the layout of the next version.
+type Migration func(data json.RawMessage) (json.RawMessage, error)
+
+// Kind is a kind of data persisted in the envelopes. The files written before the envelopes were introduced
+// only hold the data, and are read as version 0, which has the same layout as version 1.
+type Kind struct {
+	Name string
+	// Version is the current version of the data
+	Version int
+	// Migrations are keyed by the version they convert the data from
+	Migrations map[int]Migration
+}
+
+// Read decodes the data stored in the file into v, migrating it to the current version. It returns
+// false when the file does not exist, and leaves v untouched.
+func (k *Kind) Read(path string, v interface{}) (bool, error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := k.Decode(raw, v); err != nil {
+		return false, fmt.Errorf("failed to read the %s file %s: %v", k.Name, path, err)
+	}
+	return true, nil
+}
+
+// Decode decodes the content of a file into v, migrating the data to the current version.
+func (k *Kind) Decode(raw []byte, v interface{}) error {
+	data, version, err := k.unwrap(raw)
+	if err != nil {
+		return err
+	}
+	if version > k.Version {
+		return fmt.Errorf("version %d was written by a newer engine, which this engine (version %d) cannot read", version, k.Version)
+	}
+
+	if version == 0 {
+		version = 1
+	}
+	for ; version < k.Version; version++ {
+		m, found := k.Migrations[version]
+		if !found {
+			return fmt.Errorf("no migration is available from version %d", version)
+		}
+		if data, err = m(data); err != nil {
+			return fmt.Errorf("failed to migrate from version %d: %v", version, err)
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (k *Kind) unwrap(raw []byte) (json.RawMessage, int, error) {
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil || env.Format != Format {
+		// The files written before the envelopes were introduced
+		if !json.Valid(raw) {
+			return nil, 0, fmt.Errorf("the content is not valid JSON")
+		}
+		return raw, 0, nil
+	}
+
+	if env.Kind != k.Name {
+		return nil, 0, fmt.Errorf("the file holds %s data", env.Kind)
+	}
+	if env.Version < 1 {
+		return nil, 0, fmt.Errorf("%d is not a valid version", env.Version)
+	}
+	return env.Data, env.Version, nil
+}
+
+// Write stores v in the file, using the envelope of the current version.
+func (k *Kind) Write(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(&Envelope{
+		Format:  Format,
+		Kind:    k.Name,
+		Version: k.Version,
+		Engine:  format.Version,
+		Written: time.Now().UTC(),
+		Data:    data,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
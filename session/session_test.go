@@ -0,0 +1,96 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type counters map[string]int
+
+func TestReadWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+	k := &Kind{Name: "counters", Version: 1}
+
+	var c counters
+	if found, err := k.Read(path, &c); found || err != nil {
+		t.Fatalf("Expected no data for the missing file, got %v %v", found, err)
+	}
+
+	if err := k.Write(path, counters{"a": 1}); err != nil {
+		t.Fatalf("Failed to write the file: %v", err)
+	}
+	if found, err := k.Read(path, &c); !found || err != nil || c["a"] != 1 {
+		t.Fatalf("Failed to read the file: %v %v", c, err)
+	}
+
+	var env Envelope
+	raw, _ := os.ReadFile(path)
+	if err := json.Unmarshal(raw, &env); err != nil || env.Format != Format || env.Kind != "counters" || env.Version != 1 || env.Engine == "" {
+		t.Errorf("Unexpected envelope: %+v", env)
+	}
+
+	other := &Kind{Name: "other", Version: 1}
+	if _, err := other.Read(path, &c); err == nil {
+		t.Error("Expected an error for the data of another kind")
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	// Version 2 of the counters stores the values under a field
+	type countersV2 struct {
+		Values counters `json:"values"`
+	}
+	k := &Kind{
+		Name:    "counters",
+		Version: 2,
+		Migrations: map[int]Migration{
+			1: func(data json.RawMessage) (json.RawMessage, error) {
+				var c counters
+				if err := json.Unmarshal(data, &c); err != nil {
+					return nil, err
+				}
+				return json.Marshal(&countersV2{Values: c})
+			},
+		},
+	}
+
+	// The files written before the envelopes were introduced
+	var c countersV2
+	if err := k.Decode([]byte(`{"a": 1}`), &c); err != nil || c.Values["a"] != 1 {
+		t.Fatalf("Failed to migrate the file without an envelope: %v %v", c, err)
+	}
+
+	dir := t.TempDir()
+	v1 := &Kind{Name: "counters", Version: 1}
+	path := filepath.Join(dir, "counters.json")
+	if err := v1.Write(path, counters{"b": 2}); err != nil {
+		t.Fatalf("Failed to write the file: %v", err)
+	}
+	if found, err := k.Read(path, &c); !found || err != nil || c.Values["b"] != 2 {
+		t.Fatalf("Failed to migrate version 1: %v %v", c, err)
+	}
+
+	// The older engine refuses the files written by the newer engine
+	if err := k.Write(path, &c); err != nil {
+		t.Fatalf("Failed to write the file: %v", err)
+	}
+	var old counters
+	if _, err := v1.Read(path, &old); err == nil || !strings.Contains(err.Error(), "newer engine") {
+		t.Errorf("Expected an error for the newer version, got %v", err)
+	}
+
+	delete(k.Migrations, 1)
+	if err := v1.Write(path, counters{"b": 2}); err != nil {
+		t.Fatalf("Failed to write the file: %v", err)
+	}
+	if _, err := k.Read(path, &c); err == nil {
+		t.Error("Expected an error for the missing migration")
+	}
+}
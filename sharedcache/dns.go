@@ -0,0 +1,61 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package sharedcache
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// maxDNSTTL is the longest time the DNS responses are kept, regardless of the TTL of their answers.
+const maxDNSTTL = time.Hour
+
+func dnsKey(name string, qtype uint16) string {
+	return "dns:" + strconv.Itoa(int(qtype)) + ":" + strings.ToLower(dns.Fqdn(name))
+}
+
+// LookupDNS returns the response to the query stored by an engine instance, or nil when the response
+// is missing, has expired or the server cannot be reached.
+func (s *Store) LookupDNS(name string, qtype uint16) *dns.Msg {
+	data, found := s.Get(dnsKey(name, qtype))
+	if !found {
+		return nil
+	}
+
+	resp := new(dns.Msg)
+	if err := resp.Unpack(data); err != nil {
+		return nil
+	}
+	return resp
+}
+
+// StoreDNS keeps the successful response to the query for the lowest TTL of its answers, so the other
+// engine instances do not send the same query. The responses without answers are not kept.
+func (s *Store) StoreDNS(resp *dns.Msg) {
+	if resp == nil || resp.Rcode != dns.RcodeSuccess || len(resp.Question) == 0 || len(resp.Answer) == 0 {
+		return
+	}
+
+	ttl := maxDNSTTL
+	for _, rr := range resp.Answer {
+		if t := time.Duration(rr.Header().Ttl) * time.Second; t < ttl {
+			ttl = t
+		}
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := resp.Pack()
+	if err != nil {
+		return
+	}
+
+	q := resp.Question[0]
+	_ = s.Set(dnsKey(q.Name, q.Qtype), data, ttl)
+}
@@ -0,0 +1,240 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package sharedcache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dialTimeout = 5 * time.Second
+	// opTimeout is the deadline of each command sent to the server
+	opTimeout = 2 * time.Second
+	// maxIdleConns is the number of connections kept open for the following commands
+	maxIdleConns = 8
+	// maxBulkLen is the largest value accepted from the server
+	maxBulkLen = 64 << 20
+)
+
+// errNil is returned for the nil replies of the server, such as GET for a missing key.
+var errNil = errors.New("redis: nil reply")
+
+// client sends the commands to a Redis server using the RESP protocol.
+type client struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      bool
+	idle     chan *conn
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// newClient returns a client for the server at the redis:// or rediss:// URL,
+// which may provide the credentials and the database number.
+func newClient(rawURL string) (*client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		return nil, fmt.Errorf("%s is not a valid redis:// URL", rawURL)
+	}
+
+	c := &client{
+		addr: u.Host,
+		tls:  u.Scheme == "rediss",
+		idle: make(chan *conn, maxIdleConns),
+	}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil || c.db < 0 {
+			return nil, fmt.Errorf("the database number %s is not valid", db)
+		}
+	}
+	return c, nil
+}
+
+// do sends the command and returns the reply, which is a string, an integer, an array or errNil.
+func (c *client) do(args ...string) (interface{}, error) {
+	cn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+
+	_ = cn.SetDeadline(time.Now().Add(opTimeout))
+	reply, err := cn.command(args...)
+	if err != nil && !isServerError(err) && err != errNil {
+		// The connection is in an unknown state after the network errors
+		_ = cn.Close()
+		return nil, err
+	}
+
+	c.put(cn)
+	return reply, err
+}
+
+func (c *client) get() (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+	return c.dial()
+}
+
+func (c *client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		_ = cn.Close()
+	}
+}
+
+func (c *client) dial() (*conn, error) {
+	d := &net.Dialer{Timeout: dialTimeout}
+
+	var nc net.Conn
+	var err error
+	if c.tls {
+		nc, err = dialTLS(d, c.addr)
+	} else {
+		nc, err = d.Dial("tcp", c.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the Redis server at %s: %v", c.addr, err)
+	}
+
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+	_ = cn.SetDeadline(time.Now().Add(opTimeout))
+	if c.password != "" {
+		args := []string{"AUTH", c.password}
+		if c.username != "" {
+			args = []string{"AUTH", c.username, c.password}
+		}
+		if _, err := cn.command(args...); err != nil {
+			_ = cn.Close()
+			return nil, fmt.Errorf("failed to authenticate with the Redis server: %v", err)
+		}
+	}
+	if c.db > 0 {
+		if _, err := cn.command("SELECT", strconv.Itoa(c.db)); err != nil {
+			_ = cn.Close()
+			return nil, fmt.Errorf("failed to select the Redis database %d: %v", c.db, err)
+		}
+	}
+	return cn, nil
+}
+
+// close closes the idle connections.
+func (c *client) close() {
+	for {
+		select {
+		case cn := <-c.idle:
+			_ = cn.Close()
+		default:
+			return
+		}
+	}
+}
+
+func dialTLS(d *net.Dialer, addr string) (net.Conn, error) {
+	host, _, _ := net.SplitHostPort(addr)
+	return tls.DialWithDialer(d, "tcp", addr, &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	})
+}
+
+type serverError string
+
+func (e serverError) Error() string { return "redis: " + string(e) }
+
+func isServerError(err error) bool {
+	_, ok := err.(serverError)
+	return ok
+}
+
+func (cn *conn) command(args ...string) (interface{}, error) {
+	var b strings.Builder
+
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		b.WriteString("$" + strconv.Itoa(len(a)) + "\r\n" + a + "\r\n")
+	}
+	if _, err := cn.Write([]byte(b.String())); err != nil {
+		return nil, err
+	}
+	return readReply(cn.r)
+}
+
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, errors.New("redis: malformed reply")
+	}
+
+	body := line[1 : len(line)-2]
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, serverError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n > maxBulkLen {
+			return nil, errors.New("redis: malformed bulk reply")
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errors.New("redis: malformed array reply")
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := readReply(r)
+			if err != nil && err != errNil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+}
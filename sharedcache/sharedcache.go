@@ -0,0 +1,166 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sharedcache keeps the state of the engine caches in Redis, so the engine instances of
+// a horizontally scaled deployment share the cached HTTP and DNS responses and the filtered names,
+// and avoid repeating the external requests made by the other instances.
+package sharedcache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPrefix = "amass:"
+	// sessionTTL is how long the names of a session are kept after the last instance joined or
+	// added a name, when the instances did not release them
+	sessionTTL = 24 * time.Hour
+)
+
+// Default is the shared cache used by the engine, or nil when the shared_cache option is not provided.
+var Default *Store
+
+// Store is the shared cache kept by the Redis server.
+type Store struct {
+	c       *client
+	prefix  string
+	session string
+}
+
+// Settings are provided by the shared_cache option of the configuration.
+type Settings struct {
+	// URL of the Redis server, such as redis://:password@10.0.0.5:6379/0
+	URL string
+	// Prefix of the keys, so the deployments can share the Redis server
+	Prefix string
+	// Session identifies the enumeration whose engine instances share the filtered names. Each
+	// session has its own names when it is not provided
+	Session string
+}
+
+// ParseSettings returns the settings of the shared cache, or nil when the option is not provided.
+// The option is either the URL of the Redis server or provides the url and prefix settings.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	s := &Settings{Prefix: defaultPrefix}
+
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		s.URL = v
+	case map[string]interface{}:
+		for key, dst := range map[string]*string{"url": &s.URL, "prefix": &s.Prefix, "session": &s.Session} {
+			if val, found := v[key]; found && val != nil {
+				str, ok := val.(string)
+				if !ok {
+					return nil, fmt.Errorf("the shared_cache %s setting must be a string", key)
+				}
+				*dst = str
+			}
+		}
+	default:
+		return nil, errors.New("shared_cache must provide the URL of the Redis server")
+	}
+
+	if s.URL == "" {
+		return nil, errors.New("shared_cache must provide the URL of the Redis server")
+	}
+	if _, err := newClient(s.URL); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Open returns the Store after checking that the Redis server can be reached, and joins the session
+// of the settings, so its names are kept until the last instance of the session closes the Store.
+func Open(s *Settings) (*Store, error) {
+	c, err := newClient(s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.do("PING"); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	session := s.Session
+	if session == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			c.close()
+			return nil, err
+		}
+		session = hex.EncodeToString(b)
+	}
+
+	st := &Store{c: c, prefix: s.Prefix, session: session}
+	if _, err := c.do("INCR", st.instancesKey()); err != nil {
+		c.close()
+		return nil, err
+	}
+	_, _ = c.do("PEXPIRE", st.instancesKey(), strconv.FormatInt(sessionTTL.Milliseconds(), 10))
+	return st, nil
+}
+
+func (s *Store) namesKey() string {
+	return s.prefix + "session:" + s.session + ":names"
+}
+
+func (s *Store) instancesKey() string {
+	return s.prefix + "session:" + s.session + ":instances"
+}
+
+// Get returns the value of the key, and false when the key is missing or the server cannot be reached.
+func (s *Store) Get(key string) ([]byte, bool) {
+	reply, err := s.c.do("GET", s.prefix+key)
+	if err != nil {
+		return nil, false
+	}
+
+	v, ok := reply.(string)
+	return []byte(v), ok
+}
+
+// Set stores the value of the key, which expires after the TTL, or is kept when the TTL is zero.
+func (s *Store) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", s.prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+
+	_, err := s.c.do(args...)
+	return err
+}
+
+// AddName adds the name to the names sent by the data sources during the session, and returns true
+// when it was added by this call, so the name is only released by one instance of the session.
+func (s *Store) AddName(name string) (bool, error) {
+	reply, err := s.c.do("SADD", s.namesKey(), name)
+	if err != nil {
+		return false, err
+	}
+
+	added, _ := reply.(int64)
+	if added == 1 {
+		_, _ = s.c.do("PEXPIRE", s.namesKey(), strconv.FormatInt(sessionTTL.Milliseconds(), 10))
+	}
+	return added == 1, nil
+}
+
+// Close leaves the session, releasing its names once the last instance of the session has left,
+// and closes the connections to the Redis server.
+func (s *Store) Close() {
+	if reply, err := s.c.do("DECR", s.instancesKey()); err == nil {
+		if n, ok := reply.(int64); ok && n <= 0 {
+			_, _ = s.c.do("DEL", s.namesKey(), s.instancesKey())
+		}
+	}
+	s.c.close()
+}
@@ -0,0 +1,285 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package sharedcache
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// fakeRedis serves the subset of the Redis commands used by the Store.
+type fakeRedis struct {
+	sync.Mutex
+	ln       net.Listener
+	password string
+	values   map[string]string
+	sets     map[string]map[string]bool
+	counters map[string]int
+	expires  map[string]time.Duration
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	f := &fakeRedis{
+		ln:       ln,
+		password: password,
+		values:   make(map[string]string),
+		sets:     make(map[string]map[string]bool),
+		counters: make(map[string]int),
+		expires:  make(map[string]time.Duration),
+	}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(c)
+		}
+	}()
+	t.Cleanup(func() { _ = ln.Close() })
+	return f
+}
+
+func (f *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+
+	r := bufio.NewReader(c)
+	authed := f.password == ""
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+
+		var args []string
+		for _, a := range reply.([]interface{}) {
+			args = append(args, a.(string))
+		}
+		if !authed && args[0] != "AUTH" {
+			_, _ = c.Write([]byte("-NOAUTH Authentication required.\r\n"))
+			continue
+		}
+		_, _ = c.Write([]byte(f.handle(args, &authed)))
+	}
+}
+
+func (f *fakeRedis) handle(args []string, authed *bool) string {
+	f.Lock()
+	defer f.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		if args[len(args)-1] != f.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		*authed = true
+		return "+OK\r\n"
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		v, found := f.values[args[1]]
+		if !found {
+			return "$-1\r\n"
+		}
+		return "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+	case "SET":
+		var nx bool
+		var ttl time.Duration
+		for i := 3; i < len(args); i++ {
+			switch args[i] {
+			case "NX":
+				nx = true
+			case "PX":
+				ms, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(ms) * time.Millisecond
+				i++
+			}
+		}
+		if _, found := f.values[args[1]]; found && nx {
+			return "$-1\r\n"
+		}
+		f.values[args[1]] = args[2]
+		f.expires[args[1]] = ttl
+		return "+OK\r\n"
+	case "SADD":
+		if f.sets[args[1]] == nil {
+			f.sets[args[1]] = make(map[string]bool)
+		}
+		if f.sets[args[1]][args[2]] {
+			return ":0\r\n"
+		}
+		f.sets[args[1]][args[2]] = true
+		return ":1\r\n"
+	case "INCR", "DECR":
+		if args[0] == "INCR" {
+			f.counters[args[1]]++
+		} else {
+			f.counters[args[1]]--
+		}
+		return ":" + strconv.Itoa(f.counters[args[1]]) + "\r\n"
+	case "PEXPIRE":
+		ms, _ := strconv.Atoi(args[2])
+		f.expires[args[1]] = time.Duration(ms) * time.Millisecond
+		return ":1\r\n"
+	case "DEL":
+		for _, key := range args[1:] {
+			delete(f.values, key)
+			delete(f.sets, key)
+			delete(f.counters, key)
+		}
+		return ":" + strconv.Itoa(len(args)-1) + "\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestStore(t *testing.T) {
+	f := newFakeRedis(t, "s3cr3t")
+
+	s, err := Open(&Settings{URL: "redis://:s3cr3t@" + f.ln.Addr().String(), Prefix: "test:"})
+	if err != nil {
+		t.Fatalf("Failed to open the store: %v", err)
+	}
+	defer s.Close()
+
+	if _, found := s.Get("missing"); found {
+		t.Error("The missing key was found")
+	}
+
+	value := "line one\r\nline two"
+	if err := s.Set("key", []byte(value), time.Hour); err != nil {
+		t.Fatalf("Failed to set the key: %v", err)
+	}
+	if v, found := s.Get("key"); !found || string(v) != value {
+		t.Errorf("Unexpected value: %q", v)
+	}
+	if f.expires["test:key"] != time.Hour {
+		t.Errorf("The key expires after %v", f.expires["test:key"])
+	}
+
+	if added, err := s.AddName("www.owasp.org"); err != nil || !added {
+		t.Errorf("The new name was not added: %v", err)
+	}
+	if added, err := s.AddName("www.owasp.org"); err != nil || added {
+		t.Errorf("The name was added twice: %v", err)
+	}
+
+	if _, err := Open(&Settings{URL: "redis://:wrong@" + f.ln.Addr().String()}); err == nil {
+		t.Error("Expected an error with the wrong password")
+	}
+}
+
+func TestSessionNames(t *testing.T) {
+	f := newFakeRedis(t, "")
+	settings := &Settings{URL: "redis://" + f.ln.Addr().String(), Prefix: "test:", Session: "scan1"}
+
+	first, err := Open(settings)
+	if err != nil {
+		t.Fatalf("Failed to open the store: %v", err)
+	}
+	second, err := Open(settings)
+	if err != nil {
+		t.Fatalf("Failed to open the store: %v", err)
+	}
+	other, err := Open(&Settings{URL: settings.URL, Prefix: "test:"})
+	if err != nil {
+		t.Fatalf("Failed to open the store: %v", err)
+	}
+	defer other.Close()
+
+	if added, _ := first.AddName("www.owasp.org"); !added {
+		t.Error("The new name was not added")
+	}
+	if added, _ := second.AddName("www.owasp.org"); added {
+		t.Error("The name was not shared by the instances of the session")
+	}
+	if added, _ := other.AddName("www.owasp.org"); !added {
+		t.Error("The name was shared with another session")
+	}
+	if f.expires["test:session:scan1:names"] != sessionTTL {
+		t.Errorf("The names expire after %v", f.expires["test:session:scan1:names"])
+	}
+
+	first.Close()
+	if _, found := f.sets["test:session:scan1:names"]; !found {
+		t.Error("The names were released before the last instance of the session left")
+	}
+	second.Close()
+	if _, found := f.sets["test:session:scan1:names"]; found {
+		t.Error("The names were not released when the last instance of the session left")
+	}
+}
+
+func TestDNS(t *testing.T) {
+	f := newFakeRedis(t, "")
+
+	s, err := Open(&Settings{URL: "redis://" + f.ln.Addr().String(), Prefix: "test:"})
+	if err != nil {
+		t.Fatalf("Failed to open the store: %v", err)
+	}
+	defer s.Close()
+
+	if resp := s.LookupDNS("www.owasp.org", dns.TypeA); resp != nil {
+		t.Error("The missing response was found")
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion("www.owasp.org.", dns.TypeA)
+	s.StoreDNS(msg)
+	if len(f.values) != 0 {
+		t.Error("The response without answers was stored")
+	}
+
+	for _, ttl := range []string{"300", "60"} {
+		rr, _ := dns.NewRR("www.owasp.org. " + ttl + " IN A 104.22.27.77")
+		msg.Answer = append(msg.Answer, rr)
+	}
+	s.StoreDNS(msg)
+
+	resp := s.LookupDNS("WWW.owasp.org", dns.TypeA)
+	if resp == nil || len(resp.Answer) != 2 || resp.Answer[1].String() != msg.Answer[1].String() {
+		t.Errorf("Unexpected response: %v", resp)
+	}
+	if ttl := f.expires["test:"+dnsKey("www.owasp.org", dns.TypeA)]; ttl != time.Minute {
+		t.Errorf("The response expires after %v", ttl)
+	}
+	if resp := s.LookupDNS("www.owasp.org", dns.TypeAAAA); resp != nil {
+		t.Error("The response was returned for another type")
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	if s, err := ParseSettings(nil); s != nil || err != nil {
+		t.Errorf("Unexpected settings without the option: %v %v", s, err)
+	}
+	if s, err := ParseSettings("redis://10.0.0.5:6379/2"); err != nil || s.Prefix != defaultPrefix {
+		t.Errorf("Unexpected settings: %v %v", s, err)
+	}
+	if s, err := ParseSettings(map[string]interface{}{"url": "rediss://10.0.0.5", "prefix": "team1:", "session": "scan1"}); err != nil || s.Prefix != "team1:" || s.Session != "scan1" {
+		t.Errorf("Unexpected settings: %v %v", s, err)
+	}
+
+	for _, raw := range []interface{}{
+		"http://10.0.0.5:6379",
+		"redis://10.0.0.5:6379/db",
+		map[string]interface{}{"prefix": "team1:"},
+		6379,
+	} {
+		if _, err := ParseSettings(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sightings records the data sources that provided each name during the enumerations,
+// since the graph database only keeps the names, so the listings can weigh the evidence for them.
+package sightings
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the file in the output directory that stores the sightings.
+const FileName = "sightings.json"
+
+// Sighting is a name provided by a data source.
+type Sighting struct {
+	Time   time.Time `json:"time"`
+	Name   string    `json:"name"`
+	Source string    `json:"source"`
+}
+
+// Log appends the sightings to a file with one JSON object per line.
+type Log struct {
+	sync.Mutex
+	f    *os.File
+	enc  *json.Encoder
+	seen map[string]struct{}
+}
+
+// NewLog opens the sightings file at the provided path for appending.
+func NewLog(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Log{
+		f:    f,
+		enc:  json.NewEncoder(f),
+		seen: make(map[string]struct{}),
+	}, nil
+}
+
+// Add writes the sighting of the name by the data source, once for each enumeration.
+func (l *Log) Add(name, source string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	key := name + " " + source
+	if _, found := l.seen[key]; found {
+		return nil
+	}
+	l.seen[key] = struct{}{}
+
+	return l.enc.Encode(&Sighting{
+		Time:   time.Now(),
+		Name:   name,
+		Source: source,
+	})
+}
+
+// Close flushes the sightings to disk and closes the file.
+func (l *Log) Close() error {
+	l.Lock()
+	defer l.Unlock()
+
+	_ = l.f.Sync()
+	return l.f.Close()
+}
+
+// Read returns the data sources that provided each name, according to the file at the provided path.
+func Read(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sets := make(map[string]map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var s Sighting
+
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil || s.Name == "" || s.Source == "" {
+			continue
+		}
+		if _, found := sets[s.Name]; !found {
+			sets[s.Name] = make(map[string]struct{})
+		}
+		sets[s.Name][s.Source] = struct{}{}
+	}
+
+	results := make(map[string][]string, len(sets))
+	for name, set := range sets {
+		srcs := make([]string, 0, len(set))
+		for src := range set {
+			srcs = append(srcs, src)
+		}
+		sort.Strings(srcs)
+		results[name] = srcs
+	}
+	return results, scanner.Err()
+}
+
+// Compact rewrites the file at the provided path, keeping only the latest sighting of each name by each data source,
+// and removing the sightings of the names rejected by the keep function, such as the names purged from the graph
+// database. The number of sightings removed is returned.
+func Compact(path string, keep func(name string) bool) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var count int
+	var order []string
+	latest := make(map[string]*Sighting)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var s Sighting
+
+		count++
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil || s.Name == "" || s.Source == "" || !keep(s.Name) {
+			continue
+		}
+
+		key := s.Name + " " + s.Source
+		if prev, found := latest[key]; !found {
+			order = append(order, key)
+		} else if s.Time.Before(prev.Time) {
+			continue
+		}
+		latest[key] = &s
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), FileName+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, key := range order {
+		if err := enc.Encode(latest[key]); err != nil {
+			tmp.Close()
+			return 0, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return count - len(order), nil
+}
@@ -0,0 +1,83 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package sightings
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	// The sightings of two enumerations are kept by the same file
+	for _, run := range [][][2]string{
+		{{"www.owasp.org", "crtsh"}, {"www.owasp.org", "crtsh"}, {"dev.owasp.org", "Wayback"}},
+		{{"www.owasp.org", "DNSDumpster"}},
+	} {
+		l, err := NewLog(path)
+		if err != nil {
+			t.Fatalf("Failed to open the sightings log: %v", err)
+		}
+		for _, s := range run {
+			if err := l.Add(s[0], s[1]); err != nil {
+				t.Errorf("Failed to add the sighting: %v", err)
+			}
+		}
+		if err := l.Close(); err != nil {
+			t.Errorf("Failed to close the sightings log: %v", err)
+		}
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read the sightings: %v", err)
+	}
+
+	expected := map[string][]string{
+		"www.owasp.org": {"DNSDumpster", "crtsh"},
+		"dev.owasp.org": {"Wayback"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	for _, run := range [][][2]string{
+		{{"www.owasp.org", "crtsh"}, {"dev.owasp.org", "Wayback"}},
+		{{"www.owasp.org", "crtsh"}, {"www.owasp.org", "DNSDumpster"}},
+	} {
+		l, err := NewLog(path)
+		if err != nil {
+			t.Fatalf("Failed to open the sightings log: %v", err)
+		}
+		for _, s := range run {
+			_ = l.Add(s[0], s[1])
+		}
+		_ = l.Close()
+	}
+
+	removed, err := Compact(path, func(name string) bool { return name != "dev.owasp.org" })
+	if err != nil {
+		t.Fatalf("Failed to compact the sightings: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected two sightings to be removed, got %d", removed)
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read the sightings: %v", err)
+	}
+
+	expected := map[string][]string{"www.owasp.org": {"DNSDumpster", "crtsh"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// FederationLocal is the origin label of the assets provided by the database of the session.
+const FederationLocal = "local"
+
+var federationNameRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// FederatedDatabase is a database searched by the query and reporting subcommands along with the
+// database of the session, such as the database of another business unit.
+type FederatedDatabase struct {
+	// Name is the origin label of the assets provided by the database
+	Name     string
+	Database *SharedDatabase
+}
+
+// ParseFederation returns the databases provided by the federation option, which maps the origin labels
+// to either postgres:// URIs or the paths of the output directories of other sessions, sorted by name.
+func ParseFederation(raw interface{}, dir string) ([]*FederatedDatabase, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the federation option must map the names of the databases to their URIs or paths")
+	}
+
+	var results []*FederatedDatabase
+	for name, v := range m {
+		if name == FederationLocal || !federationNameRE.MatchString(name) {
+			return nil, fmt.Errorf("%s is not a valid name for a federated database", name)
+		}
+
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return nil, fmt.Errorf("the federated database %s must be a postgres URI or the path of an output directory", name)
+		}
+
+		db, err := ParseSharedDatabase(s, dir)
+		if err != nil {
+			return nil, fmt.Errorf("the federated database %s: %v", name, err)
+		}
+		results = append(results, &FederatedDatabase{Name: name, Database: db})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
@@ -0,0 +1,187 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// The amount of time a single health check has to complete before the component is considered wedged.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck is the result of checking a single component of the System.
+type HealthCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// HealthReport is the response body returned by the health endpoints.
+type HealthReport struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+// HealthServer exposes the /healthz and /readyz endpoints for container orchestrators.
+type HealthServer struct {
+	sys    System
+	ln     net.Listener
+	server *http.Server
+}
+
+// NewHealthServer starts serving the health endpoints for the System on the provided address.
+func NewHealthServer(sys System, addr string) (*HealthServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for health checks on %s: %v", addr, err)
+	}
+
+	h := &HealthServer{
+		sys: sys,
+		ln:  ln,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.healthz)
+	mux.HandleFunc("/readyz", h.readyz)
+	h.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() { _ = h.server.Serve(ln) }()
+	return h, nil
+}
+
+// Addr returns the address the health endpoints are being served on.
+func (h *HealthServer) Addr() string {
+	return h.ln.Addr().String()
+}
+
+// Close stops serving the health endpoints.
+func (h *HealthServer) Close() error {
+	return h.server.Close()
+}
+
+// The liveness endpoint only fails when the System has become unresponsive.
+func (h *HealthServer) healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, runHealthChecks(map[string]func() error{
+		"registry": h.checkRegistry,
+	}))
+}
+
+// The readiness endpoint fails when any dependency of the enumeration is unavailable.
+func (h *HealthServer) readyz(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, runHealthChecks(map[string]func() error{
+		"database":     h.checkDatabases,
+		"resolvers":    h.checkResolvers,
+		"data_sources": h.checkDataSources,
+	}))
+}
+
+// checkRegistry fails when the registry of the data sources no longer answers within the timeout, such as
+// once the System was shut down, or when a registered data source has stopped while the System is running.
+func (h *HealthServer) checkRegistry() error {
+	for _, src := range h.sys.DataSources() {
+		if src == nil {
+			continue
+		}
+
+		select {
+		case <-src.Done():
+			return fmt.Errorf("the %s data source has stopped", src.String())
+		default:
+		}
+	}
+	return nil
+}
+
+func (h *HealthServer) checkDataSources() error {
+	if len(h.sys.DataSources()) == 0 {
+		return errors.New("no data sources have been registered")
+	}
+	return nil
+}
+
+func (h *HealthServer) checkResolvers() error {
+	if pool := h.sys.Resolvers(); pool == nil || pool.Len() == 0 {
+		return errors.New("no untrusted resolvers are available")
+	}
+	if pool := h.sys.TrustedResolvers(); pool == nil || pool.Len() == 0 {
+		return errors.New("no trusted resolvers are available")
+	}
+	return nil
+}
+
+func (h *HealthServer) checkDatabases() error {
+	graphs := h.sys.GraphDatabases()
+	if len(graphs) == 0 {
+		return errors.New("no graph databases have been setup")
+	}
+	if history := h.sys.HistoryDatabase(); history != nil {
+		graphs = append(graphs, history)
+	}
+
+	for _, g := range graphs {
+		if g == nil || g.DB == nil {
+			return errors.New("the graph database has not been initialized")
+		}
+		// A query for a name that cannot exist verifies connectivity without returning data
+		if _, err := g.DB.FindByContent(&domain.FQDN{Name: "healthz.invalid"}, time.Time{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runHealthChecks(checks map[string]func() error) *HealthReport {
+	report := &HealthReport{Status: "ok"}
+
+	for _, name := range []string{"registry", "database", "resolvers", "data_sources"} {
+		check, found := checks[name]
+		if !found {
+			continue
+		}
+
+		result := HealthCheck{Name: name, OK: true}
+		if err := runWithTimeout(check, healthCheckTimeout); err != nil {
+			result.OK = false
+			result.Error = err.Error()
+			report.Status = "failed"
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+func runWithTimeout(check func() error, timeout time.Duration) error {
+	ch := make(chan error, 1)
+	go func() { ch <- check() }()
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case err := <-ch:
+		return err
+	case <-t.C:
+		return fmt.Errorf("the check did not complete within %v", timeout)
+	}
+}
+
+func writeHealthReport(w http.ResponseWriter, report *HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
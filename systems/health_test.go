@@ -0,0 +1,124 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/config/config"
+)
+
+type testSource struct {
+	*service.BaseService
+}
+
+func newTestSource(name string) *testSource {
+	src := new(testSource)
+	src.BaseService = service.NewBaseService(src, name)
+	return src
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	sys := &SimpleSystem{Cfg: config.NewConfig()}
+
+	h, err := NewHealthServer(sys, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start the health server: %v", err)
+	}
+	defer func() { _ = h.Close() }()
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{path: "/healthz", status: http.StatusOK},
+		{path: "/readyz", status: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		resp, err := http.Get("http://" + h.Addr() + tt.path)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.path, err)
+		}
+
+		var report HealthReport
+		err = json.NewDecoder(resp.Body).Decode(&report)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: failed to decode the report: %v", tt.path, err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, resp.StatusCode)
+		}
+		if len(report.Checks) == 0 {
+			t.Errorf("%s: the report did not include any checks", tt.path)
+		}
+	}
+}
+
+func TestHealthCheckNames(t *testing.T) {
+	src := newTestSource("Stopped")
+	if err := src.Start(); err != nil {
+		t.Fatalf("Failed to start the data source: %v", err)
+	}
+	sys := &SimpleSystem{Cfg: config.NewConfig(), Service: src}
+
+	h, err := NewHealthServer(sys, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start the health server: %v", err)
+	}
+	defer func() { _ = h.Close() }()
+
+	if err := h.checkRegistry(); err != nil {
+		t.Errorf("Expected the running data source to pass the liveness check: %v", err)
+	}
+	_ = src.Stop()
+	if err := h.checkRegistry(); err == nil {
+		t.Error("Expected the stopped data source to fail the liveness check")
+	}
+
+	names := make(map[string]string)
+	for _, path := range []string{"/healthz", "/readyz"} {
+		resp, err := http.Get("http://" + h.Addr() + path)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", path, err)
+		}
+
+		var report HealthReport
+		err = json.NewDecoder(resp.Body).Decode(&report)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: failed to decode the report: %v", path, err)
+		}
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusServiceUnavailable, resp.StatusCode)
+		}
+		// The checks of both endpoints are distinguished by their names
+		for _, c := range report.Checks {
+			if other, found := names[c.Name]; found && other != path {
+				t.Errorf("The %s check is reported by both %s and %s", c.Name, other, path)
+			}
+			names[c.Name] = path
+		}
+	}
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	wedged := func() error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	if err := runWithTimeout(wedged, 10*time.Millisecond); err == nil {
+		t.Errorf("Expected the wedged check to time out")
+	}
+	if err := runWithTimeout(func() error { return nil }, time.Second); err != nil {
+		t.Errorf("Expected the check to succeed: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/config/config"
+)
+
+// SharedDatabase is a graph database of other sessions, which is only read.
+type SharedDatabase struct {
+	// System is either postgres, or local for the database in the output directory of another session
+	System  string
+	Path    string
+	ConnStr string
+	Options string
+}
+
+// ParseHistoryDatabase returns the shared database of the previous sessions provided by the history_database
+// option, so the assets confirmed by other engagements are not reported as new by a session writing to its own
+// database. Nil is returned when the option is not provided.
+func ParseHistoryDatabase(raw interface{}, dir string) (*SharedDatabase, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	s, ok := raw.(string)
+	if !ok || strings.TrimSpace(s) == "" {
+		return nil, errors.New("the history_database option must be a postgres URI or the path of an output directory")
+	}
+	return ParseSharedDatabase(s, dir)
+}
+
+// ParseSharedDatabase returns the SharedDatabase for either a postgres:// URI or the path of the output
+// directory of another session. The output directory of the session cannot be used, since it is the
+// database written by the session.
+func ParseSharedDatabase(s, dir string) (*SharedDatabase, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the database URI: %v", err)
+		}
+		if u.Scheme != "postgres" {
+			return nil, fmt.Errorf("%s is not a supported shared database system", u.Scheme)
+		}
+		if u.Hostname() == "" || u.User == nil || u.User.Username() == "" {
+			return nil, errors.New("the database URI must provide the username and hostname")
+		}
+
+		password, _ := u.User.Password()
+		connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", u.Hostname(),
+			u.Port(), u.User.Username(), password, strings.TrimPrefix(u.Path, "/"))
+		return &SharedDatabase{System: "postgres", ConnStr: connStr, Options: u.Query().Encode()}, nil
+	}
+
+	path, err := filepath.Abs(s)
+	if err != nil {
+		return nil, err
+	}
+	if own, err := filepath.Abs(config.OutputDirectory(dir)); err == nil && own == path {
+		return nil, errors.New("the shared database cannot be the database of the session")
+	}
+
+	file := filepath.Join(path, "amass.sqlite")
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("the database %s was not found", file)
+	}
+	return &SharedDatabase{System: "local", Path: file}, nil
+}
+
+// Open returns the graph of the shared database.
+func (d *SharedDatabase) Open() (*netmap.Graph, error) {
+	var g *netmap.Graph
+	if d.System == "local" {
+		g = netmap.NewGraph(d.System, d.Path, d.Options)
+	} else {
+		g = netmap.NewGraph(d.System, d.ConnStr, d.Options)
+	}
+
+	if g == nil {
+		return nil, fmt.Errorf("System: failed to open the %s shared database", d.System)
+	}
+	return g, nil
+}
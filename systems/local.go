@@ -5,6 +5,7 @@
 package systems
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -17,9 +18,14 @@ import (
 
 	"github.com/caffix/netmap"
 	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/dbindex"
 	amassnet "github.com/owasp-amass/amass/v4/net"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/ratelimit"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/amass/v4/resources"
+	"github.com/owasp-amass/amass/v4/sharedcache"
+	"github.com/owasp-amass/amass/v4/vault"
 	"github.com/owasp-amass/config/config"
 	"github.com/owasp-amass/resolve"
 )
@@ -30,6 +36,12 @@ type LocalSystem struct {
 	pool              *resolve.Resolvers
 	trusted           *resolve.Resolvers
 	graphs            []*netmap.Graph
+	history           *netmap.Graph
+	vault             *vault.Vault
+	limits            *ratelimit.Server
+	shared            *sharedcache.Store
+	sealPath          string
+	pgDSN             string
 	cache             *requests.ASNCache
 	done              chan struct{}
 	doneAlreadyClosed bool
@@ -40,17 +52,57 @@ type LocalSystem struct {
 // NewLocalSystem returns an initialized LocalSystem object.
 func NewLocalSystem(cfg *config.Config) (*LocalSystem, error) {
 	if err := cfg.CheckSettings(); err != nil {
-		return nil, err
+		return nil, setupError(KindConfig, err)
+	}
+	// The proxy is applied to the outbound HTTP requests of all the data sources
+	proxy, err := amassnet.ParseProxySettings(cfg.Options["proxy"])
+	if err != nil {
+		return nil, setupError(KindConfig, err)
+	}
+	amassnet.Proxy = proxy.Default
+	// The outbound connections are made from the configured local addresses in rotation
+	srcs, err := amassnet.ParseSourceAddresses(cfg.Options["source_addresses"])
+	if err != nil {
+		return nil, setupError(KindConfig, err)
+	}
+	amassnet.SourceAddrs = srcs
+	// HTTP/3 is used for the servers advertising it when the option is enabled
+	if enabled, ok := cfg.Options["http3"].(bool); ok && enabled {
+		amasshttp.EnableHTTP3()
+	}
+	// The TLS settings of the data sources are checked before the sources are loaded
+	if _, err := amassnet.ParseTLSOptions(cfg.Options["tls"]); err != nil {
+		return nil, setupError(KindConfig, err)
+	}
+	// The request rates of the shared API keys are coordinated with the other engine instances
+	limits, err := ratelimit.ParseSettings(cfg.Options["rate_limit_coordinator"])
+	if err != nil {
+		return nil, setupError(KindConfig, err)
+	}
+	// The caches are kept by the Redis server shared with the other engine instances
+	shared, err := sharedcache.ParseSettings(cfg.Options["shared_cache"])
+	if err != nil {
+		return nil, setupError(KindConfig, err)
+	}
+	// The local database is encrypted at rest when the option is provided
+	v, err := vault.ParseSettings(cfg.Options["database_encryption"])
+	if err != nil {
+		return nil, setupError(KindConfig, err)
+	}
+	// The session can read the assets of the previous sessions from a shared database
+	history, err := ParseHistoryDatabase(cfg.Options["history_database"], cfg.Dir)
+	if err != nil {
+		return nil, setupError(KindConfig, err)
 	}
 
 	trusted, num := trustedResolvers(cfg)
 	if trusted == nil || num == 0 {
-		return nil, errors.New("the system was unable to build the pool of trusted resolvers")
+		return nil, setupError(KindResolvers, errors.New("the system was unable to build the pool of trusted resolvers"))
 	}
 
 	pool, num := untrustedResolvers(cfg)
 	if pool == nil || num == 0 {
-		return nil, errors.New("the system was unable to build the pool of untrusted resolvers")
+		return nil, setupError(KindResolvers, errors.New("the system was unable to build the pool of untrusted resolvers"))
 	}
 	if cfg.MaxDNSQueries == 0 {
 		cfg.MaxDNSQueries += num * cfg.ResolversQPS
@@ -67,6 +119,7 @@ func NewLocalSystem(cfg *config.Config) (*LocalSystem, error) {
 		pool:       pool,
 		trusted:    trusted,
 		cache:      requests.NewASNCache(),
+		vault:      v,
 		done:       make(chan struct{}, 2),
 		addSource:  make(chan service.Service),
 		allSources: make(chan chan []service.Service, 10),
@@ -80,12 +133,40 @@ func NewLocalSystem(cfg *config.Config) (*LocalSystem, error) {
 	// Make sure that the output directory is setup for this local system
 	if err := sys.setupOutputDirectory(); err != nil {
 		_ = sys.Shutdown()
-		return nil, err
+		return nil, setupError(KindIO, err)
 	}
 	// Setup the correct graph database handler
 	if err := sys.setupGraphDBs(cfg); err != nil {
 		_ = sys.Shutdown()
-		return nil, err
+		return nil, setupError(KindDatabase, err)
+	}
+	if history != nil {
+		if sys.history, err = history.Open(); err != nil {
+			_ = sys.Shutdown()
+			return nil, setupError(KindDatabase, err)
+		}
+	}
+
+	if shared != nil {
+		if sys.shared, err = sharedcache.Open(shared); err != nil {
+			_ = sys.Shutdown()
+			return nil, setupError(KindNetwork, err)
+		}
+	}
+	sharedcache.Default = sys.shared
+	// The HTTP clients of the enumeration apply the proxies, TLS settings and response cache of the configuration
+	var store amasshttp.CacheStore
+	if sys.shared != nil {
+		store = sys.shared
+	}
+	if err := amasshttp.Configure(cfg.Options["proxy"], cfg.Options["tls"], config.OutputDirectory(cfg.Dir), store); err != nil {
+		_ = sys.Shutdown()
+		return nil, setupError(KindConfig, err)
+	}
+	// Serve the coordinator, or reach it, before the data sources make their requests
+	if sys.limits, err = ratelimit.Setup(limits); err != nil {
+		_ = sys.Shutdown()
+		return nil, setupError(KindNetwork, err)
 	}
 
 	go sys.manageDataSources()
@@ -167,6 +248,11 @@ func (l *LocalSystem) GraphDatabases() []*netmap.Graph {
 	return l.graphs
 }
 
+// HistoryDatabase implements the System interface.
+func (l *LocalSystem) HistoryDatabase() *netmap.Graph {
+	return l.history
+}
+
 // Shutdown implements the System interface.
 func (l *LocalSystem) Shutdown() error {
 	if l.doneAlreadyClosed {
@@ -193,6 +279,16 @@ func (l *LocalSystem) Shutdown() error {
 	l.pool.Stop()
 	l.trusted.Stop()
 	l.cache = nil
+	if l.limits != nil {
+		_ = l.limits.Close()
+	}
+	if l.shared != nil {
+		l.shared.Close()
+	}
+	// Encrypt the local database once it is no longer in use
+	if l.vault != nil && l.sealPath != "" {
+		return l.vault.Seal(l.sealPath)
+	}
 	return nil
 }
 
@@ -221,10 +317,21 @@ func (l *LocalSystem) setupGraphDBs(cfg *config.Config) error {
 			var g *netmap.Graph
 
 			if db.System == "local" {
-				g = netmap.NewGraph(db.System, filepath.Join(config.OutputDirectory(cfg.Dir), "amass.sqlite"), db.Options)
+				path := filepath.Join(config.OutputDirectory(cfg.Dir), "amass.sqlite")
+				if l.vault != nil {
+					if err := l.vault.Open(path); err != nil {
+						return err
+					}
+					l.sealPath = path
+				}
+				g = netmap.NewGraph(db.System, path, db.Options)
 			} else {
 				connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", db.Host, db.Port, db.Username, db.Password, db.DBName)
 				g = netmap.NewGraph(db.System, connStr, db.Options)
+				if g != nil && db.System == "postgres" {
+					l.pgDSN = connStr
+					l.createIndexes(cfg)
+				}
 			}
 
 			if g == nil {
@@ -242,6 +349,28 @@ func (l *LocalSystem) setupGraphDBs(cfg *config.Config) error {
 	return nil
 }
 
+// PostgresDSN returns the connection string of the primary graph database,
+// or an empty string when the graph database is not stored by PostgreSQL.
+func (l *LocalSystem) PostgresDSN() string {
+	return l.pgDSN
+}
+
+// createIndexes creates the indexes needed by the queries of the engine in the PostgreSQL graph database,
+// unless the postgres_indexes option is false. The failures are logged, since the queries still succeed.
+func (l *LocalSystem) createIndexes(cfg *config.Config) {
+	if enabled, ok := cfg.Options["postgres_indexes"].(bool); ok && !enabled {
+		return
+	}
+
+	created, err := dbindex.Setup(context.Background(), l.pgDSN)
+	for _, idx := range created {
+		cfg.Log.Printf("Created the %s index on the %s table for %s", idx.Name, idx.Table, idx.Reason)
+	}
+	if err != nil {
+		cfg.Log.Printf("Failed to create the indexes of the graph database: %v", err)
+	}
+}
+
 // GetMemoryUsage returns the number bytes allocated to heap objects on this system.
 func (l *LocalSystem) GetMemoryUsage() uint64 {
 	var m runtime.MemStats
@@ -301,7 +430,8 @@ func trustedResolvers(cfg *config.Config) (*resolve.Resolvers, int) {
 		trusted = cfg.TrustedResolvers
 	}
 
-	_ = pool.AddResolvers(cfg.TrustedQPS, trusted...)
+	// The resolvers next to each other in the pool are selected together
+	_ = pool.AddResolvers(cfg.TrustedQPS, Shuffle(cfg, resolverSeedSalt, trusted)...)
 	pool.SetDetectionResolver(cfg.TrustedQPS, "8.8.8.8")
 
 	pool.SetLogger(cfg.Log)
@@ -317,7 +447,7 @@ func untrustedResolvers(cfg *config.Config) (*resolve.Resolvers, int) {
 			cfg.Resolvers = config.DefaultBaselineResolvers
 		}
 	}
-	cfg.Resolvers = checkAddresses(cfg.Resolvers)
+	cfg.Resolvers = Shuffle(cfg, resolverSeedSalt, checkAddresses(cfg.Resolvers))
 
 	pool := resolve.NewResolvers()
 	pool.SetLogger(cfg.Log)
@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// Provides the resolver ordering with a sequence of random numbers different from other components.
+const resolverSeedSalt = 3
+
+// SetRandomSeed selects the seed of the run from the seed option. When the option is not provided,
+// a seed is selected and saved in the configuration, so it can be reported and used to reproduce the run.
+// The components derive their own sources from the seed with NewRand, such as the resolver ordering,
+// the data source scripts and the scan ordering, rather than sharing the global source.
+func SetRandomSeed(cfg *config.Config) int64 {
+	seed, ok := optionSeed(cfg)
+	if !ok {
+		seed = time.Now().UnixNano()
+		cfg.Options["seed"] = seed
+	}
+	return seed
+}
+
+// NewRand returns a source of random numbers derived from the seed of the run. The
+// salt provides each component with a different sequence from the same seed.
+func NewRand(cfg *config.Config, salt int64) *rand.Rand {
+	seed, ok := optionSeed(cfg)
+	if !ok {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed + salt))
+}
+
+// Randomized returns true when the randomize option is enabled, so the order of the DNS resolvers, the
+// wordlists and the data sources, and the timing between the data source requests, vary between the runs
+// rather than following a pattern that identifies the tool in the telemetry of the targets.
+func Randomized(cfg *config.Config) bool {
+	v, ok := cfg.Options["randomize"].(bool)
+	return ok && v
+}
+
+// Shuffle returns the strings in a random order when the randomize option is enabled, using the salt
+// to obtain a sequence derived from the seed of the run, and the strings unchanged otherwise.
+func Shuffle(cfg *config.Config, salt int64, list []string) []string {
+	if !Randomized(cfg) || len(list) < 2 {
+		return list
+	}
+
+	shuffled := make([]string, len(list))
+	copy(shuffled, list)
+	rnd := NewRand(cfg, salt)
+	rnd.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+func optionSeed(cfg *config.Config) (int64, bool) {
+	switch v := cfg.Options["seed"].(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,55 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestRandomSeed(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options["seed"] = 42
+
+	if seed := SetRandomSeed(cfg); seed != 42 {
+		t.Errorf("Expected the seed 42, got %d", seed)
+	}
+	if a, b := NewRand(cfg, 1).Int63(), NewRand(cfg, 1).Int63(); a != b {
+		t.Errorf("The same seed and salt provided different sequences")
+	}
+	if a, b := NewRand(cfg, 1).Int63(), NewRand(cfg, 2).Int63(); a == b {
+		t.Errorf("Different salts provided the same sequence")
+	}
+
+	cfg = config.NewConfig()
+	seed := SetRandomSeed(cfg)
+	if v, ok := cfg.Options["seed"].(int64); !ok || v != seed {
+		t.Errorf("The selected seed was not saved in the configuration")
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options["seed"] = 42
+
+	list := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	if shuffled := Shuffle(cfg, 1, list); &shuffled[0] != &list[0] {
+		t.Error("Expected the list to be unchanged without the randomize option")
+	}
+
+	cfg.Options["randomize"] = true
+	shuffled := Shuffle(cfg, 1, list)
+	if len(shuffled) != len(list) || list[0] != "a" {
+		t.Fatalf("Unexpected shuffle of the list: %v", shuffled)
+	}
+	if again := Shuffle(cfg, 1, list); strings.Join(again, "") != strings.Join(shuffled, "") {
+		t.Errorf("The same seed and salt provided different orders: %v and %v", shuffled, again)
+	}
+	if strings.Join(shuffled, "") == strings.Join(list, "") {
+		t.Error("Expected the order to change")
+	}
+}
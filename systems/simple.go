@@ -19,6 +19,7 @@ type SimpleSystem struct {
 	Pool     *resolve.Resolvers
 	Trusted  *resolve.Resolvers
 	Graph    *netmap.Graph
+	History  *netmap.Graph
 	ASNCache *requests.ASNCache
 	Service  service.Service
 }
@@ -61,6 +62,9 @@ func (ss *SimpleSystem) SetDataSources(sources []service.Service) error {
 // GraphDatabases implements the System interface.
 func (ss *SimpleSystem) GraphDatabases() []*netmap.Graph { return []*netmap.Graph{ss.Graph} }
 
+// HistoryDatabase implements the System interface.
+func (ss *SimpleSystem) HistoryDatabase() *netmap.Graph { return ss.History }
+
 // Shutdown implements the System interface.
 func (ss *SimpleSystem) Shutdown() error {
 	if ss.Service != nil {
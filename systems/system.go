@@ -44,6 +44,10 @@ type System interface {
 	// GraphDatabases return the Graphs used by the System
 	GraphDatabases() []*netmap.Graph
 
+	// HistoryDatabase returns the shared Graph of the previous sessions, which is only read,
+	// or nil when the System was not configured with one
+	HistoryDatabase() *netmap.Graph
+
 	// GetMemoryUsage() returns the number bytes allocated to heap objects on this system
 	GetMemoryUsage() uint64
 
@@ -51,6 +55,34 @@ type System interface {
 	Shutdown() error
 }
 
+// The kinds of the failures preventing a System from starting.
+const (
+	KindConfig    = "config"
+	KindDatabase  = "database"
+	KindResolvers = "resolvers"
+	KindNetwork   = "network"
+	KindIO        = "io"
+)
+
+// Error is returned when a System cannot be started, so the callers can tell the kinds of failures apart.
+type Error struct {
+	Kind string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error that caused the failure.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func setupError(kind string, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
 // PopulateCache updates the provided System cache with ASN information from the System data sources.
 func PopulateCache(ctx context.Context, asn int, sys System) {
 	// Send the ASN requests to the data sources
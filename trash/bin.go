@@ -0,0 +1,172 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package trash
+
+import (
+	"fmt"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// Database is the subset of the graph database used by the cleanup operations.
+type Database interface {
+	FindById(id string, since time.Time) (*types.Asset, error)
+	FindByType(atype oam.AssetType, since time.Time) ([]*types.Asset, error)
+	IncomingRelations(asset *types.Asset, since time.Time, relationTypes ...string) ([]*types.Relation, error)
+	OutgoingRelations(asset *types.Asset, since time.Time, relationTypes ...string) ([]*types.Relation, error)
+	Create(source *types.Asset, relation string, discovered oam.Asset) (*types.Asset, error)
+	DeleteAsset(id string) error
+	DeleteRelation(id string) error
+}
+
+// Bin is the graph database used by a cleanup operation, which adds the assets and relations to the trash
+// before removing them. An asset or relation that cannot be added to the trash is not removed.
+type Bin struct {
+	Database
+	trash     *Trash
+	actor     string
+	operation string
+	reason    string
+	mu        sync.Mutex
+	relations map[string]*types.Relation
+}
+
+// NewBin returns the Bin adding the removals made by the operation to the trash, on behalf of the actor.
+func NewBin(db Database, t *Trash, operation, actor, reason string) *Bin {
+	return &Bin{
+		Database:  db,
+		trash:     t,
+		actor:     actor,
+		operation: operation,
+		reason:    reason,
+		relations: make(map[string]*types.Relation),
+	}
+}
+
+// CurrentActor returns the name of the user running the program, which the removals are attributed to.
+func CurrentActor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "unknown"
+}
+
+// SetReason replaces the reason recorded for the next removals, such as the issue repaired by a removal.
+func (b *Bin) SetReason(reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.reason = reason
+}
+
+// Known provides the relations loaded by the operation, since the graph database cannot find the
+// relations by their identifiers. Only the known relations can be removed using DeleteRelation.
+func (b *Bin) Known(rels []*types.Relation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, rel := range rels {
+		b.relations[rel.ID] = rel
+	}
+}
+
+// DeleteAsset adds the asset and its relations to the trash, and removes the asset from the graph database.
+func (b *Bin) DeleteAsset(id string) error {
+	a, err := b.FindById(id, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to read the asset %s before its removal: %v", id, err)
+	}
+
+	in, err := b.IncomingRelations(a, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to read the relations of the asset %s before its removal: %v", id, err)
+	}
+	out, err := b.OutgoingRelations(a, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to read the relations of the asset %s before its removal: %v", id, err)
+	}
+
+	var records []*format.RelationRecord
+	for _, rel := range append(in, out...) {
+		// The relations linking the assets already removed cannot be restored
+		if rec, err := b.relationRecord(rel); err == nil {
+			records = append(records, rec)
+		}
+	}
+
+	b.trash.Add(b.entry(format.NewAssetRecord(a), records))
+	return b.Database.DeleteAsset(id)
+}
+
+// DeleteRelation adds the relation to the trash, and removes it from the graph database. The relations
+// linking an asset no longer stored are added to the trash as well, but cannot be restored.
+func (b *Bin) DeleteRelation(id string) error {
+	b.mu.Lock()
+	rel, found := b.relations[id]
+	b.mu.Unlock()
+	if !found {
+		return fmt.Errorf("the relation %s was not loaded, so it cannot be added to the trash", id)
+	}
+
+	rec, err := b.relationRecord(rel)
+	if err != nil {
+		// The relations left behind by the assets already removed are kept by the identifiers
+		// of the missing assets, so the removal is recorded though it cannot be restored
+		rec = b.danglingRecord(rel)
+	}
+
+	b.trash.Add(b.entry(nil, []*format.RelationRecord{rec}))
+	return b.Database.DeleteRelation(id)
+}
+
+func (b *Bin) relationRecord(rel *types.Relation) (*format.RelationRecord, error) {
+	from, err := b.FindById(rel.FromAsset.ID, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the assets of the relation %s: %v", rel.ID, err)
+	}
+	to, err := b.FindById(rel.ToAsset.ID, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the assets of the relation %s: %v", rel.ID, err)
+	}
+	return format.NewRelationRecord(from, rel, to), nil
+}
+
+// danglingRecord returns the record of the relation using the assets still stored, and the identifiers
+// as the keys of the assets missing from the graph database.
+func (b *Bin) danglingRecord(rel *types.Relation) *format.RelationRecord {
+	record := func(id string) *format.AssetRecord {
+		if a, err := b.FindById(id, time.Time{}); err == nil {
+			return format.NewAssetRecord(a)
+		}
+		return &format.AssetRecord{Key: id}
+	}
+
+	return &format.RelationRecord{
+		From:      record(rel.FromAsset.ID),
+		Relation:  rel.Type,
+		To:        record(rel.ToAsset.ID),
+		CreatedAt: rel.CreatedAt,
+		LastSeen:  rel.LastSeen,
+	}
+}
+
+func (b *Bin) entry(asset *format.AssetRecord, rels []*format.RelationRecord) *Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return &Entry{
+		Deleted:   time.Now().UTC(),
+		Actor:     b.actor,
+		Operation: b.operation,
+		Reason:    b.reason,
+		Asset:     asset,
+		Relations: rels,
+	}
+}
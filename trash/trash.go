@@ -0,0 +1,228 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package trash keeps the assets and relations removed from the graph database by the cleanup operations,
+// along with who removed them, when and why, so the removals made by mistake can be restored. The graph
+// database cannot flag the assets as deleted, so the content of each asset and relation is kept in the
+// trash file until the entries are purged, which cannot be undone.
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// FileName is the name of the file in the output directory that stores the trash.
+const FileName = "trash.json"
+
+// Entry is an asset or relation removed from the graph database. The entries removing an asset also keep
+// the relations of the asset, so restoring the asset links it again to the other assets.
+type Entry struct {
+	ID        int       `json:"id"`
+	Deleted   time.Time `json:"deleted"`
+	Actor     string    `json:"actor"`
+	Operation string    `json:"operation"`
+	Reason    string    `json:"reason,omitempty"`
+	// Asset is the asset removed, or nil when the entry only removes a relation
+	Asset     *format.AssetRecord      `json:"asset,omitempty"`
+	Relations []*format.RelationRecord `json:"relations,omitempty"`
+	Restored  *time.Time               `json:"restored,omitempty"`
+}
+
+// Subject returns a short description of the asset or relation removed.
+func (e *Entry) Subject() string {
+	if e.Asset != nil {
+		return e.Asset.Type + ":" + e.Asset.Key
+	}
+	if len(e.Relations) > 0 {
+		rel := e.Relations[0]
+		return rel.From.Key + " " + rel.Relation + " " + rel.To.Key
+	}
+	return ""
+}
+
+// Trash is the set of entries removed from the graph database.
+type Trash struct {
+	sync.Mutex
+	// LastID is the identifier of the last entry added, so the identifiers of the purged entries are not reused
+	LastID  int      `json:"last_id"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Read returns the trash stored in the file, or an empty Trash when the file does not exist.
+func Read(path string) (*Trash, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return new(Trash), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	t := new(Trash)
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("failed to parse the trash file %s: %v", path, err)
+	}
+	return t, nil
+}
+
+// Write stores the trash in the file.
+func (t *Trash) Write(path string) error {
+	t.Lock()
+	data, err := json.MarshalIndent(t, "", "  ")
+	t.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Add assigns the entry the next identifier and adds it to the trash.
+func (t *Trash) Add(e *Entry) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.LastID++
+	e.ID = t.LastID
+	t.Entries = append(t.Entries, e)
+}
+
+// Get returns the entry with the identifier.
+func (t *Trash) Get(id int) (*Entry, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, e := range t.Entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%d is not an entry of the trash", id)
+}
+
+// Purge permanently removes the entries that were deleted before the provided time, or the entries with
+// the identifiers when provided, and returns the entries removed. The purged entries cannot be restored.
+func (t *Trash) Purge(before time.Time, ids ...int) []*Entry {
+	t.Lock()
+	defer t.Unlock()
+
+	selected := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		selected[id] = struct{}{}
+	}
+
+	var purged []*Entry
+	kept := t.Entries[:0]
+	for _, e := range t.Entries {
+		_, found := selected[e.ID]
+		if (len(ids) > 0 && found) || (len(ids) == 0 && e.Deleted.Before(before)) {
+			purged = append(purged, e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	t.Entries = kept
+
+	sort.Slice(purged, func(i, j int) bool { return purged[i].ID < purged[j].ID })
+	return purged
+}
+
+// RestoreDatabase is the subset of the graph database used to restore the entries.
+type RestoreDatabase interface {
+	Create(source *types.Asset, relation string, discovered oam.Asset) (*types.Asset, error)
+}
+
+// Restore stores the asset and the relations of the entry in the graph database again, and marks the entry
+// as restored. The restored assets are given new identifiers by the graph database.
+func Restore(db RestoreDatabase, e *Entry) error {
+	if e.Restored != nil {
+		return fmt.Errorf("the entry %d was already restored", e.ID)
+	}
+
+	if e.Asset != nil {
+		a, err := Asset(e.Asset)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Create(nil, "", a); err != nil {
+			return fmt.Errorf("failed to restore %s: %v", e.Subject(), err)
+		}
+	}
+
+	for _, rel := range e.Relations {
+		from, err := Asset(rel.From)
+		if err != nil {
+			return err
+		}
+		to, err := Asset(rel.To)
+		if err != nil {
+			return err
+		}
+
+		src, err := db.Create(nil, "", from)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s:%s: %v", rel.From.Type, rel.From.Key, err)
+		}
+		if _, err := db.Create(src, rel.Relation, to); err != nil {
+			return fmt.Errorf("failed to restore the %s relation of %s: %v", rel.Relation, rel.From.Key, err)
+		}
+	}
+
+	now := time.Now().UTC()
+	e.Restored = &now
+	return nil
+}
+
+// Asset returns the asset stored by the record.
+func Asset(rec *format.AssetRecord) (oam.Asset, error) {
+	if rec == nil || len(rec.Asset) == 0 {
+		return nil, errors.New("the record does not provide the content of the asset")
+	}
+
+	var a oam.Asset
+	var err error
+	switch oam.AssetType(rec.Type) {
+	case oam.FQDN:
+		var v domain.FQDN
+		err = json.Unmarshal(rec.Asset, &v)
+		a = v
+	case oam.IPAddress:
+		var v network.IPAddress
+		err = json.Unmarshal(rec.Asset, &v)
+		a = v
+	case oam.Netblock:
+		var v network.Netblock
+		err = json.Unmarshal(rec.Asset, &v)
+		a = v
+	case oam.ASN:
+		var v network.AutonomousSystem
+		err = json.Unmarshal(rec.Asset, &v)
+		a = v
+	case oam.RIROrg:
+		var v network.RIROrganization
+		err = json.Unmarshal(rec.Asset, &v)
+		a = v
+	default:
+		return nil, fmt.Errorf("the %s asset type cannot be restored", rec.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the %s asset %s: %v", rec.Type, rec.Key, err)
+	}
+	return a, nil
+}
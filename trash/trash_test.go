@@ -0,0 +1,104 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package trash
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/assettest"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+func TestBin(t *testing.T) {
+	g := assettest.NewGraph(t)
+	db := g.DB
+	www, addr := assettest.Link(t, g, assettest.FQDN("www.owasp.org"), "a_record", assettest.IP("192.0.2.1"))
+	_, _ = assettest.Link(t, g, assettest.FQDN("www.owasp.org"), "cname_record", assettest.FQDN("owasp.org"))
+
+	rels, err := db.OutgoingRelations(www, time.Time{}, "cname_record")
+	if err != nil || len(rels) != 1 {
+		t.Fatalf("Failed to read the relation: %v", err)
+	}
+	cname := rels[0]
+
+	tr := new(Trash)
+	bin := NewBin(db, tr, "report -repair", "jdoe", "orphaned assets")
+	if err := bin.DeleteRelation(cname.ID); err == nil {
+		t.Error("Expected an error for the relation that was not loaded")
+	}
+	bin.Known([]*types.Relation{cname})
+	if err := bin.DeleteRelation(cname.ID); err != nil {
+		t.Fatalf("Failed to delete the relation: %v", err)
+	}
+	if err := bin.DeleteAsset(addr.ID); err != nil {
+		t.Fatalf("Failed to delete the asset: %v", err)
+	}
+	if a, err := db.FindById(addr.ID, time.Time{}); err == nil && a != nil {
+		t.Error("The asset was not removed from the graph database")
+	}
+
+	if len(tr.Entries) != 2 {
+		t.Fatalf("Expected two entries, got %d", len(tr.Entries))
+	}
+	e := tr.Entries[1]
+	if e.ID != 2 || e.Actor != "jdoe" || e.Operation != "report -repair" || e.Subject() != "IPAddress:192.0.2.1" || len(e.Relations) != 1 {
+		t.Errorf("Unexpected entry: %+v", e)
+	}
+	if s := tr.Entries[0].Subject(); s != "www.owasp.org cname_record owasp.org" {
+		t.Errorf("Unexpected subject of the relation: %s", s)
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := tr.Write(path); err != nil {
+		t.Fatalf("Failed to write the trash: %v", err)
+	}
+	tr, err = Read(path)
+	if err != nil || len(tr.Entries) != 2 {
+		t.Fatalf("Failed to read the trash: %v", err)
+	}
+
+	e, _ = tr.Get(2)
+	if err := Restore(db, e); err != nil {
+		t.Fatalf("Failed to restore the entry: %v", err)
+	}
+	if e.Restored == nil || Restore(db, e) == nil {
+		t.Error("The entry was not marked as restored")
+	}
+	restored, _ := db.FindByType(oam.IPAddress, time.Time{})
+	if len(restored) != 1 {
+		t.Fatalf("Expected the address to be restored, got %d addresses", len(restored))
+	}
+	if in, _ := db.IncomingRelations(restored[0], time.Time{}); len(in) != 1 || in[0].Type != "a_record" {
+		t.Errorf("The relation of the address was not restored: %v", in)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Now()
+	tr := new(Trash)
+	for _, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, time.Hour} {
+		tr.Add(&Entry{Deleted: now.Add(-age)})
+	}
+
+	if purged := tr.Purge(now.Add(-24 * time.Hour)); len(purged) != 2 || purged[0].ID != 1 {
+		t.Errorf("Unexpected entries purged by age: %+v", purged)
+	}
+	tr.Add(&Entry{Deleted: now})
+	if tr.Entries[1].ID != 4 {
+		t.Errorf("Expected the next identifier, got %d", tr.Entries[1].ID)
+	}
+	if purged := tr.Purge(time.Time{}, 4); len(purged) != 1 || len(tr.Entries) != 1 || tr.Entries[0].ID != 3 {
+		t.Errorf("Unexpected entries after purging the identifier: %+v", tr.Entries)
+	}
+	if tr.Add(&Entry{Deleted: now}); tr.Entries[1].ID != 5 {
+		t.Errorf("Expected the identifier of the purged entry not to be reused, got %d", tr.Entries[1].ID)
+	}
+	if _, err := tr.Get(4); err == nil {
+		t.Error("Expected an error for the purged entry")
+	}
+}
@@ -0,0 +1,468 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package update replaces the Amass binary with a release from the selected channel, after
+// verifying the signature of the release checksums and the compatibility of the versions.
+package update
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultReleasesURL is the GitHub API endpoint listing the Amass releases.
+const DefaultReleasesURL = "https://api.github.com/repos/owasp-amass/amass/releases"
+
+// The release channels.
+const (
+	// ChannelStable only provides the releases that are not marked as prereleases
+	ChannelStable = "stable"
+	// ChannelBeta also provides the prereleases
+	ChannelBeta = "beta"
+)
+
+// The names of the release assets used to verify the archives.
+const (
+	ChecksumsName = "amass_checksums.txt"
+	SignatureName = ChecksumsName + ".sig"
+)
+
+// Limits the size of the downloaded release assets.
+const maxDownload = 256 << 20
+
+// Settings are provided by the update option of the configuration.
+type Settings struct {
+	// Channel is the release channel that updates are installed from
+	Channel string `yaml:"channel,omitempty"`
+	// PublicKey is the ed25519 public key, or the path to the file providing it, that signs the release checksums
+	PublicKey string `yaml:"public_key,omitempty"`
+	// URL lists the releases, such as a mirror of the GitHub releases API
+	URL string `yaml:"url,omitempty"`
+}
+
+// ParseSettings returns the Settings for the update option, with the stable channel and
+// the GitHub releases selected by default.
+func ParseSettings(raw interface{}) (*Settings, error) {
+	s := &Settings{}
+
+	if raw != nil {
+		data, err := yaml.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the update settings: %v", err)
+		}
+		if err := yaml.Unmarshal(data, s); err != nil {
+			return nil, errors.New("the update option must be a map of the update settings")
+		}
+	}
+
+	s.Channel = strings.ToLower(strings.TrimSpace(s.Channel))
+	if s.Channel == "" {
+		s.Channel = ChannelStable
+	}
+	if s.Channel != ChannelStable && s.Channel != ChannelBeta {
+		return nil, fmt.Errorf("%s is not a valid release channel", s.Channel)
+	}
+	if s.URL == "" {
+		s.URL = DefaultReleasesURL
+	}
+	return s, nil
+}
+
+// Release is a published Amass release.
+type Release struct {
+	Version    string   `json:"tag_name"`
+	Prerelease bool     `json:"prerelease"`
+	Draft      bool     `json:"draft"`
+	Assets     []*Asset `json:"assets"`
+}
+
+// Asset is a file published with a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version is a semantic version, such as v4.2.0 or v4.3.0-beta.1.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+// ParseVersion returns the Version for the version string, with or without the leading v.
+func ParseVersion(s string) (*Version, error) {
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	var v Version
+	if i := strings.IndexAny(core, "-+"); i != -1 {
+		if core[i] == '-' {
+			v.Pre = strings.SplitN(core[i+1:], "+", 2)[0]
+		}
+		core = core[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%s is not a valid version", s)
+	}
+
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s is not a valid version", s)
+		}
+		nums[i] = n
+	}
+
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return &v, nil
+}
+
+// Compare returns -1, 0 or 1 when the version is older than, the same as, or newer than o.
+// A prerelease is older than the release of the same version.
+func (v *Version) Compare(o *Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		} else if d > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	return comparePre(strings.Split(v.Pre, "."), strings.Split(o.Pre, "."))
+}
+
+// comparePre orders the prerelease identifiers, comparing the numeric identifiers numerically,
+// so beta.10 is newer than beta.9.
+func comparePre(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+
+		x, xerr := strconv.Atoi(a[i])
+		y, yerr := strconv.Atoi(b[i])
+		switch {
+		case xerr == nil && yerr == nil && x < y:
+			return -1
+		case xerr == nil && yerr == nil:
+			return 1
+		case xerr == nil:
+			return -1
+		case yerr == nil:
+			return 1
+		case a[i] < b[i]:
+			return -1
+		}
+		return 1
+	}
+
+	if len(a) < len(b) {
+		return -1
+	} else if len(a) > len(b) {
+		return 1
+	}
+	return 0
+}
+
+// String implements the fmt.Stringer interface.
+func (v *Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// CheckCompatible returns an error when the candidate release cannot replace the current version.
+// Releases with another major version change the configuration and graph database formats, so
+// they are only permitted when allowMajor is true. Older releases are never installed.
+func CheckCompatible(current, candidate string, allowMajor bool) error {
+	cur, err := ParseVersion(current)
+	if err != nil {
+		return err
+	}
+
+	cand, err := ParseVersion(candidate)
+	if err != nil {
+		return err
+	}
+
+	if cand.Compare(cur) <= 0 {
+		return fmt.Errorf("%s is not newer than the installed version %s", cand, cur)
+	}
+	if cand.Major != cur.Major && !allowMajor {
+		return fmt.Errorf("%s is a new major version, which is not compatible with the configuration and graph database of %s", cand, cur)
+	}
+	return nil
+}
+
+// Latest returns the newest release from the channel that is listed by the releases URL.
+func Latest(ctx context.Context, client *http.Client, url, channel string) (*Release, error) {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return nil, fmt.Errorf("%s is not a valid release channel", channel)
+	}
+
+	data, err := download(ctx, client, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the releases: %v", err)
+	}
+
+	var releases []*Release
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse the releases: %v", err)
+	}
+
+	var latest *Release
+	var version *Version
+	for _, rel := range releases {
+		if rel.Draft || (rel.Prerelease && channel != ChannelBeta) {
+			continue
+		}
+
+		v, err := ParseVersion(rel.Version)
+		if err != nil {
+			continue
+		}
+		if version == nil || v.Compare(version) > 0 {
+			latest, version = rel, v
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no releases were found in the %s channel", channel)
+	}
+	return latest, nil
+}
+
+// ArchiveName returns the name of the release archive built for the operating system and architecture.
+func ArchiveName(goos, goarch string) string {
+	if goarch == "386" {
+		goarch = "i386"
+	}
+	if goos != "" {
+		goos = strings.ToUpper(goos[:1]) + goos[1:]
+	}
+	return fmt.Sprintf("amass_%s_%s.zip", goos, goarch)
+}
+
+// Asset returns the release asset with the name.
+func (r *Release) Asset(name string) *Asset {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Fetch downloads the archive of the release for the operating system and architecture, verifies the signature
+// of the release checksums using the public key and the checksum of the archive, and returns the Amass binary.
+func Fetch(ctx context.Context, client *http.Client, rel *Release, goos, goarch string, pub ed25519.PublicKey) ([]byte, error) {
+	name := ArchiveName(goos, goarch)
+
+	archive := rel.Asset(name)
+	if archive == nil {
+		return nil, fmt.Errorf("%s does not provide a build for %s/%s", rel.Version, goos, goarch)
+	}
+
+	sums, sig := rel.Asset(ChecksumsName), rel.Asset(SignatureName)
+	if sums == nil || sig == nil {
+		return nil, fmt.Errorf("%s does not provide signed checksums", rel.Version)
+	}
+
+	var files [3][]byte
+	for i, a := range []*Asset{sums, sig, archive} {
+		data, err := download(ctx, client, a.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %v", a.Name, err)
+		}
+		files[i] = data
+	}
+
+	if err := VerifySignature(files[0], files[1], pub); err != nil {
+		return nil, err
+	}
+
+	expected, err := Checksum(files[0], name)
+	if err != nil {
+		return nil, err
+	}
+	if sum := sha256.Sum256(files[2]); hex.EncodeToString(sum[:]) != expected {
+		return nil, fmt.Errorf("the checksum of %s does not match the signed checksums", name)
+	}
+
+	bin := "amass"
+	if goos == "windows" {
+		bin += ".exe"
+	}
+	return ExtractBinary(files[2], bin)
+}
+
+// VerifySignature checks the ed25519 signature of the checksums, which is either the raw
+// signature or its base64 encoding.
+func VerifySignature(checksums, sig []byte, pub ed25519.PublicKey) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return errors.New("the release signing key is required to verify the release")
+	}
+
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return errors.New("the signature of the release checksums is not valid")
+		}
+		sig = decoded
+	}
+	if !ed25519.Verify(pub, checksums, sig) {
+		return errors.New("the release checksums were not signed by the release signing key")
+	}
+	return nil
+}
+
+// Checksum returns the SHA-256 checksum of the named file from the checksums file.
+func Checksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("the release checksums do not include %s", name)
+}
+
+// ExtractBinary returns the named binary from the zip archive.
+func ExtractBinary(archive []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the release archive: %v", err)
+	}
+
+	for _, f := range zr.File {
+		if path.Base(f.Name) != name || f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = rc.Close() }()
+
+		return io.ReadAll(io.LimitReader(rc, maxDownload))
+	}
+	return nil, fmt.Errorf("the release archive does not contain %s", name)
+}
+
+// ParsePublicKey returns the ed25519 public key that is either PEM-encoded, such as the key produced
+// by 'openssl pkey -pubout', or the base64 encoding of the raw key. A path to a file providing the
+// key is also accepted.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if data, err := os.ReadFile(s); err == nil {
+		s = strings.TrimSpace(string(data))
+	}
+
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the release signing key: %v", err)
+		}
+		if pub, ok := key.(ed25519.PublicKey); ok {
+			return pub, nil
+		}
+		return nil, errors.New("the release signing key is not an ed25519 key")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("the release signing key must be a PEM-encoded or base64 ed25519 public key")
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Install replaces the executable with the binary. The previous executable is renamed before
+// the new one is moved into place, since running executables cannot be overwritten on all platforms.
+func Install(exe string, bin []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, ".amass-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary: %v", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = tmp.Write(bin); err == nil {
+		err = tmp.Chmod(info.Mode().Perm() | 0111)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary: %v", err)
+	}
+
+	old := exe + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return fmt.Errorf("failed to replace the binary: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		_ = os.Rename(old, exe)
+		return fmt.Errorf("failed to replace the binary: %v", err)
+	}
+	// The previous executable cannot be removed while it is running on Windows
+	_ = os.Remove(old)
+	return nil
+}
+
+func download(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDownload))
+}
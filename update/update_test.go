@@ -0,0 +1,196 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package update
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"v4.2.0", "v4.2.0", 0},
+		{"v4.2.1", "v4.2.0", 1},
+		{"4.10.0", "v4.9.3", 1},
+		{"v4.3.0-beta.1", "v4.3.0", -1},
+		{"v4.3.0-beta.10", "v4.3.0-beta.9", 1},
+		{"v4.3.0-alpha", "v4.3.0-beta", -1},
+	} {
+		a, _ := ParseVersion(tc.a)
+		b, _ := ParseVersion(tc.b)
+		if got := a.Compare(b); got != tc.want {
+			t.Errorf("Comparing %s and %s: expected %d, got %d", tc.a, tc.b, tc.want, got)
+		}
+	}
+
+	if _, err := ParseVersion("v4.2"); err == nil {
+		t.Error("Expected an error for the incomplete version")
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	if err := CheckCompatible("v4.2.0", "v4.3.0", false); err != nil {
+		t.Errorf("Expected the minor release to be compatible: %v", err)
+	}
+	if err := CheckCompatible("v4.2.0", "v5.0.0", false); err == nil {
+		t.Error("Expected the major release to be rejected")
+	}
+	if err := CheckCompatible("v4.2.0", "v5.0.0", true); err != nil {
+		t.Errorf("Expected the major release to be permitted: %v", err)
+	}
+	if err := CheckCompatible("v4.2.0", "v4.1.0", true); err == nil {
+		t.Error("Expected the older release to be rejected")
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings(nil)
+	if err != nil || s.Channel != ChannelStable || s.URL != DefaultReleasesURL {
+		t.Errorf("Unexpected default settings: %+v %v", s, err)
+	}
+	if s, err := ParseSettings(map[string]interface{}{"channel": "Beta"}); err != nil || s.Channel != ChannelBeta {
+		t.Errorf("Expected the beta channel: %v", err)
+	}
+	if _, err := ParseSettings(map[string]interface{}{"channel": "nightly"}); err == nil {
+		t.Error("Expected an error for the unknown channel")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	binary := []byte("new amass binary")
+	archive := testArchive(t, "amass_Linux_amd64/amass", binary)
+
+	sum := sha256.Sum256(archive)
+	checksums := []byte(fmt.Sprintf("%s  amass_Linux_amd64.zip\n", hex.EncodeToString(sum[:])))
+	files := map[string][]byte{
+		"/amass_Linux_amd64.zip": archive,
+		"/" + ChecksumsName:      checksums,
+		"/" + SignatureName:      ed25519.Sign(priv, checksums),
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/releases" {
+			_ = json.NewEncoder(w).Encode([]*Release{
+				{Version: "v4.3.0", Assets: testAssets(srv.URL)},
+				{Version: "v4.4.0-beta.1", Prerelease: true, Assets: testAssets(srv.URL)},
+				{Version: "v4.5.0", Draft: true},
+			})
+			return
+		}
+		if data, found := files[r.URL.Path]; found {
+			_, _ = w.Write(data)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	rel, err := Latest(ctx, srv.Client(), srv.URL+"/releases", ChannelStable)
+	if err != nil || rel.Version != "v4.3.0" {
+		t.Fatalf("Expected the stable release v4.3.0: %v", err)
+	}
+	if beta, err := Latest(ctx, srv.Client(), srv.URL+"/releases", ChannelBeta); err != nil || beta.Version != "v4.4.0-beta.1" {
+		t.Errorf("Expected the prerelease in the beta channel: %v", err)
+	}
+	if _, err := Latest(ctx, srv.Client(), srv.URL+"/releases", "nightly"); err == nil {
+		t.Error("Expected an error for the unknown channel")
+	}
+
+	bin, err := Fetch(ctx, srv.Client(), rel, "linux", "amd64", pub)
+	if err != nil || !bytes.Equal(bin, binary) {
+		t.Fatalf("Failed to fetch the binary: %v", err)
+	}
+	if _, err := Fetch(ctx, srv.Client(), rel, "plan9", "amd64", pub); err == nil {
+		t.Error("Expected an error for the unsupported platform")
+	}
+
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := Fetch(ctx, srv.Client(), rel, "linux", "amd64", other); err == nil {
+		t.Error("Expected an error for the checksums signed by another key")
+	}
+
+	files["/amass_Linux_amd64.zip"] = testArchive(t, "amass_Linux_amd64/amass", []byte("tampered binary"))
+	if _, err := Fetch(ctx, srv.Client(), rel, "linux", "amd64", pub); err == nil {
+		t.Error("Expected an error for the tampered archive")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	encoded := base64.StdEncoding.EncodeToString(pub)
+
+	if key, err := ParsePublicKey(encoded); err != nil || !key.Equal(pub) {
+		t.Errorf("Failed to parse the base64 key: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "release.pub")
+	_ = os.WriteFile(path, []byte(encoded+"\n"), 0600)
+	if key, err := ParsePublicKey(path); err != nil || !key.Equal(pub) {
+		t.Errorf("Failed to parse the key file: %v", err)
+	}
+	if _, err := ParsePublicKey("not a key"); err == nil {
+		t.Error("Expected an error for the invalid key")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "amass")
+	if err := os.WriteFile(exe, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Install(exe, []byte("new binary")); err != nil {
+		t.Fatalf("Failed to install the binary: %v", err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "new binary" {
+		t.Errorf("Unexpected binary: %s", data)
+	}
+	if info, _ := os.Stat(exe); info.Mode().Perm()&0100 == 0 {
+		t.Error("The new binary is not executable")
+	}
+}
+
+func testArchive(t *testing.T, name string, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testAssets(base string) []*Asset {
+	var assets []*Asset
+
+	for _, name := range []string{"amass_Linux_amd64.zip", ChecksumsName, SignatureName} {
+		assets = append(assets, &Asset{Name: name, URL: base + "/" + name})
+	}
+	return assets
+}
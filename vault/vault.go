@@ -0,0 +1,319 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package vault encrypts the local database files at rest, so the collected data is protected
+// when the device storing it is lost. The files are decrypted while Amass is using them.
+package vault
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Ext is appended to the path of the encrypted database file.
+const Ext = ".enc"
+
+// PassphraseEnv is the environment variable providing the passphrase when the configuration does not.
+const PassphraseEnv = "AMASS_DB_PASSPHRASE"
+
+const (
+	magic     = "AMASSDB1"
+	saltSize  = 16
+	chunkSize = 1 << 20
+)
+
+// Files that SQLite keeps next to the database, which contain plaintext records.
+var sidecars = []string{"-journal", "-wal", "-shm"}
+
+// Vault holds the secret used to encrypt and decrypt the database files.
+type Vault struct {
+	secret []byte
+}
+
+// ParseSettings returns the Vault for the database_encryption option, which is either the path to a
+// key file or a map providing the passphrase or key_file. When the option is true or the map provides
+// neither, the passphrase is read from the AMASS_DB_PASSPHRASE environment variable. A nil Vault is
+// returned when the option is not provided or false.
+func ParseSettings(raw interface{}) (*Vault, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	var m map[string]interface{}
+	switch v := raw.(type) {
+	case bool:
+		if !v {
+			return nil, nil
+		}
+		m = map[string]interface{}{}
+	case string:
+		m = map[string]interface{}{"key_file": v}
+	case map[string]interface{}:
+		m = v
+	default:
+		return nil, errors.New("the database_encryption option must be a key file path or a map")
+	}
+
+	for _, key := range []string{"passphrase", "key_file"} {
+		if v, found := m[key]; found {
+			if _, ok := v.(string); !ok {
+				return nil, fmt.Errorf("the database_encryption %s must be a string", key)
+			}
+		}
+	}
+
+	if path, _ := m["key_file"].(string); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the database key file: %v", err)
+		}
+		return New(bytes.TrimSpace(data))
+	}
+	if pass, _ := m["passphrase"].(string); pass != "" {
+		return New([]byte(pass))
+	}
+	if pass := strings.TrimSpace(os.Getenv(PassphraseEnv)); pass != "" {
+		return New([]byte(pass))
+	}
+	return nil, fmt.Errorf("database encryption requires a passphrase, a key file or the %s environment variable", PassphraseEnv)
+}
+
+// New returns the Vault for the secret, which is a passphrase or the contents of a key file.
+func New(secret []byte) (*Vault, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("the database encryption secret cannot be empty")
+	}
+	return &Vault{secret: secret}, nil
+}
+
+// Open prepares the database file at path for use. When the encrypted file exists and the plaintext
+// file does not, the encrypted file is decrypted in its place. A plaintext file left by an execution
+// that did not shut down cleanly is newer than the encrypted file, so it is used instead.
+func (v *Vault) Open(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(path + Ext)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open the encrypted database: %v", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create the database file: %v", err)
+	}
+
+	w := bufio.NewWriter(out)
+	if err = v.Decrypt(w, bufio.NewReader(in)); err == nil {
+		err = w.Flush()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to decrypt the database: %v", err)
+	}
+	return nil
+}
+
+// Seal encrypts the database file at path and removes the plaintext file and those kept next to it.
+func (v *Vault) Seal(path string) error {
+	in, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open the database: %v", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	// The previous encrypted file is only replaced once the new one has been written
+	tmp := path + Ext + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create the encrypted database: %v", err)
+	}
+
+	w := bufio.NewWriter(out)
+	if err = v.Encrypt(w, in); err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+Ext)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to encrypt the database: %v", err)
+	}
+
+	_ = in.Close()
+	for _, suffix := range append([]string{""}, sidecars...) {
+		if err := os.Remove(path + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove the plaintext database: %v", err)
+		}
+	}
+	return nil
+}
+
+// Encrypt writes the contents of src to dst using AES-256-GCM. The key is derived from the secret
+// using scrypt and a random salt. The data is sealed in chunks, and the last chunk is marked, so
+// reordered or truncated files are detected.
+func (v *Vault) Encrypt(dst io.Writer, src io.Reader) error {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	aead, err := v.cipher(salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	header := append(append([]byte(magic), salt...), nonce...)
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(src)
+	buf := make([]byte, chunkSize)
+	for count := uint64(0); ; count++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		last := err != nil
+		if !last {
+			// A full chunk is the last one when nothing follows it
+			if _, perr := r.Peek(1); perr == io.EOF {
+				last = true
+			} else if perr != nil {
+				return perr
+			}
+		}
+
+		sealed := aead.Seal(nil, chunkNonce(nonce, count), buf[:n], chunkData(header, count, last))
+		size := make([]byte, 4)
+		binary.BigEndian.PutUint32(size, uint32(len(sealed)))
+		if _, err := dst.Write(append(size, sealed...)); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// Decrypt writes the contents of the data encrypted by Encrypt to dst.
+func (v *Vault) Decrypt(dst io.Writer, src io.Reader) error {
+	header := make([]byte, len(magic)+saltSize)
+	if _, err := io.ReadFull(src, header); err != nil || string(header[:len(magic)]) != magic {
+		return errors.New("the file is not an encrypted database")
+	}
+
+	aead, err := v.cipher(header[len(magic):])
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(src, nonce); err != nil {
+		return errors.New("the encrypted database is truncated")
+	}
+	header = append(header, nonce...)
+
+	size := make([]byte, 4)
+	for count := uint64(0); ; count++ {
+		if _, err := io.ReadFull(src, size); err != nil {
+			return errors.New("the encrypted database is truncated")
+		}
+
+		n := binary.BigEndian.Uint32(size)
+		if n > chunkSize+uint32(aead.Overhead()) {
+			return errors.New("the encrypted database is corrupted")
+		}
+
+		sealed := make([]byte, n)
+		if _, err := io.ReadFull(src, sealed); err != nil {
+			return errors.New("the encrypted database is truncated")
+		}
+
+		last := true
+		plain, err := aead.Open(nil, chunkNonce(nonce, count), sealed, chunkData(header, count, last))
+		if err != nil {
+			last = false
+			plain, err = aead.Open(nil, chunkNonce(nonce, count), sealed, chunkData(header, count, last))
+		}
+		if err != nil {
+			return errors.New("the passphrase or key file cannot decrypt the database")
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+func (v *Vault) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(v.secret, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(base []byte, count uint64) []byte {
+	nonce := make([]byte, len(base))
+	copy(nonce, base)
+
+	ctr := make([]byte, 8)
+	binary.BigEndian.PutUint64(ctr, count)
+	for i := range ctr {
+		nonce[len(nonce)-8+i] ^= ctr[i]
+	}
+	return nonce
+}
+
+func chunkData(header []byte, count uint64, last bool) []byte {
+	data := make([]byte, len(header)+9)
+	copy(data, header)
+	binary.BigEndian.PutUint64(data[len(header):], count)
+	if last {
+		data[len(data)-1] = 1
+	}
+	return data
+}
@@ -0,0 +1,117 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package vault
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	v, _ := New([]byte("correct horse battery staple"))
+
+	for _, size := range []int{0, 100, chunkSize, 2*chunkSize + 7} {
+		data := bytes.Repeat([]byte("amass"), size/5+1)[:size]
+
+		var enc bytes.Buffer
+		if err := v.Encrypt(&enc, bytes.NewReader(data)); err != nil {
+			t.Fatalf("Failed to encrypt %d bytes: %v", size, err)
+		}
+		if size > 0 && bytes.Contains(enc.Bytes(), data[:5]) {
+			t.Errorf("The plaintext was found in the %d encrypted bytes", size)
+		}
+
+		var dec bytes.Buffer
+		if err := v.Decrypt(&dec, bytes.NewReader(enc.Bytes())); err != nil {
+			t.Fatalf("Failed to decrypt %d bytes: %v", size, err)
+		}
+		if !bytes.Equal(dec.Bytes(), data) {
+			t.Errorf("The %d decrypted bytes do not match the plaintext", size)
+		}
+
+		if size == 2*chunkSize+7 {
+			// Removing the last chunk must be detected
+			truncated := enc.Bytes()[:enc.Len()-(7+16+4)]
+			if err := v.Decrypt(&bytes.Buffer{}, bytes.NewReader(truncated)); err == nil {
+				t.Error("Expected an error for the truncated data")
+			}
+		}
+	}
+
+	var enc bytes.Buffer
+	_ = v.Encrypt(&enc, bytes.NewReader([]byte("secret")))
+	wrong, _ := New([]byte("wrong"))
+	if err := wrong.Decrypt(&bytes.Buffer{}, bytes.NewReader(enc.Bytes())); err == nil {
+		t.Error("Expected an error for the wrong passphrase")
+	}
+}
+
+func TestOpenSeal(t *testing.T) {
+	v, _ := New([]byte("passphrase"))
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+
+	if err := os.WriteFile(path, []byte("records"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+"-journal", []byte("journal"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Seal(path); err != nil {
+		t.Fatalf("Failed to seal the database: %v", err)
+	}
+	for _, p := range []string{path, path + "-journal"} {
+		if _, err := os.Stat(p); err == nil {
+			t.Errorf("The plaintext file %s was not removed", p)
+		}
+	}
+
+	if err := v.Open(path); err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "records" {
+		t.Errorf("Unexpected database contents: %s", data)
+	}
+
+	_ = os.Remove(path)
+	wrong, _ := New([]byte("wrong"))
+	if err := wrong.Open(path); err == nil {
+		t.Error("Expected an error for the wrong passphrase")
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("The partially decrypted database was not removed")
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	if v, err := ParseSettings(nil); v != nil || err != nil {
+		t.Errorf("Expected no vault without the option")
+	}
+	if v, err := ParseSettings(false); v != nil || err != nil {
+		t.Errorf("Expected no vault when the option is false")
+	}
+
+	t.Setenv(PassphraseEnv, "")
+	if _, err := ParseSettings(true); err == nil {
+		t.Error("Expected an error without a passphrase")
+	}
+	t.Setenv(PassphraseEnv, "from the environment")
+	if v, err := ParseSettings(true); err != nil || string(v.secret) != "from the environment" {
+		t.Errorf("Expected the passphrase from the environment: %v", err)
+	}
+
+	key := filepath.Join(t.TempDir(), "db.key")
+	_ = os.WriteFile(key, []byte("key material\n"), 0600)
+	if v, err := ParseSettings(map[string]interface{}{"key_file": key}); err != nil || string(v.secret) != "key material" {
+		t.Errorf("Expected the secret from the key file: %v", err)
+	}
+	if v, err := ParseSettings(map[string]interface{}{"passphrase": "configured"}); err != nil || string(v.secret) != "configured" {
+		t.Errorf("Expected the configured passphrase: %v", err)
+	}
+	if _, err := ParseSettings(map[string]interface{}{"passphrase": 5}); err == nil {
+		t.Error("Expected an error for a passphrase that is not a string")
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package verify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/session"
+)
+
+// StateFileName is the name of the file in the output directory that stores the results of the last checks.
+const StateFileName = "verify_state.json"
+
+// stateKind is the versioned format of the state file, which is documented by the session package.
+var stateKind = &session.Kind{Name: "verify", Version: 1}
+
+// TypeVerificationChange is the type of the findings reporting the changes found by the checks.
+const TypeVerificationChange = "verification_change"
+
+// Run is the last re-verification of a collection.
+type Run struct {
+	Checked time.Time `json:"checked"`
+	// Results are keyed by the identifiers of the members
+	Results map[string]*Result `json:"results"`
+}
+
+// State is the last re-verification of each collection, keyed by the collection names.
+type State map[string]*Run
+
+// ReadState returns the state stored in the file, or an empty State when the file does not exist.
+func ReadState(path string) (State, error) {
+	s := make(State)
+	if _, err := stateKind.Read(path, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write stores the state in the file.
+func (s State) Write(path string) error {
+	return stateKind.Write(path, s)
+}
+
+// Due returns true when the collection has not been checked within the interval.
+func (s State) Due(collection string, interval time.Duration, now time.Time) bool {
+	run, found := s[collection]
+	return !found || !now.Before(run.Checked.Add(interval))
+}
+
+// Update replaces the results of the collection, and returns the findings for the changes since the
+// previous results. The first results of an asset are the baseline and do not produce findings.
+func (s State) Update(collection string, results []*Result, now time.Time) []*findings.Finding {
+	prev := s[collection]
+
+	run := &Run{
+		Checked: now.UTC(),
+		Results: make(map[string]*Result, len(results)),
+	}
+
+	var changes []*findings.Finding
+	for _, r := range results {
+		run.Results[r.Member.ID()] = r
+
+		if prev != nil {
+			if p, found := prev.Results[r.Member.ID()]; found {
+				changes = append(changes, Changes(collection, p, r)...)
+			}
+		}
+	}
+
+	s[collection] = run
+	return changes
+}
+
+// Changes returns the findings for the differences between the previous and the current results of an asset.
+func Changes(collection string, prev, cur *Result) []*findings.Finding {
+	var changes []*findings.Finding
+	key := cur.Member.Key
+	add := func(change, severity, title, from, to string) {
+		changes = append(changes, &findings.Finding{
+			Time:     cur.Checked,
+			Asset:    key,
+			Type:     TypeVerificationChange,
+			Severity: severity,
+			Title:    title,
+			Details:  fmt.Sprintf("%s changed from %s to %s", change, describe(from), describe(to)),
+			Attributes: map[string]string{
+				"collection": collection,
+				"change":     change,
+				"previous":   from,
+				"current":    to,
+			},
+		})
+	}
+
+	// A failed lookup says nothing about the asset, so the other checks are not compared
+	if prev.Error != "" || cur.Error != "" {
+		return nil
+	}
+
+	from, to := strings.Join(prev.Addresses, ","), strings.Join(cur.Addresses, ",")
+	switch {
+	case from == to:
+	case to == "":
+		add("addresses", findings.SeverityMedium, key+" no longer resolves", from, to)
+	case from == "":
+		add("addresses", findings.SeverityLow, key+" resolves again", from, to)
+	default:
+		add("addresses", findings.SeverityLow, "The addresses of "+key+" changed", from, to)
+	}
+
+	pc, cc := prev.Certificate, cur.Certificate
+	switch {
+	case pc == nil && cc == nil:
+	case cc == nil:
+		add("certificate", findings.SeverityMedium, "The certificate of "+key+" is no longer served", pc.Fingerprint, "")
+	case pc == nil:
+		add("certificate", findings.SeverityInfo, "A certificate is now served for "+key, "", cc.Fingerprint)
+	case pc.Valid && !cc.Valid:
+		add("certificate", findings.SeverityMedium, "The certificate of "+key+" is no longer valid", pc.Fingerprint, cc.Fingerprint)
+	case pc.Fingerprint != cc.Fingerprint:
+		add("certificate", findings.SeverityInfo, "The certificate of "+key+" changed", pc.Fingerprint, cc.Fingerprint)
+	}
+
+	if prev.Status != cur.Status {
+		severity := findings.SeverityLow
+		if cur.Status == 0 || cur.Status >= 500 {
+			severity = findings.SeverityMedium
+		}
+		add("status", severity, "The HTTP status of "+key+" changed", status(prev.Status), status(cur.Status))
+	}
+	return changes
+}
+
+func status(code int) string {
+	if code == 0 {
+		return ""
+	}
+	return strconv.Itoa(code)
+}
+
+func describe(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
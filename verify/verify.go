@@ -0,0 +1,283 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package verify re-verifies the assets of the collections on a schedule using lightweight checks, which
+// resolve the names, obtain the certificates served and the status of the web servers, and reports the
+// changes since the previous checks without running the enumeration.
+package verify
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/collections"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// DefaultPort is the port checked for the certificates and the web servers by default.
+const DefaultPort = 443
+
+// DefaultTimeout is the time allowed for each check of an asset by default.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultWorkers is the number of assets checked concurrently by default.
+const DefaultWorkers = 16
+
+// Certificate is the certificate served for the asset.
+type Certificate struct {
+	Fingerprint string    `json:"fingerprint"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	NotAfter    time.Time `json:"not_after"`
+	// Valid is true when the certificate is valid for the name and has not expired. The chain is not verified
+	Valid bool `json:"valid"`
+}
+
+// Result is the outcome of the checks of an asset.
+type Result struct {
+	Member  collections.Member `json:"member"`
+	Checked time.Time          `json:"checked"`
+	// Addresses are the addresses of the name, or the address itself
+	Addresses   []string     `json:"addresses,omitempty"`
+	Certificate *Certificate `json:"certificate,omitempty"`
+	// Status is the HTTP status of the web server, or zero when no response was received
+	Status int `json:"status,omitempty"`
+	// Error is the reason the name could not be resolved, other than the name not existing
+	Error string `json:"error,omitempty"`
+}
+
+// Checker performs the checks of the assets.
+type Checker struct {
+	// Resolve returns the addresses of the name, and no addresses when the name does not exist
+	Resolve func(ctx context.Context, name string) ([]string, error)
+	Dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+	Port    int
+	Timeout time.Duration
+	Workers int
+}
+
+// NewChecker returns a Checker using the system resolver and the configured network settings.
+func NewChecker() *Checker {
+	return &Checker{
+		Resolve: lookupHost,
+		Dial:    amassnet.DialContext,
+		Port:    DefaultPort,
+		Timeout: DefaultTimeout,
+		Workers: DefaultWorkers,
+	}
+}
+
+func lookupHost(ctx context.Context, name string) ([]string, error) {
+	addrs, err := net.DefaultResolver.LookupHost(ctx, name)
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, nil
+	}
+	return addrs, err
+}
+
+// CheckAll checks the names and addresses of the members concurrently, and returns the results in the
+// order of the members. The other assets, such as the netblocks, cannot be checked and are skipped.
+func (c *Checker) CheckAll(ctx context.Context, members []collections.Member) []*Result {
+	var checked []collections.Member
+	for _, m := range members {
+		if m.Type == string(oam.FQDN) || m.Type == string(oam.IPAddress) {
+			checked = append(checked, m)
+		}
+	}
+
+	workers := c.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	results := make([]*Result, len(checked))
+	for i, m := range checked {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, m collections.Member) {
+			defer func() { <-sem; wg.Done() }()
+
+			results[i] = c.Check(ctx, m)
+		}(i, m)
+	}
+	wg.Wait()
+	return results
+}
+
+// Check resolves the name, or uses the address, and obtains the certificate and the HTTP status
+// served at the first address on the port of the Checker.
+func (c *Checker) Check(ctx context.Context, m collections.Member) *Result {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r := &Result{Member: m, Checked: time.Now().UTC()}
+	var name string
+	if m.Type == string(oam.FQDN) {
+		name = m.Key
+
+		addrs, err := c.Resolve(ctx, name)
+		if err != nil {
+			r.Error = err.Error()
+			return r
+		}
+		sort.Strings(addrs)
+		r.Addresses = addrs
+	} else {
+		r.Addresses = []string{m.Key}
+	}
+	if len(r.Addresses) == 0 {
+		return r
+	}
+
+	port := c.Port
+	if port <= 0 {
+		port = DefaultPort
+	}
+	target := net.JoinHostPort(r.Addresses[0], strconv.Itoa(port))
+
+	r.Certificate = c.certificate(ctx, name, target)
+	scheme := "http"
+	if r.Certificate != nil {
+		scheme = "https"
+	}
+	r.Status = c.status(ctx, scheme, name, target, port)
+	return r
+}
+
+func (c *Checker) certificate(ctx context.Context, name, target string) *Certificate {
+	conn, err := c.Dial(ctx, "tcp", target)
+	if err != nil {
+		return nil
+	}
+
+	tc := tls.Client(conn, &tls.Config{
+		ServerName:         name,
+		InsecureSkipVerify: true,
+	})
+	defer tc.Close()
+
+	if err := tc.HandshakeContext(ctx); err != nil {
+		return nil
+	}
+	certs := tc.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil
+	}
+	return newCertificate(certs[0], name, time.Now())
+}
+
+func newCertificate(cert *x509.Certificate, name string, now time.Time) *Certificate {
+	sum := sha256.Sum256(cert.Raw)
+
+	valid := now.After(cert.NotBefore) && now.Before(cert.NotAfter)
+	if name != "" && cert.VerifyHostname(name) != nil {
+		valid = false
+	}
+	return &Certificate{
+		Fingerprint: hex.EncodeToString(sum[:]),
+		Subject:     cert.Subject.CommonName,
+		Issuer:      cert.Issuer.CommonName,
+		NotAfter:    cert.NotAfter.UTC(),
+		Valid:       valid,
+	}
+}
+
+// status returns the HTTP status of the web server at the target, requested for the name when provided.
+// The redirects are not followed, so a change of the redirect is reported as it is.
+func (c *Checker) status(ctx context.Context, scheme, name, target string, port int) int {
+	host := name
+	if host == "" {
+		host, _, _ = net.SplitHostPort(target)
+	}
+	if (scheme == "https" && port != 443) || (scheme == "http" && port != 80) {
+		host = net.JoinHostPort(host, strconv.Itoa(port))
+	} else if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		host = "[" + host + "]"
+	}
+
+	client := amasshttp.NewClient("verify", &amasshttp.ClientOptions{
+		Timeout: c.Timeout,
+		// The connections are made to the checked address, rather than resolving the name again
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return c.Dial(ctx, network, target)
+		},
+		Insecure:     true,
+		NoRedirects:  true,
+		NoKeepAlives: true,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+host+"/", nil)
+	if err != nil {
+		return 0
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+// Job is the re-verification of a collection at an interval.
+type Job struct {
+	Collection string
+	Interval   time.Duration
+}
+
+// ParseJobs returns the jobs from the verify option, which maps the collection names to the number
+// of minutes between the checks of their assets.
+func ParseJobs(raw interface{}) ([]*Job, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the verify option must map the collection names to numbers of minutes")
+	}
+
+	var jobs []*Job
+	for name, v := range m {
+		var minutes float64
+		switch n := v.(type) {
+		case int:
+			minutes = float64(n)
+		case float64:
+			minutes = n
+		default:
+			return nil, fmt.Errorf("the %s collection must provide the number of minutes between the checks", name)
+		}
+		if minutes <= 0 {
+			return nil, fmt.Errorf("the interval of the %s collection must be greater than zero", name)
+		}
+
+		jobs = append(jobs, &Job{Collection: name, Interval: time.Duration(minutes * float64(time.Minute))})
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Collection < jobs[j].Collection })
+	return jobs, nil
+}
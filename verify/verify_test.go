@@ -0,0 +1,172 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package verify
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/collections"
+)
+
+func testChecker(t *testing.T, handler http.HandlerFunc) *Checker {
+	ts := httptest.NewTLSServer(handler)
+	t.Cleanup(ts.Close)
+
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	var d net.Dialer
+	return &Checker{
+		Resolve: func(ctx context.Context, name string) ([]string, error) {
+			if name == "missing.example.com" {
+				return nil, nil
+			}
+			return []string{"127.0.0.1"}, nil
+		},
+		Dial:    d.DialContext,
+		Port:    port,
+		Timeout: 5 * time.Second,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	c := testChecker(t, func(w http.ResponseWriter, req *http.Request) {
+		if host, _, _ := net.SplitHostPort(req.Host); host != "example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	members := []collections.Member{
+		{Type: "FQDN", Key: "example.com"},
+		{Type: "FQDN", Key: "missing.example.com"},
+		{Type: "Netblock", Key: "192.0.2.0/24"},
+		{Type: "IPAddress", Key: "127.0.0.1"},
+	}
+	results := c.CheckAll(context.Background(), members)
+	if len(results) != 3 {
+		t.Fatalf("Expected three results, got %d", len(results))
+	}
+
+	r := results[0]
+	if len(r.Addresses) != 1 || r.Addresses[0] != "127.0.0.1" {
+		t.Errorf("Unexpected addresses: %v", r.Addresses)
+	}
+	if r.Certificate == nil || !r.Certificate.Valid || r.Certificate.Fingerprint == "" {
+		t.Errorf("Unexpected certificate: %+v", r.Certificate)
+	}
+	if r.Status != http.StatusTeapot {
+		t.Errorf("Expected the status %d, got %d", http.StatusTeapot, r.Status)
+	}
+
+	if r := results[1]; len(r.Addresses) != 0 || r.Certificate != nil || r.Status != 0 {
+		t.Errorf("Unexpected result for the missing name: %+v", r)
+	}
+	if r := results[2]; r.Certificate == nil || r.Status != http.StatusNotFound {
+		t.Errorf("Unexpected result for the address: %+v", r)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+	s, err := ReadState(path)
+	if err != nil || len(s) != 0 {
+		t.Fatalf("Expected an empty state: %v", err)
+	}
+
+	now := time.Now()
+	if !s.Due("crown-jewels", time.Hour, now) {
+		t.Error("The collection that was never checked should be due")
+	}
+
+	www := collections.Member{Type: "FQDN", Key: "www.example.com"}
+	first := &Result{
+		Member:      www,
+		Checked:     now,
+		Addresses:   []string{"192.0.2.1"},
+		Certificate: &Certificate{Fingerprint: "aa", Valid: true},
+		Status:      200,
+	}
+	if changes := s.Update("crown-jewels", []*Result{first}, now); len(changes) != 0 {
+		t.Errorf("The baseline should not produce findings: %v", changes)
+	}
+	if s.Due("crown-jewels", time.Hour, now.Add(30*time.Minute)) {
+		t.Error("The collection should not be due within the interval")
+	}
+	if err := s.Write(path); err != nil {
+		t.Fatalf("Failed to write the state: %v", err)
+	}
+
+	s, err = ReadState(path)
+	if err != nil {
+		t.Fatalf("Failed to read the state: %v", err)
+	}
+	later := now.Add(2 * time.Hour)
+	if !s.Due("crown-jewels", time.Hour, later) {
+		t.Error("The collection should be due after the interval")
+	}
+
+	second := &Result{
+		Member:      www,
+		Checked:     later,
+		Addresses:   []string{"192.0.2.1"},
+		Certificate: &Certificate{Fingerprint: "bb", Valid: false},
+		Status:      503,
+	}
+	changes := s.Update("crown-jewels", []*Result{second}, later)
+	if len(changes) != 2 {
+		t.Fatalf("Expected two findings, got %d", len(changes))
+	}
+	for _, f := range changes {
+		if f.Type != TypeVerificationChange || f.Asset != "www.example.com" || f.Attributes["collection"] != "crown-jewels" {
+			t.Errorf("Unexpected finding: %+v", f)
+		}
+	}
+	if c := changes[0]; c.Attributes["change"] != "certificate" || c.Attributes["current"] != "bb" {
+		t.Errorf("Unexpected certificate finding: %+v", c)
+	}
+	if c := changes[1]; c.Attributes["change"] != "status" || c.Attributes["previous"] != "200" || c.Severity != "medium" {
+		t.Errorf("Unexpected status finding: %+v", c)
+	}
+
+	third := &Result{Member: www, Checked: later.Add(time.Hour)}
+	changes = s.Update("crown-jewels", []*Result{third}, later.Add(time.Hour))
+	if len(changes) != 3 || changes[0].Title != "www.example.com no longer resolves" {
+		t.Errorf("Unexpected findings after the name stopped resolving: %+v", changes)
+	}
+}
+
+func TestParseJobs(t *testing.T) {
+	jobs, err := ParseJobs(map[string]interface{}{
+		"payments":     30,
+		"crown-jewels": 1.5,
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse the jobs: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Collection != "crown-jewels" || jobs[0].Interval != 90*time.Second ||
+		jobs[1].Interval != 30*time.Minute {
+		t.Errorf("Unexpected jobs: %+v, %+v", jobs[0], jobs[1])
+	}
+
+	for _, raw := range []interface{}{
+		"30",
+		map[string]interface{}{"payments": "hourly"},
+		map[string]interface{}{"payments": 0},
+	} {
+		if _, err := ParseJobs(raw); err == nil {
+			t.Errorf("Expected an error for %v", raw)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package views maintains the summaries read by the reports, such as the latest resolution of each name and the
+// inventory of the live services, so the reports do not walk the relations of the graph database on each run.
+package views
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// FileName is the name of the file in the output directory that stores the views.
+const FileName = "views.json"
+
+// maxAliases is the number of CNAME records followed from each name.
+const maxAliases = 10
+
+// Database is the subset of the graph database used to build the views.
+type Database interface {
+	FindByType(atype oam.AssetType, since time.Time) ([]*types.Asset, error)
+	OutgoingRelations(asset *types.Asset, since time.Time, relationTypes ...string) ([]*types.Relation, error)
+}
+
+// Resolution is the latest resolution of a name, following its CNAME records to the addresses.
+type Resolution struct {
+	Name      string    `json:"name"`
+	Aliases   []string  `json:"aliases,omitempty"`
+	Addresses []string  `json:"addresses,omitempty"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Service is a port of an address confirmed to be serving the names.
+type Service struct {
+	Address  string    `json:"address"`
+	Port     int       `json:"port"`
+	Names    []string  `json:"names"`
+	Evidence []string  `json:"evidence"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Views are the summaries of the graph database and the findings, as of the time they were generated.
+type Views struct {
+	Generated   time.Time     `json:"generated"`
+	Resolutions []*Resolution `json:"resolutions"`
+	Services    []*Service    `json:"services"`
+}
+
+// Build returns the views of the names accepted by the inScope function, and of the services in the findings.
+func Build(ctx context.Context, db Database, fs []*findings.Finding, inScope func(name string) bool) (*Views, error) {
+	res, err := resolutions(ctx, db, inScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Views{
+		Generated:   time.Now(),
+		Resolutions: res,
+		Services:    services(fs, inScope),
+	}, nil
+}
+
+type link struct {
+	to   string
+	seen time.Time
+}
+
+// resolutions returns the addresses of each name, and the names of the CNAME records followed to them.
+// The last seen time of a resolution is the latest time that the records followed were observed.
+func resolutions(ctx context.Context, db Database, inScope func(name string) bool) ([]*Resolution, error) {
+	assets, err := db.FindByType(oam.FQDN, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the names: %v", err)
+	}
+
+	var fqdns []*types.Asset
+	names := make(map[string]string)
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok {
+			names[a.ID] = fqdn.Name
+			fqdns = append(fqdns, a)
+		}
+	}
+
+	assets, err = db.FindByType(oam.IPAddress, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the addresses: %v", err)
+	}
+
+	addrs := make(map[string]string)
+	for _, a := range assets {
+		if ip, ok := a.Asset.(network.IPAddress); ok {
+			addrs[a.ID] = ip.Address.String()
+		}
+	}
+
+	aliases := make(map[string]link)
+	records := make(map[string][]link)
+	for _, a := range fqdns {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rels, err := db.OutgoingRelations(a, time.Time{}, "a_record", "aaaa_record", "cname_record")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the records of %s: %v", names[a.ID], err)
+		}
+
+		for _, rel := range rels {
+			if rel.Type == "cname_record" {
+				if prev, found := aliases[a.ID]; !found || rel.LastSeen.After(prev.seen) {
+					aliases[a.ID] = link{to: rel.ToAsset.ID, seen: rel.LastSeen}
+				}
+			} else if _, found := addrs[rel.ToAsset.ID]; found {
+				records[a.ID] = append(records[a.ID], link{to: rel.ToAsset.ID, seen: rel.LastSeen})
+			}
+		}
+	}
+
+	var results []*Resolution
+	for _, a := range fqdns {
+		name := names[a.ID]
+		if !inScope(name) {
+			continue
+		}
+
+		r := &Resolution{Name: name}
+		id := a.ID
+		for i := 0; i < maxAliases; i++ {
+			alias, found := aliases[id]
+			if !found || names[alias.to] == "" {
+				break
+			}
+
+			id = alias.to
+			r.Aliases = append(r.Aliases, names[id])
+			if alias.seen.After(r.LastSeen) {
+				r.LastSeen = alias.seen
+			}
+		}
+
+		set := make(map[string]struct{})
+		for _, rec := range records[id] {
+			set[addrs[rec.to]] = struct{}{}
+			if rec.seen.After(r.LastSeen) {
+				r.LastSeen = rec.seen
+			}
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		for addr := range set {
+			r.Addresses = append(r.Addresses, addr)
+		}
+		sort.Strings(r.Addresses)
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+// services returns the ports of the addresses confirmed to serve in-scope names by the findings
+// providing the address and port, such as the SNI bindings and the HTTP virtual hosts.
+func services(fs []*findings.Finding, inScope func(name string) bool) []*Service {
+	byKey := make(map[string]*Service)
+	nameSets := make(map[string]map[string]struct{})
+	typeSets := make(map[string]map[string]struct{})
+
+	for _, f := range fs {
+		addr := f.Attributes["address"]
+		port, err := strconv.Atoi(f.Attributes["port"])
+		if addr == "" || err != nil || !inScope(strings.TrimPrefix(f.Asset, "*.")) {
+			continue
+		}
+
+		key := addr + " " + strconv.Itoa(port)
+		s, found := byKey[key]
+		if !found {
+			s = &Service{Address: addr, Port: port}
+			byKey[key] = s
+			nameSets[key] = make(map[string]struct{})
+			typeSets[key] = make(map[string]struct{})
+		}
+		nameSets[key][f.Asset] = struct{}{}
+		typeSets[key][f.Type] = struct{}{}
+		if f.Time.After(s.LastSeen) {
+			s.LastSeen = f.Time
+		}
+	}
+
+	results := make([]*Service, 0, len(byKey))
+	for key, s := range byKey {
+		s.Names = sortedKeys(nameSets[key])
+		s.Evidence = sortedKeys(typeSets[key])
+		results = append(results, s)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Address != results[j].Address {
+			return results[i].Address < results[j].Address
+		}
+		return results[i].Port < results[j].Port
+	})
+	return results
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Save writes the views to the file at the provided path, replacing the previous views
+// only once the file is complete, so the reports never read partial views.
+func Save(path string, v *Views) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), FileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(v); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads the views from the file at the provided path.
+func Load(path string) (*Views, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var v Views
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse the views: %v", err)
+	}
+	return &v, nil
+}
@@ -0,0 +1,80 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package views
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/assettest"
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestBuild(t *testing.T) {
+	g := assettest.NewGraph(t)
+	assettest.Populate(t, g,
+		assettest.CNAME("www.owasp.org", "web.owasp.org"),
+		assettest.A("web.owasp.org", "192.0.2.1"),
+		assettest.AAAA("web.owasp.org", "2001:db8::1"),
+		assettest.A("mail.owasp.org", "192.0.2.2"),
+		assettest.A("www.example.com", "198.51.100.1"),
+	)
+	assettest.Store(t, g, assettest.FQDN("dev.owasp.org"))
+
+	now := time.Now()
+	fs := []*findings.Finding{
+		{Time: now.Add(-time.Hour), Asset: "www.owasp.org", Type: "sni_binding",
+			Attributes: map[string]string{"address": "192.0.2.1", "port": "443"}},
+		{Time: now, Asset: "web.owasp.org", Type: "http_vhost",
+			Attributes: map[string]string{"address": "192.0.2.1", "port": "443"}},
+		{Time: now, Asset: "www.example.com", Type: "http_vhost",
+			Attributes: map[string]string{"address": "198.51.100.1", "port": "80"}},
+		{Time: now, Asset: "owasp.org", Type: "dnssec"},
+	}
+
+	inScope := func(name string) bool { return strings.HasSuffix(name, "owasp.org") }
+	v, err := Build(context.Background(), g.DB, fs, inScope)
+	if err != nil {
+		t.Fatalf("Failed to build the views: %v", err)
+	}
+
+	got := make(map[string][]string)
+	for _, r := range v.Resolutions {
+		got[r.Name] = append(r.Aliases, r.Addresses...)
+	}
+	expected := map[string][]string{
+		"www.owasp.org":  {"web.owasp.org", "192.0.2.1", "2001:db8::1"},
+		"web.owasp.org":  {"192.0.2.1", "2001:db8::1"},
+		"mail.owasp.org": {"192.0.2.2"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected the resolutions %v, got %v", expected, got)
+	}
+
+	if len(v.Services) != 1 {
+		t.Fatalf("Expected one service, got %d", len(v.Services))
+	}
+	s := v.Services[0]
+	if s.Address != "192.0.2.1" || s.Port != 443 || len(s.Names) != 2 || len(s.Evidence) != 2 || !s.LastSeen.Equal(now) {
+		t.Errorf("Unexpected service: %+v", s)
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := Save(path, v); err != nil {
+		t.Fatalf("Failed to save the views: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load the views: %v", err)
+	}
+	if len(loaded.Resolutions) != 3 || len(loaded.Services) != 1 {
+		t.Errorf("Expected the saved views to be loaded, got %d resolutions and %d services",
+			len(loaded.Resolutions), len(loaded.Services))
+	}
+}